@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"mystravastats/internal/platform/activityprovider"
+
+	"github.com/gorilla/mux"
+)
+
+// athleteIDHeader selects the athlete of a request on the unprefixed routes.
+// The /api/athletes/{athleteId}/... routes take precedence over it.
+const athleteIDHeader = "X-Athlete-Id"
+
+const athleteIDPathVariable = "athleteId"
+
+type athleteContextKey struct{}
+
+// athleteServed is replaced in tests to avoid reading ATHLETES_FILE.
+var athleteServed = activityprovider.HasAthlete
+
+// withAthleteScope resolves the athlete selected by the path prefix or the
+// X-Athlete-Id header and rejects unknown athletes before the handler runs.
+func withAthleteScope(inner http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		athleteID := strings.TrimSpace(mux.Vars(request)[athleteIDPathVariable])
+		if athleteID == "" {
+			athleteID = strings.TrimSpace(request.Header.Get(athleteIDHeader))
+		}
+		if athleteID == "" {
+			inner.ServeHTTP(writer, request)
+			return
+		}
+		if !athleteServed(athleteID) {
+			writeNotFound(writer, "Athlete not found", "athlete "+athleteID+" is not served by this instance")
+			return
+		}
+		inner.ServeHTTP(writer, request.WithContext(context.WithValue(request.Context(), athleteContextKey{}, athleteID)))
+	})
+}
+
+// requestAthleteID returns the athlete selected by withAthleteScope, or "" for
+// the default athlete.
+func requestAthleteID(request *http.Request) string {
+	if request == nil {
+		return ""
+	}
+	athleteID, _ := request.Context().Value(athleteContextKey{}).(string)
+	return athleteID
+}
+
+// athleteScopedPattern maps an /api route onto its /api/athletes/{athleteId}
+// variant. The "me" routes already describe the current athlete.
+func athleteScopedPattern(pattern string) string {
+	const athletePrefix = "/api/athletes/{" + athleteIDPathVariable + "}"
+	if pattern == "/api/athletes/me" {
+		return athletePrefix
+	}
+	if strings.HasPrefix(pattern, "/api/athletes/me/") {
+		return athletePrefix + strings.TrimPrefix(pattern, "/api/athletes/me")
+	}
+	return athletePrefix + strings.TrimPrefix(pattern, "/api")
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	athleteApp "mystravastats/internal/athlete/application"
+	"mystravastats/internal/platform/activityprovider"
+	"mystravastats/internal/shared/domain/strava"
+)
+
+func TestAthleteScope_SelectsAthleteByPathPrefixOrHeader(t *testing.T) {
+	// GIVEN
+	setTestContainer(t, athleteTestContainer(1))
+	setTestAthleteContainer(t, "bob", athleteTestContainer(2))
+	router := NewRouter()
+
+	cases := []struct {
+		name       string
+		path       string
+		header     string
+		wantStatus int
+		wantID     int64
+	}{
+		{name: "default athlete", path: "/api/athletes/me", wantStatus: http.StatusOK, wantID: 1},
+		{name: "path prefix", path: "/api/athletes/bob", wantStatus: http.StatusOK, wantID: 2},
+		{name: "header", path: "/api/athletes/me", header: "bob", wantStatus: http.StatusOK, wantID: 2},
+		{name: "path prefix wins over header", path: "/api/athletes/bob", header: "alice", wantStatus: http.StatusOK, wantID: 2},
+		{name: "unknown athlete in path", path: "/api/athletes/alice", wantStatus: http.StatusNotFound},
+		{name: "unknown athlete in header", path: "/api/athletes/me", header: "alice", wantStatus: http.StatusNotFound},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, testCase.path, nil)
+			if testCase.header != "" {
+				request.Header.Set(athleteIDHeader, testCase.header)
+			}
+			recorder := httptest.NewRecorder()
+
+			// WHEN
+			router.ServeHTTP(recorder, request)
+
+			// THEN
+			if recorder.Code != testCase.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", testCase.wantStatus, recorder.Code, recorder.Body.String())
+			}
+			if testCase.wantStatus != http.StatusOK {
+				return
+			}
+			var response struct {
+				ID int64 `json:"id"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to decode JSON response: %v", err)
+			}
+			if response.ID != testCase.wantID {
+				t.Fatalf("expected athlete %d, got %d", testCase.wantID, response.ID)
+			}
+		})
+	}
+}
+
+func TestGetAthletes_ListsServedAthletes(t *testing.T) {
+	// GIVEN
+	previousAthletes := servedAthletes
+	servedAthletes = func() []activityprovider.AthleteSummary {
+		return []activityprovider.AthleteSummary{
+			{ID: "alice", Name: "Alice", Default: true, Sources: []string{"strava", "fit"}},
+			{ID: "bob", Name: "Bob", Sources: []string{"gpx"}},
+		}
+	}
+	t.Cleanup(func() { servedAthletes = previousAthletes })
+	request := httptest.NewRequest(http.MethodGet, "/api/athletes", nil)
+	recorder := httptest.NewRecorder()
+
+	// WHEN
+	NewRouter().ServeHTTP(recorder, request)
+
+	// THEN
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", recorder.Code)
+	}
+	var response []activityprovider.AthleteSummary
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode JSON response: %v", err)
+	}
+	if len(response) != 2 || response[0].ID != "alice" || !response[0].Default || response[1].Sources[0] != "gpx" {
+		t.Fatalf("expected alice (default) and bob, got %+v", response)
+	}
+}
+
+func athleteTestContainer(athleteID int64) *container {
+	return &container{
+		getAthleteUseCase: athleteApp.NewGetAthleteUseCase(&contractAthleteReaderStub{
+			athlete: strava.Athlete{Id: athleteID},
+		}),
+	}
+}
+
+// setTestAthleteContainer serves athleteID with testContainer; any other
+// non-default athlete is reported as unknown.
+func setTestAthleteContainer(t *testing.T, athleteID string, testContainer *container) {
+	t.Helper()
+
+	previousServed := athleteServed
+	athleteServed = func(id string) bool { return id == athleteID }
+	athleteContainersMutex.Lock()
+	athleteContainers[athleteID] = testContainer
+	athleteContainersMutex.Unlock()
+
+	t.Cleanup(func() {
+		athleteServed = previousServed
+		athleteContainersMutex.Lock()
+		delete(athleteContainers, athleteID)
+		athleteContainersMutex.Unlock()
+	})
+}
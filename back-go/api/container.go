@@ -1,6 +1,7 @@
 package api
 
 import (
+	"net/http"
 	"sync"
 
 	activitiesApp "mystravastats/internal/activities/application"
@@ -21,15 +22,20 @@ import (
 	healthInfra "mystravastats/internal/health/infrastructure"
 	heartrateApp "mystravastats/internal/heartrate/application"
 	heartrateInfra "mystravastats/internal/heartrate/infrastructure"
+	"mystravastats/internal/platform/activityprovider"
 	routesApp "mystravastats/internal/routes/application"
 	routesInfra "mystravastats/internal/routes/infrastructure"
 	routingControlInfra "mystravastats/internal/routingcontrol/infrastructure"
 	segmentsApp "mystravastats/internal/segments/application"
 	segmentsInfra "mystravastats/internal/segments/infrastructure"
+	sourceMergeApp "mystravastats/internal/sourcemerge/application"
+	sourceMergeInfra "mystravastats/internal/sourcemerge/infrastructure"
 	sourceModeApp "mystravastats/internal/sourcemode/application"
 	sourceModeInfra "mystravastats/internal/sourcemode/infrastructure"
 	statisticsApp "mystravastats/internal/statistics/application"
 	statisticsInfra "mystravastats/internal/statistics/infrastructure"
+	stravaUploadApp "mystravastats/internal/stravaupload/application"
+	stravaUploadInfra "mystravastats/internal/stravaupload/infrastructure"
 )
 
 type container struct {
@@ -45,6 +51,10 @@ type container struct {
 	updatePerformanceSettingsUseCase         *athleteApp.UpdatePerformanceSettingsUseCase
 	listStatisticsUseCase                    *statisticsApp.ListStatisticsUseCase
 	listPersonalRecordsTimelineUseCase       *statisticsApp.ListPersonalRecordsTimelineUseCase
+	getCustomStatisticsUseCase               *statisticsApp.GetCustomStatisticsUseCase
+	updateCustomStatisticsUseCase            *statisticsApp.UpdateCustomStatisticsUseCase
+	getPowerCurveUseCase                     *statisticsApp.GetPowerCurveUseCase
+	getPaceCurveUseCase                      *statisticsApp.GetPaceCurveUseCase
 	getSegmentClimbProgressionUseCase        *segmentsApp.GetSegmentClimbProgressionUseCase
 	listSegmentsUseCase                      *segmentsApp.ListSegmentsUseCase
 	listSegmentEffortsUseCase                *segmentsApp.ListSegmentEffortsUseCase
@@ -57,6 +67,7 @@ type container struct {
 	getDistanceByPeriodUseCase               *chartsApp.GetDistanceByPeriodUseCase
 	getElevationByPeriodUseCase              *chartsApp.GetElevationByPeriodUseCase
 	getAverageSpeedByPeriodUseCase           *chartsApp.GetAverageSpeedByPeriodUseCase
+	getAverageGradeAdjustedSpeedUseCase      *chartsApp.GetAverageGradeAdjustedSpeedByPeriodUseCase
 	getAverageCadenceByPeriodUseCase         *chartsApp.GetAverageCadenceByPeriodUseCase
 	getDashboardDataUseCase                  *dashboardApp.GetDashboardDataUseCase
 	getCumulativeDataPerYearUseCase          *dashboardApp.GetCumulativeDataPerYearUseCase
@@ -80,80 +91,192 @@ type container struct {
 	revertDataQualityCorrectionUseCase       *dataQualityApp.RevertDataQualityCorrectionUseCase
 	previewSourceModeUseCase                 *sourceModeApp.PreviewSourceModeUseCase
 	applySourceModeUseCase                   *sourceModeApp.ApplySourceModeUseCase
+	uploadStravaActivitiesUseCase            *stravaUploadApp.UploadActivitiesUseCase
+	getSourceMergeOverridesUseCase           *sourceMergeApp.GetSourceMergeOverridesUseCase
+	linkSourceActivitiesUseCase              *sourceMergeApp.LinkSourceActivitiesUseCase
+	splitSourceActivityUseCase               *sourceMergeApp.SplitSourceActivityUseCase
+	updateDefaultFieldSourcesUseCase         *sourceMergeApp.UpdateDefaultFieldSourcesUseCase
+	updateActivityFieldSourcesUseCase        *sourceMergeApp.UpdateActivityFieldSourcesUseCase
+	resetActivitySourceMergeUseCase          *sourceMergeApp.ResetActivitySourceMergeUseCase
+	getSourceCoverageUseCase                 *sourceMergeApp.GetSourceCoverageUseCase
 }
 
+const maxFilteredContainers = 64
+
 var (
 	containerOnce   sync.Once
 	sharedContainer *container
+
+	athleteContainersMutex sync.Mutex
+	athleteContainers      = make(map[string]*container)
+	filteredContainers     = make(map[string]*container)
 )
 
+// getContainer returns the container of the default athlete.
 func getContainer() *container {
 	containerOnce.Do(func() {
-		detailedActivityReader := activitiesInfra.NewDetailedActivityServiceAdapter()
-		athleteReader := athleteInfra.NewAthleteServiceAdapter()
-		badgesReader := badgesInfra.NewBadgesServiceAdapter()
-		statisticsReader := statisticsInfra.NewStatisticsServiceAdapter()
-		segmentsReader := segmentsInfra.NewSegmentServiceAdapter()
-		routingEngine := routesInfra.NewOSMRoutingAdapter()
-		osrmControl := routingControlInfra.NewOSRMControlAdapter()
-		routesReader := routesInfra.NewRouteServiceAdapter(routingEngine)
-		heartRateReader := heartrateInfra.NewHeartRateServiceAdapter()
-		gearAnalysisReader := gearAnalysisInfra.NewGearAnalysisServiceAdapter()
-		healthReader := healthInfra.NewHealthServiceAdapter(routingEngine)
-		dataQualityReader := dataQualityInfra.NewDataQualityServiceAdapter()
-		chartsReader := chartsInfra.NewChartsServiceAdapter()
-		dashboardReader := dashboardInfra.NewDashboardServiceAdapter()
-		sourceModeReader := sourceModeInfra.NewSourceModeServiceAdapter()
-		sharedContainer = &container{
-			getDetailedActivityUseCase:               activitiesApp.NewGetDetailedActivityUseCase(detailedActivityReader),
-			getActivityComparisonUseCase:             activitiesApp.NewGetActivityComparisonUseCase(detailedActivityReader),
-			listActivitiesUseCase:                    activitiesApp.NewListActivitiesUseCase(detailedActivityReader),
-			exportActivitiesCSVUseCase:               activitiesApp.NewExportActivitiesCSVUseCase(detailedActivityReader),
-			getMapsGPXUseCase:                        activitiesApp.NewGetMapsGPXUseCase(detailedActivityReader),
-			getMapPassagesUseCase:                    activitiesApp.NewGetMapPassagesUseCase(detailedActivityReader),
-			getAthleteUseCase:                        athleteApp.NewGetAthleteUseCase(athleteReader),
-			getFtpEstimateUseCase:                    athleteApp.NewGetFtpEstimateUseCase(athleteReader),
-			getPerformanceSettingsUseCase:            athleteApp.NewGetPerformanceSettingsUseCase(athleteReader),
-			updatePerformanceSettingsUseCase:         athleteApp.NewUpdatePerformanceSettingsUseCase(athleteReader),
-			listStatisticsUseCase:                    statisticsApp.NewListStatisticsUseCase(statisticsReader),
-			listPersonalRecordsTimelineUseCase:       statisticsApp.NewListPersonalRecordsTimelineUseCase(statisticsReader),
-			getSegmentClimbProgressionUseCase:        segmentsApp.NewGetSegmentClimbProgressionUseCase(segmentsReader),
-			listSegmentsUseCase:                      segmentsApp.NewListSegmentsUseCase(segmentsReader),
-			listSegmentEffortsUseCase:                segmentsApp.NewListSegmentEffortsUseCase(segmentsReader),
-			getSegmentSummaryUseCase:                 segmentsApp.NewGetSegmentSummaryUseCase(segmentsReader),
-			getRouteExplorerUseCase:                  routesApp.NewGetRouteExplorerUseCase(routesReader),
-			routingEngine:                            routingEngine,
-			getHeartRateZoneSettingsUseCase:          heartrateApp.NewGetHeartRateZoneSettingsUseCase(heartRateReader),
-			updateHeartRateZoneSettingsUseCase:       heartrateApp.NewUpdateHeartRateZoneSettingsUseCase(heartRateReader),
-			getHeartRateZoneAnalysisUseCase:          heartrateApp.NewGetHeartRateZoneAnalysisUseCase(heartRateReader),
-			getDistanceByPeriodUseCase:               chartsApp.NewGetDistanceByPeriodUseCase(chartsReader),
-			getElevationByPeriodUseCase:              chartsApp.NewGetElevationByPeriodUseCase(chartsReader),
-			getAverageSpeedByPeriodUseCase:           chartsApp.NewGetAverageSpeedByPeriodUseCase(chartsReader),
-			getAverageCadenceByPeriodUseCase:         chartsApp.NewGetAverageCadenceByPeriodUseCase(chartsReader),
-			getDashboardDataUseCase:                  dashboardApp.NewGetDashboardDataUseCase(dashboardReader),
-			getCumulativeDataPerYearUseCase:          dashboardApp.NewGetCumulativeDataPerYearUseCase(dashboardReader),
-			getActivityHeatmapUseCase:                dashboardApp.NewGetActivityHeatmapUseCase(dashboardReader),
-			getEddingtonNumberUseCase:                dashboardApp.NewGetEddingtonNumberUseCase(dashboardReader),
-			getAnnualGoalsUseCase:                    dashboardApp.NewGetAnnualGoalsUseCase(dashboardReader),
-			updateAnnualGoalsUseCase:                 dashboardApp.NewUpdateAnnualGoalsUseCase(dashboardReader),
-			getGearAnalysisUseCase:                   gearAnalysisApp.NewGetGearAnalysisUseCase(gearAnalysisReader),
-			saveGearMaintenanceRecordUseCase:         gearAnalysisApp.NewSaveGearMaintenanceRecordUseCase(gearAnalysisReader),
-			deleteGearMaintenanceRecordUseCase:       gearAnalysisApp.NewDeleteGearMaintenanceRecordUseCase(gearAnalysisReader),
-			getBadgesUseCase:                         badgesApp.NewGetBadgesUseCase(badgesReader),
-			getCacheHealthDetailsUseCase:             healthApp.NewGetCacheHealthDetailsUseCase(healthReader),
-			osrmControl:                              osrmControl,
-			getDataQualityReportUseCase:              dataQualityApp.NewGetDataQualityReportUseCase(dataQualityReader),
-			excludeActivityFromStatsUseCase:          dataQualityApp.NewExcludeActivityFromStatsUseCase(dataQualityReader),
-			includeActivityInStatsUseCase:            dataQualityApp.NewIncludeActivityInStatsUseCase(dataQualityReader),
-			previewDataQualityCorrectionUseCase:      dataQualityApp.NewPreviewDataQualityCorrectionUseCase(dataQualityReader),
-			previewSafeDataQualityCorrectionsUseCase: dataQualityApp.NewPreviewSafeDataQualityCorrectionsUseCase(dataQualityReader),
-			applyDataQualityCorrectionUseCase:        dataQualityApp.NewApplyDataQualityCorrectionUseCase(dataQualityReader),
-			applySafeDataQualityCorrectionsUseCase:   dataQualityApp.NewApplySafeDataQualityCorrectionsUseCase(dataQualityReader),
-			revertDataQualityCorrectionUseCase:       dataQualityApp.NewRevertDataQualityCorrectionUseCase(dataQualityReader),
-			previewSourceModeUseCase:                 sourceModeApp.NewPreviewSourceModeUseCase(sourceModeReader),
-			applySourceModeUseCase:                   sourceModeApp.NewApplySourceModeUseCase(sourceModeReader),
-		}
+		sharedContainer = newContainer(
+			activityprovider.Get,
+			routesInfra.NewOSMRoutingAdapter(),
+			routingControlInfra.NewOSRMControlAdapter(),
+		)
 	})
 
 	return sharedContainer
 }
+
+// containerFor returns the container of the athlete selected by the request.
+// Athlete containers are built on first use and share the routing engine of the
+// default container, which holds no athlete data. A request carrying a valid
+// source filter or date range gets a container listing only the matching
+// activities.
+func containerFor(request *http.Request) *container {
+	athleteID := requestAthleteID(request)
+	if athleteID == "" {
+		athleteID = activityprovider.DefaultAthlete()
+	}
+	filter, err := getSourceFilterParam(request)
+	if err != nil {
+		filter = activityprovider.SourceFilter{}
+	}
+	dateRange, err := getDateRangeParam(request)
+	if err != nil {
+		dateRange = activityprovider.DateRange{}
+	}
+	if !filter.IsZero() || !dateRange.IsZero() {
+		return filteredContainerFor(athleteID, filter, dateRange)
+	}
+	if athleteID == activityprovider.DefaultAthlete() {
+		return getContainer()
+	}
+
+	athleteContainersMutex.Lock()
+	defer athleteContainersMutex.Unlock()
+	if athleteContainer, ok := athleteContainers[athleteID]; ok {
+		return athleteContainer
+	}
+	shared := getContainer()
+	athleteContainer := newContainer(
+		athleteLookup(athleteID),
+		shared.routingEngine,
+		shared.osrmControl,
+	)
+	athleteContainers[athleteID] = athleteContainer
+	return athleteContainer
+}
+
+// filteredContainerFor returns the container of athleteID restricted by
+// filter and dateRange. Containers are kept per canonical filter and range,
+// so that adapter caches survive between requests. Rolling ranges move every
+// day, so the kept containers are dropped once there are too many of them.
+func filteredContainerFor(athleteID string, filter activityprovider.SourceFilter, dateRange activityprovider.DateRange) *container {
+	key := athleteID + "?source=" + filter.String() + "&dates=" + dateRange.String()
+
+	athleteContainersMutex.Lock()
+	defer athleteContainersMutex.Unlock()
+	if filteredContainer, ok := filteredContainers[key]; ok {
+		return filteredContainer
+	}
+	if len(filteredContainers) >= maxFilteredContainers {
+		filteredContainers = make(map[string]*container)
+	}
+	shared := getContainer()
+	filteredContainer := newContainer(
+		activityprovider.WithinDateRange(activityprovider.Filtered(athleteLookup(athleteID), filter), dateRange),
+		shared.routingEngine,
+		shared.osrmControl,
+	)
+	filteredContainers[key] = filteredContainer
+	return filteredContainer
+}
+
+func athleteLookup(athleteID string) activityprovider.Lookup {
+	if athleteID == activityprovider.DefaultAthlete() {
+		return activityprovider.Get
+	}
+	return activityprovider.LookupFor(athleteID)
+}
+
+func newContainer(
+	providers activityprovider.Lookup,
+	routingEngine routesApp.RoutingEnginePort,
+	osrmControl *routingControlInfra.OSRMControlAdapter,
+) *container {
+	detailedActivityReader := activitiesInfra.NewDetailedActivityServiceAdapter(providers)
+	athleteReader := athleteInfra.NewAthleteServiceAdapter(providers)
+	badgesReader := badgesInfra.NewBadgesServiceAdapter(providers)
+	statisticsReader := statisticsInfra.NewStatisticsServiceAdapter(providers)
+	segmentsReader := segmentsInfra.NewSegmentServiceAdapter(providers)
+	routesReader := routesInfra.NewRouteServiceAdapter(providers, routingEngine)
+	heartRateReader := heartrateInfra.NewHeartRateServiceAdapter(providers)
+	gearAnalysisReader := gearAnalysisInfra.NewGearAnalysisServiceAdapter(providers)
+	healthReader := healthInfra.NewHealthServiceAdapter(providers, routingEngine)
+	dataQualityReader := dataQualityInfra.NewDataQualityServiceAdapter(providers)
+	chartsReader := chartsInfra.NewChartsServiceAdapter(providers)
+	dashboardReader := dashboardInfra.NewDashboardServiceAdapter(providers)
+	sourceModeReader := sourceModeInfra.NewSourceModeServiceAdapter()
+	stravaUploadWriter := stravaUploadInfra.NewStravaUploadServiceAdapter(providers)
+	sourceMergeAdapter := sourceMergeInfra.NewSourceMergeServiceAdapter(providers)
+	return &container{
+		getDetailedActivityUseCase:               activitiesApp.NewGetDetailedActivityUseCase(detailedActivityReader),
+		getActivityComparisonUseCase:             activitiesApp.NewGetActivityComparisonUseCase(detailedActivityReader),
+		listActivitiesUseCase:                    activitiesApp.NewListActivitiesUseCase(detailedActivityReader),
+		exportActivitiesCSVUseCase:               activitiesApp.NewExportActivitiesCSVUseCase(detailedActivityReader),
+		getMapsGPXUseCase:                        activitiesApp.NewGetMapsGPXUseCase(detailedActivityReader),
+		getMapPassagesUseCase:                    activitiesApp.NewGetMapPassagesUseCase(detailedActivityReader),
+		getAthleteUseCase:                        athleteApp.NewGetAthleteUseCase(athleteReader),
+		getFtpEstimateUseCase:                    athleteApp.NewGetFtpEstimateUseCase(athleteReader),
+		getPerformanceSettingsUseCase:            athleteApp.NewGetPerformanceSettingsUseCase(athleteReader),
+		updatePerformanceSettingsUseCase:         athleteApp.NewUpdatePerformanceSettingsUseCase(athleteReader),
+		listStatisticsUseCase:                    statisticsApp.NewListStatisticsUseCase(statisticsReader),
+		listPersonalRecordsTimelineUseCase:       statisticsApp.NewListPersonalRecordsTimelineUseCase(statisticsReader),
+		getCustomStatisticsUseCase:               statisticsApp.NewGetCustomStatisticsUseCase(statisticsReader),
+		updateCustomStatisticsUseCase:            statisticsApp.NewUpdateCustomStatisticsUseCase(statisticsReader),
+		getPowerCurveUseCase:                     statisticsApp.NewGetPowerCurveUseCase(statisticsReader),
+		getPaceCurveUseCase:                      statisticsApp.NewGetPaceCurveUseCase(statisticsReader),
+		getSegmentClimbProgressionUseCase:        segmentsApp.NewGetSegmentClimbProgressionUseCase(segmentsReader),
+		listSegmentsUseCase:                      segmentsApp.NewListSegmentsUseCase(segmentsReader),
+		listSegmentEffortsUseCase:                segmentsApp.NewListSegmentEffortsUseCase(segmentsReader),
+		getSegmentSummaryUseCase:                 segmentsApp.NewGetSegmentSummaryUseCase(segmentsReader),
+		getRouteExplorerUseCase:                  routesApp.NewGetRouteExplorerUseCase(routesReader),
+		routingEngine:                            routingEngine,
+		getHeartRateZoneSettingsUseCase:          heartrateApp.NewGetHeartRateZoneSettingsUseCase(heartRateReader),
+		updateHeartRateZoneSettingsUseCase:       heartrateApp.NewUpdateHeartRateZoneSettingsUseCase(heartRateReader),
+		getHeartRateZoneAnalysisUseCase:          heartrateApp.NewGetHeartRateZoneAnalysisUseCase(heartRateReader),
+		getDistanceByPeriodUseCase:               chartsApp.NewGetDistanceByPeriodUseCase(chartsReader),
+		getElevationByPeriodUseCase:              chartsApp.NewGetElevationByPeriodUseCase(chartsReader),
+		getAverageSpeedByPeriodUseCase:           chartsApp.NewGetAverageSpeedByPeriodUseCase(chartsReader),
+		getAverageGradeAdjustedSpeedUseCase:      chartsApp.NewGetAverageGradeAdjustedSpeedByPeriodUseCase(chartsReader),
+		getAverageCadenceByPeriodUseCase:         chartsApp.NewGetAverageCadenceByPeriodUseCase(chartsReader),
+		getDashboardDataUseCase:                  dashboardApp.NewGetDashboardDataUseCase(dashboardReader),
+		getCumulativeDataPerYearUseCase:          dashboardApp.NewGetCumulativeDataPerYearUseCase(dashboardReader),
+		getActivityHeatmapUseCase:                dashboardApp.NewGetActivityHeatmapUseCase(dashboardReader),
+		getEddingtonNumberUseCase:                dashboardApp.NewGetEddingtonNumberUseCase(dashboardReader),
+		getAnnualGoalsUseCase:                    dashboardApp.NewGetAnnualGoalsUseCase(dashboardReader),
+		updateAnnualGoalsUseCase:                 dashboardApp.NewUpdateAnnualGoalsUseCase(dashboardReader),
+		getGearAnalysisUseCase:                   gearAnalysisApp.NewGetGearAnalysisUseCase(gearAnalysisReader),
+		saveGearMaintenanceRecordUseCase:         gearAnalysisApp.NewSaveGearMaintenanceRecordUseCase(gearAnalysisReader),
+		deleteGearMaintenanceRecordUseCase:       gearAnalysisApp.NewDeleteGearMaintenanceRecordUseCase(gearAnalysisReader),
+		getBadgesUseCase:                         badgesApp.NewGetBadgesUseCase(badgesReader),
+		getCacheHealthDetailsUseCase:             healthApp.NewGetCacheHealthDetailsUseCase(healthReader),
+		osrmControl:                              osrmControl,
+		getDataQualityReportUseCase:              dataQualityApp.NewGetDataQualityReportUseCase(dataQualityReader),
+		excludeActivityFromStatsUseCase:          dataQualityApp.NewExcludeActivityFromStatsUseCase(dataQualityReader),
+		includeActivityInStatsUseCase:            dataQualityApp.NewIncludeActivityInStatsUseCase(dataQualityReader),
+		previewDataQualityCorrectionUseCase:      dataQualityApp.NewPreviewDataQualityCorrectionUseCase(dataQualityReader),
+		previewSafeDataQualityCorrectionsUseCase: dataQualityApp.NewPreviewSafeDataQualityCorrectionsUseCase(dataQualityReader),
+		applyDataQualityCorrectionUseCase:        dataQualityApp.NewApplyDataQualityCorrectionUseCase(dataQualityReader),
+		applySafeDataQualityCorrectionsUseCase:   dataQualityApp.NewApplySafeDataQualityCorrectionsUseCase(dataQualityReader),
+		revertDataQualityCorrectionUseCase:       dataQualityApp.NewRevertDataQualityCorrectionUseCase(dataQualityReader),
+		previewSourceModeUseCase:                 sourceModeApp.NewPreviewSourceModeUseCase(sourceModeReader),
+		applySourceModeUseCase:                   sourceModeApp.NewApplySourceModeUseCase(sourceModeReader),
+		uploadStravaActivitiesUseCase:            stravaUploadApp.NewUploadActivitiesUseCase(stravaUploadWriter),
+		getSourceMergeOverridesUseCase:           sourceMergeApp.NewGetSourceMergeOverridesUseCase(sourceMergeAdapter),
+		linkSourceActivitiesUseCase:              sourceMergeApp.NewLinkSourceActivitiesUseCase(sourceMergeAdapter),
+		splitSourceActivityUseCase:               sourceMergeApp.NewSplitSourceActivityUseCase(sourceMergeAdapter),
+		updateDefaultFieldSourcesUseCase:         sourceMergeApp.NewUpdateDefaultFieldSourcesUseCase(sourceMergeAdapter),
+		updateActivityFieldSourcesUseCase:        sourceMergeApp.NewUpdateActivityFieldSourcesUseCase(sourceMergeAdapter),
+		resetActivitySourceMergeUseCase:          sourceMergeApp.NewResetActivitySourceMergeUseCase(sourceMergeAdapter),
+		getSourceCoverageUseCase:                 sourceMergeApp.NewGetSourceCoverageUseCase(sourceMergeAdapter),
+	}
+}
@@ -13,6 +13,7 @@ type ActivityDto struct {
 	MovingTime                       int     `json:"movingTime"`
 	TotalElevationGain               int     `json:"totalElevationGain"`
 	AverageSpeed                     float64 `json:"averageSpeed"`
+	AverageGradeAdjustedSpeed        float64 `json:"averageGradeAdjustedSpeed,omitempty"`
 	AverageHeartrate                 int     `json:"averageHeartrate"`
 	BestSpeedForDistanceFor1000m     float64 `json:"bestSpeedForDistanceFor1000m"`
 	BestElevationForDistanceFor500m  float64 `json:"bestElevationForDistanceFor500m"`
@@ -58,6 +59,49 @@ type DetailedActivityDto struct {
 	Type                 string                   `json:"type"`
 	SportType            string                   `json:"sportType"`
 	WeightedAverageWatts int                      `json:"weightedAverageWatts"`
+	Laps                 []LapDto                 `json:"laps"`
+	Sessions             []ActivitySessionDto     `json:"sessions,omitempty"`
+
+	AverageGradeAdjustedSpeed float64 `json:"averageGradeAdjustedSpeed,omitempty"`
+}
+
+type LapDto struct {
+	LapIndex           int     `json:"lapIndex"`
+	Name               string  `json:"name"`
+	StartIndex         int     `json:"startIndex"`
+	EndIndex           int     `json:"endIndex"`
+	StartDate          string  `json:"startDate,omitempty"`
+	Distance           float64 `json:"distance"`
+	ElapsedTime        int     `json:"elapsedTime"`
+	MovingTime         int     `json:"movingTime"`
+	TotalElevationGain float64 `json:"totalElevationGain"`
+	AverageSpeed       float64 `json:"averageSpeed"`
+	MaxSpeed           float64 `json:"maxSpeed"`
+	AverageHeartrate   int     `json:"averageHeartrate"`
+	MaxHeartrate       int     `json:"maxHeartrate"`
+	AverageWatts       int     `json:"averageWatts"`
+	AverageCadence     int     `json:"averageCadence"`
+	Trigger            string  `json:"trigger,omitempty"`
+	Intensity          string  `json:"intensity,omitempty"`
+}
+
+type ActivitySessionDto struct {
+	SessionIndex       int     `json:"sessionIndex"`
+	SportType          string  `json:"sportType"`
+	StartDate          string  `json:"startDate,omitempty"`
+	StartIndex         int     `json:"startIndex"`
+	EndIndex           int     `json:"endIndex"`
+	Distance           float64 `json:"distance"`
+	ElapsedTime        int     `json:"elapsedTime"`
+	MovingTime         int     `json:"movingTime"`
+	TotalElevationGain float64 `json:"totalElevationGain"`
+	AverageSpeed       float64 `json:"averageSpeed"`
+	MaxSpeed           float64 `json:"maxSpeed"`
+	AverageHeartrate   int     `json:"averageHeartrate"`
+	MaxHeartrate       int     `json:"maxHeartrate"`
+	AverageWatts       int     `json:"averageWatts"`
+	FirstLapIndex      int     `json:"firstLapIndex,omitempty"`
+	LapCount           int     `json:"lapCount,omitempty"`
 }
 
 type ActivitySourceDto struct {
@@ -97,9 +141,10 @@ type ActivityComparisonDto struct {
 }
 
 type ActivityComparisonCriteriaDto struct {
-	ActivityType string `json:"activityType"`
-	Year         int    `json:"year"`
-	SampleSize   int    `json:"sampleSize"`
+	ActivityType  string `json:"activityType"`
+	Year          int    `json:"year"`
+	SampleSize    int    `json:"sampleSize"`
+	GradeAdjusted bool   `json:"gradeAdjusted"`
 }
 
 type ActivityComparisonBaselineDto struct {
@@ -110,6 +155,8 @@ type ActivityComparisonBaselineDto struct {
 	AverageHeartrate float64 `json:"averageHeartrate"`
 	AverageWatts     float64 `json:"averageWatts"`
 	AverageCadence   float64 `json:"averageCadence"`
+
+	AverageGradeAdjustedSpeed float64 `json:"averageGradeAdjustedSpeed,omitempty"`
 }
 
 type ActivityComparisonDeltasDto struct {
@@ -121,6 +168,9 @@ type ActivityComparisonDeltasDto struct {
 	AverageHeartrate float64 `json:"averageHeartrate"`
 	AverageWatts     float64 `json:"averageWatts"`
 	AverageCadence   float64 `json:"averageCadence"`
+
+	AverageGradeAdjustedSpeed    float64 `json:"averageGradeAdjustedSpeed,omitempty"`
+	AverageGradeAdjustedSpeedPct float64 `json:"averageGradeAdjustedSpeedPct,omitempty"`
 }
 
 type ActivityComparisonActivityDto struct {
@@ -135,6 +185,8 @@ type ActivityComparisonActivityDto struct {
 	AverageWatts     float64 `json:"averageWatts"`
 	AverageCadence   float64 `json:"averageCadence"`
 	SimilarityScore  float64 `json:"similarityScore"`
+
+	AverageGradeAdjustedSpeed float64 `json:"averageGradeAdjustedSpeed,omitempty"`
 }
 
 type ActivityComparisonSegmentDto struct {
@@ -213,4 +265,22 @@ type StreamDto struct {
 	Altitude       []float64   `json:"altitude,omitempty"`
 	Watts          []float64   `json:"watts,omitempty"`
 	VelocitySmooth []float64   `json:"velocitySmooth,omitempty"`
+	// GradeAdjustedDistance is the cumulative flat-equivalent distance of
+	// activities on foot, from which the grade-adjusted pace is derived.
+	GradeAdjustedDistance []float64 `json:"gradeAdjustedDistance,omitempty"`
+
+	Temperature         []float64                 `json:"temperature,omitempty"`
+	CoreTemperature     []float64                 `json:"coreTemperature,omitempty"`
+	LeftRightBalance    []float64                 `json:"leftRightBalance,omitempty"`
+	VerticalOscillation []float64                 `json:"verticalOscillation,omitempty"`
+	GroundContactTime   []float64                 `json:"groundContactTime,omitempty"`
+	StrideLength        []float64                 `json:"strideLength,omitempty"`
+	DeveloperFields     []DeveloperFieldStreamDto `json:"developerFields,omitempty"`
+}
+
+type DeveloperFieldStreamDto struct {
+	Name        string    `json:"name"`
+	Units       string    `json:"units,omitempty"`
+	NativeField string    `json:"nativeField,omitempty"`
+	Data        []float64 `json:"data"`
 }
@@ -84,6 +84,11 @@ func ToActivityDto(activity strava.Activity) ActivityDto {
 		link = fmt.Sprintf("https://www.strava.com/activities/%d", activity.Id)
 	}
 
+	averageGradeAdjustedSpeed := 0.0
+	if strava.IsOnFoot(activity.Type) {
+		averageGradeAdjustedSpeed = strava.GradeAdjustedSpeed(activity.AverageSpeed, activity.Stream)
+	}
+
 	bestTimeForDistanceFor1000m := 0.0
 	if bestTimeForDistance := statistics.BestTimeEffort(activity, 1000.0); bestTimeForDistance != nil {
 		bestTimeForDistanceFor1000m = bestTimeForDistance.GetMSSpeed()
@@ -110,6 +115,7 @@ func ToActivityDto(activity strava.Activity) ActivityDto {
 		MovingTime:                       activity.MovingTime,
 		TotalElevationGain:               finiteInt(activity.TotalElevationGain),
 		AverageSpeed:                     finiteFloat64(activity.AverageSpeed), // in m/s
+		AverageGradeAdjustedSpeed:        finiteFloat64(averageGradeAdjustedSpeed),
 		AverageHeartrate:                 finiteInt(activity.AverageHeartrate),
 		BestSpeedForDistanceFor1000m:     finiteFloat64(bestTimeForDistanceFor1000m), // in m/s
 		BestElevationForDistanceFor500m:  finiteFloat64(bestElevationForDistanceFor500m),
@@ -192,6 +198,15 @@ func ToDetailedActivityDto(detailedActivity *strava.DetailedActivity) DetailedAc
 
 	activityEfforts := BuildActivityEfforts(detailedActivity)
 
+	stream := toStreamDto(detailedActivity.Stream)
+	averageGradeAdjustedSpeed := 0.0
+	if strava.IsOnFoot(firstNonEmpty(detailedActivity.SportType, detailedActivity.Type)) {
+		averageGradeAdjustedSpeed = strava.GradeAdjustedSpeed(detailedActivity.AverageSpeed, detailedActivity.Stream)
+		if stream != nil {
+			stream.GradeAdjustedDistance = finiteFloat64Slice(detailedActivity.Stream.GradeAdjustedDistances())
+		}
+	}
+
 	return DetailedActivityDto{
 		AverageCadence:       finiteInt(detailedActivity.AverageCadence),
 		AverageHeartrate:     finiteInt(detailedActivity.AverageHeartrate),
@@ -216,14 +231,72 @@ func ToDetailedActivityDto(detailedActivity *strava.DetailedActivity) DetailedAc
 		StartDateLocal:       detailedActivity.StartDateLocal,
 		StartLatlng:          finiteFloat64Slice(detailedActivity.StartLatLng),
 		Source:               toActivitySourceDto(detailedActivity.Source),
-		Stream:               toStreamDto(detailedActivity.Stream),
+		Stream:               stream,
 		SufferScore:          finiteFloat64Ptr(detailedActivity.SufferScore),
 		TotalDescent:         finiteFloat64(calculateTotalDescent(detailedActivity.Stream)),
 		TotalElevationGain:   finiteInt(detailedActivity.TotalElevationGain),
 		Type:                 detailedActivity.Type,
 		SportType:            firstNonEmpty(detailedActivity.SportType, detailedActivity.Type),
 		WeightedAverageWatts: detailedActivity.WeightedAverageWatts,
+		Laps:                 toLapsDto(detailedActivity.Laps),
+		Sessions:             toActivitySessionsDto(detailedActivity.Sessions),
+
+		AverageGradeAdjustedSpeed: finiteFloat64(averageGradeAdjustedSpeed),
+	}
+}
+
+func toLapsDto(laps []strava.Lap) []LapDto {
+	result := make([]LapDto, 0, len(laps))
+	for _, lap := range laps {
+		result = append(result, LapDto{
+			LapIndex:           lap.LapIndex,
+			Name:               lap.Name,
+			StartIndex:         lap.StartIndex,
+			EndIndex:           lap.EndIndex,
+			StartDate:          lap.StartDate,
+			Distance:           finiteFloat64(lap.Distance),
+			ElapsedTime:        lap.ElapsedTime,
+			MovingTime:         lap.MovingTime,
+			TotalElevationGain: finiteFloat64(lap.TotalElevationGain),
+			AverageSpeed:       finiteFloat64(lap.AverageSpeed),
+			MaxSpeed:           finiteFloat64(lap.MaxSpeed),
+			AverageHeartrate:   finiteInt(lap.AverageHeartrate),
+			MaxHeartrate:       finiteInt(lap.MaxHeartrate),
+			AverageWatts:       finiteInt(lap.AverageWatts),
+			AverageCadence:     finiteInt(lap.AverageCadence),
+			Trigger:            lap.Trigger,
+			Intensity:          lap.Intensity,
+		})
 	}
+	return result
+}
+
+func toActivitySessionsDto(sessions []strava.ActivitySession) []ActivitySessionDto {
+	if len(sessions) == 0 {
+		return nil
+	}
+	result := make([]ActivitySessionDto, 0, len(sessions))
+	for _, session := range sessions {
+		result = append(result, ActivitySessionDto{
+			SessionIndex:       session.SessionIndex,
+			SportType:          session.SportType,
+			StartDate:          session.StartDate,
+			StartIndex:         session.StartIndex,
+			EndIndex:           session.EndIndex,
+			Distance:           finiteFloat64(session.Distance),
+			ElapsedTime:        session.ElapsedTime,
+			MovingTime:         session.MovingTime,
+			TotalElevationGain: finiteFloat64(session.TotalElevationGain),
+			AverageSpeed:       finiteFloat64(session.AverageSpeed),
+			MaxSpeed:           finiteFloat64(session.MaxSpeed),
+			AverageHeartrate:   finiteInt(session.AverageHeartrate),
+			MaxHeartrate:       finiteInt(session.MaxHeartrate),
+			AverageWatts:       finiteInt(session.AverageWatts),
+			FirstLapIndex:      session.FirstLapIndex,
+			LapCount:           session.LapCount,
+		})
+	}
+	return result
 }
 
 func toActivitySourceDto(source *strava.ActivitySource) *ActivitySourceDto {
@@ -439,16 +512,63 @@ func toStreamDto(stream *strava.Stream) *StreamDto {
 		copy(cadence, stream.Cadence.Data)
 	}
 
+	var temperature []float64
+	if stream.Temperature != nil && stream.Temperature.Data != nil {
+		temperature = finiteFloat64Slice(stream.Temperature.Data)
+	}
+
+	var coreTemperature []float64
+	if stream.CoreTemperature != nil && stream.CoreTemperature.Data != nil {
+		coreTemperature = finiteFloat64Slice(stream.CoreTemperature.Data)
+	}
+
+	var leftRightBalance []float64
+	if stream.LeftRightBalance != nil && stream.LeftRightBalance.Data != nil {
+		leftRightBalance = finiteFloat64Slice(stream.LeftRightBalance.Data)
+	}
+
+	var verticalOscillation []float64
+	if stream.VerticalOscillation != nil && stream.VerticalOscillation.Data != nil {
+		verticalOscillation = finiteFloat64Slice(stream.VerticalOscillation.Data)
+	}
+
+	var groundContactTime []float64
+	if stream.GroundContactTime != nil && stream.GroundContactTime.Data != nil {
+		groundContactTime = finiteFloat64Slice(stream.GroundContactTime.Data)
+	}
+
+	var strideLength []float64
+	if stream.StrideLength != nil && stream.StrideLength.Data != nil {
+		strideLength = finiteFloat64Slice(stream.StrideLength.Data)
+	}
+
+	var developerFields []DeveloperFieldStreamDto
+	for _, field := range stream.DeveloperFields {
+		developerFields = append(developerFields, DeveloperFieldStreamDto{
+			Name:        field.Name,
+			Units:       field.Units,
+			NativeField: field.NativeField,
+			Data:        finiteFloat64Slice(field.Data),
+		})
+	}
+
 	return &StreamDto{
-		Distance:       finiteFloat64Slice(stream.Distance.Data),
-		Time:           stream.Time.Data,
-		Latlng:         latlng,
-		Heartrate:      heartrate,
-		Cadence:        cadence,
-		Moving:         moving,
-		Altitude:       altitude,
-		Watts:          watts,
-		VelocitySmooth: velocitySmooth,
+		Distance:            finiteFloat64Slice(stream.Distance.Data),
+		Time:                stream.Time.Data,
+		Latlng:              latlng,
+		Heartrate:           heartrate,
+		Cadence:             cadence,
+		Moving:              moving,
+		Altitude:            altitude,
+		Watts:               watts,
+		VelocitySmooth:      velocitySmooth,
+		Temperature:         temperature,
+		CoreTemperature:     coreTemperature,
+		LeftRightBalance:    leftRightBalance,
+		VerticalOscillation: verticalOscillation,
+		GroundContactTime:   groundContactTime,
+		StrideLength:        strideLength,
+		DeveloperFields:     developerFields,
 	}
 }
 
@@ -588,6 +708,127 @@ func ToStatisticDto(statistic statistics.Statistic) StatisticDto {
 	}
 }
 
+func ToCustomStatisticDefinitionDtos(definitions []business.CustomStatisticDefinition) []CustomStatisticDefinitionDto {
+	definitionDtos := make([]CustomStatisticDefinitionDto, len(definitions))
+	for i, definition := range definitions {
+		definitionDtos[i] = CustomStatisticDefinitionDto{Label: definition.Label, Expression: definition.Expression}
+	}
+	return definitionDtos
+}
+
+func ToCustomStatisticDefinitions(definitionDtos []CustomStatisticDefinitionDto) []business.CustomStatisticDefinition {
+	definitions := make([]business.CustomStatisticDefinition, len(definitionDtos))
+	for i, definitionDto := range definitionDtos {
+		definitions[i] = business.CustomStatisticDefinition{Label: definitionDto.Label, Expression: definitionDto.Expression}
+	}
+	return definitions
+}
+
+func ToPowerCurveDto(curve business.PowerCurve) PowerCurveDto {
+	points := make([]PowerCurvePointDto, len(curve.Points))
+	for i, point := range curve.Points {
+		points[i] = PowerCurvePointDto{
+			Seconds:      point.Seconds,
+			Watts:        point.Watts,
+			ActivityDate: point.ActivityDate,
+			Activity: ActivityShortDto{
+				ID:   point.Activity.Id,
+				Name: point.Activity.Name,
+				Type: point.Activity.Type.String(),
+			},
+		}
+	}
+
+	return PowerCurveDto{
+		Points:         points,
+		TwoParameter:   toCriticalPowerModelDto(curve.TwoParameter),
+		ThreeParameter: toCriticalPowerModelDto(curve.ThreeParameter),
+		ActivityCount:  curve.ActivityCount,
+		WindowDays:     curve.WindowDays,
+		ActivityID:     curve.ActivityID,
+	}
+}
+
+func toCriticalPowerModelDto(model *business.CriticalPowerModel) *CriticalPowerModelDto {
+	if model == nil {
+		return nil
+	}
+	return &CriticalPowerModelDto{
+		Model:         model.Model,
+		CriticalPower: model.CriticalPower,
+		WPrime:        model.WPrime,
+		K:             model.K,
+		MaxPower:      model.MaxPower,
+		RSquared:      model.RSquared,
+		RMSE:          model.RMSE,
+		FromSeconds:   model.FromSeconds,
+		ToSeconds:     model.ToSeconds,
+		PointCount:    model.PointCount,
+	}
+}
+
+func ToPaceCurveDto(curve business.PaceCurve) PaceCurveDto {
+	points := make([]PaceCurvePointDto, len(curve.Points))
+	for i, point := range curve.Points {
+		points[i] = toPaceCurvePointDto(point)
+	}
+	predictions := make([]RacePredictionDto, len(curve.Predictions))
+	for i, prediction := range curve.Predictions {
+		predictions[i] = RacePredictionDto{
+			Label:          prediction.Label,
+			Distance:       prediction.Distance,
+			RiegelSeconds:  prediction.RiegelSeconds,
+			CameronSeconds: prediction.CameronSeconds,
+			VdotSeconds:    prediction.VdotSeconds,
+		}
+		if prediction.Best != nil {
+			best := toPaceCurvePointDto(*prediction.Best)
+			predictions[i].Best = &best
+		}
+	}
+	trend := make([]VdotTrendPointDto, len(curve.VdotTrend))
+	for i, point := range curve.VdotTrend {
+		trend[i] = VdotTrendPointDto{
+			Period: point.Period,
+			Vdot:   point.Vdot,
+			Effort: toPaceCurvePointDto(point.Effort),
+		}
+	}
+
+	paceCurveDto := PaceCurveDto{
+		Points:        points,
+		Vdot:          curve.Vdot,
+		Predictions:   predictions,
+		VdotTrend:     trend,
+		ActivityCount: curve.ActivityCount,
+		WindowDays:    curve.WindowDays,
+		GradeAdjusted: curve.GradeAdjusted,
+	}
+	if curve.Reference != nil {
+		reference := toPaceCurvePointDto(*curve.Reference)
+		paceCurveDto.Reference = &reference
+	}
+	return paceCurveDto
+}
+
+func toPaceCurvePointDto(point business.PaceCurvePoint) PaceCurvePointDto {
+	secondsPerKm := 0.0
+	if point.Distance > 0 {
+		secondsPerKm = float64(point.Seconds) / point.Distance * 1000
+	}
+	return PaceCurvePointDto{
+		Distance:     point.Distance,
+		Seconds:      point.Seconds,
+		SecondsPerKm: secondsPerKm,
+		ActivityDate: point.ActivityDate,
+		Activity: ActivityShortDto{
+			ID:   point.Activity.Id,
+			Name: point.Activity.Name,
+			Type: point.Activity.Type.String(),
+		},
+	}
+}
+
 func ToPersonalRecordTimelineDto(entry business.PersonalRecordTimelineEntry) PersonalRecordTimelineDto {
 	return PersonalRecordTimelineDto{
 		MetricKey:     entry.MetricKey,
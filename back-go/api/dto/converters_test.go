@@ -471,6 +471,37 @@ func TestToDetailedActivityDto_ExposesStravaSegmentEfforts(t *testing.T) {
 	}
 }
 
+func TestToDetailedActivityDto_ExposesLapsAndSessions(t *testing.T) {
+	// GIVEN
+	detailedActivity := &strava.DetailedActivity{
+		Id:        55,
+		Name:      "Intervals",
+		Type:      "Run",
+		SportType: "Run",
+		Laps: []strava.Lap{
+			{LapIndex: 1, Name: "Lap 1", StartIndex: 0, EndIndex: 299, Distance: 1000, ElapsedTime: 300, AverageHeartrate: 171.6, AverageWatts: math.NaN(), Trigger: "time", Intensity: "active"},
+		},
+		Sessions: []strava.ActivitySession{
+			{SessionIndex: 1, SportType: "Swim", StartIndex: 0, EndIndex: 120, Distance: 1500},
+			{SessionIndex: 2, SportType: "Run", StartIndex: 121, EndIndex: 299, Distance: 10000, FirstLapIndex: 1, LapCount: 1},
+		},
+	}
+
+	// WHEN
+	dto := ToDetailedActivityDto(detailedActivity)
+
+	// THEN
+	if len(dto.Laps) != 1 || dto.Laps[0].EndIndex != 299 || dto.Laps[0].AverageHeartrate != 171 || dto.Laps[0].AverageWatts != 0 {
+		t.Fatalf("unexpected laps: %#v", dto.Laps)
+	}
+	if dto.Laps[0].Trigger != "time" || dto.Laps[0].Intensity != "active" {
+		t.Fatalf("expected lap trigger and intensity, got %#v", dto.Laps[0])
+	}
+	if len(dto.Sessions) != 2 || dto.Sessions[1].SportType != "Run" || dto.Sessions[1].FirstLapIndex != 1 {
+		t.Fatalf("unexpected sessions: %#v", dto.Sessions)
+	}
+}
+
 func TestToStreamDto_MapsValues(t *testing.T) {
 	// GIVEN
 	stream := &strava.Stream{
@@ -523,6 +554,40 @@ func TestToStreamDto_MapsValues(t *testing.T) {
 	}
 }
 
+func TestToStreamDto_MapsDeviceChannels(t *testing.T) {
+	// GIVEN
+	stream := &strava.Stream{
+		Distance:            strava.DistanceStream{Data: []float64{1, 2}},
+		Time:                strava.TimeStream{Data: []int{10, 20}},
+		Temperature:         &strava.TemperatureStream{Data: []float64{21, 22}},
+		CoreTemperature:     &strava.TemperatureStream{Data: []float64{37.4, math.NaN()}},
+		LeftRightBalance:    &strava.LeftRightBalanceStream{Data: []float64{48, 51}},
+		VerticalOscillation: &strava.VerticalOscillationStream{Data: []float64{9.5, 9.7}},
+		GroundContactTime:   &strava.GroundContactTimeStream{Data: []float64{248, 251}},
+		StrideLength:        &strava.StrideLengthStream{Data: []float64{1.2, 1.25}},
+		DeveloperFields: []strava.DeveloperFieldStream{
+			{Name: "Power", Units: "Watts", NativeField: "power", Data: []float64{250, 260}},
+		},
+	}
+
+	// WHEN
+	dto := toStreamDto(stream)
+
+	// THEN
+	if dto.Temperature[1] != 22 || dto.LeftRightBalance[0] != 48 || dto.StrideLength[1] != 1.25 {
+		t.Fatalf("unexpected temperature, balance or stride length: %v %v %v", dto.Temperature, dto.LeftRightBalance, dto.StrideLength)
+	}
+	if dto.VerticalOscillation[0] != 9.5 || dto.GroundContactTime[1] != 251 {
+		t.Fatalf("unexpected running dynamics: %v %v", dto.VerticalOscillation, dto.GroundContactTime)
+	}
+	if dto.CoreTemperature[0] != 37.4 || dto.CoreTemperature[1] != 0 {
+		t.Fatalf("expected sanitized core temperature, got %v", dto.CoreTemperature)
+	}
+	if len(dto.DeveloperFields) != 1 || dto.DeveloperFields[0].NativeField != "power" || dto.DeveloperFields[0].Data[1] != 260 {
+		t.Fatalf("unexpected developer fields: %#v", dto.DeveloperFields)
+	}
+}
+
 func TestComputeFamousClimbEffortSeconds_UsesSegmentDurationNotActivityDuration(t *testing.T) {
 	// GIVEN
 	badge := badges.FamousClimbBadge{
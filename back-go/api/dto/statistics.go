@@ -12,6 +12,74 @@ type ActivityShortDto struct {
 	Type string `json:"type"`
 }
 
+type CustomStatisticDefinitionDto struct {
+	Label      string `json:"label"`
+	Expression string `json:"expression"`
+}
+
+type PowerCurveDto struct {
+	Points         []PowerCurvePointDto   `json:"points"`
+	TwoParameter   *CriticalPowerModelDto `json:"twoParameter,omitempty"`
+	ThreeParameter *CriticalPowerModelDto `json:"threeParameter,omitempty"`
+	ActivityCount  int                    `json:"activityCount"`
+	WindowDays     int                    `json:"windowDays,omitempty"`
+	ActivityID     *int64                 `json:"activityId,omitempty"`
+}
+
+type PowerCurvePointDto struct {
+	Seconds      int              `json:"seconds"`
+	Watts        float64          `json:"watts"`
+	ActivityDate string           `json:"activityDate"`
+	Activity     ActivityShortDto `json:"activity"`
+}
+
+type CriticalPowerModelDto struct {
+	Model         string   `json:"model"`
+	CriticalPower float64  `json:"criticalPower"`
+	WPrime        float64  `json:"wPrime"`
+	K             float64  `json:"k"`
+	MaxPower      *float64 `json:"maxPower,omitempty"`
+	RSquared      float64  `json:"rSquared"`
+	RMSE          float64  `json:"rmse"`
+	FromSeconds   int      `json:"fromSeconds"`
+	ToSeconds     int      `json:"toSeconds"`
+	PointCount    int      `json:"pointCount"`
+}
+
+type PaceCurveDto struct {
+	Points        []PaceCurvePointDto `json:"points"`
+	Reference     *PaceCurvePointDto  `json:"reference,omitempty"`
+	Vdot          *float64            `json:"vdot,omitempty"`
+	Predictions   []RacePredictionDto `json:"predictions"`
+	VdotTrend     []VdotTrendPointDto `json:"vdotTrend"`
+	ActivityCount int                 `json:"activityCount"`
+	WindowDays    int                 `json:"windowDays,omitempty"`
+	GradeAdjusted bool                `json:"gradeAdjusted"`
+}
+
+type PaceCurvePointDto struct {
+	Distance     float64          `json:"distance"`
+	Seconds      int              `json:"seconds"`
+	SecondsPerKm float64          `json:"secondsPerKm"`
+	ActivityDate string           `json:"activityDate"`
+	Activity     ActivityShortDto `json:"activity"`
+}
+
+type RacePredictionDto struct {
+	Label          string             `json:"label"`
+	Distance       float64            `json:"distance"`
+	RiegelSeconds  int                `json:"riegelSeconds"`
+	CameronSeconds int                `json:"cameronSeconds"`
+	VdotSeconds    int                `json:"vdotSeconds"`
+	Best           *PaceCurvePointDto `json:"best,omitempty"`
+}
+
+type VdotTrendPointDto struct {
+	Period string            `json:"period"`
+	Vdot   float64           `json:"vdot"`
+	Effort PaceCurvePointDto `json:"effort"`
+}
+
 type PersonalRecordTimelineDto struct {
 	MetricKey     string           `json:"metricKey"`
 	MetricLabel   string           `json:"metricLabel"`
@@ -22,6 +22,10 @@ import (
 // @Produce json
 // @Param year query int false "Year"
 // @Param activityType query string true "Activity type"
+// @Param source query string false "Source filter, e.g. fit,no-strava"
+// @Param from query string false "First day of the date range (YYYY-MM-DD)"
+// @Param to query string false "Last day of the date range (YYYY-MM-DD)"
+// @Param last query string false "Rolling date range ending today, e.g. 90d, 12w, 6m or 1y"
 // @Success 200 {array} dto.ActivityDto
 // @Failure 400 {string} string "Invalid parameters"
 // @Failure 500 {string} string "Internal server error"
@@ -33,7 +37,7 @@ func getActivitiesByActivityType(writer http.ResponseWriter, request *http.Reque
 		return
 	}
 
-	activities := getContainer().listActivitiesUseCase.Execute(year, activityTypes)
+	activities := containerFor(request).listActivitiesUseCase.Execute(year, activityTypes)
 	activitiesDto := make([]dto.ActivityDto, len(activities))
 	for i, activity := range activities {
 		activitiesDto[i] = dto.ToActivityDto(*activity)
@@ -65,9 +69,9 @@ func getDetailedActivity(writer http.ResponseWriter, request *http.Request) {
 	rawVersion := request.URL.Query().Get("version") == "raw"
 	var detailedActivity *strava.DetailedActivity
 	if rawVersion {
-		detailedActivity, err = getContainer().getDetailedActivityUseCase.ExecuteRaw(activityId)
+		detailedActivity, err = containerFor(request).getDetailedActivityUseCase.ExecuteRaw(activityId)
 	} else {
-		detailedActivity, err = getContainer().getDetailedActivityUseCase.Execute(activityId)
+		detailedActivity, err = containerFor(request).getDetailedActivityUseCase.Execute(activityId)
 	}
 	if err != nil {
 		if errors.Is(err, activitiesDomain.ErrInvalidActivityID) {
@@ -79,9 +83,9 @@ func getDetailedActivity(writer http.ResponseWriter, request *http.Request) {
 	}
 
 	detailedActivityDto := dto.ToDetailedActivityDto(detailedActivity)
-	if getContainer().getActivityComparisonUseCase != nil {
+	if containerFor(request).getActivityComparisonUseCase != nil {
 		detailedActivityDto.ActivityComparison = toActivityComparisonDto(
-			getContainer().getActivityComparisonUseCase.Execute(detailedActivity),
+			containerFor(request).getActivityComparisonUseCase.Execute(detailedActivity),
 		)
 	}
 	if err := writeJSON(writer, http.StatusOK, detailedActivityDto); err != nil {
@@ -108,6 +112,8 @@ func toActivityComparisonDto(comparison *activitiesApp.ActivityComparison) *dto.
 			AverageWatts:     activity.AverageWatts,
 			AverageCadence:   activity.AverageCadence,
 			SimilarityScore:  activity.SimilarityScore,
+
+			AverageGradeAdjustedSpeed: activity.AverageGradeAdjustedSpeed,
 		})
 	}
 
@@ -129,6 +135,8 @@ func toActivityComparisonDto(comparison *activitiesApp.ActivityComparison) *dto.
 			ActivityType: comparison.Criteria.ActivityType,
 			Year:         comparison.Criteria.Year,
 			SampleSize:   comparison.Criteria.SampleSize,
+
+			GradeAdjusted: comparison.Criteria.GradeAdjusted,
 		},
 		Baseline: dto.ActivityComparisonBaselineDto{
 			Distance:         comparison.Baseline.Distance,
@@ -138,6 +146,8 @@ func toActivityComparisonDto(comparison *activitiesApp.ActivityComparison) *dto.
 			AverageHeartrate: comparison.Baseline.AverageHeartrate,
 			AverageWatts:     comparison.Baseline.AverageWatts,
 			AverageCadence:   comparison.Baseline.AverageCadence,
+
+			AverageGradeAdjustedSpeed: comparison.Baseline.AverageGradeAdjustedSpeed,
 		},
 		Deltas: dto.ActivityComparisonDeltasDto{
 			Distance:         comparison.Deltas.Distance,
@@ -148,6 +158,9 @@ func toActivityComparisonDto(comparison *activitiesApp.ActivityComparison) *dto.
 			AverageHeartrate: comparison.Deltas.AverageHeartrate,
 			AverageWatts:     comparison.Deltas.AverageWatts,
 			AverageCadence:   comparison.Deltas.AverageCadence,
+
+			AverageGradeAdjustedSpeed:    comparison.Deltas.AverageGradeAdjustedSpeed,
+			AverageGradeAdjustedSpeedPct: comparison.Deltas.AverageGradeAdjustedSpeedPct,
 		},
 		SimilarActivities: similarActivities,
 		CommonSegments:    commonSegments,
@@ -161,6 +174,10 @@ func toActivityComparisonDto(comparison *activitiesApp.ActivityComparison) *dto.
 // @Produce text/csv
 // @Param year query int false "Year"
 // @Param activityType query string true "Activity type"
+// @Param source query string false "Source filter, e.g. fit,no-strava"
+// @Param from query string false "First day of the date range (YYYY-MM-DD)"
+// @Param to query string false "Last day of the date range (YYYY-MM-DD)"
+// @Param last query string false "Rolling date range ending today, e.g. 90d, 12w, 6m or 1y"
 // @Success 200 {file} file "CSV file of activities"
 // @Failure 400 {string} string "Invalid parameters"
 // @Failure 500 {string} string "Internal server error"
@@ -171,7 +188,7 @@ func getExportCSV(writer http.ResponseWriter, request *http.Request) {
 		writeBadRequest(writer, "Invalid request parameters", err.Error())
 		return
 	}
-	csvData := getContainer().exportActivitiesCSVUseCase.Execute(year, activityTypes)
+	csvData := containerFor(request).exportActivitiesCSVUseCase.Execute(year, activityTypes)
 
 	writer.Header().Set("Content-Type", "text/csv")
 	writer.Header().Set("Content-Disposition", "attachment; filename=\"activities.csv\"")
@@ -190,6 +207,10 @@ func getExportCSV(writer http.ResponseWriter, request *http.Request) {
 // @Produce json
 // @Param year query int false "Year"
 // @Param activityType query string true "Activity type"
+// @Param source query string false "Source filter, e.g. fit,no-strava"
+// @Param from query string false "First day of the date range (YYYY-MM-DD)"
+// @Param to query string false "Last day of the date range (YYYY-MM-DD)"
+// @Param last query string false "Rolling date range ending today, e.g. 90d, 12w, 6m or 1y"
 // @Success 200 {object} object "GPX data"
 // @Failure 400 {string} string "Invalid parameters"
 // @Failure 500 {string} string "Internal server error"
@@ -201,7 +222,7 @@ func getMapsGPX(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	gpx := getContainer().getMapsGPXUseCase.Execute(year, activityTypes)
+	gpx := containerFor(request).getMapsGPXUseCase.Execute(year, activityTypes)
 	if err := writeJSON(writer, http.StatusOK, gpx); err != nil {
 		log.Printf("failed to write gpx response: %v", err)
 		writeInternalServerError(writer, "Failed to encode gpx response")
@@ -215,6 +236,10 @@ func getMapsGPX(writer http.ResponseWriter, request *http.Request) {
 // @Produce json
 // @Param year query int false "Year"
 // @Param activityType query string true "Activity type"
+// @Param source query string false "Source filter, e.g. fit,no-strava"
+// @Param from query string false "First day of the date range (YYYY-MM-DD)"
+// @Param to query string false "Last day of the date range (YYYY-MM-DD)"
+// @Param last query string false "Rolling date range ending today, e.g. 90d, 12w, 6m or 1y"
 // @Success 200 {object} object "Map passage data"
 // @Failure 400 {string} string "Invalid parameters"
 // @Failure 500 {string} string "Internal server error"
@@ -226,7 +251,7 @@ func getMapPassages(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	passages := getContainer().getMapPassagesUseCase.Execute(year, activityTypes)
+	passages := containerFor(request).getMapPassagesUseCase.Execute(year, activityTypes)
 	if err := writeJSON(writer, http.StatusOK, passages); err != nil {
 		log.Printf("failed to write map passages response: %v", err)
 		writeInternalServerError(writer, "Failed to encode map passages response")
@@ -6,12 +6,30 @@ import (
 	"log"
 	"mystravastats/api/dto"
 	athleteApp "mystravastats/internal/athlete/application"
+	"mystravastats/internal/platform/activityprovider"
 	"mystravastats/internal/shared/domain/business"
 	"net/http"
 	"strconv"
 	"strings"
 )
 
+// servedAthletes is replaced in tests to avoid reading ATHLETES_FILE.
+var servedAthletes = activityprovider.Athletes
+
+// getAthletes godoc
+// @Summary List athletes
+// @Description Returns the athletes served by this instance, default athlete first
+// @Tags athlete
+// @Produce json
+// @Success 200 {array} activityprovider.AthleteSummary
+// @Router /api/athletes [get]
+func getAthletes(writer http.ResponseWriter, _ *http.Request) {
+	if err := writeJSON(writer, http.StatusOK, servedAthletes()); err != nil {
+		log.Printf("failed to write athletes response: %v", err)
+		writeInternalServerError(writer, "Failed to encode athletes response")
+	}
+}
+
 // getAthlete godoc
 // @Summary Get athlete information
 // @Description Returns the current athlete information
@@ -20,8 +38,8 @@ import (
 // @Success 200 {object} dto.AthleteDto
 // @Failure 500 {string} string "Internal server error"
 // @Router /api/athletes/me [get]
-func getAthlete(writer http.ResponseWriter, _ *http.Request) {
-	athlete := getContainer().getAthleteUseCase.Execute()
+func getAthlete(writer http.ResponseWriter, request *http.Request) {
+	athlete := containerFor(request).getAthleteUseCase.Execute()
 	athleteDto := dto.ToAthleteDto(athlete)
 	if err := writeJSON(writer, http.StatusOK, athleteDto); err != nil {
 		log.Printf("failed to write athlete response: %v", err)
@@ -29,8 +47,8 @@ func getAthlete(writer http.ResponseWriter, _ *http.Request) {
 	}
 }
 
-func getAthletePerformanceSettings(writer http.ResponseWriter, _ *http.Request) {
-	settings := getContainer().getPerformanceSettingsUseCase.Execute()
+func getAthletePerformanceSettings(writer http.ResponseWriter, request *http.Request) {
+	settings := containerFor(request).getPerformanceSettingsUseCase.Execute()
 	settingsDto := dto.ToAthletePerformanceSettingsDto(settings)
 	if err := writeJSON(writer, http.StatusOK, settingsDto); err != nil {
 		log.Printf("failed to write performance settings response: %v", err)
@@ -51,7 +69,7 @@ func getAthleteFtpEstimate(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	estimate := getContainer().getFtpEstimateUseCase.Execute(activityTypes, windowDays)
+	estimate := containerFor(request).getFtpEstimateUseCase.Execute(activityTypes, windowDays)
 	estimateDto := dto.ToFtpEstimateDto(estimate)
 	if err := writeJSON(writer, http.StatusOK, estimateDto); err != nil {
 		log.Printf("failed to write FTP estimate response: %v", err)
@@ -73,7 +91,7 @@ func putAthletePerformanceSettings(writer http.ResponseWriter, request *http.Req
 	}
 
 	settings := dto.ToAthletePerformanceSettings(settingsDto)
-	updatedSettings := getContainer().updatePerformanceSettingsUseCase.Execute(settings)
+	updatedSettings := containerFor(request).updatePerformanceSettingsUseCase.Execute(settings)
 	updatedSettingsDto := dto.ToAthletePerformanceSettingsDto(updatedSettings)
 
 	if err := writeJSON(writer, http.StatusOK, updatedSettingsDto); err != nil {
@@ -101,8 +119,8 @@ func getOptionalFtpEstimateWindowDays(request *http.Request) (int, error) {
 	return days, nil
 }
 
-func getAthleteHeartRateZones(writer http.ResponseWriter, _ *http.Request) {
-	settings := getContainer().getHeartRateZoneSettingsUseCase.Execute()
+func getAthleteHeartRateZones(writer http.ResponseWriter, request *http.Request) {
+	settings := containerFor(request).getHeartRateZoneSettingsUseCase.Execute()
 	settingsDto := dto.ToHeartRateZoneSettingsDto(settings)
 	if err := writeJSON(writer, http.StatusOK, settingsDto); err != nil {
 		log.Printf("failed to write heart rate settings response: %v", err)
@@ -124,7 +142,7 @@ func putAthleteHeartRateZones(writer http.ResponseWriter, request *http.Request)
 	}
 
 	settings := dto.ToHeartRateZoneSettings(settingsDto)
-	updatedSettings := getContainer().updateHeartRateZoneSettingsUseCase.Execute(settings)
+	updatedSettings := containerFor(request).updateHeartRateZoneSettingsUseCase.Execute(settings)
 	updatedSettingsDto := dto.ToHeartRateZoneSettingsDto(updatedSettings)
 
 	if err := writeJSON(writer, http.StatusOK, updatedSettingsDto); err != nil {
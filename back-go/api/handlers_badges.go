@@ -13,6 +13,10 @@ import (
 // @Produce json
 // @Param year query int false "Year"
 // @Param activityType query string true "Activity type"
+// @Param source query string false "Source filter, e.g. fit,no-strava"
+// @Param from query string false "First day of the date range (YYYY-MM-DD)"
+// @Param to query string false "Last day of the date range (YYYY-MM-DD)"
+// @Param last query string false "Rolling date range ending today, e.g. 90d, 12w, 6m or 1y"
 // @Param badgeSet query string false "Badge set (GENERAL, FAMOUS)"
 // @Success 200 {array} dto.BadgeCheckResultDto
 // @Failure 400 {string} string "Invalid parameters"
@@ -30,7 +34,7 @@ func getBadges(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	badges := getContainer().getBadgesUseCase.Execute(year, badgeSet, activityTypes)
+	badges := containerFor(request).getBadgesUseCase.Execute(year, badgeSet, activityTypes)
 
 	badgesDto := make([]dto.BadgeCheckResultDto, len(badges))
 	for i, badge := range badges {
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"time"
+
+	"mystravastats/internal/cachebackup"
+	"mystravastats/internal/platform/activityprovider"
+)
+
+// getCacheBackup returns a tar.gz snapshot of the athlete cache. The snapshot
+// is written to a temporary file first, so that cache writes are only paused
+// for the time of a local copy rather than of the download.
+func getCacheBackup(writer http.ResponseWriter, request *http.Request) {
+	athleteID := cacheBackupAthleteID(request)
+	roots, ok := activityprovider.SourceRoots(athleteID)
+	if !ok {
+		writeNotFound(writer, "Athlete not found", "athlete "+athleteID+" is not served by this instance")
+		return
+	}
+
+	file, err := os.CreateTemp("", "mystravastats-cache-*.tar.gz")
+	if err != nil {
+		log.Printf("failed to create cache backup file: %v", err)
+		writeInternalServerError(writer, "Failed to create cache backup")
+		return
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	manifest, err := cachebackup.Create(file, athleteID, roots)
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		log.Printf("failed to create cache backup: %v", err)
+		writeInternalServerError(writer, "Failed to create cache backup")
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/gzip")
+	writer.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", cachebackup.FileName(athleteID, time.Now())))
+	writer.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(writer, file); err != nil {
+		log.Printf("failed to write cache backup response: %v", err)
+		return
+	}
+	log.Printf("Cache backup of %s sent (%d files)", athleteID, len(manifest.Files))
+}
+
+// postCacheRestore restores a snapshot sent as an application/gzip body of at
+// most cachebackup.MaxSnapshotBytes, then reloads the athlete provider from the
+// restored cache.
+func postCacheRestore(writer http.ResponseWriter, request *http.Request) {
+	mediaType, _, _ := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	if mediaType != "application/gzip" && mediaType != "application/x-gzip" && mediaType != "application/octet-stream" {
+		writeBadRequest(writer, "Invalid snapshot", "send the tar.gz snapshot as an application/gzip body")
+		return
+	}
+	athleteID := cacheBackupAthleteID(request)
+	roots, ok := activityprovider.SourceRoots(athleteID)
+	if !ok {
+		writeNotFound(writer, "Athlete not found", "athlete "+athleteID+" is not served by this instance")
+		return
+	}
+
+	request.Body = http.MaxBytesReader(writer, request.Body, cachebackup.MaxSnapshotBytes)
+	result, err := cachebackup.Restore(request.Body, roots)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeAPIError(writer, http.StatusRequestEntityTooLarge, "Snapshot too large",
+			fmt.Sprintf("snapshots are limited to %d MiB; restore larger ones offline with -cache-restore", tooLarge.Limit>>20))
+		return
+	}
+	if errors.Is(err, cachebackup.ErrSnapshotTooLarge) {
+		writeAPIError(writer, http.StatusRequestEntityTooLarge, "Snapshot too large", err.Error())
+		return
+	}
+	if err != nil {
+		writeBadRequest(writer, "Invalid snapshot", err.Error())
+		return
+	}
+	if provider, ok := activityprovider.ForAthlete(athleteID); ok {
+		activityprovider.ReloadFromCache(provider)
+	}
+
+	if err := writeJSON(writer, http.StatusOK, result); err != nil {
+		log.Printf("failed to write cache restore response: %v", err)
+		writeInternalServerError(writer, "Failed to encode cache restore response")
+	}
+}
+
+func cacheBackupAthleteID(request *http.Request) string {
+	if athleteID := requestAthleteID(request); athleteID != "" {
+		return athleteID
+	}
+	return activityprovider.DefaultAthlete()
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"log"
+	chartsApp "mystravastats/internal/charts/application"
 	"net/http"
 )
 
@@ -12,6 +13,10 @@ import (
 // @Produce json
 // @Param year query int false "Year"
 // @Param activityType query string true "Activity type"
+// @Param source query string false "Source filter, e.g. fit,no-strava"
+// @Param from query string false "First day of the date range (YYYY-MM-DD)"
+// @Param to query string false "Last day of the date range (YYYY-MM-DD)"
+// @Param last query string false "Rolling date range ending today, e.g. 90d, 12w, 6m or 1y"
 // @Param period query string false "Aggregation period"
 // @Success 200 {object} object "Distance data by period"
 // @Failure 400 {string} string "Invalid parameters"
@@ -23,7 +28,7 @@ func getChartsDistanceByPeriod(writer http.ResponseWriter, request *http.Request
 		writeBadRequest(writer, "Invalid request parameters", err.Error())
 		return
 	}
-	if year == nil {
+	if year == nil && !hasDateRangeParam(request) {
 		writeBadRequest(writer, "Invalid request parameters", "year is required")
 		return
 	}
@@ -33,7 +38,7 @@ func getChartsDistanceByPeriod(writer http.ResponseWriter, request *http.Request
 		return
 	}
 
-	distanceByPeriod := getContainer().getDistanceByPeriodUseCase.Execute(year, period, activityTypes)
+	distanceByPeriod := containerFor(request).getDistanceByPeriodUseCase.Execute(year, period, activityTypes)
 	if err := writeJSON(writer, http.StatusOK, distanceByPeriod); err != nil {
 		log.Printf("failed to write distance chart response: %v", err)
 		writeInternalServerError(writer, "Failed to encode distance chart response")
@@ -47,6 +52,10 @@ func getChartsDistanceByPeriod(writer http.ResponseWriter, request *http.Request
 // @Produce json
 // @Param year query int false "Year"
 // @Param activityType query string true "Activity type"
+// @Param source query string false "Source filter, e.g. fit,no-strava"
+// @Param from query string false "First day of the date range (YYYY-MM-DD)"
+// @Param to query string false "Last day of the date range (YYYY-MM-DD)"
+// @Param last query string false "Rolling date range ending today, e.g. 90d, 12w, 6m or 1y"
 // @Param period query string false "Aggregation period"
 // @Success 200 {object} object "Elevation data by period"
 // @Failure 400 {string} string "Invalid parameters"
@@ -58,7 +67,7 @@ func getChartsElevationByPeriod(writer http.ResponseWriter, request *http.Reques
 		writeBadRequest(writer, "Invalid request parameters", err.Error())
 		return
 	}
-	if year == nil {
+	if year == nil && !hasDateRangeParam(request) {
 		writeBadRequest(writer, "Invalid request parameters", "year is required")
 		return
 	}
@@ -68,7 +77,7 @@ func getChartsElevationByPeriod(writer http.ResponseWriter, request *http.Reques
 		return
 	}
 
-	elevationByPeriod := getContainer().getElevationByPeriodUseCase.Execute(year, period, activityTypes)
+	elevationByPeriod := containerFor(request).getElevationByPeriodUseCase.Execute(year, period, activityTypes)
 	if err := writeJSON(writer, http.StatusOK, elevationByPeriod); err != nil {
 		log.Printf("failed to write elevation chart response: %v", err)
 		writeInternalServerError(writer, "Failed to encode elevation chart response")
@@ -82,7 +91,12 @@ func getChartsElevationByPeriod(writer http.ResponseWriter, request *http.Reques
 // @Produce json
 // @Param year query int false "Year"
 // @Param activityType query string true "Activity type"
+// @Param source query string false "Source filter, e.g. fit,no-strava"
+// @Param from query string false "First day of the date range (YYYY-MM-DD)"
+// @Param to query string false "Last day of the date range (YYYY-MM-DD)"
+// @Param last query string false "Rolling date range ending today, e.g. 90d, 12w, 6m or 1y"
 // @Param period query string false "Aggregation period"
+// @Param gradeAdjusted query bool false "Use the grade-adjusted speed of activities on foot"
 // @Success 200 {object} object "Average speed data by period"
 // @Failure 400 {string} string "Invalid parameters"
 // @Failure 500 {string} string "Internal server error"
@@ -93,7 +107,7 @@ func getChartsAverageSpeedByPeriod(writer http.ResponseWriter, request *http.Req
 		writeBadRequest(writer, "Invalid request parameters", err.Error())
 		return
 	}
-	if year == nil {
+	if year == nil && !hasDateRangeParam(request) {
 		writeBadRequest(writer, "Invalid request parameters", "year is required")
 		return
 	}
@@ -103,7 +117,18 @@ func getChartsAverageSpeedByPeriod(writer http.ResponseWriter, request *http.Req
 		return
 	}
 
-	averageSpeedByPeriod := getContainer().getAverageSpeedByPeriodUseCase.Execute(year, period, activityTypes)
+	gradeAdjusted, err := getBoolParam(request, "gradeAdjusted")
+	if err != nil {
+		writeBadRequest(writer, "Invalid request parameters", err.Error())
+		return
+	}
+
+	var averageSpeedByPeriod []chartsApp.ChartPeriodPoint
+	if gradeAdjusted != nil && *gradeAdjusted {
+		averageSpeedByPeriod = containerFor(request).getAverageGradeAdjustedSpeedUseCase.Execute(year, period, activityTypes)
+	} else {
+		averageSpeedByPeriod = containerFor(request).getAverageSpeedByPeriodUseCase.Execute(year, period, activityTypes)
+	}
 	if err := writeJSON(writer, http.StatusOK, averageSpeedByPeriod); err != nil {
 		log.Printf("failed to write average speed chart response: %v", err)
 		writeInternalServerError(writer, "Failed to encode average speed chart response")
@@ -117,6 +142,10 @@ func getChartsAverageSpeedByPeriod(writer http.ResponseWriter, request *http.Req
 // @Produce json
 // @Param year query int false "Year"
 // @Param activityType query string true "Activity type"
+// @Param source query string false "Source filter, e.g. fit,no-strava"
+// @Param from query string false "First day of the date range (YYYY-MM-DD)"
+// @Param to query string false "Last day of the date range (YYYY-MM-DD)"
+// @Param last query string false "Rolling date range ending today, e.g. 90d, 12w, 6m or 1y"
 // @Param period query string false "Aggregation period"
 // @Success 200 {object} object "Average cadence data by period"
 // @Failure 400 {string} string "Invalid parameters"
@@ -128,7 +157,7 @@ func getChartsAverageCadenceByPeriod(writer http.ResponseWriter, request *http.R
 		writeBadRequest(writer, "Invalid request parameters", err.Error())
 		return
 	}
-	if year == nil {
+	if year == nil && !hasDateRangeParam(request) {
 		writeBadRequest(writer, "Invalid request parameters", "year is required")
 		return
 	}
@@ -138,7 +167,7 @@ func getChartsAverageCadenceByPeriod(writer http.ResponseWriter, request *http.R
 		return
 	}
 
-	averageCadenceByPeriod := getContainer().getAverageCadenceByPeriodUseCase.Execute(year, period, activityTypes)
+	averageCadenceByPeriod := containerFor(request).getAverageCadenceByPeriodUseCase.Execute(year, period, activityTypes)
 	if err := writeJSON(writer, http.StatusOK, averageCadenceByPeriod); err != nil {
 		log.Printf("failed to write average cadence chart response: %v", err)
 		writeInternalServerError(writer, "Failed to encode average cadence chart response")
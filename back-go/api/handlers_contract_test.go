@@ -144,6 +144,35 @@ func (stub *contractStatisticsReaderStub) FindStatisticsByYearAndTypes(_ *int, _
 	return stub.statistics
 }
 
+type contractCustomStatisticsStoreStub struct {
+	definitions []business.CustomStatisticDefinition
+}
+
+func (stub *contractCustomStatisticsStoreStub) FindCustomStatisticDefinitions(_ ...business.ActivityType) []business.CustomStatisticDefinition {
+	return stub.definitions
+}
+
+func (stub *contractCustomStatisticsStoreStub) SaveCustomStatisticDefinitions(definitions []business.CustomStatisticDefinition, _ ...business.ActivityType) []business.CustomStatisticDefinition {
+	stub.definitions = definitions
+	return definitions
+}
+
+type contractPowerCurveReaderStub struct {
+	curve business.PowerCurve
+}
+
+func (stub *contractPowerCurveReaderStub) FindPowerCurve(_ *int, _ int, _ *int64, _ ...business.ActivityType) business.PowerCurve {
+	return stub.curve
+}
+
+type contractPaceCurveReaderStub struct {
+	curve business.PaceCurve
+}
+
+func (stub *contractPaceCurveReaderStub) FindPaceCurve(_ *int, _ int, _ bool, _ ...business.ActivityType) business.PaceCurve {
+	return stub.curve
+}
+
 type contractAthleteReaderStub struct {
 	athlete             strava.Athlete
 	activities          []*strava.Activity
@@ -227,7 +256,8 @@ func (stub *contractRoutesReaderStub) FindRouteExplorerByYearAndTypes(_ *int, re
 }
 
 type contractChartsReaderStub struct {
-	result []chartsApp.ChartPeriodPoint
+	result              []chartsApp.ChartPeriodPoint
+	gradeAdjustedResult []chartsApp.ChartPeriodPoint
 }
 
 func (stub *contractChartsReaderStub) FindDistanceByPeriod(_ *int, _ business.Period, _ ...business.ActivityType) []chartsApp.ChartPeriodPoint {
@@ -242,6 +272,10 @@ func (stub *contractChartsReaderStub) FindAverageSpeedByPeriod(_ *int, _ busines
 	return stub.result
 }
 
+func (stub *contractChartsReaderStub) FindAverageGradeAdjustedSpeedByPeriod(_ *int, _ business.Period, _ ...business.ActivityType) []chartsApp.ChartPeriodPoint {
+	return stub.gradeAdjustedResult
+}
+
 func (stub *contractChartsReaderStub) FindAverageCadenceByPeriod(_ *int, _ business.Period, _ ...business.ActivityType) []chartsApp.ChartPeriodPoint {
 	return stub.result
 }
@@ -605,6 +639,165 @@ func TestGetBadges_InvalidBadgeSet_Returns400(t *testing.T) {
 	}
 }
 
+func TestPutCustomStatisticsByActivityType_ReturnsSavedDefinitions(t *testing.T) {
+	// GIVEN
+	store := &contractCustomStatisticsStoreStub{}
+	setTestContainer(t, &container{
+		updateCustomStatisticsUseCase: statisticsApp.NewUpdateCustomStatisticsUseCase(store),
+	})
+
+	request := httptest.NewRequest(
+		http.MethodPut,
+		"/api/statistics/custom?activityType=Ride",
+		strings.NewReader(`[{"label":"Commutes","expression":"count() where commute"}]`),
+	)
+	recorder := httptest.NewRecorder()
+
+	// WHEN
+	putCustomStatisticsByActivityType(recorder, request)
+
+	// THEN
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", recorder.Code)
+	}
+	var response []map[string]any
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode JSON response: %v", err)
+	}
+	if len(response) != 1 || response[0]["expression"] != "count() where commute" {
+		t.Fatalf("expected the saved definition, got %v", response)
+	}
+	if len(store.definitions) != 1 {
+		t.Fatalf("expected 1 definition saved, got %d", len(store.definitions))
+	}
+}
+
+func TestPutCustomStatisticsByActivityType_InvalidExpression_Returns400(t *testing.T) {
+	// GIVEN
+	store := &contractCustomStatisticsStoreStub{}
+	setTestContainer(t, &container{
+		updateCustomStatisticsUseCase: statisticsApp.NewUpdateCustomStatisticsUseCase(store),
+	})
+
+	request := httptest.NewRequest(
+		http.MethodPut,
+		"/api/statistics/custom?activityType=Ride",
+		strings.NewReader(`[{"label":"Median","expression":"median(distance)"}]`),
+	)
+	recorder := httptest.NewRecorder()
+
+	// WHEN
+	putCustomStatisticsByActivityType(recorder, request)
+
+	// THEN
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", recorder.Code)
+	}
+	var response contractErrorResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode JSON response: %v", err)
+	}
+	if !strings.Contains(response.Description, "unknown function") {
+		t.Fatalf("expected the parse error in the description, got %q", response.Description)
+	}
+	if store.definitions != nil {
+		t.Fatalf("expected nothing to be saved, got %v", store.definitions)
+	}
+}
+
+func TestGetPowerCurveByActivityType_Returns200WithModel(t *testing.T) {
+	// GIVEN
+	setTestContainer(t, &container{
+		getPowerCurveUseCase: statisticsApp.NewGetPowerCurveUseCase(&contractPowerCurveReaderStub{
+			curve: business.PowerCurve{
+				Points: []business.PowerCurvePoint{
+					{Seconds: 300, Watts: 320, ActivityDate: "2025-05-01", Activity: business.ActivityShort{Id: 7, Name: "Test", Type: business.Ride}},
+				},
+				TwoParameter:  &business.CriticalPowerModel{Model: business.CriticalPowerModelTwoParameter, CriticalPower: 250, WPrime: 20000},
+				ActivityCount: 1,
+			},
+		}),
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/api/statistics/power-curve?activityType=Ride&windowDays=90", nil)
+	recorder := httptest.NewRecorder()
+
+	// WHEN
+	getPowerCurveByActivityType(recorder, request)
+
+	// THEN
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", recorder.Code)
+	}
+	var response map[string]any
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode JSON response: %v", err)
+	}
+	points := response["points"].([]any)
+	activity := points[0].(map[string]any)["activity"].(map[string]any)
+	if got := activity["id"].(float64); got != 7 {
+		t.Fatalf("expected point linked to activity 7, got %v", got)
+	}
+	if got := response["twoParameter"].(map[string]any)["criticalPower"].(float64); got != 250 {
+		t.Fatalf("expected critical power 250, got %v", got)
+	}
+}
+
+func TestGetPowerCurveByActivityType_NegativeWindow_Returns400(t *testing.T) {
+	// GIVEN
+	request := httptest.NewRequest(http.MethodGet, "/api/statistics/power-curve?activityType=Ride&windowDays=-1", nil)
+	recorder := httptest.NewRecorder()
+
+	// WHEN
+	getPowerCurveByActivityType(recorder, request)
+
+	// THEN
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", recorder.Code)
+	}
+}
+
+func TestGetPaceCurveByActivityType_Returns200WithPredictions(t *testing.T) {
+	// GIVEN
+	reference := business.PaceCurvePoint{Distance: 5000, Seconds: 1200, ActivityDate: "2025-05-01", Activity: business.ActivityShort{Id: 9, Name: "Parkrun", Type: business.Run}}
+	vdot := 49.8
+	setTestContainer(t, &container{
+		getPaceCurveUseCase: statisticsApp.NewGetPaceCurveUseCase(&contractPaceCurveReaderStub{
+			curve: business.PaceCurve{
+				Points:      []business.PaceCurvePoint{reference},
+				Reference:   &reference,
+				Vdot:        &vdot,
+				Predictions: []business.RacePrediction{{Label: "5 km", Distance: 5000, RiegelSeconds: 1200, CameronSeconds: 1200, VdotSeconds: 1200, Best: &reference}},
+			},
+		}),
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/api/statistics/pace-curve?activityType=Run", nil)
+	recorder := httptest.NewRecorder()
+
+	// WHEN
+	getPaceCurveByActivityType(recorder, request)
+
+	// THEN
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", recorder.Code)
+	}
+	var response map[string]any
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode JSON response: %v", err)
+	}
+	if got := response["reference"].(map[string]any)["secondsPerKm"].(float64); got != 240 {
+		t.Fatalf("expected a reference pace of 240 s/km, got %v", got)
+	}
+	prediction := response["predictions"].([]any)[0].(map[string]any)
+	if got := prediction["best"].(map[string]any)["activity"].(map[string]any)["id"].(float64); got != 9 {
+		t.Fatalf("expected the prediction to link activity 9, got %v", got)
+	}
+	if trend, ok := response["vdotTrend"].([]any); !ok || len(trend) != 0 {
+		t.Fatalf("expected an empty VDOT trend array, got %v", response["vdotTrend"])
+	}
+}
+
 func TestGetStatisticsByActivityType_Returns200AndArray(t *testing.T) {
 	// GIVEN
 	// WHEN
@@ -851,6 +1044,36 @@ func TestGetChartsAverageSpeedByPeriod_Returns200(t *testing.T) {
 	}
 }
 
+func TestGetChartsAverageSpeedByPeriod_GradeAdjusted_Returns200(t *testing.T) {
+	// GIVEN
+	reader := &contractChartsReaderStub{
+		result:              []chartsApp.ChartPeriodPoint{{PeriodKey: "01", Value: 2.5, ActivityCount: 1}},
+		gradeAdjustedResult: []chartsApp.ChartPeriodPoint{{PeriodKey: "01", Value: 3.1, ActivityCount: 1}},
+	}
+	setTestContainer(t, &container{
+		getAverageSpeedByPeriodUseCase:      chartsApp.NewGetAverageSpeedByPeriodUseCase(reader),
+		getAverageGradeAdjustedSpeedUseCase: chartsApp.NewGetAverageGradeAdjustedSpeedByPeriodUseCase(reader),
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/api/charts/average-speed-by-period?year=2025&activityType=TrailRun&period=MONTHS&gradeAdjusted=true", nil)
+	recorder := httptest.NewRecorder()
+
+	// WHEN
+	getChartsAverageSpeedByPeriod(recorder, request)
+
+	// THEN
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", recorder.Code)
+	}
+	var response []chartsApp.ChartPeriodPoint
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode JSON response: %v", err)
+	}
+	if len(response) != 1 || response[0].Value != 3.1 {
+		t.Fatalf("expected the grade-adjusted speed 3.1, got %#v", response)
+	}
+}
+
 func TestGetChartsElevationByPeriod_InvalidPeriod_Returns400(t *testing.T) {
 	// GIVEN
 	// WHEN
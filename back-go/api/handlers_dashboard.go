@@ -14,6 +14,10 @@ import (
 // @Tags dashboard
 // @Produce json
 // @Param activityType query string true "Activity type"
+// @Param source query string false "Source filter, e.g. fit,no-strava"
+// @Param from query string false "First day of the date range (YYYY-MM-DD)"
+// @Param to query string false "Last day of the date range (YYYY-MM-DD)"
+// @Param last query string false "Rolling date range ending today, e.g. 90d, 12w, 6m or 1y"
 // @Success 200 {object} dto.DashboardDataDto
 // @Failure 400 {string} string "Invalid parameters"
 // @Failure 500 {string} string "Internal server error"
@@ -25,7 +29,7 @@ func getDashboard(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	dashboardData := getContainer().getDashboardDataUseCase.Execute(activityTypes)
+	dashboardData := containerFor(request).getDashboardDataUseCase.Execute(activityTypes)
 	dashboardDataDto := dto.ToDashboardDataDto(dashboardData)
 
 	if err := writeJSON(writer, http.StatusOK, dashboardDataDto); err != nil {
@@ -40,6 +44,10 @@ func getDashboard(writer http.ResponseWriter, request *http.Request) {
 // @Tags dashboard
 // @Produce json
 // @Param activityType query string true "Activity type"
+// @Param source query string false "Source filter, e.g. fit,no-strava"
+// @Param from query string false "First day of the date range (YYYY-MM-DD)"
+// @Param to query string false "Last day of the date range (YYYY-MM-DD)"
+// @Param last query string false "Rolling date range ending today, e.g. 90d, 12w, 6m or 1y"
 // @Success 200 {object} dto.CumulativeDataPerYearDto
 // @Failure 400 {string} string "Invalid parameters"
 // @Failure 500 {string} string "Internal server error"
@@ -51,7 +59,7 @@ func getDashboardCumulativeDataByYear(writer http.ResponseWriter, request *http.
 		return
 	}
 
-	cumulativeData := getContainer().getCumulativeDataPerYearUseCase.Execute(activityTypes)
+	cumulativeData := containerFor(request).getCumulativeDataPerYearUseCase.Execute(activityTypes)
 	cumulativeDataDto := dto.CumulativeDataPerYearDto{
 		Distance:  cumulativeData.Distance,
 		Elevation: cumulativeData.Elevation,
@@ -69,6 +77,10 @@ func getDashboardCumulativeDataByYear(writer http.ResponseWriter, request *http.
 // @Tags dashboard
 // @Produce json
 // @Param activityType query string true "Activity type"
+// @Param source query string false "Source filter, e.g. fit,no-strava"
+// @Param from query string false "First day of the date range (YYYY-MM-DD)"
+// @Param to query string false "Last day of the date range (YYYY-MM-DD)"
+// @Param last query string false "Rolling date range ending today, e.g. 90d, 12w, 6m or 1y"
 // @Success 200 {object} map[string]map[string]interface{}
 // @Failure 400 {string} string "Invalid parameters"
 // @Failure 500 {string} string "Internal server error"
@@ -80,7 +92,7 @@ func getDashboardActivityHeatmap(writer http.ResponseWriter, request *http.Reque
 		return
 	}
 
-	heatmap := getContainer().getActivityHeatmapUseCase.Execute(activityTypes)
+	heatmap := containerFor(request).getActivityHeatmapUseCase.Execute(activityTypes)
 	if err := writeJSON(writer, http.StatusOK, heatmap); err != nil {
 		log.Printf("failed to write activity heatmap response: %v", err)
 		writeInternalServerError(writer, "Failed to encode activity heatmap response")
@@ -93,6 +105,10 @@ func getDashboardActivityHeatmap(writer http.ResponseWriter, request *http.Reque
 // @Tags dashboard
 // @Produce json
 // @Param activityType query string true "Activity type"
+// @Param source query string false "Source filter, e.g. fit,no-strava"
+// @Param from query string false "First day of the date range (YYYY-MM-DD)"
+// @Param to query string false "Last day of the date range (YYYY-MM-DD)"
+// @Param last query string false "Rolling date range ending today, e.g. 90d, 12w, 6m or 1y"
 // @Param year query int false "Year. Required when scope is year"
 // @Param scope query string false "Eddington scope" Enums(lifetime, year, rolling-12-months) default(lifetime)
 // @Param metric query string false "Eddington metric" Enums(distance, elevation) default(distance)
@@ -127,7 +143,7 @@ func getDashboardEddingtonNumber(writer http.ResponseWriter, request *http.Reque
 		return
 	}
 
-	edNum := getContainer().getEddingtonNumberUseCase.Execute(scope, metric, basis, year, activityTypes)
+	edNum := containerFor(request).getEddingtonNumberUseCase.Execute(scope, metric, basis, year, activityTypes)
 	edNumDto := dto.EddingtonNumberDto{
 		EddingtonNumber: edNum.Number,
 		EddingtonList:   edNum.List,
@@ -156,6 +172,10 @@ func getDashboardEddingtonNumber(writer http.ResponseWriter, request *http.Reque
 // @Produce json
 // @Param year query int true "Year"
 // @Param activityType query string true "Activity type"
+// @Param source query string false "Source filter, e.g. fit,no-strava"
+// @Param from query string false "First day of the date range (YYYY-MM-DD)"
+// @Param to query string false "Last day of the date range (YYYY-MM-DD)"
+// @Param last query string false "Rolling date range ending today, e.g. 90d, 12w, 6m or 1y"
 // @Success 200 {object} dto.AnnualGoalsDto
 // @Failure 400 {string} string "Invalid parameters"
 // @Failure 500 {string} string "Internal server error"
@@ -171,7 +191,7 @@ func getDashboardAnnualGoals(writer http.ResponseWriter, request *http.Request)
 		return
 	}
 
-	goals := getContainer().getAnnualGoalsUseCase.Execute(*year, activityTypes)
+	goals := containerFor(request).getAnnualGoalsUseCase.Execute(*year, activityTypes)
 	if err := writeJSON(writer, http.StatusOK, dto.ToAnnualGoalsDto(goals)); err != nil {
 		log.Printf("failed to write annual goals response: %v", err)
 		writeInternalServerError(writer, "Failed to encode annual goals response")
@@ -186,6 +206,10 @@ func getDashboardAnnualGoals(writer http.ResponseWriter, request *http.Request)
 // @Produce json
 // @Param year query int true "Year"
 // @Param activityType query string true "Activity type"
+// @Param source query string false "Source filter, e.g. fit,no-strava"
+// @Param from query string false "First day of the date range (YYYY-MM-DD)"
+// @Param to query string false "Last day of the date range (YYYY-MM-DD)"
+// @Param last query string false "Rolling date range ending today, e.g. 90d, 12w, 6m or 1y"
 // @Param targets body dto.AnnualGoalTargetsDto true "Annual goal targets"
 // @Success 200 {object} dto.AnnualGoalsDto
 // @Failure 400 {string} string "Invalid parameters"
@@ -208,7 +232,7 @@ func putDashboardAnnualGoals(writer http.ResponseWriter, request *http.Request)
 		return
 	}
 
-	goals := getContainer().updateAnnualGoalsUseCase.Execute(*year, dto.ToAnnualGoalTargets(requestDto), activityTypes)
+	goals := containerFor(request).updateAnnualGoalsUseCase.Execute(*year, dto.ToAnnualGoalTargets(requestDto), activityTypes)
 	if err := writeJSON(writer, http.StatusOK, dto.ToAnnualGoalsDto(goals)); err != nil {
 		log.Printf("failed to write annual goals response: %v", err)
 		writeInternalServerError(writer, "Failed to encode annual goals response")
@@ -12,8 +12,8 @@ import (
 	"github.com/gorilla/mux"
 )
 
-func getDataQualityIssues(writer http.ResponseWriter, _ *http.Request) {
-	report := getContainer().getDataQualityReportUseCase.Execute()
+func getDataQualityIssues(writer http.ResponseWriter, request *http.Request) {
+	report := containerFor(request).getDataQualityReportUseCase.Execute()
 	writer.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(writer).Encode(report); err != nil {
 		log.Printf("failed to write data quality response: %v", err)
@@ -37,7 +37,7 @@ func putDataQualityStatsExclusion(writer http.ResponseWriter, request *http.Requ
 		}
 	}
 
-	report, err := getContainer().excludeActivityFromStatsUseCase.Execute(activityID, payload.Reason)
+	report, err := containerFor(request).excludeActivityFromStatsUseCase.Execute(activityID, payload.Reason)
 	if err != nil {
 		writeBadRequest(writer, "Invalid data quality exclusion", err.Error())
 		return
@@ -55,7 +55,7 @@ func deleteDataQualityStatsExclusion(writer http.ResponseWriter, request *http.R
 		return
 	}
 
-	report, err := getContainer().includeActivityInStatsUseCase.Execute(activityID)
+	report, err := containerFor(request).includeActivityInStatsUseCase.Execute(activityID)
 	if err != nil {
 		writeBadRequest(writer, "Invalid data quality exclusion", err.Error())
 		return
@@ -68,7 +68,7 @@ func deleteDataQualityStatsExclusion(writer http.ResponseWriter, request *http.R
 
 func getDataQualityCorrectionPreview(writer http.ResponseWriter, request *http.Request) {
 	issueID := mux.Vars(request)["issueId"]
-	preview, err := getContainer().previewDataQualityCorrectionUseCase.Execute(issueID)
+	preview, err := containerFor(request).previewDataQualityCorrectionUseCase.Execute(issueID)
 	if err != nil {
 		writeBadRequest(writer, "Invalid data quality correction preview", err.Error())
 		return
@@ -79,8 +79,8 @@ func getDataQualityCorrectionPreview(writer http.ResponseWriter, request *http.R
 	}
 }
 
-func getDataQualitySafeCorrectionPreview(writer http.ResponseWriter, _ *http.Request) {
-	preview := getContainer().previewSafeDataQualityCorrectionsUseCase.Execute()
+func getDataQualitySafeCorrectionPreview(writer http.ResponseWriter, request *http.Request) {
+	preview := containerFor(request).previewSafeDataQualityCorrectionsUseCase.Execute()
 	if err := writeJSON(writer, http.StatusOK, preview); err != nil {
 		log.Printf("failed to write data quality safe correction preview response: %v", err)
 		writeInternalServerError(writer, "Failed to encode data quality correction preview response")
@@ -89,7 +89,7 @@ func getDataQualitySafeCorrectionPreview(writer http.ResponseWriter, _ *http.Req
 
 func postDataQualityCorrection(writer http.ResponseWriter, request *http.Request) {
 	issueID := mux.Vars(request)["issueId"]
-	report, err := getContainer().applyDataQualityCorrectionUseCase.Execute(issueID)
+	report, err := containerFor(request).applyDataQualityCorrectionUseCase.Execute(issueID)
 	if err != nil {
 		writeBadRequest(writer, "Invalid data quality correction", err.Error())
 		return
@@ -100,8 +100,8 @@ func postDataQualityCorrection(writer http.ResponseWriter, request *http.Request
 	}
 }
 
-func postDataQualitySafeCorrections(writer http.ResponseWriter, _ *http.Request) {
-	report, err := getContainer().applySafeDataQualityCorrectionsUseCase.Execute()
+func postDataQualitySafeCorrections(writer http.ResponseWriter, request *http.Request) {
+	report, err := containerFor(request).applySafeDataQualityCorrectionsUseCase.Execute()
 	if err != nil {
 		writeBadRequest(writer, "Invalid data quality safe corrections", err.Error())
 		return
@@ -114,7 +114,7 @@ func postDataQualitySafeCorrections(writer http.ResponseWriter, _ *http.Request)
 
 func deleteDataQualityCorrection(writer http.ResponseWriter, request *http.Request) {
 	correctionID := mux.Vars(request)["correctionId"]
-	report, err := getContainer().revertDataQualityCorrectionUseCase.Execute(correctionID)
+	report, err := containerFor(request).revertDataQualityCorrectionUseCase.Execute(correctionID)
 	if err != nil {
 		writeBadRequest(writer, "Invalid data quality correction revert", err.Error())
 		return
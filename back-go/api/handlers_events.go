@@ -0,0 +1,103 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"mystravastats/internal/platform/events"
+)
+
+const eventsHeartbeatInterval = 15 * time.Second
+
+// getEvents streams progress events as server-sent events. Each event is sent
+// with its type as SSE event name and its JSON payload as data. A client
+// reconnecting with Last-Event-ID first receives the retained events it
+// missed.
+func getEvents(writer http.ResponseWriter, request *http.Request) {
+	afterID, err := lastEventID(request)
+	if err != nil {
+		writeBadRequest(writer, "Invalid Last-Event-ID", err.Error())
+		return
+	}
+
+	controller := http.NewResponseController(writer)
+	// The server write timeout would otherwise close the stream after a minute.
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Printf("failed to clear event stream write deadline: %v", err)
+	}
+
+	replay, published, cancel := events.Default().Subscribe(afterID)
+	defer cancel()
+
+	writer.Header().Set("Content-Type", "text/event-stream")
+	writer.Header().Set("Cache-Control", "no-cache")
+	writer.Header().Set("Connection", "keep-alive")
+	writer.Header().Set("X-Accel-Buffering", "no")
+	writer.WriteHeader(http.StatusOK)
+
+	if _, err := io.WriteString(writer, "retry: 3000\n\n"); err != nil {
+		return
+	}
+	for _, event := range replay {
+		if err := writeServerSentEvent(writer, event); err != nil {
+			return
+		}
+	}
+	if err := controller.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(eventsHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-request.Context().Done():
+			return
+		case event := <-published:
+			if err := writeServerSentEvent(writer, event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := io.WriteString(writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// lastEventID reads the Last-Event-ID header sent by a reconnecting
+// EventSource, or the lastEventId query parameter for clients that cannot set
+// headers.
+func lastEventID(request *http.Request) (int64, error) {
+	value := strings.TrimSpace(request.Header.Get("Last-Event-ID"))
+	if value == "" {
+		value = strings.TrimSpace(request.URL.Query().Get("lastEventId"))
+	}
+	if value == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("invalid event id: %q", value)
+	}
+	return id, nil
+}
+
+func writeServerSentEvent(writer io.Writer, event events.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"mystravastats/internal/platform/events"
+)
+
+func TestGetEvents_StreamsMissedAndNewEvents(t *testing.T) {
+	// GIVEN
+	missed := events.Default().Publish(events.Event{Type: events.TypeStarted, Operation: events.OperationFITImport, Total: 2})
+	server := httptest.NewServer(http.HandlerFunc(getEvents))
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Last-Event-ID", strconv.FormatInt(missed.ID-1, 10))
+
+	// WHEN
+	response, err := server.Client().Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	reader := bufio.NewReader(response.Body)
+	replayed := readServerSentEvent(t, reader)
+	published := events.Default().Publish(events.Event{RunID: missed.RunID, Type: events.TypeCompleted, Operation: events.OperationFITImport})
+	streamed := readServerSentEvent(t, reader)
+
+	// THEN
+	if contentType := response.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf("expected text/event-stream, got %q", contentType)
+	}
+	if replayed.ID != missed.ID || replayed.Type != events.TypeStarted || replayed.Total != 2 {
+		t.Fatalf("expected missed started event %d, got %#v", missed.ID, replayed)
+	}
+	if streamed.ID != published.ID || streamed.RunID != missed.RunID || streamed.Type != events.TypeCompleted {
+		t.Fatalf("expected completed event %d of run %d, got %#v", published.ID, missed.RunID, streamed)
+	}
+}
+
+func TestGetEvents_RejectsInvalidLastEventID(t *testing.T) {
+	// GIVEN
+	request := httptest.NewRequest(http.MethodGet, "/api/events?lastEventId=abc", nil)
+	recorder := httptest.NewRecorder()
+
+	// WHEN
+	getEvents(recorder, request)
+
+	// THEN
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", recorder.Code)
+	}
+}
+
+func readServerSentEvent(t *testing.T, reader *bufio.Reader) events.Event {
+	t.Helper()
+	var name string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("failed to read event stream: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			var event events.Event
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event); err != nil {
+				t.Fatalf("expected JSON event data, got %v: %s", err, line)
+			}
+			if event.Type != name {
+				t.Fatalf("expected SSE event name %q to match type %q", name, event.Type)
+			}
+			return event
+		}
+	}
+}
@@ -16,7 +16,7 @@ func getGearAnalysisByActivityType(writer http.ResponseWriter, request *http.Req
 		return
 	}
 
-	analysis := getContainer().getGearAnalysisUseCase.Execute(year, activityTypes)
+	analysis := containerFor(request).getGearAnalysisUseCase.Execute(year, activityTypes)
 	analysisDto := dto.ToGearAnalysisDto(analysis)
 
 	if err := writeJSON(writer, http.StatusOK, analysisDto); err != nil {
@@ -35,7 +35,7 @@ func postGearMaintenanceRecord(writer http.ResponseWriter, request *http.Request
 		}
 	}
 
-	record, err := getContainer().saveGearMaintenanceRecordUseCase.Execute(dto.ToGearMaintenanceRecordRequest(payload))
+	record, err := containerFor(request).saveGearMaintenanceRecordUseCase.Execute(dto.ToGearMaintenanceRecordRequest(payload))
 	if err != nil {
 		writeBadRequest(writer, "Invalid gear maintenance record", err.Error())
 		return
@@ -48,7 +48,7 @@ func postGearMaintenanceRecord(writer http.ResponseWriter, request *http.Request
 
 func deleteGearMaintenanceRecord(writer http.ResponseWriter, request *http.Request) {
 	recordID := mux.Vars(request)["recordId"]
-	if err := getContainer().deleteGearMaintenanceRecordUseCase.Execute(recordID); err != nil {
+	if err := containerFor(request).deleteGearMaintenanceRecordUseCase.Execute(recordID); err != nil {
 		writeBadRequest(writer, "Invalid gear maintenance record", err.Error())
 		return
 	}
@@ -13,8 +13,8 @@ import (
 // @Success 200 {object} map[string]interface{}
 // @Failure 500 {string} string "Internal server error"
 // @Router /api/health/details [get]
-func getHealthDetails(writer http.ResponseWriter, _ *http.Request) {
-	details := getContainer().getCacheHealthDetailsUseCase.Execute()
+func getHealthDetails(writer http.ResponseWriter, request *http.Request) {
+	details := containerFor(request).getCacheHealthDetailsUseCase.Execute()
 	if err := writeJSON(writer, http.StatusOK, details); err != nil {
 		log.Printf("failed to write cache health response: %v", err)
 		writeInternalServerError(writer, "Failed to encode cache health response")
@@ -22,7 +22,7 @@ func getRouteRecommendationsByActivityType(writer http.ResponseWriter, request *
 		return
 	}
 
-	explorer := getContainer().getRouteExplorerUseCase.Execute(year, req, activityTypes)
+	explorer := containerFor(request).getRouteExplorerUseCase.Execute(year, req, activityTypes)
 	explorerDto := dto.ToRouteExplorerResultDto(explorer)
 	if err := writeJSON(writer, http.StatusOK, explorerDto); err != nil {
 		log.Printf("failed to write routes explorer response: %v", err)
@@ -43,7 +43,7 @@ func getRouteRecommendationGPXByActivityType(writer http.ResponseWriter, request
 		return
 	}
 
-	explorer := getContainer().getRouteExplorerUseCase.Execute(year, req, activityTypes)
+	explorer := containerFor(request).getRouteExplorerUseCase.Execute(year, req, activityTypes)
 	name, points, found := findRouteForGPXExport(explorer, routeID)
 	if !found {
 		writeNotFound(writer, "Route not found", fmt.Sprintf("No route found for routeId=%s with current filters", routeID))
@@ -109,7 +109,7 @@ func generateShapeRoutesByActivityType(writer http.ResponseWriter, request *http
 		ShapePolyline: optionalNonEmptyString(strings.TrimSpace(payload.ShapeData)),
 		IncludeRemix:  true,
 	}
-	result := getContainer().getRouteExplorerUseCase.Execute(year, req, activityTypes)
+	result := containerFor(request).getRouteExplorerUseCase.Execute(year, req, activityTypes)
 	response := buildShapeGeneratedRoutesResponse(
 		result,
 		routeType,
@@ -159,7 +159,7 @@ func editGeneratedRouteByID(writer http.ResponseWriter, request *http.Request) {
 	for _, point := range payload.ControlPoints {
 		controlPoints = append(controlPoints, routesDomain.Coordinates{Lat: point.Lat, Lng: point.Lng})
 	}
-	editResult, err := getContainer().routingEngine.EditRoute(routesApp.RoutingEngineEditRequest{
+	editResult, err := containerFor(request).routingEngine.EditRoute(routesApp.RoutingEngineEditRequest{
 		RouteID:       routeID,
 		RouteType:     routeType,
 		ControlPoints: controlPoints,
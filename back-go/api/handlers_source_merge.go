@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"mystravastats/internal/shared/domain/business"
+
+	"github.com/gorilla/mux"
+)
+
+func getSourceMergeOverrides(writer http.ResponseWriter, request *http.Request) {
+	overrides, err := containerFor(request).getSourceMergeOverridesUseCase.Execute()
+	writeSourceMergeOverrides(writer, overrides, err)
+}
+
+func postSourceMergeLink(writer http.ResponseWriter, request *http.Request) {
+	var linkRequest business.SourceMergeLinkRequest
+	if err := json.NewDecoder(request.Body).Decode(&linkRequest); err != nil {
+		writeBadRequest(writer, "Invalid request body", err.Error())
+		return
+	}
+	overrides, err := containerFor(request).linkSourceActivitiesUseCase.Execute(linkRequest)
+	writeSourceMergeOverrides(writer, overrides, err)
+}
+
+func postSourceMergeSplit(writer http.ResponseWriter, request *http.Request) {
+	var source business.SourceActivityKey
+	if err := json.NewDecoder(request.Body).Decode(&source); err != nil {
+		writeBadRequest(writer, "Invalid request body", err.Error())
+		return
+	}
+	overrides, err := containerFor(request).splitSourceActivityUseCase.Execute(source)
+	writeSourceMergeOverrides(writer, overrides, err)
+}
+
+func putSourceMergeFieldSources(writer http.ResponseWriter, request *http.Request) {
+	var fieldsRequest business.SourceMergeFieldSourcesRequest
+	if err := json.NewDecoder(request.Body).Decode(&fieldsRequest); err != nil {
+		writeBadRequest(writer, "Invalid request body", err.Error())
+		return
+	}
+	overrides, err := containerFor(request).updateDefaultFieldSourcesUseCase.Execute(fieldsRequest)
+	writeSourceMergeOverrides(writer, overrides, err)
+}
+
+func putSourceMergeActivityFieldSources(writer http.ResponseWriter, request *http.Request) {
+	activityID, err := strconv.ParseInt(mux.Vars(request)["activityId"], 10, 64)
+	if err != nil || activityID <= 0 {
+		writeBadRequest(writer, "Invalid request parameters", "invalid activityId")
+		return
+	}
+	var fieldsRequest business.SourceMergeFieldSourcesRequest
+	if err := json.NewDecoder(request.Body).Decode(&fieldsRequest); err != nil {
+		writeBadRequest(writer, "Invalid request body", err.Error())
+		return
+	}
+	overrides, err := containerFor(request).updateActivityFieldSourcesUseCase.Execute(activityID, fieldsRequest)
+	writeSourceMergeOverrides(writer, overrides, err)
+}
+
+func deleteSourceMergeActivity(writer http.ResponseWriter, request *http.Request) {
+	activityID, err := strconv.ParseInt(mux.Vars(request)["activityId"], 10, 64)
+	if err != nil || activityID <= 0 {
+		writeBadRequest(writer, "Invalid request parameters", "invalid activityId")
+		return
+	}
+	overrides, err := containerFor(request).resetActivitySourceMergeUseCase.Execute(activityID)
+	writeSourceMergeOverrides(writer, overrides, err)
+}
+
+func writeSourceMergeOverrides(writer http.ResponseWriter, overrides business.SourceMergeOverrides, err error) {
+	if err != nil {
+		writeBadRequest(writer, "Invalid source merge override", err.Error())
+		return
+	}
+	if err := writeJSON(writer, http.StatusOK, overrides); err != nil {
+		log.Printf("failed to write source merge overrides response: %v", err)
+		writeInternalServerError(writer, "Failed to encode source merge overrides response")
+	}
+}
+
+// getSourcesCoverage lists, per year, the activities present in one source but
+// missing from another.
+func getSourcesCoverage(writer http.ResponseWriter, request *http.Request) {
+	year, err := getYearParam(request)
+	if err != nil {
+		writeBadRequest(writer, "Invalid request parameters", err.Error())
+		return
+	}
+	report, err := containerFor(request).getSourceCoverageUseCase.Execute(year)
+	if err != nil {
+		writeBadRequest(writer, "Source coverage unavailable", err.Error())
+		return
+	}
+	if err := writeJSON(writer, http.StatusOK, report); err != nil {
+		log.Printf("failed to write source coverage response: %v", err)
+		writeInternalServerError(writer, "Failed to encode source coverage response")
+	}
+}
@@ -9,10 +9,11 @@ import (
 	"log"
 	"mystravastats/internal/platform/runtimeconfig"
 	"mystravastats/internal/shared/domain/business"
+	"mystravastats/internal/shared/infrastructure/secretstore"
+	"mystravastats/internal/shared/infrastructure/stravaapi"
 	"net"
 	"net/http"
 	"net/url"
-	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -22,7 +23,6 @@ import (
 
 const stravaOAuthSettingsURL = "https://www.strava.com/settings/api"
 const stravaOAuthTokenURL = "https://www.strava.com/oauth/token"
-const stravaOAuthScope = "read_all,activity:read_all,profile:read_all"
 const stravaOAuthSessionTTL = 10 * time.Minute
 
 var stravaClientIDPattern = regexp.MustCompile(`^\d+$`)
@@ -184,7 +184,7 @@ func completeStravaOAuthEnrollment(query url.Values) (string, int) {
 		return stravaOAuthHTML("Authorization failed", err.Error()), http.StatusBadGateway
 	}
 	if scope == "" {
-		scope = stravaOAuthScope
+		scope = stravaapi.OAuthScope()
 	}
 	token["scope"] = scope
 	athlete, err := fetchStravaAthlete(fmt.Sprint(token["access_token"]))
@@ -201,7 +201,7 @@ func completeStravaOAuthEnrollment(query url.Values) (string, int) {
 }
 
 func readStravaCredentials(path string) (clientID string, clientSecret string, useCache bool) {
-	data, err := os.ReadFile(filepath.Join(path, ".strava"))
+	data, err := secretstore.ReadFile(filepath.Join(path, ".strava"))
 	if err != nil {
 		return "", "", false
 	}
@@ -223,15 +223,8 @@ func readStravaCredentials(path string) (clientID string, clientSecret string, u
 }
 
 func writeStravaCredentials(path string, clientID string, clientSecret string, useCache bool) error {
-	if err := os.MkdirAll(path, 0o700); err != nil {
-		return err
-	}
 	content := fmt.Sprintf("clientId=%s\nclientSecret=%s\nuseCache=%t\n", clientID, clientSecret, useCache)
-	file := filepath.Join(path, ".strava")
-	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
-		return err
-	}
-	return os.Chmod(file, 0o600)
+	return secretstore.WriteFile(filepath.Join(path, ".strava"), []byte(content))
 }
 
 func stravaOAuthCallbackURLFromRequest(request *http.Request) string {
@@ -248,7 +241,7 @@ func stravaAuthorizeURL(clientID string, callbackURL string, state string) strin
 	values.Set("response_type", "code")
 	values.Set("redirect_uri", callbackURL)
 	values.Set("approval_prompt", "auto")
-	values.Set("scope", stravaOAuthScope)
+	values.Set("scope", stravaapi.OAuthScope())
 	values.Set("state", state)
 	return "https://www.strava.com/oauth/authorize?" + values.Encode()
 }
@@ -262,7 +255,7 @@ func missingStravaOAuthScopes(scope string) []string {
 		granted[strings.TrimSpace(part)] = true
 	}
 	missing := make([]string, 0)
-	for _, required := range strings.Split(stravaOAuthScope, ",") {
+	for _, required := range stravaapi.ReadScopes() {
 		if !granted[required] {
 			missing = append(missing, required)
 		}
@@ -321,18 +314,12 @@ func fetchStravaAthlete(accessToken string) (map[string]any, error) {
 }
 
 func writePrivateJSON(path string, payload map[string]any) error {
-	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
-		return err
-	}
 	data, err := json.MarshalIndent(payload, "", "  ")
 	if err != nil {
 		return err
 	}
 	data = append(data, '\n')
-	if err := os.WriteFile(path, data, 0o600); err != nil {
-		return err
-	}
-	return os.Chmod(path, 0o600)
+	return secretstore.WriteFile(path, data)
 }
 
 func newStravaOAuthState() (string, error) {
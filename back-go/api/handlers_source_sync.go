@@ -1,14 +1,121 @@
 package api
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"mime"
 	"net/http"
+	"os"
+	"strings"
 
 	"mystravastats/internal/sourcesync"
 )
 
 func postSourceSyncSynchronize(writer http.ResponseWriter, _ *http.Request) {
 	result := sourcesync.Synchronize("manual")
+	writeSourceSyncResult(writer, result)
+}
+
+// postSourceSyncStravaReconcile reconciles the cached past Strava years with
+// Strava on demand, the way STRAVA_RECONCILE_SCHEDULE does on a schedule.
+func postSourceSyncStravaReconcile(writer http.ResponseWriter, _ *http.Request) {
+	if err := writeJSON(writer, http.StatusOK, sourcesync.ReconcileStrava()); err != nil {
+		log.Printf("failed to write Strava reconciliation response: %v", err)
+		writeInternalServerError(writer, "Failed to encode Strava reconciliation response")
+	}
+}
+
+func getSourceSyncStatus(writer http.ResponseWriter, _ *http.Request) {
+	if err := writeJSON(writer, http.StatusOK, sourcesync.Status()); err != nil {
+		log.Printf("failed to write source synchronization status response: %v", err)
+		writeInternalServerError(writer, "Failed to encode source synchronization status response")
+	}
+}
+
+// getSourceSyncHistory returns the persisted synchronization runs, newest
+// first. The optional limit query parameter caps the number of entries.
+func getSourceSyncHistory(writer http.ResponseWriter, request *http.Request) {
+	limit, err := getIntParam(request, "limit")
+	if err != nil || (limit != nil && *limit < 0) {
+		writeBadRequest(writer, "Invalid limit", "limit must be a positive integer")
+		return
+	}
+	maxEntries := 0
+	if limit != nil {
+		maxEntries = *limit
+	}
+	if err := writeJSON(writer, http.StatusOK, sourcesync.History(maxEntries)); err != nil {
+		log.Printf("failed to write source synchronization history response: %v", err)
+		writeInternalServerError(writer, "Failed to encode source synchronization history response")
+	}
+}
+
+type stravaArchiveImportRequest struct {
+	Path string `json:"path"`
+}
+
+// postSourceSyncStravaArchive imports a Strava bulk-export archive, either
+// referenced by a path inside a source folder of the athlete or sent as an
+// application/zip body of at most sourcesync.MaxArchiveUploadBytes.
+func postSourceSyncStravaArchive(writer http.ResponseWriter, request *http.Request) {
+	request.Body = http.MaxBytesReader(writer, request.Body, sourcesync.MaxArchiveUploadBytes)
+	mediaType, _, _ := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	if mediaType == "application/zip" || mediaType == "application/octet-stream" {
+		archivePath, err := spoolStravaArchive(request.Body)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeAPIError(writer, http.StatusRequestEntityTooLarge, "Archive too large",
+				fmt.Sprintf("uploads are limited to %d MiB; copy the archive into a source folder and import it by path", tooLarge.Limit>>20))
+			return
+		}
+		if err != nil {
+			writeBadRequest(writer, "Invalid archive", err.Error())
+			return
+		}
+		defer os.Remove(archivePath)
+		writeSourceSyncResult(writer, sourcesync.ImportStravaArchive(requestAthleteID(request), archivePath))
+		return
+	}
+
+	var importRequest stravaArchiveImportRequest
+	if err := json.NewDecoder(request.Body).Decode(&importRequest); err != nil {
+		writeBadRequest(writer, "Invalid request body", err.Error())
+		return
+	}
+	if strings.TrimSpace(importRequest.Path) == "" {
+		writeBadRequest(writer, "Missing archive", "path must reference the Strava export ZIP file")
+		return
+	}
+	athleteID := requestAthleteID(request)
+	archivePath, err := sourcesync.ResolveArchivePath(athleteID, importRequest.Path)
+	if err != nil {
+		writeAPIError(writer, http.StatusForbidden, "Archive path not allowed", err.Error())
+		return
+	}
+	writeSourceSyncResult(writer, sourcesync.ImportStravaArchive(athleteID, archivePath))
+}
+
+func spoolStravaArchive(body io.Reader) (string, error) {
+	file, err := os.CreateTemp("", "strava-archive-*.zip")
+	if err != nil {
+		return "", err
+	}
+	_, copyErr := io.Copy(file, body)
+	closeErr := file.Close()
+	if copyErr != nil || closeErr != nil {
+		_ = os.Remove(file.Name())
+		if copyErr != nil {
+			return "", copyErr
+		}
+		return "", closeErr
+	}
+	return file.Name(), nil
+}
+
+func writeSourceSyncResult(writer http.ResponseWriter, result sourcesync.SyncResult) {
 	status := http.StatusOK
 	if result.Status == "failed" {
 		status = http.StatusInternalServerError
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mystravastats/internal/sourcesync"
+)
+
+func TestPostSourceSyncStravaArchiveRejectsPathsOutsideSourceFolders(t *testing.T) {
+	// GIVEN
+	t.Setenv("FIT_FILES_PATH", t.TempDir())
+	body := strings.NewReader(`{"path":"/etc/passwd"}`)
+	request := httptest.NewRequest(http.MethodPost, "/api/source-sync/strava-archive", body)
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+
+	// WHEN
+	postSourceSyncStravaArchive(recorder, request)
+
+	// THEN
+	if recorder.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestPostSourceSyncStravaArchiveResolvesPathsAgainstTheSelectedAthlete(t *testing.T) {
+	// GIVEN
+	fitDirectory := t.TempDir()
+	t.Setenv("FIT_FILES_PATH", fitDirectory)
+	setTestAthleteContainer(t, "bob", athleteTestContainer(2))
+	body := strings.NewReader(`{"path":"` + filepath.ToSlash(filepath.Join(fitDirectory, "export.zip")) + `"}`)
+	request := httptest.NewRequest(http.MethodPost, "/api/athletes/bob/source-sync/strava-archive", body)
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+
+	// WHEN
+	NewRouter().ServeHTTP(recorder, request)
+
+	// THEN
+	if recorder.Code != http.StatusForbidden {
+		t.Fatalf("expected the default athlete's folder to be refused for bob, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestPostSourceSyncStravaArchiveLimitsUploadSize(t *testing.T) {
+	// GIVEN
+	body := io.LimitReader(zeroReader{}, sourcesync.MaxArchiveUploadBytes+1)
+	request := httptest.NewRequest(http.MethodPost, "/api/source-sync/strava-archive", body)
+	request.Header.Set("Content-Type", "application/zip")
+	recorder := httptest.NewRecorder()
+
+	// WHEN
+	postSourceSyncStravaArchive(recorder, request)
+
+	// THEN
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+// zeroReader streams zeros, so that an oversized body is not held in memory.
+type zeroReader struct{}
+
+func (zeroReader) Read(buffer []byte) (int, error) {
+	clear(buffer)
+	return len(buffer), nil
+}
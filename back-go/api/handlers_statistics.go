@@ -1,6 +1,8 @@
 package api
 
 import (
+	"encoding/json"
+	"fmt"
 	"log"
 	"mystravastats/api/dto"
 	"net/http"
@@ -13,6 +15,10 @@ import (
 // @Produce json
 // @Param year query int false "Year"
 // @Param activityType query string true "Activity type"
+// @Param source query string false "Source filter, e.g. fit,no-strava"
+// @Param from query string false "First day of the date range (YYYY-MM-DD)"
+// @Param to query string false "Last day of the date range (YYYY-MM-DD)"
+// @Param last query string false "Rolling date range ending today, e.g. 90d, 12w, 6m or 1y"
 // @Success 200 {array} dto.StatisticDto
 // @Failure 400 {string} string "Invalid parameters"
 // @Failure 500 {string} string "Internal server error"
@@ -24,7 +30,7 @@ func getStatisticsByActivityType(writer http.ResponseWriter, request *http.Reque
 		return
 	}
 
-	statistics := getContainer().listStatisticsUseCase.Execute(year, activityTypes)
+	statistics := containerFor(request).listStatisticsUseCase.Execute(year, activityTypes)
 	statisticsDto := make([]dto.StatisticDto, len(statistics))
 	for i, statistic := range statistics {
 		statisticsDto[i] = dto.ToStatisticDto(statistic)
@@ -43,6 +49,10 @@ func getStatisticsByActivityType(writer http.ResponseWriter, request *http.Reque
 // @Produce json
 // @Param year query int false "Year"
 // @Param activityType query string true "Activity type"
+// @Param source query string false "Source filter, e.g. fit,no-strava"
+// @Param from query string false "First day of the date range (YYYY-MM-DD)"
+// @Param to query string false "Last day of the date range (YYYY-MM-DD)"
+// @Param last query string false "Rolling date range ending today, e.g. 90d, 12w, 6m or 1y"
 // @Param metric query string false "Metric key"
 // @Success 200 {array} dto.PersonalRecordTimelineDto
 // @Failure 400 {string} string "Invalid parameters"
@@ -56,7 +66,7 @@ func getPersonalRecordsTimelineByActivityType(writer http.ResponseWriter, reques
 	}
 	metric := getMetricParam(request)
 
-	timeline := getContainer().listPersonalRecordsTimelineUseCase.Execute(year, metric, activityTypes)
+	timeline := containerFor(request).listPersonalRecordsTimelineUseCase.Execute(year, metric, activityTypes)
 	timelineDto := make([]dto.PersonalRecordTimelineDto, len(timeline))
 	for i, entry := range timeline {
 		timelineDto[i] = dto.ToPersonalRecordTimelineDto(entry)
@@ -68,6 +78,103 @@ func getPersonalRecordsTimelineByActivityType(writer http.ResponseWriter, reques
 	}
 }
 
+// getPowerCurveByActivityType godoc
+// @Summary Get power curve and critical power model
+// @Description Returns the mean-maximal power curve from 1 s to 5 h with the fitted 2-parameter and Morton 3-parameter critical power models
+// @Tags statistics
+// @Produce json
+// @Param year query int false "Year"
+// @Param activityType query string true "Activity type"
+// @Param source query string false "Source filter, e.g. fit,no-strava"
+// @Param from query string false "First day of the date range (YYYY-MM-DD)"
+// @Param to query string false "Last day of the date range (YYYY-MM-DD)"
+// @Param last query string false "Rolling date range ending today, e.g. 90d, 12w, 6m or 1y"
+// @Param windowDays query int false "Only the last days before the most recent activity"
+// @Param activityId query int false "Curve of a single activity"
+// @Success 200 {object} dto.PowerCurveDto
+// @Failure 400 {string} string "Invalid parameters"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/statistics/power-curve [get]
+func getPowerCurveByActivityType(writer http.ResponseWriter, request *http.Request) {
+	year, activityTypes, err := parseActivityRequestParams(request)
+	if err != nil {
+		writeBadRequest(writer, "Invalid request parameters", err.Error())
+		return
+	}
+	windowDays, err := getIntParam(request, "windowDays")
+	if err == nil && windowDays != nil && *windowDays < 0 {
+		err = fmt.Errorf("windowDays must not be negative")
+	}
+	if err != nil {
+		writeBadRequest(writer, "Invalid request parameters", err.Error())
+		return
+	}
+	activityID, err := getActivityIDParam(request)
+	if err != nil {
+		writeBadRequest(writer, "Invalid request parameters", err.Error())
+		return
+	}
+
+	days := 0
+	if windowDays != nil {
+		days = *windowDays
+	}
+	curve := containerFor(request).getPowerCurveUseCase.Execute(year, days, activityID, activityTypes)
+	if err := writeJSON(writer, http.StatusOK, dto.ToPowerCurveDto(curve)); err != nil {
+		log.Printf("failed to write power curve response: %v", err)
+		writeInternalServerError(writer, "Failed to encode power curve response")
+	}
+}
+
+// getPaceCurveByActivityType godoc
+// @Summary Get pace curve and race predictions
+// @Description Returns the best pace curve from 100 m to the marathon and, for runs, the Riegel, Cameron and VDOT race predictions with the monthly VDOT trend
+// @Tags statistics
+// @Produce json
+// @Param year query int false "Year"
+// @Param activityType query string true "Activity type"
+// @Param source query string false "Source filter, e.g. fit,no-strava"
+// @Param from query string false "First day of the date range (YYYY-MM-DD)"
+// @Param to query string false "Last day of the date range (YYYY-MM-DD)"
+// @Param last query string false "Rolling date range ending today, e.g. 90d, 12w, 6m or 1y"
+// @Param windowDays query int false "Only the last days before the most recent activity"
+// @Param gradeAdjusted query bool false "Build the curve on the grade-adjusted pace"
+// @Success 200 {object} dto.PaceCurveDto
+// @Failure 400 {string} string "Invalid parameters"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/statistics/pace-curve [get]
+func getPaceCurveByActivityType(writer http.ResponseWriter, request *http.Request) {
+	year, activityTypes, err := parseActivityRequestParams(request)
+	if err != nil {
+		writeBadRequest(writer, "Invalid request parameters", err.Error())
+		return
+	}
+	windowDays, err := getIntParam(request, "windowDays")
+	if err == nil && windowDays != nil && *windowDays < 0 {
+		err = fmt.Errorf("windowDays must not be negative")
+	}
+	if err != nil {
+		writeBadRequest(writer, "Invalid request parameters", err.Error())
+		return
+	}
+
+	gradeAdjusted, err := getBoolParam(request, "gradeAdjusted")
+	if err != nil {
+		writeBadRequest(writer, "Invalid request parameters", err.Error())
+		return
+	}
+
+	days := 0
+	if windowDays != nil {
+		days = *windowDays
+	}
+	curve := containerFor(request).getPaceCurveUseCase.Execute(year, days, gradeAdjusted != nil && *gradeAdjusted, activityTypes)
+	if err := writeJSON(writer, http.StatusOK, dto.ToPaceCurveDto(curve)); err != nil {
+		log.Printf("failed to write pace curve response: %v", err)
+		writeInternalServerError(writer, "Failed to encode pace curve response")
+	}
+}
+
 func getHeartRateZoneAnalysisByActivityType(writer http.ResponseWriter, request *http.Request) {
 	year, activityTypes, err := parseActivityRequestParams(request)
 	if err != nil {
@@ -75,7 +182,7 @@ func getHeartRateZoneAnalysisByActivityType(writer http.ResponseWriter, request
 		return
 	}
 
-	analysis := getContainer().getHeartRateZoneAnalysisUseCase.Execute(year, activityTypes)
+	analysis := containerFor(request).getHeartRateZoneAnalysisUseCase.Execute(year, activityTypes)
 	analysisDto := dto.ToHeartRateZoneAnalysisDto(analysis)
 
 	if err := writeJSON(writer, http.StatusOK, analysisDto); err != nil {
@@ -91,6 +198,10 @@ func getHeartRateZoneAnalysisByActivityType(writer http.ResponseWriter, request
 // @Produce json
 // @Param year query int false "Year"
 // @Param activityType query string true "Activity type"
+// @Param source query string false "Source filter, e.g. fit,no-strava"
+// @Param from query string false "First day of the date range (YYYY-MM-DD)"
+// @Param to query string false "Last day of the date range (YYYY-MM-DD)"
+// @Param last query string false "Rolling date range ending today, e.g. 90d, 12w, 6m or 1y"
 // @Param metric query string false "Metric (TIME or SPEED)"
 // @Param targetType query string false "Target type filter (ALL, SEGMENT, CLIMB)"
 // @Param targetId query int false "Target id"
@@ -112,7 +223,7 @@ func getSegmentClimbProgressionByActivityType(writer http.ResponseWriter, reques
 		return
 	}
 
-	progression := getContainer().getSegmentClimbProgressionUseCase.Execute(year, metric, targetType, targetId, activityTypes)
+	progression := containerFor(request).getSegmentClimbProgressionUseCase.Execute(year, metric, targetType, targetId, activityTypes)
 	progressionDto := dto.ToSegmentClimbProgressionDto(progression)
 
 	if err := writeJSON(writer, http.StatusOK, progressionDto); err != nil {
@@ -140,7 +251,7 @@ func getSegmentsByActivityType(writer http.ResponseWriter, request *http.Request
 		return
 	}
 
-	segments := getContainer().listSegmentsUseCase.Execute(year, metric, query, from, to, activityTypes)
+	segments := containerFor(request).listSegmentsUseCase.Execute(year, metric, query, from, to, activityTypes)
 	segmentsDto := make([]dto.SegmentClimbTargetSummaryDto, len(segments))
 	for i, segment := range segments {
 		segmentsDto[i] = dto.ToSegmentClimbTargetSummaryDto(segment)
@@ -175,7 +286,7 @@ func getSegmentEffortsByActivityType(writer http.ResponseWriter, request *http.R
 		return
 	}
 
-	efforts := getContainer().listSegmentEffortsUseCase.Execute(year, metric, segmentID, from, to, activityTypes)
+	efforts := containerFor(request).listSegmentEffortsUseCase.Execute(year, metric, segmentID, from, to, activityTypes)
 	effortsDto := make([]dto.SegmentClimbAttemptDto, len(efforts))
 	for i, effort := range efforts {
 		effortsDto[i] = dto.ToSegmentClimbAttemptDto(effort)
@@ -210,7 +321,7 @@ func getSegmentSummaryByActivityType(writer http.ResponseWriter, request *http.R
 		return
 	}
 
-	summary := getContainer().getSegmentSummaryUseCase.Execute(year, metric, segmentID, from, to, activityTypes)
+	summary := containerFor(request).getSegmentSummaryUseCase.Execute(year, metric, segmentID, from, to, activityTypes)
 	if summary == nil {
 		writeNotFound(writer, "Segment not found", "No attempts found for this segment with current filters")
 		return
@@ -238,3 +349,63 @@ func getSegmentSummaryByActivityType(writer http.ResponseWriter, request *http.R
 		writeInternalServerError(writer, "Failed to encode segment summary response")
 	}
 }
+
+// getCustomStatisticsByActivityType godoc
+// @Summary Get custom statistic definitions
+// @Description Returns the user-defined statistics evaluated with the built-in ones for an activity type
+// @Tags statistics
+// @Produce json
+// @Param activityType query string true "Activity type"
+// @Success 200 {array} dto.CustomStatisticDefinitionDto
+// @Failure 400 {string} string "Invalid parameters"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/statistics/custom [get]
+func getCustomStatisticsByActivityType(writer http.ResponseWriter, request *http.Request) {
+	_, activityTypes, err := parseActivityRequestParams(request)
+	if err != nil {
+		writeBadRequest(writer, "Invalid request parameters", err.Error())
+		return
+	}
+
+	definitions := containerFor(request).getCustomStatisticsUseCase.Execute(activityTypes)
+	if err := writeJSON(writer, http.StatusOK, dto.ToCustomStatisticDefinitionDtos(definitions)); err != nil {
+		log.Printf("failed to write custom statistics response: %v", err)
+		writeInternalServerError(writer, "Failed to encode custom statistics response")
+	}
+}
+
+// putCustomStatisticsByActivityType godoc
+// @Summary Save custom statistic definitions
+// @Description Replaces the user-defined statistics of an activity type after checking every expression
+// @Tags statistics
+// @Accept json
+// @Produce json
+// @Param activityType query string true "Activity type"
+// @Param definitions body []dto.CustomStatisticDefinitionDto true "Custom statistic definitions"
+// @Success 200 {array} dto.CustomStatisticDefinitionDto
+// @Failure 400 {string} string "Invalid parameters or expression"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/statistics/custom [put]
+func putCustomStatisticsByActivityType(writer http.ResponseWriter, request *http.Request) {
+	_, activityTypes, err := parseActivityRequestParams(request)
+	if err != nil {
+		writeBadRequest(writer, "Invalid request parameters", err.Error())
+		return
+	}
+
+	var requestDto []dto.CustomStatisticDefinitionDto
+	if err := json.NewDecoder(request.Body).Decode(&requestDto); err != nil {
+		writeBadRequest(writer, "Invalid request body", err.Error())
+		return
+	}
+
+	definitions, err := containerFor(request).updateCustomStatisticsUseCase.Execute(dto.ToCustomStatisticDefinitions(requestDto), activityTypes)
+	if err != nil {
+		writeBadRequest(writer, "Invalid custom statistic", err.Error())
+		return
+	}
+	if err := writeJSON(writer, http.StatusOK, dto.ToCustomStatisticDefinitionDtos(definitions)); err != nil {
+		log.Printf("failed to write custom statistics response: %v", err)
+		writeInternalServerError(writer, "Failed to encode custom statistics response")
+	}
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"mystravastats/internal/shared/domain/business"
+	"mystravastats/internal/shared/infrastructure/stravaapi"
+)
+
+// maxStravaUploadsPerRequest bounds how long one request can block: every
+// upload is polled until Strava has processed the file.
+const maxStravaUploadsPerRequest = 25
+
+func postStravaUploads(writer http.ResponseWriter, request *http.Request) {
+	if !stravaapi.UploadEnabled() {
+		writeAPIError(writer, http.StatusForbidden, "Strava upload disabled", "set "+stravaapi.UploadEnabledEnv+"=true and connect Strava again to grant activity:write")
+		return
+	}
+	var uploadRequest business.StravaUploadRequest
+	if err := json.NewDecoder(request.Body).Decode(&uploadRequest); err != nil {
+		writeBadRequest(writer, "Invalid request body", err.Error())
+		return
+	}
+	if len(uploadRequest.ActivityIDs) == 0 {
+		writeBadRequest(writer, "Missing activities", "activityIds must list at least one activity")
+		return
+	}
+	if len(uploadRequest.ActivityIDs) > maxStravaUploadsPerRequest {
+		writeBadRequest(writer, "Too many activities", fmt.Sprintf("upload at most %d activities per request", maxStravaUploadsPerRequest))
+		return
+	}
+
+	result := containerFor(request).uploadStravaActivitiesUseCase.Execute(uploadRequest)
+	if err := writeJSON(writer, http.StatusOK, result); err != nil {
+		log.Printf("failed to write Strava upload response: %v", err)
+		writeInternalServerError(writer, "Failed to encode Strava upload response")
+	}
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"mystravastats/internal/platform/activityprovider"
+	"mystravastats/internal/platform/runtimeconfig"
+	"mystravastats/internal/shared/infrastructure/stravaapi"
+)
+
+const (
+	stravaWebhookVerifyTokenEnv    = "STRAVA_WEBHOOK_VERIFY_TOKEN"
+	stravaWebhookSubscriptionIDEnv = "STRAVA_WEBHOOK_SUBSCRIPTION_ID"
+)
+
+type stravaWebhookReceiver interface {
+	EnqueueWebhookEvent(event stravaapi.WebhookEvent) bool
+}
+
+// stravaWebhookReceivers is replaced in tests to avoid building the providers.
+// Strava sends every event of an application to the same callback, so each
+// loaded athlete is offered the event and keeps only its own activities.
+var stravaWebhookReceivers = func() []stravaWebhookReceiver {
+	receivers := make([]stravaWebhookReceiver, 0)
+	for _, provider := range activityprovider.Loaded() {
+		if stravaProvider, ok := activityprovider.StravaOf(provider); ok {
+			receivers = append(receivers, stravaProvider)
+		}
+	}
+	return receivers
+}
+
+// getStravaWebhook answers the subscription validation request Strava sends
+// when a push subscription is created.
+func getStravaWebhook(writer http.ResponseWriter, request *http.Request) {
+	verifyToken := strings.TrimSpace(runtimeconfig.StringValue(stravaWebhookVerifyTokenEnv, ""))
+	if verifyToken == "" {
+		writeNotFound(writer, "Strava webhook is not configured", "Set "+stravaWebhookVerifyTokenEnv+" to enable push subscriptions")
+		return
+	}
+
+	query := request.URL.Query()
+	if query.Get("hub.mode") != "subscribe" {
+		writeBadRequest(writer, "Invalid Strava webhook validation", "hub.mode must be subscribe")
+		return
+	}
+	challenge := query.Get("hub.challenge")
+	if challenge == "" {
+		writeBadRequest(writer, "Invalid Strava webhook validation", "hub.challenge is required")
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(query.Get("hub.verify_token")), []byte(verifyToken)) != 1 {
+		writeAPIError(writer, http.StatusForbidden, "Invalid Strava webhook verify token", "hub.verify_token does not match "+stravaWebhookVerifyTokenEnv)
+		return
+	}
+
+	if err := writeJSON(writer, http.StatusOK, map[string]string{"hub.challenge": challenge}); err != nil {
+		log.Printf("failed to write Strava webhook validation response: %v", err)
+	}
+}
+
+// postStravaWebhook receives push events. Strava expects a 200 within two
+// seconds, so events are only queued here and fetched in the background.
+// Events are not signed: only those of the configured subscription are
+// accepted, and providers check the owner and confirm deletions with Strava.
+func postStravaWebhook(writer http.ResponseWriter, request *http.Request) {
+	if strings.TrimSpace(runtimeconfig.StringValue(stravaWebhookVerifyTokenEnv, "")) == "" {
+		writeNotFound(writer, "Strava webhook is not configured", "Set "+stravaWebhookVerifyTokenEnv+" to enable push subscriptions")
+		return
+	}
+	subscriptionID, err := strconv.ParseInt(strings.TrimSpace(runtimeconfig.StringValue(stravaWebhookSubscriptionIDEnv, "")), 10, 64)
+	if err != nil || subscriptionID <= 0 {
+		writeNotFound(writer, "Strava webhook is not configured", "Set "+stravaWebhookSubscriptionIDEnv+" to the id of the push subscription")
+		return
+	}
+
+	var event stravaapi.WebhookEvent
+	if err := json.NewDecoder(request.Body).Decode(&event); err != nil {
+		writeBadRequest(writer, "Invalid Strava webhook event", err.Error())
+		return
+	}
+	if event.SubscriptionID != subscriptionID {
+		writeAPIError(writer, http.StatusForbidden, "Invalid Strava webhook event", "subscription_id does not match "+stravaWebhookSubscriptionIDEnv)
+		return
+	}
+
+	status := "ignored"
+	for _, receiver := range stravaWebhookReceivers() {
+		if receiver.EnqueueWebhookEvent(event) {
+			status = "queued"
+		}
+	}
+	if err := writeJSON(writer, http.StatusOK, map[string]string{"status": status}); err != nil {
+		log.Printf("failed to write Strava webhook event response: %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"mystravastats/internal/shared/infrastructure/stravaapi"
+)
+
+func TestGetStravaWebhookEchoesChallengeForMatchingVerifyToken(t *testing.T) {
+	// GIVEN
+	t.Setenv(stravaWebhookVerifyTokenEnv, "secret-token")
+	request := httptest.NewRequest(http.MethodGet, "/api/source-modes/strava/webhook?hub.mode=subscribe&hub.challenge=abc123&hub.verify_token=secret-token", nil)
+	recorder := httptest.NewRecorder()
+
+	// WHEN
+	getStravaWebhook(recorder, request)
+
+	// THEN
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var result map[string]string
+	if err := json.Unmarshal(recorder.Body.Bytes(), &result); err != nil {
+		t.Fatalf("invalid JSON response: %v", err)
+	}
+	if result["hub.challenge"] != "abc123" {
+		t.Fatalf("expected challenge to be echoed, got %#v", result)
+	}
+}
+
+func TestGetStravaWebhookRejectsWrongVerifyToken(t *testing.T) {
+	// GIVEN
+	t.Setenv(stravaWebhookVerifyTokenEnv, "secret-token")
+	request := httptest.NewRequest(http.MethodGet, "/api/source-modes/strava/webhook?hub.mode=subscribe&hub.challenge=abc123&hub.verify_token=wrong", nil)
+	recorder := httptest.NewRecorder()
+
+	// WHEN
+	getStravaWebhook(recorder, request)
+
+	// THEN
+	if recorder.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestPostStravaWebhookQueuesEventOnStravaProvider(t *testing.T) {
+	// GIVEN
+	t.Setenv(stravaWebhookVerifyTokenEnv, "secret-token")
+	t.Setenv(stravaWebhookSubscriptionIDEnv, "9")
+	receiver := &recordingWebhookReceiver{}
+	previousReceivers := stravaWebhookReceivers
+	stravaWebhookReceivers = func() []stravaWebhookReceiver { return []stravaWebhookReceiver{receiver} }
+	defer func() { stravaWebhookReceivers = previousReceivers }()
+	body := strings.NewReader(`{"aspect_type":"update","event_time":1780000000,"object_id":42,"object_type":"activity","owner_id":123,"subscription_id":9,"updates":{"title":"Evening Ride"}}`)
+	request := httptest.NewRequest(http.MethodPost, "/api/source-modes/strava/webhook", body)
+	recorder := httptest.NewRecorder()
+
+	// WHEN
+	postStravaWebhook(recorder, request)
+
+	// THEN
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), `"queued"`) {
+		t.Fatalf("expected queued 200 response, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if len(receiver.events) != 1 || receiver.events[0].ObjectID != 42 || receiver.events[0].AspectType != stravaapi.WebhookAspectUpdate {
+		t.Fatalf("expected update event for activity 42, got %#v", receiver.events)
+	}
+}
+
+func TestPostStravaWebhookRejectsOtherSubscriptions(t *testing.T) {
+	// GIVEN
+	t.Setenv(stravaWebhookVerifyTokenEnv, "secret-token")
+	t.Setenv(stravaWebhookSubscriptionIDEnv, "9")
+	receiver := &recordingWebhookReceiver{}
+	previousReceivers := stravaWebhookReceivers
+	stravaWebhookReceivers = func() []stravaWebhookReceiver { return []stravaWebhookReceiver{receiver} }
+	defer func() { stravaWebhookReceivers = previousReceivers }()
+
+	for _, payload := range []string{
+		`{"aspect_type":"delete","object_id":42,"object_type":"activity","owner_id":123,"subscription_id":10}`,
+		`{"aspect_type":"delete","object_id":42,"object_type":"activity","owner_id":123}`,
+	} {
+		request := httptest.NewRequest(http.MethodPost, "/api/source-modes/strava/webhook", strings.NewReader(payload))
+		recorder := httptest.NewRecorder()
+
+		// WHEN
+		postStravaWebhook(recorder, request)
+
+		// THEN
+		if recorder.Code != http.StatusForbidden {
+			t.Fatalf("expected 403 for %s, got %d: %s", payload, recorder.Code, recorder.Body.String())
+		}
+	}
+	if len(receiver.events) != 0 {
+		t.Fatalf("expected no event to reach the providers, got %#v", receiver.events)
+	}
+}
+
+func TestPostStravaWebhookRequiresSubscriptionID(t *testing.T) {
+	// GIVEN
+	t.Setenv(stravaWebhookVerifyTokenEnv, "secret-token")
+	t.Setenv(stravaWebhookSubscriptionIDEnv, "")
+	body := strings.NewReader(`{"aspect_type":"delete","object_id":42,"object_type":"activity","owner_id":123,"subscription_id":9}`)
+	request := httptest.NewRequest(http.MethodPost, "/api/source-modes/strava/webhook", body)
+	recorder := httptest.NewRecorder()
+
+	// WHEN
+	postStravaWebhook(recorder, request)
+
+	// THEN
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 without a subscription id, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+type recordingWebhookReceiver struct {
+	events []stravaapi.WebhookEvent
+}
+
+func (receiver *recordingWebhookReceiver) EnqueueWebhookEvent(event stravaapi.WebhookEvent) bool {
+	receiver.events = append(receiver.events, event)
+	return true
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"fmt"
+	"mystravastats/internal/platform/activityprovider"
 	routesDomain "mystravastats/internal/routes/domain"
 	"mystravastats/internal/shared/domain/business"
 	"net/http"
@@ -50,6 +51,33 @@ func getYearParam(request *http.Request) (*int, error) {
 	return &y, nil
 }
 
+// getSourceFilterParam reads the source provenance filter, e.g.
+// source=fit,no-strava.
+func getSourceFilterParam(request *http.Request) (activityprovider.SourceFilter, error) {
+	return activityprovider.ParseSourceFilter(request.URL.Query().Get("source"))
+}
+
+// getDateRangeParam reads the date range, either from=YYYY-MM-DD and
+// to=YYYY-MM-DD or a rolling range such as last=90d ending today.
+func getDateRangeParam(request *http.Request) (activityprovider.DateRange, error) {
+	query := request.URL.Query()
+	return activityprovider.ParseDateRange(
+		strings.TrimSpace(query.Get("from")),
+		strings.TrimSpace(query.Get("to")),
+		strings.ToLower(strings.TrimSpace(query.Get("last"))),
+		time.Now(),
+	)
+}
+
+// hasDateRangeParam reports whether the request carries a valid date range.
+func hasDateRangeParam(request *http.Request) bool {
+	dateRange, err := getDateRangeParam(request)
+	return err == nil && !dateRange.IsZero()
+}
+
+// parseActivityRequestParams reads the year and activity type parameters and
+// validates the source filter and the date range, which containerFor applies
+// to the activities of the request.
 func parseActivityRequestParams(request *http.Request) (*int, []business.ActivityType, error) {
 	year, err := getYearParam(request)
 	if err != nil {
@@ -59,6 +87,12 @@ func parseActivityRequestParams(request *http.Request) (*int, []business.Activit
 	if err != nil {
 		return nil, nil, err
 	}
+	if _, err := getSourceFilterParam(request); err != nil {
+		return nil, nil, err
+	}
+	if _, err := getDateRangeParam(request); err != nil {
+		return nil, nil, err
+	}
 	return year, activityTypes, nil
 }
 
@@ -231,6 +265,18 @@ func getTargetIDParam(request *http.Request) (*int64, error) {
 	return &id, nil
 }
 
+func getActivityIDParam(request *http.Request) (*int64, error) {
+	activityID := strings.TrimSpace(request.URL.Query().Get("activityId"))
+	if activityID == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseInt(activityID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid activityId: %q", activityID)
+	}
+	return &id, nil
+}
+
 func getSegmentIDPathParam(request *http.Request) (int64, error) {
 	segmentIDValue := strings.TrimSpace(mux.Vars(request)["segmentId"])
 	if segmentIDValue == "" {
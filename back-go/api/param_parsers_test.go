@@ -0,0 +1,55 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseActivityRequestParams_ValidatesSourceFilter(t *testing.T) {
+	// GIVEN
+	valid := httptest.NewRequest(http.MethodGet, "/api/activities?activityType=Ride&source=fit,no-strava", nil)
+	invalid := httptest.NewRequest(http.MethodGet, "/api/activities?activityType=Ride&source=garmin", nil)
+
+	// WHEN
+	_, _, validErr := parseActivityRequestParams(valid)
+	_, _, invalidErr := parseActivityRequestParams(invalid)
+
+	// THEN
+	if validErr != nil {
+		t.Fatalf("expected source=fit,no-strava to be accepted, got %v", validErr)
+	}
+	if invalidErr == nil {
+		t.Fatal("expected an unknown source to be rejected")
+	}
+}
+
+func TestParseActivityRequestParams_ValidatesDateRange(t *testing.T) {
+	// GIVEN
+	valid := []string{"from=2025-10-01&to=2026-03-31", "from=2025-10-01", "last=90d", "last=12W"}
+	invalid := []string{"from=2025-13-01", "from=2026-03-31&to=2025-10-01", "last=90", "last=9999y", "last=90d&from=2025-10-01"}
+
+	for _, query := range valid {
+		// WHEN
+		request := httptest.NewRequest(http.MethodGet, "/api/statistics?activityType=Run&"+query, nil)
+		_, _, err := parseActivityRequestParams(request)
+
+		// THEN
+		if err != nil {
+			t.Fatalf("expected %s to be accepted, got %v", query, err)
+		}
+		if !hasDateRangeParam(request) {
+			t.Fatalf("expected %s to carry a date range", query)
+		}
+	}
+	for _, query := range invalid {
+		// WHEN
+		request := httptest.NewRequest(http.MethodGet, "/api/statistics?activityType=Run&"+query, nil)
+		_, _, err := parseActivityRequestParams(request)
+
+		// THEN
+		if err == nil {
+			t.Fatalf("expected %s to be rejected", query)
+		}
+	}
+}
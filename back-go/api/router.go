@@ -15,6 +15,9 @@ func NewRouter() *mux.Router {
 		var handler http.Handler
 
 		handler = route.HandlerFunc
+		if !route.Global {
+			handler = withAthleteScope(handler)
+		}
 		handler = domain.Logger(handler, route.Name)
 
 		router.
@@ -25,6 +28,20 @@ func NewRouter() *mux.Router {
 
 	}
 
+	// Athlete-scoped variants are registered after the plain routes so that
+	// /api/athletes/me/... keeps matching its own handlers.
+	for _, route := range routes {
+		if route.Global {
+			continue
+		}
+		name := route.Name + "ForAthlete"
+		router.
+			Methods(route.Method).
+			Path(athleteScopedPattern(route.Pattern)).
+			Name(name).
+			Handler(domain.Logger(withAthleteScope(route.HandlerFunc), name))
+	}
+
 	// Add Swagger UI route
 	router.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
 
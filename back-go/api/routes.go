@@ -7,6 +7,9 @@ type Route struct {
 	Method      string
 	Pattern     string
 	HandlerFunc http.HandlerFunc
+	// Global routes act on the whole instance rather than on one athlete: they
+	// ignore X-Athlete-Id and get no /api/athletes/{athleteId} variant.
+	Global bool
 }
 
 type Routes []Route
@@ -21,11 +24,29 @@ var routes = Routes{
 	{Name: "PostDataQualitySafeCorrections", Method: "POST", Pattern: "/api/data-quality/corrections/safe", HandlerFunc: postDataQualitySafeCorrections},
 	{Name: "PostDataQualityCorrection", Method: "POST", Pattern: "/api/data-quality/corrections/{issueId}", HandlerFunc: postDataQualityCorrection},
 	{Name: "DeleteDataQualityCorrection", Method: "DELETE", Pattern: "/api/data-quality/corrections/{correctionId}", HandlerFunc: deleteDataQualityCorrection},
-	{Name: "PostSourceModePreview", Method: "POST", Pattern: "/api/source-modes/preview", HandlerFunc: postSourceModePreview},
-	{Name: "PostSourceModeApply", Method: "POST", Pattern: "/api/source-modes/apply", HandlerFunc: postSourceModeApply},
-	{Name: "PostStravaOAuthStart", Method: "POST", Pattern: "/api/source-modes/strava/oauth/start", HandlerFunc: postStravaOAuthStart},
-	{Name: "GetStravaOAuthCallback", Method: "GET", Pattern: "/api/source-modes/strava/oauth/callback", HandlerFunc: getStravaOAuthCallback},
-	{Name: "PostSourceSyncSynchronize", Method: "POST", Pattern: "/api/source-sync/synchronize", HandlerFunc: postSourceSyncSynchronize},
+	{Name: "PostSourceModePreview", Method: "POST", Pattern: "/api/source-modes/preview", HandlerFunc: postSourceModePreview, Global: true},
+	{Name: "PostSourceModeApply", Method: "POST", Pattern: "/api/source-modes/apply", HandlerFunc: postSourceModeApply, Global: true},
+	{Name: "PostStravaOAuthStart", Method: "POST", Pattern: "/api/source-modes/strava/oauth/start", HandlerFunc: postStravaOAuthStart, Global: true},
+	{Name: "GetStravaOAuthCallback", Method: "GET", Pattern: "/api/source-modes/strava/oauth/callback", HandlerFunc: getStravaOAuthCallback, Global: true},
+	{Name: "GetStravaWebhook", Method: "GET", Pattern: "/api/source-modes/strava/webhook", HandlerFunc: getStravaWebhook, Global: true},
+	{Name: "PostStravaWebhook", Method: "POST", Pattern: "/api/source-modes/strava/webhook", HandlerFunc: postStravaWebhook, Global: true},
+	{Name: "PostStravaUploads", Method: "POST", Pattern: "/api/source-modes/strava/uploads", HandlerFunc: postStravaUploads},
+	{Name: "GetSourceMergeOverrides", Method: "GET", Pattern: "/api/source-merge/overrides", HandlerFunc: getSourceMergeOverrides},
+	{Name: "PostSourceMergeLink", Method: "POST", Pattern: "/api/source-merge/links", HandlerFunc: postSourceMergeLink},
+	{Name: "PostSourceMergeSplit", Method: "POST", Pattern: "/api/source-merge/splits", HandlerFunc: postSourceMergeSplit},
+	{Name: "PutSourceMergeFieldSources", Method: "PUT", Pattern: "/api/source-merge/field-sources", HandlerFunc: putSourceMergeFieldSources},
+	{Name: "PutSourceMergeActivityFieldSources", Method: "PUT", Pattern: "/api/source-merge/activities/{activityId}/field-sources", HandlerFunc: putSourceMergeActivityFieldSources},
+	{Name: "DeleteSourceMergeActivity", Method: "DELETE", Pattern: "/api/source-merge/activities/{activityId}", HandlerFunc: deleteSourceMergeActivity},
+	{Name: "GetSourcesCoverage", Method: "GET", Pattern: "/api/sources/coverage", HandlerFunc: getSourcesCoverage},
+	{Name: "PostSourceSyncSynchronize", Method: "POST", Pattern: "/api/source-sync/synchronize", HandlerFunc: postSourceSyncSynchronize, Global: true},
+	{Name: "PostSourceSyncStravaArchive", Method: "POST", Pattern: "/api/source-sync/strava-archive", HandlerFunc: postSourceSyncStravaArchive},
+	{Name: "PostSourceSyncStravaReconcile", Method: "POST", Pattern: "/api/source-sync/strava-reconcile", HandlerFunc: postSourceSyncStravaReconcile, Global: true},
+	{Name: "GetSourceSyncStatus", Method: "GET", Pattern: "/api/source-sync/status", HandlerFunc: getSourceSyncStatus, Global: true},
+	{Name: "GetSourceSyncHistory", Method: "GET", Pattern: "/api/source-sync/history", HandlerFunc: getSourceSyncHistory, Global: true},
+	{Name: "GetCacheBackup", Method: "GET", Pattern: "/api/cache/backup", HandlerFunc: getCacheBackup},
+	{Name: "PostCacheRestore", Method: "POST", Pattern: "/api/cache/restore", HandlerFunc: postCacheRestore},
+	{Name: "GetEvents", Method: "GET", Pattern: "/api/events", HandlerFunc: getEvents, Global: true},
+	{Name: "GetAthletes", Method: "GET", Pattern: "/api/athletes", HandlerFunc: getAthletes, Global: true},
 	{Name: "GetAthlete", Method: "GET", Pattern: "/api/athletes/me", HandlerFunc: getAthlete},
 	{Name: "GetAthleteFtpEstimate", Method: "GET", Pattern: "/api/athletes/me/ftp-estimate", HandlerFunc: getAthleteFtpEstimate},
 	{Name: "GetAthletePerformanceSettings", Method: "GET", Pattern: "/api/athletes/me/performance-settings", HandlerFunc: getAthletePerformanceSettings},
@@ -37,6 +58,10 @@ var routes = Routes{
 	{Name: "GetDetailedActivity", Method: "GET", Pattern: "/api/activities/{activityId}", HandlerFunc: getDetailedActivity},
 	{Name: "GetStatisticsByActivityType", Method: "GET", Pattern: "/api/statistics", HandlerFunc: getStatisticsByActivityType},
 	{Name: "GetPersonalRecordsTimelineByActivityType", Method: "GET", Pattern: "/api/statistics/personal-records-timeline", HandlerFunc: getPersonalRecordsTimelineByActivityType},
+	{Name: "GetCustomStatisticsByActivityType", Method: "GET", Pattern: "/api/statistics/custom", HandlerFunc: getCustomStatisticsByActivityType},
+	{Name: "PutCustomStatisticsByActivityType", Method: "PUT", Pattern: "/api/statistics/custom", HandlerFunc: putCustomStatisticsByActivityType},
+	{Name: "GetPowerCurveByActivityType", Method: "GET", Pattern: "/api/statistics/power-curve", HandlerFunc: getPowerCurveByActivityType},
+	{Name: "GetPaceCurveByActivityType", Method: "GET", Pattern: "/api/statistics/pace-curve", HandlerFunc: getPaceCurveByActivityType},
 	{Name: "GetHeartRateZoneAnalysisByActivityType", Method: "GET", Pattern: "/api/statistics/heart-rate-zones", HandlerFunc: getHeartRateZoneAnalysisByActivityType},
 	{Name: "GetSegmentClimbProgressionByActivityType", Method: "GET", Pattern: "/api/statistics/segment-climb-progression", HandlerFunc: getSegmentClimbProgressionByActivityType},
 	{Name: "GetGearAnalysisByActivityType", Method: "GET", Pattern: "/api/gear-analysis", HandlerFunc: getGearAnalysisByActivityType},
@@ -50,7 +75,7 @@ var routes = Routes{
 	{Name: "GenerateShapeRoutesByActivityType", Method: "POST", Pattern: "/api/routes/generate/shape", HandlerFunc: generateShapeRoutesByActivityType},
 	{Name: "EditGeneratedRoute", Method: "POST", Pattern: "/api/routes/{routeId}/edit", HandlerFunc: editGeneratedRouteByID},
 	{Name: "GetGeneratedRouteGpx", Method: "GET", Pattern: "/api/routes/{routeId}/gpx", HandlerFunc: getGeneratedRouteGPXByID},
-	{Name: "PostOSRMStart", Method: "POST", Pattern: "/api/routing/osrm/start", HandlerFunc: postOSRMStart},
+	{Name: "PostOSRMStart", Method: "POST", Pattern: "/api/routing/osrm/start", HandlerFunc: postOSRMStart, Global: true},
 	{Name: "GetMapsGPX", Method: "GET", Pattern: "/api/maps/gpx", HandlerFunc: getMapsGPX},
 	{Name: "GetMapPassages", Method: "GET", Pattern: "/api/maps/passages", HandlerFunc: getMapPassages},
 	{Name: "GetChartsDistanceByPeriod", Method: "GET", Pattern: "/api/charts/distance-by-period", HandlerFunc: getChartsDistanceByPeriod},
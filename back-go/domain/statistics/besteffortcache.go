@@ -3,6 +3,7 @@ package statistics
 import (
 	"encoding/json"
 	"fmt"
+	"mystravastats/internal/platform/cachewrite"
 	"mystravastats/internal/shared/domain/business"
 	"mystravastats/internal/shared/domain/strava"
 	"os"
@@ -142,12 +143,8 @@ func SaveBestEffortCacheToDisk(path string) (int, error) {
 		return 0, fmt.Errorf("marshal best effort cache: %w", err)
 	}
 
-	tmp := path + ".tmp"
-	if err := os.WriteFile(tmp, payload, 0o644); err != nil {
-		return 0, fmt.Errorf("write temp best effort cache: %w", err)
-	}
-	if err := os.Rename(tmp, path); err != nil {
-		return 0, fmt.Errorf("rename best effort cache: %w", err)
+	if err := cachewrite.WriteFile(path, payload, 0o644); err != nil {
+		return 0, fmt.Errorf("write best effort cache: %w", err)
 	}
 
 	return len(persisted), nil
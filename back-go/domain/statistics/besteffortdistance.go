@@ -50,9 +50,19 @@ func NewBestEffortDistanceStatistic(name string, activities []*strava.Activity,
 }
 
 func FindBestActivityEffort(activities []*strava.Activity, distance float64) *business.ActivityEffort {
+	return findFastestEffort(activities, distance, BestTimeEffort)
+}
+
+// FindBestGradeAdjustedActivityEffort is FindBestActivityEffort on the
+// grade-adjusted pace.
+func FindBestGradeAdjustedActivityEffort(activities []*strava.Activity, distance float64) *business.ActivityEffort {
+	return findFastestEffort(activities, distance, BestGradeAdjustedTimeEffort)
+}
+
+func findFastestEffort(activities []*strava.Activity, distance float64, bestEffortOf func(strava.Activity, float64) *business.ActivityEffort) *business.ActivityEffort {
 	var bestEffort *business.ActivityEffort
 	for _, activity := range activities {
-		effort := BestTimeEffort(*activity, distance)
+		effort := bestEffortOf(*activity, distance)
 		if effort != nil && (bestEffort == nil || effort.Seconds < bestEffort.Seconds) {
 			bestEffort = effort
 		}
@@ -76,6 +86,35 @@ func BestTimeEffort(activity strava.Activity, distance float64) *business.Activi
 	)
 }
 
+// BestGradeAdjustedTimeEffort returns the fastest effort over a
+// flat-equivalent distance: the search runs on the grade-adjusted distances
+// of the stream, so a climb counts for more than its length.
+func BestGradeAdjustedTimeEffort(activity strava.Activity, distance float64) *business.ActivityEffort {
+	if activity.Stream == nil || activity.Stream.Altitude == nil || len(activity.Stream.Altitude.Data) == 0 {
+		return nil
+	}
+
+	return getOrComputeBestEffort(
+		activity.Id,
+		"best-gap-time-distance-v1",
+		effortDistanceTarget(distance),
+		activity.Stream,
+		func() *business.ActivityEffort {
+			adjusted := activity.Stream.GradeAdjustedDistances()
+			if adjusted == nil {
+				return nil
+			}
+			stream := *activity.Stream
+			stream.Distance = strava.DistanceStream{Data: adjusted}
+			effort := BestTimeForDistance(activity.Id, activity.Name, activity.Type, &stream, distance)
+			if effort != nil {
+				effort.Label = fmt.Sprintf("Best grade-adjusted speed for %.0fm", distance)
+			}
+			return effort
+		},
+	)
+}
+
 func BestTimeForDistance(id int64, name, activityType string, stream *strava.Stream, distance float64) *business.ActivityEffort {
 	idxStart, idxEnd := 0, 0
 	bestTime := math.MaxFloat64
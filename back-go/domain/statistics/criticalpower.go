@@ -0,0 +1,161 @@
+package statistics
+
+import (
+	"math"
+	"mystravastats/internal/shared/domain/business"
+)
+
+const (
+	// The 2-parameter model only holds for efforts exhausting W' while CP is
+	// sustainable, usually between 3 and 20 min.
+	criticalPowerFromSeconds = 3 * 60
+	criticalPowerToSeconds   = 20 * 60
+	// The Morton 3-parameter model bends towards a finite maximal power and
+	// also fits the shorter efforts.
+	mortonFromSeconds      = 30
+	mortonToSeconds        = 30 * 60
+	mortonMaxOffsetSeconds = 300
+	minCriticalPowerPoints = 3
+)
+
+// FitCriticalPower fits the 2-parameter critical power model to the curve
+// points between 3 and 20 min. The work of an effort is linear in its
+// duration, W = CP·t + W', so the fit is a linear regression of work on time.
+func FitCriticalPower(points []business.PowerCurvePoint) *business.CriticalPowerModel {
+	selected := criticalPowerPoints(points, criticalPowerFromSeconds, criticalPowerToSeconds)
+	if len(selected) < minCriticalPowerPoints {
+		return nil
+	}
+
+	durations := make([]float64, len(selected))
+	works := make([]float64, len(selected))
+	for index, point := range selected {
+		durations[index] = float64(point.Seconds)
+		works[index] = point.Watts * float64(point.Seconds)
+	}
+	criticalPower, wPrime, ok := linearRegression(durations, works)
+	if !ok || criticalPower <= 0 || wPrime <= 0 {
+		return nil
+	}
+
+	model := &business.CriticalPowerModel{
+		Model:         business.CriticalPowerModelTwoParameter,
+		CriticalPower: criticalPower,
+		WPrime:        wPrime,
+		FromSeconds:   criticalPowerFromSeconds,
+		ToSeconds:     criticalPowerToSeconds,
+		PointCount:    len(selected),
+	}
+	model.RSquared, model.RMSE = criticalPowerFitQuality(selected, model)
+	return model
+}
+
+// FitMortonCriticalPower fits the Morton 3-parameter model,
+// P(t) = CP + W'/(t - K) with K < 0, to the curve points between 30 s and
+// 30 min. For a given K the model is linear in 1/(t - K), so K is searched
+// second by second and CP and W' come from a linear regression.
+func FitMortonCriticalPower(points []business.PowerCurvePoint) *business.CriticalPowerModel {
+	selected := criticalPowerPoints(points, mortonFromSeconds, mortonToSeconds)
+	if len(selected) < minCriticalPowerPoints {
+		return nil
+	}
+
+	inverses := make([]float64, len(selected))
+	powers := make([]float64, len(selected))
+	var best *business.CriticalPowerModel
+	bestError := math.Inf(1)
+	for offset := 1; offset <= mortonMaxOffsetSeconds; offset++ {
+		for index, point := range selected {
+			inverses[index] = 1 / float64(point.Seconds+offset)
+			powers[index] = point.Watts
+		}
+		wPrime, criticalPower, ok := linearRegression(inverses, powers)
+		if !ok || criticalPower <= 0 || wPrime <= 0 {
+			continue
+		}
+		candidate := &business.CriticalPowerModel{
+			Model:         business.CriticalPowerModelMorton,
+			CriticalPower: criticalPower,
+			WPrime:        wPrime,
+			K:             -float64(offset),
+		}
+		if squaredError := criticalPowerSquaredError(selected, candidate); squaredError < bestError {
+			best, bestError = candidate, squaredError
+		}
+	}
+	if best == nil {
+		return nil
+	}
+
+	maxPower := best.CriticalPower - best.WPrime/best.K
+	best.MaxPower = &maxPower
+	best.FromSeconds = mortonFromSeconds
+	best.ToSeconds = mortonToSeconds
+	best.PointCount = len(selected)
+	best.RSquared, best.RMSE = criticalPowerFitQuality(selected, best)
+	return best
+}
+
+// PredictCriticalPower returns the power the model predicts for seconds.
+func PredictCriticalPower(model business.CriticalPowerModel, seconds float64) float64 {
+	return model.CriticalPower + model.WPrime/(seconds-model.K)
+}
+
+func criticalPowerPoints(points []business.PowerCurvePoint, fromSeconds int, toSeconds int) []business.PowerCurvePoint {
+	selected := make([]business.PowerCurvePoint, 0, len(points))
+	for _, point := range points {
+		if point.Seconds >= fromSeconds && point.Seconds <= toSeconds && point.Watts > 0 {
+			selected = append(selected, point)
+		}
+	}
+	return selected
+}
+
+func criticalPowerSquaredError(points []business.PowerCurvePoint, model *business.CriticalPowerModel) float64 {
+	squaredError := 0.0
+	for _, point := range points {
+		residual := point.Watts - PredictCriticalPower(*model, float64(point.Seconds))
+		squaredError += residual * residual
+	}
+	return squaredError
+}
+
+// criticalPowerFitQuality returns the coefficient of determination and the
+// root-mean-square error of the model, both on power.
+func criticalPowerFitQuality(points []business.PowerCurvePoint, model *business.CriticalPowerModel) (float64, float64) {
+	mean := 0.0
+	for _, point := range points {
+		mean += point.Watts
+	}
+	mean /= float64(len(points))
+
+	totalSquares := 0.0
+	for _, point := range points {
+		totalSquares += (point.Watts - mean) * (point.Watts - mean)
+	}
+	squaredError := criticalPowerSquaredError(points, model)
+	rSquared := 1.0
+	if totalSquares > 0 {
+		rSquared = 1 - squaredError/totalSquares
+	}
+	return rSquared, math.Sqrt(squaredError / float64(len(points)))
+}
+
+// linearRegression returns the slope and intercept of the least-squares line
+// through the points.
+func linearRegression(xs []float64, ys []float64) (float64, float64, bool) {
+	count := float64(len(xs))
+	sumX, sumY, sumXX, sumXY := 0.0, 0.0, 0.0, 0.0
+	for index := range xs {
+		sumX += xs[index]
+		sumY += ys[index]
+		sumXX += xs[index] * xs[index]
+		sumXY += xs[index] * ys[index]
+	}
+	denominator := count*sumXX - sumX*sumX
+	if count < 2 || math.Abs(denominator) < 1e-12 {
+		return 0, 0, false
+	}
+	slope := (count*sumXY - sumX*sumY) / denominator
+	return slope, (sumY - slope*sumX) / count, true
+}
@@ -0,0 +1,432 @@
+package statistics
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Custom statistics are written in a small declarative language:
+//
+//	<function>(<arguments>) [where <condition>]
+//
+// for instance "best_distance(15 km)", "count() where commute" or
+// "percentile(90, average_heartrate) where distance > 10 km and not commute".
+// The language has no variables, loops or user functions: an expression is
+// parsed once into a closed set of functions, fields and comparisons, so that
+// evaluating it is bounded by the number of activities.
+
+const (
+	maxCustomExpressionLength = 512
+	maxCustomExpressionDepth  = 32
+	// Best efforts slide a window over every stream, so their distance and
+	// duration are capped to what a single activity can cover.
+	maxBestEffortDistance = 1_000_000
+	maxBestEffortDuration = 24 * 60 * 60
+)
+
+// CustomExpression is a parsed custom statistic expression.
+type CustomExpression struct {
+	source   string
+	function string
+	field    *customField
+	quantity float64
+	filter   customCondition
+}
+
+// String returns the expression as written.
+func (expression *CustomExpression) String() string {
+	return expression.source
+}
+
+type customDimension string
+
+const (
+	customDistance  customDimension = "distance"
+	customElevation customDimension = "elevation"
+	customDuration  customDimension = "duration"
+	customSpeed     customDimension = "speed"
+	customHeartRate customDimension = "heart rate"
+	customPower     customDimension = "power"
+	customCadence   customDimension = "cadence"
+	customEnergy    customDimension = "energy"
+)
+
+// customUnits gives, for each dimension, the factor converting a unit to the
+// unit Strava stores. A bare number is read in the stored unit.
+var customUnits = map[customDimension]map[string]float64{
+	customDistance:  {"m": 1, "km": 1000},
+	customElevation: {"m": 1},
+	customDuration:  {"s": 1, "min": 60, "h": 3600},
+	customSpeed:     {"kph": 1 / 3.6},
+	customHeartRate: {"bpm": 1},
+	customPower:     {"w": 1},
+	customCadence:   {"rpm": 1},
+	customEnergy:    {"kj": 1},
+}
+
+var customKeywords = map[string]bool{"where": true, "and": true, "or": true, "not": true, "true": true, "false": true}
+
+type customTokenKind int
+
+const (
+	customTokenEnd customTokenKind = iota
+	customTokenIdentifier
+	customTokenNumber
+	customTokenString
+	customTokenSymbol
+)
+
+type customToken struct {
+	kind     customTokenKind
+	text     string
+	number   float64
+	position int
+}
+
+func (token customToken) describe() string {
+	switch token.kind {
+	case customTokenEnd:
+		return "end of expression"
+	case customTokenString:
+		return strconv.Quote(token.text)
+	default:
+		return fmt.Sprintf("%q", token.text)
+	}
+}
+
+// ParseCustomExpression parses and checks a custom statistic expression.
+func ParseCustomExpression(source string) (*CustomExpression, error) {
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return nil, fmt.Errorf("expression is empty")
+	}
+	if len(source) > maxCustomExpressionLength {
+		return nil, fmt.Errorf("expression is longer than %d characters", maxCustomExpressionLength)
+	}
+	tokens, err := tokenizeCustomExpression(source)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &customParser{tokens: tokens}
+	expression := &CustomExpression{source: source}
+	if err := parser.parseCall(expression); err != nil {
+		return nil, err
+	}
+	if parser.acceptKeyword("where") {
+		filter, err := parser.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		expression.filter = filter
+	}
+	if token := parser.peek(); token.kind != customTokenEnd {
+		return nil, parser.errorAt(token, "unexpected %s", token.describe())
+	}
+	return expression, nil
+}
+
+func tokenizeCustomExpression(source string) ([]customToken, error) {
+	tokens := make([]customToken, 0)
+	for index := 0; index < len(source); {
+		character := source[index]
+		start := index
+		switch {
+		case character == ' ' || character == '\t' || character == '\n' || character == '\r':
+			index++
+		case isCustomLetter(character):
+			for index < len(source) && (isCustomLetter(source[index]) || isCustomDigit(source[index])) {
+				index++
+			}
+			tokens = append(tokens, customToken{kind: customTokenIdentifier, text: strings.ToLower(source[start:index]), position: start})
+		case isCustomDigit(character) || character == '.':
+			for index < len(source) && (isCustomDigit(source[index]) || source[index] == '.') {
+				index++
+			}
+			number, err := strconv.ParseFloat(source[start:index], 64)
+			if err != nil || math.IsInf(number, 0) {
+				return nil, fmt.Errorf("at %d: invalid number %q", start+1, source[start:index])
+			}
+			tokens = append(tokens, customToken{kind: customTokenNumber, text: source[start:index], number: number, position: start})
+		case character == '\'' || character == '"':
+			end := strings.IndexByte(source[start+1:], source[start])
+			if end < 0 {
+				return nil, fmt.Errorf("at %d: unterminated string", start+1)
+			}
+			index = start + 1 + end + 1
+			tokens = append(tokens, customToken{kind: customTokenString, text: source[start+1 : index-1], position: start})
+		case strings.IndexByte("<>=!", character) >= 0:
+			index++
+			if index < len(source) && source[index] == '=' {
+				index++
+			}
+			symbol := source[start:index]
+			if symbol == "=" || symbol == "!" {
+				return nil, fmt.Errorf("at %d: unknown operator %q, use == or !=", start+1, symbol)
+			}
+			tokens = append(tokens, customToken{kind: customTokenSymbol, text: symbol, position: start})
+		case strings.IndexByte("(),", character) >= 0:
+			index++
+			tokens = append(tokens, customToken{kind: customTokenSymbol, text: source[start:index], position: start})
+		default:
+			return nil, fmt.Errorf("at %d: unexpected character %q", start+1, character)
+		}
+	}
+	return append(tokens, customToken{kind: customTokenEnd, position: len(source)}), nil
+}
+
+func isCustomLetter(character byte) bool {
+	return character == '_' || (character >= 'a' && character <= 'z') || (character >= 'A' && character <= 'Z')
+}
+
+func isCustomDigit(character byte) bool {
+	return character >= '0' && character <= '9'
+}
+
+type customParser struct {
+	tokens []customToken
+	index  int
+	depth  int
+}
+
+func (parser *customParser) peek() customToken {
+	return parser.tokens[parser.index]
+}
+
+func (parser *customParser) next() customToken {
+	token := parser.tokens[parser.index]
+	if token.kind != customTokenEnd {
+		parser.index++
+	}
+	return token
+}
+
+func (parser *customParser) errorAt(token customToken, format string, arguments ...any) error {
+	return fmt.Errorf("at %d: %s", token.position+1, fmt.Sprintf(format, arguments...))
+}
+
+func (parser *customParser) acceptSymbol(symbol string) bool {
+	if token := parser.peek(); token.kind == customTokenSymbol && token.text == symbol {
+		parser.index++
+		return true
+	}
+	return false
+}
+
+func (parser *customParser) expectSymbol(symbol string) error {
+	if !parser.acceptSymbol(symbol) {
+		token := parser.peek()
+		return parser.errorAt(token, "expected %q, got %s", symbol, token.describe())
+	}
+	return nil
+}
+
+func (parser *customParser) acceptKeyword(keyword string) bool {
+	if token := parser.peek(); token.kind == customTokenIdentifier && token.text == keyword {
+		parser.index++
+		return true
+	}
+	return false
+}
+
+func (parser *customParser) parseCall(expression *CustomExpression) error {
+	token := parser.next()
+	if token.kind != customTokenIdentifier {
+		return parser.errorAt(token, "expected a function, got %s", token.describe())
+	}
+	expression.function = token.text
+	if err := parser.expectSymbol("("); err != nil {
+		return err
+	}
+
+	var err error
+	switch token.text {
+	case "count":
+	case "sum", "avg", "min", "max":
+		expression.field, err = parser.parseNumericField()
+	case "percentile":
+		expression.quantity, err = parser.parsePercentile()
+		if err == nil {
+			err = parser.expectSymbol(",")
+		}
+		if err == nil {
+			expression.field, err = parser.parseNumericField()
+		}
+	case "best_distance", "best_gradient":
+		expression.quantity, err = parser.parseQuantityArgument(customDistance, 100, maxBestEffortDistance, "100 m", "1000 km")
+	case "best_time":
+		expression.quantity, err = parser.parseQuantityArgument(customDuration, 1, maxBestEffortDuration, "1 s", "24 h")
+	case "best_power":
+		expression.quantity, err = parser.parseQuantityArgument(customDuration, 11, maxBestEffortDuration, "11 s", "24 h")
+	default:
+		return parser.errorAt(token, "unknown function %q", token.text)
+	}
+	if err != nil {
+		return err
+	}
+	return parser.expectSymbol(")")
+}
+
+func (parser *customParser) parseNumericField() (*customField, error) {
+	token := parser.next()
+	field, ok := customFields[token.text]
+	if token.kind != customTokenIdentifier || !ok {
+		return nil, parser.errorAt(token, "expected an activity field, got %s", token.describe())
+	}
+	if field.kind != customNumericField {
+		return nil, parser.errorAt(token, "%s is not a numeric field", field.name)
+	}
+	return field, nil
+}
+
+func (parser *customParser) parsePercentile() (float64, error) {
+	token := parser.next()
+	if token.kind != customTokenNumber {
+		return 0, parser.errorAt(token, "expected a percentile, got %s", token.describe())
+	}
+	if token.number > 100 {
+		return 0, parser.errorAt(token, "percentile must be between 0 and 100")
+	}
+	return token.number, nil
+}
+
+func (parser *customParser) parseQuantityArgument(dimension customDimension, minimum float64, maximum float64, minimumText string, maximumText string) (float64, error) {
+	start := parser.peek()
+	quantity, err := parser.parseQuantity(dimension)
+	if err != nil {
+		return 0, err
+	}
+	if quantity < minimum {
+		return 0, parser.errorAt(start, "%s must be at least %s", dimension, minimumText)
+	}
+	if quantity > maximum {
+		return 0, parser.errorAt(start, "%s must be at most %s", dimension, maximumText)
+	}
+	return quantity, nil
+}
+
+// parseQuantity reads a number followed by an optional unit of dimension and
+// returns it in the unit Strava stores.
+func (parser *customParser) parseQuantity(dimension customDimension) (float64, error) {
+	token := parser.next()
+	if token.kind != customTokenNumber {
+		return 0, parser.errorAt(token, "expected a %s, got %s", dimension, token.describe())
+	}
+	unit := parser.peek()
+	if unit.kind != customTokenIdentifier || customKeywords[unit.text] {
+		return token.number, nil
+	}
+	parser.index++
+	factor, ok := customUnits[dimension][unit.text]
+	if !ok {
+		return 0, parser.errorAt(unit, "unknown %s unit %q", dimension, unit.text)
+	}
+	return token.number * factor, nil
+}
+
+func (parser *customParser) parseOr() (customCondition, error) {
+	left, err := parser.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for parser.acceptKeyword("or") {
+		right, err := parser.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = customOr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (parser *customParser) parseAnd() (customCondition, error) {
+	left, err := parser.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for parser.acceptKeyword("and") {
+		right, err := parser.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = customAnd{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (parser *customParser) parseNot() (customCondition, error) {
+	if parser.depth >= maxCustomExpressionDepth {
+		return nil, parser.errorAt(parser.peek(), "condition is nested too deeply")
+	}
+	parser.depth++
+	defer func() { parser.depth-- }()
+
+	if parser.acceptKeyword("not") {
+		condition, err := parser.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return customNot{condition: condition}, nil
+	}
+	if parser.acceptSymbol("(") {
+		condition, err := parser.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return condition, parser.expectSymbol(")")
+	}
+	return parser.parseComparison()
+}
+
+func (parser *customParser) parseComparison() (customCondition, error) {
+	token := parser.next()
+	field, ok := customFields[token.text]
+	if token.kind != customTokenIdentifier || !ok {
+		return nil, parser.errorAt(token, "expected an activity field, got %s", token.describe())
+	}
+
+	operator := parser.peek()
+	if operator.kind != customTokenSymbol || !isCustomComparisonOperator(operator.text) {
+		if field.kind == customBooleanField {
+			return customComparison{field: field, operator: "==", boolean: true}, nil
+		}
+		return nil, parser.errorAt(operator, "expected a comparison after %s", field.name)
+	}
+	parser.index++
+
+	comparison := customComparison{field: field, operator: operator.text}
+	if field.kind != customNumericField && operator.text != "==" && operator.text != "!=" {
+		return nil, parser.errorAt(operator, "%s can only be compared with == or !=", field.name)
+	}
+	switch field.kind {
+	case customNumericField:
+		value, err := parser.parseQuantity(field.dimension)
+		if err != nil {
+			return nil, err
+		}
+		comparison.number = value
+	case customBooleanField:
+		value := parser.next()
+		if value.kind != customTokenIdentifier || (value.text != "true" && value.text != "false") {
+			return nil, parser.errorAt(value, "expected true or false, got %s", value.describe())
+		}
+		comparison.boolean = value.text == "true"
+	case customTextField:
+		value := parser.next()
+		if value.kind != customTokenString {
+			return nil, parser.errorAt(value, "expected a quoted string, got %s", value.describe())
+		}
+		comparison.text = value.text
+	}
+	return comparison, nil
+}
+
+func isCustomComparisonOperator(symbol string) bool {
+	switch symbol {
+	case "<", "<=", ">", ">=", "==", "!=":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,288 @@
+package statistics
+
+import (
+	"fmt"
+	"math"
+	"mystravastats/internal/shared/domain/business"
+	"mystravastats/internal/shared/domain/strava"
+	"sort"
+	"strings"
+)
+
+type customFieldKind int
+
+const (
+	customNumericField customFieldKind = iota
+	customBooleanField
+	customTextField
+)
+
+// customField is an activity field an expression can read. Optional fields
+// hold 0 when the sensor was missing: such activities fail every comparison
+// on the field and are left out of its aggregations.
+type customField struct {
+	name      string
+	kind      customFieldKind
+	dimension customDimension
+	optional  bool
+	number    func(*strava.Activity) float64
+	boolean   func(*strava.Activity) bool
+	text      func(*strava.Activity) string
+}
+
+func (field *customField) value(activity *strava.Activity) (float64, bool) {
+	value := field.number(activity)
+	if field.optional && value == 0 {
+		return 0, false
+	}
+	return value, true
+}
+
+var customFields = map[string]*customField{}
+
+func init() {
+	numeric := func(name string, dimension customDimension, optional bool, number func(*strava.Activity) float64) {
+		customFields[name] = &customField{name: name, kind: customNumericField, dimension: dimension, optional: optional, number: number}
+	}
+	numeric("distance", customDistance, false, func(activity *strava.Activity) float64 { return activity.Distance })
+	numeric("moving_time", customDuration, false, func(activity *strava.Activity) float64 { return float64(activity.MovingTime) })
+	numeric("elapsed_time", customDuration, false, func(activity *strava.Activity) float64 { return float64(activity.ElapsedTime) })
+	numeric("elevation_gain", customElevation, false, func(activity *strava.Activity) float64 { return activity.TotalElevationGain })
+	numeric("highest_point", customElevation, true, func(activity *strava.Activity) float64 { return activity.ElevHigh })
+	numeric("average_speed", customSpeed, false, func(activity *strava.Activity) float64 { return activity.AverageSpeed })
+	numeric("max_speed", customSpeed, false, func(activity *strava.Activity) float64 { return activity.MaxSpeed })
+	numeric("average_heartrate", customHeartRate, true, func(activity *strava.Activity) float64 { return activity.AverageHeartrate })
+	numeric("max_heartrate", customHeartRate, true, func(activity *strava.Activity) float64 { return activity.MaxHeartrate })
+	numeric("average_watts", customPower, true, func(activity *strava.Activity) float64 { return activity.AverageWatts })
+	numeric("weighted_average_watts", customPower, true, func(activity *strava.Activity) float64 { return float64(activity.WeightedAverageWatts) })
+	numeric("average_cadence", customCadence, true, func(activity *strava.Activity) float64 { return activity.AverageCadence })
+	numeric("kilojoules", customEnergy, true, func(activity *strava.Activity) float64 { return activity.Kilojoules })
+
+	customFields["commute"] = &customField{name: "commute", kind: customBooleanField, boolean: func(activity *strava.Activity) bool { return activity.Commute }}
+	customFields["sport_type"] = &customField{name: "sport_type", kind: customTextField, text: func(activity *strava.Activity) string { return activity.SportType }}
+	customFields["name"] = &customField{name: "name", kind: customTextField, text: func(activity *strava.Activity) string { return activity.Name }}
+}
+
+type customCondition interface {
+	matches(activity *strava.Activity) bool
+}
+
+type customComparison struct {
+	field    *customField
+	operator string
+	number   float64
+	boolean  bool
+	text     string
+}
+
+func (comparison customComparison) matches(activity *strava.Activity) bool {
+	switch comparison.field.kind {
+	case customBooleanField:
+		return (comparison.field.boolean(activity) == comparison.boolean) == (comparison.operator == "==")
+	case customTextField:
+		return strings.EqualFold(comparison.field.text(activity), comparison.text) == (comparison.operator == "==")
+	}
+
+	value, ok := comparison.field.value(activity)
+	if !ok {
+		return false
+	}
+	switch comparison.operator {
+	case "<":
+		return value < comparison.number
+	case "<=":
+		return value <= comparison.number
+	case ">":
+		return value > comparison.number
+	case ">=":
+		return value >= comparison.number
+	case "==":
+		return value == comparison.number
+	default:
+		return value != comparison.number
+	}
+}
+
+type customAnd struct {
+	left  customCondition
+	right customCondition
+}
+
+func (condition customAnd) matches(activity *strava.Activity) bool {
+	return condition.left.matches(activity) && condition.right.matches(activity)
+}
+
+type customOr struct {
+	left  customCondition
+	right customCondition
+}
+
+func (condition customOr) matches(activity *strava.Activity) bool {
+	return condition.left.matches(activity) || condition.right.matches(activity)
+}
+
+type customNot struct {
+	condition customCondition
+}
+
+func (condition customNot) matches(activity *strava.Activity) bool {
+	return !condition.condition.matches(activity)
+}
+
+// Filter returns the activities matching the where clause of the expression.
+func (expression *CustomExpression) Filter(activities []*strava.Activity) []*strava.Activity {
+	if expression.filter == nil {
+		return activities
+	}
+	filtered := make([]*strava.Activity, 0, len(activities))
+	for _, activity := range activities {
+		if expression.filter.matches(activity) {
+			filtered = append(filtered, activity)
+		}
+	}
+	return filtered
+}
+
+// CustomExtremumStatistic is the activity reaching the minimum or the maximum
+// of a field, as computed by min(field) and max(field).
+type CustomExtremumStatistic struct {
+	ActivityStatistic
+	field *customField
+	value *float64
+}
+
+func (stat *CustomExtremumStatistic) Value() string {
+	if stat.value == nil {
+		return "Not available"
+	}
+	return formatCustomValue(stat.field.dimension, *stat.value)
+}
+
+// NewCustomStatistic evaluates expression over the activities matching its
+// where clause. Best efforts reuse the built-in best effort statistics and
+// aggregations are global statistics.
+func NewCustomStatistic(name string, expression *CustomExpression, activities []*strava.Activity) Statistic {
+	activities = expression.Filter(activities)
+	field := expression.field
+
+	switch expression.function {
+	case "best_distance":
+		return NewBestEffortDistanceStatistic(name, activities, expression.quantity)
+	case "best_time":
+		return NewBestEffortTimeStatistic(name, activities, int(math.Round(expression.quantity)))
+	case "best_power":
+		return NewBestEffortPowerStatistic(name, activities, int(math.Round(expression.quantity)))
+	case "best_gradient":
+		return NewBestElevationDistanceStatistic(name, activities, expression.quantity)
+	case "min", "max":
+		return newCustomExtremumStatistic(name, activities, field, expression.function == "max")
+	case "count":
+		return NewGlobalStatistic(name, activities, func(activities []*strava.Activity) string {
+			return fmt.Sprintf("%d", len(activities))
+		})
+	case "sum":
+		return NewGlobalStatistic(name, activities, func(activities []*strava.Activity) string {
+			total := 0.0
+			for _, value := range customFieldValues(field, activities) {
+				total += value
+			}
+			return formatCustomValue(field.dimension, total)
+		})
+	case "avg":
+		return NewGlobalStatistic(name, activities, func(activities []*strava.Activity) string {
+			values := customFieldValues(field, activities)
+			if len(values) == 0 {
+				return "Not available"
+			}
+			total := 0.0
+			for _, value := range values {
+				total += value
+			}
+			return formatCustomValue(field.dimension, total/float64(len(values)))
+		})
+	default:
+		percentile := expression.quantity
+		return NewGlobalStatistic(name, activities, func(activities []*strava.Activity) string {
+			values := customFieldValues(field, activities)
+			if len(values) == 0 {
+				return "Not available"
+			}
+			return formatCustomValue(field.dimension, percentileOf(values, percentile))
+		})
+	}
+}
+
+func newCustomExtremumStatistic(name string, activities []*strava.Activity, field *customField, maximum bool) *CustomExtremumStatistic {
+	stat := &CustomExtremumStatistic{
+		ActivityStatistic: ActivityStatistic{
+			BaseStatistic: BaseStatistic{name: name, Activities: activities},
+		},
+		field: field,
+	}
+
+	var extremumActivity *strava.Activity
+	extremum := 0.0
+	for _, activity := range activities {
+		value, ok := field.value(activity)
+		if !ok {
+			continue
+		}
+		if extremumActivity == nil || (maximum && value > extremum) || (!maximum && value < extremum) {
+			extremumActivity = activity
+			extremum = value
+		}
+	}
+
+	if extremumActivity != nil {
+		stat.activity = &business.ActivityShort{
+			Id:   extremumActivity.Id,
+			Name: extremumActivity.Name,
+			Type: business.ActivityTypes[extremumActivity.Type],
+		}
+		stat.value = &extremum
+	}
+	return stat
+}
+
+func customFieldValues(field *customField, activities []*strava.Activity) []float64 {
+	values := make([]float64, 0, len(activities))
+	for _, activity := range activities {
+		if value, ok := field.value(activity); ok {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// percentileOf interpolates linearly between the closest ranks.
+func percentileOf(values []float64, percentile float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := percentile / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	if lower >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lower] + (rank-float64(lower))*(sorted[lower+1]-sorted[lower])
+}
+
+func formatCustomValue(dimension customDimension, value float64) string {
+	switch dimension {
+	case customDistance:
+		return fmt.Sprintf("%.2f km", value/1000)
+	case customElevation:
+		return fmt.Sprintf("%.0f m", value)
+	case customDuration:
+		return formatSeconds(int(math.Round(value)))
+	case customSpeed:
+		return fmt.Sprintf("%.2f km/h", value*3.6)
+	case customHeartRate:
+		return fmt.Sprintf("%.0f bpm", value)
+	case customPower:
+		return fmt.Sprintf("%.0f W", value)
+	case customEnergy:
+		return fmt.Sprintf("%.0f kJ", value)
+	default:
+		return fmt.Sprintf("%.0f", value)
+	}
+}
@@ -0,0 +1,131 @@
+package statistics
+
+import (
+	"mystravastats/internal/shared/domain/strava"
+	"strings"
+	"testing"
+)
+
+func customStatisticActivities() []*strava.Activity {
+	return []*strava.Activity{
+		{Id: 1, Name: "Morning commute", Type: "Ride", Distance: 12000, MovingTime: 1800, AverageHeartrate: 130, Commute: true},
+		{Id: 2, Name: "Evening commute", Type: "Ride", Distance: 13000, MovingTime: 1900, AverageHeartrate: 0, Commute: true},
+		{Id: 3, Name: "Long ride", Type: "Ride", Distance: 120000, MovingTime: 18000, AverageHeartrate: 145, SportType: "GravelRide"},
+		{Id: 4, Name: "Race", Type: "Ride", Distance: 80000, MovingTime: 7200, AverageHeartrate: 168},
+	}
+}
+
+func TestNewCustomStatistic_CountsFilteredActivities(t *testing.T) {
+	// GIVEN
+	expression, err := ParseCustomExpression("count() where commute or (distance > 100 km and sport_type == 'gravelride')")
+	if err != nil {
+		t.Fatalf("expected expression to parse, got %v", err)
+	}
+
+	// WHEN
+	stat := NewCustomStatistic("Commutes and gravel", expression, customStatisticActivities())
+
+	// THEN
+	if stat.Label() != "Commutes and gravel" || stat.Value() != "3" {
+		t.Fatalf("expected 3 activities, got %s = %s", stat.Label(), stat.Value())
+	}
+}
+
+func TestNewCustomStatistic_SkipsMissingHeartRate(t *testing.T) {
+	// GIVEN
+	expression, err := ParseCustomExpression("sum(distance) where average_heartrate < 150 bpm")
+	if err != nil {
+		t.Fatalf("expected expression to parse, got %v", err)
+	}
+
+	// WHEN
+	stat := NewCustomStatistic("Easy distance", expression, customStatisticActivities())
+
+	// THEN
+	if stat.Value() != "132.00 km" {
+		t.Fatalf("expected the activity without heart rate to be left out, got %s", stat.Value())
+	}
+}
+
+func TestNewCustomStatistic_Percentile(t *testing.T) {
+	// GIVEN
+	expression, err := ParseCustomExpression("percentile(50, moving_time)")
+	if err != nil {
+		t.Fatalf("expected expression to parse, got %v", err)
+	}
+
+	// WHEN
+	stat := NewCustomStatistic("Median moving time", expression, customStatisticActivities())
+
+	// THEN
+	if stat.Value() != formatSeconds(4550) {
+		t.Fatalf("expected %s, got %s", formatSeconds(4550), stat.Value())
+	}
+}
+
+func TestNewCustomStatistic_MaxLinksActivity(t *testing.T) {
+	// GIVEN
+	expression, err := ParseCustomExpression("max(average_heartrate) where not commute")
+	if err != nil {
+		t.Fatalf("expected expression to parse, got %v", err)
+	}
+
+	// WHEN
+	stat := NewCustomStatistic("Hardest effort", expression, customStatisticActivities())
+
+	// THEN
+	if stat.Value() != "168 bpm" {
+		t.Fatalf("expected 168 bpm, got %s", stat.Value())
+	}
+	if stat.Activity() == nil || stat.Activity().Id != 4 {
+		t.Fatalf("expected activity 4, got %+v", stat.Activity())
+	}
+}
+
+func TestNewCustomStatistic_BestDistanceUsesBestEffortStatistic(t *testing.T) {
+	// GIVEN
+	expression, err := ParseCustomExpression("best_distance(15 km) where not commute")
+	if err != nil {
+		t.Fatalf("expected expression to parse, got %v", err)
+	}
+
+	// WHEN
+	stat := NewCustomStatistic("Best 15 km", expression, customStatisticActivities())
+
+	// THEN
+	bestEffort, ok := stat.(*BestEffortDistanceStatistic)
+	if !ok {
+		t.Fatalf("expected a best effort distance statistic, got %T", stat)
+	}
+	if bestEffort.Distance != 15000 || len(bestEffort.Activities) != 2 {
+		t.Fatalf("expected 15000 m over 2 activities, got %.0f m over %d", bestEffort.Distance, len(bestEffort.Activities))
+	}
+}
+
+func TestParseCustomExpression_RejectsInvalidExpressions(t *testing.T) {
+	cases := map[string]string{
+		"":                                   "empty",
+		"median(distance)":                   "unknown function",
+		"sum(commute)":                       "not a numeric field",
+		"sum(heart_rate)":                    "expected an activity field",
+		"count() where distance > 30 min":    "unknown distance unit",
+		"count() where name > 'x'":           "can only be compared with == or !=",
+		"count() where distance = 10":        "use == or !=",
+		"best_distance(50 m)":                "at least 100 m",
+		"best_gradient(5000 km)":             "at most 1000 km",
+		"best_time(25 h)":                    "at most 24 h",
+		"best_power(1000000000)":             "at most 24 h",
+		"percentile(120, distance)":          "between 0 and 100",
+		"count() where commute distance > 1": "unexpected",
+		"count() where " + strings.Repeat("(", 40) + "commute" + strings.Repeat(")", 40): "nested too deeply",
+	}
+	for source, expected := range cases {
+		// WHEN
+		_, err := ParseCustomExpression(source)
+
+		// THEN
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected %q to fail with %q, got %v", source, expected, err)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package statistics
+
+import (
+	"math"
+	"mystravastats/internal/shared/domain/business"
+	"mystravastats/internal/shared/domain/strava"
+	"sort"
+)
+
+const (
+	paceCurveMinDistance = 100.0
+	paceCurveMaxDistance = 42195.0
+	// paceCurvePointsPerDecade spaces the curve distances evenly on a log
+	// scale: 100, 133, 178, 237, 316, 422, 562, 750 m, 1 km...
+	paceCurvePointsPerDecade = 8
+)
+
+// paceCurveRaceDistances are always on the curve, so that the usual race
+// distances show their actual best effort.
+var paceCurveRaceDistances = []float64{400, 1000, 1609.344, 5000, 10000, 21097.5, 42195}
+
+// PaceCurveDistances are the distances of the best pace curve, log-spaced
+// from 100 m to the marathon, with the race distances in place of the
+// nearest log-spaced ones.
+var PaceCurveDistances = paceCurveDistances()
+
+func paceCurveDistances() []float64 {
+	halfStep := math.Pow(10, 0.5/paceCurvePointsPerDecade)
+	distances := append([]float64{}, paceCurveRaceDistances...)
+	for step := 0; ; step++ {
+		distance := math.Round(paceCurveMinDistance * math.Pow(10, float64(step)/paceCurvePointsPerDecade))
+		if distance > paceCurveMaxDistance {
+			break
+		}
+		nearRace := false
+		for _, race := range paceCurveRaceDistances {
+			if distance > race/halfStep && distance < race*halfStep {
+				nearRace = true
+				break
+			}
+		}
+		if !nearRace {
+			distances = append(distances, distance)
+		}
+	}
+	sort.Float64s(distances)
+	return distances
+}
+
+// BestPaceCurve returns, for each of PaceCurveDistances, the fastest effort
+// of the activities, nil where no activity covers the distance. With
+// gradeAdjusted, distances are flat-equivalent ones.
+func BestPaceCurve(activities []*strava.Activity, gradeAdjusted bool) []*business.ActivityEffort {
+	findBest := FindBestActivityEffort
+	if gradeAdjusted {
+		findBest = FindBestGradeAdjustedActivityEffort
+	}
+	best := make([]*business.ActivityEffort, len(PaceCurveDistances))
+	for index, distance := range PaceCurveDistances {
+		best[index] = findBest(activities, distance)
+	}
+	return best
+}
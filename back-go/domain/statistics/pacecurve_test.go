@@ -0,0 +1,105 @@
+package statistics
+
+import (
+	"math"
+	"mystravastats/internal/shared/domain/business"
+	"mystravastats/internal/shared/domain/strava"
+	"testing"
+)
+
+func TestPaceCurveDistances_IncludeRaceDistances(t *testing.T) {
+	// WHEN
+	distances := PaceCurveDistances
+
+	// THEN
+	if distances[0] != 100 || distances[len(distances)-1] != 42195 {
+		t.Fatalf("expected distances from 100 m to the marathon, got %v", distances)
+	}
+	for index := 1; index < len(distances); index++ {
+		if distances[index] <= distances[index-1] {
+			t.Fatalf("expected increasing distances, got %v", distances)
+		}
+	}
+	for _, race := range []float64{5000, 10000, 21097.5} {
+		found := false
+		for _, distance := range distances {
+			found = found || distance == race
+		}
+		if !found {
+			t.Fatalf("expected %.1f m on the curve, got %v", race, distances)
+		}
+	}
+}
+
+func TestVdot_MatchesDanielsTables(t *testing.T) {
+	// WHEN: 5 km in 20:00 is a VDOT of about 49.8 in Daniels' tables
+	vdot := Vdot(5000, 20*60)
+
+	// THEN
+	if math.Abs(vdot-49.8) > 0.1 {
+		t.Fatalf("expected VDOT 49.8, got %.2f", vdot)
+	}
+	if seconds := VdotRaceTime(vdot, 5000); math.Abs(seconds-1200) > 0.1 {
+		t.Fatalf("expected 5 km back in 1200 s, got %.2f s", seconds)
+	}
+	if seconds := VdotRaceTime(vdot, 42195); seconds < 3*60*60 || seconds > 3*60*60+15*60 {
+		t.Fatalf("expected a marathon between 3:00 and 3:15, got %.0f s", seconds)
+	}
+}
+
+func TestRaceTimePredictors_ExtrapolateFromFiveKilometers(t *testing.T) {
+	// WHEN
+	riegel := RiegelRaceTime(5000, 1200, 10000)
+	cameron := CameronRaceTime(5000, 1200, 10000)
+
+	// THEN
+	if math.Abs(riegel-1200*math.Pow(2, 1.06)) > 1e-9 {
+		t.Fatalf("expected Riegel 10 km of %.1f s, got %.1f s", 1200*math.Pow(2, 1.06), riegel)
+	}
+	if math.Abs(cameron-riegel) > 10 {
+		t.Fatalf("expected Cameron close to Riegel over 10 km, got %.1f s and %.1f s", cameron, riegel)
+	}
+}
+
+func TestBestVdotEffort_IgnoresShortEfforts(t *testing.T) {
+	// GIVEN
+	efforts := []*business.ActivityEffort{
+		{Distance: 1000, Seconds: 180, ActivityShort: business.ActivityShort{Id: 1}},
+		{Distance: 5000, Seconds: 1200, ActivityShort: business.ActivityShort{Id: 2}},
+		nil,
+		{Distance: 10000, Seconds: 2700, ActivityShort: business.ActivityShort{Id: 3}},
+	}
+
+	// WHEN
+	effort, vdot := BestVdotEffort(efforts)
+
+	// THEN
+	if effort == nil || effort.ActivityShort.Id != 2 || math.Abs(vdot-Vdot(5000, 1200)) > 1e-9 {
+		t.Fatalf("expected the 5 km effort as reference, got %+v with VDOT %.2f", effort, vdot)
+	}
+}
+
+func TestBestGradeAdjustedTimeEffort_CreditsClimbs(t *testing.T) {
+	// GIVEN: 1.5 km at 1 m/s on a 10% grade
+	ClearBestEffortCache()
+	stream := &strava.Stream{Altitude: &strava.AltitudeStream{}}
+	for second := 0; second <= 1500; second++ {
+		stream.Distance.Data = append(stream.Distance.Data, float64(second))
+		stream.Time.Data = append(stream.Time.Data, second)
+		stream.Altitude.Data = append(stream.Altitude.Data, float64(second)/10)
+	}
+	activity := strava.Activity{Id: 1, Name: "Hill", Type: "TrailRun", Stream: stream}
+
+	// WHEN
+	raw := BestTimeEffort(activity, 1000)
+	adjusted := BestGradeAdjustedTimeEffort(activity, 1000)
+
+	// THEN
+	if raw == nil || raw.Seconds != 1000 {
+		t.Fatalf("expected 1000 s over 1 km, got %+v", raw)
+	}
+	expected := 1000 * 3.6 / strava.MinettiEnergyCost(0.1)
+	if adjusted == nil || math.Abs(float64(adjusted.Seconds)-expected) > 2 {
+		t.Fatalf("expected about %.0f s over a flat-equivalent 1 km, got %+v", expected, adjusted)
+	}
+}
@@ -0,0 +1,186 @@
+package statistics
+
+import (
+	"fmt"
+	"math"
+	"mystravastats/internal/shared/domain/business"
+	"mystravastats/internal/shared/domain/strava"
+	"sort"
+)
+
+const (
+	powerCurveMaxSeconds = 5 * 60 * 60
+	// powerCurvePointsPerDecade spaces the curve durations evenly on a log
+	// scale: 1, 2, 3, 4, 6, 7, 10, 13, 18, 24 s...
+	powerCurvePointsPerDecade = 8
+	// powerCurveMaxGapSeconds is the longest recording gap over which the
+	// last power sample is held. Longer gaps are pauses and count as 0 W.
+	powerCurveMaxGapSeconds = 10
+	// powerCurveMaxRecordingSeconds bounds the 1 Hz resampling of a stream
+	// whose time values are corrupted.
+	powerCurveMaxRecordingSeconds = 48 * 60 * 60
+)
+
+// PowerCurveDurations are the durations of the mean-maximal power curve,
+// log-spaced from 1 s to 5 h.
+var PowerCurveDurations = logSpacedDurations(powerCurveMaxSeconds, powerCurvePointsPerDecade)
+
+// logSpacedDurations ends with maxSeconds, dropping the step before it when
+// it is less than half a step away.
+func logSpacedDurations(maxSeconds int, pointsPerDecade int) []int {
+	durations := make([]int, 0)
+	lastStep := float64(maxSeconds) / math.Pow(10, 0.5/float64(pointsPerDecade))
+	for step := 0; ; step++ {
+		seconds := int(math.Round(math.Pow(10, float64(step)/float64(pointsPerDecade))))
+		if float64(seconds) > lastStep {
+			break
+		}
+		if len(durations) == 0 || seconds > durations[len(durations)-1] {
+			durations = append(durations, seconds)
+		}
+	}
+	return append(durations, maxSeconds)
+}
+
+// MeanMaxPowerCurve returns the best average power of the activity for each
+// of PowerCurveDurations, nil where the activity is too short or has no power.
+func MeanMaxPowerCurve(activity strava.Activity) []*business.ActivityEffort {
+	curve := make([]*business.ActivityEffort, len(PowerCurveDurations))
+	stream := activity.Stream
+	if stream == nil || stream.Watts == nil || len(stream.Watts.Data) == 0 || len(stream.Time.Data) == 0 {
+		return curve
+	}
+
+	var series *powerSeries
+	for index, seconds := range PowerCurveDurations {
+		curve[index] = getOrComputeBestEffort(
+			activity.Id,
+			"mean-max-power-v1",
+			effortSecondsTarget(seconds),
+			stream,
+			func() *business.ActivityEffort {
+				if series == nil {
+					series = newPowerSeries(stream)
+				}
+				return series.bestEffort(activity, seconds)
+			},
+		)
+	}
+	return curve
+}
+
+// BestMeanMaxPowerCurve merges the curves of the activities, keeping for each
+// duration the activity with the highest average power.
+func BestMeanMaxPowerCurve(activities []*strava.Activity) []*business.ActivityEffort {
+	best := make([]*business.ActivityEffort, len(PowerCurveDurations))
+	for _, activity := range activities {
+		if activity == nil {
+			continue
+		}
+		for index, effort := range MeanMaxPowerCurve(*activity) {
+			if effort == nil || effort.AveragePower == nil {
+				continue
+			}
+			if best[index] == nil || *effort.AveragePower > *best[index].AveragePower {
+				best[index] = effort
+			}
+		}
+	}
+	return best
+}
+
+// powerSeries is a power stream resampled at 1 Hz, stored as the cumulative
+// energy so that the average power of any window is a subtraction.
+type powerSeries struct {
+	stream *strava.Stream
+	size   int
+	energy []float64
+}
+
+func newPowerSeries(stream *strava.Stream) *powerSeries {
+	times := stream.Time.Data
+	watts := stream.Watts.Data
+	size := len(times)
+	if len(watts) < size {
+		size = len(watts)
+	}
+	series := &powerSeries{stream: stream, size: size}
+	if size == 0 {
+		return series
+	}
+
+	duration := times[size-1] - times[0] + 1
+	if duration < 1 {
+		return series
+	}
+	if duration > powerCurveMaxRecordingSeconds {
+		duration = powerCurveMaxRecordingSeconds
+	}
+	series.energy = make([]float64, duration+1)
+	filled := 0
+	for index := 0; index < size && filled < duration; index++ {
+		offset := times[index] - times[0]
+		next := duration
+		if index+1 < size && times[index+1]-times[0] < duration {
+			next = times[index+1] - times[0]
+		}
+		power := math.Max(watts[index], 0)
+		for second := max(offset, filled); second < next; second++ {
+			value := power
+			if second-offset >= powerCurveMaxGapSeconds {
+				value = 0
+			}
+			series.energy[second+1] = series.energy[second] + value
+		}
+		filled = max(filled, next)
+	}
+	series.energy = series.energy[:filled+1]
+	return series
+}
+
+func (series *powerSeries) bestEffort(activity strava.Activity, seconds int) *business.ActivityEffort {
+	if len(series.energy)-1 < seconds {
+		return nil
+	}
+
+	bestEnergy, bestStart := 0.0, -1
+	for start := 0; start+seconds < len(series.energy); start++ {
+		if energy := series.energy[start+seconds] - series.energy[start]; energy > bestEnergy {
+			bestEnergy, bestStart = energy, start
+		}
+	}
+	if bestStart < 0 {
+		return nil
+	}
+
+	averagePower := bestEnergy / float64(seconds)
+	idxStart := series.sampleIndex(bestStart)
+	idxEnd := series.sampleIndex(bestStart + seconds - 1)
+	distance := 0.0
+	if distances := series.stream.Distance.Data; idxEnd < len(distances) {
+		distance = distances[idxEnd] - distances[idxStart]
+	}
+	return &business.ActivityEffort{
+		Distance:     distance,
+		Seconds:      seconds,
+		IdxStart:     idxStart,
+		IdxEnd:       idxEnd,
+		AveragePower: &averagePower,
+		Label:        fmt.Sprintf("Best power for %s", formatSeconds(seconds)),
+		ActivityShort: business.ActivityShort{
+			Id:   activity.Id,
+			Name: activity.Name,
+			Type: business.ActivityTypes[activity.Type],
+		},
+	}
+}
+
+// sampleIndex returns the index of the stream sample recorded at offset
+// seconds from the start, or of the last sample before it.
+func (series *powerSeries) sampleIndex(offset int) int {
+	times := series.stream.Time.Data[:series.size]
+	index := sort.Search(len(times), func(index int) bool {
+		return times[index]-times[0] > offset
+	})
+	return max(index-1, 0)
+}
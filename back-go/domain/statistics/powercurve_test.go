@@ -0,0 +1,152 @@
+package statistics
+
+import (
+	"math"
+	"mystravastats/internal/shared/domain/business"
+	"mystravastats/internal/shared/domain/strava"
+	"testing"
+)
+
+func powerCurveActivity(id int64, times []int, watts []float64) *strava.Activity {
+	distances := make([]float64, len(times))
+	for index := range times {
+		distances[index] = float64(times[index]) * 10
+	}
+	return &strava.Activity{
+		Id:   id,
+		Name: "Power ride",
+		Type: "Ride",
+		Stream: &strava.Stream{
+			Distance: strava.DistanceStream{Data: distances},
+			Time:     strava.TimeStream{Data: times},
+			Watts:    &strava.PowerStream{Data: watts},
+		},
+	}
+}
+
+func TestLogSpacedDurations_CoverOneSecondToFiveHours(t *testing.T) {
+	// WHEN
+	durations := PowerCurveDurations
+
+	// THEN
+	if durations[0] != 1 || durations[len(durations)-1] != 5*60*60 {
+		t.Fatalf("expected durations from 1 s to 5 h, got %v", durations)
+	}
+	for index := 1; index < len(durations); index++ {
+		if durations[index] <= durations[index-1] {
+			t.Fatalf("expected increasing durations, got %v", durations)
+		}
+	}
+}
+
+func TestMeanMaxPowerCurve_HoldsShortGapsAndDropsPauses(t *testing.T) {
+	// GIVEN: 200 W for 60 s, a 3 s recording gap at 300 W, then a 60 s pause
+	ClearBestEffortCache()
+	times := []int{}
+	watts := []float64{}
+	for second := 0; second < 60; second++ {
+		times = append(times, second)
+		watts = append(watts, 200)
+	}
+	times = append(times, 60, 63, 123)
+	watts = append(watts, 300, 300, 100)
+	activity := powerCurveActivity(1, times, watts)
+
+	// WHEN
+	curve := MeanMaxPowerCurve(*activity)
+
+	// THEN
+	best3s := curve[2]
+	if best3s == nil || *best3s.AveragePower != 300 {
+		t.Fatalf("expected the 3 s gap to hold 300 W, got %+v", best3s)
+	}
+	best13s := curve[7]
+	if PowerCurveDurations[7] != 13 || best13s == nil || *best13s.AveragePower != 300 {
+		t.Fatalf("expected 300 W held for the first 10 s of the pause, got %+v", best13s)
+	}
+	best18s := curve[8]
+	expected := (5*200 + 13*300) / 18.0
+	if best18s == nil || math.Abs(*best18s.AveragePower-expected) > 1e-9 {
+		t.Fatalf("expected %.1f W over 18 s, got %+v", expected, best18s)
+	}
+	if curve[len(curve)-1] != nil {
+		t.Fatalf("expected no 5 h effort for a 2 min activity, got %+v", curve[len(curve)-1])
+	}
+	if best3s.ActivityShort.Id != 1 || best3s.IdxStart != 60 {
+		t.Fatalf("expected the effort to start at sample 60 of activity 1, got %+v", best3s)
+	}
+}
+
+func TestBestMeanMaxPowerCurve_KeepsBestActivityPerDuration(t *testing.T) {
+	// GIVEN
+	ClearBestEffortCache()
+	sprint := powerCurveActivity(1, []int{0, 1, 2, 3, 4}, []float64{900, 900, 100, 100, 100})
+	steady := powerCurveActivity(2, []int{0, 1, 2, 3, 4}, []float64{600, 600, 600, 600, 600})
+
+	// WHEN
+	curve := BestMeanMaxPowerCurve([]*strava.Activity{sprint, steady})
+
+	// THEN
+	if curve[0].ActivityShort.Id != 1 || *curve[0].AveragePower != 900 {
+		t.Fatalf("expected 1 s from the sprint, got %+v", curve[0])
+	}
+	if curve[3].ActivityShort.Id != 2 || *curve[3].AveragePower != 600 {
+		t.Fatalf("expected 4 s from the steady ride, got %+v", curve[3])
+	}
+}
+
+func TestFitCriticalPower_RecoversModelParameters(t *testing.T) {
+	// GIVEN
+	points := modelPowerCurve(business.CriticalPowerModel{CriticalPower: 250, WPrime: 20000})
+
+	// WHEN
+	model := FitCriticalPower(points)
+
+	// THEN
+	if model == nil {
+		t.Fatalf("expected a fitted model")
+	}
+	if math.Abs(model.CriticalPower-250) > 0.01 || math.Abs(model.WPrime-20000) > 1 {
+		t.Fatalf("expected CP 250 W and W' 20000 J, got %.2f W and %.0f J", model.CriticalPower, model.WPrime)
+	}
+	if model.RSquared < 0.999 || model.PointCount < 3 {
+		t.Fatalf("expected an exact fit over several points, got R2 %.4f over %d points", model.RSquared, model.PointCount)
+	}
+}
+
+func TestFitMortonCriticalPower_RecoversMaximalPower(t *testing.T) {
+	// GIVEN
+	points := modelPowerCurve(business.CriticalPowerModel{CriticalPower: 250, WPrime: 20000, K: -25})
+
+	// WHEN
+	model := FitMortonCriticalPower(points)
+
+	// THEN
+	if model == nil || model.MaxPower == nil {
+		t.Fatalf("expected a fitted model with a maximal power")
+	}
+	if model.K != -25 || math.Abs(model.CriticalPower-250) > 0.01 || math.Abs(*model.MaxPower-1050) > 0.1 {
+		t.Fatalf("expected K -25, CP 250 W and Pmax 1050 W, got %.0f, %.2f W and %.1f W", model.K, model.CriticalPower, *model.MaxPower)
+	}
+}
+
+func TestFitCriticalPower_NeedsEnoughPoints(t *testing.T) {
+	// GIVEN
+	points := []business.PowerCurvePoint{{Seconds: 300, Watts: 320}, {Seconds: 600, Watts: 290}}
+
+	// WHEN
+	model := FitCriticalPower(points)
+
+	// THEN
+	if model != nil {
+		t.Fatalf("expected no model from two points, got %+v", model)
+	}
+}
+
+func modelPowerCurve(model business.CriticalPowerModel) []business.PowerCurvePoint {
+	points := make([]business.PowerCurvePoint, 0, len(PowerCurveDurations))
+	for _, seconds := range PowerCurveDurations {
+		points = append(points, business.PowerCurvePoint{Seconds: seconds, Watts: PredictCriticalPower(model, float64(seconds))})
+	}
+	return points
+}
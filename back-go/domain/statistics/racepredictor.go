@@ -0,0 +1,87 @@
+package statistics
+
+import (
+	"math"
+	"mystravastats/internal/shared/domain/business"
+)
+
+const (
+	riegelExponent = 1.06
+	metersPerMile  = 1609.344
+	// Efforts shorter than 3 km are often intervals inside a longer run and
+	// overstate endurance, so they are not used as a reference.
+	raceReferenceMinDistance = 3000.0
+	raceReferenceMaxDistance = 42195.0
+)
+
+// RacePredictionTarget is a race distance the predictor estimates a time for.
+type RacePredictionTarget struct {
+	Label    string
+	Distance float64
+}
+
+var RacePredictionTargets = []RacePredictionTarget{
+	{Label: "5 km", Distance: 5000},
+	{Label: "10 km", Distance: 10000},
+	{Label: "Half marathon", Distance: 21097.5},
+	{Label: "Marathon", Distance: 42195},
+}
+
+// RiegelRaceTime predicts the time over targetDistance from a performance
+// with Riegel's power law, T2 = T1·(D2/D1)^1.06.
+func RiegelRaceTime(distance float64, seconds float64, targetDistance float64) float64 {
+	return seconds * math.Pow(targetDistance/distance, riegelExponent)
+}
+
+// CameronRaceTime predicts the time over targetDistance from a performance
+// with Dave Cameron's model, which bends less than Riegel's on long distances.
+func CameronRaceTime(distance float64, seconds float64, targetDistance float64) float64 {
+	return seconds * (targetDistance / distance) * cameronFactor(distance) / cameronFactor(targetDistance)
+}
+
+func cameronFactor(distance float64) float64 {
+	miles := distance / metersPerMile
+	return 13.49681 - 0.048865*miles + 2.438936/math.Pow(miles, 0.7905)
+}
+
+// Vdot returns the Daniels-Gilbert VDOT of a performance: the oxygen cost of
+// its speed divided by the fraction of VO2max sustainable for its duration.
+func Vdot(distance float64, seconds float64) float64 {
+	minutes := seconds / 60
+	velocity := distance / minutes
+	oxygenCost := -4.60 + 0.182258*velocity + 0.000104*velocity*velocity
+	fraction := 0.8 + 0.1894393*math.Exp(-0.012778*minutes) + 0.2989558*math.Exp(-0.1932605*minutes)
+	return oxygenCost / fraction
+}
+
+// VdotRaceTime returns the time over distance of a runner with the given
+// VDOT. VDOT decreases with the time over a distance, so it is found by
+// bisection between 60 km/h and 3 km/h.
+func VdotRaceTime(vdot float64, distance float64) float64 {
+	low, high := distance/1000*60, distance/50*60
+	for iteration := 0; iteration < 100 && high-low > 0.01; iteration++ {
+		middle := (low + high) / 2
+		if Vdot(distance, middle) > vdot {
+			low = middle
+		} else {
+			high = middle
+		}
+	}
+	return (low + high) / 2
+}
+
+// BestVdotEffort returns the effort with the highest VDOT between 3 km and
+// the marathon, and that VDOT.
+func BestVdotEffort(efforts []*business.ActivityEffort) (*business.ActivityEffort, float64) {
+	var best *business.ActivityEffort
+	bestVdot := 0.0
+	for _, effort := range efforts {
+		if effort == nil || effort.Seconds <= 0 || effort.Distance < raceReferenceMinDistance || effort.Distance > raceReferenceMaxDistance {
+			continue
+		}
+		if vdot := Vdot(effort.Distance, float64(effort.Seconds)); vdot > bestVdot {
+			best, bestVdot = effort, vdot
+		}
+	}
+	return best, bestVdot
+}
@@ -39,6 +39,8 @@ type ActivityComparisonCriteria struct {
 	ActivityType string
 	Year         int
 	SampleSize   int
+	// GradeAdjusted tells that the status compares grade-adjusted speeds.
+	GradeAdjusted bool
 }
 
 type ActivityComparisonBaseline struct {
@@ -49,6 +51,8 @@ type ActivityComparisonBaseline struct {
 	AverageHeartrate float64
 	AverageWatts     float64
 	AverageCadence   float64
+
+	AverageGradeAdjustedSpeed float64
 }
 
 type ActivityComparisonDeltas struct {
@@ -60,6 +64,9 @@ type ActivityComparisonDeltas struct {
 	AverageHeartrate float64
 	AverageWatts     float64
 	AverageCadence   float64
+
+	AverageGradeAdjustedSpeed    float64
+	AverageGradeAdjustedSpeedPct float64
 }
 
 type ActivityComparisonActivity struct {
@@ -74,6 +81,8 @@ type ActivityComparisonActivity struct {
 	AverageWatts     float64
 	AverageCadence   float64
 	SimilarityScore  float64
+
+	AverageGradeAdjustedSpeed float64
 }
 
 type ActivityComparisonSegment struct {
@@ -117,9 +126,20 @@ func (uc *GetActivityComparisonUseCase) Execute(target *strava.DetailedActivity)
 		return comparison
 	}
 
+	targetGradeAdjustedSpeed := 0.0
+	if strava.IsOnFoot(activityType.String()) {
+		targetGradeAdjustedSpeed = strava.GradeAdjustedSpeed(target.AverageSpeed, target.Stream)
+		fillGradeAdjustedSpeeds(selected, candidates)
+	}
+
 	comparison.Baseline = buildComparisonBaseline(selected)
-	comparison.Deltas = buildComparisonDeltas(target, comparison.Baseline)
-	comparison.Status, comparison.Label = classifyComparison(comparison.Deltas)
+	comparison.Deltas = buildComparisonDeltas(target, targetGradeAdjustedSpeed, comparison.Baseline)
+	comparison.Criteria.GradeAdjusted = targetGradeAdjustedSpeed > 0 && comparison.Baseline.AverageGradeAdjustedSpeed > 0
+	speedPct := comparison.Deltas.AverageSpeedPct
+	if comparison.Criteria.GradeAdjusted {
+		speedPct = comparison.Deltas.AverageGradeAdjustedSpeedPct
+	}
+	comparison.Status, comparison.Label = classifyComparison(speedPct)
 	comparison.CommonSegments = findCommonSegments(target, selected, uc.reader)
 	return comparison
 }
@@ -215,6 +235,22 @@ func topSimilarActivities(ranked []ActivityComparisonActivity, limit int) []Acti
 	return append([]ActivityComparisonActivity(nil), ranked[:limit]...)
 }
 
+// fillGradeAdjustedSpeeds sets the grade-adjusted speed of the selected
+// activities from the streams of the candidates they were picked from.
+func fillGradeAdjustedSpeeds(selected []ActivityComparisonActivity, candidates []*strava.Activity) {
+	byID := make(map[int64]*strava.Activity, len(candidates))
+	for _, candidate := range candidates {
+		if candidate != nil {
+			byID[candidate.Id] = candidate
+		}
+	}
+	for index := range selected {
+		if candidate := byID[selected[index].ID]; candidate != nil {
+			selected[index].AverageGradeAdjustedSpeed = strava.GradeAdjustedSpeed(candidate.AverageSpeed, candidate.Stream)
+		}
+	}
+}
+
 func buildComparisonBaseline(activities []ActivityComparisonActivity) ActivityComparisonBaseline {
 	return ActivityComparisonBaseline{
 		Distance:         averageFloat(activities, func(activity ActivityComparisonActivity) float64 { return activity.Distance }, false),
@@ -224,11 +260,17 @@ func buildComparisonBaseline(activities []ActivityComparisonActivity) ActivityCo
 		AverageHeartrate: averageFloat(activities, func(activity ActivityComparisonActivity) float64 { return activity.AverageHeartrate }, true),
 		AverageWatts:     averageFloat(activities, func(activity ActivityComparisonActivity) float64 { return activity.AverageWatts }, true),
 		AverageCadence:   averageFloat(activities, func(activity ActivityComparisonActivity) float64 { return activity.AverageCadence }, true),
+
+		AverageGradeAdjustedSpeed: averageFloat(activities, func(activity ActivityComparisonActivity) float64 { return activity.AverageGradeAdjustedSpeed }, true),
 	}
 }
 
-func buildComparisonDeltas(target *strava.DetailedActivity, baseline ActivityComparisonBaseline) ActivityComparisonDeltas {
+func buildComparisonDeltas(target *strava.DetailedActivity, targetGradeAdjustedSpeed float64, baseline ActivityComparisonBaseline) ActivityComparisonDeltas {
 	speedDelta := finiteDelta(target.AverageSpeed, baseline.AverageSpeed)
+	gradeAdjustedSpeedDelta := 0.0
+	if targetGradeAdjustedSpeed > 0 {
+		gradeAdjustedSpeedDelta = finiteDelta(targetGradeAdjustedSpeed, baseline.AverageGradeAdjustedSpeed)
+	}
 	return ActivityComparisonDeltas{
 		Distance:         finiteDelta(target.Distance, baseline.Distance),
 		ElevationGain:    finiteDelta(target.TotalElevationGain, baseline.ElevationGain),
@@ -238,17 +280,20 @@ func buildComparisonDeltas(target *strava.DetailedActivity, baseline ActivityCom
 		AverageHeartrate: finiteDelta(target.AverageHeartrate, baseline.AverageHeartrate),
 		AverageWatts:     finiteDelta(target.AverageWatts, baseline.AverageWatts),
 		AverageCadence:   finiteDelta(target.AverageCadence, baseline.AverageCadence),
+
+		AverageGradeAdjustedSpeed:    gradeAdjustedSpeedDelta,
+		AverageGradeAdjustedSpeedPct: percentageDelta(gradeAdjustedSpeedDelta, baseline.AverageGradeAdjustedSpeed),
 	}
 }
 
-func classifyComparison(deltas ActivityComparisonDeltas) (string, string) {
-	if math.Abs(deltas.AverageSpeedPct) >= 15 {
+func classifyComparison(speedPct float64) (string, string) {
+	if math.Abs(speedPct) >= 15 {
 		return "atypical", "Atypical pace for similar activities"
 	}
-	if deltas.AverageSpeedPct >= 5 {
+	if speedPct >= 5 {
 		return "faster", "Faster than similar activities"
 	}
-	if deltas.AverageSpeedPct <= -5 {
+	if speedPct <= -5 {
 		return "slower", "Slower than similar activities"
 	}
 	return "typical", "In line with similar activities"
@@ -132,6 +132,35 @@ func TestGetActivityComparisonUseCase_Execute_KeepsFlatActivitiesWithSmallElevat
 	}
 }
 
+func TestGetActivityComparisonUseCase_Execute_ClassifiesRunsOnGradeAdjustedSpeed(t *testing.T) {
+	// GIVEN: a hilly trail run, slower than two flat runs on raw speed
+	target := comparisonDetailedActivity(100, "Hill repeats", "2025-05-10T09:00:00Z", 10_000, 300, 2.0)
+	target.Type, target.SportType = "TrailRun", "TrailRun"
+	target.Stream = comparisonStream(10_000, 10)
+	flatA := comparisonActivity(1, "Flat A", "2025-05-01T09:00:00Z", 10_000, 300, 3.0)
+	flatB := comparisonActivity(2, "Flat B", "2025-05-03T09:00:00Z", 10_000, 300, 3.0)
+	for _, activity := range []*strava.Activity{flatA, flatB} {
+		activity.Type, activity.SportType = "TrailRun", "TrailRun"
+		activity.Stream = comparisonStream(10_000, 0)
+	}
+	reader := &activityComparisonReaderStub{activities: []*strava.Activity{flatA, flatB}}
+
+	// WHEN
+	comparison := NewGetActivityComparisonUseCase(reader).Execute(target)
+
+	// THEN
+	if comparison == nil || !comparison.Criteria.GradeAdjusted {
+		t.Fatalf("expected a grade-adjusted comparison, got %#v", comparison)
+	}
+	if comparison.Deltas.AverageSpeedPct > -30 || comparison.Deltas.AverageGradeAdjustedSpeedPct < 5 {
+		t.Fatalf("expected slower raw and faster grade-adjusted speed, got %.1f%% and %.1f%%",
+			comparison.Deltas.AverageSpeedPct, comparison.Deltas.AverageGradeAdjustedSpeedPct)
+	}
+	if comparison.Status != "faster" {
+		t.Fatalf("expected faster status on grade-adjusted speed, got %q", comparison.Status)
+	}
+}
+
 func comparisonDetailedActivity(id int64, name string, date string, distance float64, elevation float64, speed float64) *strava.DetailedActivity {
 	return &strava.DetailedActivity{
 		Id:                 id,
@@ -168,6 +197,17 @@ func comparisonActivity(id int64, name string, date string, distance float64, el
 	}
 }
 
+// comparisonStream returns a stream climbing at a constant grade, in percent.
+func comparisonStream(distance float64, grade float64) *strava.Stream {
+	stream := &strava.Stream{Altitude: &strava.AltitudeStream{}}
+	for meters := 0.0; meters <= distance; meters += 10 {
+		stream.Distance.Data = append(stream.Distance.Data, meters)
+		stream.Time.Data = append(stream.Time.Data, int(meters))
+		stream.Altitude.Data = append(stream.Altitude.Data, meters*grade/100)
+	}
+	return stream
+}
+
 func comparisonSegmentEffort(segmentID int64, name string) strava.SegmentEffort {
 	return strava.SegmentEffort{
 		Segment: strava.Segment{
@@ -13,14 +13,14 @@ import (
 	"time"
 )
 
-func computeExportCSVByYearAndTypes(year *int, activityTypes ...business.ActivityType) string {
+func computeExportCSVByYearAndTypes(provider activityprovider.ActivityProvider, year *int, activityTypes ...business.ActivityType) string {
 	if year == nil {
 		log.Printf("Get export CSV by activity (%s) type for all years", activityTypes)
 	} else {
 		log.Printf("Get export CSV by activity (%s) type by year (%d)", activityTypes, *year)
 	}
 
-	activities := dataqualityInfra.ApplyCurrentProviderCorrections(activityprovider.Get().GetActivitiesByYearAndActivityTypes(year, activityTypes...))
+	activities := dataqualityInfra.ApplyProviderCorrections(provider, provider.GetActivitiesByYearAndActivityTypes(year, activityTypes...))
 
 	switch activityTypes[0] {
 	case business.Ride, business.VirtualRide, business.MountainBikeRide, business.GravelRide, business.Commute:
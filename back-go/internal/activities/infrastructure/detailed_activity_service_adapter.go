@@ -12,10 +12,12 @@ import (
 )
 
 // DetailedActivityServiceAdapter computes activity read models from provider data.
-type DetailedActivityServiceAdapter struct{}
+type DetailedActivityServiceAdapter struct {
+	providers activityprovider.Lookup
+}
 
-func NewDetailedActivityServiceAdapter() *DetailedActivityServiceAdapter {
-	return &DetailedActivityServiceAdapter{}
+func NewDetailedActivityServiceAdapter(providers activityprovider.Lookup) *DetailedActivityServiceAdapter {
+	return &DetailedActivityServiceAdapter{providers: providers}
 }
 
 func (adapter *DetailedActivityServiceAdapter) FindDetailedActivityByID(activityID int64) (*strava.DetailedActivity, error) {
@@ -23,11 +25,11 @@ func (adapter *DetailedActivityServiceAdapter) FindDetailedActivityByID(activity
 	if err != nil {
 		return nil, err
 	}
-	return dataqualityInfra.ApplyCurrentProviderCorrectionsToDetailedActivity(detailedActivity), nil
+	return dataqualityInfra.ApplyProviderCorrectionsToDetailedActivity(adapter.providers(), detailedActivity), nil
 }
 
 func (adapter *DetailedActivityServiceAdapter) FindRawDetailedActivityByID(activityID int64) (*strava.DetailedActivity, error) {
-	detailedActivity := activityprovider.Get().GetDetailedActivity(activityID)
+	detailedActivity := adapter.providers().GetDetailedActivity(activityID)
 	if detailedActivity == nil {
 		return nil, fmt.Errorf("activity %d not found", activityID)
 	}
@@ -35,23 +37,25 @@ func (adapter *DetailedActivityServiceAdapter) FindRawDetailedActivityByID(activ
 }
 
 func (adapter *DetailedActivityServiceAdapter) FindCachedDetailedActivityByID(activityID int64) *strava.DetailedActivity {
-	detailedActivity := activityprovider.Get().GetCachedDetailedActivity(activityID)
+	detailedActivity := adapter.providers().GetCachedDetailedActivity(activityID)
 	if detailedActivity == nil {
 		return nil
 	}
-	return dataqualityInfra.ApplyCurrentProviderCorrectionsToDetailedActivity(detailedActivity)
+	return dataqualityInfra.ApplyProviderCorrectionsToDetailedActivity(adapter.providers(), detailedActivity)
 }
 
 func (adapter *DetailedActivityServiceAdapter) FindActivitiesByYearAndTypes(year *int, activityTypes ...business.ActivityType) []*strava.Activity {
-	return dataqualityInfra.ApplyCurrentProviderCorrections(activityprovider.Get().GetActivitiesByYearAndActivityTypes(year, activityTypes...))
+	provider := adapter.providers()
+	return dataqualityInfra.ApplyProviderCorrections(provider, provider.GetActivitiesByYearAndActivityTypes(year, activityTypes...))
 }
 
 func (adapter *DetailedActivityServiceAdapter) ExportCSVByYearAndTypes(year *int, activityTypes ...business.ActivityType) string {
-	return computeExportCSVByYearAndTypes(year, activityTypes...)
+	return computeExportCSVByYearAndTypes(adapter.providers(), year, activityTypes...)
 }
 
 func (adapter *DetailedActivityServiceAdapter) FindGPXByYearAndTypes(year *int, activityTypes ...business.ActivityType) []application.MapTrack {
-	activities := dataqualityInfra.ApplyCurrentProviderCorrections(activityprovider.Get().GetActivitiesByYearAndActivityTypes(year, activityTypes...))
+	provider := adapter.providers()
+	activities := dataqualityInfra.ApplyProviderCorrections(provider, provider.GetActivitiesByYearAndActivityTypes(year, activityTypes...))
 
 	step := 100
 	if year != nil {
@@ -93,8 +97,9 @@ func (adapter *DetailedActivityServiceAdapter) FindGPXByYearAndTypes(year *int,
 }
 
 func (adapter *DetailedActivityServiceAdapter) FindPassagesByYearAndTypes(year *int, activityTypes ...business.ActivityType) application.MapPassagesResponse {
-	activities := dataqualityInfra.ApplyCurrentProviderCorrections(activityprovider.Get().GetActivitiesByYearAndActivityTypes(year, activityTypes...))
-	return computeMapPassagesWithOptions(activities, dataqualityInfra.CurrentProviderExclusions(), mapPassageOptionsForYear(year))
+	provider := adapter.providers()
+	activities := dataqualityInfra.ApplyProviderCorrections(provider, provider.GetActivitiesByYearAndActivityTypes(year, activityTypes...))
+	return computeMapPassagesWithOptions(activities, dataqualityInfra.ProviderExclusions(provider), mapPassageOptionsForYear(year))
 }
 
 func resolveMapTrackActivityType(activity *strava.Activity) string {
@@ -9,24 +9,27 @@ import (
 
 // AthleteServiceAdapter bridges the current internal/services layer
 // to the hexagonal outbound ports used by athlete use cases.
-type AthleteServiceAdapter struct{}
+type AthleteServiceAdapter struct {
+	providers activityprovider.Lookup
+}
 
-func NewAthleteServiceAdapter() *AthleteServiceAdapter {
-	return &AthleteServiceAdapter{}
+func NewAthleteServiceAdapter(providers activityprovider.Lookup) *AthleteServiceAdapter {
+	return &AthleteServiceAdapter{providers: providers}
 }
 
 func (adapter *AthleteServiceAdapter) FindAthlete() strava.Athlete {
-	return activityprovider.Get().GetAthlete()
+	return adapter.providers().GetAthlete()
 }
 
 func (adapter *AthleteServiceAdapter) FindActivitiesByYearAndTypes(year *int, activityTypes ...business.ActivityType) []*strava.Activity {
-	return dataqualityInfra.FilterExcludedFromStats(activityprovider.Get().GetActivitiesByYearAndActivityTypes(year, activityTypes...))
+	provider := adapter.providers()
+	return dataqualityInfra.FilterExcludedFromStats(provider, provider.GetActivitiesByYearAndActivityTypes(year, activityTypes...))
 }
 
 func (adapter *AthleteServiceAdapter) FindPerformanceSettings() business.AthletePerformanceSettings {
-	return activityprovider.Get().GetPerformanceSettings()
+	return adapter.providers().GetPerformanceSettings()
 }
 
 func (adapter *AthleteServiceAdapter) SavePerformanceSettings(settings business.AthletePerformanceSettings) business.AthletePerformanceSettings {
-	return activityprovider.Get().SavePerformanceSettings(settings)
+	return adapter.providers().SavePerformanceSettings(settings)
 }
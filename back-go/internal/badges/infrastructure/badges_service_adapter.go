@@ -23,10 +23,12 @@ var (
 )
 
 // BadgesServiceAdapter computes badges directly from provider activities.
-type BadgesServiceAdapter struct{}
+type BadgesServiceAdapter struct {
+	providers activityprovider.Lookup
+}
 
-func NewBadgesServiceAdapter() *BadgesServiceAdapter {
-	return &BadgesServiceAdapter{}
+func NewBadgesServiceAdapter(providers activityprovider.Lookup) *BadgesServiceAdapter {
+	return &BadgesServiceAdapter{providers: providers}
 }
 
 func (adapter *BadgesServiceAdapter) FindGeneralBadges(year *int, activityTypes ...business.ActivityType) []business.BadgeCheckResult {
@@ -35,7 +37,8 @@ func (adapter *BadgesServiceAdapter) FindGeneralBadges(year *int, activityTypes
 		return []business.BadgeCheckResult{}
 	}
 
-	activities := dataqualityInfra.FilterExcludedFromStats(activityprovider.Get().GetActivitiesByYearAndActivityTypes(year, activityTypes...))
+	provider := adapter.providers()
+	activities := dataqualityInfra.FilterExcludedFromStats(provider, provider.GetActivitiesByYearAndActivityTypes(year, activityTypes...))
 	return checkGeneralBadges(activities, activityTypes...)
 }
 
@@ -75,7 +78,8 @@ func (adapter *BadgesServiceAdapter) FindFamousBadges(year *int, activityTypes .
 		pyreneesBadgeSet = loadBadgeSet("pyrenees", "strava-cache/famous-climb/pyrenees.json")
 	})
 
-	activities := dataqualityInfra.FilterExcludedFromStats(activityprovider.Get().GetActivitiesByYearAndActivityTypes(year, activityTypes...))
+	provider := adapter.providers()
+	activities := dataqualityInfra.FilterExcludedFromStats(provider, provider.GetActivitiesByYearAndActivityTypes(year, activityTypes...))
 	representativeActivityType, ok := business.RepresentativeBadgeActivityType(activityTypes...)
 	if ok && representativeActivityType == business.Ride {
 		return append(alpesBadgeSet.Check(activities), pyreneesBadgeSet.Check(activities)...)
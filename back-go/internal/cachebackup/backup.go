@@ -0,0 +1,216 @@
+// Package cachebackup writes consistent tar.gz snapshots of an athlete cache
+// and restores them.
+//
+// A snapshot holds, for each source of the athlete, the strava-<clientId>
+// cache directories (activities, streams, detailed activities, settings,
+// goals, custom statistics, corrections, exclusions, gear maintenance, merge
+// overrides, local indexes and manifests) and cache-schema.json. Credentials,
+// the sync history and the raw FIT/GPX/TCX/JSON files are left out. Each
+// entry is stored as <source>/<path relative to the source directory>,
+// followed by manifest.json listing the size and SHA-256 of every file.
+package cachebackup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"mystravastats/internal/platform/cachewrite"
+)
+
+const (
+	// FormatVersion is the snapshot layout written by Create.
+	FormatVersion = 1
+
+	manifestFileName    = "manifest.json"
+	schemaStateFileName = "cache-schema.json"
+	cacheDirectoryGlob  = "strava-*"
+	backupsDirectory    = "cache-backups"
+	tempFileSuffix      = ".tmp"
+)
+
+// Manifest describes the content of a snapshot.
+type Manifest struct {
+	FormatVersion int            `json:"formatVersion"`
+	CreatedAt     string         `json:"createdAt"`
+	AthleteID     string         `json:"athleteId"`
+	Sources       []string       `json:"sources"`
+	Files         []ManifestFile `json:"files"`
+}
+
+// ManifestFile is one file of a snapshot, with its path inside the archive.
+type ManifestFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// FileName returns the conventional name of a snapshot taken at createdAt.
+func FileName(athleteID string, createdAt time.Time) string {
+	return fmt.Sprintf("mystravastats-cache-%s-%s.tar.gz", athleteID, createdAt.UTC().Format("20060102T150405Z"))
+}
+
+// Create writes a snapshot of roots, keyed by source name, to writer. Cache
+// writes are paused until the snapshot is complete, so writer should be a
+// local file rather than a slow network connection.
+func Create(writer io.Writer, athleteID string, roots map[string]string) (Manifest, error) {
+	roots = distinctRoots(roots)
+	manifest := Manifest{
+		FormatVersion: FormatVersion,
+		CreatedAt:     time.Now().UTC().Format(time.RFC3339),
+		AthleteID:     athleteID,
+		Sources:       sortedSources(roots),
+		Files:         []ManifestFile{},
+	}
+
+	resume := cachewrite.Pause()
+	defer resume()
+
+	gzipWriter := gzip.NewWriter(writer)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for _, source := range manifest.Sources {
+		files, err := cacheFiles(roots[source])
+		if err != nil {
+			return Manifest{}, fmt.Errorf("list %s cache: %w", source, err)
+		}
+		for _, relative := range files {
+			entry, ok, err := addFile(tarWriter, filepath.Join(roots[source], filepath.FromSlash(relative)), path.Join(source, relative))
+			if err != nil {
+				return Manifest{}, err
+			}
+			if ok {
+				manifest.Files = append(manifest.Files, entry)
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return Manifest{}, fmt.Errorf("encode manifest: %w", err)
+	}
+	if err := tarWriter.WriteHeader(&tar.Header{
+		Name:    manifestFileName,
+		Mode:    0o600,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}); err != nil {
+		return Manifest{}, fmt.Errorf("write manifest: %w", err)
+	}
+	if _, err := tarWriter.Write(data); err != nil {
+		return Manifest{}, fmt.Errorf("write manifest: %w", err)
+	}
+	if err := tarWriter.Close(); err != nil {
+		return Manifest{}, fmt.Errorf("close archive: %w", err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return Manifest{}, fmt.Errorf("close archive: %w", err)
+	}
+	return manifest, nil
+}
+
+// cacheFiles lists the files of a source directory that belong in a
+// snapshot, as slash-separated paths relative to root.
+func cacheFiles(root string) ([]string, error) {
+	entries, err := os.ReadDir(root)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0)
+	for _, entry := range entries {
+		if !isCacheEntry(entry.Name()) {
+			continue
+		}
+		if !entry.IsDir() {
+			if entry.Type().IsRegular() {
+				files = append(files, entry.Name())
+			}
+			continue
+		}
+		err := filepath.WalkDir(filepath.Join(root, entry.Name()), func(file string, dirEntry fs.DirEntry, walkErr error) error {
+			if walkErr != nil {
+				return walkErr
+			}
+			if !dirEntry.Type().IsRegular() || strings.HasSuffix(dirEntry.Name(), tempFileSuffix) {
+				return nil
+			}
+			relative, err := filepath.Rel(root, file)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(relative))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// isCacheEntry reports whether a top-level entry of a source directory is
+// part of the cache.
+func isCacheEntry(name string) bool {
+	if name == schemaStateFileName {
+		return true
+	}
+	matched, _ := path.Match(cacheDirectoryGlob, name)
+	return matched
+}
+
+// addFile copies file into the archive. A file removed since it was listed is
+// skipped.
+func addFile(tarWriter *tar.Writer, file string, name string) (ManifestFile, bool, error) {
+	source, err := os.Open(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ManifestFile{}, false, nil
+	}
+	if err != nil {
+		return ManifestFile{}, false, fmt.Errorf("open %s: %w", file, err)
+	}
+	defer source.Close()
+
+	info, err := source.Stat()
+	if err != nil {
+		return ManifestFile{}, false, fmt.Errorf("stat %s: %w", file, err)
+	}
+	if err := tarWriter.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    int64(info.Mode().Perm()),
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}); err != nil {
+		return ManifestFile{}, false, fmt.Errorf("write %s: %w", name, err)
+	}
+	hasher := sha256.New()
+	written, err := io.Copy(io.MultiWriter(tarWriter, hasher), source)
+	if err != nil {
+		return ManifestFile{}, false, fmt.Errorf("write %s: %w", name, err)
+	}
+	return ManifestFile{Path: name, Size: written, SHA256: hex.EncodeToString(hasher.Sum(nil))}, true, nil
+}
+
+func sortedSources(roots map[string]string) []string {
+	sources := make([]string, 0, len(roots))
+	for source := range roots {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	return sources
+}
@@ -0,0 +1,217 @@
+package cachebackup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCreate_ArchivesCacheFilesOnly(t *testing.T) {
+	// GIVEN
+	stravaRoot := t.TempDir()
+	fitRoot := t.TempDir()
+	writeFile(t, stravaRoot, ".strava", "clientId=42")
+	writeFile(t, stravaRoot, "source-sync-history.json", "[]")
+	writeFile(t, stravaRoot, "cache-schema.json", `{"schemaVersion":1}`)
+	writeFile(t, stravaRoot, "strava-42/annual-goals-42.json", `{"ride":1000}`)
+	writeFile(t, stravaRoot, "strava-42/strava-42-2024/stream-1", `{}`)
+	writeFile(t, stravaRoot, "strava-42/strava-42-2024/stream-2.tmp", `{`)
+	writeFile(t, fitRoot, "2024/ride.fit", "raw")
+	writeFile(t, fitRoot, "strava-fit/local-index-fit/index.json", `{}`)
+	var archive bytes.Buffer
+
+	// WHEN
+	manifest, err := Create(&archive, "default", map[string]string{"strava": stravaRoot, "fit": fitRoot})
+
+	// THEN
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	paths := make([]string, 0, len(manifest.Files))
+	for _, file := range manifest.Files {
+		paths = append(paths, file.Path)
+	}
+	expected := "fit/strava-fit/local-index-fit/index.json,strava/cache-schema.json,strava/strava-42/annual-goals-42.json,strava/strava-42/strava-42-2024/stream-1"
+	if strings.Join(paths, ",") != expected {
+		t.Fatalf("expected %s, got %s", expected, strings.Join(paths, ","))
+	}
+	if manifest.FormatVersion != FormatVersion || strings.Join(manifest.Sources, ",") != "fit,strava" {
+		t.Fatalf("expected format %d with fit and strava sources, got %#v", FormatVersion, manifest)
+	}
+}
+
+func TestRestore_SwapsCacheAndKeepsPreviousOne(t *testing.T) {
+	// GIVEN
+	root := t.TempDir()
+	roots := map[string]string{"strava": root}
+	writeFile(t, root, ".strava", "clientId=42")
+	writeFile(t, root, "strava-42/annual-goals-42.json", `{"ride":1000}`)
+	var archive bytes.Buffer
+	if _, err := Create(&archive, "default", roots); err != nil {
+		t.Fatalf("unable to create snapshot: %v", err)
+	}
+	writeFile(t, root, "strava-42/annual-goals-42.json", `{"ride":2000}`)
+	writeFile(t, root, "strava-42/gear-maintenance-42.json", `{"records":[]}`)
+
+	// WHEN
+	result, err := Restore(&archive, roots)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.RestoredFiles != 1 {
+		t.Fatalf("expected 1 restored file, got %d", result.RestoredFiles)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "strava-42", "annual-goals-42.json")); string(data) != `{"ride":1000}` {
+		t.Fatalf("expected snapshot goals, got %s", data)
+	}
+	if _, err := os.Stat(filepath.Join(root, "strava-42", "gear-maintenance-42.json")); !os.IsNotExist(err) {
+		t.Fatalf("expected files missing from the snapshot to be moved aside, got %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(result.Previous["strava"], "strava-42", "annual-goals-42.json")); string(data) != `{"ride":2000}` {
+		t.Fatalf("expected replaced goals in the previous directory, got %s", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, ".strava")); string(data) != "clientId=42" {
+		t.Fatalf("expected credentials to stay in place, got %s", data)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(result.Previous["strava"]), "incoming")); !os.IsNotExist(err) {
+		t.Fatalf("expected incoming directory to be removed, got %v", err)
+	}
+}
+
+func TestRestore_RejectsFileNotMatchingManifest(t *testing.T) {
+	// GIVEN
+	root := t.TempDir()
+	writeFile(t, root, "strava-42/annual-goals-42.json", `{"ride":2000}`)
+	manifest := Manifest{
+		FormatVersion: FormatVersion,
+		Sources:       []string{"strava"},
+		Files:         []ManifestFile{{Path: "strava/strava-42/annual-goals-42.json", Size: 13, SHA256: strings.Repeat("0", 64)}},
+	}
+	archive := buildArchive(t, map[string]string{"strava/strava-42/annual-goals-42.json": `{"ride":1000}`}, manifest)
+
+	// WHEN
+	_, err := Restore(archive, map[string]string{"strava": root})
+
+	// THEN
+	if err == nil || !strings.Contains(err.Error(), "does not match the manifest") {
+		t.Fatalf("expected a manifest mismatch, got %v", err)
+	}
+	assertCacheUntouched(t, root, `{"ride":2000}`)
+}
+
+func TestRestore_RejectsEntriesOutsideTheCache(t *testing.T) {
+	// GIVEN
+	root := t.TempDir()
+	writeFile(t, root, "strava-42/annual-goals-42.json", `{"ride":2000}`)
+	manifest := Manifest{FormatVersion: FormatVersion, Sources: []string{"strava"}}
+	archive := buildArchive(t, map[string]string{"strava/../.strava": "clientId=1"}, manifest)
+
+	// WHEN
+	_, err := Restore(archive, map[string]string{"strava": root})
+
+	// THEN
+	if err == nil || !strings.Contains(err.Error(), "invalid snapshot entry") {
+		t.Fatalf("expected the entry to be refused, got %v", err)
+	}
+	assertCacheUntouched(t, root, `{"ride":2000}`)
+}
+
+func TestRestore_RejectsSourceTheAthleteDoesNotHave(t *testing.T) {
+	// GIVEN
+	root := t.TempDir()
+	manifest := Manifest{FormatVersion: FormatVersion, Sources: []string{"fit"}}
+	archive := buildArchive(t, map[string]string{}, manifest)
+
+	// WHEN
+	_, err := Restore(archive, map[string]string{"strava": root})
+
+	// THEN
+	if err == nil || !strings.Contains(err.Error(), "no fit source") {
+		t.Fatalf("expected the fit snapshot to be refused, got %v", err)
+	}
+}
+
+func TestRestore_RejectsSnapshotsExtractingTooMuch(t *testing.T) {
+	// GIVEN
+	root := t.TempDir()
+	writeFile(t, root, "strava-42/annual-goals-42.json", `{"ride":2000}`)
+	manifest := Manifest{FormatVersion: FormatVersion, Sources: []string{"strava"}}
+	previousFile, previousTotal := maxRestoredFileBytes, maxRestoredBytes
+	defer func() { maxRestoredFileBytes, maxRestoredBytes = previousFile, previousTotal }()
+	maxRestoredFileBytes, maxRestoredBytes = 1024, 1500
+	oversizedFile := buildArchive(t, map[string]string{"strava/strava-42/stream-1": strings.Repeat("0", 1025)}, manifest)
+	oversizedTotal := buildArchive(t, map[string]string{
+		"strava/strava-42/stream-1": strings.Repeat("0", 1000),
+		"strava/strava-42/stream-2": strings.Repeat("0", 1000),
+	}, manifest)
+
+	for name, archive := range map[string]*bytes.Buffer{"file": oversizedFile, "total": oversizedTotal} {
+		// WHEN
+		_, err := Restore(archive, map[string]string{"strava": root})
+
+		// THEN
+		if !errors.Is(err, ErrSnapshotTooLarge) {
+			t.Fatalf("expected the oversized %s to be refused, got %v", name, err)
+		}
+		assertCacheUntouched(t, root, `{"ride":2000}`)
+	}
+}
+
+func assertCacheUntouched(t *testing.T, root string, goals string) {
+	t.Helper()
+	if data, _ := os.ReadFile(filepath.Join(root, "strava-42", "annual-goals-42.json")); string(data) != goals {
+		t.Fatalf("expected the cache to stay untouched, got %s", data)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(root, backupsDirectory)); len(entries) != 0 {
+		t.Fatalf("expected the restore working directory to be removed, got %d entries", len(entries))
+	}
+}
+
+func buildArchive(t *testing.T, files map[string]string, manifest Manifest) *bytes.Buffer {
+	t.Helper()
+	var archive bytes.Buffer
+	gzipWriter := gzip.NewWriter(&archive)
+	tarWriter := tar.NewWriter(gzipWriter)
+	add := func(name string, content []byte) {
+		if err := tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: int64(len(content))}); err != nil {
+			t.Fatalf("unable to write %s header: %v", name, err)
+		}
+		if _, err := tarWriter.Write(content); err != nil {
+			t.Fatalf("unable to write %s: %v", name, err)
+		}
+	}
+	for name, content := range files {
+		add(name, []byte(content))
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatalf("unable to encode manifest: %v", err)
+	}
+	add(manifestFileName, data)
+	if err := tarWriter.Close(); err != nil {
+		t.Fatalf("unable to close archive: %v", err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatalf("unable to close archive: %v", err)
+	}
+	return &archive
+}
+
+func writeFile(t *testing.T, root string, name string, content string) {
+	t.Helper()
+	path := filepath.Join(root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("unable to create %s: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("unable to write %s: %v", path, err)
+	}
+}
@@ -0,0 +1,352 @@
+package cachebackup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"mystravastats/internal/platform/cachemigration"
+	"mystravastats/internal/platform/cachewrite"
+)
+
+// RestoreResult reports a restored snapshot.
+type RestoreResult struct {
+	Manifest      Manifest `json:"manifest"`
+	RestoredFiles int      `json:"restoredFiles"`
+	// Previous maps each restored source to the directory now holding the
+	// cache it replaced, which can be deleted once the restore is checked.
+	Previous   map[string]string `json:"previous"`
+	Migrations []string          `json:"migrations,omitempty"`
+}
+
+// restoreTarget is the working area of one source during a restore. Both
+// directories live under the source directory, so the swap only renames.
+type restoreTarget struct {
+	root     string
+	incoming string
+	previous string
+}
+
+type move struct {
+	from string
+	to   string
+}
+
+// MaxSnapshotBytes bounds the snapshot body accepted by the restore endpoint.
+const MaxSnapshotBytes = 4 << 30
+
+// ErrSnapshotTooLarge is returned when a snapshot would extract more than the
+// restore accepts. Nothing is left on disk when it is returned.
+var ErrSnapshotTooLarge = errors.New("snapshot too large")
+
+// maxRestoredFileBytes and maxRestoredBytes bound what a snapshot extracts, per
+// file and in total, so that a small compressed archive cannot fill the disk.
+var (
+	maxRestoredFileBytes int64 = 256 << 20
+	maxRestoredBytes     int64 = 16 << 30
+)
+
+// restoreMutex serializes restores, whose swaps would otherwise interleave.
+var restoreMutex sync.Mutex
+
+// Restore extracts a snapshot written by Create, checks every file against
+// its manifest, then swaps the cache directories of each source it holds.
+// The current cache is left untouched when the snapshot is invalid. Cache
+// writes are paused during the swap only; the caller reloads the provider.
+func Restore(reader io.Reader, roots map[string]string) (RestoreResult, error) {
+	restoreMutex.Lock()
+	defer restoreMutex.Unlock()
+
+	roots = distinctRoots(roots)
+	stamp := "restore-" + time.Now().UTC().Format("20060102T150405.000Z")
+	targets := make(map[string]restoreTarget, len(roots))
+	for source, root := range roots {
+		work := filepath.Join(root, backupsDirectory, stamp)
+		targets[source] = restoreTarget{
+			root:     root,
+			incoming: filepath.Join(work, "incoming"),
+			previous: filepath.Join(work, "previous"),
+		}
+	}
+	swapped := false
+	defer func() {
+		for _, target := range targets {
+			_ = os.RemoveAll(target.incoming)
+			if !swapped {
+				_ = os.RemoveAll(filepath.Dir(target.incoming))
+			}
+		}
+	}()
+
+	manifest, extracted, err := extract(reader, targets)
+	if err != nil {
+		return RestoreResult{}, err
+	}
+	if err := validate(manifest, extracted, targets); err != nil {
+		return RestoreResult{}, err
+	}
+	for _, source := range manifest.Sources {
+		report := cachemigration.Run(targets[source].incoming, cachemigration.Schemas, true)
+		if len(report.Errors) > 0 {
+			return RestoreResult{}, fmt.Errorf("%s cache: %s", source, strings.Join(report.Errors, "; "))
+		}
+	}
+
+	if err := swap(manifest.Sources, targets); err != nil {
+		return RestoreResult{}, err
+	}
+	swapped = true
+
+	result := RestoreResult{
+		Manifest:      *manifest,
+		RestoredFiles: len(manifest.Files),
+		Previous:      make(map[string]string, len(manifest.Sources)),
+	}
+	for _, source := range manifest.Sources {
+		result.Previous[source] = targets[source].previous
+		// Snapshots of an older release are upgraded like the cache at startup.
+		report := cachemigration.Run(targets[source].root, cachemigration.Schemas, false)
+		for _, upgrade := range report.Upgrades {
+			result.Migrations = append(result.Migrations, fmt.Sprintf("%s: %s v%d -> v%d", source, upgrade.Schema, upgrade.From, upgrade.To))
+		}
+		for _, message := range report.Errors {
+			result.Migrations = append(result.Migrations, fmt.Sprintf("%s: %s", source, message))
+		}
+	}
+	log.Printf("Restored %d cache files from a snapshot of %s taken at %s", result.RestoredFiles, manifest.AthleteID, manifest.CreatedAt)
+	return result, nil
+}
+
+// extract writes the snapshot files to the incoming directory of their source
+// and returns the manifest with the size and hash of what was written.
+func extract(reader io.Reader, targets map[string]restoreTarget) (*Manifest, map[string]ManifestFile, error) {
+	gzipReader, err := gzip.NewReader(reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid snapshot: %w", err)
+	}
+	defer gzipReader.Close()
+
+	var manifest *Manifest
+	extracted := make(map[string]ManifestFile)
+	var total int64
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid snapshot: %w", err)
+		}
+		if header.Typeflag == tar.TypeDir {
+			continue
+		}
+		if header.Typeflag != tar.TypeReg {
+			return nil, nil, fmt.Errorf("invalid snapshot: %s is not a regular file", header.Name)
+		}
+		if header.Size > maxRestoredFileBytes {
+			return nil, nil, fmt.Errorf("%w: %s is larger than %d MiB", ErrSnapshotTooLarge, header.Name, maxRestoredFileBytes>>20)
+		}
+		total += header.Size
+		if total > maxRestoredBytes {
+			return nil, nil, fmt.Errorf("%w: it extracts more than %d MiB", ErrSnapshotTooLarge, maxRestoredBytes>>20)
+		}
+
+		if header.Name == manifestFileName {
+			if manifest != nil {
+				return nil, nil, fmt.Errorf("invalid snapshot: duplicate %s", manifestFileName)
+			}
+			manifest = &Manifest{}
+			if err := json.NewDecoder(tarReader).Decode(manifest); err != nil {
+				return nil, nil, fmt.Errorf("invalid snapshot manifest: %w", err)
+			}
+			continue
+		}
+
+		source, relative, err := splitEntryName(header.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		target, ok := targets[source]
+		if !ok {
+			return nil, nil, fmt.Errorf("snapshot holds a %s cache but the athlete has no %s source", source, source)
+		}
+		if _, duplicate := extracted[header.Name]; duplicate {
+			return nil, nil, fmt.Errorf("invalid snapshot: duplicate %s", header.Name)
+		}
+		entry, err := extractFile(tarReader, header, filepath.Join(target.incoming, filepath.FromSlash(relative)))
+		if err != nil {
+			return nil, nil, err
+		}
+		extracted[header.Name] = entry
+	}
+	if manifest == nil {
+		return nil, nil, fmt.Errorf("invalid snapshot: %s is missing", manifestFileName)
+	}
+	return manifest, extracted, nil
+}
+
+// splitEntryName checks that an archive entry is a cache file of a source and
+// splits it into the source and the path relative to the source directory.
+func splitEntryName(name string) (string, string, error) {
+	if path.Clean(name) != name || path.IsAbs(name) || strings.Contains(name, "\\") {
+		return "", "", fmt.Errorf("invalid snapshot entry: %s", name)
+	}
+	parts := strings.Split(name, "/")
+	if len(parts) < 2 || !isCacheEntry(parts[1]) || (parts[1] == schemaStateFileName) != (len(parts) == 2) {
+		return "", "", fmt.Errorf("invalid snapshot entry: %s", name)
+	}
+	for _, part := range parts {
+		if part == ".." {
+			return "", "", fmt.Errorf("invalid snapshot entry: %s", name)
+		}
+	}
+	return parts[0], strings.Join(parts[1:], "/"), nil
+}
+
+func extractFile(reader io.Reader, header *tar.Header, file string) (ManifestFile, error) {
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		return ManifestFile{}, fmt.Errorf("create %s: %w", filepath.Dir(file), err)
+	}
+	mode := fs.FileMode(header.Mode).Perm()
+	if mode == 0 {
+		mode = 0o600
+	}
+	destination, err := os.OpenFile(file, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode)
+	if err != nil {
+		return ManifestFile{}, fmt.Errorf("create %s: %w", file, err)
+	}
+	hasher := sha256.New()
+	written, err := io.Copy(io.MultiWriter(destination, hasher), reader)
+	if closeErr := destination.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return ManifestFile{}, fmt.Errorf("extract %s: %w", header.Name, err)
+	}
+	if !header.ModTime.IsZero() {
+		_ = os.Chtimes(file, header.ModTime, header.ModTime)
+	}
+	return ManifestFile{Path: header.Name, Size: written, SHA256: hex.EncodeToString(hasher.Sum(nil))}, nil
+}
+
+// validate checks that the extracted files are exactly the ones listed by the
+// manifest.
+func validate(manifest *Manifest, extracted map[string]ManifestFile, targets map[string]restoreTarget) error {
+	if manifest.FormatVersion != FormatVersion {
+		return fmt.Errorf("unsupported snapshot format version %d (expected %d)", manifest.FormatVersion, FormatVersion)
+	}
+	if len(manifest.Sources) == 0 {
+		return fmt.Errorf("invalid snapshot manifest: no source")
+	}
+	sources := make(map[string]struct{}, len(manifest.Sources))
+	for _, source := range manifest.Sources {
+		if _, ok := targets[source]; !ok {
+			return fmt.Errorf("snapshot holds a %s cache but the athlete has no %s source", source, source)
+		}
+		sources[source] = struct{}{}
+	}
+
+	listed := make(map[string]struct{}, len(manifest.Files))
+	for _, file := range manifest.Files {
+		source, _, err := splitEntryName(file.Path)
+		if err != nil {
+			return fmt.Errorf("invalid snapshot manifest: %w", err)
+		}
+		if _, ok := sources[source]; !ok {
+			return fmt.Errorf("invalid snapshot manifest: %s is outside the listed sources", file.Path)
+		}
+		entry, ok := extracted[file.Path]
+		if !ok {
+			return fmt.Errorf("snapshot is incomplete: %s is missing", file.Path)
+		}
+		if entry.Size != file.Size || entry.SHA256 != file.SHA256 {
+			return fmt.Errorf("snapshot is corrupted: %s does not match the manifest", file.Path)
+		}
+		listed[file.Path] = struct{}{}
+	}
+	for name := range extracted {
+		if _, ok := listed[name]; !ok {
+			return fmt.Errorf("invalid snapshot: %s is not listed in the manifest", name)
+		}
+	}
+	return nil
+}
+
+// swap moves the current cache entries of each source to its previous
+// directory and the extracted ones in their place. A failed swap is rolled
+// back.
+func swap(sources []string, targets map[string]restoreTarget) error {
+	resume := cachewrite.Pause()
+	defer resume()
+
+	moves := make([]move, 0)
+	rollback := func(cause error) error {
+		for index := len(moves) - 1; index >= 0; index-- {
+			if err := os.Rename(moves[index].to, moves[index].from); err != nil {
+				log.Printf("Unable to roll back cache restore, move %s back to %s: %v", moves[index].to, moves[index].from, err)
+			}
+		}
+		return cause
+	}
+	moveEntries := func(from string, to string) error {
+		entries, err := os.ReadDir(from)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		for _, entry := range entries {
+			if !isCacheEntry(entry.Name()) {
+				continue
+			}
+			current := move{from: filepath.Join(from, entry.Name()), to: filepath.Join(to, entry.Name())}
+			if err := os.Rename(current.from, current.to); err != nil {
+				return err
+			}
+			moves = append(moves, current)
+		}
+		return nil
+	}
+
+	for _, source := range sources {
+		target := targets[source]
+		if err := os.MkdirAll(target.previous, 0o700); err != nil {
+			return rollback(fmt.Errorf("create %s: %w", target.previous, err))
+		}
+		if err := moveEntries(target.root, target.previous); err != nil {
+			return rollback(fmt.Errorf("move %s cache aside: %w", source, err))
+		}
+		if err := moveEntries(target.incoming, target.root); err != nil {
+			return rollback(fmt.Errorf("restore %s cache: %w", source, err))
+		}
+	}
+	return nil
+}
+
+// distinctRoots drops the sources sharing their directory with a source
+// sorted before them, so that a directory is only archived once.
+func distinctRoots(roots map[string]string) map[string]string {
+	distinct := make(map[string]string, len(roots))
+	seen := make(map[string]struct{}, len(roots))
+	for _, source := range sortedSources(roots) {
+		root := filepath.Clean(roots[source])
+		if _, ok := seen[root]; ok {
+			continue
+		}
+		seen[root] = struct{}{}
+		distinct[source] = roots[source]
+	}
+	return distinct
+}
@@ -14,5 +14,6 @@ type ChartsReader interface {
 	FindDistanceByPeriod(year *int, period business.Period, activityTypes ...business.ActivityType) []ChartPeriodPoint
 	FindElevationByPeriod(year *int, period business.Period, activityTypes ...business.ActivityType) []ChartPeriodPoint
 	FindAverageSpeedByPeriod(year *int, period business.Period, activityTypes ...business.ActivityType) []ChartPeriodPoint
+	FindAverageGradeAdjustedSpeedByPeriod(year *int, period business.Period, activityTypes ...business.ActivityType) []ChartPeriodPoint
 	FindAverageCadenceByPeriod(year *int, period business.Period, activityTypes ...business.ActivityType) []ChartPeriodPoint
 }
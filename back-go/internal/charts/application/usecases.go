@@ -50,6 +50,22 @@ func (uc *GetAverageSpeedByPeriodUseCase) Execute(year *int, period business.Per
 	return result
 }
 
+type GetAverageGradeAdjustedSpeedByPeriodUseCase struct {
+	reader ChartsReader
+}
+
+func NewGetAverageGradeAdjustedSpeedByPeriodUseCase(reader ChartsReader) *GetAverageGradeAdjustedSpeedByPeriodUseCase {
+	return &GetAverageGradeAdjustedSpeedByPeriodUseCase{reader: reader}
+}
+
+func (uc *GetAverageGradeAdjustedSpeedByPeriodUseCase) Execute(year *int, period business.Period, activityTypes []business.ActivityType) []ChartPeriodPoint {
+	result := uc.reader.FindAverageGradeAdjustedSpeedByPeriod(year, period, activityTypes...)
+	if result == nil {
+		return []ChartPeriodPoint{}
+	}
+	return result
+}
+
 type GetAverageCadenceByPeriodUseCase struct {
 	reader ChartsReader
 }
@@ -21,6 +21,10 @@ func (stub *chartsReaderStub) FindAverageSpeedByPeriod(_ *int, _ business.Period
 	return stub.result
 }
 
+func (stub *chartsReaderStub) FindAverageGradeAdjustedSpeedByPeriod(_ *int, _ business.Period, _ ...business.ActivityType) []ChartPeriodPoint {
+	return stub.result
+}
+
 func (stub *chartsReaderStub) FindAverageCadenceByPeriod(_ *int, _ business.Period, _ ...business.ActivityType) []ChartPeriodPoint {
 	return stub.result
 }
@@ -13,23 +13,20 @@ import (
 )
 
 // ChartsServiceAdapter provides chart projections directly from provider data.
-type ChartsServiceAdapter struct{}
+type ChartsServiceAdapter struct {
+	providers activityprovider.Lookup
+}
 
-func NewChartsServiceAdapter() *ChartsServiceAdapter {
-	return &ChartsServiceAdapter{}
+func NewChartsServiceAdapter(providers activityprovider.Lookup) *ChartsServiceAdapter {
+	return &ChartsServiceAdapter{providers: providers}
 }
 
 func (adapter *ChartsServiceAdapter) FindDistanceByPeriod(year *int, period business.Period, activityTypes ...business.ActivityType) []application.ChartPeriodPoint {
-	resolvedYear, ok := resolveChartYear(year, period, activityTypes, "distance")
+	activitiesByPeriod, ok := adapter.chartActivitiesByPeriod(year, period, "distance", activityTypes)
 	if !ok {
 		return []application.ChartPeriodPoint{}
 	}
 
-	log.Printf("Get distance by %s by activity (%v) type by year (%d)", period, activityTypes, resolvedYear)
-
-	activities := dataqualityInfra.FilterExcludedFromStats(activityprovider.Get().GetActivitiesByYearAndActivityTypes(year, activityTypes...))
-	activitiesByPeriod := activitiesByPeriod(activities, resolvedYear, period)
-
 	result := make([]application.ChartPeriodPoint, 0, len(activitiesByPeriod))
 	for _, periodKey := range sortedPeriodKeys(activitiesByPeriod) {
 		periodActivities := activitiesByPeriod[periodKey]
@@ -48,16 +45,11 @@ func (adapter *ChartsServiceAdapter) FindDistanceByPeriod(year *int, period busi
 }
 
 func (adapter *ChartsServiceAdapter) FindElevationByPeriod(year *int, period business.Period, activityTypes ...business.ActivityType) []application.ChartPeriodPoint {
-	resolvedYear, ok := resolveChartYear(year, period, activityTypes, "elevation")
+	activitiesByPeriod, ok := adapter.chartActivitiesByPeriod(year, period, "elevation", activityTypes)
 	if !ok {
 		return []application.ChartPeriodPoint{}
 	}
 
-	log.Printf("Get elevation by %s by activity (%v) type by year (%d)", period, activityTypes, resolvedYear)
-
-	activities := dataqualityInfra.FilterExcludedFromStats(activityprovider.Get().GetActivitiesByYearAndActivityTypes(year, activityTypes...))
-	activitiesByPeriod := activitiesByPeriod(activities, resolvedYear, period)
-
 	size := 12
 	switch period {
 	case business.PeriodWeeks:
@@ -84,16 +76,31 @@ func (adapter *ChartsServiceAdapter) FindElevationByPeriod(year *int, period bus
 }
 
 func (adapter *ChartsServiceAdapter) FindAverageSpeedByPeriod(year *int, period business.Period, activityTypes ...business.ActivityType) []application.ChartPeriodPoint {
-	resolvedYear, ok := resolveChartYear(year, period, activityTypes, "average speed")
+	return adapter.averageSpeedByPeriod(year, period, "average speed", func(activity *strava.Activity) float64 {
+		return activity.AverageSpeed
+	}, activityTypes...)
+}
+
+// FindAverageGradeAdjustedSpeedByPeriod averages the grade-adjusted speed of
+// the activities on foot. Other activities, and those without altitude, keep
+// their raw speed.
+func (adapter *ChartsServiceAdapter) FindAverageGradeAdjustedSpeedByPeriod(year *int, period business.Period, activityTypes ...business.ActivityType) []application.ChartPeriodPoint {
+	return adapter.averageSpeedByPeriod(year, period, "average grade-adjusted speed", func(activity *strava.Activity) float64 {
+		if strava.IsOnFoot(activity.Type) {
+			if speed := strava.GradeAdjustedSpeed(activity.AverageSpeed, activity.Stream); speed > 0 {
+				return speed
+			}
+		}
+		return activity.AverageSpeed
+	}, activityTypes...)
+}
+
+func (adapter *ChartsServiceAdapter) averageSpeedByPeriod(year *int, period business.Period, label string, speedOf func(*strava.Activity) float64, activityTypes ...business.ActivityType) []application.ChartPeriodPoint {
+	activitiesByPeriod, ok := adapter.chartActivitiesByPeriod(year, period, label, activityTypes)
 	if !ok {
 		return []application.ChartPeriodPoint{}
 	}
 
-	log.Printf("Get average speed by %s by activity (%v) type by year (%d)", period, activityTypes, resolvedYear)
-
-	activities := dataqualityInfra.FilterExcludedFromStats(activityprovider.Get().GetActivitiesByYearAndActivityTypes(year, activityTypes...))
-	activitiesByPeriod := activitiesByPeriod(activities, resolvedYear, period)
-
 	size := 12
 	switch period {
 	case business.PeriodWeeks:
@@ -115,7 +122,7 @@ func (adapter *ChartsServiceAdapter) FindAverageSpeedByPeriod(year *int, period
 		}
 		totalSpeed := 0.0
 		for _, activity := range periodActivities {
-			totalSpeed += activity.AverageSpeed
+			totalSpeed += speedOf(activity)
 		}
 		averageSpeed := totalSpeed / float64(len(periodActivities))
 		result = append(result, application.ChartPeriodPoint{
@@ -129,16 +136,11 @@ func (adapter *ChartsServiceAdapter) FindAverageSpeedByPeriod(year *int, period
 }
 
 func (adapter *ChartsServiceAdapter) FindAverageCadenceByPeriod(year *int, period business.Period, activityTypes ...business.ActivityType) []application.ChartPeriodPoint {
-	resolvedYear, ok := resolveChartYear(year, period, activityTypes, "average cadence")
+	activitiesByPeriod, ok := adapter.chartActivitiesByPeriod(year, period, "average cadence", activityTypes)
 	if !ok {
 		return []application.ChartPeriodPoint{}
 	}
 
-	log.Printf("Get average cadence by %s by activity (%v) type by year (%d)", period, activityTypes, resolvedYear)
-
-	activities := dataqualityInfra.FilterExcludedFromStats(activityprovider.Get().GetActivitiesByYearAndActivityTypes(year, activityTypes...))
-	activitiesByPeriod := activitiesByPeriod(activities, resolvedYear, period)
-
 	size := 12
 	switch period {
 	case business.PeriodWeeks:
@@ -190,12 +192,24 @@ func (adapter *ChartsServiceAdapter) FindAverageCadenceByPeriod(year *int, perio
 	return result
 }
 
-func resolveChartYear(year *int, period business.Period, activityTypes []business.ActivityType, metric string) (int, bool) {
-	if year == nil {
+// chartActivitiesByPeriod groups the activities of a chart by period of the
+// year. Without a year, a chart restricted to a date range spans the
+// activities of the range, with periods keyed by their year.
+func (adapter *ChartsServiceAdapter) chartActivitiesByPeriod(year *int, period business.Period, metric string, activityTypes []business.ActivityType) (map[string][]*strava.Activity, bool) {
+	provider := adapter.providers()
+	dateRange, ranged := activityprovider.DateRangeOf(provider)
+	if year == nil && !ranged {
 		log.Printf("Skip %s by %s by activity (%v): missing year", metric, period, activityTypes)
-		return 0, false
+		return nil, false
 	}
-	return *year, true
+
+	activities := dataqualityInfra.FilterExcludedFromStats(provider, provider.GetActivitiesByYearAndActivityTypes(year, activityTypes...))
+	if year == nil {
+		log.Printf("Get %s by %s by activity (%v) type between %s", metric, period, activityTypes, dateRange)
+		return activitiesByRangePeriod(activities, period), true
+	}
+	log.Printf("Get %s by %s by activity (%v) type by year (%d)", metric, period, activityTypes, *year)
+	return activitiesByPeriod(activities, *year, period), true
 }
 
 func activitiesByPeriod(activities []*strava.Activity, year int, period business.Period) map[string][]*strava.Activity {
@@ -276,6 +290,61 @@ func groupActivitiesByDay(activities []*strava.Activity, year int) map[string][]
 	return activitiesByDay
 }
 
+// activitiesByRangePeriod groups activities by month (2006-01), ISO week
+// (2006-W01) or day (2006-01-02), with an empty period for each one between
+// the first and the last activity without activity. The fill stops at the
+// activities rather than at the range bounds, which may be as far apart as
+// from=0001-01-01 and to=9999-12-31.
+func activitiesByRangePeriod(activities []*strava.Activity, period business.Period) map[string][]*strava.Activity {
+	periodKey := rangePeriodKey(period)
+	if periodKey == nil {
+		return map[string][]*strava.Activity{}
+	}
+
+	result := make(map[string][]*strava.Activity)
+	var first, last time.Time
+	for _, activity := range activities {
+		date, err := time.Parse("2006-01-02", activityprovider.ActivityDay(activity))
+		if err != nil {
+			continue
+		}
+		key := periodKey(date)
+		result[key] = append(result[key], activity)
+		if first.IsZero() || date.Before(first) {
+			first = date
+		}
+		if date.After(last) {
+			last = date
+		}
+	}
+
+	if first.IsZero() {
+		return result
+	}
+	for date := first; !date.After(last); date = date.AddDate(0, 0, 1) {
+		if key := periodKey(date); result[key] == nil {
+			result[key] = []*strava.Activity{}
+		}
+	}
+	return result
+}
+
+func rangePeriodKey(period business.Period) func(time.Time) string {
+	switch period {
+	case business.PeriodMonths:
+		return func(date time.Time) string { return date.Format("2006-01") }
+	case business.PeriodWeeks:
+		return func(date time.Time) string {
+			year, week := date.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}
+	case business.PeriodDays:
+		return func(date time.Time) string { return date.Format("2006-01-02") }
+	default:
+		return nil
+	}
+}
+
 func isLeapYear(year int) bool {
 	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
 }
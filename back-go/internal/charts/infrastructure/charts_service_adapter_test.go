@@ -0,0 +1,58 @@
+package infrastructure
+
+import (
+	"mystravastats/internal/shared/domain/business"
+	"mystravastats/internal/shared/domain/strava"
+	"testing"
+)
+
+func TestActivitiesByRangePeriod_SpansTheActivitiesAcrossYears(t *testing.T) {
+	// GIVEN
+	activities := []*strava.Activity{
+		{Id: 1, StartDateLocal: "2025-11-03T08:00:00Z"},
+		{Id: 2, StartDateLocal: "2026-01-04T08:00:00Z"},
+		{Id: 3, StartDateLocal: "2026-01-20T08:00:00Z"},
+		{Id: 4, StartDateLocal: "2026-03-02T08:00:00Z"},
+	}
+
+	// WHEN
+	byMonth := activitiesByRangePeriod(activities, business.PeriodMonths)
+	byWeek := activitiesByRangePeriod(activities, business.PeriodWeeks)
+	byDay := activitiesByRangePeriod(activities, business.PeriodDays)
+
+	// THEN
+	months := sortedPeriodKeys(byMonth)
+	if len(months) != 5 || months[0] != "2025-11" || months[4] != "2026-03" {
+		t.Fatalf("expected the five months from November to March, got %v", months)
+	}
+	if len(byMonth["2026-01"]) != 2 || len(byMonth["2025-12"]) != 0 {
+		t.Fatalf("expected two activities in January and none in December, got %v", byMonth)
+	}
+	// 2026-01-04 is a Sunday, the last day of ISO week 1 of 2026.
+	if len(byWeek["2026-W01"]) != 1 || byWeek["2025-W50"] == nil {
+		t.Fatalf("expected ISO weeks keyed by their year, got %v", sortedPeriodKeys(byWeek))
+	}
+	if len(byDay) != 120 || len(byDay["2025-11-03"]) != 1 {
+		t.Fatalf("expected the 120 days from November 3 to March 2, got %d", len(byDay))
+	}
+}
+
+func TestActivitiesByRangePeriod_FillsOnlyBetweenActivities(t *testing.T) {
+	// GIVEN
+	activities := []*strava.Activity{
+		{Id: 1, StartDateLocal: "2026-02-10T08:00:00Z"},
+		{Id: 2, StartDateLocal: "2026-04-02T08:00:00Z"},
+	}
+
+	// WHEN
+	byDay := activitiesByRangePeriod(activities, business.PeriodDays)
+	byMonth := activitiesByRangePeriod(activities, business.PeriodMonths)
+
+	// THEN
+	if len(byDay) != 52 {
+		t.Fatalf("expected the 52 days between the activities, got %d", len(byDay))
+	}
+	if months := sortedPeriodKeys(byMonth); len(months) != 3 || months[0] != "2026-02" || months[2] != "2026-04" {
+		t.Fatalf("expected February to April, got %v", months)
+	}
+}
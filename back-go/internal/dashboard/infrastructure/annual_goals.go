@@ -25,32 +25,30 @@ type annualGoalMetricDefinition struct {
 	last30DaysValue  float64
 }
 
-func computeAnnualGoals(year int, targets business.AnnualGoalTargets, activityTypes ...business.ActivityType) business.AnnualGoals {
+func computeAnnualGoals(provider activityprovider.ActivityProvider, year int, targets business.AnnualGoalTargets, activityTypes ...business.ActivityType) business.AnnualGoals {
 	log.Printf("Get annual goals for year %d and activity type %s", year, activityTypes)
 
 	normalizedTargets := normalizeAnnualGoalTargets(targets)
-	activities := dataqualityInfra.FilterExcludedFromStats(activityprovider.Get().GetActivitiesByYearAndActivityTypes(&year, activityTypes...))
+	activities := dataqualityInfra.FilterExcludedFromStats(provider, provider.GetActivitiesByYearAndActivityTypes(&year, activityTypes...))
 	return buildAnnualGoals(year, activityTypeKey(activityTypes...), normalizedTargets, activities, time.Now())
 }
 
-func saveAnnualGoals(year int, targets business.AnnualGoalTargets, activityTypes ...business.ActivityType) business.AnnualGoals {
+func saveAnnualGoals(provider activityprovider.ActivityProvider, year int, targets business.AnnualGoalTargets, activityTypes ...business.ActivityType) business.AnnualGoals {
 	normalizedTargets := normalizeAnnualGoalTargets(targets)
 	key := annualGoalTargetsKey(year, activityTypes...)
-	provider := activityprovider.Get()
 	repository := localrepository.NewStravaRepository(provider.CacheRootPath())
 	repository.SaveAnnualGoalTargets(provider.ClientID(), key, normalizedTargets)
 
-	activities := dataqualityInfra.FilterExcludedFromStats(provider.GetActivitiesByYearAndActivityTypes(&year, activityTypes...))
+	activities := dataqualityInfra.FilterExcludedFromStats(provider, provider.GetActivitiesByYearAndActivityTypes(&year, activityTypes...))
 	return buildAnnualGoals(year, activityTypeKey(activityTypes...), normalizedTargets, activities, time.Now())
 }
 
-func loadAnnualGoals(year int, activityTypes ...business.ActivityType) business.AnnualGoals {
+func loadAnnualGoals(provider activityprovider.ActivityProvider, year int, activityTypes ...business.ActivityType) business.AnnualGoals {
 	key := annualGoalTargetsKey(year, activityTypes...)
-	provider := activityprovider.Get()
 	repository := localrepository.NewStravaRepository(provider.CacheRootPath())
 	targets := repository.LoadAnnualGoalTargets(provider.ClientID(), key)
 
-	activities := dataqualityInfra.FilterExcludedFromStats(provider.GetActivitiesByYearAndActivityTypes(&year, activityTypes...))
+	activities := dataqualityInfra.FilterExcludedFromStats(provider, provider.GetActivitiesByYearAndActivityTypes(&year, activityTypes...))
 	return buildAnnualGoals(year, activityTypeKey(activityTypes...), normalizeAnnualGoalTargets(targets), activities, time.Now())
 }
 
@@ -14,7 +14,7 @@ import (
 	"time"
 )
 
-func computeEddingtonNumber(scope business.EddingtonScope, metric business.EddingtonMetric, basis business.EddingtonBasis, year *int, activityTypes ...business.ActivityType) business.EddingtonNumber {
+func computeEddingtonNumber(provider activityprovider.ActivityProvider, scope business.EddingtonScope, metric business.EddingtonMetric, basis business.EddingtonBasis, year *int, activityTypes ...business.ActivityType) business.EddingtonNumber {
 	if scope == "" {
 		scope = business.EddingtonScopeLifetime
 	}
@@ -26,20 +26,20 @@ func computeEddingtonNumber(scope business.EddingtonScope, metric business.Eddin
 	}
 	log.Printf("Get Eddington number for scope %s, metric %s, basis %s and activity type %s", scope, metric, basis, activityTypes)
 
-	activities := eddingtonActivitiesForScope(scope, year, activityTypes...)
+	activities := eddingtonActivitiesForScope(provider, scope, year, activityTypes...)
 	return computeEddingtonFromValues(scope, metric, basis, eddingtonValues(activities, metric, basis))
 }
 
-func eddingtonActivitiesForScope(scope business.EddingtonScope, year *int, activityTypes ...business.ActivityType) []*strava.Activity {
+func eddingtonActivitiesForScope(provider activityprovider.ActivityProvider, scope business.EddingtonScope, year *int, activityTypes ...business.ActivityType) []*strava.Activity {
 	switch scope {
 	case business.EddingtonScopeYear:
-		return dataqualityInfra.FilterExcludedFromStats(activityprovider.Get().GetActivitiesByYearAndActivityTypes(year, activityTypes...))
+		return dataqualityInfra.FilterExcludedFromStats(provider, provider.GetActivitiesByYearAndActivityTypes(year, activityTypes...))
 	case business.EddingtonScopeRolling12Months:
-		activities := dataqualityInfra.FilterExcludedFromStats(activityprovider.Get().GetActivitiesByYearAndActivityTypes(nil, activityTypes...))
+		activities := dataqualityInfra.FilterExcludedFromStats(provider, provider.GetActivitiesByYearAndActivityTypes(nil, activityTypes...))
 		now := time.Now()
 		return filterActivitiesByDateRange(activities, now.AddDate(-1, 0, 0), now)
 	default:
-		return dataqualityInfra.FilterExcludedFromStats(activityprovider.Get().GetActivitiesByYearAndActivityTypes(nil, activityTypes...))
+		return dataqualityInfra.FilterExcludedFromStats(provider, provider.GetActivitiesByYearAndActivityTypes(nil, activityTypes...))
 	}
 }
 
@@ -187,10 +187,10 @@ func truncateDate(value time.Time) time.Time {
 	return time.Date(value.Year(), value.Month(), value.Day(), 0, 0, 0, 0, time.UTC)
 }
 
-func computeCumulativeDistancePerYear(activityTypes ...business.ActivityType) map[string]map[string]float64 {
+func computeCumulativeDistancePerYear(provider activityprovider.ActivityProvider, activityTypes ...business.ActivityType) map[string]map[string]float64 {
 	log.Printf("Get cumulative distance per year for activity type %s", activityTypes)
 
-	activitiesByYear := groupActivitiesByYear(dataqualityInfra.FilterExcludedFromStats(activityprovider.Get().GetActivitiesByYearAndActivityTypes(nil, activityTypes...)))
+	activitiesByYear := groupActivitiesByYear(dataqualityInfra.FilterExcludedFromStats(provider, provider.GetActivitiesByYearAndActivityTypes(nil, activityTypes...)))
 	currentYear := time.Now().Year()
 	result := make(map[string]map[string]float64)
 
@@ -217,10 +217,10 @@ func calculateCumulativeDistance(activitiesByDay map[string][]*strava.Activity)
 	return result
 }
 
-func computeCumulativeElevationPerYear(activityTypes ...business.ActivityType) map[string]map[string]float64 {
+func computeCumulativeElevationPerYear(provider activityprovider.ActivityProvider, activityTypes ...business.ActivityType) map[string]map[string]float64 {
 	log.Printf("Get cumulative elevation per year for activity type %s", activityTypes)
 
-	activitiesByYear := groupActivitiesByYear(dataqualityInfra.FilterExcludedFromStats(activityprovider.Get().GetActivitiesByYearAndActivityTypes(nil, activityTypes...)))
+	activitiesByYear := groupActivitiesByYear(dataqualityInfra.FilterExcludedFromStats(provider, provider.GetActivitiesByYearAndActivityTypes(nil, activityTypes...)))
 	result := make(map[string]map[string]float64)
 	currentYear := time.Now().Year()
 
@@ -247,10 +247,10 @@ func calculateCumulativeElevation(activitiesByDay map[string][]*strava.Activity)
 	return result
 }
 
-func computeDashboardData(activityTypes ...business.ActivityType) business.DashboardData {
+func computeDashboardData(provider activityprovider.ActivityProvider, activityTypes ...business.ActivityType) business.DashboardData {
 	log.Printf("Get dashboard data for activity type %s", activityTypes)
 
-	activities := dataqualityInfra.FilterExcludedFromStats(activityprovider.Get().GetActivitiesByYearAndActivityTypes(nil, activityTypes...))
+	activities := dataqualityInfra.FilterExcludedFromStats(provider, provider.GetActivitiesByYearAndActivityTypes(nil, activityTypes...))
 	activitiesGroupedByYear := groupActivitiesByYear(activities)
 
 	nbActivitiesByYear := make(map[string]int)
@@ -754,10 +754,10 @@ func daysInYear(year int) int {
 	return 365
 }
 
-func computeActivityHeatmap(activityTypes ...business.ActivityType) map[string]map[string]dashboardDomain.ActivityHeatmapDay {
+func computeActivityHeatmap(provider activityprovider.ActivityProvider, activityTypes ...business.ActivityType) map[string]map[string]dashboardDomain.ActivityHeatmapDay {
 	log.Printf("Get activity heatmap for activity type %s", activityTypes)
 
-	activitiesByYear := groupActivitiesByYear(dataqualityInfra.FilterExcludedFromStats(activityprovider.Get().GetActivitiesByYearAndActivityTypes(nil, activityTypes...)))
+	activitiesByYear := groupActivitiesByYear(dataqualityInfra.FilterExcludedFromStats(provider, provider.GetActivitiesByYearAndActivityTypes(nil, activityTypes...)))
 	result := make(map[string]map[string]dashboardDomain.ActivityHeatmapDay)
 	currentYear := time.Now().Year()
 
@@ -2,40 +2,43 @@ package infrastructure
 
 import (
 	dashboardDomain "mystravastats/internal/dashboard/domain"
+	"mystravastats/internal/platform/activityprovider"
 	"mystravastats/internal/shared/domain/business"
 )
 
 // DashboardServiceAdapter computes dashboard read models directly from provider data.
-type DashboardServiceAdapter struct{}
+type DashboardServiceAdapter struct {
+	providers activityprovider.Lookup
+}
 
-func NewDashboardServiceAdapter() *DashboardServiceAdapter {
-	return &DashboardServiceAdapter{}
+func NewDashboardServiceAdapter(providers activityprovider.Lookup) *DashboardServiceAdapter {
+	return &DashboardServiceAdapter{providers: providers}
 }
 
 func (adapter *DashboardServiceAdapter) FindDashboardData(activityTypes ...business.ActivityType) business.DashboardData {
-	return computeDashboardData(activityTypes...)
+	return computeDashboardData(adapter.providers(), activityTypes...)
 }
 
 func (adapter *DashboardServiceAdapter) FindCumulativeDistancePerYear(activityTypes ...business.ActivityType) map[string]map[string]float64 {
-	return computeCumulativeDistancePerYear(activityTypes...)
+	return computeCumulativeDistancePerYear(adapter.providers(), activityTypes...)
 }
 
 func (adapter *DashboardServiceAdapter) FindCumulativeElevationPerYear(activityTypes ...business.ActivityType) map[string]map[string]float64 {
-	return computeCumulativeElevationPerYear(activityTypes...)
+	return computeCumulativeElevationPerYear(adapter.providers(), activityTypes...)
 }
 
 func (adapter *DashboardServiceAdapter) FindActivityHeatmap(activityTypes ...business.ActivityType) map[string]map[string]dashboardDomain.ActivityHeatmapDay {
-	return computeActivityHeatmap(activityTypes...)
+	return computeActivityHeatmap(adapter.providers(), activityTypes...)
 }
 
 func (adapter *DashboardServiceAdapter) FindEddingtonNumber(scope business.EddingtonScope, metric business.EddingtonMetric, basis business.EddingtonBasis, year *int, activityTypes ...business.ActivityType) business.EddingtonNumber {
-	return computeEddingtonNumber(scope, metric, basis, year, activityTypes...)
+	return computeEddingtonNumber(adapter.providers(), scope, metric, basis, year, activityTypes...)
 }
 
 func (adapter *DashboardServiceAdapter) FindAnnualGoals(year int, activityTypes ...business.ActivityType) business.AnnualGoals {
-	return loadAnnualGoals(year, activityTypes...)
+	return loadAnnualGoals(adapter.providers(), year, activityTypes...)
 }
 
 func (adapter *DashboardServiceAdapter) SaveAnnualGoals(year int, targets business.AnnualGoalTargets, activityTypes ...business.ActivityType) business.AnnualGoals {
-	return saveAnnualGoals(year, targets, activityTypes...)
+	return saveAnnualGoals(adapter.providers(), year, targets, activityTypes...)
 }
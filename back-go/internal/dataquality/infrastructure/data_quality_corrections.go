@@ -6,6 +6,7 @@ import (
 	"log"
 	"math"
 	"mystravastats/internal/platform/activityprovider"
+	"mystravastats/internal/platform/cachewrite"
 	"mystravastats/internal/shared/domain/business"
 	"mystravastats/internal/shared/domain/strava"
 	"os"
@@ -21,13 +22,13 @@ type dataQualityCorrectionFile struct {
 	Corrections []business.DataQualityCorrection `json:"corrections"`
 }
 
-func CurrentProviderCorrectionPreview(issueID string) (business.DataQualityCorrectionPreview, error) {
+func ProviderCorrectionPreview(provider activityprovider.ActivityProvider, issueID string) (business.DataQualityCorrectionPreview, error) {
 	issueID = strings.TrimSpace(issueID)
 	if issueID == "" {
 		return business.DataQualityCorrectionPreview{}, fmt.Errorf("issueId must not be empty")
 	}
 
-	context := currentProviderCorrectionContext()
+	context := providerCorrectionContext(provider)
 	for _, issue := range context.report.Issues {
 		if issue.ID != issueID {
 			continue
@@ -46,8 +47,8 @@ func CurrentProviderCorrectionPreview(issueID string) (business.DataQualityCorre
 	return business.DataQualityCorrectionPreview{}, fmt.Errorf("issue %s not found", issueID)
 }
 
-func CurrentProviderSafeCorrectionPreview() business.DataQualityCorrectionPreview {
-	context := currentProviderCorrectionContext()
+func ProviderSafeCorrectionPreview(provider activityprovider.ActivityProvider) business.DataQualityCorrectionPreview {
+	context := providerCorrectionContext(provider)
 	preview := newCorrectionPreview("safe_batch")
 	manualReviewCount := 0
 	unsupportedCount := 0
@@ -74,8 +75,8 @@ func CurrentProviderSafeCorrectionPreview() business.DataQualityCorrectionPrevie
 	return preview
 }
 
-func ApplyCurrentProviderCorrection(issueID string) (business.DataQualityReport, error) {
-	preview, err := CurrentProviderCorrectionPreview(issueID)
+func ApplyProviderCorrection(provider activityprovider.ActivityProvider, issueID string) (business.DataQualityReport, error) {
+	preview, err := ProviderCorrectionPreview(provider, issueID)
 	if err != nil {
 		return business.DataQualityReport{}, err
 	}
@@ -86,30 +87,29 @@ func ApplyCurrentProviderCorrection(issueID string) (business.DataQualityReport,
 	if correction.Safety != business.DataQualityCorrectionSafetySafe {
 		return business.DataQualityReport{}, fmt.Errorf("issue %s requires manual review", issueID)
 	}
-	if err := saveCurrentProviderCorrections([]business.DataQualityCorrection{correction}); err != nil {
+	if err := saveProviderCorrections(provider, []business.DataQualityCorrection{correction}); err != nil {
 		return business.DataQualityReport{}, err
 	}
-	return CurrentProviderReport(), nil
+	return ProviderReport(provider), nil
 }
 
-func ApplyCurrentProviderSafeCorrections() (business.DataQualityReport, error) {
-	preview := CurrentProviderSafeCorrectionPreview()
+func ApplyProviderSafeCorrections(provider activityprovider.ActivityProvider) (business.DataQualityReport, error) {
+	preview := ProviderSafeCorrectionPreview(provider)
 	if len(preview.Corrections) == 0 {
-		return CurrentProviderReport(), nil
+		return ProviderReport(provider), nil
 	}
-	if err := saveCurrentProviderCorrections(preview.Corrections); err != nil {
+	if err := saveProviderCorrections(provider, preview.Corrections); err != nil {
 		return business.DataQualityReport{}, err
 	}
-	return CurrentProviderReport(), nil
+	return ProviderReport(provider), nil
 }
 
-func RevertCurrentProviderCorrection(correctionID string) (business.DataQualityReport, error) {
+func RevertProviderCorrection(provider activityprovider.ActivityProvider, correctionID string) (business.DataQualityReport, error) {
 	correctionID = strings.TrimSpace(correctionID)
 	if correctionID == "" {
 		return business.DataQualityReport{}, fmt.Errorf("correctionId must not be empty")
 	}
 
-	provider := activityprovider.Get()
 	corrections := loadCorrections(provider.CacheRootPath(), provider.ClientID())
 	found := false
 	now := time.Now().UTC().Format(time.RFC3339)
@@ -127,24 +127,21 @@ func RevertCurrentProviderCorrection(correctionID string) (business.DataQualityR
 	if err := saveCorrections(provider.CacheRootPath(), provider.ClientID(), sortedCorrections(corrections)); err != nil {
 		return business.DataQualityReport{}, err
 	}
-	return CurrentProviderReport(), nil
+	return ProviderReport(provider), nil
 }
 
-func CurrentProviderCorrections() []business.DataQualityCorrection {
-	provider := activityprovider.Get()
+func ProviderCorrections(provider activityprovider.ActivityProvider) []business.DataQualityCorrection {
 	return loadCorrections(provider.CacheRootPath(), provider.ClientID())
 }
 
-func ApplyCurrentProviderCorrections(activities []*strava.Activity) []*strava.Activity {
-	provider := activityprovider.Get()
+func ApplyProviderCorrections(provider activityprovider.ActivityProvider, activities []*strava.Activity) []*strava.Activity {
 	return ApplyCorrectionsToActivities(activities, activeCorrections(loadCorrections(provider.CacheRootPath(), provider.ClientID())))
 }
 
-func ApplyCurrentProviderCorrectionsToDetailedActivity(activity *strava.DetailedActivity) *strava.DetailedActivity {
+func ApplyProviderCorrectionsToDetailedActivity(provider activityprovider.ActivityProvider, activity *strava.DetailedActivity) *strava.DetailedActivity {
 	if activity == nil {
 		return nil
 	}
-	provider := activityprovider.Get()
 	corrections := activeCorrections(loadCorrections(provider.CacheRootPath(), provider.ClientID()))
 	if len(corrections) == 0 {
 		return activity
@@ -187,8 +184,7 @@ func ApplyCorrectionsToActivities(activities []*strava.Activity, corrections []b
 	return result
 }
 
-func CorrectionSignature() string {
-	provider := activityprovider.Get()
+func CorrectionSignature(provider activityprovider.ActivityProvider) string {
 	correctionsFile := correctionsFilePath(provider.CacheRootPath(), provider.ClientID())
 	info, err := os.Stat(correctionsFile)
 	if err != nil {
@@ -202,8 +198,7 @@ type correctionContext struct {
 	activityByID map[int64]*strava.Activity
 }
 
-func currentProviderCorrectionContext() correctionContext {
-	provider := activityprovider.Get()
+func providerCorrectionContext(provider activityprovider.ActivityProvider) correctionContext {
 	diagnostics := provider.CacheDiagnostics()
 	source := strings.ToLower(fmt.Sprint(diagnostics["provider"]))
 	sourcePath := provider.CacheRootPath()
@@ -703,6 +698,37 @@ func removeGPSPoint(activity *strava.Activity, index int) {
 		stream.Cadence.Data = removeInt(stream.Cadence.Data, index)
 		stream.Cadence.OriginalSize = len(stream.Cadence.Data)
 	}
+	if stream.Temperature != nil && len(stream.Temperature.Data) == originalSize {
+		stream.Temperature.Data = removeFloat64(stream.Temperature.Data, index)
+		stream.Temperature.OriginalSize = len(stream.Temperature.Data)
+	}
+	if stream.CoreTemperature != nil && len(stream.CoreTemperature.Data) == originalSize {
+		stream.CoreTemperature.Data = removeFloat64(stream.CoreTemperature.Data, index)
+		stream.CoreTemperature.OriginalSize = len(stream.CoreTemperature.Data)
+	}
+	if stream.LeftRightBalance != nil && len(stream.LeftRightBalance.Data) == originalSize {
+		stream.LeftRightBalance.Data = removeFloat64(stream.LeftRightBalance.Data, index)
+		stream.LeftRightBalance.OriginalSize = len(stream.LeftRightBalance.Data)
+	}
+	if stream.VerticalOscillation != nil && len(stream.VerticalOscillation.Data) == originalSize {
+		stream.VerticalOscillation.Data = removeFloat64(stream.VerticalOscillation.Data, index)
+		stream.VerticalOscillation.OriginalSize = len(stream.VerticalOscillation.Data)
+	}
+	if stream.GroundContactTime != nil && len(stream.GroundContactTime.Data) == originalSize {
+		stream.GroundContactTime.Data = removeFloat64(stream.GroundContactTime.Data, index)
+		stream.GroundContactTime.OriginalSize = len(stream.GroundContactTime.Data)
+	}
+	if stream.StrideLength != nil && len(stream.StrideLength.Data) == originalSize {
+		stream.StrideLength.Data = removeFloat64(stream.StrideLength.Data, index)
+		stream.StrideLength.OriginalSize = len(stream.StrideLength.Data)
+	}
+	for fieldIndex := range stream.DeveloperFields {
+		field := &stream.DeveloperFields[fieldIndex]
+		if len(field.Data) == originalSize {
+			field.Data = removeFloat64(field.Data, index)
+			field.OriginalSize = len(field.Data)
+		}
+	}
 	recomputeDistanceAndSpeed(activity)
 	recomputeElevation(activity)
 }
@@ -865,6 +891,43 @@ func cloneStream(stream *strava.Stream) *strava.Stream {
 		grade.Data = cloneFloat64Slice(stream.GradeSmooth.Data)
 		cloned.GradeSmooth = &grade
 	}
+	if stream.Temperature != nil {
+		temperature := *stream.Temperature
+		temperature.Data = cloneFloat64Slice(stream.Temperature.Data)
+		cloned.Temperature = &temperature
+	}
+	if stream.CoreTemperature != nil {
+		coreTemperature := *stream.CoreTemperature
+		coreTemperature.Data = cloneFloat64Slice(stream.CoreTemperature.Data)
+		cloned.CoreTemperature = &coreTemperature
+	}
+	if stream.LeftRightBalance != nil {
+		balance := *stream.LeftRightBalance
+		balance.Data = cloneFloat64Slice(stream.LeftRightBalance.Data)
+		cloned.LeftRightBalance = &balance
+	}
+	if stream.VerticalOscillation != nil {
+		verticalOscillation := *stream.VerticalOscillation
+		verticalOscillation.Data = cloneFloat64Slice(stream.VerticalOscillation.Data)
+		cloned.VerticalOscillation = &verticalOscillation
+	}
+	if stream.GroundContactTime != nil {
+		groundContactTime := *stream.GroundContactTime
+		groundContactTime.Data = cloneFloat64Slice(stream.GroundContactTime.Data)
+		cloned.GroundContactTime = &groundContactTime
+	}
+	if stream.StrideLength != nil {
+		strideLength := *stream.StrideLength
+		strideLength.Data = cloneFloat64Slice(stream.StrideLength.Data)
+		cloned.StrideLength = &strideLength
+	}
+	if stream.DeveloperFields != nil {
+		cloned.DeveloperFields = make([]strava.DeveloperFieldStream, len(stream.DeveloperFields))
+		for index, field := range stream.DeveloperFields {
+			field.Data = cloneFloat64Slice(field.Data)
+			cloned.DeveloperFields[index] = field
+		}
+	}
 	return &cloned
 }
 
@@ -876,6 +939,7 @@ func activityFromDetailed(activity *strava.DetailedActivity) *strava.Activity {
 		MaxHeartrate:         activity.MaxHeartrate,
 		AverageWatts:         activity.AverageWatts,
 		Commute:              activity.Commute,
+		Description:          activity.Description,
 		Distance:             activity.Distance,
 		DeviceWatts:          activity.DeviceWatts,
 		ElapsedTime:          activity.ElapsedTime,
@@ -985,8 +1049,7 @@ func removeBool(values []bool, index int) []bool {
 	return result
 }
 
-func saveCurrentProviderCorrections(corrections []business.DataQualityCorrection) error {
-	provider := activityprovider.Get()
+func saveProviderCorrections(provider activityprovider.ActivityProvider, corrections []business.DataQualityCorrection) error {
 	existing := loadCorrections(provider.CacheRootPath(), provider.ClientID())
 	byID := make(map[string]business.DataQualityCorrection, len(existing)+len(corrections))
 	for _, correction := range existing {
@@ -1035,7 +1098,7 @@ func saveCorrections(cacheRoot string, clientID string, corrections []business.D
 	if err != nil {
 		return fmt.Errorf("unable to encode data quality corrections: %w", err)
 	}
-	if err := os.WriteFile(correctionsFilePath(cacheRoot, clientID), data, dataQualitySecureFileMode); err != nil {
+	if err := cachewrite.WriteFile(correctionsFilePath(cacheRoot, clientID), data, dataQualitySecureFileMode); err != nil {
 		return fmt.Errorf("unable to write data quality corrections: %w", err)
 	}
 	return nil
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"mystravastats/internal/platform/activityprovider"
+	"mystravastats/internal/platform/cachewrite"
 	"mystravastats/internal/shared/domain/business"
 	"mystravastats/internal/shared/domain/strava"
 )
@@ -20,19 +21,18 @@ const (
 	dataQualitySecureFileMode = 0600
 )
 
-func CurrentProviderExclusions() map[int64]business.DataQualityExclusion {
-	provider := activityprovider.Get()
+func ProviderExclusions(provider activityprovider.ActivityProvider) map[int64]business.DataQualityExclusion {
 	exclusions := loadExclusions(provider.CacheRootPath(), provider.ClientID())
 	return exclusionsByActivityID(exclusions)
 }
 
-func FilterExcludedFromStats(activities []*strava.Activity) []*strava.Activity {
+func FilterExcludedFromStats(provider activityprovider.ActivityProvider, activities []*strava.Activity) []*strava.Activity {
 	if len(activities) == 0 {
 		return []*strava.Activity{}
 	}
 
-	correctedActivities := ApplyCurrentProviderCorrections(activities)
-	exclusions := CurrentProviderExclusions()
+	correctedActivities := ApplyProviderCorrections(provider, activities)
+	exclusions := ProviderExclusions(provider)
 	if len(exclusions) == 0 {
 		return correctedActivities
 	}
@@ -56,8 +56,7 @@ func cloneDataQualityActivityPointers(activities []*strava.Activity) []*strava.A
 	return cloned
 }
 
-func ExclusionSignature() string {
-	provider := activityprovider.Get()
+func ExclusionSignature(provider activityprovider.ActivityProvider) string {
 	exclusionsFile := exclusionsFilePath(provider.CacheRootPath(), provider.ClientID())
 	info, err := os.Stat(exclusionsFile)
 	if err != nil {
@@ -66,12 +65,11 @@ func ExclusionSignature() string {
 	return fmt.Sprintf("%d:%d", info.ModTime().UnixNano(), info.Size())
 }
 
-func excludeCurrentProviderActivityFromStats(activityID int64, reason string) (business.DataQualityReport, error) {
+func excludeProviderActivityFromStats(provider activityprovider.ActivityProvider, activityID int64, reason string) (business.DataQualityReport, error) {
 	if activityID <= 0 {
 		return business.DataQualityReport{}, fmt.Errorf("activityId must be > 0")
 	}
 
-	provider := activityprovider.Get()
 	activities := provider.GetActivitiesByYearAndActivityTypes(nil, allActivityTypes()...)
 	activity := findActivity(activities, activityID)
 	if activity == nil {
@@ -99,21 +97,20 @@ func excludeCurrentProviderActivityFromStats(activityID int64, reason string) (b
 	if err := saveExclusions(provider.CacheRootPath(), provider.ClientID(), sortedExclusions(exclusionsByID)); err != nil {
 		return business.DataQualityReport{}, err
 	}
-	return CurrentProviderReport(), nil
+	return ProviderReport(provider), nil
 }
 
-func includeCurrentProviderActivityInStats(activityID int64) (business.DataQualityReport, error) {
+func includeProviderActivityInStats(provider activityprovider.ActivityProvider, activityID int64) (business.DataQualityReport, error) {
 	if activityID <= 0 {
 		return business.DataQualityReport{}, fmt.Errorf("activityId must be > 0")
 	}
 
-	provider := activityprovider.Get()
 	exclusionsByID := exclusionsByActivityID(loadExclusions(provider.CacheRootPath(), provider.ClientID()))
 	delete(exclusionsByID, activityID)
 	if err := saveExclusions(provider.CacheRootPath(), provider.ClientID(), sortedExclusions(exclusionsByID)); err != nil {
 		return business.DataQualityReport{}, err
 	}
-	return CurrentProviderReport(), nil
+	return ProviderReport(provider), nil
 }
 
 func loadExclusions(cacheRoot string, clientID string) []business.DataQualityExclusion {
@@ -151,7 +148,7 @@ func saveExclusions(cacheRoot string, clientID string, exclusions []business.Dat
 	if err != nil {
 		return fmt.Errorf("unable to encode data quality exclusions: %w", err)
 	}
-	if err := os.WriteFile(exclusionsFilePath(cacheRoot, clientID), data, dataQualitySecureFileMode); err != nil {
+	if err := cachewrite.WriteFile(exclusionsFilePath(cacheRoot, clientID), data, dataQualitySecureFileMode); err != nil {
 		return fmt.Errorf("unable to write data quality exclusions: %w", err)
 	}
 	return nil
@@ -19,46 +19,47 @@ const (
 	earthRadiusMeters = 6371e3
 )
 
-type DataQualityServiceAdapter struct{}
+type DataQualityServiceAdapter struct {
+	providers activityprovider.Lookup
+}
 
-func NewDataQualityServiceAdapter() *DataQualityServiceAdapter {
-	return &DataQualityServiceAdapter{}
+func NewDataQualityServiceAdapter(providers activityprovider.Lookup) *DataQualityServiceAdapter {
+	return &DataQualityServiceAdapter{providers: providers}
 }
 
 func (adapter *DataQualityServiceAdapter) GetDataQualityReport() business.DataQualityReport {
-	return CurrentProviderReport()
+	return ProviderReport(adapter.providers())
 }
 
 func (adapter *DataQualityServiceAdapter) ExcludeActivityFromStats(activityID int64, reason string) (business.DataQualityReport, error) {
-	return excludeCurrentProviderActivityFromStats(activityID, reason)
+	return excludeProviderActivityFromStats(adapter.providers(), activityID, reason)
 }
 
 func (adapter *DataQualityServiceAdapter) IncludeActivityInStats(activityID int64) (business.DataQualityReport, error) {
-	return includeCurrentProviderActivityInStats(activityID)
+	return includeProviderActivityInStats(adapter.providers(), activityID)
 }
 
 func (adapter *DataQualityServiceAdapter) PreviewCorrection(issueID string) (business.DataQualityCorrectionPreview, error) {
-	return CurrentProviderCorrectionPreview(issueID)
+	return ProviderCorrectionPreview(adapter.providers(), issueID)
 }
 
 func (adapter *DataQualityServiceAdapter) PreviewSafeCorrections() business.DataQualityCorrectionPreview {
-	return CurrentProviderSafeCorrectionPreview()
+	return ProviderSafeCorrectionPreview(adapter.providers())
 }
 
 func (adapter *DataQualityServiceAdapter) ApplyCorrection(issueID string) (business.DataQualityReport, error) {
-	return ApplyCurrentProviderCorrection(issueID)
+	return ApplyProviderCorrection(adapter.providers(), issueID)
 }
 
 func (adapter *DataQualityServiceAdapter) ApplySafeCorrections() (business.DataQualityReport, error) {
-	return ApplyCurrentProviderSafeCorrections()
+	return ApplyProviderSafeCorrections(adapter.providers())
 }
 
 func (adapter *DataQualityServiceAdapter) RevertCorrection(correctionID string) (business.DataQualityReport, error) {
-	return RevertCurrentProviderCorrection(correctionID)
+	return RevertProviderCorrection(adapter.providers(), correctionID)
 }
 
-func CurrentProviderReport() business.DataQualityReport {
-	provider := activityprovider.Get()
+func ProviderReport(provider activityprovider.ActivityProvider) business.DataQualityReport {
 	diagnostics := provider.CacheDiagnostics()
 	source := strings.ToLower(fmt.Sprint(diagnostics["provider"]))
 	sourcePath := provider.CacheRootPath()
@@ -141,7 +142,7 @@ func analyzeActivity(source string, sourcePath string, activity *strava.Activity
 			issues = append(issues, newIssue(source, sourcePath, activity, business.DataQualitySeverityInfo, business.DataQualityCategoryMissingStream, "stream", "Detailed stream is missing from the local cache.", "", "Download missing streams from Strava when API access is available."))
 			return issues
 		}
-		if source != "fit" && source != "gpx" {
+		if source != "fit" && source != "gpx" && source != "tcx" && source != "json" {
 			return issues
 		}
 		issues = append(issues, newIssue(source, sourcePath, activity, business.DataQualitySeverityWarning, business.DataQualityCategoryMissingStream, "stream", "Activity has no stream data.", "", "Open the source file and verify GPS/time streams are present."))
@@ -6,27 +6,29 @@ import (
 	"mystravastats/internal/shared/domain/business"
 )
 
-type GearAnalysisServiceAdapter struct{}
+type GearAnalysisServiceAdapter struct {
+	providers activityprovider.Lookup
+}
 
-func NewGearAnalysisServiceAdapter() *GearAnalysisServiceAdapter {
-	return &GearAnalysisServiceAdapter{}
+func NewGearAnalysisServiceAdapter(providers activityprovider.Lookup) *GearAnalysisServiceAdapter {
+	return &GearAnalysisServiceAdapter{providers: providers}
 }
 
 func (adapter *GearAnalysisServiceAdapter) FindGearAnalysis(year *int, activityTypes ...business.ActivityType) business.GearAnalysis {
-	provider := activityprovider.Get()
-	activities := dataqualityInfra.FilterExcludedFromStats(provider.GetActivitiesByYearAndActivityTypes(year, activityTypes...))
-	lifetimeActivities := dataqualityInfra.FilterExcludedFromStats(provider.GetActivitiesByYearAndActivityTypes(nil, allGearActivityTypes()...))
+	provider := adapter.providers()
+	activities := dataqualityInfra.FilterExcludedFromStats(provider, provider.GetActivitiesByYearAndActivityTypes(year, activityTypes...))
+	lifetimeActivities := dataqualityInfra.FilterExcludedFromStats(provider, provider.GetActivitiesByYearAndActivityTypes(nil, allGearActivityTypes()...))
 	athlete := provider.GetAthlete()
-	maintenanceRecords := loadCurrentProviderGearMaintenanceRecords()
+	maintenanceRecords := loadProviderGearMaintenanceRecords(provider)
 	return buildGearAnalysis(activities, lifetimeActivities, athlete, maintenanceRecords)
 }
 
 func (adapter *GearAnalysisServiceAdapter) SaveGearMaintenanceRecord(request business.GearMaintenanceRecordRequest) (business.GearMaintenanceRecord, error) {
-	return saveCurrentProviderGearMaintenanceRecord(request)
+	return saveProviderGearMaintenanceRecord(adapter.providers(), request)
 }
 
 func (adapter *GearAnalysisServiceAdapter) DeleteGearMaintenanceRecord(recordID string) error {
-	return deleteCurrentProviderGearMaintenanceRecord(recordID)
+	return deleteProviderGearMaintenanceRecord(adapter.providers(), recordID)
 }
 
 func allGearActivityTypes() []business.ActivityType {
@@ -12,6 +12,7 @@ import (
 	"unicode"
 
 	"mystravastats/internal/platform/activityprovider"
+	"mystravastats/internal/platform/cachewrite"
 	"mystravastats/internal/shared/domain/business"
 	"mystravastats/internal/shared/domain/strava"
 )
@@ -25,13 +26,11 @@ type gearMaintenanceFile struct {
 	Records []business.GearMaintenanceRecord `json:"records"`
 }
 
-func loadCurrentProviderGearMaintenanceRecords() []business.GearMaintenanceRecord {
-	provider := activityprovider.Get()
+func loadProviderGearMaintenanceRecords(provider activityprovider.ActivityProvider) []business.GearMaintenanceRecord {
 	return loadGearMaintenanceRecords(provider.CacheRootPath(), provider.ClientID())
 }
 
-func saveCurrentProviderGearMaintenanceRecord(request business.GearMaintenanceRecordRequest) (business.GearMaintenanceRecord, error) {
-	provider := activityprovider.Get()
+func saveProviderGearMaintenanceRecord(provider activityprovider.ActivityProvider, request business.GearMaintenanceRecordRequest) (business.GearMaintenanceRecord, error) {
 	normalized, err := normalizeGearMaintenanceRequest(request)
 	if err != nil {
 		return business.GearMaintenanceRecord{}, err
@@ -66,8 +65,7 @@ func saveCurrentProviderGearMaintenanceRecord(request business.GearMaintenanceRe
 	return record, nil
 }
 
-func deleteCurrentProviderGearMaintenanceRecord(recordID string) error {
-	provider := activityprovider.Get()
+func deleteProviderGearMaintenanceRecord(provider activityprovider.ActivityProvider, recordID string) error {
 	trimmedID := strings.TrimSpace(recordID)
 	if trimmedID == "" {
 		return fmt.Errorf("recordId is required")
@@ -119,7 +117,7 @@ func saveGearMaintenanceRecords(cacheRoot string, clientID string, records []bus
 	if err != nil {
 		return fmt.Errorf("unable to encode gear maintenance records: %w", err)
 	}
-	if err := os.WriteFile(gearMaintenanceFilePath(cacheRoot, clientID), data, gearMaintenanceSecureFileMode); err != nil {
+	if err := cachewrite.WriteFile(gearMaintenanceFilePath(cacheRoot, clientID), data, gearMaintenanceSecureFileMode); err != nil {
 		return fmt.Errorf("unable to write gear maintenance records: %w", err)
 	}
 	return nil
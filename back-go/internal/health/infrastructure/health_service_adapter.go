@@ -5,31 +5,39 @@ import (
 	"mystravastats/internal/platform/activityprovider"
 	"mystravastats/internal/platform/runtimeconfig"
 	routeApp "mystravastats/internal/routes/application"
+	"mystravastats/internal/shared/infrastructure/stravaapi"
 	"mystravastats/internal/sourcesync"
 )
 
 // HealthServiceAdapter bridges the current internal/services layer
 // to the hexagonal outbound ports used by health use cases.
 type HealthServiceAdapter struct {
+	providers     activityprovider.Lookup
 	routingEngine routeApp.RoutingEnginePort
 }
 
-func NewHealthServiceAdapter(routingEngine routeApp.RoutingEnginePort) *HealthServiceAdapter {
+func NewHealthServiceAdapter(providers activityprovider.Lookup, routingEngine routeApp.RoutingEnginePort) *HealthServiceAdapter {
 	return &HealthServiceAdapter{
+		providers:     providers,
 		routingEngine: routingEngine,
 	}
 }
 
 func (adapter *HealthServiceAdapter) FindCacheHealthDetails() map[string]any {
-	diagnostics := activityprovider.Get().CacheDiagnostics()
+	provider := adapter.providers()
+	diagnostics := provider.CacheDiagnostics()
 	if diagnostics == nil {
 		diagnostics = map[string]any{}
 	}
 	if adapter.routingEngine != nil {
 		diagnostics["routing"] = adapter.routingEngine.HealthDetails()
 	}
-	diagnostics["dataQuality"] = dataqualityInfra.CurrentProviderReport().Summary
+	diagnostics["dataQuality"] = dataqualityInfra.ProviderReport(provider).Summary
 	diagnostics["runtimeConfig"] = runtimeconfig.Details()
 	diagnostics["sourceSync"] = sourcesync.LastResult()
+	if stravaProvider, ok := activityprovider.StravaOf(provider); ok {
+		diagnostics["stravaReconciliation"] = stravaProvider.ReconciliationReport()
+		diagnostics["stravaRateBudget"] = stravaapi.RateBudgetDiagnostics(stravaProvider.ClientID())
+	}
 	return diagnostics
 }
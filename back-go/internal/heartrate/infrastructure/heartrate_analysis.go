@@ -12,9 +12,9 @@ import (
 var heartRateZoneCodes = []string{"Z1", "Z2", "Z3", "Z4", "Z5"}
 var heartRateZoneLabels = []string{"Recovery", "Endurance", "Tempo", "Threshold", "VO2 Max"}
 
-func computeHeartRateZoneAnalysisByYearAndTypes(year *int, activityTypes ...business.ActivityType) business.HeartRateZoneAnalysis {
-	settings := normalizeHeartRateZoneSettings(activityprovider.Get().GetHeartRateZoneSettings())
-	activities := dataqualityInfra.FilterExcludedFromStats(activityprovider.Get().GetActivitiesByYearAndActivityTypes(year, activityTypes...))
+func computeHeartRateZoneAnalysisByYearAndTypes(provider activityprovider.ActivityProvider, year *int, activityTypes ...business.ActivityType) business.HeartRateZoneAnalysis {
+	settings := normalizeHeartRateZoneSettings(provider.GetHeartRateZoneSettings())
+	activities := dataqualityInfra.FilterExcludedFromStats(provider, provider.GetActivitiesByYearAndActivityTypes(year, activityTypes...))
 	sort.Slice(activities, func(i, j int) bool {
 		return activities[i].StartDateLocal < activities[j].StartDateLocal
 	})
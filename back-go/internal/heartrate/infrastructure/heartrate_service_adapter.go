@@ -6,20 +6,22 @@ import (
 )
 
 // HeartRateServiceAdapter computes heart-rate zone analysis from provider data.
-type HeartRateServiceAdapter struct{}
+type HeartRateServiceAdapter struct {
+	providers activityprovider.Lookup
+}
 
-func NewHeartRateServiceAdapter() *HeartRateServiceAdapter {
-	return &HeartRateServiceAdapter{}
+func NewHeartRateServiceAdapter(providers activityprovider.Lookup) *HeartRateServiceAdapter {
+	return &HeartRateServiceAdapter{providers: providers}
 }
 
 func (adapter *HeartRateServiceAdapter) FindHeartRateZoneSettings() business.HeartRateZoneSettings {
-	return activityprovider.Get().GetHeartRateZoneSettings()
+	return adapter.providers().GetHeartRateZoneSettings()
 }
 
 func (adapter *HeartRateServiceAdapter) SaveHeartRateZoneSettings(settings business.HeartRateZoneSettings) business.HeartRateZoneSettings {
-	return activityprovider.Get().SaveHeartRateZoneSettings(settings)
+	return adapter.providers().SaveHeartRateZoneSettings(settings)
 }
 
 func (adapter *HeartRateServiceAdapter) FindHeartRateZoneAnalysisByYearAndTypes(year *int, activityTypes ...business.ActivityType) business.HeartRateZoneAnalysis {
-	return computeHeartRateZoneAnalysisByYearAndTypes(year, activityTypes...)
+	return computeHeartRateZoneAnalysisByYearAndTypes(adapter.providers(), year, activityTypes...)
 }
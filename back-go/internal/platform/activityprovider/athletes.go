@@ -0,0 +1,374 @@
+package activityprovider
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"mystravastats/internal/helpers"
+	"mystravastats/internal/platform/runtimeconfig"
+)
+
+// AthletesFileEnv points to a JSON file listing the athletes served by this
+// instance. Without it the instance serves one athlete built from the
+// STRAVA_CACHE_PATH / FIT_FILES_PATH / GPX_FILES_PATH / TCX_FILES_PATH /
+// JSON_FILES_PATH keys.
+const AthletesFileEnv = "ATHLETES_FILE"
+
+// DefaultAthleteID identifies the single athlete of an instance without
+// ATHLETES_FILE.
+const DefaultAthleteID = "default"
+
+var athleteIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// Athlete is one entry of ATHLETES_FILE. Each athlete gets its own provider,
+// so every source path must be distinct from the other athletes' paths: the
+// settings, data-quality and gear files live next to those sources.
+type Athlete struct {
+	ID              string `json:"id"`
+	Name            string `json:"name,omitempty"`
+	StravaCachePath string `json:"stravaCachePath,omitempty"`
+	FITFilesPath    string `json:"fitFilesPath,omitempty"`
+	GPXFilesPath    string `json:"gpxFilesPath,omitempty"`
+	TCXFilesPath    string `json:"tcxFilesPath,omitempty"`
+	JSONFilesPath   string `json:"jsonFilesPath,omitempty"`
+}
+
+// AthleteSummary is the public view of a configured athlete.
+type AthleteSummary struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	Default bool     `json:"default"`
+	Sources []string `json:"sources"`
+}
+
+type athletesFile struct {
+	Athletes []Athlete `json:"athletes"`
+}
+
+type athleteSlot struct {
+	once     sync.Once
+	provider ActivityProvider
+}
+
+var (
+	athletesOnce  sync.Once
+	athletes      []Athlete
+	athleteSlots  map[string]*athleteSlot
+	athletesMutex sync.Mutex
+)
+
+func (athlete Athlete) sourcePaths() sourcePaths {
+	return sourcePaths{
+		strava: strings.TrimSpace(athlete.StravaCachePath),
+		fit:    strings.TrimSpace(athlete.FITFilesPath),
+		gpx:    strings.TrimSpace(athlete.GPXFilesPath),
+		tcx:    strings.TrimSpace(athlete.TCXFilesPath),
+		json:   strings.TrimSpace(athlete.JSONFilesPath),
+	}
+}
+
+func (paths sourcePaths) names() []string {
+	names := make([]string, 0, 5)
+	if paths.strava != "" {
+		names = append(names, "strava")
+	}
+	if paths.fit != "" {
+		names = append(names, "fit")
+	}
+	if paths.gpx != "" {
+		names = append(names, "gpx")
+	}
+	if paths.tcx != "" {
+		names = append(names, "tcx")
+	}
+	if paths.json != "" {
+		names = append(names, "json")
+	}
+	return names
+}
+
+func (paths sourcePaths) all() []string {
+	return []string{paths.strava, paths.fit, paths.gpx, paths.tcx, paths.json}
+}
+
+// Athletes lists the athletes served by this instance, default athlete first.
+func Athletes() []AthleteSummary {
+	configured := configuredAthletes()
+	if len(configured) == 0 {
+		names := environmentSourcePaths().names()
+		if len(names) == 0 {
+			names = []string{"strava"}
+		}
+		return []AthleteSummary{{ID: DefaultAthleteID, Name: DefaultAthleteID, Default: true, Sources: names}}
+	}
+
+	summaries := make([]AthleteSummary, 0, len(configured))
+	for index, athlete := range configured {
+		summaries = append(summaries, AthleteSummary{
+			ID:      athlete.ID,
+			Name:    athlete.Name,
+			Default: index == 0,
+			Sources: athlete.sourcePaths().names(),
+		})
+	}
+	return summaries
+}
+
+// CacheRoots lists the distinct source directories of every athlete, where the
+// caches and settings of each source are stored, followed by the default Strava
+// cache, which also holds instance-wide files such as the sync history.
+func CacheRoots() []string {
+	pathSets := []sourcePaths{environmentSourcePaths()}
+	if configured := configuredAthletes(); len(configured) > 0 {
+		pathSets = pathSets[:0]
+		for _, athlete := range configured {
+			pathSets = append(pathSets, athlete.sourcePaths())
+		}
+	}
+	candidates := make([]string, 0, len(pathSets)*5+1)
+	for _, paths := range pathSets {
+		candidates = append(candidates, paths.all()...)
+	}
+	candidates = append(candidates, helpers.StravaCachePath)
+
+	roots := make([]string, 0, len(candidates))
+	seen := make(map[string]struct{}, len(candidates))
+	for _, path := range candidates {
+		if path == "" {
+			continue
+		}
+		key := filepath.Clean(path)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		roots = append(roots, path)
+	}
+	return roots
+}
+
+// SourceRoots returns the directory of each source of athleteID, keyed by
+// source name. Without any configured source, Strava is read from the default
+// cache path.
+func SourceRoots(athleteID string) (map[string]string, bool) {
+	paths := environmentSourcePaths()
+	if athlete, ok := findAthlete(athleteID); ok {
+		paths = athlete.sourcePaths()
+	} else if athleteID != DefaultAthlete() {
+		return nil, false
+	}
+
+	roots := make(map[string]string, 5)
+	all := paths.all()
+	for index, name := range []string{"strava", "fit", "gpx", "tcx", "json"} {
+		if all[index] != "" {
+			roots[name] = all[index]
+		}
+	}
+	if len(roots) == 0 {
+		roots["strava"] = helpers.StravaCachePath
+	}
+	return roots, true
+}
+
+// DefaultAthlete returns the id of the athlete served when a request does not
+// select one.
+func DefaultAthlete() string {
+	if athlete, ok := defaultAthlete(); ok {
+		return athlete.ID
+	}
+	return DefaultAthleteID
+}
+
+// HasAthlete reports whether athleteID is served by this instance.
+func HasAthlete(athleteID string) bool {
+	if athleteID == DefaultAthlete() {
+		return true
+	}
+	_, ok := findAthlete(athleteID)
+	return ok
+}
+
+// ForAthlete returns the provider of athleteID, building it on first use. The
+// default athlete shares the provider returned by Get.
+func ForAthlete(athleteID string) (ActivityProvider, bool) {
+	if athleteID == "" || athleteID == DefaultAthlete() {
+		return Get(), true
+	}
+	athlete, ok := findAthlete(athleteID)
+	if !ok {
+		return nil, false
+	}
+
+	athletesMutex.Lock()
+	slot, exists := athleteSlots[athlete.ID]
+	if !exists {
+		slot = &athleteSlot{}
+		athleteSlots[athlete.ID] = slot
+	}
+	athletesMutex.Unlock()
+
+	slot.once.Do(func() {
+		log.Printf("Initialize activity provider for athlete %s (%s)", athlete.ID, strings.Join(athlete.sourcePaths().names(), ", "))
+		slot.provider = newProvider(athlete.sourcePaths())
+	})
+	return slot.provider, true
+}
+
+// LookupFor returns a Lookup bound to athleteID.
+func LookupFor(athleteID string) Lookup {
+	return func() ActivityProvider {
+		provider, ok := ForAthlete(athleteID)
+		if !ok {
+			return Get()
+		}
+		return provider
+	}
+}
+
+// Loaded returns the providers built so far, default athlete first.
+func Loaded() []ActivityProvider {
+	providers := []ActivityProvider{Get()}
+	athletesMutex.Lock()
+	defer athletesMutex.Unlock()
+	for _, athlete := range configuredAthletes() {
+		if slot, ok := athleteSlots[athlete.ID]; ok && slot.provider != nil {
+			providers = append(providers, slot.provider)
+		}
+	}
+	return providers
+}
+
+// LoadedFor returns the provider of athleteID when it has already been built,
+// without building it.
+func LoadedFor(athleteID string) (ActivityProvider, bool) {
+	if athleteID == "" || athleteID == DefaultAthlete() {
+		return Get(), true
+	}
+	athletesMutex.Lock()
+	defer athletesMutex.Unlock()
+	if slot, ok := athleteSlots[athleteID]; ok && slot.provider != nil {
+		return slot.provider, true
+	}
+	return nil, false
+}
+
+func defaultAthlete() (Athlete, bool) {
+	configured := configuredAthletes()
+	if len(configured) == 0 {
+		return Athlete{}, false
+	}
+	return configured[0], true
+}
+
+func findAthlete(athleteID string) (Athlete, bool) {
+	for _, athlete := range configuredAthletes() {
+		if athlete.ID == athleteID {
+			return athlete, true
+		}
+	}
+	return Athlete{}, false
+}
+
+func configuredAthletes() []Athlete {
+	athletesOnce.Do(func() {
+		athleteSlots = make(map[string]*athleteSlot)
+		path, ok := runtimeconfig.OptionalValue(AthletesFileEnv)
+		if !ok {
+			return
+		}
+		loaded, err := loadAthletes(path)
+		if err != nil {
+			log.Printf("Ignoring %s=%s: %v", AthletesFileEnv, path, err)
+			return
+		}
+		athletes = loaded
+		log.Printf("Serving %d athletes from %s (default: %s)", len(athletes), path, athletes[0].ID)
+	})
+	return athletes
+}
+
+// loadAthletes reads and validates an athletes file. Entries with an invalid
+// id, no source or a source path already used by another athlete are skipped.
+func loadAthletes(path string) ([]Athlete, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var payload athletesFile
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("invalid athletes file: %w", err)
+	}
+
+	baseDirectory := filepath.Dir(path)
+	seenIDs := make(map[string]struct{})
+	seenPaths := make(map[string]string)
+	loaded := make([]Athlete, 0, len(payload.Athletes))
+	for _, athlete := range payload.Athletes {
+		athlete.ID = strings.TrimSpace(athlete.ID)
+		if !athleteIDPattern.MatchString(athlete.ID) || athlete.ID == "me" {
+			log.Printf("Skipping athlete with invalid id %q", athlete.ID)
+			continue
+		}
+		if _, duplicate := seenIDs[athlete.ID]; duplicate {
+			log.Printf("Skipping duplicate athlete id %q", athlete.ID)
+			continue
+		}
+		if strings.TrimSpace(athlete.Name) == "" {
+			athlete.Name = athlete.ID
+		}
+		athlete.StravaCachePath = resolveAthletePath(baseDirectory, athlete.StravaCachePath)
+		athlete.FITFilesPath = resolveAthletePath(baseDirectory, athlete.FITFilesPath)
+		athlete.GPXFilesPath = resolveAthletePath(baseDirectory, athlete.GPXFilesPath)
+		athlete.TCXFilesPath = resolveAthletePath(baseDirectory, athlete.TCXFilesPath)
+		athlete.JSONFilesPath = resolveAthletePath(baseDirectory, athlete.JSONFilesPath)
+
+		paths := athlete.sourcePaths()
+		if len(paths.names()) == 0 {
+			log.Printf("Skipping athlete %q without any source path", athlete.ID)
+			continue
+		}
+		if owner, shared := sharedAthletePath(paths, seenPaths); shared {
+			log.Printf("Skipping athlete %q: a source path is already used by athlete %q", athlete.ID, owner)
+			continue
+		}
+		for _, sourcePath := range paths.all() {
+			if sourcePath != "" {
+				seenPaths[sourcePath] = athlete.ID
+			}
+		}
+		seenIDs[athlete.ID] = struct{}{}
+		loaded = append(loaded, athlete)
+	}
+	if len(loaded) == 0 {
+		return nil, fmt.Errorf("no valid athlete")
+	}
+	return loaded, nil
+}
+
+func sharedAthletePath(paths sourcePaths, seenPaths map[string]string) (string, bool) {
+	for _, sourcePath := range paths.all() {
+		if owner, ok := seenPaths[sourcePath]; ok && sourcePath != "" {
+			return owner, true
+		}
+	}
+	return "", false
+}
+
+// resolveAthletePath makes relative paths relative to the athletes file.
+func resolveAthletePath(baseDirectory string, value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ""
+	}
+	if filepath.IsAbs(value) {
+		return filepath.Clean(value)
+	}
+	return filepath.Clean(filepath.Join(baseDirectory, value))
+}
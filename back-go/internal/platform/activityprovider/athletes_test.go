@@ -0,0 +1,61 @@
+package activityprovider
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadAthletes_ResolvesPathsAndSkipsInvalidEntries(t *testing.T) {
+	// GIVEN
+	directory := t.TempDir()
+	path := filepath.Join(directory, "athletes.json")
+	content := `{"athletes":[
+		{"id":"alice","name":"Alice","stravaCachePath":"alice/strava","fitFilesPath":"/data/alice/fit"},
+		{"id":"bob","gpxFilesPath":"bob/gpx"},
+		{"id":"alice","gpxFilesPath":"other/gpx"},
+		{"id":"me","fitFilesPath":"me/fit"},
+		{"id":"bad id","fitFilesPath":"bad/fit"},
+		{"id":"carol"},
+		{"id":"dave","stravaCachePath":"alice/strava"}
+	]}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("unable to write athletes file: %v", err)
+	}
+
+	// WHEN
+	athletes, err := loadAthletes(path)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("expected athletes file to load, got %v", err)
+	}
+	if len(athletes) != 2 || athletes[0].ID != "alice" || athletes[1].ID != "bob" {
+		t.Fatalf("expected only alice and bob, got %#v", athletes)
+	}
+	if athletes[0].StravaCachePath != filepath.Join(directory, "alice", "strava") || athletes[0].FITFilesPath != "/data/alice/fit" {
+		t.Fatalf("expected paths resolved against the athletes file, got %#v", athletes[0])
+	}
+	if athletes[1].Name != "bob" || athletes[1].StravaCachePath != "" {
+		t.Fatalf("expected name to default to id and unset paths to stay empty, got %#v", athletes[1])
+	}
+	if names := athletes[1].sourcePaths().names(); len(names) != 1 || names[0] != "gpx" {
+		t.Fatalf("expected bob to only have a GPX source, got %v", names)
+	}
+}
+
+func TestLoadAthletes_RejectsFileWithoutValidAthlete(t *testing.T) {
+	// GIVEN
+	path := filepath.Join(t.TempDir(), "athletes.json")
+	if err := os.WriteFile(path, []byte(`{"athletes":[{"id":"carol"}]}`), 0o600); err != nil {
+		t.Fatalf("unable to write athletes file: %v", err)
+	}
+
+	// WHEN
+	_, err := loadAthletes(path)
+
+	// THEN
+	if err == nil {
+		t.Fatal("expected an error when no athlete is usable")
+	}
+}
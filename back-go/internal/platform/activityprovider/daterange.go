@@ -0,0 +1,175 @@
+package activityprovider
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"mystravastats/internal/helpers"
+	"mystravastats/internal/shared/domain/business"
+	"mystravastats/internal/shared/domain/strava"
+)
+
+const dateRangeLayout = "2006-01-02"
+
+// maxRollingRangeYears bounds a rolling range: the daily and periodic charts
+// fill every period of the range.
+const maxRollingRangeYears = 50
+
+// rollingRangePattern reads a rolling range such as 90d, 12w, 6m or 1y.
+var rollingRangePattern = regexp.MustCompile(`^([1-9][0-9]{0,3})([dwmy])$`)
+
+// DateRange keeps the activities started between From and To, both local days
+// in YYYY-MM-DD form and inclusive. An empty bound leaves that side open, so
+// the zero value keeps every activity.
+type DateRange struct {
+	From string
+	To   string
+}
+
+// ParseDateRange reads a range either from explicit from and to days or from a
+// rolling last value (90d, 12w, 6m, 1y) ending on today. A rolling range is
+// resolved to its days, so that it can be compared and cached like any other.
+func ParseDateRange(from string, to string, last string, today time.Time) (DateRange, error) {
+	if last != "" {
+		if from != "" || to != "" {
+			return DateRange{}, fmt.Errorf("last cannot be combined with from or to")
+		}
+		return rollingDateRange(last, today)
+	}
+	if err := checkRangeDay("from", from); err != nil {
+		return DateRange{}, err
+	}
+	if err := checkRangeDay("to", to); err != nil {
+		return DateRange{}, err
+	}
+	if from != "" && to != "" && from > to {
+		return DateRange{}, fmt.Errorf("from date %s is after to date %s", from, to)
+	}
+	return DateRange{From: from, To: to}, nil
+}
+
+func checkRangeDay(key string, value string) error {
+	if value == "" {
+		return nil
+	}
+	if _, err := time.Parse(dateRangeLayout, value); err != nil {
+		return fmt.Errorf("invalid %s date: %q (expected YYYY-MM-DD)", key, value)
+	}
+	return nil
+}
+
+func rollingDateRange(last string, today time.Time) (DateRange, error) {
+	match := rollingRangePattern.FindStringSubmatch(last)
+	if match == nil {
+		return DateRange{}, fmt.Errorf("invalid last range: %q (expected e.g. 90d, 12w, 6m or 1y)", last)
+	}
+	count, _ := strconv.Atoi(match[1])
+	years, months, days := 0, 0, 0
+	switch match[2] {
+	case "d":
+		days = count
+	case "w":
+		days = 7 * count
+	case "m":
+		months = count
+	case "y":
+		years = count
+	}
+	end := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	start := end.AddDate(-years, -months, -days).AddDate(0, 0, 1)
+	if start.Before(end.AddDate(-maxRollingRangeYears, 0, 0)) {
+		return DateRange{}, fmt.Errorf("last range %q is longer than %d years", last, maxRollingRangeYears)
+	}
+	return DateRange{From: start.Format(dateRangeLayout), To: end.Format(dateRangeLayout)}, nil
+}
+
+// IsZero reports whether the range keeps every activity.
+func (dateRange DateRange) IsZero() bool {
+	return dateRange.From == "" && dateRange.To == ""
+}
+
+// String returns the range as from..to, with an empty side when it is open.
+func (dateRange DateRange) String() string {
+	return dateRange.From + ".." + dateRange.To
+}
+
+// Contains reports whether the activity started within the range. Activities
+// without a readable start date are only kept by the zero range.
+func (dateRange DateRange) Contains(activity *strava.Activity) bool {
+	if dateRange.IsZero() {
+		return true
+	}
+	day := ActivityDay(activity)
+	if day == "" {
+		return false
+	}
+	return (dateRange.From == "" || day >= dateRange.From) && (dateRange.To == "" || day <= dateRange.To)
+}
+
+// ActivityDay returns the local day an activity started, in YYYY-MM-DD form,
+// or an empty string when its dates cannot be read.
+func ActivityDay(activity *strava.Activity) string {
+	return helpers.ExtractSortableDay(helpers.FirstNonEmpty(activity.StartDateLocal, activity.StartDate))
+}
+
+// WithinDateRange returns a lookup whose provider only lists the activities
+// started within dateRange. Detailed activities and settings are read from
+// the unrestricted provider.
+func WithinDateRange(lookup Lookup, dateRange DateRange) Lookup {
+	if dateRange.IsZero() {
+		return lookup
+	}
+	return func() ActivityProvider {
+		return &dateRangeProvider{ActivityProvider: lookup(), dateRange: dateRange}
+	}
+}
+
+type dateRangeProvider struct {
+	ActivityProvider
+	dateRange DateRange
+}
+
+// DateRangeOf returns the date range provider is restricted to, if any.
+func DateRangeOf(provider ActivityProvider) (DateRange, bool) {
+	for {
+		switch current := provider.(type) {
+		case *dateRangeProvider:
+			return current.dateRange, true
+		case *sourceFilteredProvider:
+			provider = current.ActivityProvider
+		default:
+			return DateRange{}, false
+		}
+	}
+}
+
+func (provider *dateRangeProvider) GetActivitiesByYearAndActivityTypes(year *int, activityTypes ...business.ActivityType) []*strava.Activity {
+	return provider.keep(provider.ActivityProvider.GetActivitiesByYearAndActivityTypes(year, activityTypes...))
+}
+
+func (provider *dateRangeProvider) GetActivitiesByActivityTypeGroupByYear(activityTypes ...business.ActivityType) map[string][]*strava.Activity {
+	grouped := provider.ActivityProvider.GetActivitiesByActivityTypeGroupByYear(activityTypes...)
+	result := make(map[string][]*strava.Activity, len(grouped))
+	for year, activities := range grouped {
+		if kept := provider.keep(activities); len(kept) > 0 {
+			result[year] = kept
+		}
+	}
+	return result
+}
+
+func (provider *dateRangeProvider) GetActivitiesByActivityTypeGroupByActiveDays(activityTypes ...business.ActivityType) map[string]int {
+	return activeDayDistances(provider.GetActivitiesByYearAndActivityTypes(nil, activityTypes...))
+}
+
+func (provider *dateRangeProvider) keep(activities []*strava.Activity) []*strava.Activity {
+	kept := make([]*strava.Activity, 0, len(activities))
+	for _, activity := range activities {
+		if activity != nil && provider.dateRange.Contains(activity) {
+			kept = append(kept, activity)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,119 @@
+package activityprovider
+
+import (
+	"testing"
+	"time"
+
+	"mystravastats/internal/shared/domain/business"
+	"mystravastats/internal/shared/domain/strava"
+)
+
+func TestParseDateRange_ResolvesRollingRangesEndingToday(t *testing.T) {
+	// GIVEN
+	today := time.Date(2026, time.October, 18, 21, 30, 0, 0, time.Local)
+	cases := map[string]DateRange{
+		"90d": {From: "2026-07-21", To: "2026-10-18"},
+		"2w":  {From: "2026-10-05", To: "2026-10-18"},
+		"6m":  {From: "2026-04-19", To: "2026-10-18"},
+		"1y":  {From: "2025-10-19", To: "2026-10-18"},
+		"50y": {From: "1976-10-19", To: "2026-10-18"},
+	}
+
+	for last, expected := range cases {
+		// WHEN
+		dateRange, err := ParseDateRange("", "", last, today)
+
+		// THEN
+		if err != nil {
+			t.Fatalf("expected %q to parse, got %v", last, err)
+		}
+		if dateRange != expected {
+			t.Fatalf("expected %q to resolve to %s, got %s", last, expected, dateRange)
+		}
+	}
+}
+
+func TestParseDateRange_RejectsInvalidRanges(t *testing.T) {
+	today := time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)
+	cases := [][3]string{
+		{"2026-13-01", "", ""},
+		{"", "18/10/2026", ""},
+		{"2026-03-31", "2025-10-01", ""},
+		{"", "", "90"},
+		{"", "", "0d"},
+		{"", "", "3h"},
+		{"", "", "51y"},
+		{"", "", "9999y"},
+		{"", "", "2700w"},
+		{"2025-10-01", "", "90d"},
+	}
+
+	for _, values := range cases {
+		// WHEN
+		_, err := ParseDateRange(values[0], values[1], values[2], today)
+
+		// THEN
+		if err == nil {
+			t.Fatalf("expected from=%q to=%q last=%q to be rejected", values[0], values[1], values[2])
+		}
+	}
+}
+
+func TestWithinDateRange_ListsActivitiesStartedWithinTheRange(t *testing.T) {
+	// GIVEN
+	provider := &testSourceProvider{name: "fit", activities: []*strava.Activity{
+		testRide(2001, "2025-09-30T23:30:00Z", nil),
+		testRide(2002, "2025-10-01T07:00:00Z", nil),
+		testRide(2003, "2026-01-15T08:00:00Z", nil),
+		testRide(2004, "2026-03-31T18:00:00Z", nil),
+		testRide(2005, "2026-04-01T08:00:00Z", nil),
+	}}
+	dateRange, _ := ParseDateRange("2025-10-01", "2026-03-31", "", time.Now())
+
+	// WHEN
+	ranged := WithinDateRange(func() ActivityProvider { return provider }, dateRange)()
+
+	// THEN
+	activities := ranged.GetActivitiesByYearAndActivityTypes(nil, business.Ride)
+	if len(activities) != 3 || activities[0].Id != 2002 || activities[2].Id != 2004 {
+		t.Fatalf("expected the October to March activities, got %d activities", len(activities))
+	}
+	if byYear := ranged.GetActivitiesByActivityTypeGroupByYear(business.Ride); len(byYear["2025"]) != 1 || len(byYear["2026"]) != 2 {
+		t.Fatalf("expected one activity in 2025 and two in 2026, got %#v", byYear)
+	}
+	if days := ranged.GetActivitiesByActivityTypeGroupByActiveDays(business.Ride); len(days) != 3 || days["2025-10-01"] != 30 {
+		t.Fatalf("expected three active days within the range, got %#v", days)
+	}
+	if found, ok := DateRangeOf(ranged); !ok || found != dateRange {
+		t.Fatalf("expected the date range of the provider, got %s", found)
+	}
+	if Unfiltered(ranged) != provider {
+		t.Fatal("expected the unrestricted provider behind the date range")
+	}
+}
+
+func TestWithinDateRange_CombinesWithSourceFilter(t *testing.T) {
+	// GIVEN
+	provider := &testSourceProvider{name: "fit", activities: []*strava.Activity{
+		testRide(2001, "2025-10-12T08:00:00Z", &strava.Stream{}),
+		testRide(2002, "2025-11-02T08:00:00Z", nil),
+		testRide(2003, "2026-05-01T08:00:00Z", &strava.Stream{}),
+	}}
+	filter, _ := ParseSourceFilter("stream")
+	dateRange, _ := ParseDateRange("2025-10-01", "2026-03-31", "", time.Now())
+
+	// WHEN
+	ranged := WithinDateRange(Filtered(func() ActivityProvider { return provider }, filter), dateRange)()
+
+	// THEN
+	activities := ranged.GetActivitiesByYearAndActivityTypes(nil, business.Ride)
+	if len(activities) != 1 || activities[0].Id != 2001 {
+		t.Fatalf("expected only the activity with a stream within the range, got %d activities", len(activities))
+	}
+	if Unfiltered(ranged) != provider {
+		t.Fatal("expected the provider behind both filters")
+	}
+	if _, ok := DateRangeOf(Filtered(func() ActivityProvider { return provider }, filter)()); ok {
+		t.Fatal("expected no date range behind a source filter alone")
+	}
+}
@@ -10,7 +10,9 @@ import (
 	compositeprovider "mystravastats/internal/shared/infrastructure/composite"
 	fitprovider "mystravastats/internal/shared/infrastructure/fit"
 	gpxprovider "mystravastats/internal/shared/infrastructure/gpx"
+	jsonexportprovider "mystravastats/internal/shared/infrastructure/jsonexport"
 	"mystravastats/internal/shared/infrastructure/stravaapi"
+	tcxprovider "mystravastats/internal/shared/infrastructure/tcx"
 )
 
 var (
@@ -39,70 +41,183 @@ type ReloadableActivityProvider interface {
 	Reload()
 }
 
-// Init eagerly initializes the activity provider singleton.
+// Lookup resolves the provider an adapter reads from. Adapters keep a Lookup
+// rather than a provider so that building them does not load any source.
+type Lookup func() ActivityProvider
+
+// Init eagerly initializes the default athlete provider.
 func Init(port string) {
 	serverPort = port
 	_ = Get()
 }
 
-// Get returns the singleton activity provider (FIT, GPX or Strava).
+// Get returns the default athlete provider (FIT, GPX, TCX, JSON, Strava or
+// composite).
 func Get() ActivityProvider {
 	providerOnce.Do(func() {
-		stravaCachePath, stravaConfigured := runtimeconfig.OptionalValue("STRAVA_CACHE_PATH")
-		fitFilesPath, fitConfigured := runtimeconfig.OptionalValue("FIT_FILES_PATH")
-		gpxFilesPath, gpxConfigured := runtimeconfig.OptionalValue("GPX_FILES_PATH")
+		if athlete, ok := defaultAthlete(); ok {
+			provider = newProvider(athlete.sourcePaths())
+			return
+		}
+		provider = newProvider(environmentSourcePaths())
+	})
+	return provider
+}
+
+// sourcePaths holds the configured location of each source; an empty path
+// means the source is not configured.
+type sourcePaths struct {
+	strava string
+	fit    string
+	gpx    string
+	tcx    string
+	json   string
+}
 
-		configuredSources := 0
+func environmentSourcePaths() sourcePaths {
+	paths := sourcePaths{}
+	if value, ok := runtimeconfig.OptionalValue("STRAVA_CACHE_PATH"); ok {
+		paths.strava = value
+	}
+	if value, ok := runtimeconfig.OptionalValue("FIT_FILES_PATH"); ok {
+		paths.fit = value
+	}
+	if value, ok := runtimeconfig.OptionalValue("GPX_FILES_PATH"); ok {
+		paths.gpx = value
+	}
+	if value, ok := runtimeconfig.OptionalValue("TCX_FILES_PATH"); ok {
+		paths.tcx = value
+	}
+	if value, ok := runtimeconfig.OptionalValue("JSON_FILES_PATH"); ok {
+		paths.json = value
+	}
+	return paths
+}
+
+func newProvider(paths sourcePaths) ActivityProvider {
+	stravaConfigured := paths.strava != ""
+	fitConfigured := paths.fit != ""
+	gpxConfigured := paths.gpx != ""
+	tcxConfigured := paths.tcx != ""
+	jsonConfigured := paths.json != ""
+
+	configuredSources := 0
+	if stravaConfigured {
+		configuredSources++
+	}
+	if fitConfigured {
+		configuredSources++
+	}
+	if gpxConfigured {
+		configuredSources++
+	}
+	if tcxConfigured {
+		configuredSources++
+	}
+	if jsonConfigured {
+		configuredSources++
+	}
+
+	if configuredSources > 1 {
+		sources := make([]compositeprovider.Source, 0, configuredSources)
 		if stravaConfigured {
-			configuredSources++
+			sources = append(sources, compositeprovider.Source{
+				Name:     "strava",
+				Provider: stravaapi.NewStravaActivityProvider(paths.strava, serverPort),
+			})
 		}
 		if fitConfigured {
-			configuredSources++
+			sources = append(sources, compositeprovider.Source{
+				Name:     "fit",
+				Provider: fitprovider.NewFITActivityProvider(paths.fit),
+			})
 		}
 		if gpxConfigured {
-			configuredSources++
+			sources = append(sources, compositeprovider.Source{
+				Name:     "gpx",
+				Provider: gpxprovider.NewGPXActivityProvider(paths.gpx),
+			})
 		}
-
-		if configuredSources > 1 {
-			sources := make([]compositeprovider.Source, 0, configuredSources)
-			if stravaConfigured {
-				sources = append(sources, compositeprovider.Source{
-					Name:     "strava",
-					Provider: stravaapi.NewStravaActivityProvider(stravaCachePath, serverPort),
-				})
-			}
-			if fitConfigured {
-				sources = append(sources, compositeprovider.Source{
-					Name:     "fit",
-					Provider: fitprovider.NewFITActivityProvider(fitFilesPath),
-				})
-			}
-			if gpxConfigured {
-				sources = append(sources, compositeprovider.Source{
-					Name:     "gpx",
-					Provider: gpxprovider.NewGPXActivityProvider(gpxFilesPath),
-				})
-			}
-			provider = compositeprovider.NewCompositeActivityProvider(sources)
-			return
+		if tcxConfigured {
+			sources = append(sources, compositeprovider.Source{
+				Name:     "tcx",
+				Provider: tcxprovider.NewTCXActivityProvider(paths.tcx),
+			})
 		}
-
-		if fitConfigured {
-			provider = fitprovider.NewFITActivityProvider(fitFilesPath)
-			return
+		if jsonConfigured {
+			sources = append(sources, compositeprovider.Source{
+				Name:     "json",
+				Provider: jsonexportprovider.NewJSONActivityProvider(paths.json),
+			})
 		}
-		if gpxConfigured {
-			provider = gpxprovider.NewGPXActivityProvider(gpxFilesPath)
-			return
-		}
-		provider = stravaapi.NewStravaActivityProvider(helpers.StravaCachePath, serverPort)
-	})
-	return provider
+		return compositeprovider.NewCompositeActivityProvider(sources)
+	}
+
+	if fitConfigured {
+		return fitprovider.NewFITActivityProvider(paths.fit)
+	}
+	if gpxConfigured {
+		return gpxprovider.NewGPXActivityProvider(paths.gpx)
+	}
+	if tcxConfigured {
+		return tcxprovider.NewTCXActivityProvider(paths.tcx)
+	}
+	if jsonConfigured {
+		return jsonexportprovider.NewJSONActivityProvider(paths.json)
+	}
+	if stravaConfigured {
+		return stravaapi.NewStravaActivityProvider(paths.strava, serverPort)
+	}
+	return stravaapi.NewStravaActivityProvider(helpers.StravaCachePath, serverPort)
+}
+
+// Strava returns the default athlete Strava provider when it is the active
+// source or one of the composite sources.
+func Strava() (*stravaapi.StravaActivityProvider, bool) {
+	return StravaOf(Get())
+}
+
+// StravaOf returns the Strava provider behind current, unwrapping composites,
+// source filters and date ranges.
+func StravaOf(current ActivityProvider) (*stravaapi.StravaActivityProvider, bool) {
+	switch current := Unfiltered(current).(type) {
+	case *stravaapi.StravaActivityProvider:
+		return current, true
+	case *compositeprovider.CompositeActivityProvider:
+		stravaProvider, ok := current.SourceProvider("strava").(*stravaapi.StravaActivityProvider)
+		return stravaProvider, ok
+	}
+	return nil, false
 }
 
+// Reload reloads every athlete provider built so far.
 func Reload() {
-	currentProvider := Get()
+	for _, currentProvider := range Loaded() {
+		if reloadable, ok := currentProvider.(ReloadableActivityProvider); ok {
+			reloadable.Reload()
+		}
+	}
+}
+
+// ReloadAthlete reloads the provider of athleteID. A provider that has not been
+// built yet is left alone: it reads its sources when first used.
+func ReloadAthlete(athleteID string) {
+	currentProvider, ok := LoadedFor(athleteID)
+	if !ok {
+		return
+	}
 	if reloadable, ok := currentProvider.(ReloadableActivityProvider); ok {
 		reloadable.Reload()
 	}
 }
+
+// ReloadFromCache rereads the caches of current from disk, without calling
+// Strava, for instance after they were restored from a backup.
+func ReloadFromCache(current ActivityProvider) {
+	switch reloadable := Unfiltered(current).(type) {
+	case interface{ ReloadFromCache() }:
+		reloadable.ReloadFromCache()
+	case ReloadableActivityProvider:
+		reloadable.Reload()
+	}
+}
@@ -0,0 +1,256 @@
+package activityprovider
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"mystravastats/internal/shared/domain/business"
+	"mystravastats/internal/shared/domain/strava"
+	compositeprovider "mystravastats/internal/shared/infrastructure/composite"
+	fitprovider "mystravastats/internal/shared/infrastructure/fit"
+	gpxprovider "mystravastats/internal/shared/infrastructure/gpx"
+	jsonexportprovider "mystravastats/internal/shared/infrastructure/jsonexport"
+	"mystravastats/internal/shared/infrastructure/stravaapi"
+	tcxprovider "mystravastats/internal/shared/infrastructure/tcx"
+)
+
+// SourceNames lists the sources an activity can come from.
+var SourceNames = []string{"strava", "fit", "gpx", "tcx", "json"}
+
+const (
+	sourceFilterNegation = "no-"
+	sourceFilterStream   = "stream"
+	sourceFilterMerged   = "merged"
+	sourceFilterUnmerged = "unmerged"
+)
+
+// SourceFilter keeps the activities whose provenance matches every condition.
+// The zero value keeps every activity.
+type SourceFilter struct {
+	// Providers must each hold a copy of the activity.
+	Providers []string
+	// MissingProviders must not hold a copy of the activity.
+	MissingProviders []string
+	// Stream requires at least one copy with a stream, or none when false.
+	Stream *bool
+	// Merged requires copies from more than one source, or a single one when
+	// false.
+	Merged *bool
+}
+
+// ParseSourceFilter parses a comma-separated list of conditions: a source name
+// (strava, fit, gpx, tcx, json), "stream", "merged" or "unmerged". A "no-"
+// prefix negates a source name or "stream", e.g. "fit,no-strava" keeps the
+// FIT activities missing from Strava.
+func ParseSourceFilter(value string) (SourceFilter, error) {
+	filter := SourceFilter{}
+	for _, term := range strings.Split(value, ",") {
+		term = strings.ToLower(strings.TrimSpace(term))
+		if term == "" {
+			continue
+		}
+		name, negated := strings.CutPrefix(term, sourceFilterNegation)
+		switch {
+		case term == sourceFilterMerged || term == sourceFilterUnmerged:
+			merged := term == sourceFilterMerged
+			if filter.Merged != nil && *filter.Merged != merged {
+				return SourceFilter{}, fmt.Errorf("source filter cannot be both merged and unmerged")
+			}
+			filter.Merged = &merged
+		case name == sourceFilterStream:
+			stream := !negated
+			if filter.Stream != nil && *filter.Stream != stream {
+				return SourceFilter{}, fmt.Errorf("source filter cannot be both stream and no-stream")
+			}
+			filter.Stream = &stream
+		case slices.Contains(SourceNames, name):
+			required, excluded := &filter.Providers, &filter.MissingProviders
+			if negated {
+				required, excluded = excluded, required
+			}
+			if slices.Contains(*excluded, name) {
+				return SourceFilter{}, fmt.Errorf("source filter cannot both require and exclude %s", name)
+			}
+			if !slices.Contains(*required, name) {
+				*required = append(*required, name)
+			}
+		default:
+			return SourceFilter{}, fmt.Errorf("unknown source filter: %q", term)
+		}
+	}
+	slices.Sort(filter.Providers)
+	slices.Sort(filter.MissingProviders)
+	return filter, nil
+}
+
+// IsZero reports whether the filter keeps every activity.
+func (filter SourceFilter) IsZero() bool {
+	return len(filter.Providers) == 0 && len(filter.MissingProviders) == 0 && filter.Stream == nil && filter.Merged == nil
+}
+
+// String returns the canonical form of the filter, which ParseSourceFilter
+// reads back.
+func (filter SourceFilter) String() string {
+	terms := append([]string(nil), filter.Providers...)
+	for _, name := range filter.MissingProviders {
+		terms = append(terms, sourceFilterNegation+name)
+	}
+	if filter.Stream != nil {
+		if *filter.Stream {
+			terms = append(terms, sourceFilterStream)
+		} else {
+			terms = append(terms, sourceFilterNegation+sourceFilterStream)
+		}
+	}
+	if filter.Merged != nil {
+		if *filter.Merged {
+			terms = append(terms, sourceFilterMerged)
+		} else {
+			terms = append(terms, sourceFilterUnmerged)
+		}
+	}
+	return strings.Join(terms, ",")
+}
+
+// Matches reports whether an activity merged from sources passes the filter.
+func (filter SourceFilter) Matches(sources []compositeprovider.ActivitySourceRef) bool {
+	providers := make(map[string]struct{}, len(sources))
+	hasStream := false
+	for _, source := range sources {
+		providers[source.Provider] = struct{}{}
+		hasStream = hasStream || source.HasStream
+	}
+	for _, name := range filter.Providers {
+		if _, ok := providers[name]; !ok {
+			return false
+		}
+	}
+	for _, name := range filter.MissingProviders {
+		if _, ok := providers[name]; ok {
+			return false
+		}
+	}
+	if filter.Stream != nil && *filter.Stream != hasStream {
+		return false
+	}
+	if filter.Merged != nil && *filter.Merged != (len(providers) > 1) {
+		return false
+	}
+	return true
+}
+
+// Filtered returns a lookup whose provider only lists the activities kept by
+// filter. Detailed activities and settings are read from the unfiltered
+// provider.
+func Filtered(lookup Lookup, filter SourceFilter) Lookup {
+	if filter.IsZero() {
+		return lookup
+	}
+	return func() ActivityProvider {
+		return &sourceFilteredProvider{ActivityProvider: lookup(), filter: filter}
+	}
+}
+
+type sourceFilteredProvider struct {
+	ActivityProvider
+	filter SourceFilter
+}
+
+// Unfiltered returns the provider behind source filters and date ranges, or
+// provider itself.
+func Unfiltered(provider ActivityProvider) ActivityProvider {
+	for {
+		switch filtered := provider.(type) {
+		case *sourceFilteredProvider:
+			provider = filtered.ActivityProvider
+		case *dateRangeProvider:
+			provider = filtered.ActivityProvider
+		default:
+			return provider
+		}
+	}
+}
+
+// activitySourceIndex is implemented by the composite provider.
+type activitySourceIndex interface {
+	ActivitySourcesByID() map[int64][]compositeprovider.ActivitySourceRef
+}
+
+func (provider *sourceFilteredProvider) GetActivitiesByYearAndActivityTypes(year *int, activityTypes ...business.ActivityType) []*strava.Activity {
+	return provider.keep(provider.ActivityProvider.GetActivitiesByYearAndActivityTypes(year, activityTypes...))
+}
+
+func (provider *sourceFilteredProvider) GetActivitiesByActivityTypeGroupByYear(activityTypes ...business.ActivityType) map[string][]*strava.Activity {
+	grouped := provider.ActivityProvider.GetActivitiesByActivityTypeGroupByYear(activityTypes...)
+	result := make(map[string][]*strava.Activity, len(grouped))
+	for year, activities := range grouped {
+		if kept := provider.keep(activities); len(kept) > 0 {
+			result[year] = kept
+		}
+	}
+	return result
+}
+
+func (provider *sourceFilteredProvider) GetActivitiesByActivityTypeGroupByActiveDays(activityTypes ...business.ActivityType) map[string]int {
+	return activeDayDistances(provider.GetActivitiesByYearAndActivityTypes(nil, activityTypes...))
+}
+
+// activeDayDistances sums the kilometers of the activities by local day.
+func activeDayDistances(activities []*strava.Activity) map[string]int {
+	result := make(map[string]int)
+	for _, activity := range activities {
+		date := strings.Split(activity.StartDateLocal, "T")[0]
+		if date == "" {
+			continue
+		}
+		result[date] += int(activity.Distance / 1000)
+	}
+	return result
+}
+
+func (provider *sourceFilteredProvider) keep(activities []*strava.Activity) []*strava.Activity {
+	sourcesOf := provider.sourcesResolver()
+	kept := make([]*strava.Activity, 0, len(activities))
+	for _, activity := range activities {
+		if activity != nil && provider.filter.Matches(sourcesOf(activity)) {
+			kept = append(kept, activity)
+		}
+	}
+	return kept
+}
+
+// sourcesResolver returns the sources of an activity. Outside composite mode
+// every activity comes from the single configured source.
+func (provider *sourceFilteredProvider) sourcesResolver() func(activity *strava.Activity) []compositeprovider.ActivitySourceRef {
+	if index, ok := provider.ActivityProvider.(activitySourceIndex); ok {
+		sourcesByID := index.ActivitySourcesByID()
+		return func(activity *strava.Activity) []compositeprovider.ActivitySourceRef {
+			return sourcesByID[activity.Id]
+		}
+	}
+	name := sourceNameOf(provider.ActivityProvider)
+	return func(activity *strava.Activity) []compositeprovider.ActivitySourceRef {
+		return []compositeprovider.ActivitySourceRef{{
+			Provider:   name,
+			ActivityID: activity.Id,
+			HasStream:  activity.Stream != nil,
+		}}
+	}
+}
+
+func sourceNameOf(provider ActivityProvider) string {
+	switch provider.(type) {
+	case *stravaapi.StravaActivityProvider:
+		return "strava"
+	case *fitprovider.FITActivityProvider:
+		return "fit"
+	case *gpxprovider.GPXActivityProvider:
+		return "gpx"
+	case *tcxprovider.TCXActivityProvider:
+		return "tcx"
+	case *jsonexportprovider.JSONActivityProvider:
+		return "json"
+	}
+	return ""
+}
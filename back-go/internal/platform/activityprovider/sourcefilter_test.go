@@ -0,0 +1,182 @@
+package activityprovider
+
+import (
+	"testing"
+
+	"mystravastats/internal/shared/domain/business"
+	"mystravastats/internal/shared/domain/strava"
+	compositeprovider "mystravastats/internal/shared/infrastructure/composite"
+)
+
+func TestParseSourceFilter_ReturnsCanonicalFilter(t *testing.T) {
+	// GIVEN
+	value := " Stream, no-strava ,fit,unmerged,fit"
+
+	// WHEN
+	filter, err := ParseSourceFilter(value)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("expected filter to parse, got %v", err)
+	}
+	if filter.String() != "fit,no-strava,stream,unmerged" {
+		t.Fatalf("expected canonical filter, got %q", filter.String())
+	}
+	if empty, _ := ParseSourceFilter(""); !empty.IsZero() {
+		t.Fatalf("expected an empty value to keep every activity, got %#v", empty)
+	}
+}
+
+func TestParseSourceFilter_RejectsUnknownAndContradictoryTerms(t *testing.T) {
+	for _, value := range []string{"garmin", "no-merged", "fit,no-fit", "merged,unmerged", "stream,no-stream"} {
+		// WHEN
+		_, err := ParseSourceFilter(value)
+
+		// THEN
+		if err == nil {
+			t.Fatalf("expected %q to be rejected", value)
+		}
+	}
+}
+
+func TestFiltered_ListsActivitiesMatchingTheirSources(t *testing.T) {
+	// GIVEN
+	composite := compositeprovider.NewCompositeActivityProvider([]compositeprovider.Source{
+		{Name: "strava", Provider: &testSourceProvider{name: "strava", activities: []*strava.Activity{
+			testRide(1001, "2025-04-12T08:00:00Z", nil),
+			testRide(1002, "2026-03-01T08:00:00Z", nil),
+		}}},
+		{Name: "fit", Provider: &testSourceProvider{name: "fit", activities: []*strava.Activity{
+			testRide(2001, "2025-04-12T08:00:10Z", &strava.Stream{}),
+			testRide(2002, "2026-05-01T08:00:00Z", &strava.Stream{}),
+		}}},
+	})
+	lookup := func() ActivityProvider { return composite }
+	cases := map[string][]int64{
+		"fit,no-strava": {2002},
+		"strava,no-fit": {1002},
+		"merged":        {1001},
+		"no-stream":     {1002},
+		"stream":        {2002, 1001},
+	}
+
+	for value, expected := range cases {
+		filter, err := ParseSourceFilter(value)
+		if err != nil {
+			t.Fatalf("expected %q to parse, got %v", value, err)
+		}
+
+		// WHEN
+		activities := Filtered(lookup, filter)().GetActivitiesByYearAndActivityTypes(nil, business.Ride)
+
+		// THEN
+		if len(activities) != len(expected) {
+			t.Fatalf("expected %d activities for %q, got %d", len(expected), value, len(activities))
+		}
+		for index, activity := range activities {
+			if activity.Id != expected[index] {
+				t.Fatalf("expected activities %v for %q, got %d at %d", expected, value, activity.Id, index)
+			}
+		}
+	}
+}
+
+func TestFiltered_GroupsOnlyKeptActivities(t *testing.T) {
+	// GIVEN
+	provider := &testSourceProvider{name: "fit", activities: []*strava.Activity{
+		testRide(2001, "2025-04-12T08:00:00Z", &strava.Stream{}),
+		testRide(2002, "2026-05-01T08:00:00Z", nil),
+	}}
+	filter, _ := ParseSourceFilter("stream")
+
+	// WHEN
+	filtered := Filtered(func() ActivityProvider { return provider }, filter)()
+
+	// THEN
+	if byYear := filtered.GetActivitiesByActivityTypeGroupByYear(business.Ride); len(byYear) != 1 || len(byYear["2025"]) != 1 {
+		t.Fatalf("expected only 2025 to keep an activity, got %#v", byYear)
+	}
+	if days := filtered.GetActivitiesByActivityTypeGroupByActiveDays(business.Ride); len(days) != 1 || days["2025-04-12"] != 30 {
+		t.Fatalf("expected one active day of 30 km, got %#v", days)
+	}
+	if Unfiltered(filtered) != provider {
+		t.Fatal("expected the unfiltered provider behind the filter")
+	}
+}
+
+type testSourceProvider struct {
+	name       string
+	activities []*strava.Activity
+}
+
+func (provider *testSourceProvider) GetDetailedActivity(activityId int64) *strava.DetailedActivity {
+	return nil
+}
+
+func (provider *testSourceProvider) GetCachedDetailedActivity(activityId int64) *strava.DetailedActivity {
+	return nil
+}
+
+func (provider *testSourceProvider) GetActivitiesByYearAndActivityTypes(year *int, activityTypes ...business.ActivityType) []*strava.Activity {
+	return append([]*strava.Activity(nil), provider.activities...)
+}
+
+func (provider *testSourceProvider) GetActivitiesByActivityTypeGroupByYear(activityTypes ...business.ActivityType) map[string][]*strava.Activity {
+	grouped := make(map[string][]*strava.Activity)
+	for _, activity := range provider.activities {
+		grouped[activity.StartDateLocal[:4]] = append(grouped[activity.StartDateLocal[:4]], activity)
+	}
+	return grouped
+}
+
+func (provider *testSourceProvider) GetActivitiesByActivityTypeGroupByActiveDays(activityTypes ...business.ActivityType) map[string]int {
+	return map[string]int{}
+}
+
+func (provider *testSourceProvider) GetAthlete() strava.Athlete {
+	return strava.Athlete{}
+}
+
+func (provider *testSourceProvider) GetHeartRateZoneSettings() business.HeartRateZoneSettings {
+	return business.HeartRateZoneSettings{}
+}
+
+func (provider *testSourceProvider) SaveHeartRateZoneSettings(settings business.HeartRateZoneSettings) business.HeartRateZoneSettings {
+	return settings
+}
+
+func (provider *testSourceProvider) GetPerformanceSettings() business.AthletePerformanceSettings {
+	return business.AthletePerformanceSettings{}
+}
+
+func (provider *testSourceProvider) SavePerformanceSettings(settings business.AthletePerformanceSettings) business.AthletePerformanceSettings {
+	return settings
+}
+
+func (provider *testSourceProvider) CacheDiagnostics() map[string]any {
+	return map[string]any{"provider": provider.name, "activities": len(provider.activities)}
+}
+
+func (provider *testSourceProvider) ClientID() string {
+	return provider.name + "-athlete"
+}
+
+func (provider *testSourceProvider) CacheRootPath() string {
+	return ""
+}
+
+func testRide(id int64, start string, stream *strava.Stream) *strava.Activity {
+	return &strava.Activity{
+		Id:             id,
+		Name:           "ride",
+		Type:           "Ride",
+		SportType:      "Ride",
+		StartDate:      start,
+		StartDateLocal: start,
+		StartLatlng:    []float64{48.8566, 2.3522},
+		Distance:       30000,
+		ElapsedTime:    3600,
+		MovingTime:     3600,
+		Stream:         stream,
+	}
+}
@@ -0,0 +1,406 @@
+// Package cachemigration upgrades the files persisted under a cache root when
+// their format changes. Every file kind is registered in Schemas with its
+// ordered migrations; the version reached by each kind is recorded in
+// cache-schema.json at the cache root. Before a migration rewrites anything,
+// the files it changes are copied to cache-backups/.
+package cachemigration
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"mystravastats/internal/platform/cachewrite"
+	"mystravastats/internal/platform/runtimeconfig"
+)
+
+const (
+	stateFileName      = "cache-schema.json"
+	stateSchemaVersion = 1
+	backupsDirectory   = "cache-backups"
+	maxStateHistory    = 100
+
+	modeEnv = "CACHE_MIGRATION_MODE"
+)
+
+// Values of CACHE_MIGRATION_MODE.
+const (
+	ModeApply  = "apply"
+	ModeDryRun = "dry-run"
+	ModeOff    = "off"
+)
+
+// Migration upgrades one file to version To. Apply must accept content already
+// in the new format and return it unchanged, so that an interrupted run can be
+// replayed safely.
+type Migration struct {
+	To          int
+	Description string
+	Apply       func(data []byte) ([]byte, error)
+}
+
+// Schema is one kind of persisted file. Patterns are glob patterns relative to
+// the cache root; Migrations are ordered and their To versions start at 2.
+type Schema struct {
+	Name       string
+	Patterns   []string
+	Migrations []Migration
+}
+
+// Version is the version files of this kind are written in.
+func (schema Schema) Version() int {
+	return 1 + len(schema.Migrations)
+}
+
+// Report describes the migrations of one cache root. Changes lists the files
+// whose content is rewritten; a schema can be upgraded without changing any
+// file.
+type Report struct {
+	Root     string          `json:"root"`
+	DryRun   bool            `json:"dryRun"`
+	Upgrades []SchemaUpgrade `json:"upgrades"`
+	Changes  []FileChange    `json:"changes"`
+	Backup   string          `json:"backup,omitempty"`
+	Errors   []string        `json:"errors,omitempty"`
+}
+
+type SchemaUpgrade struct {
+	Schema     string   `json:"schema"`
+	From       int      `json:"from"`
+	To         int      `json:"to"`
+	Files      int      `json:"files"`
+	Migrations []string `json:"migrations"`
+}
+
+type FileChange struct {
+	Schema string `json:"schema"`
+	File   string `json:"file"`
+	From   int    `json:"from"`
+	To     int    `json:"to"`
+}
+
+// schemaState is the content of cache-schema.json. A schema missing from
+// Versions is at version 1.
+type schemaState struct {
+	SchemaVersion int                `json:"schemaVersion"`
+	UpdatedAt     string             `json:"updatedAt"`
+	Versions      map[string]int     `json:"versions"`
+	History       []appliedMigration `json:"history,omitempty"`
+}
+
+type appliedMigration struct {
+	AppliedAt string `json:"appliedAt"`
+	Schema    string `json:"schema"`
+	From      int    `json:"from"`
+	To        int    `json:"to"`
+	Files     int    `json:"files"`
+	Backup    string `json:"backup,omitempty"`
+}
+
+// Mode returns CACHE_MIGRATION_MODE. An unknown value falls back to a dry run
+// so that a typo never rewrites the cache.
+func Mode() string {
+	mode := strings.ToLower(strings.TrimSpace(runtimeconfig.StringValue(modeEnv, ModeApply)))
+	switch mode {
+	case ModeApply, ModeDryRun, ModeOff:
+		return mode
+	}
+	log.Printf("Unknown %s %q, running cache migrations as a dry run", modeEnv, mode)
+	return ModeDryRun
+}
+
+// Startup runs the registered migrations on every root according to
+// CACHE_MIGRATION_MODE. It must run before any provider reads its cache.
+func Startup(roots []string) []Report {
+	mode := Mode()
+	if mode == ModeOff {
+		log.Printf("Cache migrations disabled (%s=%s)", modeEnv, ModeOff)
+		return nil
+	}
+	reports := make([]Report, 0, len(roots))
+	for _, root := range roots {
+		report := Run(root, Schemas, mode == ModeDryRun)
+		logReport(report)
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+// Run plans the migrations of root and, unless dryRun is set, backs up the
+// files they change, rewrites them and records the new versions. Nothing is
+// written when planning fails.
+func Run(root string, schemas []Schema, dryRun bool) Report {
+	report := Report{Root: root, DryRun: dryRun, Upgrades: []SchemaUpgrade{}, Changes: []FileChange{}}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return report
+	}
+	if err := validateSchemas(schemas); err != nil {
+		report.Errors = append(report.Errors, err.Error())
+		return report
+	}
+	state, err := loadState(root)
+	if err != nil {
+		report.Errors = append(report.Errors, err.Error())
+		return report
+	}
+
+	pending := make(map[string][]string)
+	for _, schema := range schemas {
+		from := state.version(schema.Name)
+		if from > schema.Version() {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s is at version %d, newer than the supported version %d", schema.Name, from, schema.Version()))
+			continue
+		}
+		if from == schema.Version() {
+			continue
+		}
+		files, err := matchingFiles(root, schema.Patterns)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", schema.Name, err))
+			continue
+		}
+		upgrade := SchemaUpgrade{Schema: schema.Name, From: from, To: schema.Version(), Files: len(files), Migrations: []string{}}
+		for _, migration := range schema.Migrations[from-1:] {
+			upgrade.Migrations = append(upgrade.Migrations, migration.Description)
+		}
+		for _, file := range files {
+			_, changed, err := migrateFile(file, schema, from)
+			if err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", relativePath(root, file), err))
+				continue
+			}
+			if changed {
+				report.Changes = append(report.Changes, FileChange{Schema: schema.Name, File: relativePath(root, file), From: from, To: schema.Version()})
+				pending[schema.Name] = append(pending[schema.Name], file)
+			}
+		}
+		report.Upgrades = append(report.Upgrades, upgrade)
+	}
+	if dryRun || len(report.Errors) > 0 || len(report.Upgrades) == 0 {
+		return report
+	}
+
+	now := time.Now().UTC()
+	if len(report.Changes) > 0 {
+		backup, err := backupFiles(root, report.Changes, now)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("backup failed, cache left unchanged: %v", err))
+			return report
+		}
+		report.Backup = backup
+	}
+
+	schemasByName := make(map[string]Schema, len(schemas))
+	for _, schema := range schemas {
+		schemasByName[schema.Name] = schema
+	}
+	for _, upgrade := range report.Upgrades {
+		schema := schemasByName[upgrade.Schema]
+		for _, file := range pending[upgrade.Schema] {
+			if err := rewriteFile(file, schema, upgrade.From); err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", relativePath(root, file), err))
+				return report
+			}
+		}
+		state.Versions[upgrade.Schema] = upgrade.To
+		state.History = append(state.History, appliedMigration{
+			AppliedAt: now.Format(time.RFC3339),
+			Schema:    upgrade.Schema,
+			From:      upgrade.From,
+			To:        upgrade.To,
+			Files:     len(pending[upgrade.Schema]),
+			Backup:    report.Backup,
+		})
+	}
+	if overflow := len(state.History) - maxStateHistory; overflow > 0 {
+		state.History = state.History[overflow:]
+	}
+	state.UpdatedAt = now.Format(time.RFC3339)
+	if err := saveState(root, state); err != nil {
+		report.Errors = append(report.Errors, err.Error())
+	}
+	return report
+}
+
+func validateSchemas(schemas []Schema) error {
+	names := make(map[string]struct{}, len(schemas))
+	for _, schema := range schemas {
+		if schema.Name == "" || len(schema.Patterns) == 0 {
+			return fmt.Errorf("cache schema %q needs a name and a pattern", schema.Name)
+		}
+		if _, ok := names[schema.Name]; ok {
+			return fmt.Errorf("cache schema %q is registered twice", schema.Name)
+		}
+		names[schema.Name] = struct{}{}
+		for index, migration := range schema.Migrations {
+			if migration.To != index+2 || migration.Apply == nil {
+				return fmt.Errorf("cache schema %q: migration %d must upgrade to version %d", schema.Name, index+1, index+2)
+			}
+		}
+	}
+	return nil
+}
+
+func (state schemaState) version(name string) int {
+	if version, ok := state.Versions[name]; ok && version > 0 {
+		return version
+	}
+	return 1
+}
+
+func loadState(root string) (schemaState, error) {
+	state := schemaState{SchemaVersion: stateSchemaVersion, Versions: make(map[string]int)}
+	data, err := os.ReadFile(filepath.Join(root, stateFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("unable to read %s: %w", stateFileName, err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("unable to parse %s: %w", stateFileName, err)
+	}
+	if state.Versions == nil {
+		state.Versions = make(map[string]int)
+	}
+	return state, nil
+}
+
+func saveState(root string, state schemaState) error {
+	state.SchemaVersion = stateSchemaVersion
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode %s: %w", stateFileName, err)
+	}
+	if err := cachewrite.WriteFile(filepath.Join(root, stateFileName), data, 0o644); err != nil {
+		return fmt.Errorf("unable to write %s: %w", stateFileName, err)
+	}
+	return nil
+}
+
+func matchingFiles(root string, patterns []string) ([]string, error) {
+	files := make([]string, 0)
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(root, pattern))
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && info.Mode().IsRegular() {
+				files = append(files, match)
+			}
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// migrateFile applies the migrations of schema after version from to the
+// content of file.
+func migrateFile(file string, schema Schema, from int) ([]byte, bool, error) {
+	original, err := os.ReadFile(file)
+	if err != nil {
+		return nil, false, err
+	}
+	data := original
+	for _, migration := range schema.Migrations[from-1:] {
+		data, err = migration.Apply(data)
+		if err != nil {
+			return nil, false, fmt.Errorf("migration to version %d: %w", migration.To, err)
+		}
+	}
+	return data, !bytes.Equal(original, data), nil
+}
+
+func rewriteFile(file string, schema Schema, from int) error {
+	data, changed, err := migrateFile(file, schema, from)
+	if err != nil || !changed {
+		return err
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	return cachewrite.WriteFile(file, data, info.Mode().Perm())
+}
+
+// backupFiles copies the files about to change, and the current schema state,
+// to a new directory of cache-backups.
+func backupFiles(root string, changes []FileChange, now time.Time) (string, error) {
+	backup := filepath.Join(root, backupsDirectory, "migration-"+now.Format("20060102T150405Z"))
+	if _, err := os.Stat(backup); err == nil {
+		backup = fmt.Sprintf("%s-%d", backup, now.UnixNano())
+	}
+	files := make([]string, 0, len(changes)+1)
+	for _, change := range changes {
+		files = append(files, change.File)
+	}
+	if _, err := os.Stat(filepath.Join(root, stateFileName)); err == nil {
+		files = append(files, stateFileName)
+	}
+	for _, file := range files {
+		if err := copyFile(filepath.Join(root, file), filepath.Join(backup, file)); err != nil {
+			return "", err
+		}
+	}
+	return backup, nil
+}
+
+func copyFile(source string, destination string) error {
+	info, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(destination), 0o700); err != nil {
+		return err
+	}
+	input, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+	output, err := os.OpenFile(destination, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	_, copyErr := io.Copy(output, input)
+	closeErr := output.Close()
+	return errors.Join(copyErr, closeErr)
+}
+
+func relativePath(root string, file string) string {
+	if relative, err := filepath.Rel(root, file); err == nil {
+		return filepath.ToSlash(relative)
+	}
+	return file
+}
+
+func logReport(report Report) {
+	for _, err := range report.Errors {
+		log.Printf("Cache migration of %s: %s", report.Root, err)
+	}
+	for _, upgrade := range report.Upgrades {
+		changed := 0
+		for _, change := range report.Changes {
+			if change.Schema == upgrade.Schema {
+				changed++
+			}
+		}
+		if report.DryRun {
+			log.Printf("Cache migration dry run of %s: %s v%d -> v%d would change %d of %d file(s)", report.Root, upgrade.Schema, upgrade.From, upgrade.To, changed, upgrade.Files)
+		} else if len(report.Errors) == 0 {
+			log.Printf("Migrated %s in %s from v%d to v%d (%d of %d file(s) changed)", upgrade.Schema, report.Root, upgrade.From, upgrade.To, changed, upgrade.Files)
+		}
+	}
+	if report.Backup != "" {
+		log.Printf("Cache files changed by the migration of %s were backed up to %s", report.Root, report.Backup)
+	}
+}
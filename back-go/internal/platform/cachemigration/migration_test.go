@@ -0,0 +1,238 @@
+package cachemigration
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mystravastats/internal/platform/cachewrite"
+)
+
+func TestRun_DryRunReportsChangesWithoutWriting(t *testing.T) {
+	// GIVEN
+	root := t.TempDir()
+	file := writeCacheFile(t, root, "strava-42/annual-goals-42.json", `{"ride":1000}`)
+
+	// WHEN
+	report := Run(root, []Schema{renameRideSchema()}, true)
+
+	// THEN
+	if len(report.Errors) != 0 {
+		t.Fatalf("expected no error, got %v", report.Errors)
+	}
+	if len(report.Upgrades) != 1 || report.Upgrades[0].From != 1 || report.Upgrades[0].To != 2 || report.Upgrades[0].Files != 1 {
+		t.Fatalf("expected one upgrade from v1 to v2, got %#v", report.Upgrades)
+	}
+	if len(report.Changes) != 1 || report.Changes[0].File != "strava-42/annual-goals-42.json" {
+		t.Fatalf("expected the goals file to be reported, got %#v", report.Changes)
+	}
+	if data, _ := os.ReadFile(file); string(data) != `{"ride":1000}` {
+		t.Fatalf("expected dry run to leave the file unchanged, got %s", data)
+	}
+	if _, err := os.Stat(filepath.Join(root, stateFileName)); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected dry run not to write %s, got %v", stateFileName, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, backupsDirectory)); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected dry run not to take a backup, got %v", err)
+	}
+}
+
+func TestRun_BacksUpMigratesAndRecordsVersions(t *testing.T) {
+	// GIVEN
+	root := t.TempDir()
+	file := writeCacheFile(t, root, "strava-42/annual-goals-42.json", `{"ride":1000}`)
+	untouched := writeCacheFile(t, root, "strava-42/annual-goals-7.json", `{"cycling":500}`)
+	schemas := []Schema{renameRideSchema()}
+
+	// WHEN
+	report := Run(root, schemas, false)
+
+	// THEN
+	if len(report.Errors) != 0 {
+		t.Fatalf("expected no error, got %v", report.Errors)
+	}
+	if data, _ := os.ReadFile(file); string(data) != `{"cycling":1000}` {
+		t.Fatalf("expected migrated content, got %s", data)
+	}
+	if data, _ := os.ReadFile(untouched); string(data) != `{"cycling":500}` {
+		t.Fatalf("expected file already in the new format to stay unchanged, got %s", data)
+	}
+	backup, err := os.ReadFile(filepath.Join(report.Backup, "strava-42", "annual-goals-42.json"))
+	if err != nil || string(backup) != `{"ride":1000}` {
+		t.Fatalf("expected the original content in the backup, got %q (%v)", backup, err)
+	}
+	if _, err := os.Stat(filepath.Join(report.Backup, "strava-42", "annual-goals-7.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected unchanged files to stay out of the backup, got %v", err)
+	}
+	state, err := loadState(root)
+	if err != nil || state.version("annual-goals") != 2 || len(state.History) != 1 {
+		t.Fatalf("expected annual-goals recorded at v2 with one history entry, got %#v (%v)", state, err)
+	}
+
+	// WHEN
+	again := Run(root, schemas, false)
+
+	// THEN
+	if len(again.Upgrades) != 0 || again.Backup != "" {
+		t.Fatalf("expected nothing left to migrate, got %#v", again)
+	}
+}
+
+func TestRun_WaitsWhileTheCacheIsPaused(t *testing.T) {
+	// GIVEN
+	root := t.TempDir()
+	file := writeCacheFile(t, root, "strava-42/annual-goals-42.json", `{"ride":1000}`)
+	resume := cachewrite.Pause()
+	done := make(chan Report)
+
+	// WHEN
+	go func() { done <- Run(root, []Schema{renameRideSchema()}, false) }()
+
+	// THEN
+	select {
+	case <-done:
+		resume()
+		t.Fatal("expected the migration to wait for the paused cache")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if data, _ := os.ReadFile(file); string(data) != `{"ride":1000}` {
+		t.Fatalf("expected the file unchanged while paused, got %s", data)
+	}
+	resume()
+	if report := <-done; len(report.Errors) != 0 {
+		t.Fatalf("expected no error after resume, got %v", report.Errors)
+	}
+	if data, _ := os.ReadFile(file); string(data) != `{"cycling":1000}` {
+		t.Fatalf("expected migrated content after resume, got %s", data)
+	}
+}
+
+func TestRun_LeavesCacheUntouchedWhenAMigrationFails(t *testing.T) {
+	// GIVEN
+	root := t.TempDir()
+	good := writeCacheFile(t, root, "strava-42/annual-goals-42.json", `{"ride":1000}`)
+	writeCacheFile(t, root, "strava-42/annual-goals-43.json", `not json`)
+
+	// WHEN
+	report := Run(root, []Schema{renameRideSchema()}, false)
+
+	// THEN
+	if len(report.Errors) != 1 {
+		t.Fatalf("expected the malformed file to be reported, got %v", report.Errors)
+	}
+	if data, _ := os.ReadFile(good); string(data) != `{"ride":1000}` {
+		t.Fatalf("expected no file to be rewritten, got %s", data)
+	}
+	if _, err := os.Stat(filepath.Join(root, stateFileName)); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected versions not to be recorded, got %v", err)
+	}
+}
+
+func TestRun_RefusesCacheWrittenByANewerVersion(t *testing.T) {
+	// GIVEN
+	root := t.TempDir()
+	writeCacheFile(t, root, stateFileName, `{"schemaVersion":1,"versions":{"annual-goals":3}}`)
+
+	// WHEN
+	report := Run(root, []Schema{renameRideSchema()}, false)
+
+	// THEN
+	if len(report.Errors) != 1 || len(report.Upgrades) != 0 {
+		t.Fatalf("expected a newer schema to be refused, got %#v", report)
+	}
+}
+
+func TestSchemas_AreValidAndMatchEachPersistedFileOnce(t *testing.T) {
+	// GIVEN
+	root := t.TempDir()
+	files := []string{
+		"strava-42/cache-manifest.json",
+		"strava-42/best-effort-cache.json",
+		"strava-42/warmup-summaries.json",
+		"strava-42/athlete-42.json",
+		"strava-42/strava-42-2024/activities-42-2024.json",
+		"strava-42/strava-42-2024/stravaActivity-123",
+		"strava-42/strava-42-2024/stream-123",
+		"strava-42/heart-rate-zones-42.json",
+		"strava-42/performance-settings-42.json",
+		"strava-42/annual-goals-42.json",
+		"strava-42/custom-statistics-42.json",
+		"strava-42/strava-uploads-42.json",
+		"strava-42/data-quality-exclusions-42.json",
+		"strava-42/data-quality-corrections-42.json",
+		"strava-42/gear-maintenance-42.json",
+		"strava-42/composite-merge-overrides.json",
+		"strava-42/segment-analysis-cache-v1.json",
+		"strava-42/local-index-fit/index.json",
+		"strava-42/local-index-fit/streams/abc.json",
+		"source-sync-history.json",
+	}
+	for _, file := range files {
+		writeCacheFile(t, root, file, `{}`)
+	}
+
+	// WHEN
+	err := validateSchemas(Schemas)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("expected registered schemas to be valid, got %v", err)
+	}
+	matches := make(map[string]int)
+	for _, schema := range Schemas {
+		schemaFiles, err := matchingFiles(root, schema.Patterns)
+		if err != nil {
+			t.Fatalf("expected %s patterns to be valid, got %v", schema.Name, err)
+		}
+		for _, file := range schemaFiles {
+			matches[relativePath(root, file)]++
+		}
+	}
+	for _, file := range files {
+		if matches[file] != 1 {
+			t.Fatalf("expected %s to match exactly one schema, got %d", file, matches[file])
+		}
+	}
+}
+
+// renameRideSchema renames the "ride" goal to "cycling".
+func renameRideSchema() Schema {
+	return Schema{
+		Name:     "annual-goals",
+		Patterns: []string{"strava-*/annual-goals-*.json"},
+		Migrations: []Migration{{
+			To:          2,
+			Description: "rename ride goal to cycling",
+			Apply: func(data []byte) ([]byte, error) {
+				var goals map[string]int
+				if err := json.Unmarshal(data, &goals); err != nil {
+					return nil, err
+				}
+				value, ok := goals["ride"]
+				if !ok {
+					return data, nil
+				}
+				delete(goals, "ride")
+				goals["cycling"] = value
+				migrated, err := json.Marshal(goals)
+				return bytes.TrimSpace(migrated), err
+			},
+		}},
+	}
+}
+
+func writeCacheFile(t *testing.T, root string, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("unable to create %s: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("unable to write %s: %v", path, err)
+	}
+	return path
+}
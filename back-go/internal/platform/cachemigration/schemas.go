@@ -0,0 +1,31 @@
+package cachemigration
+
+// Schemas registers every file the backend persists under a cache root. All
+// formats start at version 1, the layout written before versioning existed.
+//
+// To change a format, append a Migration whose To is the next version and keep
+// the reader able to load both the old and the new layout until the migration
+// has run everywhere. Credentials (.strava, .strava-token.json) are left out:
+// they may be encrypted and are handled by the secret store.
+var Schemas = []Schema{
+	{Name: "cache-manifest", Patterns: []string{"strava-*/cache-manifest.json"}},
+	{Name: "best-effort-cache", Patterns: []string{"strava-*/best-effort-cache.json"}},
+	{Name: "warmup-summaries", Patterns: []string{"strava-*/warmup-summaries.json"}},
+	{Name: "athlete", Patterns: []string{"strava-*/athlete-*.json"}},
+	{Name: "yearly-activities", Patterns: []string{"strava-*/strava-*-*/activities-*-*.json"}},
+	{Name: "detailed-activity", Patterns: []string{"strava-*/strava-*-*/stravaActivity-*"}},
+	{Name: "activity-stream", Patterns: []string{"strava-*/strava-*-*/stream-*"}},
+	{Name: "heart-rate-zones", Patterns: []string{"strava-*/heart-rate-zones-*.json"}},
+	{Name: "performance-settings", Patterns: []string{"strava-*/performance-settings-*.json"}},
+	{Name: "annual-goals", Patterns: []string{"strava-*/annual-goals-*.json"}},
+	{Name: "custom-statistics", Patterns: []string{"strava-*/custom-statistics-*.json"}},
+	{Name: "strava-uploads", Patterns: []string{"strava-*/strava-uploads-*.json"}},
+	{Name: "data-quality-exclusions", Patterns: []string{"strava-*/data-quality-exclusions-*.json"}},
+	{Name: "data-quality-corrections", Patterns: []string{"strava-*/data-quality-corrections-*.json"}},
+	{Name: "gear-maintenance", Patterns: []string{"strava-*/gear-maintenance-*.json"}},
+	{Name: "composite-merge-overrides", Patterns: []string{"strava-*/composite-merge-overrides.json"}},
+	{Name: "segment-analysis-cache", Patterns: []string{"strava-*/segment-analysis-cache-v1.json"}},
+	{Name: "local-index", Patterns: []string{"strava-*/local-index-*/index.json"}},
+	{Name: "local-index-stream", Patterns: []string{"strava-*/local-index-*/streams/*.json"}},
+	{Name: "source-sync-history", Patterns: []string{"source-sync-history.json"}},
+}
@@ -0,0 +1,38 @@
+// Package cachewrite serializes the writes of cache files with the operations
+// that need a stable view of the whole cache, such as backups and restores.
+package cachewrite
+
+import (
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// writers is held shared by every write and exclusively while the cache is
+// paused.
+var writers sync.RWMutex
+
+// WriteFile replaces path with data through a temporary file renamed over it,
+// so that readers never see a partially written file. The parent directory
+// must exist. It blocks while the cache is paused.
+func WriteFile(path string, data []byte, perm fs.FileMode) error {
+	writers.RLock()
+	defer writers.RUnlock()
+
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, data, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		_ = os.Remove(tempPath)
+		return err
+	}
+	return nil
+}
+
+// Pause waits for the writes in progress, then blocks new ones until resume is
+// called.
+func Pause() (resume func()) {
+	writers.Lock()
+	return writers.Unlock
+}
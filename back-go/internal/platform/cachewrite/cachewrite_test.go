@@ -0,0 +1,51 @@
+package cachewrite
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteFile_ReplacesContentWithoutLeavingTempFile(t *testing.T) {
+	// GIVEN
+	path := filepath.Join(t.TempDir(), "settings.json")
+	if err := os.WriteFile(path, []byte("old"), 0o600); err != nil {
+		t.Fatalf("unable to write %s: %v", path, err)
+	}
+
+	// WHEN
+	err := WriteFile(path, []byte("new"), 0o600)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Fatalf("expected new content, got %s", data)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("expected temp file to be renamed, got %v", err)
+	}
+}
+
+func TestPause_BlocksWritesUntilResumed(t *testing.T) {
+	// GIVEN
+	path := filepath.Join(t.TempDir(), "settings.json")
+	resume := Pause()
+	written := make(chan error, 1)
+
+	// WHEN
+	go func() { written <- WriteFile(path, []byte("value"), 0o600) }()
+
+	// THEN
+	select {
+	case err := <-written:
+		t.Fatalf("expected write to wait for resume, got %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	resume()
+	if err := <-written; err != nil {
+		t.Fatalf("expected write to succeed after resume, got %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+// Package events is the in-process bus behind GET /api/events. Long running
+// operations publish progress events on it and the API streams them to the
+// browser as server-sent events.
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Event types.
+const (
+	TypeStarted   = "started"
+	TypeProgress  = "progress"
+	TypeCompleted = "completed"
+	TypeFailed    = "failed"
+)
+
+// Operations reporting progress.
+const (
+	OperationStravaRefresh    = "strava_refresh"
+	OperationStreamBackfill   = "stream_backfill"
+	OperationDetailedBackfill = "detailed_backfill"
+	OperationWarmup           = "warmup"
+	OperationLocalScan        = "local_scan"
+	OperationSourceSync       = "source_sync"
+	OperationFITImport        = "fit_import"
+	OperationSegmentCache     = "segment_cache"
+)
+
+const (
+	replayBufferSize     = 256
+	subscriberBufferSize = 64
+)
+
+// Event is one progress notification. RunID is the ID of the started event
+// of the operation, so that clients can group the events of one run.
+type Event struct {
+	ID        int64    `json:"id"`
+	RunID     int64    `json:"runId"`
+	Type      string   `json:"type"`
+	Operation string   `json:"operation"`
+	ClientID  string   `json:"clientId,omitempty"`
+	Phase     string   `json:"phase,omitempty"`
+	Year      int      `json:"year,omitempty"`
+	Current   int      `json:"current"`
+	Total     int      `json:"total"`
+	Message   string   `json:"message,omitempty"`
+	Errors    []string `json:"errors,omitempty"`
+	Time      string   `json:"time"`
+}
+
+// Bus fans events out to its subscribers and keeps the latest ones so that a
+// reconnecting client can catch up from its Last-Event-ID.
+type Bus struct {
+	mutex       sync.Mutex
+	nextID      int64
+	recent      []Event
+	subscribers map[chan Event]struct{}
+	now         func() time.Time
+}
+
+func NewBus() *Bus {
+	return &Bus{
+		subscribers: make(map[chan Event]struct{}),
+		now:         time.Now,
+	}
+}
+
+var defaultBus = NewBus()
+
+// Default returns the bus shared by the whole process.
+func Default() *Bus {
+	return defaultBus
+}
+
+// Publish assigns the event its ID and timestamp and delivers it. A subscriber
+// whose buffer is full misses the event rather than blocking the publisher.
+func (bus *Bus) Publish(event Event) Event {
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+
+	bus.nextID++
+	event.ID = bus.nextID
+	if event.RunID == 0 {
+		event.RunID = event.ID
+	}
+	event.Time = bus.now().UTC().Format(time.RFC3339Nano)
+
+	bus.recent = append(bus.recent, event)
+	if overflow := len(bus.recent) - replayBufferSize; overflow > 0 {
+		bus.recent = append([]Event(nil), bus.recent[overflow:]...)
+	}
+	for subscriber := range bus.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+		}
+	}
+	return event
+}
+
+// Subscribe returns the retained events published after afterID, then a
+// channel receiving the following ones. cancel must be called once the
+// subscriber is done.
+func (bus *Bus) Subscribe(afterID int64) (replay []Event, events <-chan Event, cancel func()) {
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+
+	for _, event := range bus.recent {
+		if event.ID > afterID {
+			replay = append(replay, event)
+		}
+	}
+	subscriber := make(chan Event, subscriberBufferSize)
+	bus.subscribers[subscriber] = struct{}{}
+	var once sync.Once
+	cancel = func() {
+		once.Do(func() {
+			bus.mutex.Lock()
+			delete(bus.subscribers, subscriber)
+			bus.mutex.Unlock()
+		})
+	}
+	return replay, subscriber, cancel
+}
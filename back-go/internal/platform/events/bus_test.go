@@ -0,0 +1,107 @@
+package events
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBus_ReplaysEventsAfterLastEventID(t *testing.T) {
+	// GIVEN
+	bus := NewBus()
+	first := bus.Publish(Event{Type: TypeStarted, Operation: OperationWarmup})
+	second := bus.Publish(Event{Type: TypeProgress, Operation: OperationWarmup, RunID: first.RunID})
+
+	// WHEN
+	replay, published, cancel := bus.Subscribe(first.ID)
+	defer cancel()
+	third := bus.Publish(Event{Type: TypeCompleted, Operation: OperationWarmup, RunID: first.RunID})
+
+	// THEN
+	if len(replay) != 1 || replay[0].ID != second.ID {
+		t.Fatalf("expected only event %d to be replayed, got %#v", second.ID, replay)
+	}
+	if second.RunID != first.ID {
+		t.Fatalf("expected progress to belong to run %d, got %d", first.ID, second.RunID)
+	}
+	select {
+	case event := <-published:
+		if event.ID != third.ID || event.Type != TypeCompleted {
+			t.Fatalf("expected completed event %d, got %#v", third.ID, event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected published event to be delivered")
+	}
+}
+
+func TestBus_DropsEventsForFullSubscriberInsteadOfBlocking(t *testing.T) {
+	// GIVEN
+	bus := NewBus()
+	_, published, cancel := bus.Subscribe(0)
+
+	// WHEN
+	for index := 0; index < subscriberBufferSize+10; index++ {
+		bus.Publish(Event{Type: TypeProgress, Operation: OperationLocalScan, Current: index})
+	}
+	cancel()
+	bus.Publish(Event{Type: TypeCompleted, Operation: OperationLocalScan})
+
+	// THEN
+	if len(published) != subscriberBufferSize {
+		t.Fatalf("expected %d buffered events, got %d", subscriberBufferSize, len(published))
+	}
+}
+
+func TestProgress_ThrottlesUpdatesAndReportsErrorsOnCompletion(t *testing.T) {
+	// GIVEN
+	bus := NewBus()
+	now := time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC)
+	bus.now = func() time.Time { return now }
+	_, published, cancel := bus.Subscribe(0)
+	defer cancel()
+
+	// WHEN
+	progress := bus.Start(OperationFITImport, "42", 3, "")
+	progress.Update("inbox", 2026, 0, 0)
+	progress.Update("inbox", 2026, 1, 0)
+	progress.Error("broken.fit: invalid header")
+	progress.Update("inbox", 2026, 3, 0)
+	progress.Complete("done", "late error")
+	progress.Fail("ignored")
+
+	// THEN
+	drain := func() []Event {
+		received := make([]Event, 0, len(published))
+		for len(published) > 0 {
+			received = append(received, <-published)
+		}
+		return received
+	}
+	received := drain()
+	types := make([]string, 0, len(received))
+	for _, event := range received {
+		types = append(types, event.Type)
+	}
+	expectedTypes := []string{TypeStarted, TypeProgress, TypeProgress, TypeProgress, TypeCompleted}
+	if !reflect.DeepEqual(types, expectedTypes) {
+		t.Fatalf("expected events %v, got %v", expectedTypes, types)
+	}
+	if received[2].Errors[0] != "broken.fit: invalid header" || received[3].Current != 3 {
+		t.Fatalf("expected error event then final progress, got %#v and %#v", received[2], received[3])
+	}
+	completed := received[4]
+	if completed.RunID != received[0].ID || completed.ClientID != "42" || completed.Message != "done" {
+		t.Fatalf("expected completion of run %d, got %#v", received[0].ID, completed)
+	}
+	if !reflect.DeepEqual(completed.Errors, []string{"broken.fit: invalid header", "late error"}) {
+		t.Fatalf("expected run errors on completion, got %v", completed.Errors)
+	}
+}
+
+func TestProgress_NilIgnoresCalls(t *testing.T) {
+	var progress *Progress
+
+	progress.Update("phase", 0, 1, 2)
+	progress.Error("ignored")
+	progress.Complete("ignored")
+}
@@ -0,0 +1,139 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	progressThrottle = 250 * time.Millisecond
+	maxRunErrors     = 20
+)
+
+// Progress publishes the events of one run of an operation. Progress updates
+// are throttled so that tight loops do not flood subscribers; the first and
+// last update, errors and the final event are always published. A nil
+// *Progress ignores every call.
+type Progress struct {
+	bus       *Bus
+	operation string
+	clientID  string
+	runID     int64
+
+	mutex          sync.Mutex
+	phase          string
+	year           int
+	current        int
+	total          int
+	errors         []string
+	lastProgressAt time.Time
+	finished       bool
+}
+
+// Start publishes the started event of an operation on the default bus.
+func Start(operation string, clientID string, total int, message string) *Progress {
+	return defaultBus.Start(operation, clientID, total, message)
+}
+
+func (bus *Bus) Start(operation string, clientID string, total int, message string) *Progress {
+	started := bus.Publish(Event{
+		Type:      TypeStarted,
+		Operation: operation,
+		ClientID:  clientID,
+		Total:     total,
+		Message:   message,
+	})
+	return &Progress{
+		bus:       bus,
+		operation: operation,
+		clientID:  clientID,
+		runID:     started.RunID,
+		total:     total,
+	}
+}
+
+// Update records the phase, year and counters of the run. A total of zero
+// keeps the previous one.
+func (progress *Progress) Update(phase string, year int, current int, total int) {
+	if progress == nil {
+		return
+	}
+	progress.mutex.Lock()
+	defer progress.mutex.Unlock()
+	if progress.finished {
+		return
+	}
+	progress.phase, progress.year, progress.current = phase, year, current
+	if total > 0 {
+		progress.total = total
+	}
+	now := progress.bus.now()
+	last := progress.total > 0 && progress.current >= progress.total
+	if !last && !progress.lastProgressAt.IsZero() && now.Sub(progress.lastProgressAt) < progressThrottle {
+		return
+	}
+	progress.lastProgressAt = now
+	progress.publishLocked(TypeProgress, "", nil)
+}
+
+// Error publishes a progress event carrying err. It is repeated in the final
+// event, up to the first 20 errors of the run.
+func (progress *Progress) Error(err string) {
+	if progress == nil {
+		return
+	}
+	progress.mutex.Lock()
+	defer progress.mutex.Unlock()
+	if progress.finished {
+		return
+	}
+	if len(progress.errors) < maxRunErrors {
+		progress.errors = append(progress.errors, err)
+	}
+	progress.publishLocked(TypeProgress, "", []string{err})
+}
+
+// Complete publishes the completed event. errs are added to the errors of the
+// run without being published one by one. Only the first call to Complete or
+// Fail has an effect.
+func (progress *Progress) Complete(message string, errs ...string) {
+	progress.finish(TypeCompleted, message, errs)
+}
+
+func (progress *Progress) Fail(message string, errs ...string) {
+	progress.finish(TypeFailed, message, errs)
+}
+
+func (progress *Progress) finish(eventType string, message string, errs []string) {
+	if progress == nil {
+		return
+	}
+	progress.mutex.Lock()
+	defer progress.mutex.Unlock()
+	if progress.finished {
+		return
+	}
+	progress.finished = true
+	for _, err := range errs {
+		if len(progress.errors) >= maxRunErrors {
+			break
+		}
+		progress.errors = append(progress.errors, err)
+	}
+	progress.publishLocked(eventType, message, progress.errors)
+}
+
+func (progress *Progress) publishLocked(eventType string, message string, errors []string) {
+	progress.bus.Publish(Event{
+		RunID:     progress.runID,
+		Type:      eventType,
+		Operation: progress.operation,
+		ClientID:  progress.clientID,
+		Phase:     progress.phase,
+		Year:      progress.year,
+		Current:   progress.current,
+		Total:     progress.total,
+		Message:   message,
+		Errors:    append([]string(nil), errors...),
+	})
+}
@@ -22,14 +22,16 @@ const (
 
 var defaultCORSAllowedOrigins = []string{"http://localhost", "http://localhost:5173"}
 var defaultCORSAllowedMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-var defaultCORSAllowedHeaders = []string{"Content-Type", "Authorization", "X-Request-Id"}
+var defaultCORSAllowedHeaders = []string{"Content-Type", "Authorization", "X-Request-Id", "X-Athlete-Id"}
 
 func Details() map[string]any {
 	fitFilesPath, fitConfigured := optionalEnv("FIT_FILES_PATH")
 	fitInboxPath, fitInboxConfigured, fitInboxSource := FITInboxPath()
 	gpxFilesPath, gpxConfigured := optionalEnv("GPX_FILES_PATH")
+	tcxFilesPath, tcxConfigured := optionalEnv("TCX_FILES_PATH")
+	jsonFilesPath, jsonConfigured := optionalEnv("JSON_FILES_PATH")
 	stravaConfigured := isConfigured("STRAVA_CACHE_PATH")
-	dataProvider, activeProviders := dataProviderDetails(stravaConfigured, fitConfigured, gpxConfigured)
+	dataProvider, activeProviders := dataProviderDetails(stravaConfigured, fitConfigured, gpxConfigured, tcxConfigured, jsonConfigured)
 
 	corsOrigins, corsSource := corsAllowedOriginsWithSource()
 
@@ -41,6 +43,13 @@ func Details() map[string]any {
 			"stravaCacheConfigured":     isConfigured("STRAVA_CACHE_PATH"),
 			"stravaApiBaseUrl":          StravaAPIBaseURL(),
 			"stravaApiBaseConfigured":   isConfigured("STRAVA_API_BASE_URL"),
+			"stravaWebhookConfigured":   isConfigured("STRAVA_WEBHOOK_VERIFY_TOKEN"),
+			"stravaSecretKeyConfigured": isConfigured("STRAVA_SECRET_KEY"),
+			"stravaSecretKeyFile":       readStringEnv("STRAVA_SECRET_KEY_FILE", ""),
+			"stravaShortBudget":         readIntEnv("STRAVA_RATE_LIMIT_SHORT_BUDGET", 100),
+			"stravaDailyBudget":         readIntEnv("STRAVA_RATE_LIMIT_DAILY_BUDGET", 1000),
+			"stravaInteractiveReserve":  readIntEnv("STRAVA_RATE_LIMIT_INTERACTIVE_RESERVE", 10),
+			"stravaUploadEnabled":       readBoolEnv("STRAVA_UPLOAD_ENABLED", false),
 			"fitFilesPath":              fitFilesPath,
 			"fitFilesConfigured":        fitConfigured,
 			"fitInboxPath":              fitInboxPath,
@@ -48,12 +57,25 @@ func Details() map[string]any {
 			"fitInboxSource":            fitInboxSource,
 			"garminFitSourcePath":       readStringEnv("GARMIN_FIT_SOURCE_PATH", ""),
 			"garminFitSourceConfigured": isConfigured("GARMIN_FIT_SOURCE_PATH"),
+			"garminMtpEnabled":          readBoolEnv("GARMIN_MTP_ENABLED", false),
 			"gpxFilesPath":              gpxFilesPath,
 			"gpxFilesConfigured":        gpxConfigured,
 			"gpxFilesSupported":         true,
+			"tcxFilesPath":              tcxFilesPath,
+			"tcxFilesConfigured":        tcxConfigured,
+			"tcxFilesSupported":         true,
+			"jsonFilesPath":             jsonFilesPath,
+			"jsonFilesConfigured":       jsonConfigured,
+			"jsonFilesSupported":        true,
+			"localSourceWatchEnabled":   readBoolEnv("LOCAL_SOURCE_WATCH_ENABLED", false),
+			"sourceSyncSchedule":        readStringEnv("SOURCE_SYNC_SCHEDULE", ""),
+			"stravaReconcileSchedule":   readStringEnv("STRAVA_RECONCILE_SCHEDULE", ""),
+			"cacheMigrationMode":        readStringEnv("CACHE_MIGRATION_MODE", "apply"),
+			"athletesFile":              readStringEnv("ATHLETES_FILE", ""),
+			"athletesFileConfigured":    isConfigured("ATHLETES_FILE"),
 			"activeProviders":           activeProviders,
 			"compositeAutoEnabled":      len(activeProviders) > 1,
-			"providerSelectionOrder":    []string{"STRAVA_CACHE_PATH", "FIT_FILES_PATH", "GPX_FILES_PATH"},
+			"providerSelectionOrder":    []string{"STRAVA_CACHE_PATH", "FIT_FILES_PATH", "GPX_FILES_PATH", "TCX_FILES_PATH", "JSON_FILES_PATH"},
 		},
 		"server": map[string]any{
 			"host":              readFirstStringEnv(defaultServerHost, "SERVER_HOST", "HOST"),
@@ -88,8 +110,8 @@ func Details() map[string]any {
 	}
 }
 
-func dataProviderDetails(stravaConfigured, fitConfigured, gpxConfigured bool) (string, []string) {
-	activeProviders := make([]string, 0, 3)
+func dataProviderDetails(stravaConfigured, fitConfigured, gpxConfigured, tcxConfigured, jsonConfigured bool) (string, []string) {
+	activeProviders := make([]string, 0, 5)
 	if stravaConfigured {
 		activeProviders = append(activeProviders, "strava")
 	}
@@ -99,6 +121,12 @@ func dataProviderDetails(stravaConfigured, fitConfigured, gpxConfigured bool) (s
 	if gpxConfigured {
 		activeProviders = append(activeProviders, "gpx")
 	}
+	if tcxConfigured {
+		activeProviders = append(activeProviders, "tcx")
+	}
+	if jsonConfigured {
+		activeProviders = append(activeProviders, "json")
+	}
 	if len(activeProviders) > 1 {
 		return "composite", activeProviders
 	}
@@ -1,7 +1,9 @@
 package runtimeconfig
 
 import (
+	"encoding/json"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -12,6 +14,7 @@ func TestDetails_DefaultsToStravaRuntimeConfig(t *testing.T) {
 	t.Setenv("FIT_INBOX_PATH", "")
 	t.Setenv("GARMIN_FIT_SOURCE_PATH", "")
 	t.Setenv("GPX_FILES_PATH", "")
+	t.Setenv("TCX_FILES_PATH", "")
 	t.Setenv("CORS_ALLOWED_ORIGINS", "")
 	t.Setenv("OSM_ROUTING_BASE_URL", "")
 	t.Setenv("OSRM_CONTROL_ENABLED", "")
@@ -62,6 +65,7 @@ func TestDetails_ExposesConfiguredRuntimeValues(t *testing.T) {
 	t.Setenv("FIT_INBOX_PATH", "/data/fit-inbox")
 	t.Setenv("GARMIN_FIT_SOURCE_PATH", "/Volumes/FENIX/GARMIN/ACTIVITY")
 	t.Setenv("GPX_FILES_PATH", "/data/gpx")
+	t.Setenv("TCX_FILES_PATH", "")
 	t.Setenv("STRAVA_CACHE_PATH", "/data/strava")
 	t.Setenv("STRAVA_API_BASE_URL", "https://www.api-v3.strava.com/")
 	t.Setenv("CORS_ALLOWED_ORIGINS", "http://localhost:5173, https://app.example")
@@ -142,6 +146,7 @@ func TestDetails_KeepsSingleConfiguredLocalProviderExclusive(t *testing.T) {
 	t.Setenv("STRAVA_CACHE_PATH", "")
 	t.Setenv("FIT_FILES_PATH", "/data/fit")
 	t.Setenv("GPX_FILES_PATH", "")
+	t.Setenv("TCX_FILES_PATH", "")
 
 	details := Details()
 	data := details["data"].(map[string]any)
@@ -156,3 +161,42 @@ func TestDetails_KeepsSingleConfiguredLocalProviderExclusive(t *testing.T) {
 		t.Fatalf("expected composite disabled, got %#v", data["compositeAutoEnabled"])
 	}
 }
+
+func TestDetails_AddsTCXToCompositeProviders(t *testing.T) {
+	t.Setenv("STRAVA_CACHE_PATH", "")
+	t.Setenv("FIT_FILES_PATH", "/data/fit")
+	t.Setenv("GPX_FILES_PATH", "")
+	t.Setenv("TCX_FILES_PATH", "/data/tcx")
+
+	details := Details()
+	data := details["data"].(map[string]any)
+
+	if data["provider"] != "composite" {
+		t.Fatalf("expected composite provider, got %#v", data["provider"])
+	}
+	if !reflect.DeepEqual(data["activeProviders"], []string{"fit", "tcx"}) {
+		t.Fatalf("expected active providers fit and tcx, got %#v", data["activeProviders"])
+	}
+	if data["tcxFilesPath"] != "/data/tcx" || data["tcxFilesConfigured"] != true || data["tcxFilesSupported"] != true {
+		t.Fatalf("expected configured TCX path, got %#v", data)
+	}
+}
+
+func TestDetails_ReportsSecretKeyWithoutItsValue(t *testing.T) {
+	t.Setenv("STRAVA_SECRET_KEY", "runtime-secret-key")
+	t.Setenv("STRAVA_SECRET_KEY_FILE", "/keys/mystravastats.key")
+
+	details := Details()
+	data := details["data"].(map[string]any)
+
+	if data["stravaSecretKeyConfigured"] != true || data["stravaSecretKeyFile"] != "/keys/mystravastats.key" {
+		t.Fatalf("expected secret key configuration to be reported, got %v", data)
+	}
+	encoded, err := json.Marshal(details)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(encoded), "runtime-secret-key") {
+		t.Fatalf("expected runtime details to never contain the secret key, got %s", encoded)
+	}
+}
@@ -14,11 +14,13 @@ import (
 
 // RouteServiceAdapter computes route explorer recommendations from cached activities.
 type RouteServiceAdapter struct {
+	providers     activityprovider.Lookup
 	routingEngine routeApp.RoutingEnginePort
 }
 
-func NewRouteServiceAdapter(routingEngine routeApp.RoutingEnginePort) *RouteServiceAdapter {
+func NewRouteServiceAdapter(providers activityprovider.Lookup, routingEngine routeApp.RoutingEnginePort) *RouteServiceAdapter {
 	return &RouteServiceAdapter{
+		providers:     providers,
 		routingEngine: routingEngine,
 	}
 }
@@ -28,7 +30,8 @@ func (adapter *RouteServiceAdapter) FindRouteExplorerByYearAndTypes(
 	request routesDomain.RouteExplorerRequest,
 	activityTypes ...business.ActivityType,
 ) routesDomain.RouteExplorerResult {
-	activities := dataqualityInfra.ApplyCurrentProviderCorrections(activityprovider.Get().GetActivitiesByYearAndActivityTypes(year, activityTypes...))
+	provider := adapter.providers()
+	activities := dataqualityInfra.ApplyProviderCorrections(provider, provider.GetActivitiesByYearAndActivityTypes(year, activityTypes...))
 	result := computeRouteExplorerFromActivities(activities, request)
 
 	if adapter.routingEngine == nil || request.StartPoint == nil {
@@ -6,6 +6,7 @@ import (
 	"hash/fnv"
 	"log"
 	"mystravastats/internal/platform/activityprovider"
+	"mystravastats/internal/platform/cachewrite"
 	"mystravastats/internal/shared/domain/business"
 	"mystravastats/internal/shared/domain/strava"
 	"os"
@@ -26,12 +27,21 @@ const (
 	segmentAnalysisAlgorithmVersion   = "direction-v2"
 )
 
-var segmentAttemptsCache = struct {
+// segmentAttemptsStore is the in-memory view of one segment analysis cache
+// file. Each provider gets its own store, keyed by the file path, so athletes
+// served by the same instance never see each other's attempts.
+type segmentAttemptsStore struct {
 	sync.RWMutex
+	path    string
 	loaded  bool
 	entries map[string]segmentAttemptsCacheEntry
+}
+
+var segmentAttemptsStores = struct {
+	sync.Mutex
+	byPath map[string]*segmentAttemptsStore
 }{
-	entries: make(map[string]segmentAttemptsCacheEntry),
+	byPath: make(map[string]*segmentAttemptsStore),
 }
 
 type segmentAttemptsCacheEntry struct {
@@ -117,34 +127,49 @@ func computeSegmentActivitiesSignature(activities []*strava.Activity) uint64 {
 	return hasher.Sum64()
 }
 
-func getSegmentAttemptsFromCache(cacheKey string) (map[int64][]segmentAttemptRaw, bool) {
-	ensureSegmentAttemptsCacheLoaded()
+func segmentAttemptsCacheFor(provider activityprovider.ActivityProvider) *segmentAttemptsStore {
+	cachePath, _ := segmentAnalysisCachePath(provider)
+	segmentAttemptsStores.Lock()
+	defer segmentAttemptsStores.Unlock()
+	store, ok := segmentAttemptsStores.byPath[cachePath]
+	if !ok {
+		store = &segmentAttemptsStore{
+			path:    cachePath,
+			entries: make(map[string]segmentAttemptsCacheEntry),
+		}
+		segmentAttemptsStores.byPath[cachePath] = store
+	}
+	return store
+}
+
+func (cache *segmentAttemptsStore) get(cacheKey string) (map[int64][]segmentAttemptRaw, bool) {
+	cache.ensureLoaded()
 
 	now := time.Now().UTC()
-	segmentAttemptsCache.RLock()
-	entry, ok := segmentAttemptsCache.entries[cacheKey]
-	segmentAttemptsCache.RUnlock()
+	cache.RLock()
+	entry, ok := cache.entries[cacheKey]
+	cache.RUnlock()
 	if !ok {
 		return nil, false
 	}
 
 	if !entry.ExpiresAt.After(now) {
-		segmentAttemptsCache.Lock()
-		delete(segmentAttemptsCache.entries, cacheKey)
-		persistSegmentAttemptsCacheLocked()
-		segmentAttemptsCache.Unlock()
+		cache.Lock()
+		delete(cache.entries, cacheKey)
+		cache.persistLocked()
+		cache.Unlock()
 		return nil, false
 	}
 
 	return cloneAttemptsByTarget(entry.AttemptsByTarg), true
 }
 
-func storeSegmentAttemptsInCache(
+func (cache *segmentAttemptsStore) store(
 	cacheKey string,
 	attemptsByTarget map[int64][]segmentAttemptRaw,
 	fallbackUsed bool,
 ) {
-	ensureSegmentAttemptsCacheLoaded()
+	cache.ensureLoaded()
 
 	if len(attemptsByTarget) == 0 {
 		return
@@ -163,23 +188,23 @@ func storeSegmentAttemptsInCache(
 		AttemptsByTarg: cloneAttemptsByTarget(attemptsByTarget),
 	}
 
-	segmentAttemptsCache.Lock()
-	segmentAttemptsCache.entries[cacheKey] = entry
-	trimSegmentAttemptsCacheLocked(now)
-	persistSegmentAttemptsCacheLocked()
-	segmentAttemptsCache.Unlock()
+	cache.Lock()
+	cache.entries[cacheKey] = entry
+	cache.trimLocked(now)
+	cache.persistLocked()
+	cache.Unlock()
 }
 
-func ensureSegmentAttemptsCacheLoaded() {
-	segmentAttemptsCache.Lock()
-	defer segmentAttemptsCache.Unlock()
-	if segmentAttemptsCache.loaded {
+func (cache *segmentAttemptsStore) ensureLoaded() {
+	cache.Lock()
+	defer cache.Unlock()
+	if cache.loaded {
 		return
 	}
 
-	segmentAttemptsCache.loaded = true
-	cachePath, ok := segmentAnalysisCachePath()
-	if !ok {
+	cache.loaded = true
+	cachePath := cache.path
+	if cachePath == "" {
 		return
 	}
 
@@ -215,7 +240,7 @@ func ensureSegmentAttemptsCacheLoaded() {
 		if len(attemptsByTarget) == 0 {
 			continue
 		}
-		segmentAttemptsCache.entries[diskEntry.Key] = segmentAttemptsCacheEntry{
+		cache.entries[diskEntry.Key] = segmentAttemptsCacheEntry{
 			CreatedAt:      createdAt,
 			ExpiresAt:      expiresAt,
 			FallbackUsed:   diskEntry.FallbackUsed,
@@ -229,14 +254,14 @@ func ensureSegmentAttemptsCacheLoaded() {
 	}
 }
 
-func trimSegmentAttemptsCacheLocked(now time.Time) {
-	for key, entry := range segmentAttemptsCache.entries {
+func (cache *segmentAttemptsStore) trimLocked(now time.Time) {
+	for key, entry := range cache.entries {
 		if !entry.ExpiresAt.After(now) {
-			delete(segmentAttemptsCache.entries, key)
+			delete(cache.entries, key)
 		}
 	}
 
-	if len(segmentAttemptsCache.entries) <= segmentAnalysisCacheMaxEntries {
+	if len(cache.entries) <= segmentAnalysisCacheMaxEntries {
 		return
 	}
 
@@ -244,8 +269,8 @@ func trimSegmentAttemptsCacheLocked(now time.Time) {
 		key       string
 		createdAt time.Time
 	}
-	sortedEntries := make([]sortableEntry, 0, len(segmentAttemptsCache.entries))
-	for key, entry := range segmentAttemptsCache.entries {
+	sortedEntries := make([]sortableEntry, 0, len(cache.entries))
+	for key, entry := range cache.entries {
 		sortedEntries = append(sortedEntries, sortableEntry{
 			key:       key,
 			createdAt: entry.CreatedAt,
@@ -256,19 +281,19 @@ func trimSegmentAttemptsCacheLocked(now time.Time) {
 	})
 
 	for index := segmentAnalysisCacheMaxEntries; index < len(sortedEntries); index++ {
-		delete(segmentAttemptsCache.entries, sortedEntries[index].key)
+		delete(cache.entries, sortedEntries[index].key)
 	}
 }
 
-func persistSegmentAttemptsCacheLocked() {
-	cachePath, ok := segmentAnalysisCachePath()
-	if !ok {
+func (cache *segmentAttemptsStore) persistLocked() {
+	cachePath := cache.path
+	if cachePath == "" {
 		return
 	}
 
 	now := time.Now().UTC()
-	diskEntries := make([]segmentAnalysisCacheDiskEntry, 0, len(segmentAttemptsCache.entries))
-	for key, entry := range segmentAttemptsCache.entries {
+	diskEntries := make([]segmentAnalysisCacheDiskEntry, 0, len(cache.entries))
+	for key, entry := range cache.entries {
 		if !entry.ExpiresAt.After(now) {
 			continue
 		}
@@ -307,15 +332,10 @@ func writeSegmentAnalysisCacheAtomically(path string, data []byte) error {
 		return err
 	}
 
-	tempPath := path + ".tmp"
-	if err := os.WriteFile(tempPath, data, 0o644); err != nil {
-		return err
-	}
-	return os.Rename(tempPath, path)
+	return cachewrite.WriteFile(path, data, 0o644)
 }
 
-func segmentAnalysisCachePath() (string, bool) {
-	provider := activityprovider.Get()
+func segmentAnalysisCachePath(provider activityprovider.ActivityProvider) (string, bool) {
 	cacheRoot := strings.TrimSpace(provider.CacheRootPath())
 	clientID := strings.TrimSpace(provider.ClientID())
 	if cacheRoot == "" || clientID == "" {
@@ -1,12 +1,15 @@
 package infrastructure
 
 import (
+	"fmt"
 	"hash/fnv"
 	dataqualityInfra "mystravastats/internal/dataquality/infrastructure"
 	"mystravastats/internal/platform/activityprovider"
+	"mystravastats/internal/platform/events"
 	"mystravastats/internal/shared/domain/business"
 	"mystravastats/internal/shared/domain/strava"
 	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -19,6 +22,7 @@ type SegmentSummaryResult struct {
 }
 
 func computeSegmentsByYearMetricQueryRangeAndTypes(
+	provider activityprovider.ActivityProvider,
 	year *int,
 	metric *string,
 	query *string,
@@ -33,7 +37,7 @@ func computeSegmentsByYearMetricQueryRangeAndTypes(
 	resolvedMetric := parseSegmentMetric(metric)
 	queryFilter := strings.ToLower(strings.TrimSpace(valueOrEmpty(query)))
 
-	attemptsByTarget := collectSegmentAttemptsGroupedByTarget(year, from, to, activityTypes...)
+	attemptsByTarget := collectSegmentAttemptsGroupedByTarget(provider, year, from, to, activityTypes...)
 	summaries := make([]business.SegmentClimbTargetSummary, 0, len(attemptsByTarget))
 	for _, attempts := range attemptsByTarget {
 		if len(attempts) < 2 {
@@ -57,6 +61,7 @@ func computeSegmentsByYearMetricQueryRangeAndTypes(
 }
 
 func computeSegmentEffortsByYearMetricRangeAndTypes(
+	provider activityprovider.ActivityProvider,
 	year *int,
 	metric *string,
 	targetId int64,
@@ -68,7 +73,7 @@ func computeSegmentEffortsByYearMetricRangeAndTypes(
 		return []business.SegmentClimbAttempt{}
 	}
 
-	attemptsByTarget := collectSegmentAttemptsGroupedByTarget(year, from, to, activityTypes...)
+	attemptsByTarget := collectSegmentAttemptsGroupedByTarget(provider, year, from, to, activityTypes...)
 	attempts := attemptsByTarget[targetId]
 	if len(attempts) == 0 {
 		return []business.SegmentClimbAttempt{}
@@ -79,6 +84,7 @@ func computeSegmentEffortsByYearMetricRangeAndTypes(
 }
 
 func computeSegmentSummaryByYearMetricRangeAndTypes(
+	provider activityprovider.ActivityProvider,
 	year *int,
 	metric *string,
 	targetId int64,
@@ -90,7 +96,7 @@ func computeSegmentSummaryByYearMetricRangeAndTypes(
 		return nil
 	}
 
-	attemptsByTarget := collectSegmentAttemptsGroupedByTarget(year, from, to, activityTypes...)
+	attemptsByTarget := collectSegmentAttemptsGroupedByTarget(provider, year, from, to, activityTypes...)
 	attempts := attemptsByTarget[targetId]
 	if len(attempts) == 0 {
 		return nil
@@ -116,27 +122,31 @@ func computeSegmentSummaryByYearMetricRangeAndTypes(
 }
 
 func collectSegmentAttemptsGroupedByTarget(
+	provider activityprovider.ActivityProvider,
 	year *int,
 	from *string,
 	to *string,
 	activityTypes ...business.ActivityType,
 ) map[int64][]segmentAttemptRaw {
-	filteredActivities := dataqualityInfra.FilterExcludedFromStats(activityprovider.Get().GetActivitiesByYearAndActivityTypes(year, activityTypes...))
+	filteredActivities := dataqualityInfra.FilterExcludedFromStats(provider, provider.GetActivitiesByYearAndActivityTypes(year, activityTypes...))
 	sort.Slice(filteredActivities, func(i, j int) bool {
 		return filteredActivities[i].StartDateLocal < filteredActivities[j].StartDateLocal
 	})
 
 	activitySignature := computeSegmentActivitiesSignature(filteredActivities)
 	cacheKey := buildSegmentAttemptsCacheKey(year, from, to, activityTypes, activitySignature)
-	if cachedAttempts, ok := getSegmentAttemptsFromCache(cacheKey); ok {
+	cache := segmentAttemptsCacheFor(provider)
+	if cachedAttempts, ok := cache.get(cacheKey); ok {
 		return cachedAttempts
 	}
 
+	progress := events.Start(events.OperationSegmentCache, provider.ClientID(), len(filteredActivities), "Rebuilding segment analysis cache.")
 	attemptsByTarget := make(map[int64][]segmentAttemptRaw)
-	for _, activity := range filteredActivities {
-		detailedActivity := activityprovider.Get().GetCachedDetailedActivity(activity.Id)
+	for position, activity := range filteredActivities {
+		progress.Update("segment_efforts", activityStartYear(activity), position, 0)
+		detailedActivity := provider.GetCachedDetailedActivity(activity.Id)
 		if detailedActivity == nil {
-			detailedActivity = activityprovider.Get().GetDetailedActivity(activity.Id)
+			detailedActivity = provider.GetDetailedActivity(activity.Id)
 		}
 		if detailedActivity == nil {
 			continue
@@ -162,9 +172,11 @@ func collectSegmentAttemptsGroupedByTarget(
 	}
 
 	attemptsByTarget = splitAttemptsByDirection(attemptsByTarget)
+	progress.Update("segment_efforts", 0, len(filteredActivities), 0)
 
 	if len(attemptsByTarget) > 0 {
-		storeSegmentAttemptsInCache(cacheKey, attemptsByTarget, false)
+		cache.store(cacheKey, attemptsByTarget, false)
+		progress.Complete(fmt.Sprintf("%d segment(s) analysed.", len(attemptsByTarget)))
 		return attemptsByTarget
 	}
 
@@ -172,7 +184,8 @@ func collectSegmentAttemptsGroupedByTarget(
 	// When detailed activities are unavailable in cache (thus no segment_efforts),
 	// provide route-level progression by grouping repeated activity names.
 	fallbackAttempts := collectNameBasedAttemptsByTarget(filteredActivities, from, to)
-	storeSegmentAttemptsInCache(cacheKey, fallbackAttempts, true)
+	cache.store(cacheKey, fallbackAttempts, true)
+	progress.Complete(fmt.Sprintf("No segment efforts cached; %d repeated route(s) grouped by name.", len(fallbackAttempts)))
 	return fallbackAttempts
 }
 
@@ -294,6 +307,14 @@ func rankTopEfforts(
 	return ranked[:limit]
 }
 
+func activityStartYear(activity *strava.Activity) int {
+	if activity == nil || len(activity.StartDateLocal) < 4 {
+		return 0
+	}
+	year, _ := strconv.Atoi(activity.StartDateLocal[:4])
+	return year
+}
+
 func extractDateOnly(value string) string {
 	if len(value) >= 10 {
 		return value[:10]
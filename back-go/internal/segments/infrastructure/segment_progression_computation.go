@@ -48,6 +48,7 @@ type segmentAttemptRaw struct {
 }
 
 func computeSegmentClimbProgressionByYearMetricTargetAndTypes(
+	provider activityprovider.ActivityProvider,
 	year *int,
 	metric *string,
 	targetType *string,
@@ -62,14 +63,14 @@ func computeSegmentClimbProgressionByYearMetricTargetAndTypes(
 		return emptySegmentClimbProgression(resolvedMetric, resolvedTargetType)
 	}
 
-	filteredActivities := dataqualityInfra.FilterExcludedFromStats(activityprovider.Get().GetActivitiesByYearAndActivityTypes(year, activityTypes...))
+	filteredActivities := dataqualityInfra.FilterExcludedFromStats(provider, provider.GetActivitiesByYearAndActivityTypes(year, activityTypes...))
 	if len(filteredActivities) == 0 && year != nil {
 		log.Printf(
 			"Segment progression fallback to all years: requestedYear=%v targetType=%s activities=0",
 			yearValueForLog(year),
 			resolvedTargetType,
 		)
-		filteredActivities = dataqualityInfra.FilterExcludedFromStats(activityprovider.Get().GetActivitiesByYearAndActivityTypes(nil, activityTypes...))
+		filteredActivities = dataqualityInfra.FilterExcludedFromStats(provider, provider.GetActivitiesByYearAndActivityTypes(nil, activityTypes...))
 	}
 	sort.Slice(filteredActivities, func(i, j int) bool {
 		return filteredActivities[i].StartDateLocal < filteredActivities[j].StartDateLocal
@@ -83,9 +84,9 @@ func computeSegmentClimbProgressionByYearMetricTargetAndTypes(
 	detailedMissingCount := 0
 	totalCandidateEfforts := 0
 	for _, activity := range filteredActivities {
-		detailedActivity := activityprovider.Get().GetCachedDetailedActivity(activity.Id)
+		detailedActivity := provider.GetCachedDetailedActivity(activity.Id)
 		if detailedActivity == nil {
-			detailedActivity = activityprovider.Get().GetDetailedActivity(activity.Id)
+			detailedActivity = provider.GetDetailedActivity(activity.Id)
 		}
 		if detailedActivity == nil {
 			detailedMissingCount++
@@ -179,7 +180,7 @@ func computeSegmentClimbProgressionByYearMetricTargetAndTypes(
 	if len(rawAttempts) == 0 {
 		if year != nil {
 			// Fallback for UX: when selected year has no segment data yet, reuse all-years data.
-			return computeSegmentClimbProgressionByYearMetricTargetAndTypes(nil, metric, strPtr(string(effectiveTargetType)), targetId, activityTypes...)
+			return computeSegmentClimbProgressionByYearMetricTargetAndTypes(provider, nil, metric, strPtr(string(effectiveTargetType)), targetId, activityTypes...)
 		}
 		return emptySegmentClimbProgression(resolvedMetric, resolvedTargetType)
 	}
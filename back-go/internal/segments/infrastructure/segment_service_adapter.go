@@ -1,15 +1,18 @@
 package infrastructure
 
 import (
+	"mystravastats/internal/platform/activityprovider"
 	"mystravastats/internal/segments/domain"
 	"mystravastats/internal/shared/domain/business"
 )
 
 // SegmentServiceAdapter computes segment read models directly from provider data.
-type SegmentServiceAdapter struct{}
+type SegmentServiceAdapter struct {
+	providers activityprovider.Lookup
+}
 
-func NewSegmentServiceAdapter() *SegmentServiceAdapter {
-	return &SegmentServiceAdapter{}
+func NewSegmentServiceAdapter(providers activityprovider.Lookup) *SegmentServiceAdapter {
+	return &SegmentServiceAdapter{providers: providers}
 }
 
 func (adapter *SegmentServiceAdapter) FindSegmentClimbProgressionByYearMetricTargetAndTypes(
@@ -19,7 +22,7 @@ func (adapter *SegmentServiceAdapter) FindSegmentClimbProgressionByYearMetricTar
 	targetId *int64,
 	activityTypes ...business.ActivityType,
 ) business.SegmentClimbProgression {
-	return computeSegmentClimbProgressionByYearMetricTargetAndTypes(year, metric, targetType, targetId, activityTypes...)
+	return computeSegmentClimbProgressionByYearMetricTargetAndTypes(adapter.providers(), year, metric, targetType, targetId, activityTypes...)
 }
 
 func (adapter *SegmentServiceAdapter) FindSegmentsByYearMetricQueryRangeAndTypes(
@@ -30,7 +33,7 @@ func (adapter *SegmentServiceAdapter) FindSegmentsByYearMetricQueryRangeAndTypes
 	to *string,
 	activityTypes ...business.ActivityType,
 ) []business.SegmentClimbTargetSummary {
-	return computeSegmentsByYearMetricQueryRangeAndTypes(year, metric, query, from, to, activityTypes...)
+	return computeSegmentsByYearMetricQueryRangeAndTypes(adapter.providers(), year, metric, query, from, to, activityTypes...)
 }
 
 func (adapter *SegmentServiceAdapter) FindSegmentEffortsByYearMetricRangeAndTypes(
@@ -41,7 +44,7 @@ func (adapter *SegmentServiceAdapter) FindSegmentEffortsByYearMetricRangeAndType
 	to *string,
 	activityTypes ...business.ActivityType,
 ) []business.SegmentClimbAttempt {
-	return computeSegmentEffortsByYearMetricRangeAndTypes(year, metric, segmentID, from, to, activityTypes...)
+	return computeSegmentEffortsByYearMetricRangeAndTypes(adapter.providers(), year, metric, segmentID, from, to, activityTypes...)
 }
 
 func (adapter *SegmentServiceAdapter) FindSegmentSummaryByYearMetricRangeAndTypes(
@@ -52,7 +55,7 @@ func (adapter *SegmentServiceAdapter) FindSegmentSummaryByYearMetricRangeAndType
 	to *string,
 	activityTypes ...business.ActivityType,
 ) *domain.SegmentSummary {
-	summary := computeSegmentSummaryByYearMetricRangeAndTypes(year, metric, segmentID, from, to, activityTypes...)
+	summary := computeSegmentSummaryByYearMetricRangeAndTypes(adapter.providers(), year, metric, segmentID, from, to, activityTypes...)
 	if summary == nil {
 		return nil
 	}
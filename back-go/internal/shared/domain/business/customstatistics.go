@@ -0,0 +1,9 @@
+package business
+
+// CustomStatisticDefinition is a user-defined statistic: a label and an
+// expression evaluated by the statistics engine, e.g.
+// "best_distance(15 km) where not commute".
+type CustomStatisticDefinition struct {
+	Label      string `json:"label"`
+	Expression string `json:"expression"`
+}
@@ -0,0 +1,42 @@
+package business
+
+// PaceCurvePoint is the fastest time over a distance, with the activity it
+// comes from.
+type PaceCurvePoint struct {
+	Distance     float64
+	Seconds      int
+	ActivityDate string
+	Activity     ActivityShort
+}
+
+// RacePrediction is the predicted time over a race distance with the Riegel,
+// Cameron and VDOT models, next to the actual best effort over the distance.
+type RacePrediction struct {
+	Label          string
+	Distance       float64
+	RiegelSeconds  int
+	CameronSeconds int
+	VdotSeconds    int
+	Best           *PaceCurvePoint
+}
+
+// VdotTrendPoint is the best VDOT of a month and the effort it comes from.
+type VdotTrendPoint struct {
+	Period string
+	Vdot   float64
+	Effort PaceCurvePoint
+}
+
+// PaceCurve is the best pace curve of running activities. The predictions
+// all derive from Reference, the effort with the highest VDOT. With
+// GradeAdjusted, distances are flat-equivalent ones.
+type PaceCurve struct {
+	Points        []PaceCurvePoint
+	Reference     *PaceCurvePoint
+	Vdot          *float64
+	Predictions   []RacePrediction
+	VdotTrend     []VdotTrendPoint
+	ActivityCount int
+	WindowDays    int
+	GradeAdjusted bool
+}
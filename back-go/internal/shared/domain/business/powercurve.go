@@ -0,0 +1,40 @@
+package business
+
+// PowerCurvePoint is the best average power held for a duration, with the
+// activity it comes from.
+type PowerCurvePoint struct {
+	Seconds      int
+	Watts        float64
+	ActivityDate string
+	Activity     ActivityShort
+}
+
+const (
+	CriticalPowerModelTwoParameter = "CP2"
+	CriticalPowerModelMorton       = "MORTON_3P"
+)
+
+// CriticalPowerModel is a critical power model fitted to a power curve:
+// P(t) = CP + W'/(t - K), with K = 0 for the 2-parameter model. MaxPower is
+// the power the Morton 3-parameter model predicts at t = 0.
+type CriticalPowerModel struct {
+	Model         string
+	CriticalPower float64
+	WPrime        float64
+	K             float64
+	MaxPower      *float64
+	RSquared      float64
+	RMSE          float64
+	FromSeconds   int
+	ToSeconds     int
+	PointCount    int
+}
+
+type PowerCurve struct {
+	Points         []PowerCurvePoint
+	TwoParameter   *CriticalPowerModel
+	ThreeParameter *CriticalPowerModel
+	ActivityCount  int
+	WindowDays     int
+	ActivityID     *int64
+}
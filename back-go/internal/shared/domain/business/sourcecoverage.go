@@ -0,0 +1,35 @@
+package business
+
+// SourceCoverageReport compares, year by year, the activities held by each
+// composite source.
+type SourceCoverageReport struct {
+	Sources []string             `json:"sources"`
+	Years   []SourceCoverageYear `json:"years"`
+}
+
+// SourceCoverageYear counts the composite activities of one year and lists,
+// for each pair of sources, the activities found in one but not the other.
+type SourceCoverageYear struct {
+	Year       string              `json:"year"`
+	Activities int                 `json:"activities"`
+	Merged     int                 `json:"merged"`
+	BySource   map[string]int      `json:"bySource"`
+	Gaps       []SourceCoverageGap `json:"gaps"`
+}
+
+// SourceCoverageGap lists the activities of Source missing from MissingFrom.
+type SourceCoverageGap struct {
+	Source      string                   `json:"source"`
+	MissingFrom string                   `json:"missingFrom"`
+	Count       int                      `json:"count"`
+	Activities  []SourceCoverageActivity `json:"activities"`
+}
+
+type SourceCoverageActivity struct {
+	ActivityID       int64   `json:"activityId"`
+	SourceActivityID int64   `json:"sourceActivityId"`
+	Name             string  `json:"name"`
+	SportType        string  `json:"sportType"`
+	StartDateLocal   string  `json:"startDateLocal"`
+	Distance         float64 `json:"distance"`
+}
@@ -0,0 +1,77 @@
+package business
+
+// Fields whose winning source can be pinned when the composite provider merges
+// the copies of one activity.
+const (
+	SourceMergeFieldName        = "name"
+	SourceMergeFieldSportType   = "sportType"
+	SourceMergeFieldDescription = "description"
+	SourceMergeFieldGear        = "gear"
+	SourceMergeFieldCommute     = "commute"
+	SourceMergeFieldDistance    = "distance"
+	SourceMergeFieldDuration    = "duration"
+	SourceMergeFieldElevation   = "elevation"
+	SourceMergeFieldHeartRate   = "heartRate"
+	SourceMergeFieldPower       = "power"
+	SourceMergeFieldCadence     = "cadence"
+	SourceMergeFieldStream      = "stream"
+)
+
+var SourceMergeFields = []string{
+	SourceMergeFieldName,
+	SourceMergeFieldSportType,
+	SourceMergeFieldDescription,
+	SourceMergeFieldGear,
+	SourceMergeFieldCommute,
+	SourceMergeFieldDistance,
+	SourceMergeFieldDuration,
+	SourceMergeFieldElevation,
+	SourceMergeFieldHeartRate,
+	SourceMergeFieldPower,
+	SourceMergeFieldCadence,
+	SourceMergeFieldStream,
+}
+
+// SourceActivityKey identifies one activity of one composite source.
+type SourceActivityKey struct {
+	Provider   string `json:"provider"`
+	ActivityID int64  `json:"activityId"`
+}
+
+// SourceMergeLink forces source activities into the same composite activity,
+// whatever the automatic matching decides.
+type SourceMergeLink struct {
+	Sources   []SourceActivityKey `json:"sources"`
+	CreatedAt string              `json:"createdAt"`
+}
+
+// SourceMergeSplit keeps a source activity out of automatic matching, which
+// splits it from the cluster it was wrongly merged into.
+type SourceMergeSplit struct {
+	Source    SourceActivityKey `json:"source"`
+	CreatedAt string            `json:"createdAt"`
+}
+
+// SourceMergeFieldPin pins field sources for the composite activity holding
+// Source. It takes precedence over SourceMergeOverrides.FieldSources.
+type SourceMergeFieldPin struct {
+	Source SourceActivityKey `json:"source"`
+	Fields map[string]string `json:"fields"`
+}
+
+// SourceMergeOverrides are the user corrections applied on top of automatic
+// composite matching.
+type SourceMergeOverrides struct {
+	Links                []SourceMergeLink     `json:"links"`
+	Splits               []SourceMergeSplit    `json:"splits"`
+	FieldSources         map[string]string     `json:"fieldSources"`
+	ActivityFieldSources []SourceMergeFieldPin `json:"activityFieldSources"`
+}
+
+type SourceMergeLinkRequest struct {
+	Sources []SourceActivityKey `json:"sources"`
+}
+
+type SourceMergeFieldSourcesRequest struct {
+	Fields map[string]string `json:"fields"`
+}
@@ -6,6 +6,8 @@ const (
 	SourceModeStrava SourceMode = "STRAVA"
 	SourceModeFIT    SourceMode = "FIT"
 	SourceModeGPX    SourceMode = "GPX"
+	SourceModeTCX    SourceMode = "TCX"
+	SourceModeJSON   SourceMode = "JSON"
 )
 
 type SourceModePreviewRequest struct {
@@ -90,6 +92,9 @@ type StravaOAuthStatus struct {
 	RequiredScopes         []string `json:"requiredScopes"`
 	MissingScopes          []string `json:"missingScopes"`
 	TokenError             string   `json:"tokenError"`
+
+	CredentialsEncrypted bool `json:"credentialsEncrypted"`
+	TokenEncrypted       bool `json:"tokenEncrypted"`
 }
 
 type SourceModePreview struct {
@@ -0,0 +1,39 @@
+package business
+
+const (
+	StravaUploadStatusUploaded  = "uploaded"
+	StravaUploadStatusDuplicate = "duplicate"
+	StravaUploadStatusFailed    = "failed"
+	StravaUploadStatusNotFound  = "not_found"
+)
+
+type StravaUploadRequest struct {
+	ActivityIDs []int64 `json:"activityIds"`
+}
+
+type StravaUploadItem struct {
+	ActivityID       int64  `json:"activityId"`
+	Source           string `json:"source,omitempty"`
+	FilePath         string `json:"filePath,omitempty"`
+	Status           string `json:"status"`
+	StravaActivityID int64  `json:"stravaActivityId,omitempty"`
+	UploadID         int64  `json:"uploadId,omitempty"`
+	Message          string `json:"message,omitempty"`
+}
+
+type StravaUploadResult struct {
+	Uploaded   int                `json:"uploaded"`
+	Duplicates int                `json:"duplicates"`
+	Failed     int                `json:"failed"`
+	Items      []StravaUploadItem `json:"items"`
+}
+
+// StravaUploadRecord remembers which Strava activity a local file became, so
+// the same file is never uploaded twice.
+type StravaUploadRecord struct {
+	ContentHash      string `json:"contentHash"`
+	FilePath         string `json:"filePath"`
+	UploadID         int64  `json:"uploadId,omitempty"`
+	StravaActivityID int64  `json:"stravaActivityId"`
+	UploadedAt       string `json:"uploadedAt"`
+}
@@ -12,6 +12,7 @@ type Activity struct {
 	MaxHeartrate         float64    `json:"max_heartrate"`
 	AverageWatts         float64    `json:"average_watts"`
 	Commute              bool       `json:"commute"`
+	Description          *string    `json:"description,omitempty"`
 	Distance             float64    `json:"distance"`
 	DeviceWatts          bool       `json:"device_watts"`
 	ElapsedTime          int        `json:"elapsed_time"`
@@ -31,6 +32,9 @@ type Activity struct {
 	UploadId             int64      `json:"upload_id"`
 	WeightedAverageWatts int        `json:"weighted_average_watts"`
 	Stream               *Stream    `json:"stream"`
+
+	Laps     []Lap             `json:"laps,omitempty"`
+	Sessions []ActivitySession `json:"sessions,omitempty"`
 }
 
 func (activity *Activity) ToStravaDetailedActivity() *DetailedActivity {
@@ -46,7 +50,7 @@ func (activity *Activity) ToStravaDetailedActivity() *DetailedActivity {
 		Calories:                 0.0,
 		CommentCount:             0,
 		Commute:                  activity.Commute,
-		Description:              nil,
+		Description:              activity.Description,
 		DeviceName:               nil,
 		DeviceWatts:              activity.DeviceWatts,
 		Distance:                 activity.Distance,
@@ -94,5 +98,7 @@ func (activity *Activity) ToStravaDetailedActivity() *DetailedActivity {
 		WeightedAverageWatts:     activity.WeightedAverageWatts,
 		WorkoutType:              0,
 		Stream:                   activity.Stream,
+		Laps:                     activity.Laps,
+		Sessions:                 activity.Sessions,
 	}
 }
@@ -66,6 +66,9 @@ type DetailedActivity struct {
 	WorkoutType              int             `json:"workout_type"`
 	Stream                   *Stream         `json:"stream,omitempty"`
 	Source                   *ActivitySource `json:"source,omitempty"`
+
+	Laps     []Lap             `json:"laps,omitempty"`
+	Sessions []ActivitySession `json:"sessions,omitempty"`
 }
 
 type ActivitySource struct {
@@ -93,3 +96,34 @@ type ActivitySourceConflict struct {
 	Other   string `json:"other"`
 	Source  string `json:"source"`
 }
+
+func (activity *DetailedActivity) ToStravaActivity() *Activity {
+	return &Activity{
+		Athlete:              AthleteRef{ID: int(activity.Athlete.Id)},
+		AverageSpeed:         activity.AverageSpeed,
+		AverageCadence:       activity.AverageCadence,
+		AverageHeartrate:     activity.AverageHeartrate,
+		MaxHeartrate:         activity.MaxHeartrate,
+		AverageWatts:         activity.AverageWatts,
+		Commute:              activity.Commute,
+		Distance:             activity.Distance,
+		DeviceWatts:          activity.DeviceWatts,
+		ElapsedTime:          activity.ElapsedTime,
+		ElevHigh:             activity.ElevHigh,
+		GearId:               activity.GearId,
+		Id:                   activity.Id,
+		Kilojoules:           activity.Kilojoules,
+		MaxSpeed:             activity.MaxSpeed,
+		MovingTime:           activity.MovingTime,
+		Name:                 activity.Name,
+		SportType:            activity.SportType,
+		StartDate:            activity.StartDate,
+		StartDateLocal:       activity.StartDateLocal,
+		StartLatlng:          activity.StartLatLng,
+		TotalElevationGain:   activity.TotalElevationGain,
+		Type:                 activity.Type,
+		UploadId:             activity.UploadId,
+		WeightedAverageWatts: activity.WeightedAverageWatts,
+		Stream:               activity.Stream,
+	}
+}
@@ -0,0 +1,87 @@
+package strava
+
+import "math"
+
+const (
+	// Minetti measured the energy cost of running between -45% and +45%.
+	minettiMaxGrade = 0.45
+	// minettiFlatCost is the energy cost of running on the flat, in J/kg/m.
+	minettiFlatCost = 3.6
+	// gradeAdjustedSmoothingMeters smooths the grade computed from altitude
+	// differences, which are too noisy sample by sample.
+	gradeAdjustedSmoothingMeters = 50.0
+)
+
+// MinettiEnergyCost returns the energy cost of running on a grade, in J/kg/m,
+// from the polynomial fitted by Minetti et al. (2002). The grade is a ratio,
+// 0.1 for 10%.
+func MinettiEnergyCost(grade float64) float64 {
+	grade = math.Max(-minettiMaxGrade, math.Min(minettiMaxGrade, grade))
+	return 155.4*math.Pow(grade, 5) - 30.4*math.Pow(grade, 4) - 43.3*math.Pow(grade, 3) +
+		46.3*grade*grade + 19.5*grade + minettiFlatCost
+}
+
+// GradeAdjustedDistances returns the cumulative flat-equivalent distance of
+// the stream: each step counts for the distance that costs the same energy
+// on the flat. The pace over this distance is the grade-adjusted pace (GAP).
+// It returns nil when the stream has neither grade nor altitude.
+func (s *Stream) GradeAdjustedDistances() []float64 {
+	if s == nil || (s.Altitude == nil && s.GradeSmooth == nil) {
+		return nil
+	}
+	distances := s.Distance.Data
+	dataSize := len(distances)
+	if s.GradeSmooth == nil || len(s.GradeSmooth.Data) < dataSize {
+		if s.Altitude == nil {
+			return nil
+		}
+		dataSize = min(dataSize, len(s.Altitude.Data))
+	}
+	if dataSize < 2 {
+		return nil
+	}
+	// A grade stream of zeros falls back to altitudes; without any, the
+	// stream is flat.
+	altitudes := make([]float64, dataSize)
+	if s.Altitude != nil && len(s.Altitude.Data) >= dataSize {
+		altitudes = s.Altitude.Data
+	}
+
+	grades := s.gradePercentSamples(altitudes, distances, dataSize)
+	grades = smoothGradeByDistance(grades, distances, dataSize, gradeAdjustedSmoothingMeters)
+	adjusted := make([]float64, dataSize)
+	for i := 1; i < dataSize; i++ {
+		adjusted[i] = adjusted[i-1]
+		step := distances[i] - distances[i-1]
+		if step <= 0 || !isFiniteFloat(step) {
+			continue
+		}
+		adjusted[i] += step * MinettiEnergyCost(grades[i]/100) / minettiFlatCost
+	}
+	return adjusted
+}
+
+// GradeAdjustedSpeed scales an average speed by the ratio of flat-equivalent
+// to actual distance of the stream. It returns 0 when the stream cannot be
+// grade-adjusted.
+func GradeAdjustedSpeed(averageSpeed float64, stream *Stream) float64 {
+	adjusted := stream.GradeAdjustedDistances()
+	if len(adjusted) == 0 || averageSpeed <= 0 {
+		return 0
+	}
+	distances := stream.Distance.Data
+	distance := distances[len(adjusted)-1] - distances[0]
+	if distance <= 0 || !isFiniteFloat(distance) {
+		return 0
+	}
+	return averageSpeed * adjusted[len(adjusted)-1] / distance
+}
+
+// IsOnFoot reports whether the grade-adjusted pace applies to the sport.
+func IsOnFoot(activityType string) bool {
+	switch activityType {
+	case "Run", "TrailRun", "VirtualRun", "Hike", "Walk":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package strava
+
+import (
+	"math"
+	"testing"
+)
+
+// climbStream returns a 1 km stream at 1 m/s on a constant grade, in percent.
+func climbStream(grade float64) *Stream {
+	stream := &Stream{Altitude: &AltitudeStream{}}
+	for second := 0; second <= 1000; second++ {
+		stream.Distance.Data = append(stream.Distance.Data, float64(second))
+		stream.Time.Data = append(stream.Time.Data, second)
+		stream.Altitude.Data = append(stream.Altitude.Data, float64(second)*grade/100)
+	}
+	return stream
+}
+
+func TestMinettiEnergyCost_FlatAndClimb(t *testing.T) {
+	// THEN
+	if cost := MinettiEnergyCost(0); cost != 3.6 {
+		t.Fatalf("expected 3.6 J/kg/m on the flat, got %.3f", cost)
+	}
+	if cost := MinettiEnergyCost(0.1); math.Abs(cost-5.968) > 0.001 {
+		t.Fatalf("expected 5.968 J/kg/m at 10%%, got %.3f", cost)
+	}
+	if MinettiEnergyCost(0.9) != MinettiEnergyCost(0.45) {
+		t.Fatalf("expected grades to be clamped at 45%%")
+	}
+}
+
+func TestGradeAdjustedDistances_StretchesClimbs(t *testing.T) {
+	// WHEN
+	flat := climbStream(0).GradeAdjustedDistances()
+	climb := climbStream(10).GradeAdjustedDistances()
+
+	// THEN
+	if flat[len(flat)-1] != 1000 {
+		t.Fatalf("expected 1000 m on the flat, got %.1f", flat[len(flat)-1])
+	}
+	expected := 1000 * 5.968 / 3.6
+	if math.Abs(climb[len(climb)-1]-expected) > 1 {
+		t.Fatalf("expected %.0f flat-equivalent meters for 1 km at 10%%, got %.1f", expected, climb[len(climb)-1])
+	}
+}
+
+func TestGradeAdjustedSpeed_NeedsAltitudeOrGrade(t *testing.T) {
+	// GIVEN
+	stream := climbStream(10)
+	stream.Altitude = nil
+
+	// THEN
+	if speed := GradeAdjustedSpeed(1, stream); speed != 0 {
+		t.Fatalf("expected no grade-adjusted speed without altitude, got %.2f", speed)
+	}
+	if speed := GradeAdjustedSpeed(1, climbStream(10)); math.Abs(speed-5.968/3.6) > 0.01 {
+		t.Fatalf("expected %.2f m/s, got %.2f", 5.968/3.6, speed)
+	}
+}
@@ -0,0 +1,47 @@
+package strava
+
+// Lap mirrors the Strava lap object. StartIndex and EndIndex point into the
+// activity stream. FIT files also provide the lap trigger and the workout step
+// intensity, which tell manual laps, auto laps and interval steps apart.
+type Lap struct {
+	Id                 int64   `json:"id"`
+	Name               string  `json:"name"`
+	LapIndex           int     `json:"lap_index"`
+	StartIndex         int     `json:"start_index"`
+	EndIndex           int     `json:"end_index"`
+	StartDate          string  `json:"start_date"`
+	Distance           float64 `json:"distance"`
+	ElapsedTime        int     `json:"elapsed_time"`
+	MovingTime         int     `json:"moving_time"`
+	TotalElevationGain float64 `json:"total_elevation_gain"`
+	AverageSpeed       float64 `json:"average_speed"`
+	MaxSpeed           float64 `json:"max_speed"`
+	AverageHeartrate   float64 `json:"average_heartrate"`
+	MaxHeartrate       float64 `json:"max_heartrate"`
+	AverageWatts       float64 `json:"average_watts"`
+	AverageCadence     float64 `json:"average_cadence"`
+	Trigger            string  `json:"trigger,omitempty"`
+	Intensity          string  `json:"intensity,omitempty"`
+}
+
+// ActivitySession is one leg of a multisport recording, such as the swim,
+// transitions, bike and run of a triathlon. The parent activity holds the
+// whole recording; indexes point into its stream and laps.
+type ActivitySession struct {
+	SessionIndex       int     `json:"session_index"`
+	SportType          string  `json:"sport_type"`
+	StartDate          string  `json:"start_date"`
+	StartIndex         int     `json:"start_index"`
+	EndIndex           int     `json:"end_index"`
+	Distance           float64 `json:"distance"`
+	ElapsedTime        int     `json:"elapsed_time"`
+	MovingTime         int     `json:"moving_time"`
+	TotalElevationGain float64 `json:"total_elevation_gain"`
+	AverageSpeed       float64 `json:"average_speed"`
+	MaxSpeed           float64 `json:"max_speed"`
+	AverageHeartrate   float64 `json:"average_heartrate"`
+	MaxHeartrate       float64 `json:"max_heartrate"`
+	AverageWatts       float64 `json:"average_watts"`
+	FirstLapIndex      int     `json:"first_lap_index,omitempty"`
+	LapCount           int     `json:"lap_count,omitempty"`
+}
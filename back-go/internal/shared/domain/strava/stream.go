@@ -15,6 +15,16 @@ type Stream struct {
 	Watts          *PowerStream          `json:"watts,omitempty"`
 	VelocitySmooth *SmoothVelocityStream `json:"velocity_smooth,omitempty"`
 	GradeSmooth    *SmoothGradeStream    `json:"grade_smooth,omitempty"`
+
+	// Channels recorded by devices but rarely returned by Strava. They are
+	// filled from FIT records when present.
+	Temperature         *TemperatureStream         `json:"temp,omitempty"`
+	CoreTemperature     *TemperatureStream         `json:"core_temperature,omitempty"`
+	LeftRightBalance    *LeftRightBalanceStream    `json:"left_right_balance,omitempty"`
+	VerticalOscillation *VerticalOscillationStream `json:"vertical_oscillation,omitempty"`
+	GroundContactTime   *GroundContactTimeStream   `json:"ground_contact_time,omitempty"`
+	StrideLength        *StrideLengthStream        `json:"stride_length,omitempty"`
+	DeveloperFields     []DeveloperFieldStream     `json:"developer_fields,omitempty"`
 }
 
 type DistanceStream struct {
@@ -87,6 +97,62 @@ type SmoothGradeStream struct {
 	SeriesType   string    `json:"series_type"`
 }
 
+// TemperatureStream holds degrees Celsius.
+type TemperatureStream struct {
+	Data         []float64 `json:"data"`
+	OriginalSize int       `json:"original_size"`
+	Resolution   string    `json:"resolution"`
+	SeriesType   string    `json:"series_type"`
+}
+
+// LeftRightBalanceStream holds the left leg share of power in percent. Zero
+// means the device did not tell which side it measured.
+type LeftRightBalanceStream struct {
+	Data         []float64 `json:"data"`
+	OriginalSize int       `json:"original_size"`
+	Resolution   string    `json:"resolution"`
+	SeriesType   string    `json:"series_type"`
+}
+
+// VerticalOscillationStream holds millimeters.
+type VerticalOscillationStream struct {
+	Data         []float64 `json:"data"`
+	OriginalSize int       `json:"original_size"`
+	Resolution   string    `json:"resolution"`
+	SeriesType   string    `json:"series_type"`
+}
+
+// GroundContactTimeStream holds milliseconds.
+type GroundContactTimeStream struct {
+	Data         []float64 `json:"data"`
+	OriginalSize int       `json:"original_size"`
+	Resolution   string    `json:"resolution"`
+	SeriesType   string    `json:"series_type"`
+}
+
+// StrideLengthStream holds meters.
+type StrideLengthStream struct {
+	Data         []float64 `json:"data"`
+	OriginalSize int       `json:"original_size"`
+	Resolution   string    `json:"resolution"`
+	SeriesType   string    `json:"series_type"`
+}
+
+// DeveloperFieldStream holds the values of one FIT developer field, such as
+// Stryd power or a Connect IQ data field, already scaled to Units.
+// NativeField names the standard record field it stands in for, if any.
+type DeveloperFieldStream struct {
+	Name               string    `json:"name"`
+	Units              string    `json:"units,omitempty"`
+	NativeField        string    `json:"native_field,omitempty"`
+	DeveloperDataIndex int       `json:"developer_data_index"`
+	FieldNumber        int       `json:"field_number"`
+	Data               []float64 `json:"data"`
+	OriginalSize       int       `json:"original_size"`
+	Resolution         string    `json:"resolution"`
+	SeriesType         string    `json:"series_type"`
+}
+
 type GpxPoint struct {
 	Latitude  float64
 	Longitude float64
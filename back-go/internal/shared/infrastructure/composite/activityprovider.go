@@ -18,6 +18,8 @@ const (
 	sourceStrava = "strava"
 	sourceFIT    = "fit"
 	sourceGPX    = "gpx"
+	sourceTCX    = "tcx"
+	sourceJSON   = "json"
 )
 
 const (
@@ -96,6 +98,9 @@ type CompositeActivityProvider struct {
 	cacheMutex                sync.RWMutex
 	diagnostics               compositeDiagnostics
 	sourceSignatures          map[string]string
+	overridesPath             string
+	overridesMutex            sync.Mutex
+	overrides                 business.SourceMergeOverrides
 }
 
 type sourceActivity struct {
@@ -111,6 +116,10 @@ type activityMatchMetadata struct {
 
 type activityCluster struct {
 	items []sourceActivity
+	// locked clusters hold a split source activity and accept no automatic match.
+	locked bool
+	// manual clusters were assembled from a user link.
+	manual bool
 }
 
 type compositeRecord struct {
@@ -121,11 +130,14 @@ type compositeRecord struct {
 	StreamProvider  string
 	Confidence      string
 	Conflicts       []MergeConflict
+	// FieldSources lists the fields taken from a pinned source.
+	FieldSources map[string]string
 }
 
 type compositeDiagnostics struct {
 	MatchedActivities   int
 	LocalOnlyActivities int
+	ManualActivities    int
 	ConflictCount       int
 	ConflictSamples     []MergeConflict
 	SourceSummaries     []map[string]any
@@ -150,6 +162,8 @@ func NewCompositeActivityProvider(sources []Source) *CompositeActivityProvider {
 	for index, source := range cleanSources {
 		provider.sourcePriority[source.Name] = index
 	}
+	provider.overridesPath = mergeOverridesPath(cleanSources)
+	provider.overrides = loadMergeOverrides(provider.overridesPath)
 	if len(cleanSources) > 0 {
 		provider.athlete = cleanSources[0].Provider.GetAthlete()
 		provider.heartRateSettingsSource = cleanSources[0].Provider
@@ -161,6 +175,8 @@ func NewCompositeActivityProvider(sources []Source) *CompositeActivityProvider {
 }
 
 func (provider *CompositeActivityProvider) rebuild() {
+	overrides := provider.mergeOverridesSnapshot()
+	splitKeys := splitSourceKeys(overrides)
 	clusters := make([]activityCluster, 0)
 	sourceSummaries := make([]map[string]any, 0, len(provider.sources))
 	sourceSignatures := make(map[string]string, len(provider.sources))
@@ -194,8 +210,15 @@ func (provider *CompositeActivityProvider) rebuild() {
 				activity: activity,
 				match:    activityMatchMetadataFor(activity),
 			}
+			_, split := splitKeys[item.key()]
 			bestIndex := -1
 			for index := range clusters {
+				if split {
+					break
+				}
+				if clusters[index].locked {
+					continue
+				}
 				if sourceActivitiesMatch(clusters[index].items[0], item) {
 					bestIndex = index
 					break
@@ -204,22 +227,27 @@ func (provider *CompositeActivityProvider) rebuild() {
 			if bestIndex >= 0 {
 				clusters[bestIndex].items = append(clusters[bestIndex].items, item)
 			} else {
-				clusters = append(clusters, activityCluster{items: []sourceActivity{item}})
+				clusters = append(clusters, activityCluster{items: []sourceActivity{item}, locked: split})
 			}
 		}
 	}
+	clusters = applyMergeLinks(clusters, overrides.Links)
 
 	records := make([]compositeRecord, 0, len(clusters))
 	conflictSamples := make([]MergeConflict, 0)
 	conflictCount := 0
 	matchedActivities := 0
 	localOnlyActivities := 0
+	manualActivities := 0
 
 	for _, cluster := range clusters {
-		record := provider.mergeCluster(cluster)
+		record := provider.mergeCluster(cluster, clusterFieldSources(cluster, overrides))
 		if len(record.Sources) > 1 {
 			matchedActivities++
 		}
+		if cluster.manual || cluster.locked {
+			manualActivities++
+		}
 		if !recordHasSource(record, sourceStrava) {
 			localOnlyActivities++
 		}
@@ -252,6 +280,7 @@ func (provider *CompositeActivityProvider) rebuild() {
 	provider.diagnostics = compositeDiagnostics{
 		MatchedActivities:   matchedActivities,
 		LocalOnlyActivities: localOnlyActivities,
+		ManualActivities:    manualActivities,
 		ConflictCount:       conflictCount,
 		ConflictSamples:     conflictSamples,
 		SourceSummaries:     sourceSummaries,
@@ -264,7 +293,7 @@ func (provider *CompositeActivityProvider) rebuild() {
 	provider.cacheMutex.Unlock()
 }
 
-func (provider *CompositeActivityProvider) mergeCluster(cluster activityCluster) compositeRecord {
+func (provider *CompositeActivityProvider) mergeCluster(cluster activityCluster, fieldSources map[string]string) compositeRecord {
 	sort.SliceStable(cluster.items, func(i, j int) bool {
 		left := provider.sourcePriority[cluster.items[i].source.Name]
 		right := provider.sourcePriority[cluster.items[j].source.Name]
@@ -274,11 +303,22 @@ func (provider *CompositeActivityProvider) mergeCluster(cluster activityCluster)
 	primary := cluster.items[0]
 	activity := cloneActivity(primary.activity)
 	stream, streamProvider := bestStreamWithProvider(cluster.items)
+	if pinned, ok := pinnedStreamWithProvider(cluster.items, fieldSources[business.SourceMergeFieldStream]); ok {
+		stream, streamProvider = pinned, fieldSources[business.SourceMergeFieldStream]
+	}
 	activity.Stream = stream
+	if laps, sessions, ok := lapsWithStream(cluster.items, streamProvider); ok {
+		activity.Laps, activity.Sessions = laps, sessions
+	}
 
 	for _, item := range cluster.items[1:] {
 		activity = enrichMissingFields(activity, item.activity)
 	}
+	appliedFields := applyFieldSources(activity, cluster.items, fieldSources)
+	confidence := confidenceForCluster(cluster)
+	if cluster.manual {
+		confidence = "manual"
+	}
 
 	conflicts := make([]MergeConflict, 0)
 	for _, item := range cluster.items[1:] {
@@ -291,8 +331,9 @@ func (provider *CompositeActivityProvider) mergeCluster(cluster activityCluster)
 		PrimaryID:       primary.activity.Id,
 		Sources:         sourceRefs(cluster.items),
 		StreamProvider:  streamProvider,
-		Confidence:      confidenceForCluster(cluster),
+		Confidence:      confidence,
 		Conflicts:       conflicts,
+		FieldSources:    appliedFields,
 	}
 }
 
@@ -310,7 +351,7 @@ func (provider *CompositeActivityProvider) GetDetailedActivity(activityID int64)
 	if detailed == nil {
 		detailed = record.Activity.ToStravaDetailedActivity()
 	}
-	return attachSourceProvenance(enrichDetailedActivity(detailed, record.Activity), record)
+	return attachSourceProvenance(enrichDetailedActivity(detailed, record), record)
 }
 
 func (provider *CompositeActivityProvider) GetCachedDetailedActivity(activityID int64) *strava.DetailedActivity {
@@ -327,7 +368,7 @@ func (provider *CompositeActivityProvider) GetCachedDetailedActivity(activityID
 	if detailed == nil {
 		return provider.GetDetailedActivity(activityID)
 	}
-	return attachSourceProvenance(enrichDetailedActivity(detailed, record.Activity), record)
+	return attachSourceProvenance(enrichDetailedActivity(detailed, record), record)
 }
 
 func (provider *CompositeActivityProvider) GetActivitiesByYearAndActivityTypes(year *int, activityTypes ...business.ActivityType) []*strava.Activity {
@@ -430,9 +471,10 @@ func (provider *CompositeActivityProvider) CacheDiagnostics() map[string]any {
 			"sources":             diagnostics.SourceSummaries,
 			"matchedActivities":   diagnostics.MatchedActivities,
 			"localOnlyActivities": diagnostics.LocalOnlyActivities,
+			"manualActivities":    diagnostics.ManualActivities,
 			"conflictCount":       diagnostics.ConflictCount,
 			"conflictSamples":     diagnostics.ConflictSamples,
-			"futureProviders":     []string{"ridewithgps", "tcx"},
+			"futureProviders":     []string{"ridewithgps"},
 		},
 	}
 }
@@ -462,6 +504,24 @@ func (provider *CompositeActivityProvider) Reload() {
 	provider.rebuild()
 }
 
+// ReloadFromCache rereads every source and the merge overrides from disk,
+// without calling Strava.
+func (provider *CompositeActivityProvider) ReloadFromCache() {
+	for _, source := range provider.sources {
+		switch reloadable := source.Provider.(type) {
+		case interface{ ReloadFromCache() }:
+			reloadable.ReloadFromCache()
+		case interface{ Reload() }:
+			reloadable.Reload()
+		}
+	}
+	overrides := loadMergeOverrides(provider.overridesPath)
+	provider.overridesMutex.Lock()
+	provider.overrides = overrides
+	provider.overridesMutex.Unlock()
+	provider.rebuild()
+}
+
 func (provider *CompositeActivityProvider) refreshIfSourceDataChanged() {
 	currentSignatures := make(map[string]string, len(provider.sources))
 	for _, source := range provider.sources {
@@ -507,9 +567,10 @@ func diagnosticBool(value any) bool {
 
 func sourceDataSignature(diagnostics map[string]any) string {
 	return fmt.Sprintf(
-		"activities=%v|years=%s",
+		"activities=%v|years=%s|revision=%v",
 		diagnostics["activities"],
 		diagnosticListSignature(diagnostics["availableYearBins"]),
+		diagnostics["dataRevision"],
 	)
 }
 
@@ -561,6 +622,16 @@ func (provider *CompositeActivityProvider) record(activityID int64) (compositeRe
 	return record, ok
 }
 
+// ActivitySources returns the per-source activities merged into activityID.
+func (provider *CompositeActivityProvider) ActivitySources(activityID int64) ([]ActivitySourceRef, bool) {
+	provider.refreshIfSourceDataChanged()
+	record, ok := provider.record(activityID)
+	if !ok {
+		return nil, false
+	}
+	return append([]ActivitySourceRef(nil), record.Sources...), true
+}
+
 func (provider *CompositeActivityProvider) getActivitiesSnapshot() []*strava.Activity {
 	provider.dataMutex.RLock()
 	defer provider.dataMutex.RUnlock()
@@ -576,6 +647,15 @@ func (provider *CompositeActivityProvider) sourceByName(name string) *Source {
 	return nil
 }
 
+// SourceProvider returns the provider registered under name, or nil.
+func (provider *CompositeActivityProvider) SourceProvider(name string) SourceProvider {
+	source := provider.sourceByName(normalizeSourceName(name))
+	if source == nil {
+		return nil
+	}
+	return source.Provider
+}
+
 func activitiesMatch(left *strava.Activity, right *strava.Activity) bool {
 	if left == nil || right == nil {
 		return false
@@ -770,6 +850,30 @@ func bestStreamWithProvider(items []sourceActivity) (*strava.Stream, string) {
 	return best, bestProvider
 }
 
+// pinnedStreamWithProvider returns the stream of the pinned source, if it has one.
+func pinnedStreamWithProvider(items []sourceActivity, sourceName string) (*strava.Stream, bool) {
+	if sourceName == "" {
+		return nil, false
+	}
+	for _, item := range items {
+		if item.source.Name == sourceName && item.activity.Stream != nil {
+			return item.activity.Stream, true
+		}
+	}
+	return nil, false
+}
+
+// lapsWithStream returns the laps and sessions of the stream source: their
+// indexes point into that stream only.
+func lapsWithStream(items []sourceActivity, sourceName string) ([]strava.Lap, []strava.ActivitySession, bool) {
+	for _, item := range items {
+		if item.source.Name == sourceName {
+			return item.activity.Laps, item.activity.Sessions, true
+		}
+	}
+	return nil, nil, false
+}
+
 func streamScore(source string, stream *strava.Stream) int {
 	if stream == nil {
 		return 0
@@ -793,6 +897,10 @@ func streamScore(source string, stream *strava.Stream) int {
 	switch source {
 	case sourceFIT:
 		score += 500
+	case sourceTCX:
+		score += 400
+	case sourceJSON:
+		score += 300
 	case sourceGPX:
 		score += 250
 	}
@@ -839,14 +947,20 @@ func enrichMissingFields(primary *strava.Activity, other *strava.Activity) *stra
 	return primary
 }
 
-func enrichDetailedActivity(detailed *strava.DetailedActivity, activity *strava.Activity) *strava.DetailedActivity {
+func enrichDetailedActivity(detailed *strava.DetailedActivity, record compositeRecord) *strava.DetailedActivity {
+	activity := record.Activity
 	if detailed == nil || activity == nil {
 		return detailed
 	}
 	enriched := *detailed
+	applyPinnedDetailedFields(&enriched, record)
 	if activity.Stream != nil {
 		enriched.Stream = activity.Stream
 	}
+	if record.StreamProvider != "" && record.StreamProvider != record.PrimaryProvider {
+		enriched.Laps = activity.Laps
+		enriched.Sessions = activity.Sessions
+	}
 	if enriched.AverageCadence == 0 {
 		enriched.AverageCadence = activity.AverageCadence
 	}
@@ -888,6 +1002,9 @@ func attachSourceProvenance(detailed *strava.DetailedActivity, record compositeR
 			"detailedStream": streamProvider,
 		},
 	}
+	for field, source := range record.FieldSources {
+		enriched.Source.FieldSources[field] = source
+	}
 	return &enriched
 }
 
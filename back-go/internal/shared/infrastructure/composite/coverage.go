@@ -0,0 +1,127 @@
+package composite
+
+import (
+	"sort"
+	"strconv"
+
+	"mystravastats/internal/shared/domain/business"
+)
+
+// ActivitySourcesByID returns the per-source activities of every composite
+// activity. The slices are shared with the provider and must not be modified.
+func (provider *CompositeActivityProvider) ActivitySourcesByID() map[int64][]ActivitySourceRef {
+	provider.refreshIfSourceDataChanged()
+	provider.dataMutex.RLock()
+	defer provider.dataMutex.RUnlock()
+	sources := make(map[int64][]ActivitySourceRef, len(provider.recordsByActivityID))
+	for activityID, record := range provider.recordsByActivityID {
+		sources[activityID] = record.Sources
+	}
+	return sources
+}
+
+// SourceCoverage reports, per year, the activities present in one source but
+// not in another. A nil year covers every year.
+func (provider *CompositeActivityProvider) SourceCoverage(year *int) business.SourceCoverageReport {
+	provider.refreshIfSourceDataChanged()
+
+	sourceNames := make([]string, 0, len(provider.sources))
+	for _, source := range provider.sources {
+		sourceNames = append(sourceNames, source.Name)
+	}
+
+	provider.dataMutex.RLock()
+	records := make([]compositeRecord, 0, len(provider.activities))
+	for _, activity := range filterActivitiesByYear(provider.activities, year) {
+		if record, ok := provider.recordsByActivityID[activity.Id]; ok {
+			records = append(records, record)
+		}
+	}
+	provider.dataMutex.RUnlock()
+
+	// provider.activities is sorted by start date, most recent first, and the
+	// report keeps that order for years and gap activities.
+	years := make([]business.SourceCoverageYear, 0)
+	yearIndex := make(map[string]int)
+	for _, record := range records {
+		activityYear := extractYear(record.Activity.StartDateLocal)
+		if _, err := strconv.Atoi(activityYear); err != nil {
+			continue
+		}
+		index, ok := yearIndex[activityYear]
+		if !ok {
+			index = len(years)
+			yearIndex[activityYear] = index
+			years = append(years, newSourceCoverageYear(activityYear, sourceNames))
+		}
+		addToSourceCoverage(&years[index], record)
+	}
+	for index := range years {
+		gaps := years[index].Gaps[:0]
+		for _, gap := range years[index].Gaps {
+			if gap.Count > 0 {
+				gaps = append(gaps, gap)
+			}
+		}
+		years[index].Gaps = gaps
+	}
+	sort.SliceStable(years, func(i, j int) bool { return years[i].Year > years[j].Year })
+
+	return business.SourceCoverageReport{
+		Sources: sourceNames,
+		Years:   years,
+	}
+}
+
+func newSourceCoverageYear(year string, sourceNames []string) business.SourceCoverageYear {
+	coverage := business.SourceCoverageYear{
+		Year:     year,
+		BySource: make(map[string]int, len(sourceNames)),
+		Gaps:     make([]business.SourceCoverageGap, 0, len(sourceNames)*(len(sourceNames)-1)),
+	}
+	for _, source := range sourceNames {
+		coverage.BySource[source] = 0
+		for _, other := range sourceNames {
+			if other != source {
+				coverage.Gaps = append(coverage.Gaps, business.SourceCoverageGap{
+					Source:      source,
+					MissingFrom: other,
+					Activities:  []business.SourceCoverageActivity{},
+				})
+			}
+		}
+	}
+	return coverage
+}
+
+func addToSourceCoverage(coverage *business.SourceCoverageYear, record compositeRecord) {
+	coverage.Activities++
+	if len(record.Sources) > 1 {
+		coverage.Merged++
+	}
+	refsBySource := make(map[string]ActivitySourceRef, len(record.Sources))
+	for _, ref := range record.Sources {
+		if _, ok := refsBySource[ref.Provider]; !ok {
+			refsBySource[ref.Provider] = ref
+		}
+	}
+	for source := range refsBySource {
+		coverage.BySource[source]++
+	}
+	for index := range coverage.Gaps {
+		gap := &coverage.Gaps[index]
+		ref, present := refsBySource[gap.Source]
+		if _, missing := refsBySource[gap.MissingFrom]; !present || missing {
+			continue
+		}
+		gap.Count++
+		gap.Activities = append(gap.Activities, business.SourceCoverageActivity{
+			ActivityID:       record.Activity.Id,
+			SourceActivityID: ref.ActivityID,
+			Name:             record.Activity.Name,
+			SportType:        record.Activity.SportType,
+			StartDateLocal:   record.Activity.StartDateLocal,
+			Distance:         record.Activity.Distance,
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package composite
+
+import (
+	"testing"
+
+	"mystravastats/internal/shared/domain/strava"
+)
+
+func TestSourceCoverage_ListsActivitiesMissingFromEachSourcePerYear(t *testing.T) {
+	// GIVEN
+	stravaMatched := testActivity(1001, "matched ride", "Ride", "2025-04-12T08:00:00Z", 40000, 5400, nil)
+	stravaOnly := testActivity(1002, "strava only ride", "Ride", "2026-03-01T08:00:00Z", 30000, 4000, nil)
+	fitMatched := testActivity(2001, "fit ride", "Ride", "2025-04-12T08:00:20Z", 40050, 5390, testStream(20))
+	fitOnly := testActivity(2002, "fit only run", "Run", "2026-03-08T07:00:00Z", 10000, 3000, testStream(20))
+	provider := NewCompositeActivityProvider([]Source{
+		{Name: "strava", Provider: testProvider{name: "strava", activities: []*strava.Activity{stravaMatched, stravaOnly}}},
+		{Name: "fit", Provider: testProvider{name: "fit", activities: []*strava.Activity{fitMatched, fitOnly}}},
+	})
+
+	// WHEN
+	report := provider.SourceCoverage(nil)
+
+	// THEN
+	if len(report.Sources) != 2 || report.Sources[0] != "strava" || report.Sources[1] != "fit" {
+		t.Fatalf("expected strava and fit sources, got %v", report.Sources)
+	}
+	if len(report.Years) != 2 || report.Years[0].Year != "2026" || report.Years[1].Year != "2025" {
+		t.Fatalf("expected 2026 then 2025, got %#v", report.Years)
+	}
+	recent := report.Years[0]
+	if recent.Activities != 2 || recent.Merged != 0 || recent.BySource["strava"] != 1 || recent.BySource["fit"] != 1 {
+		t.Fatalf("expected one activity per source in 2026, got %#v", recent)
+	}
+	if len(recent.Gaps) != 2 {
+		t.Fatalf("expected a gap in each direction, got %#v", recent.Gaps)
+	}
+	for _, gap := range recent.Gaps {
+		expectedID := int64(1002)
+		if gap.Source == "fit" {
+			expectedID = 2002
+		}
+		if gap.Count != 1 || len(gap.Activities) != 1 || gap.Activities[0].SourceActivityID != expectedID {
+			t.Fatalf("expected %s activity %d missing from %s, got %#v", gap.Source, expectedID, gap.MissingFrom, gap)
+		}
+	}
+	previous := report.Years[1]
+	if previous.Activities != 1 || previous.Merged != 1 || len(previous.Gaps) != 0 {
+		t.Fatalf("expected the matched 2025 activity to leave no gap, got %#v", previous)
+	}
+}
+
+func TestSourceCoverage_FiltersYear(t *testing.T) {
+	// GIVEN
+	provider := NewCompositeActivityProvider([]Source{
+		{Name: "strava", Provider: testProvider{name: "strava", activities: []*strava.Activity{
+			testActivity(1001, "old ride", "Ride", "2024-04-12T08:00:00Z", 40000, 5400, nil),
+			testActivity(1002, "new ride", "Ride", "2026-03-01T08:00:00Z", 30000, 4000, nil),
+		}}},
+		{Name: "gpx", Provider: testProvider{name: "gpx"}},
+	})
+	year := 2024
+
+	// WHEN
+	report := provider.SourceCoverage(&year)
+
+	// THEN
+	if len(report.Years) != 1 || report.Years[0].Year != "2024" {
+		t.Fatalf("expected only 2024, got %#v", report.Years)
+	}
+	if gaps := report.Years[0].Gaps; len(gaps) != 1 || gaps[0].Source != "strava" || gaps[0].MissingFrom != "gpx" {
+		t.Fatalf("expected the Strava ride missing from GPX, got %#v", gaps)
+	}
+}
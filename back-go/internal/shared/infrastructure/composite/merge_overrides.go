@@ -0,0 +1,542 @@
+package composite
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"mystravastats/internal/platform/cachewrite"
+	"mystravastats/internal/shared/domain/business"
+	"mystravastats/internal/shared/domain/strava"
+)
+
+const (
+	mergeOverridesFileName = "composite-merge-overrides.json"
+	mergeOverridesDirMode  = 0700
+	mergeOverridesFileMode = 0600
+)
+
+// mergeOverridesPath stores the overrides next to the cache of the first
+// source, which is the Strava cache whenever Strava is configured.
+func mergeOverridesPath(sources []Source) string {
+	if len(sources) == 0 {
+		return ""
+	}
+	root := strings.TrimSpace(sources[0].Provider.CacheRootPath())
+	if root == "" {
+		return ""
+	}
+	return filepath.Join(root, fmt.Sprintf("strava-%s", sources[0].Provider.ClientID()), mergeOverridesFileName)
+}
+
+func loadMergeOverrides(path string) business.SourceMergeOverrides {
+	overrides := business.SourceMergeOverrides{}
+	if path == "" {
+		return normalizeMergeOverrides(overrides)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("Unable to read composite merge overrides from %s: %v", path, err)
+		}
+		return normalizeMergeOverrides(overrides)
+	}
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		log.Printf("Unable to parse composite merge overrides from %s: %v", path, err)
+		return normalizeMergeOverrides(business.SourceMergeOverrides{})
+	}
+	return normalizeMergeOverrides(overrides)
+}
+
+func saveMergeOverrides(path string, overrides business.SourceMergeOverrides) error {
+	if path == "" {
+		return fmt.Errorf("no source cache available to store merge overrides")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), mergeOverridesDirMode); err != nil {
+		return fmt.Errorf("unable to create merge overrides directory: %w", err)
+	}
+	data, err := json.MarshalIndent(overrides, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode merge overrides: %w", err)
+	}
+	if err := cachewrite.WriteFile(path, data, mergeOverridesFileMode); err != nil {
+		return fmt.Errorf("unable to write merge overrides: %w", err)
+	}
+	return nil
+}
+
+// SourceMergeOverrides returns the manual links, splits and field pins.
+func (provider *CompositeActivityProvider) SourceMergeOverrides() business.SourceMergeOverrides {
+	return provider.mergeOverridesSnapshot()
+}
+
+// LinkSourceActivities merges the given source activities into one composite
+// activity. Links sharing an activity with the new one are absorbed into it.
+func (provider *CompositeActivityProvider) LinkSourceActivities(sources []business.SourceActivityKey) (business.SourceMergeOverrides, error) {
+	keys := make([]business.SourceActivityKey, 0, len(sources))
+	for _, source := range sources {
+		key, err := provider.validateSourceActivityKey(source)
+		if err != nil {
+			return business.SourceMergeOverrides{}, err
+		}
+		if !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) < 2 {
+		return business.SourceMergeOverrides{}, fmt.Errorf("a link needs at least two distinct source activities")
+	}
+
+	return provider.updateMergeOverrides(func(overrides *business.SourceMergeOverrides) error {
+		links := make([]business.SourceMergeLink, 0, len(overrides.Links)+1)
+		for _, link := range overrides.Links {
+			if !linkSharesSource(link, keys) {
+				links = append(links, link)
+				continue
+			}
+			for _, key := range link.Sources {
+				if !slices.Contains(keys, key) {
+					keys = append(keys, key)
+				}
+			}
+		}
+		overrides.Links = append(links, business.SourceMergeLink{
+			Sources:   keys,
+			CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		})
+		overrides.Splits = slices.DeleteFunc(overrides.Splits, func(split business.SourceMergeSplit) bool {
+			return slices.Contains(keys, split.Source)
+		})
+		return nil
+	})
+}
+
+// SplitSourceActivity detaches a source activity from its composite activity
+// and keeps it out of automatic matching.
+func (provider *CompositeActivityProvider) SplitSourceActivity(source business.SourceActivityKey) (business.SourceMergeOverrides, error) {
+	key, err := provider.validateSourceActivityKey(source)
+	if err != nil {
+		return business.SourceMergeOverrides{}, err
+	}
+
+	return provider.updateMergeOverrides(func(overrides *business.SourceMergeOverrides) error {
+		overrides.Links = removeSourcesFromLinks(overrides.Links, []business.SourceActivityKey{key})
+		for _, split := range overrides.Splits {
+			if split.Source == key {
+				return nil
+			}
+		}
+		overrides.Splits = append(overrides.Splits, business.SourceMergeSplit{
+			Source:    key,
+			CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		})
+		return nil
+	})
+}
+
+// SetDefaultFieldSources replaces the field sources used for every composite
+// activity without its own pins.
+func (provider *CompositeActivityProvider) SetDefaultFieldSources(fields map[string]string) (business.SourceMergeOverrides, error) {
+	normalized, err := provider.validateFieldSources(fields)
+	if err != nil {
+		return business.SourceMergeOverrides{}, err
+	}
+	return provider.updateMergeOverrides(func(overrides *business.SourceMergeOverrides) error {
+		overrides.FieldSources = normalized
+		return nil
+	})
+}
+
+// SetActivityFieldSources pins field sources for one composite activity. An
+// empty map removes its pins.
+func (provider *CompositeActivityProvider) SetActivityFieldSources(activityID int64, fields map[string]string) (business.SourceMergeOverrides, error) {
+	record, ok := provider.record(activityID)
+	if !ok {
+		return business.SourceMergeOverrides{}, fmt.Errorf("activity %d not found", activityID)
+	}
+	normalized, err := provider.validateFieldSources(fields)
+	if err != nil {
+		return business.SourceMergeOverrides{}, err
+	}
+	recordKeys := recordSourceKeys(record)
+
+	return provider.updateMergeOverrides(func(overrides *business.SourceMergeOverrides) error {
+		overrides.ActivityFieldSources = slices.DeleteFunc(overrides.ActivityFieldSources, func(pin business.SourceMergeFieldPin) bool {
+			return slices.Contains(recordKeys, pin.Source)
+		})
+		if len(normalized) > 0 {
+			overrides.ActivityFieldSources = append(overrides.ActivityFieldSources, business.SourceMergeFieldPin{
+				Source: business.SourceActivityKey{Provider: record.PrimaryProvider, ActivityID: record.PrimaryID},
+				Fields: normalized,
+			})
+		}
+		return nil
+	})
+}
+
+// ResetActivityMerge drops every link, split and field pin involving the
+// sources of a composite activity, restoring automatic matching.
+func (provider *CompositeActivityProvider) ResetActivityMerge(activityID int64) (business.SourceMergeOverrides, error) {
+	record, ok := provider.record(activityID)
+	if !ok {
+		return business.SourceMergeOverrides{}, fmt.Errorf("activity %d not found", activityID)
+	}
+	recordKeys := recordSourceKeys(record)
+
+	return provider.updateMergeOverrides(func(overrides *business.SourceMergeOverrides) error {
+		overrides.Links = slices.DeleteFunc(overrides.Links, func(link business.SourceMergeLink) bool {
+			return linkSharesSource(link, recordKeys)
+		})
+		overrides.Splits = slices.DeleteFunc(overrides.Splits, func(split business.SourceMergeSplit) bool {
+			return slices.Contains(recordKeys, split.Source)
+		})
+		overrides.ActivityFieldSources = slices.DeleteFunc(overrides.ActivityFieldSources, func(pin business.SourceMergeFieldPin) bool {
+			return slices.Contains(recordKeys, pin.Source)
+		})
+		return nil
+	})
+}
+
+func (provider *CompositeActivityProvider) updateMergeOverrides(update func(overrides *business.SourceMergeOverrides) error) (business.SourceMergeOverrides, error) {
+	provider.overridesMutex.Lock()
+	overrides := cloneMergeOverrides(provider.overrides)
+	if err := update(&overrides); err != nil {
+		provider.overridesMutex.Unlock()
+		return business.SourceMergeOverrides{}, err
+	}
+	overrides = normalizeMergeOverrides(overrides)
+	if err := saveMergeOverrides(provider.overridesPath, overrides); err != nil {
+		provider.overridesMutex.Unlock()
+		return business.SourceMergeOverrides{}, err
+	}
+	provider.overrides = overrides
+	provider.overridesMutex.Unlock()
+
+	provider.rebuild()
+	return cloneMergeOverrides(overrides), nil
+}
+
+func (provider *CompositeActivityProvider) mergeOverridesSnapshot() business.SourceMergeOverrides {
+	provider.overridesMutex.Lock()
+	defer provider.overridesMutex.Unlock()
+	return cloneMergeOverrides(provider.overrides)
+}
+
+func (provider *CompositeActivityProvider) validateSourceActivityKey(source business.SourceActivityKey) (business.SourceActivityKey, error) {
+	key := business.SourceActivityKey{Provider: normalizeSourceName(source.Provider), ActivityID: source.ActivityID}
+	if provider.sourceByName(key.Provider) == nil {
+		return key, fmt.Errorf("unknown source provider %q", source.Provider)
+	}
+	if key.ActivityID <= 0 {
+		return key, fmt.Errorf("activityId must be > 0")
+	}
+	provider.dataMutex.RLock()
+	defer provider.dataMutex.RUnlock()
+	for _, record := range provider.recordsByActivityID {
+		if slices.Contains(recordSourceKeys(record), key) {
+			return key, nil
+		}
+	}
+	return key, fmt.Errorf("%s activity %d not found", key.Provider, key.ActivityID)
+}
+
+func (provider *CompositeActivityProvider) validateFieldSources(fields map[string]string) (map[string]string, error) {
+	normalized := make(map[string]string, len(fields))
+	for field, source := range fields {
+		if !slices.Contains(business.SourceMergeFields, field) {
+			return nil, fmt.Errorf("unknown field %q, expected one of %s", field, strings.Join(business.SourceMergeFields, ", "))
+		}
+		source = normalizeSourceName(source)
+		if source == "" {
+			continue
+		}
+		if provider.sourceByName(source) == nil {
+			return nil, fmt.Errorf("unknown source provider %q for field %s", source, field)
+		}
+		normalized[field] = source
+	}
+	return normalized, nil
+}
+
+// applyMergeLinks joins the clusters holding linked source activities.
+func applyMergeLinks(clusters []activityCluster, links []business.SourceMergeLink) []activityCluster {
+	if len(links) == 0 {
+		return clusters
+	}
+	clusterByKey := make(map[business.SourceActivityKey]int)
+	for index, cluster := range clusters {
+		for _, item := range cluster.items {
+			clusterByKey[item.key()] = index
+		}
+	}
+	for _, link := range links {
+		target := -1
+		for _, key := range link.Sources {
+			index, ok := clusterByKey[key]
+			if !ok {
+				continue
+			}
+			if target < 0 {
+				target = index
+				clusters[target].manual = true
+				continue
+			}
+			if index == target {
+				continue
+			}
+			for _, item := range clusters[index].items {
+				clusterByKey[item.key()] = target
+			}
+			clusters[target].items = append(clusters[target].items, clusters[index].items...)
+			clusters[index].items = nil
+		}
+	}
+	return slices.DeleteFunc(clusters, func(cluster activityCluster) bool {
+		return len(cluster.items) == 0
+	})
+}
+
+// clusterFieldSources resolves the pinned field sources of a cluster: the
+// defaults, overridden by the pin of any of its source activities.
+func clusterFieldSources(cluster activityCluster, overrides business.SourceMergeOverrides) map[string]string {
+	fields := make(map[string]string, len(overrides.FieldSources))
+	for field, source := range overrides.FieldSources {
+		fields[field] = source
+	}
+	for _, pin := range overrides.ActivityFieldSources {
+		for _, item := range cluster.items {
+			if item.key() != pin.Source {
+				continue
+			}
+			for field, source := range pin.Fields {
+				fields[field] = source
+			}
+		}
+	}
+	return fields
+}
+
+// applyFieldSources copies pinned fields from the winning source activity and
+// returns the fields actually taken from a pinned source.
+func applyFieldSources(activity *strava.Activity, items []sourceActivity, fields map[string]string) map[string]string {
+	applied := make(map[string]string)
+	for _, field := range business.SourceMergeFields {
+		sourceName, ok := fields[field]
+		if !ok || field == business.SourceMergeFieldStream {
+			continue
+		}
+		for _, item := range items {
+			if item.source.Name == sourceName {
+				copyActivityField(activity, item.activity, field)
+				applied[field] = sourceName
+				break
+			}
+		}
+	}
+	return applied
+}
+
+func copyActivityField(target *strava.Activity, source *strava.Activity, field string) {
+	switch field {
+	case business.SourceMergeFieldName:
+		target.Name = source.Name
+	case business.SourceMergeFieldSportType:
+		target.Type = source.Type
+		target.SportType = source.SportType
+	case business.SourceMergeFieldDescription:
+		target.Description = source.Description
+	case business.SourceMergeFieldGear:
+		target.GearId = source.GearId
+	case business.SourceMergeFieldCommute:
+		target.Commute = source.Commute
+	case business.SourceMergeFieldDistance:
+		target.Distance = source.Distance
+		target.AverageSpeed = source.AverageSpeed
+		target.MaxSpeed = source.MaxSpeed
+	case business.SourceMergeFieldDuration:
+		target.MovingTime = source.MovingTime
+		target.ElapsedTime = source.ElapsedTime
+	case business.SourceMergeFieldElevation:
+		target.TotalElevationGain = source.TotalElevationGain
+		target.ElevHigh = source.ElevHigh
+	case business.SourceMergeFieldHeartRate:
+		target.AverageHeartrate = source.AverageHeartrate
+		target.MaxHeartrate = source.MaxHeartrate
+	case business.SourceMergeFieldPower:
+		target.AverageWatts = source.AverageWatts
+		target.WeightedAverageWatts = source.WeightedAverageWatts
+		target.Kilojoules = source.Kilojoules
+		target.DeviceWatts = source.DeviceWatts
+	case business.SourceMergeFieldCadence:
+		target.AverageCadence = source.AverageCadence
+	}
+}
+
+// applyPinnedDetailedFields carries the pinned summary fields of the merged
+// activity over the detailed activity of the primary source.
+func applyPinnedDetailedFields(detailed *strava.DetailedActivity, record compositeRecord) {
+	activity := record.Activity
+	for field := range record.FieldSources {
+		switch field {
+		case business.SourceMergeFieldName:
+			detailed.Name = activity.Name
+		case business.SourceMergeFieldSportType:
+			detailed.Type = activity.Type
+			detailed.SportType = activity.SportType
+		case business.SourceMergeFieldDescription:
+			detailed.Description = activity.Description
+		case business.SourceMergeFieldGear:
+			detailed.GearId = activity.GearId
+			detailed.Gear = nil
+		case business.SourceMergeFieldCommute:
+			detailed.Commute = activity.Commute
+		case business.SourceMergeFieldDistance:
+			detailed.Distance = activity.Distance
+			detailed.AverageSpeed = activity.AverageSpeed
+			detailed.MaxSpeed = activity.MaxSpeed
+		case business.SourceMergeFieldDuration:
+			detailed.MovingTime = activity.MovingTime
+			detailed.ElapsedTime = activity.ElapsedTime
+		case business.SourceMergeFieldElevation:
+			detailed.TotalElevationGain = activity.TotalElevationGain
+			detailed.ElevHigh = activity.ElevHigh
+		case business.SourceMergeFieldHeartRate:
+			detailed.AverageHeartrate = activity.AverageHeartrate
+			detailed.MaxHeartrate = activity.MaxHeartrate
+			detailed.HasHeartRate = activity.AverageHeartrate > 0
+		case business.SourceMergeFieldPower:
+			detailed.AverageWatts = activity.AverageWatts
+			detailed.WeightedAverageWatts = activity.WeightedAverageWatts
+			detailed.Kilojoules = activity.Kilojoules
+			detailed.DeviceWatts = activity.DeviceWatts
+		case business.SourceMergeFieldCadence:
+			detailed.AverageCadence = activity.AverageCadence
+		}
+	}
+}
+
+func (item sourceActivity) key() business.SourceActivityKey {
+	return business.SourceActivityKey{Provider: item.source.Name, ActivityID: item.activity.Id}
+}
+
+func recordSourceKeys(record compositeRecord) []business.SourceActivityKey {
+	keys := make([]business.SourceActivityKey, 0, len(record.Sources))
+	for _, source := range record.Sources {
+		keys = append(keys, business.SourceActivityKey{Provider: source.Provider, ActivityID: source.ActivityID})
+	}
+	return keys
+}
+
+func splitSourceKeys(overrides business.SourceMergeOverrides) map[business.SourceActivityKey]struct{} {
+	keys := make(map[business.SourceActivityKey]struct{}, len(overrides.Splits))
+	for _, split := range overrides.Splits {
+		keys[split.Source] = struct{}{}
+	}
+	return keys
+}
+
+func linkSharesSource(link business.SourceMergeLink, keys []business.SourceActivityKey) bool {
+	for _, source := range link.Sources {
+		if slices.Contains(keys, source) {
+			return true
+		}
+	}
+	return false
+}
+
+func removeSourcesFromLinks(links []business.SourceMergeLink, keys []business.SourceActivityKey) []business.SourceMergeLink {
+	result := make([]business.SourceMergeLink, 0, len(links))
+	for _, link := range links {
+		link.Sources = slices.DeleteFunc(slices.Clone(link.Sources), func(source business.SourceActivityKey) bool {
+			return slices.Contains(keys, source)
+		})
+		if len(link.Sources) >= 2 {
+			result = append(result, link)
+		}
+	}
+	return result
+}
+
+// normalizeMergeOverrides lowercases provider names, drops invalid entries and
+// sorts field pins so the stored file stays stable.
+func normalizeMergeOverrides(overrides business.SourceMergeOverrides) business.SourceMergeOverrides {
+	normalizeKey := func(key business.SourceActivityKey) business.SourceActivityKey {
+		return business.SourceActivityKey{Provider: normalizeSourceName(key.Provider), ActivityID: key.ActivityID}
+	}
+	validKey := func(key business.SourceActivityKey) bool {
+		return key.Provider != "" && key.ActivityID > 0
+	}
+
+	links := make([]business.SourceMergeLink, 0, len(overrides.Links))
+	for _, link := range overrides.Links {
+		sources := make([]business.SourceActivityKey, 0, len(link.Sources))
+		for _, source := range link.Sources {
+			if source = normalizeKey(source); validKey(source) && !slices.Contains(sources, source) {
+				sources = append(sources, source)
+			}
+		}
+		if len(sources) >= 2 {
+			links = append(links, business.SourceMergeLink{Sources: sources, CreatedAt: link.CreatedAt})
+		}
+	}
+
+	splits := make([]business.SourceMergeSplit, 0, len(overrides.Splits))
+	for _, split := range overrides.Splits {
+		if split.Source = normalizeKey(split.Source); validKey(split.Source) {
+			splits = append(splits, split)
+		}
+	}
+
+	pins := make([]business.SourceMergeFieldPin, 0, len(overrides.ActivityFieldSources))
+	for _, pin := range overrides.ActivityFieldSources {
+		if pin.Source = normalizeKey(pin.Source); validKey(pin.Source) && len(pin.Fields) > 0 {
+			pins = append(pins, pin)
+		}
+	}
+	sort.SliceStable(pins, func(i, j int) bool {
+		if pins[i].Source.Provider != pins[j].Source.Provider {
+			return pins[i].Source.Provider < pins[j].Source.Provider
+		}
+		return pins[i].Source.ActivityID < pins[j].Source.ActivityID
+	})
+
+	fieldSources := make(map[string]string, len(overrides.FieldSources))
+	for field, source := range overrides.FieldSources {
+		if source = normalizeSourceName(source); source != "" {
+			fieldSources[field] = source
+		}
+	}
+
+	return business.SourceMergeOverrides{
+		Links:                links,
+		Splits:               splits,
+		FieldSources:         fieldSources,
+		ActivityFieldSources: pins,
+	}
+}
+
+func cloneMergeOverrides(overrides business.SourceMergeOverrides) business.SourceMergeOverrides {
+	cloned := business.SourceMergeOverrides{
+		Links:                make([]business.SourceMergeLink, 0, len(overrides.Links)),
+		Splits:               slices.Clone(overrides.Splits),
+		FieldSources:         cloneStringMap(overrides.FieldSources),
+		ActivityFieldSources: make([]business.SourceMergeFieldPin, 0, len(overrides.ActivityFieldSources)),
+	}
+	if cloned.Splits == nil {
+		cloned.Splits = []business.SourceMergeSplit{}
+	}
+	for _, link := range overrides.Links {
+		cloned.Links = append(cloned.Links, business.SourceMergeLink{Sources: slices.Clone(link.Sources), CreatedAt: link.CreatedAt})
+	}
+	for _, pin := range overrides.ActivityFieldSources {
+		cloned.ActivityFieldSources = append(cloned.ActivityFieldSources, business.SourceMergeFieldPin{Source: pin.Source, Fields: cloneStringMap(pin.Fields)})
+	}
+	return cloned
+}
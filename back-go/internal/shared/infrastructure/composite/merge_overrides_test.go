@@ -0,0 +1,117 @@
+package composite
+
+import (
+	"path/filepath"
+	"testing"
+
+	"mystravastats/internal/shared/domain/business"
+	"mystravastats/internal/shared/domain/strava"
+)
+
+func TestCompositeLinkMergesActivitiesRejectedByAutomaticMatching(t *testing.T) {
+	// GIVEN
+	stravaActivity := testActivity(9101, "strava ride", "Ride", "2023-07-08T08:00:00Z", 72519, 10248, nil)
+	fitActivity := testActivity(9102, "fit ride", "Ride", "2023-07-08T08:03:00Z", 51585, 11312, testStream(180))
+	provider := testOverridesProvider(t, stravaActivity, fitActivity)
+
+	// WHEN
+	overrides, err := provider.LinkSourceActivities([]business.SourceActivityKey{
+		{Provider: "Strava", ActivityID: 9101},
+		{Provider: "fit", ActivityID: 9102},
+	})
+
+	// THEN
+	if err != nil {
+		t.Fatalf("expected link to succeed, got %v", err)
+	}
+	if len(overrides.Links) != 1 || len(overrides.Links[0].Sources) != 2 || overrides.Links[0].Sources[0].Provider != "strava" {
+		t.Fatalf("expected one normalized link, got %#v", overrides.Links)
+	}
+	activities := provider.GetActivitiesByYearAndActivityTypes(nil, business.Ride)
+	if len(activities) != 1 || activities[0].Id != 9101 {
+		t.Fatalf("expected the linked activities to be merged under the Strava id, got %#v", activities)
+	}
+	detailed := provider.GetDetailedActivity(9101)
+	if detailed.Source.MergeConfidence != "manual" || len(detailed.Source.Sources) != 2 {
+		t.Fatalf("expected a manual merge of two sources, got %#v", detailed.Source)
+	}
+	if persisted := loadMergeOverrides(provider.overridesPath); len(persisted.Links) != 1 {
+		t.Fatalf("expected the link to be persisted, got %#v", persisted)
+	}
+}
+
+func TestCompositeSplitDetachesWronglyMatchedActivity(t *testing.T) {
+	// GIVEN
+	stravaActivity := testActivity(123, "strava ride", "Ride", "2026-05-01T08:00:00Z", 10000, 3600, nil)
+	gpxActivity := testActivity(9001, "local ride", "Ride", "2026-05-01T08:04:00Z", 10100, 3620, testStream(120))
+	provider := NewCompositeActivityProvider([]Source{
+		{Name: "strava", Provider: testProvider{name: "strava", activities: []*strava.Activity{stravaActivity}}},
+		{Name: "gpx", Provider: testProvider{name: "gpx", activities: []*strava.Activity{gpxActivity}}},
+	})
+	provider.overridesPath = filepath.Join(t.TempDir(), mergeOverridesFileName)
+
+	// WHEN
+	_, err := provider.SplitSourceActivity(business.SourceActivityKey{Provider: "gpx", ActivityID: 9001})
+
+	// THEN
+	if err != nil {
+		t.Fatalf("expected split to succeed, got %v", err)
+	}
+	if activities := provider.GetActivitiesByYearAndActivityTypes(nil, business.Ride); len(activities) != 2 {
+		t.Fatalf("expected the split activity to stand alone, got %d activities", len(activities))
+	}
+	if stravaSources, _ := provider.ActivitySources(123); len(stravaSources) != 1 {
+		t.Fatalf("expected Strava activity without the GPX copy, got %#v", stravaSources)
+	}
+
+	// WHEN
+	if _, err := provider.ResetActivityMerge(9001); err != nil {
+		t.Fatalf("expected reset to succeed, got %v", err)
+	}
+
+	// THEN
+	if activities := provider.GetActivitiesByYearAndActivityTypes(nil, business.Ride); len(activities) != 1 {
+		t.Fatalf("expected automatic matching after reset, got %d activities", len(activities))
+	}
+}
+
+func TestCompositeFieldSourcesPickTheWinningSourcePerField(t *testing.T) {
+	// GIVEN
+	stravaActivity := testActivity(123, "Col du Tourmalet", "Ride", "2026-05-01T08:00:00Z", 10000, 3600, nil)
+	stravaActivity.AverageHeartrate = 120
+	fitActivity := testActivity(9001, "Ride", "Ride", "2026-05-01T08:01:00Z", 10050, 3610, testStream(120))
+	fitActivity.AverageHeartrate = 142
+	fitActivity.AverageWatts = 210
+	provider := testOverridesProvider(t, stravaActivity, fitActivity)
+
+	// WHEN
+	if _, err := provider.SetDefaultFieldSources(map[string]string{"heartRate": "fit", "power": "fit"}); err != nil {
+		t.Fatalf("expected default field sources to be saved, got %v", err)
+	}
+	if _, err := provider.SetActivityFieldSources(123, map[string]string{"name": "fit"}); err != nil {
+		t.Fatalf("expected activity field sources to be saved, got %v", err)
+	}
+
+	// THEN
+	detailed := provider.GetDetailedActivity(123)
+	if detailed.Name != "Ride" || detailed.AverageHeartrate != 142 || detailed.AverageWatts != 210 {
+		t.Fatalf("expected name, heart rate and power from FIT, got name=%q hr=%.0f watts=%.0f", detailed.Name, detailed.AverageHeartrate, detailed.AverageWatts)
+	}
+	fieldSources := detailed.Source.FieldSources
+	if fieldSources["name"] != "fit" || fieldSources["heartRate"] != "fit" || fieldSources["power"] != "fit" || fieldSources["metadata"] != "strava" {
+		t.Fatalf("expected pinned fields in field sources, got %#v", fieldSources)
+	}
+	if _, err := provider.SetActivityFieldSources(123, map[string]string{"weather": "fit"}); err == nil {
+		t.Fatalf("expected unknown field to be rejected")
+	}
+}
+
+func testOverridesProvider(t *testing.T, stravaActivity *strava.Activity, fitActivity *strava.Activity) *CompositeActivityProvider {
+	t.Helper()
+	provider := NewCompositeActivityProvider([]Source{
+		{Name: "strava", Provider: testProvider{name: "strava", activities: []*strava.Activity{stravaActivity}}},
+		{Name: "fit", Provider: testProvider{name: "fit", activities: []*strava.Activity{fitActivity}}},
+	})
+	provider.overridesPath = filepath.Join(t.TempDir(), mergeOverridesFileName)
+	return provider
+}
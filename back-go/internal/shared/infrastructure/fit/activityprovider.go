@@ -1,6 +1,7 @@
 package fit
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"hash/fnv"
@@ -17,6 +18,7 @@ import (
 	"mystravastats/internal/helpers"
 	"mystravastats/internal/shared/domain/business"
 	"mystravastats/internal/shared/domain/strava"
+	"mystravastats/internal/shared/infrastructure/localindex"
 	"mystravastats/internal/shared/infrastructure/localrepository"
 
 	fitparser "github.com/tormoder/fit"
@@ -24,8 +26,12 @@ import (
 
 const (
 	firstSupportedYear = 2010
-	fitInvalidUint8    = uint8(0xFF)
-	fitInvalidUint16   = uint16(0xFFFF)
+	// fitDecoderVersion must be bumped when DecodeFITActivity output changes so
+	// the persistent index is rebuilt instead of serving stale summaries.
+	fitDecoderVersion = "fit-decoder-v3"
+	fitInvalidUint8   = uint8(0xFF)
+	fitInvalidUint16  = uint16(0xFFFF)
+	fitInvalidSint8   = int8(0x7F)
 )
 
 type FITActivityProvider struct {
@@ -38,6 +44,7 @@ type FITActivityProvider struct {
 	heartRateZoneSettings business.HeartRateZoneSettings
 	performanceSettings   business.AthletePerformanceSettings
 	localStorageProvider  *localrepository.StravaRepository
+	index                 *localindex.Index
 	dataMutex             sync.RWMutex
 	cacheMutex            sync.RWMutex
 }
@@ -60,6 +67,7 @@ func NewFITActivityProvider(fitDirectory string) *FITActivityProvider {
 		fitDirectory:         resolvedDirectory,
 		clientID:             clientID,
 		localStorageProvider: localStorageProvider,
+		index:                localindex.Open(resolvedDirectory, clientID, "fit", fitDecoderVersion),
 		stravaAthlete: strava.Athlete{
 			Id:        athleteID,
 			Firstname: &firstName,
@@ -194,6 +202,7 @@ func (provider *FITActivityProvider) CacheDiagnostics() map[string]any {
 		"athleteId":         provider.clientID,
 		"activities":        activitiesCount,
 		"availableYearBins": years,
+		"index":             provider.index.Diagnostics(),
 	}
 }
 
@@ -205,13 +214,18 @@ func (provider *FITActivityProvider) CacheRootPath() string {
 	return provider.fitDirectory
 }
 
+// SourceFile returns the FIT file an activity was decoded from.
+func (provider *FITActivityProvider) SourceFile(activityID int64) (string, bool) {
+	return provider.index.SourceFile(activityID)
+}
+
 func (provider *FITActivityProvider) Reload() {
 	provider.replaceActivities(provider.loadActivitiesFromFITDirectory())
 }
 
 func (provider *FITActivityProvider) loadActivitiesFromFITDirectory() []*strava.Activity {
 	start := time.Now()
-	loadedActivities := make([]*strava.Activity, 0)
+	files := make([]localindex.File, 0)
 
 	for year := time.Now().Year(); year >= firstSupportedYear; year-- {
 		yearDirectory := filepath.Join(provider.fitDirectory, strconv.Itoa(year))
@@ -230,17 +244,14 @@ func (provider *FITActivityProvider) loadActivitiesFromFITDirectory() []*strava.
 			if !strings.EqualFold(filepath.Ext(entry.Name()), ".fit") {
 				continue
 			}
-
-			filePath := filepath.Join(yearDirectory, entry.Name())
-			activity, decodeErr := DecodeFITActivity(filePath, provider.stravaAthlete.Id)
-			if decodeErr != nil {
-				log.Printf("Unable to decode FIT activity %s: %v", filePath, decodeErr)
-				continue
-			}
-			loadedActivities = append(loadedActivities, activity)
+			files = append(files, localindex.File{Path: filepath.Join(yearDirectory, entry.Name()), Year: year})
 		}
 	}
 
+	loadedActivities, stats := provider.index.Load(files, func(file localindex.File) (*strava.Activity, error) {
+		return DecodeFITActivity(file.Path, provider.stravaAthlete.Id)
+	})
+
 	sort.SliceStable(loadedActivities, func(i, j int) bool {
 		left, leftOK := helpers.ParseActivityDate(loadedActivities[i].StartDateLocal)
 		right, rightOK := helpers.ParseActivityDate(loadedActivities[j].StartDateLocal)
@@ -256,23 +267,17 @@ func (provider *FITActivityProvider) loadActivitiesFromFITDirectory() []*strava.
 		}
 	})
 
-	log.Printf("Loaded %d FIT activities in %s", len(loadedActivities), time.Since(start))
+	log.Printf("Loaded %d FIT activities in %s (index hits=%d decoded=%d removed=%d)", len(loadedActivities), time.Since(start), stats.Hits, stats.Decoded, stats.Removed)
 	return loadedActivities
 }
 
 func DecodeFITActivity(filePath string, athleteID int64) (*strava.Activity, error) {
-	file, err := os.Open(filePath)
+	content, err := os.ReadFile(filePath)
 	if err != nil {
 		return nil, err
 	}
-	defer func(file *os.File) {
-		err := file.Close()
-		if err != nil {
-			log.Printf("Unable to close FIT file %s: %v", filePath, err)
-		}
-	}(file)
 
-	decodedFile, err := fitparser.Decode(file)
+	decodedFile, err := fitparser.Decode(bytes.NewReader(content))
 	if err != nil {
 		return nil, err
 	}
@@ -284,12 +289,21 @@ func DecodeFITActivity(filePath string, athleteID int64) (*strava.Activity, erro
 		return nil, errors.New("FIT file is not an activity file")
 	}
 
-	session := firstSession(activityFile.Sessions)
+	// Multisport files are kept as one activity covering all legs; the legs
+	// are exposed as sessions.
+	session := mergeFITSessions(activityFile.Sessions)
 	if session == nil {
 		return nil, errors.New("FIT activity has no session message")
 	}
 
 	stream := buildStreamFromFITRecords(activityFile.Records, session.StartTime)
+	if stream != nil {
+		if scan, err := scanFITRecordExtras(content); err != nil {
+			log.Printf("Unable to read extra FIT record fields from %s: %v", filePath, err)
+		} else {
+			addFITRecordExtras(stream, activityFile.Records, scan)
+		}
+	}
 	sportType := mapFITSportToActivityType(session.Sport, session.SubSport)
 	startDate := resolveActivityStartDate(session.StartTime, activityFile.Records)
 
@@ -338,6 +352,10 @@ func DecodeFITActivity(filePath string, athleteID int64) (*strava.Activity, erro
 	activityID := fitActivityID(filePath, startDateUTC, sportType, distance)
 	name := fmt.Sprintf("%s - %s", sportType, startDateLocal.Format("2006-01-02 15:04:05"))
 
+	timeline := newFITRecordTimeline(activityFile.Records)
+	laps := buildFITLaps(activityFile.Laps, timeline)
+	sessions := buildFITSessions(activityFile.Sessions, timeline, len(laps))
+
 	return &strava.Activity{
 		Athlete:              strava.AthleteRef{ID: int(athleteID)},
 		AverageSpeed:         averageSpeed,
@@ -364,6 +382,8 @@ func DecodeFITActivity(filePath string, athleteID int64) (*strava.Activity, erro
 		UploadId:             activityID,
 		WeightedAverageWatts: powerMetrics.weightedAverageWatts,
 		Stream:               stream,
+		Laps:                 laps,
+		Sessions:             sessions,
 	}, nil
 }
 
@@ -490,8 +510,13 @@ func buildStreamFromFITRecords(records []*fitparser.RecordMsg, startTime time.Ti
 	cadenceData := make([]int, 0, len(records))
 	heartRateData := make([]int, 0, len(records))
 	powerData := make([]float64, 0, len(records))
+	temperatureData := make([]float64, 0, len(records))
+	balanceData := make([]float64, 0, len(records))
+	verticalOscillationData := make([]float64, 0, len(records))
+	groundContactTimeData := make([]float64, 0, len(records))
 
 	lastDistance := 0.0
+	lastTemperature := 0.0
 	lastElapsedSeconds := 0
 	for index, record := range records {
 		if record == nil {
@@ -570,6 +595,16 @@ func buildStreamFromFITRecords(records []*fitparser.RecordMsg, startTime time.Ti
 			power = 0
 		}
 		powerData = append(powerData, power)
+
+		// Temperature sensors sample slower than records; keep the last reading.
+		if record.Temperature != fitInvalidSint8 {
+			lastTemperature = float64(record.Temperature)
+		}
+		temperatureData = append(temperatureData, lastTemperature)
+
+		balanceData = append(balanceData, fitLeftBalance(record.LeftRightBalance))
+		verticalOscillationData = append(verticalOscillationData, nonNegativeFinite(record.GetVerticalOscillationScaled()))
+		groundContactTimeData = append(groundContactTimeData, nonNegativeFinite(record.GetStanceTimeScaled()))
 	}
 
 	if len(distanceData) == 0 || len(timeData) == 0 {
@@ -657,6 +692,42 @@ func buildStreamFromFITRecords(records []*fitparser.RecordMsg, startTime time.Ti
 		}
 	}
 
+	if hasAnyFloat(temperatureData) {
+		stream.Temperature = &strava.TemperatureStream{
+			Data:         temperatureData,
+			OriginalSize: len(temperatureData),
+			Resolution:   "high",
+			SeriesType:   "distance",
+		}
+	}
+
+	if hasAnyFloat(balanceData) {
+		stream.LeftRightBalance = &strava.LeftRightBalanceStream{
+			Data:         balanceData,
+			OriginalSize: len(balanceData),
+			Resolution:   "high",
+			SeriesType:   "distance",
+		}
+	}
+
+	if hasAnyFloat(verticalOscillationData) {
+		stream.VerticalOscillation = &strava.VerticalOscillationStream{
+			Data:         verticalOscillationData,
+			OriginalSize: len(verticalOscillationData),
+			Resolution:   "high",
+			SeriesType:   "distance",
+		}
+	}
+
+	if hasAnyFloat(groundContactTimeData) {
+		stream.GroundContactTime = &strava.GroundContactTimeStream{
+			Data:         groundContactTimeData,
+			OriginalSize: len(groundContactTimeData),
+			Resolution:   "high",
+			SeriesType:   "distance",
+		}
+	}
+
 	stream.Moving = &strava.MovingStream{
 		Data:         movingData,
 		OriginalSize: len(movingData),
@@ -667,6 +738,91 @@ func buildStreamFromFITRecords(records []*fitparser.RecordMsg, startTime time.Ti
 	return stream
 }
 
+// fitLeftBalance converts a FIT left_right_balance value into the left leg
+// share in percent. The value is 0 when the side is unknown.
+func fitLeftBalance(balance fitparser.LeftRightBalance) float64 {
+	if balance == fitparser.LeftRightBalanceInvalid || balance&fitparser.LeftRightBalanceRight == 0 {
+		return 0
+	}
+	right := float64(balance & fitparser.LeftRightBalanceMask)
+	if right > 100 {
+		return 0
+	}
+	return 100 - right
+}
+
+// addFITRecordExtras adds the channels recovered by scanFITRecordExtras. The
+// raw scan only lines up with the decoded records when both saw the same
+// record messages; otherwise the extras are left out.
+func addFITRecordExtras(stream *strava.Stream, records []*fitparser.RecordMsg, scan fitRecordScan) {
+	if len(scan.records) != len(records) {
+		return
+	}
+
+	size := len(stream.Distance.Data)
+	strideLengthData := make([]float64, 0, size)
+	coreTemperatureData := make([]float64, 0, size)
+	developerData := make([][]float64, len(scan.developerFields))
+	for index := range developerData {
+		developerData[index] = make([]float64, 0, size)
+	}
+
+	lastCoreTemperature := 0.0
+	for index, record := range records {
+		if record == nil {
+			continue
+		}
+		extras := scan.records[index]
+		strideLengthData = append(strideLengthData, nonNegativeFinite(extras.stepLength))
+		if isFinite(extras.coreTemperature) {
+			lastCoreTemperature = extras.coreTemperature
+		}
+		coreTemperatureData = append(coreTemperatureData, lastCoreTemperature)
+		for fieldIndex, description := range scan.developerFields {
+			value, found := extras.developer[description.key]
+			if !found || !isFinite(value) {
+				value = 0
+			}
+			developerData[fieldIndex] = append(developerData[fieldIndex], value)
+		}
+	}
+
+	if hasAnyFloat(strideLengthData) {
+		stream.StrideLength = &strava.StrideLengthStream{
+			Data:         strideLengthData,
+			OriginalSize: len(strideLengthData),
+			Resolution:   "high",
+			SeriesType:   "distance",
+		}
+	}
+
+	if hasAnyFloat(coreTemperatureData) {
+		stream.CoreTemperature = &strava.TemperatureStream{
+			Data:         coreTemperatureData,
+			OriginalSize: len(coreTemperatureData),
+			Resolution:   "high",
+			SeriesType:   "distance",
+		}
+	}
+
+	for fieldIndex, description := range scan.developerFields {
+		if !hasAnyFloat(developerData[fieldIndex]) {
+			continue
+		}
+		stream.DeveloperFields = append(stream.DeveloperFields, strava.DeveloperFieldStream{
+			Name:               description.name,
+			Units:              description.units,
+			NativeField:        description.nativeField,
+			DeveloperDataIndex: int(description.key.developerDataIndex),
+			FieldNumber:        int(description.key.fieldNumber),
+			Data:               developerData[fieldIndex],
+			OriginalSize:       len(developerData[fieldIndex]),
+			Resolution:         "high",
+			SeriesType:         "distance",
+		})
+	}
+}
+
 func resolveFITMovingTime(totalMovingTime int, totalTimerTime int, elapsedTime int, stream *strava.Stream) int {
 	if totalMovingTime > 0 {
 		return totalMovingTime
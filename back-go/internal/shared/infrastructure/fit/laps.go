@@ -0,0 +1,270 @@
+package fit
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"mystravastats/internal/shared/domain/strava"
+
+	fitparser "github.com/tormoder/fit"
+)
+
+var fitLapTriggerNames = map[fitparser.LapTrigger]string{
+	fitparser.LapTriggerManual:           "manual",
+	fitparser.LapTriggerTime:             "time",
+	fitparser.LapTriggerDistance:         "distance",
+	fitparser.LapTriggerPositionStart:    "position_start",
+	fitparser.LapTriggerPositionLap:      "position_lap",
+	fitparser.LapTriggerPositionWaypoint: "position_waypoint",
+	fitparser.LapTriggerPositionMarked:   "position_marked",
+	fitparser.LapTriggerSessionEnd:       "session_end",
+	fitparser.LapTriggerFitnessEquipment: "fitness_equipment",
+}
+
+var fitIntensityNames = map[fitparser.Intensity]string{
+	fitparser.IntensityActive:   "active",
+	fitparser.IntensityRest:     "rest",
+	fitparser.IntensityWarmup:   "warmup",
+	fitparser.IntensityCooldown: "cooldown",
+	fitparser.IntensityRecovery: "recovery",
+	fitparser.IntensityInterval: "interval",
+	fitparser.IntensityOther:    "other",
+}
+
+// fitRecordTimeline lists record timestamps by stream index. It skips nil
+// records like buildStreamFromFITRecords does.
+type fitRecordTimeline []time.Time
+
+func newFITRecordTimeline(records []*fitparser.RecordMsg) fitRecordTimeline {
+	timeline := make(fitRecordTimeline, 0, len(records))
+	for _, record := range records {
+		if record != nil {
+			timeline = append(timeline, record.Timestamp)
+		}
+	}
+	return timeline
+}
+
+// indexRange returns the first and last stream indexes recorded between start
+// and end. Both are 0 when no record falls in the range.
+func (timeline fitRecordTimeline) indexRange(start time.Time, end time.Time) (int, int) {
+	first := sort.Search(len(timeline), func(index int) bool {
+		return !timeline[index].Before(start)
+	})
+	last := sort.Search(len(timeline), func(index int) bool {
+		return timeline[index].After(end)
+	}) - 1
+	if first >= len(timeline) || last < first {
+		return 0, 0
+	}
+	return first, last
+}
+
+func buildFITLaps(laps []*fitparser.LapMsg, timeline fitRecordTimeline) []strava.Lap {
+	result := make([]strava.Lap, 0, len(laps))
+	for _, lap := range laps {
+		if lap == nil {
+			continue
+		}
+		elapsedTime := roundedNonNegative(lap.GetTotalElapsedTimeScaled())
+		startIndex, endIndex := timeline.indexRange(lap.StartTime, fitEndTime(lap.StartTime, lap.Timestamp, elapsedTime))
+		lapIndex := len(result) + 1
+		result = append(result, strava.Lap{
+			Name:               fmt.Sprintf("Lap %d", lapIndex),
+			LapIndex:           lapIndex,
+			StartIndex:         startIndex,
+			EndIndex:           endIndex,
+			StartDate:          fitDate(lap.StartTime),
+			Distance:           nonNegativeFinite(lap.GetTotalDistanceScaled()),
+			ElapsedTime:        elapsedTime,
+			MovingTime:         fitMovingTime(lap.GetTotalMovingTimeScaled(), lap.GetTotalTimerTimeScaled()),
+			TotalElevationGain: validFITUint16Float(lap.TotalAscent),
+			AverageSpeed:       firstPositiveFinite(lap.GetEnhancedAvgSpeedScaled(), lap.GetAvgSpeedScaled()),
+			MaxSpeed:           firstPositiveFinite(lap.GetEnhancedMaxSpeedScaled(), lap.GetMaxSpeedScaled()),
+			AverageHeartrate:   float64(validFITUint8(lap.AvgHeartRate)),
+			MaxHeartrate:       float64(validFITUint8(lap.MaxHeartRate)),
+			AverageWatts:       validFITUint16Float(lap.AvgPower),
+			AverageCadence:     float64(validFITUint8(lap.AvgCadence)),
+			Trigger:            fitLapTriggerNames[lap.LapTrigger],
+			Intensity:          fitIntensityNames[lap.Intensity],
+		})
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// buildFITSessions describes each leg of a multisport file. Single-session
+// files have no children.
+func buildFITSessions(sessions []*fitparser.SessionMsg, timeline fitRecordTimeline, lapCount int) []strava.ActivitySession {
+	if countFITSessions(sessions) < 2 {
+		return nil
+	}
+	result := make([]strava.ActivitySession, 0, len(sessions))
+	for _, session := range sessions {
+		if session == nil {
+			continue
+		}
+		elapsedTime := roundedNonNegative(session.GetTotalElapsedTimeScaled())
+		startIndex, endIndex := timeline.indexRange(session.StartTime, fitEndTime(session.StartTime, session.Timestamp, elapsedTime))
+		child := strava.ActivitySession{
+			SessionIndex:       len(result) + 1,
+			SportType:          fitSessionSportType(session),
+			StartDate:          fitDate(session.StartTime),
+			StartIndex:         startIndex,
+			EndIndex:           endIndex,
+			Distance:           nonNegativeFinite(session.GetTotalDistanceScaled()),
+			ElapsedTime:        elapsedTime,
+			MovingTime:         fitMovingTime(session.GetTotalMovingTimeScaled(), session.GetTotalTimerTimeScaled()),
+			TotalElevationGain: validFITUint16Float(session.TotalAscent),
+			AverageSpeed:       firstPositiveFinite(session.GetEnhancedAvgSpeedScaled(), session.GetAvgSpeedScaled()),
+			MaxSpeed:           firstPositiveFinite(session.GetEnhancedMaxSpeedScaled(), session.GetMaxSpeedScaled()),
+			AverageHeartrate:   float64(validFITUint8(session.AvgHeartRate)),
+			MaxHeartrate:       float64(validFITUint8(session.MaxHeartRate)),
+			AverageWatts:       validFITUint16Float(session.AvgPower),
+		}
+		if session.NumLaps != fitInvalidUint16 && session.FirstLapIndex != fitInvalidUint16 && int(session.FirstLapIndex) < lapCount {
+			child.FirstLapIndex = int(session.FirstLapIndex) + 1
+			child.LapCount = int(session.NumLaps)
+		}
+		result = append(result, child)
+	}
+	return result
+}
+
+// mergeFITSessions sums the legs of a multisport file into one session so the
+// parent activity covers the whole recording. The sport is the one of the
+// longest leg, transitions excluded. Cadence is left out because it mixes
+// strokes, revolutions and steps.
+func mergeFITSessions(sessions []*fitparser.SessionMsg) *fitparser.SessionMsg {
+	if countFITSessions(sessions) < 2 {
+		return firstSession(sessions)
+	}
+
+	merged := fitparser.NewSessionMsg()
+	var dominant *fitparser.SessionMsg
+	var elapsedTime, timerTime, movingTime, distance, ascent float64
+	var heartRateTotal, heartRateTime, powerTotal, powerTime float64
+	var maxSpeed, maxAltitude float64
+	maxHeartRate := uint8(0)
+
+	first := true
+	for _, session := range sessions {
+		if session == nil {
+			continue
+		}
+		if first || session.StartTime.Before(merged.StartTime) {
+			merged.StartTime = session.StartTime
+			merged.StartPositionLat = session.StartPositionLat
+			merged.StartPositionLong = session.StartPositionLong
+		}
+		if first || session.Timestamp.After(merged.Timestamp) {
+			merged.Timestamp = session.Timestamp
+		}
+		first = false
+
+		sessionTimer := nonNegativeFinite(session.GetTotalTimerTimeScaled())
+		if session.Sport != fitparser.SportTransition && (dominant == nil || sessionTimer > nonNegativeFinite(dominant.GetTotalTimerTimeScaled())) {
+			dominant = session
+		}
+
+		elapsedTime += nonNegativeFinite(session.GetTotalElapsedTimeScaled())
+		timerTime += sessionTimer
+		movingTime += fitMovingSeconds(session.GetTotalMovingTimeScaled(), session.GetTotalTimerTimeScaled())
+		distance += nonNegativeFinite(session.GetTotalDistanceScaled())
+		ascent += validFITUint16Float(session.TotalAscent)
+		if heartRate := validFITUint8(session.AvgHeartRate); heartRate > 0 {
+			heartRateTotal += float64(heartRate) * sessionTimer
+			heartRateTime += sessionTimer
+		}
+		if power := validFITUint16Float(session.AvgPower); power > 0 {
+			powerTotal += power * sessionTimer
+			powerTime += sessionTimer
+		}
+		maxHeartRate = max(maxHeartRate, validFITUint8(session.MaxHeartRate))
+		maxSpeed = math.Max(maxSpeed, firstPositiveFinite(session.GetEnhancedMaxSpeedScaled(), session.GetMaxSpeedScaled()))
+		maxAltitude = math.Max(maxAltitude, firstPositiveFinite(session.GetEnhancedMaxAltitudeScaled(), session.GetMaxAltitudeScaled()))
+	}
+
+	if dominant == nil {
+		dominant = firstSession(sessions)
+	}
+	merged.Sport = dominant.Sport
+	merged.SubSport = dominant.SubSport
+	merged.TotalElapsedTime = uint32(math.Round(elapsedTime * 1000))
+	merged.TotalTimerTime = uint32(math.Round(timerTime * 1000))
+	merged.TotalMovingTime = uint32(math.Round(movingTime * 1000))
+	merged.TotalDistance = uint32(math.Round(distance * 100))
+	merged.TotalAscent = uint16(math.Min(ascent, float64(fitInvalidUint16-1)))
+	if timerTime > 0 {
+		merged.EnhancedAvgSpeed = uint32(math.Round(distance / timerTime * 1000))
+	}
+	if maxSpeed > 0 {
+		merged.EnhancedMaxSpeed = uint32(math.Round(maxSpeed * 1000))
+	}
+	if maxAltitude > 0 {
+		// Enhanced altitude has scale 5 and offset 500.
+		merged.EnhancedMaxAltitude = uint32(math.Round((maxAltitude + 500) * 5))
+	}
+	if heartRateTime > 0 {
+		merged.AvgHeartRate = uint8(math.Round(heartRateTotal / heartRateTime))
+	}
+	if maxHeartRate > 0 {
+		merged.MaxHeartRate = maxHeartRate
+	}
+	if powerTime > 0 {
+		merged.AvgPower = uint16(math.Round(powerTotal / powerTime))
+	}
+	return merged
+}
+
+func countFITSessions(sessions []*fitparser.SessionMsg) int {
+	count := 0
+	for _, session := range sessions {
+		if session != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// fitSessionSportType names the legs mapFITSportToActivityType has no
+// activity type for.
+func fitSessionSportType(session *fitparser.SessionMsg) string {
+	switch session.Sport {
+	case fitparser.SportSwimming:
+		return "Swim"
+	case fitparser.SportTransition:
+		return "Transition"
+	default:
+		return mapFITSportToActivityType(session.Sport, session.SubSport)
+	}
+}
+
+func fitEndTime(start time.Time, end time.Time, elapsedSeconds int) time.Time {
+	if !end.IsZero() && !end.Before(start) {
+		return end
+	}
+	return start.Add(time.Duration(elapsedSeconds) * time.Second)
+}
+
+func fitMovingTime(totalMovingTime float64, totalTimerTime float64) int {
+	return int(math.Round(fitMovingSeconds(totalMovingTime, totalTimerTime)))
+}
+
+func fitMovingSeconds(totalMovingTime float64, totalTimerTime float64) float64 {
+	if moving := nonNegativeFinite(totalMovingTime); moving > 0 {
+		return moving
+	}
+	return nonNegativeFinite(totalTimerTime)
+}
+
+func fitDate(value time.Time) string {
+	if value.IsZero() {
+		return ""
+	}
+	return value.UTC().Format(time.RFC3339)
+}
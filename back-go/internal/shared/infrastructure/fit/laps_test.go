@@ -0,0 +1,108 @@
+package fit
+
+import (
+	"testing"
+	"time"
+
+	fitparser "github.com/tormoder/fit"
+)
+
+func TestBuildFITLaps_MapsIntervalStepsToStreamIndexes(t *testing.T) {
+	// GIVEN
+	start := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	records := make([]*fitparser.RecordMsg, 0, 10)
+	for second := 0; second < 10; second++ {
+		records = append(records, testFITRecord(start.Add(time.Duration(second)*time.Second), fitInvalidSint8, fitparser.LeftRightBalanceInvalid, 0xFFFF, 0xFFFF))
+	}
+	work := testFITLap(start, 6, 2000, 172, fitparser.LapTriggerTime, fitparser.IntensityActive)
+	recovery := testFITLap(start.Add(6*time.Second), 4, 500, 131, fitparser.LapTriggerManual, fitparser.IntensityRecovery)
+
+	// WHEN
+	laps := buildFITLaps([]*fitparser.LapMsg{work, nil, recovery}, newFITRecordTimeline(records))
+
+	// THEN
+	if len(laps) != 2 {
+		t.Fatalf("expected 2 laps, got %d", len(laps))
+	}
+	first, second := laps[0], laps[1]
+	if first.LapIndex != 1 || first.StartIndex != 0 || first.EndIndex != 6 || first.Trigger != "time" || first.Intensity != "active" {
+		t.Fatalf("unexpected work lap: %#v", first)
+	}
+	if second.Name != "Lap 2" || second.StartIndex != 6 || second.EndIndex != 9 || second.Trigger != "manual" || second.Intensity != "recovery" {
+		t.Fatalf("unexpected recovery lap: %#v", second)
+	}
+	if first.Distance != 20 || first.ElapsedTime != 6 || first.AverageHeartrate != 172 {
+		t.Fatalf("expected scaled lap totals, got %#v", first)
+	}
+}
+
+func TestMergeFITSessions_CoversAllLegsOfAMultisportFile(t *testing.T) {
+	// GIVEN
+	start := time.Date(2026, 6, 7, 7, 0, 0, 0, time.UTC)
+	swim := testFITSession(start, fitparser.SportSwimming, 1800, 1500, 140)
+	transition := testFITSession(start.Add(1800*time.Second), fitparser.SportTransition, 120, 0, 150)
+	bike := testFITSession(start.Add(1920*time.Second), fitparser.SportCycling, 3600, 40000, 145)
+	bike.FirstLapIndex, bike.NumLaps = 2, 1
+	sessions := []*fitparser.SessionMsg{swim, transition, bike}
+
+	// WHEN
+	merged := mergeFITSessions(sessions)
+	children := buildFITSessions(sessions, newFITRecordTimeline(nil), 3)
+
+	// THEN
+	if merged.Sport != fitparser.SportCycling || !merged.StartTime.Equal(start) {
+		t.Fatalf("expected parent to start with the swim and use the longest leg sport, got %v at %v", merged.Sport, merged.StartTime)
+	}
+	assertFloatEquals(t, 41500, merged.GetTotalDistanceScaled())
+	assertFloatEquals(t, 5520, merged.GetTotalElapsedTimeScaled())
+	if merged.AvgHeartRate != 143 {
+		t.Fatalf("expected time-weighted heart rate of 143, got %d", merged.AvgHeartRate)
+	}
+	if len(children) != 3 || children[0].SportType != "Swim" || children[1].SportType != "Transition" || children[2].SportType != "Ride" {
+		t.Fatalf("expected swim, transition and ride sessions, got %#v", children)
+	}
+	if children[2].FirstLapIndex != 3 || children[2].LapCount != 1 || children[2].Distance != 40000 {
+		t.Fatalf("unexpected bike session: %#v", children[2])
+	}
+}
+
+func TestBuildFITSessions_IgnoresSingleSessionFiles(t *testing.T) {
+	// GIVEN
+	session := testFITSession(time.Date(2026, 6, 7, 7, 0, 0, 0, time.UTC), fitparser.SportRunning, 3600, 10000, 150)
+
+	// WHEN
+	children := buildFITSessions([]*fitparser.SessionMsg{session}, newFITRecordTimeline(nil), 0)
+
+	// THEN
+	if children != nil {
+		t.Fatalf("expected no child sessions, got %#v", children)
+	}
+	if mergeFITSessions([]*fitparser.SessionMsg{session}) != session {
+		t.Fatal("expected single session to be used as is")
+	}
+}
+
+func testFITLap(start time.Time, seconds uint32, distanceCentimeters uint32, heartRate uint8, trigger fitparser.LapTrigger, intensity fitparser.Intensity) *fitparser.LapMsg {
+	lap := fitparser.NewLapMsg()
+	lap.StartTime = start
+	lap.Timestamp = start.Add(time.Duration(seconds) * time.Second)
+	lap.TotalElapsedTime = seconds * 1000
+	lap.TotalTimerTime = seconds * 1000
+	lap.TotalDistance = distanceCentimeters
+	lap.AvgHeartRate = heartRate
+	lap.LapTrigger = trigger
+	lap.Intensity = intensity
+	return lap
+}
+
+func testFITSession(start time.Time, sport fitparser.Sport, seconds uint32, distanceMeters uint32, heartRate uint8) *fitparser.SessionMsg {
+	session := fitparser.NewSessionMsg()
+	session.StartTime = start
+	session.Timestamp = start.Add(time.Duration(seconds) * time.Second)
+	session.Sport = sport
+	session.TotalElapsedTime = seconds * 1000
+	session.TotalTimerTime = seconds * 1000
+	session.TotalDistance = distanceMeters * 100
+	session.AvgHeartRate = heartRate
+	return session
+}
@@ -0,0 +1,382 @@
+package fit
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// The FIT decoding library knows a profile that predates the step_length and
+// core_temperature record fields, and it discards developer field values.
+// scanFITRecordExtras walks the raw messages a second time to recover them.
+
+const (
+	fitMesgNumRecord              = 20
+	fitMesgNumFieldDescription    = 206
+	fitRecordFieldStepLength      = 85
+	fitRecordFieldCoreTemperature = 139
+)
+
+// fitNativeRecordFields names the record fields a developer field can declare
+// as its native counterpart.
+var fitNativeRecordFields = map[uint8]string{
+	2:  "altitude",
+	3:  "heart_rate",
+	4:  "cadence",
+	5:  "distance",
+	6:  "speed",
+	7:  "power",
+	13: "temperature",
+	39: "vertical_oscillation",
+	41: "stance_time",
+	85: "step_length",
+}
+
+type fitDeveloperFieldKey struct {
+	developerDataIndex uint8
+	fieldNumber        uint8
+}
+
+type fitDeveloperFieldDescription struct {
+	key         fitDeveloperFieldKey
+	name        string
+	units       string
+	nativeField string
+	baseType    byte
+	scale       float64
+	offset      float64
+}
+
+// fitRecordExtras holds what the library dropped from one record message.
+// Missing values are NaN.
+type fitRecordExtras struct {
+	stepLength      float64
+	coreTemperature float64
+	developer       map[fitDeveloperFieldKey]float64
+}
+
+type fitRecordScan struct {
+	records []fitRecordExtras
+	// developerFields lists field descriptions in the order they appear.
+	developerFields []fitDeveloperFieldDescription
+}
+
+type fitFieldDefinition struct {
+	number   uint8
+	size     int
+	baseType byte
+}
+
+type fitDeveloperFieldDefinition struct {
+	key  fitDeveloperFieldKey
+	size int
+}
+
+type fitMessageDefinition struct {
+	globalNumber    uint16
+	byteOrder       binary.ByteOrder
+	fields          []fitFieldDefinition
+	developerFields []fitDeveloperFieldDefinition
+	size            int
+}
+
+func scanFITRecordExtras(data []byte) (fitRecordScan, error) {
+	scan := fitRecordScan{}
+	if len(data) < 12 {
+		return scan, errors.New("FIT file is too short")
+	}
+	headerSize := int(data[0])
+	if headerSize < 12 || len(data) < headerSize || string(data[8:12]) != ".FIT" {
+		return scan, errors.New("invalid FIT file header")
+	}
+	end := headerSize + int(binary.LittleEndian.Uint32(data[4:8]))
+	if end > len(data) {
+		return scan, errors.New("truncated FIT file")
+	}
+
+	definitions := make(map[uint8]*fitMessageDefinition)
+	descriptionIndex := make(map[fitDeveloperFieldKey]int)
+	offset := headerSize
+	for offset < end {
+		header := data[offset]
+		offset++
+
+		var localType uint8
+		if header&0x80 != 0 {
+			// Compressed timestamp header: always a data message.
+			localType = (header >> 5) & 0x03
+		} else {
+			localType = header & 0x0F
+			if header&0x40 != 0 {
+				definition, next, err := readFITMessageDefinition(data[:end], offset, header&0x20 != 0)
+				if err != nil {
+					return scan, err
+				}
+				definitions[localType] = definition
+				offset = next
+				continue
+			}
+		}
+
+		definition := definitions[localType]
+		if definition == nil {
+			return scan, fmt.Errorf("data message for undefined local type %d", localType)
+		}
+		if offset+definition.size > end {
+			return scan, errors.New("truncated FIT data message")
+		}
+		message := data[offset : offset+definition.size]
+		offset += definition.size
+
+		switch definition.globalNumber {
+		case fitMesgNumRecord:
+			scan.records = append(scan.records, readFITRecordExtras(definition, message, scan.developerFields, descriptionIndex))
+		case fitMesgNumFieldDescription:
+			description, ok := readFITFieldDescription(definition, message)
+			if !ok {
+				continue
+			}
+			if index, found := descriptionIndex[description.key]; found {
+				scan.developerFields[index] = description
+				continue
+			}
+			descriptionIndex[description.key] = len(scan.developerFields)
+			scan.developerFields = append(scan.developerFields, description)
+		}
+	}
+	return scan, nil
+}
+
+func readFITMessageDefinition(data []byte, offset int, hasDeveloperFields bool) (*fitMessageDefinition, int, error) {
+	if offset+5 > len(data) {
+		return nil, 0, errors.New("truncated FIT definition message")
+	}
+	definition := &fitMessageDefinition{byteOrder: binary.LittleEndian}
+	if data[offset+1] == 1 {
+		definition.byteOrder = binary.BigEndian
+	}
+	definition.globalNumber = definition.byteOrder.Uint16(data[offset+2 : offset+4])
+	fieldCount := int(data[offset+4])
+	offset += 5
+
+	if offset+3*fieldCount > len(data) {
+		return nil, 0, errors.New("truncated FIT field definitions")
+	}
+	for index := 0; index < fieldCount; index++ {
+		field := fitFieldDefinition{
+			number:   data[offset],
+			size:     int(data[offset+1]),
+			baseType: data[offset+2],
+		}
+		definition.fields = append(definition.fields, field)
+		definition.size += field.size
+		offset += 3
+	}
+
+	if !hasDeveloperFields {
+		return definition, offset, nil
+	}
+	if offset >= len(data) {
+		return nil, 0, errors.New("truncated FIT developer field definitions")
+	}
+	developerFieldCount := int(data[offset])
+	offset++
+	if offset+3*developerFieldCount > len(data) {
+		return nil, 0, errors.New("truncated FIT developer field definitions")
+	}
+	for index := 0; index < developerFieldCount; index++ {
+		field := fitDeveloperFieldDefinition{
+			key:  fitDeveloperFieldKey{fieldNumber: data[offset], developerDataIndex: data[offset+2]},
+			size: int(data[offset+1]),
+		}
+		definition.developerFields = append(definition.developerFields, field)
+		definition.size += field.size
+		offset += 3
+	}
+	return definition, offset, nil
+}
+
+func readFITRecordExtras(
+	definition *fitMessageDefinition,
+	message []byte,
+	descriptions []fitDeveloperFieldDescription,
+	descriptionIndex map[fitDeveloperFieldKey]int,
+) fitRecordExtras {
+	extras := fitRecordExtras{stepLength: math.NaN(), coreTemperature: math.NaN()}
+	offset := 0
+	for _, field := range definition.fields {
+		raw := message[offset : offset+field.size]
+		offset += field.size
+		switch field.number {
+		case fitRecordFieldStepLength:
+			if value, ok := fitNumericValue(raw, field.baseType, definition.byteOrder); ok {
+				// Scale 10, millimeters.
+				extras.stepLength = value / 10 / 1000
+			}
+		case fitRecordFieldCoreTemperature:
+			if value, ok := fitNumericValue(raw, field.baseType, definition.byteOrder); ok {
+				extras.coreTemperature = value / 100
+			}
+		}
+	}
+	for _, field := range definition.developerFields {
+		raw := message[offset : offset+field.size]
+		offset += field.size
+		index, found := descriptionIndex[field.key]
+		if !found {
+			continue
+		}
+		description := descriptions[index]
+		value, ok := fitNumericValue(raw, description.baseType, definition.byteOrder)
+		if !ok {
+			continue
+		}
+		if extras.developer == nil {
+			extras.developer = make(map[fitDeveloperFieldKey]float64)
+		}
+		extras.developer[field.key] = value/description.scale - description.offset
+	}
+	return extras
+}
+
+func readFITFieldDescription(definition *fitMessageDefinition, message []byte) (fitDeveloperFieldDescription, bool) {
+	description := fitDeveloperFieldDescription{scale: 1}
+	hasIndex, hasNumber := false, false
+	nativeMessage := uint16(math.MaxUint16)
+	nativeField := fitInvalidUint8
+
+	offset := 0
+	for _, field := range definition.fields {
+		raw := message[offset : offset+field.size]
+		offset += field.size
+		if len(raw) == 0 {
+			continue
+		}
+		switch field.number {
+		case 0:
+			description.key.developerDataIndex = raw[0]
+			hasIndex = raw[0] != fitInvalidUint8
+		case 1:
+			description.key.fieldNumber = raw[0]
+			hasNumber = raw[0] != fitInvalidUint8
+		case 2:
+			description.baseType = raw[0]
+		case 3:
+			description.name = fitStringValue(raw)
+		case 6:
+			if raw[0] != fitInvalidUint8 && raw[0] != 0 {
+				description.scale = float64(raw[0])
+			}
+		case 7:
+			if int8(raw[0]) != math.MaxInt8 {
+				description.offset = float64(int8(raw[0]))
+			}
+		case 8:
+			description.units = fitStringValue(raw)
+		case 14:
+			if len(raw) >= 2 {
+				nativeMessage = definition.byteOrder.Uint16(raw)
+			}
+		case 15:
+			nativeField = raw[0]
+		}
+	}
+	if nativeMessage == fitMesgNumRecord {
+		description.nativeField = fitNativeRecordFields[nativeField]
+	}
+	if description.name == "" {
+		description.name = fmt.Sprintf("field_%d_%d", description.key.developerDataIndex, description.key.fieldNumber)
+	}
+	return description, hasIndex && hasNumber
+}
+
+// fitNumericValue reads the first element of a numeric field. Invalid values,
+// strings and byte arrays are reported as missing.
+func fitNumericValue(raw []byte, baseType byte, byteOrder binary.ByteOrder) (float64, bool) {
+	switch baseType & 0x1F {
+	case 0x00, 0x02: // enum, uint8
+		if len(raw) < 1 || raw[0] == 0xFF {
+			return 0, false
+		}
+		return float64(raw[0]), true
+	case 0x0A: // uint8z
+		if len(raw) < 1 || raw[0] == 0 {
+			return 0, false
+		}
+		return float64(raw[0]), true
+	case 0x01: // sint8
+		if len(raw) < 1 || int8(raw[0]) == math.MaxInt8 {
+			return 0, false
+		}
+		return float64(int8(raw[0])), true
+	case 0x03: // sint16
+		if len(raw) < 2 {
+			return 0, false
+		}
+		value := int16(byteOrder.Uint16(raw))
+		return float64(value), value != math.MaxInt16
+	case 0x04, 0x0B: // uint16, uint16z
+		if len(raw) < 2 {
+			return 0, false
+		}
+		value := byteOrder.Uint16(raw)
+		if baseType&0x1F == 0x0B {
+			return float64(value), value != 0
+		}
+		return float64(value), value != math.MaxUint16
+	case 0x05: // sint32
+		if len(raw) < 4 {
+			return 0, false
+		}
+		value := int32(byteOrder.Uint32(raw))
+		return float64(value), value != math.MaxInt32
+	case 0x06, 0x0C: // uint32, uint32z
+		if len(raw) < 4 {
+			return 0, false
+		}
+		value := byteOrder.Uint32(raw)
+		if baseType&0x1F == 0x0C {
+			return float64(value), value != 0
+		}
+		return float64(value), value != math.MaxUint32
+	case 0x08: // float32
+		if len(raw) < 4 {
+			return 0, false
+		}
+		value := float64(math.Float32frombits(byteOrder.Uint32(raw)))
+		return value, isFinite(value)
+	case 0x09: // float64
+		if len(raw) < 8 {
+			return 0, false
+		}
+		value := math.Float64frombits(byteOrder.Uint64(raw))
+		return value, isFinite(value)
+	case 0x0E: // sint64
+		if len(raw) < 8 {
+			return 0, false
+		}
+		value := int64(byteOrder.Uint64(raw))
+		return float64(value), value != math.MaxInt64
+	case 0x0F, 0x10: // uint64, uint64z
+		if len(raw) < 8 {
+			return 0, false
+		}
+		value := byteOrder.Uint64(raw)
+		if baseType&0x1F == 0x10 {
+			return float64(value), value != 0
+		}
+		return float64(value), value != math.MaxUint64
+	default:
+		return 0, false
+	}
+}
+
+func fitStringValue(raw []byte) string {
+	for index, value := range raw {
+		if value == 0 {
+			return string(raw[:index])
+		}
+	}
+	return string(raw)
+}
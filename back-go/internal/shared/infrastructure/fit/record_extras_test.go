@@ -0,0 +1,146 @@
+package fit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	fitparser "github.com/tormoder/fit"
+)
+
+func TestScanFITRecordExtras_ReadsStepLengthCoreTemperatureAndDeveloperFields(t *testing.T) {
+	// GIVEN
+	content := testFITWithDeveloperPower()
+
+	// WHEN
+	scan, err := scanFITRecordExtras(content)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("expected scan to succeed, got %v", err)
+	}
+	if len(scan.developerFields) != 1 {
+		t.Fatalf("expected one developer field, got %#v", scan.developerFields)
+	}
+	description := scan.developerFields[0]
+	if description.name != "Power" || description.units != "Watts" || description.nativeField != "power" {
+		t.Fatalf("expected Stryd-like power description, got %#v", description)
+	}
+	if len(scan.records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(scan.records))
+	}
+	assertFloatEquals(t, 1.2, scan.records[0].stepLength)
+	assertFloatEquals(t, 37.5, scan.records[0].coreTemperature)
+	assertFloatEquals(t, 250, scan.records[0].developer[description.key])
+	if !math.IsNaN(scan.records[1].stepLength) {
+		t.Fatalf("expected invalid step length to be missing, got %f", scan.records[1].stepLength)
+	}
+	assertFloatEquals(t, 260, scan.records[1].developer[description.key])
+}
+
+func TestBuildStreamFromFITRecords_KeepsTemperatureBalanceAndRunningDynamics(t *testing.T) {
+	// GIVEN
+	start := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	records := []*fitparser.RecordMsg{
+		testFITRecord(start, 21, fitparser.LeftRightBalanceRight|52, 95, 2480),
+		testFITRecord(start.Add(time.Second), fitInvalidSint8, fitparser.LeftRightBalanceInvalid, 0xFFFF, 0xFFFF),
+	}
+	scan, err := scanFITRecordExtras(testFITWithDeveloperPower())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// WHEN
+	stream := buildStreamFromFITRecords(records, start)
+	addFITRecordExtras(stream, records, scan)
+
+	// THEN
+	if stream.Temperature == nil || stream.Temperature.Data[0] != 21 || stream.Temperature.Data[1] != 21 {
+		t.Fatalf("expected temperature carried over missing samples, got %#v", stream.Temperature)
+	}
+	if stream.LeftRightBalance == nil || stream.LeftRightBalance.Data[0] != 48 || stream.LeftRightBalance.Data[1] != 0 {
+		t.Fatalf("expected left leg balance of 48%%, got %#v", stream.LeftRightBalance)
+	}
+	if stream.VerticalOscillation == nil || stream.VerticalOscillation.Data[0] != 9.5 {
+		t.Fatalf("expected vertical oscillation in mm, got %#v", stream.VerticalOscillation)
+	}
+	if stream.GroundContactTime == nil || stream.GroundContactTime.Data[0] != 248 {
+		t.Fatalf("expected ground contact time in ms, got %#v", stream.GroundContactTime)
+	}
+	if stream.StrideLength == nil || stream.StrideLength.Data[0] != 1.2 || stream.StrideLength.Data[1] != 0 {
+		t.Fatalf("expected stride length in meters, got %#v", stream.StrideLength)
+	}
+	if stream.CoreTemperature == nil || stream.CoreTemperature.Data[1] != 37.5 {
+		t.Fatalf("expected core temperature carried over, got %#v", stream.CoreTemperature)
+	}
+	if len(stream.DeveloperFields) != 1 || stream.DeveloperFields[0].Name != "Power" || stream.DeveloperFields[0].Data[1] != 260 {
+		t.Fatalf("expected developer power channel, got %#v", stream.DeveloperFields)
+	}
+}
+
+func TestAddFITRecordExtras_IgnoresScanNotMatchingRecords(t *testing.T) {
+	// GIVEN
+	start := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	records := []*fitparser.RecordMsg{testFITRecord(start, fitInvalidSint8, fitparser.LeftRightBalanceInvalid, 0xFFFF, 0xFFFF)}
+	scan, err := scanFITRecordExtras(testFITWithDeveloperPower())
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := buildStreamFromFITRecords(records, start)
+
+	// WHEN
+	addFITRecordExtras(stream, records, scan)
+
+	// THEN
+	if stream.StrideLength != nil || stream.CoreTemperature != nil || len(stream.DeveloperFields) != 0 {
+		t.Fatalf("expected no extra channels when record counts differ, got %#v", stream)
+	}
+}
+
+func testFITRecord(timestamp time.Time, temperature int8, balance fitparser.LeftRightBalance, verticalOscillation uint16, stanceTime uint16) *fitparser.RecordMsg {
+	record := fitparser.NewRecordMsg()
+	record.Timestamp = timestamp
+	record.Temperature = temperature
+	record.LeftRightBalance = balance
+	record.VerticalOscillation = verticalOscillation
+	record.StanceTime = stanceTime
+	return record
+}
+
+// testFITWithDeveloperPower builds a FIT file with a developer power field
+// description and two records, the second one using a compressed timestamp
+// header.
+func testFITWithDeveloperPower() []byte {
+	var messages bytes.Buffer
+	write := func(values ...any) {
+		for _, value := range values {
+			_ = binary.Write(&messages, binary.LittleEndian, value)
+		}
+	}
+	fixedString := func(value string, size int) []byte {
+		raw := make([]byte, size)
+		copy(raw, value)
+		return raw
+	}
+
+	// field_description definition (local 0) and message.
+	write(uint8(0x40), uint8(0), uint8(0), uint16(fitMesgNumFieldDescription), uint8(7))
+	write([]byte{0, 1, 0x02}, []byte{1, 1, 0x02}, []byte{2, 1, 0x02}, []byte{3, 16, 0x07}, []byte{8, 8, 0x07}, []byte{14, 2, 0x84}, []byte{15, 1, 0x02})
+	write(uint8(0x00), uint8(0), uint8(0), uint8(0x84), fixedString("Power", 16), fixedString("Watts", 8), uint16(fitMesgNumRecord), uint8(7))
+
+	// record definition (local 1) with one developer field.
+	write(uint8(0x61), uint8(0), uint8(0), uint16(fitMesgNumRecord), uint8(3))
+	write([]byte{253, 4, 0x86}, []byte{fitRecordFieldStepLength, 2, 0x84}, []byte{fitRecordFieldCoreTemperature, 2, 0x84})
+	write(uint8(1), []byte{0, 2, 0})
+	write(uint8(0x01), uint32(1_000_000_000), uint16(12000), uint16(3750), uint16(250))
+	write(uint8(0xA5), uint32(1_000_000_001), uint16(0xFFFF), uint16(0xFFFF), uint16(260))
+
+	var file bytes.Buffer
+	_ = binary.Write(&file, binary.LittleEndian, []byte{12, 0x20, 0, 0})
+	_ = binary.Write(&file, binary.LittleEndian, uint32(messages.Len()))
+	file.WriteString(".FIT")
+	file.Write(messages.Bytes())
+	return file.Bytes()
+}
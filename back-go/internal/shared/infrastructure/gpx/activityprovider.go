@@ -18,10 +18,16 @@ import (
 	"mystravastats/internal/helpers"
 	"mystravastats/internal/shared/domain/business"
 	"mystravastats/internal/shared/domain/strava"
+	"mystravastats/internal/shared/infrastructure/localindex"
 	"mystravastats/internal/shared/infrastructure/localrepository"
 )
 
-const firstSupportedYear = 2010
+const (
+	firstSupportedYear = 2010
+	// gpxDecoderVersion must be bumped when DecodeGPXActivity output changes so
+	// the persistent index is rebuilt instead of serving stale summaries.
+	gpxDecoderVersion = "gpx-decoder-v1"
+)
 
 type GPXActivityProvider struct {
 	gpxDirectory          string
@@ -33,6 +39,7 @@ type GPXActivityProvider struct {
 	heartRateZoneSettings business.HeartRateZoneSettings
 	performanceSettings   business.AthletePerformanceSettings
 	localStorageProvider  *localrepository.StravaRepository
+	index                 *localindex.Index
 	dataMutex             sync.RWMutex
 	cacheMutex            sync.RWMutex
 }
@@ -55,6 +62,7 @@ func NewGPXActivityProvider(gpxDirectory string) *GPXActivityProvider {
 		gpxDirectory:         resolvedDirectory,
 		clientID:             clientID,
 		localStorageProvider: localStorageProvider,
+		index:                localindex.Open(resolvedDirectory, clientID, "gpx", gpxDecoderVersion),
 		stravaAthlete: strava.Athlete{
 			Id:        athleteID,
 			Firstname: &firstName,
@@ -189,6 +197,7 @@ func (provider *GPXActivityProvider) CacheDiagnostics() map[string]any {
 		"athleteId":         provider.clientID,
 		"activities":        activitiesCount,
 		"availableYearBins": years,
+		"index":             provider.index.Diagnostics(),
 	}
 }
 
@@ -200,9 +209,18 @@ func (provider *GPXActivityProvider) CacheRootPath() string {
 	return provider.gpxDirectory
 }
 
+// SourceFile returns the GPX file an activity was decoded from.
+func (provider *GPXActivityProvider) SourceFile(activityID int64) (string, bool) {
+	return provider.index.SourceFile(activityID)
+}
+
+func (provider *GPXActivityProvider) Reload() {
+	provider.replaceActivities(provider.loadActivitiesFromGPXDirectory())
+}
+
 func (provider *GPXActivityProvider) loadActivitiesFromGPXDirectory() []*strava.Activity {
 	start := time.Now()
-	loadedActivities := make([]*strava.Activity, 0)
+	files := make([]localindex.File, 0)
 
 	for year := time.Now().Year(); year >= firstSupportedYear; year-- {
 		yearDirectory := filepath.Join(provider.gpxDirectory, strconv.Itoa(year))
@@ -218,17 +236,14 @@ func (provider *GPXActivityProvider) loadActivitiesFromGPXDirectory() []*strava.
 			if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".gpx") {
 				continue
 			}
-
-			filePath := filepath.Join(yearDirectory, entry.Name())
-			activity, decodeErr := DecodeGPXActivity(filePath, provider.stravaAthlete.Id, year)
-			if decodeErr != nil {
-				log.Printf("Unable to decode GPX activity %s: %v", filePath, decodeErr)
-				continue
-			}
-			loadedActivities = append(loadedActivities, activity)
+			files = append(files, localindex.File{Path: filepath.Join(yearDirectory, entry.Name()), Year: year})
 		}
 	}
 
+	loadedActivities, stats := provider.index.Load(files, func(file localindex.File) (*strava.Activity, error) {
+		return DecodeGPXActivity(file.Path, provider.stravaAthlete.Id, file.Year)
+	})
+
 	sort.SliceStable(loadedActivities, func(i, j int) bool {
 		left, leftOK := helpers.ParseActivityDate(loadedActivities[i].StartDateLocal)
 		right, rightOK := helpers.ParseActivityDate(loadedActivities[j].StartDateLocal)
@@ -244,7 +259,7 @@ func (provider *GPXActivityProvider) loadActivitiesFromGPXDirectory() []*strava.
 		}
 	})
 
-	log.Printf("Loaded %d GPX activities in %s", len(loadedActivities), time.Since(start))
+	log.Printf("Loaded %d GPX activities in %s (index hits=%d decoded=%d removed=%d)", len(loadedActivities), time.Since(start), stats.Hits, stats.Decoded, stats.Removed)
 	return loadedActivities
 }
 
@@ -0,0 +1,514 @@
+package jsonexport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mystravastats/internal/helpers"
+	"mystravastats/internal/shared/domain/business"
+	"mystravastats/internal/shared/domain/strava"
+	"mystravastats/internal/shared/infrastructure/localindex"
+	"mystravastats/internal/shared/infrastructure/localrepository"
+	"mystravastats/internal/shared/infrastructure/localtrack"
+)
+
+const (
+	firstSupportedYear = 2010
+	// jsonDecoderVersion must be bumped when DecodeJSONActivity output changes so
+	// the persistent index is rebuilt instead of serving stale summaries.
+	jsonDecoderVersion = "json-export-decoder-v1"
+)
+
+// JSONActivityProvider serves Suunto app and Coros JSON exports stored in the
+// same <year>/ folders as the FIT, GPX and TCX sources.
+type JSONActivityProvider struct {
+	jsonDirectory         string
+	clientID              string
+	stravaAthlete         strava.Athlete
+	activities            []*strava.Activity
+	activityByID          map[int64]*strava.Activity
+	filteredActivities    map[string][]*strava.Activity
+	heartRateZoneSettings business.HeartRateZoneSettings
+	performanceSettings   business.AthletePerformanceSettings
+	localStorageProvider  *localrepository.StravaRepository
+	index                 *localindex.Index
+	dataMutex             sync.RWMutex
+	cacheMutex            sync.RWMutex
+}
+
+func NewJSONActivityProvider(jsonDirectory string) *JSONActivityProvider {
+	resolvedDirectory := strings.TrimSpace(jsonDirectory)
+	if resolvedDirectory == "" {
+		resolvedDirectory = "."
+	}
+	resolvedDirectory = filepath.Clean(resolvedDirectory)
+
+	clientID := deriveJSONClientID(resolvedDirectory)
+	firstName := deriveFirstNameFromJSONDirectory(resolvedDirectory)
+	athleteID := int64(localtrack.HashStringToInt("athlete:" + clientID))
+
+	localStorageProvider := localrepository.NewStravaRepository(resolvedDirectory)
+	localStorageProvider.InitLocalStorageForClientId(clientID)
+
+	provider := &JSONActivityProvider{
+		jsonDirectory:        resolvedDirectory,
+		clientID:             clientID,
+		localStorageProvider: localStorageProvider,
+		index:                localindex.Open(resolvedDirectory, clientID, "json", jsonDecoderVersion),
+		stravaAthlete: strava.Athlete{
+			Id:        athleteID,
+			Firstname: &firstName,
+		},
+		heartRateZoneSettings: localStorageProvider.LoadHeartRateZoneSettings(clientID),
+		performanceSettings:   localStorageProvider.LoadPerformanceSettings(clientID),
+	}
+
+	loadedActivities := provider.loadActivitiesFromJSONDirectory()
+	provider.replaceActivities(loadedActivities)
+
+	log.Printf("Initialize JSONActivityProvider using %s ...", provider.jsonDirectory)
+	log.Printf("✅ JSON mode ready with profile=%s and %d activities", provider.clientID, len(loadedActivities))
+
+	return provider
+}
+
+func (provider *JSONActivityProvider) GetDetailedActivity(activityID int64) *strava.DetailedActivity {
+	activity := provider.findActivityByID(activityID)
+	if activity == nil {
+		return nil
+	}
+	return activity.ToStravaDetailedActivity()
+}
+
+func (provider *JSONActivityProvider) GetCachedDetailedActivity(activityID int64) *strava.DetailedActivity {
+	return provider.GetDetailedActivity(activityID)
+}
+
+func (provider *JSONActivityProvider) GetActivitiesByYearAndActivityTypes(year *int, activityTypes ...business.ActivityType) []*strava.Activity {
+	cacheKey := localtrack.FilterCacheKey(year, activityTypes...)
+	provider.cacheMutex.RLock()
+	if cachedActivities, ok := provider.filteredActivities[cacheKey]; ok {
+		provider.cacheMutex.RUnlock()
+		return localtrack.ClonePointers(cachedActivities)
+	}
+	provider.cacheMutex.RUnlock()
+
+	filteredActivities := localtrack.FilterByYear(provider.getActivitiesSnapshot(), year)
+	filteredActivities = localtrack.FilterByType(filteredActivities, activityTypes...)
+
+	provider.cacheMutex.Lock()
+	provider.filteredActivities[cacheKey] = filteredActivities
+	provider.cacheMutex.Unlock()
+
+	return localtrack.ClonePointers(filteredActivities)
+}
+
+func (provider *JSONActivityProvider) GetActivitiesByActivityTypeGroupByYear(activityTypes ...business.ActivityType) map[string][]*strava.Activity {
+	filteredActivities := localtrack.FilterByType(provider.getActivitiesSnapshot(), activityTypes...)
+	return localtrack.GroupByYear(filteredActivities)
+}
+
+func (provider *JSONActivityProvider) GetActivitiesByActivityTypeGroupByActiveDays(activityTypes ...business.ActivityType) map[string]int {
+	filteredActivities := localtrack.FilterByType(provider.getActivitiesSnapshot(), activityTypes...)
+	result := make(map[string]int)
+	for _, activity := range filteredActivities {
+		date := localtrack.ExtractSortableDay(activity.StartDateLocal)
+		if date == "" {
+			continue
+		}
+		result[date] += int(activity.Distance / 1000)
+	}
+	return result
+}
+
+func (provider *JSONActivityProvider) GetAthlete() strava.Athlete {
+	return provider.stravaAthlete
+}
+
+func (provider *JSONActivityProvider) GetHeartRateZoneSettings() business.HeartRateZoneSettings {
+	provider.dataMutex.RLock()
+	defer provider.dataMutex.RUnlock()
+
+	return provider.heartRateZoneSettings
+}
+
+func (provider *JSONActivityProvider) SaveHeartRateZoneSettings(settings business.HeartRateZoneSettings) business.HeartRateZoneSettings {
+	provider.dataMutex.Lock()
+	provider.heartRateZoneSettings = settings
+	provider.dataMutex.Unlock()
+
+	provider.localStorageProvider.SaveHeartRateZoneSettings(provider.clientID, settings)
+	return settings
+}
+
+func (provider *JSONActivityProvider) GetPerformanceSettings() business.AthletePerformanceSettings {
+	provider.dataMutex.RLock()
+	defer provider.dataMutex.RUnlock()
+
+	return provider.performanceSettings
+}
+
+func (provider *JSONActivityProvider) SavePerformanceSettings(settings business.AthletePerformanceSettings) business.AthletePerformanceSettings {
+	provider.dataMutex.Lock()
+	provider.performanceSettings = settings
+	provider.dataMutex.Unlock()
+
+	provider.localStorageProvider.SavePerformanceSettings(provider.clientID, settings)
+	return settings
+}
+
+func (provider *JSONActivityProvider) CacheDiagnostics() map[string]any {
+	provider.dataMutex.RLock()
+	activitiesCount := len(provider.activities)
+	provider.dataMutex.RUnlock()
+
+	yearsSet := make(map[string]struct{})
+	for _, activity := range provider.getActivitiesSnapshot() {
+		if activity == nil {
+			continue
+		}
+		year := localtrack.ExtractYear(activity.StartDateLocal)
+		if year == "" {
+			year = localtrack.ExtractYear(activity.StartDate)
+		}
+		if year != "" {
+			yearsSet[year] = struct{}{}
+		}
+	}
+
+	years := make([]string, 0, len(yearsSet))
+	for year := range yearsSet {
+		years = append(years, year)
+	}
+	sort.Strings(years)
+
+	return map[string]any{
+		"timestamp":         time.Now().UTC().Format(time.RFC3339),
+		"provider":          "json",
+		"jsonDirectory":     provider.jsonDirectory,
+		"athleteId":         provider.clientID,
+		"activities":        activitiesCount,
+		"availableYearBins": years,
+		"index":             provider.index.Diagnostics(),
+	}
+}
+
+func (provider *JSONActivityProvider) ClientID() string {
+	return provider.clientID
+}
+
+func (provider *JSONActivityProvider) CacheRootPath() string {
+	return provider.jsonDirectory
+}
+
+// SourceFile returns the JSON export an activity was decoded from.
+func (provider *JSONActivityProvider) SourceFile(activityID int64) (string, bool) {
+	return provider.index.SourceFile(activityID)
+}
+
+func (provider *JSONActivityProvider) Reload() {
+	provider.replaceActivities(provider.loadActivitiesFromJSONDirectory())
+}
+
+func (provider *JSONActivityProvider) loadActivitiesFromJSONDirectory() []*strava.Activity {
+	start := time.Now()
+	files := make([]localindex.File, 0)
+
+	for year := time.Now().Year(); year >= firstSupportedYear; year-- {
+		yearDirectory := filepath.Join(provider.jsonDirectory, strconv.Itoa(year))
+		yearEntries, err := os.ReadDir(yearDirectory)
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				log.Printf("Unable to list JSON directory %s: %v", yearDirectory, err)
+			}
+			continue
+		}
+
+		for _, entry := range yearEntries {
+			if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".json") {
+				continue
+			}
+			files = append(files, localindex.File{Path: filepath.Join(yearDirectory, entry.Name()), Year: year})
+		}
+	}
+
+	loadedActivities, stats := provider.index.Load(files, func(file localindex.File) (*strava.Activity, error) {
+		return DecodeJSONActivity(file.Path, provider.stravaAthlete.Id)
+	})
+
+	sort.SliceStable(loadedActivities, func(i, j int) bool {
+		left, leftOK := helpers.ParseActivityDate(loadedActivities[i].StartDateLocal)
+		right, rightOK := helpers.ParseActivityDate(loadedActivities[j].StartDateLocal)
+		switch {
+		case leftOK && rightOK:
+			return left.After(right)
+		case leftOK && !rightOK:
+			return true
+		case !leftOK && rightOK:
+			return false
+		default:
+			return loadedActivities[i].StartDateLocal > loadedActivities[j].StartDateLocal
+		}
+	})
+
+	log.Printf("Loaded %d JSON activities in %s (index hits=%d decoded=%d removed=%d)", len(loadedActivities), time.Since(start), stats.Hits, stats.Decoded, stats.Removed)
+	return loadedActivities
+}
+
+// exportActivity is the vendor-neutral view of a decoded export. Totals are
+// zero when the export does not carry them and are then derived from points.
+type exportActivity struct {
+	sportType       string
+	name            string
+	startTime       time.Time
+	distance        float64
+	durationSeconds float64
+	points          []localtrack.Point
+}
+
+// exportDocument is only used to detect the vendor from the document root.
+type exportDocument struct {
+	DeviceLog     json.RawMessage `json:"DeviceLog"`
+	Data          json.RawMessage `json:"data"`
+	FrequencyList json.RawMessage `json:"frequencyList"`
+}
+
+// DecodeJSONActivity decodes a Suunto app or Coros JSON export. The vendor is
+// detected from the document root: Suunto exports hold a DeviceLog, Coros
+// exports a frequencyList, optionally wrapped in a data object.
+func DecodeJSONActivity(filePath string, athleteID int64) (*strava.Activity, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	export, err := parseExport(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(export.points) < 2 {
+		return nil, errors.New("JSON export must contain at least 2 samples")
+	}
+
+	startTime := export.startTime
+	if startTime.IsZero() {
+		startTime = export.points[0].Timestamp
+	}
+	if startTime.IsZero() {
+		return nil, errors.New("JSON export has no start time")
+	}
+
+	stream, stats := localtrack.BuildStream(export.points, startTime)
+	if stream == nil || len(stream.Distance.Data) == 0 || len(stream.Time.Data) == 0 {
+		return nil, errors.New("JSON export has no usable stream")
+	}
+
+	sportType := export.sportType
+	if sportType == "" {
+		sportType = business.Ride.String()
+	}
+
+	distance := export.distance
+	if distance <= 0 {
+		distance = stats.DistanceMeters
+	}
+
+	elapsedTime := stats.ElapsedTime
+	if elapsedTime <= 0 {
+		elapsedTime = localtrack.RoundedNonNegative(export.durationSeconds)
+	}
+
+	movingTime := stats.MovingTime
+	if movingTime <= 0 {
+		movingTime = elapsedTime
+	}
+
+	averageSpeed := 0.0
+	if movingTime > 0 {
+		averageSpeed = distance / float64(movingTime)
+	}
+
+	averageWatts := localtrack.AverageFloat(stats.PowerData)
+	startDateUTC := startTime.UTC()
+	startDateLocal := helpers.ActivityLocalTime(startDateUTC)
+	activityID := jsonActivityID(filePath, startDateUTC, sportType, distance)
+
+	name := strings.TrimSpace(export.name)
+	if name == "" {
+		name = fmt.Sprintf("%s - %s", sportType, startDateLocal.Format("2006-01-02 15:04:05"))
+	}
+
+	return &strava.Activity{
+		Athlete:              strava.AthleteRef{ID: int(athleteID)},
+		AverageSpeed:         averageSpeed,
+		AverageCadence:       localtrack.AverageInt(stats.CadenceData),
+		AverageHeartrate:     localtrack.AverageInt(stats.HeartRateData),
+		MaxHeartrate:         float64(localtrack.MaxInt(stats.HeartRateData)),
+		AverageWatts:         averageWatts,
+		Commute:              false,
+		Distance:             distance,
+		DeviceWatts:          localtrack.HasAnyFloat(stats.PowerData),
+		ElapsedTime:          elapsedTime,
+		ElevHigh:             localtrack.MaxFloat(stats.AltitudeData),
+		Id:                   activityID,
+		Kilojoules:           0.8604 * averageWatts * float64(elapsedTime) / 1000,
+		MaxSpeed:             localtrack.MaxFloat(stats.VelocityData),
+		MovingTime:           movingTime,
+		Name:                 name,
+		SportType:            sportType,
+		StartDate:            startDateUTC.Format(time.RFC3339),
+		StartDateLocal:       startDateLocal.Format(time.RFC3339),
+		StartLatlng:          localtrack.FirstCoordinate(export.points),
+		TotalElevationGain:   stats.ElevationGainMeters,
+		Type:                 sportType,
+		UploadId:             activityID,
+		WeightedAverageWatts: int(math.Round(averageWatts)),
+		Stream:               stream,
+	}, nil
+}
+
+func parseExport(data []byte) (exportActivity, error) {
+	var document exportDocument
+	if err := json.Unmarshal(data, &document); err != nil {
+		return exportActivity{}, err
+	}
+	switch {
+	case len(document.DeviceLog) > 0:
+		return parseSuuntoExport(document.DeviceLog)
+	case len(document.FrequencyList) > 0:
+		return parseCorosExport(data)
+	case len(document.Data) > 0:
+		return parseCorosExport(document.Data)
+	default:
+		return exportActivity{}, errors.New("unsupported JSON export: expected a Suunto DeviceLog or a Coros frequencyList")
+	}
+}
+
+// sortExportPoints orders samples chronologically; vendors do not guarantee it.
+func sortExportPoints(points []localtrack.Point) {
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].Timestamp.Before(points[j].Timestamp)
+	})
+}
+
+func parseExportTime(value string) time.Time {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return time.Time{}
+	}
+	layouts := []string{
+		time.RFC3339Nano,
+		time.RFC3339,
+		"2006-01-02T15:04:05Z0700",
+		"2006-01-02T15:04:05",
+	}
+	for _, layout := range layouts {
+		parsed, err := time.Parse(layout, trimmed)
+		if err == nil {
+			return parsed
+		}
+	}
+	return time.Time{}
+}
+
+// mapSportNameToActivityType maps the sport names written by Suunto and the
+// Coros sport labels to the activity types used by the statistics.
+func mapSportNameToActivityType(sport string) string {
+	normalized := strings.ToLower(strings.TrimSpace(sport))
+	normalized = strings.NewReplacer("_", " ", "-", " ").Replace(normalized)
+	switch normalized {
+	case "running", "run", "treadmill", "indoor running", "track running":
+		return business.Run.String()
+	case "trail running", "trail run":
+		return business.TrailRun.String()
+	case "cycling", "biking", "ride", "road cycling", "road bike":
+		return business.Ride.String()
+	case "gravel cycling", "gravel bike", "gravel ride":
+		return business.GravelRide.String()
+	case "mountain biking", "mountain bike", "mtb":
+		return business.MountainBikeRide.String()
+	case "indoor cycling", "indoor bike", "virtual ride":
+		return business.VirtualRide.String()
+	case "walking", "walk":
+		return business.Walk.String()
+	case "hiking", "hike", "mountaineering", "mountain climb":
+		return business.Hike.String()
+	case "downhill skiing", "alpine skiing", "ski":
+		return business.AlpineSki.String()
+	case "inline skating", "inline skate":
+		return business.InlineSkate.String()
+	default:
+		return business.Ride.String()
+	}
+}
+
+func deriveJSONClientID(jsonDirectory string) string {
+	base := strings.TrimSpace(filepath.Base(jsonDirectory))
+	base = strings.ToLower(base)
+	if base == "" || base == "." || base == string(filepath.Separator) {
+		return "json-local"
+	}
+	base = strings.ReplaceAll(base, " ", "-")
+	return base
+}
+
+func deriveFirstNameFromJSONDirectory(jsonDirectory string) string {
+	base := strings.TrimSpace(filepath.Base(jsonDirectory))
+	if strings.HasPrefix(strings.ToLower(base), "json-") && len(base) > 5 {
+		return base[5:]
+	}
+	if base != "" && base != "." {
+		return base
+	}
+	return "JSON User"
+}
+
+func jsonActivityID(filePath string, startDate time.Time, sportType string, distanceMeters float64) int64 {
+	identity := fmt.Sprintf("%s|%s|%s|%.3f", filePath, startDate.UTC().Format(time.RFC3339), sportType, distanceMeters)
+	return int64(localtrack.HashStringToInt(identity))
+}
+
+func (provider *JSONActivityProvider) findActivityByID(activityID int64) *strava.Activity {
+	provider.dataMutex.RLock()
+	defer provider.dataMutex.RUnlock()
+	if provider.activityByID == nil {
+		return nil
+	}
+	return provider.activityByID[activityID]
+}
+
+func (provider *JSONActivityProvider) replaceActivities(activities []*strava.Activity) {
+	provider.dataMutex.Lock()
+	provider.activities = activities
+	provider.activityByID = make(map[int64]*strava.Activity, len(activities))
+	for _, activity := range activities {
+		if activity == nil {
+			continue
+		}
+		provider.activityByID[activity.Id] = activity
+	}
+	provider.dataMutex.Unlock()
+
+	provider.cacheMutex.Lock()
+	provider.filteredActivities = make(map[string][]*strava.Activity)
+	provider.cacheMutex.Unlock()
+}
+
+func (provider *JSONActivityProvider) getActivitiesSnapshot() []*strava.Activity {
+	provider.dataMutex.RLock()
+	defer provider.dataMutex.RUnlock()
+
+	snapshot := make([]*strava.Activity, len(provider.activities))
+	copy(snapshot, provider.activities)
+	return snapshot
+}
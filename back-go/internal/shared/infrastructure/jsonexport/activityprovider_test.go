@@ -0,0 +1,149 @@
+package jsonexport
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"mystravastats/internal/shared/domain/business"
+)
+
+func TestDecodeJSONActivity_MapsSuuntoDeviceLogToActivityWithStreams(t *testing.T) {
+	// GIVEN
+	jsonFile := writeTestJSON(t, t.TempDir(), "2026", "suunto.json", `{
+  "DeviceLog": {
+    "Header": {"Activity": "Trail running", "DateTime": "2026-04-01T10:00:00.000+02:00", "Distance": 200, "Duration": 120},
+    "Samples": [
+      {"TimeISO8601": "2026-04-01T10:00:00.000+02:00", "HR": 2.0, "Cadence": 1.4, "Distance": 0, "Altitude": 100},
+      {"TimeISO8601": "2026-04-01T10:00:00.400+02:00", "Latitude": 0.83950337, "Longitude": -0.027925268},
+      {"TimeISO8601": "2026-04-01T10:02:00.000+02:00", "HR": 2.5, "Cadence": 1.5, "Distance": 200, "Altitude": 110, "Power": 250, "Latitude": 0.839520824, "Longitude": -0.027925268},
+      {"TimeISO8601": "2026-04-01T10:01:00.000+02:00", "HR": 2.25, "Distance": 100, "Altitude": 105},
+      {"R-R": {"Data": [500, 510]}}
+    ]
+  }
+}`)
+
+	// WHEN
+	activity, err := DecodeJSONActivity(jsonFile, 42)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("expected Suunto export to decode, got error: %v", err)
+	}
+	if activity.SportType != business.TrailRun.String() {
+		t.Fatalf("expected TrailRun sport type, got %q", activity.SportType)
+	}
+	if activity.StartDate != "2026-04-01T08:00:00Z" {
+		t.Fatalf("expected UTC start date, got %q", activity.StartDate)
+	}
+	if activity.Distance != 200 || activity.ElapsedTime != 120 {
+		t.Fatalf("expected 200m in 120s, got %f/%d", activity.Distance, activity.ElapsedTime)
+	}
+	if activity.AverageHeartrate != 135 || activity.MaxHeartrate != 150 {
+		t.Fatalf("expected heart rate converted from Hz (avg 135, max 150), got %f/%f", activity.AverageHeartrate, activity.MaxHeartrate)
+	}
+	if activity.TotalElevationGain != 10 {
+		t.Fatalf("expected 10m elevation gain, got %f", activity.TotalElevationGain)
+	}
+	stream := activity.Stream
+	if stream == nil || stream.HeartRate == nil || stream.Cadence == nil || stream.Watts == nil || stream.Altitude == nil || stream.LatLng == nil {
+		t.Fatalf("expected heart-rate, cadence, power, altitude and GPS streams, got %#v", stream)
+	}
+	if len(stream.Time.Data) != 3 || stream.Time.Data[1] != 60 {
+		t.Fatalf("expected samples merged per second and sorted, got %v", stream.Time.Data)
+	}
+	if first := stream.LatLng.Data[0]; math.Abs(first[0]-48.1) > 1e-5 || math.Abs(first[1]+1.6) > 1e-5 {
+		t.Fatalf("expected positions converted from radians, got %v", first)
+	}
+	if stream.Cadence.Data[0] != 84 {
+		t.Fatalf("expected cadence converted from Hz, got %d", stream.Cadence.Data[0])
+	}
+}
+
+func TestDecodeJSONActivity_MapsCorosFrequencyListToActivity(t *testing.T) {
+	// GIVEN
+	jsonFile := writeTestJSON(t, t.TempDir(), "2026", "coros.json", `{
+  "data": {
+    "summary": {"name": "Morning Gravel", "sportType": 203, "startTimestamp": 177500000000, "distance": 150000},
+    "frequencyList": [
+      {"timestamp": 177500006000, "distance": 150000, "heart": 140, "cadence": 90, "power": 220, "altitude": 60},
+      {"timestamp": 177500000000, "distance": 0, "heart": 130, "cadence": 85, "power": 200, "altitude": 50}
+    ]
+  }
+}`)
+
+	// WHEN
+	activity, err := DecodeJSONActivity(jsonFile, 42)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("expected Coros export to decode, got error: %v", err)
+	}
+	if activity.SportType != business.GravelRide.String() || activity.Name != "Morning Gravel" {
+		t.Fatalf("expected Morning Gravel as GravelRide, got %q (%s)", activity.Name, activity.SportType)
+	}
+	if activity.Distance != 1500 || activity.ElapsedTime != 60 {
+		t.Fatalf("expected 1500m in 60s, got %f/%d", activity.Distance, activity.ElapsedTime)
+	}
+	if activity.AverageWatts != 210 || !activity.DeviceWatts {
+		t.Fatalf("expected 210W device power, got %f (device=%t)", activity.AverageWatts, activity.DeviceWatts)
+	}
+	if activity.Stream == nil || activity.Stream.LatLng != nil || activity.Stream.HeartRate == nil {
+		t.Fatalf("expected stream with heart rate and without GPS trace, got %#v", activity.Stream)
+	}
+}
+
+func TestDecodeJSONActivity_RejectsUnknownExport(t *testing.T) {
+	// GIVEN
+	jsonFile := writeTestJSON(t, t.TempDir(), "2026", "other.json", `{"activities": []}`)
+
+	// WHEN
+	_, err := DecodeJSONActivity(jsonFile, 42)
+
+	// THEN
+	if err == nil {
+		t.Fatal("expected an error for a JSON document that is neither a Suunto nor a Coros export")
+	}
+}
+
+func TestJSONActivityProvider_FiltersActivitiesByYearAndType(t *testing.T) {
+	// GIVEN
+	root := t.TempDir()
+	writeTestJSON(t, root, "2026", "run.json", `{"DeviceLog": {"Header": {"Activity": "Running"}, "Samples": [
+  {"TimeISO8601": "2026-01-01T08:00:00Z", "Distance": 0},
+  {"TimeISO8601": "2026-01-01T08:05:00Z", "Distance": 1000}
+]}}`)
+	writeTestJSON(t, root, "2025", "ride.json", `{"summary": {"sportType": 200}, "frequencyList": [
+  {"timestamp": 1735718400, "distance": 0},
+  {"timestamp": 1735718700, "distance": 300000}
+]}`)
+	writeTestJSON(t, root, "2026", "notes.txt", `not an export`)
+	provider := NewJSONActivityProvider(root)
+	year := 2026
+
+	// WHEN
+	activities := provider.GetActivitiesByYearAndActivityTypes(&year, business.Run)
+	rides := provider.GetActivitiesByYearAndActivityTypes(nil, business.Ride)
+
+	// THEN
+	if len(activities) != 1 || activities[0].SportType != business.Run.String() {
+		t.Fatalf("expected one 2026 run activity, got %d", len(activities))
+	}
+	if len(rides) != 1 || rides[0].Distance != 3000 {
+		t.Fatalf("expected the 2025 Coros ride, got %d", len(rides))
+	}
+}
+
+func writeTestJSON(t *testing.T, root string, year string, name string, content string) string {
+	t.Helper()
+	yearDirectory := filepath.Join(root, year)
+	if err := os.MkdirAll(yearDirectory, 0o700); err != nil {
+		t.Fatalf("failed to create year directory: %v", err)
+	}
+	filePath := filepath.Join(yearDirectory, name)
+	if err := os.WriteFile(filePath, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write JSON fixture: %v", err)
+	}
+	return filePath
+}
@@ -0,0 +1,119 @@
+package jsonexport
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"mystravastats/internal/shared/infrastructure/localtrack"
+)
+
+// corosActivity maps the activity detail of a Coros Training Hub export.
+// Distances are written in centimetres and timestamps in hundredths of a
+// second, although some tools rewrite them in seconds or milliseconds.
+type corosActivity struct {
+	Summary struct {
+		Name           string  `json:"name"`
+		SportType      int     `json:"sportType"`
+		StartTimestamp float64 `json:"startTimestamp"`
+		Distance       float64 `json:"distance"`
+	} `json:"summary"`
+	FrequencyList []corosSample `json:"frequencyList"`
+}
+
+type corosSample struct {
+	Timestamp float64  `json:"timestamp"`
+	Distance  *float64 `json:"distance"`
+	Heart     *float64 `json:"heart"`
+	Cadence   *float64 `json:"cadence"`
+	Power     *float64 `json:"power"`
+	Altitude  *float64 `json:"altitude"`
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+}
+
+// corosSportTypes maps the Coros sportType codes found in exports.
+var corosSportTypes = map[int]string{
+	100: "running",
+	101: "indoor running",
+	102: "trail running",
+	103: "track running",
+	104: "hiking",
+	105: "mountain climb",
+	200: "road bike",
+	201: "indoor bike",
+	203: "gravel bike",
+	204: "mountain bike",
+	500: "ski",
+	900: "walk",
+}
+
+func parseCorosExport(raw json.RawMessage) (exportActivity, error) {
+	var activity corosActivity
+	if err := json.Unmarshal(raw, &activity); err != nil {
+		return exportActivity{}, err
+	}
+	if len(activity.FrequencyList) == 0 {
+		return exportActivity{}, errors.New("Coros export has no frequencyList sample")
+	}
+
+	points := make([]localtrack.Point, 0, len(activity.FrequencyList))
+	for _, sample := range activity.FrequencyList {
+		timestamp := corosTime(sample.Timestamp)
+		if timestamp.IsZero() {
+			continue
+		}
+		point := localtrack.Point{Timestamp: timestamp}
+		if sample.Heart != nil && *sample.Heart > 0 {
+			point.HeartRate = localtrack.RoundedNonNegative(*sample.Heart)
+		}
+		if sample.Cadence != nil && *sample.Cadence > 0 {
+			point.Cadence = localtrack.RoundedNonNegative(*sample.Cadence)
+		}
+		if sample.Power != nil && *sample.Power >= 0 {
+			point.Watts = *sample.Power
+		}
+		if sample.Distance != nil && *sample.Distance >= 0 {
+			point.Distance = *sample.Distance / 100
+			point.HasDistance = true
+		}
+		if sample.Altitude != nil {
+			point.Elevation = *sample.Altitude
+			point.HasElevation = true
+		}
+		if sample.Latitude != nil && sample.Longitude != nil && localtrack.IsCoordinateValid([]float64{*sample.Latitude, *sample.Longitude}) {
+			point.Latitude = *sample.Latitude
+			point.Longitude = *sample.Longitude
+			point.HasPosition = true
+		}
+		points = append(points, point)
+	}
+	sortExportPoints(points)
+
+	sportType := ""
+	if sportName, ok := corosSportTypes[activity.Summary.SportType]; ok {
+		sportType = mapSportNameToActivityType(sportName)
+	}
+	return exportActivity{
+		sportType: sportType,
+		name:      activity.Summary.Name,
+		startTime: corosTime(activity.Summary.StartTimestamp),
+		distance:  activity.Summary.Distance / 100,
+		points:    points,
+	}, nil
+}
+
+// corosTime reads an epoch timestamp in seconds, hundredths of a second or
+// milliseconds, telling them apart by magnitude.
+func corosTime(value float64) time.Time {
+	switch {
+	case !localtrack.IsFinite(value) || value <= 0:
+		return time.Time{}
+	case value >= 1e12:
+		return time.UnixMilli(int64(value)).UTC()
+	case value >= 1e10:
+		return time.UnixMilli(int64(value * 10)).UTC()
+	default:
+		return time.Unix(int64(value), 0).UTC()
+	}
+}
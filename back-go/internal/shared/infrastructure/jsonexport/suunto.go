@@ -0,0 +1,128 @@
+package jsonexport
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"mystravastats/internal/shared/infrastructure/localtrack"
+)
+
+// suuntoDeviceLog maps the DeviceLog of a Suunto app export. Suunto stores
+// heart rate and cadence in Hz and positions in radians; samples are written
+// per sensor, so one second may be spread over several samples.
+type suuntoDeviceLog struct {
+	Header struct {
+		Activity     string          `json:"Activity"`
+		ActivityType json.RawMessage `json:"ActivityType"`
+		DateTime     string          `json:"DateTime"`
+		Distance     *float64        `json:"Distance"`
+		Duration     *float64        `json:"Duration"`
+	} `json:"Header"`
+	Samples []suuntoSample `json:"Samples"`
+}
+
+type suuntoSample struct {
+	TimeISO8601 string   `json:"TimeISO8601"`
+	HR          *float64 `json:"HR"`
+	Cadence     *float64 `json:"Cadence"`
+	Power       *float64 `json:"Power"`
+	Speed       *float64 `json:"Speed"`
+	Distance    *float64 `json:"Distance"`
+	Altitude    *float64 `json:"Altitude"`
+	GPSAltitude *float64 `json:"GPSAltitude"`
+	Latitude    *float64 `json:"Latitude"`
+	Longitude   *float64 `json:"Longitude"`
+}
+
+func parseSuuntoExport(raw json.RawMessage) (exportActivity, error) {
+	var deviceLog suuntoDeviceLog
+	if err := json.Unmarshal(raw, &deviceLog); err != nil {
+		return exportActivity{}, err
+	}
+	if len(deviceLog.Samples) == 0 {
+		return exportActivity{}, errors.New("Suunto export has no sample")
+	}
+
+	points := make([]localtrack.Point, 0, len(deviceLog.Samples))
+	indexBySecond := make(map[int64]int)
+	for _, sample := range deviceLog.Samples {
+		timestamp := parseExportTime(sample.TimeISO8601)
+		if timestamp.IsZero() {
+			continue
+		}
+		second := timestamp.Unix()
+		index, ok := indexBySecond[second]
+		if !ok {
+			points = append(points, localtrack.Point{Timestamp: timestamp.Truncate(time.Second)})
+			index = len(points) - 1
+			indexBySecond[second] = index
+		}
+		mergeSuuntoSample(&points[index], sample)
+	}
+	sortExportPoints(points)
+
+	export := exportActivity{
+		sportType: mapSportNameToActivityType(suuntoActivityName(deviceLog.Header.Activity, deviceLog.Header.ActivityType)),
+		startTime: parseExportTime(deviceLog.Header.DateTime),
+		points:    points,
+	}
+	if deviceLog.Header.Distance != nil {
+		export.distance = *deviceLog.Header.Distance
+	}
+	if deviceLog.Header.Duration != nil {
+		export.durationSeconds = *deviceLog.Header.Duration
+	}
+	return export, nil
+}
+
+func mergeSuuntoSample(point *localtrack.Point, sample suuntoSample) {
+	if sample.HR != nil && *sample.HR > 0 {
+		point.HeartRate = localtrack.RoundedNonNegative(*sample.HR * 60)
+	}
+	if sample.Cadence != nil && *sample.Cadence > 0 {
+		point.Cadence = localtrack.RoundedNonNegative(*sample.Cadence * 60)
+	}
+	if sample.Power != nil && *sample.Power >= 0 {
+		point.Watts = *sample.Power
+	}
+	if sample.Speed != nil && *sample.Speed >= 0 {
+		point.Speed = *sample.Speed
+		point.HasSpeed = true
+	}
+	if sample.Distance != nil && *sample.Distance >= 0 {
+		point.Distance = *sample.Distance
+		point.HasDistance = true
+	}
+	if sample.Altitude != nil {
+		point.Elevation = *sample.Altitude
+		point.HasElevation = true
+	} else if sample.GPSAltitude != nil && !point.HasElevation {
+		point.Elevation = *sample.GPSAltitude
+		point.HasElevation = true
+	}
+	if sample.Latitude != nil && sample.Longitude != nil {
+		latitude := *sample.Latitude * 180 / math.Pi
+		longitude := *sample.Longitude * 180 / math.Pi
+		if localtrack.IsCoordinateValid([]float64{latitude, longitude}) {
+			point.Latitude = latitude
+			point.Longitude = longitude
+			point.HasPosition = true
+		}
+	}
+}
+
+// suuntoActivityName prefers the activity name; ActivityType is only used
+// when it is written as a name too, since its numeric ids are not documented.
+func suuntoActivityName(activity string, activityType json.RawMessage) string {
+	if strings.TrimSpace(activity) != "" {
+		return activity
+	}
+	var name string
+	if err := json.Unmarshal(activityType, &name); err == nil {
+		return name
+	}
+	return ""
+}
@@ -0,0 +1,440 @@
+package localindex
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"mystravastats/internal/platform/cachewrite"
+	"mystravastats/internal/platform/events"
+	"mystravastats/internal/shared/domain/strava"
+)
+
+const (
+	indexSchemaVersion = 1
+	indexFileName      = "index.json"
+	streamsDirectory   = "streams"
+)
+
+// File is a local activity file candidate discovered by a provider scan.
+type File struct {
+	Path string
+	Year int
+}
+
+// Decoder turns a local file into an activity summary with its stream.
+type Decoder func(file File) (*strava.Activity, error)
+
+// ScanStats describes how one Load call used the index.
+type ScanStats struct {
+	Files      int   `json:"files"`
+	Hits       int   `json:"hits"`
+	Misses     int   `json:"misses"`
+	Decoded    int   `json:"decoded"`
+	Failed     int   `json:"failed"`
+	Removed    int   `json:"removed"`
+	DurationMs int64 `json:"durationMs"`
+}
+
+// Index is a persistent decode cache for local FIT/GPX/TCX files.
+//
+// Entries are keyed by file path and validated with size and modification
+// time; when those change the content hash decides whether the file must be
+// decoded again. A metadata sidecar (see Metadata) is applied after decoding
+// and invalidates the entry when it changes. Summaries live in index.json and streams are stored per
+// content hash so the index file stays small on large archives.
+type Index struct {
+	directory      string
+	clientID       string
+	kind           string
+	decoderVersion string
+
+	mutex    sync.Mutex
+	entries  map[string]*entry
+	dirty    bool
+	lastScan ScanStats
+	totals   ScanStats
+	lastLoad string
+}
+
+type entry struct {
+	Path        string `json:"path"`
+	Size        int64  `json:"size"`
+	ModTime     int64  `json:"modTime"`
+	ContentHash string `json:"contentHash"`
+	// MetadataModTime is the modification time of the metadata sidecar applied
+	// to Activity, 0 without sidecar.
+	MetadataModTime int64            `json:"metadataModTime,omitempty"`
+	Activity        *strava.Activity `json:"activity,omitempty"`
+	HasStream       bool             `json:"hasStream,omitempty"`
+	DecodeError     string           `json:"decodeError,omitempty"`
+
+	streamLoaded bool
+}
+
+type indexFile struct {
+	SchemaVersion  int      `json:"schemaVersion"`
+	Kind           string   `json:"kind"`
+	DecoderVersion string   `json:"decoderVersion"`
+	UpdatedAt      string   `json:"updatedAt"`
+	Entries        []*entry `json:"entries"`
+}
+
+// Open loads the index stored in <cacheRoot>/strava-<clientID>/local-index-<kind>.
+// A missing, unreadable or outdated index starts empty and is rebuilt on the
+// next Load.
+func Open(cacheRoot string, clientID string, kind string, decoderVersion string) *Index {
+	index := &Index{
+		directory:      filepath.Join(cacheRoot, fmt.Sprintf("strava-%s", clientID), fmt.Sprintf("local-index-%s", kind)),
+		clientID:       clientID,
+		kind:           kind,
+		decoderVersion: decoderVersion,
+		entries:        make(map[string]*entry),
+	}
+	index.readFromDisk()
+	return index
+}
+
+// Load returns the activities for files, decoding only files that are new or
+// changed since the previous scan. Entries for files that are no longer
+// listed are dropped.
+func (index *Index) Load(files []File, decode Decoder) ([]*strava.Activity, ScanStats) {
+	start := time.Now()
+
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	stats := ScanStats{Files: len(files)}
+	seen := make(map[string]struct{}, len(files))
+	activities := make([]*strava.Activity, 0, len(files))
+	progress := events.Start(events.OperationLocalScan, index.clientID, len(files), fmt.Sprintf("Scanning %s files.", index.kind))
+
+	for position, file := range files {
+		progress.Update(index.kind, file.Year, position, 0)
+		seen[file.Path] = struct{}{}
+		activity, hit, err := index.resolve(file, decode)
+		if hit {
+			stats.Hits++
+		} else {
+			stats.Misses++
+			if err == nil {
+				stats.Decoded++
+			}
+		}
+		if err != nil {
+			if !hit {
+				stats.Failed++
+				log.Printf("Unable to decode %s activity %s: %v", index.kind, file.Path, err)
+				progress.Error(fmt.Sprintf("%s: %v", file.Path, err))
+			}
+			continue
+		}
+		activities = append(activities, activity)
+	}
+
+	for path, cached := range index.entries {
+		if _, ok := seen[path]; ok {
+			continue
+		}
+		delete(index.entries, path)
+		index.removeStreamIfUnused(cached.ContentHash)
+		stats.Removed++
+		index.dirty = true
+	}
+
+	if index.dirty {
+		if err := index.writeToDisk(); err != nil {
+			log.Printf("Unable to persist %s index in %s: %v", index.kind, index.directory, err)
+		} else {
+			index.dirty = false
+		}
+	}
+
+	stats.DurationMs = time.Since(start).Milliseconds()
+	index.lastScan = stats
+	index.totals.Files += stats.Files
+	index.totals.Hits += stats.Hits
+	index.totals.Misses += stats.Misses
+	index.totals.Decoded += stats.Decoded
+	index.totals.Failed += stats.Failed
+	index.totals.Removed += stats.Removed
+	index.totals.DurationMs += stats.DurationMs
+	index.lastLoad = time.Now().UTC().Format(time.RFC3339)
+
+	progress.Update(index.kind, 0, len(files), 0)
+	progress.Complete(fmt.Sprintf("%d %s file(s) scanned, %d decoded, %d failed.", stats.Files, index.kind, stats.Decoded, stats.Failed))
+	return activities, stats
+}
+
+// Diagnostics reports the index location with the last and cumulative scan counts.
+func (index *Index) Diagnostics() map[string]any {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	return map[string]any{
+		"directory":      index.directory,
+		"decoderVersion": index.decoderVersion,
+		"entries":        len(index.entries),
+		"lastLoadAt":     index.lastLoad,
+		"lastScan":       index.lastScan,
+		"totals":         index.totals,
+	}
+}
+
+// SourceFile returns the path of the indexed file that produced activityID.
+func (index *Index) SourceFile(activityID int64) (string, bool) {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	for path, cached := range index.entries {
+		if cached.Activity != nil && cached.Activity.Id == activityID {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+func (index *Index) resolve(file File, decode Decoder) (*strava.Activity, bool, error) {
+	info, err := os.Stat(file.Path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	sidecarModTime := metadataModTime(file.Path)
+	previous := index.entries[file.Path]
+	cached := previous
+	if cached != nil && cached.MetadataModTime != sidecarModTime {
+		cached = nil
+	}
+	if cached != nil && cached.Size == info.Size() && cached.ModTime == info.ModTime().UnixNano() {
+		activity, err := index.cachedActivity(cached)
+		if err == nil {
+			return activity, true, nil
+		}
+		if cached.DecodeError != "" {
+			return nil, true, err
+		}
+	}
+
+	contentHash, err := hashFile(file.Path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if cached != nil && cached.ContentHash == contentHash {
+		cached.Size = info.Size()
+		cached.ModTime = info.ModTime().UnixNano()
+		index.dirty = true
+		if activity, err := index.cachedActivity(cached); err == nil || cached.DecodeError != "" {
+			return activity, true, err
+		}
+	}
+
+	previousHash := ""
+	if previous != nil {
+		previousHash = previous.ContentHash
+	}
+
+	fresh := &entry{
+		Path:            file.Path,
+		Size:            info.Size(),
+		ModTime:         info.ModTime().UnixNano(),
+		ContentHash:     contentHash,
+		MetadataModTime: sidecarModTime,
+		streamLoaded:    true,
+	}
+	index.entries[file.Path] = fresh
+	index.dirty = true
+	if previousHash != "" && previousHash != contentHash {
+		index.removeStreamIfUnused(previousHash)
+	}
+
+	activity, decodeErr := decode(file)
+	if decodeErr != nil {
+		fresh.DecodeError = decodeErr.Error()
+		return nil, false, decodeErr
+	}
+	if activity == nil {
+		fresh.DecodeError = "decoder returned no activity"
+		return nil, false, errors.New(fresh.DecodeError)
+	}
+
+	if sidecarModTime != 0 {
+		metadata, _, err := ReadMetadata(file.Path)
+		if err != nil {
+			log.Printf("Ignoring metadata of %s activity %s: %v", index.kind, file.Path, err)
+		}
+		metadata.Apply(activity)
+	}
+
+	fresh.Activity = activity
+	if activity.Stream != nil {
+		if err := index.writeStream(contentHash, activity.Stream); err != nil {
+			log.Printf("Unable to persist %s stream for %s: %v", index.kind, file.Path, err)
+		} else {
+			fresh.HasStream = true
+		}
+	}
+
+	return copyActivity(activity), false, nil
+}
+
+func (index *Index) cachedActivity(cached *entry) (*strava.Activity, error) {
+	if cached.DecodeError != "" {
+		return nil, errors.New(cached.DecodeError)
+	}
+	if cached.Activity == nil {
+		return nil, errors.New("index entry has no activity")
+	}
+	if !cached.streamLoaded {
+		if cached.HasStream {
+			stream, err := index.readStream(cached.ContentHash)
+			if err != nil {
+				return nil, err
+			}
+			cached.Activity.Stream = stream
+		}
+		cached.streamLoaded = true
+	}
+	return copyActivity(cached.Activity), nil
+}
+
+// copyActivity returns a shallow copy so callers can replace top-level fields
+// without touching the indexed summary. Streams are shared and read-only.
+func copyActivity(activity *strava.Activity) *strava.Activity {
+	if activity == nil {
+		return nil
+	}
+	copied := *activity
+	return &copied
+}
+
+func (index *Index) readFromDisk() {
+	data, err := os.ReadFile(filepath.Join(index.directory, indexFileName))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("Unable to read %s index in %s: %v", index.kind, index.directory, err)
+		}
+		return
+	}
+
+	var stored indexFile
+	if err := json.Unmarshal(data, &stored); err != nil {
+		log.Printf("Ignoring unreadable %s index in %s: %v", index.kind, index.directory, err)
+		return
+	}
+	if stored.SchemaVersion != indexSchemaVersion || stored.Kind != index.kind || stored.DecoderVersion != index.decoderVersion {
+		log.Printf("Ignoring outdated %s index in %s (schema=%d decoder=%s)", index.kind, index.directory, stored.SchemaVersion, stored.DecoderVersion)
+		return
+	}
+
+	for _, stored := range stored.Entries {
+		if stored == nil || stored.Path == "" {
+			continue
+		}
+		index.entries[stored.Path] = stored
+	}
+}
+
+func (index *Index) writeToDisk() error {
+	paths := make([]string, 0, len(index.entries))
+	for path := range index.entries {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	stored := indexFile{
+		SchemaVersion:  indexSchemaVersion,
+		Kind:           index.kind,
+		DecoderVersion: index.decoderVersion,
+		UpdatedAt:      time.Now().UTC().Format(time.RFC3339),
+		Entries:        make([]*entry, 0, len(paths)),
+	}
+	for _, path := range paths {
+		cached := *index.entries[path]
+		if cached.Activity != nil {
+			summary := *cached.Activity
+			summary.Stream = nil
+			cached.Activity = &summary
+		}
+		stored.Entries = append(stored.Entries, &cached)
+	}
+
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomically(filepath.Join(index.directory, indexFileName), data)
+}
+
+func (index *Index) streamPath(contentHash string) string {
+	return filepath.Join(index.directory, streamsDirectory, contentHash+".json")
+}
+
+func (index *Index) writeStream(contentHash string, stream *strava.Stream) error {
+	data, err := json.Marshal(stream)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomically(index.streamPath(contentHash), data)
+}
+
+func (index *Index) readStream(contentHash string) (*strava.Stream, error) {
+	data, err := os.ReadFile(index.streamPath(contentHash))
+	if err != nil {
+		return nil, err
+	}
+	var stream strava.Stream
+	if err := json.Unmarshal(data, &stream); err != nil {
+		return nil, err
+	}
+	return &stream, nil
+}
+
+func (index *Index) removeStreamIfUnused(contentHash string) {
+	if contentHash == "" {
+		return
+	}
+	for _, cached := range index.entries {
+		if cached.ContentHash == contentHash {
+			return
+		}
+	}
+	if err := os.Remove(index.streamPath(contentHash)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("Unable to remove %s stream %s: %v", index.kind, contentHash, err)
+	}
+}
+
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+func writeFileAtomically(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create directory for %s: %w", path, err)
+	}
+
+	if err := cachewrite.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package localindex
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mystravastats/internal/shared/domain/strava"
+)
+
+func TestIndexLoad_DecodesOnlyNewOrChangedFiles(t *testing.T) {
+	// GIVEN
+	root := t.TempDir()
+	first := writeIndexFixture(t, root, "2026", "first.fit", "first")
+	second := writeIndexFixture(t, root, "2026", "second.fit", "second")
+	decoder := &countingDecoder{}
+	index := Open(root, "athlete", "fit", "test-v1")
+	files := []File{{Path: first, Year: 2026}, {Path: second, Year: 2026}}
+	if _, stats := index.Load(files, decoder.decode); stats.Decoded != 2 {
+		t.Fatalf("expected initial scan to decode 2 files, got %#v", stats)
+	}
+
+	// WHEN
+	if err := os.WriteFile(second, []byte("second-changed"), 0o600); err != nil {
+		t.Fatalf("failed to modify fixture: %v", err)
+	}
+	activities, stats := index.Load(files, decoder.decode)
+
+	// THEN
+	if len(activities) != 2 {
+		t.Fatalf("expected 2 activities, got %d", len(activities))
+	}
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Decoded != 1 {
+		t.Fatalf("expected one hit and one re-decoded file, got %#v", stats)
+	}
+	if decoder.calls != 3 {
+		t.Fatalf("expected 3 decoder calls, got %d", decoder.calls)
+	}
+}
+
+func TestIndexLoad_ReusesPersistedSummariesAndStreamsAcrossInstances(t *testing.T) {
+	// GIVEN
+	root := t.TempDir()
+	file := writeIndexFixture(t, root, "2025", "ride.fit", "ride")
+	files := []File{{Path: file, Year: 2025}}
+	Open(root, "athlete", "fit", "test-v1").Load(files, (&countingDecoder{}).decode)
+	decoder := &countingDecoder{}
+
+	// WHEN
+	activities, stats := Open(root, "athlete", "fit", "test-v1").Load(files, decoder.decode)
+
+	// THEN
+	if decoder.calls != 0 || stats.Hits != 1 {
+		t.Fatalf("expected persisted index hit without decoding, got calls=%d stats=%#v", decoder.calls, stats)
+	}
+	if len(activities) != 1 || activities[0].Name != file {
+		t.Fatalf("expected cached activity summary, got %#v", activities)
+	}
+	if activities[0].Stream == nil || len(activities[0].Stream.Distance.Data) != 2 {
+		t.Fatalf("expected cached stream to be restored, got %#v", activities[0].Stream)
+	}
+}
+
+func TestIndexLoad_RebuildsWhenDecoderVersionChanges(t *testing.T) {
+	// GIVEN
+	root := t.TempDir()
+	file := writeIndexFixture(t, root, "2025", "ride.fit", "ride")
+	files := []File{{Path: file, Year: 2025}}
+	Open(root, "athlete", "fit", "test-v1").Load(files, (&countingDecoder{}).decode)
+	decoder := &countingDecoder{}
+
+	// WHEN
+	_, stats := Open(root, "athlete", "fit", "test-v2").Load(files, decoder.decode)
+
+	// THEN
+	if decoder.calls != 1 || stats.Decoded != 1 {
+		t.Fatalf("expected outdated index to be rebuilt, got calls=%d stats=%#v", decoder.calls, stats)
+	}
+}
+
+func TestIndexLoad_DropsRemovedFilesAndRemembersDecodeFailures(t *testing.T) {
+	// GIVEN
+	root := t.TempDir()
+	kept := writeIndexFixture(t, root, "2026", "kept.fit", "kept")
+	removed := writeIndexFixture(t, root, "2026", "removed.fit", "removed")
+	broken := writeIndexFixture(t, root, "2026", "broken.fit", "broken")
+	decoder := &countingDecoder{failing: map[string]bool{broken: true}}
+	index := Open(root, "athlete", "fit", "test-v1")
+	index.Load([]File{{Path: kept}, {Path: removed}, {Path: broken}}, decoder.decode)
+	if err := os.Remove(removed); err != nil {
+		t.Fatalf("failed to remove fixture: %v", err)
+	}
+
+	// WHEN
+	activities, stats := index.Load([]File{{Path: kept}, {Path: broken}}, decoder.decode)
+
+	// THEN
+	if len(activities) != 1 {
+		t.Fatalf("expected only the decodable activity, got %d", len(activities))
+	}
+	if stats.Removed != 1 || stats.Hits != 2 || stats.Failed != 0 {
+		t.Fatalf("expected removed entry and cached failure, got %#v", stats)
+	}
+	if decoder.calls != 3 {
+		t.Fatalf("expected broken file not to be decoded again, got %d calls", decoder.calls)
+	}
+	diagnostics := index.Diagnostics()
+	if diagnostics["entries"] != 2 {
+		t.Fatalf("expected 2 index entries, got %#v", diagnostics["entries"])
+	}
+}
+
+func TestIndexLoad_AppliesMetadataSidecarAndRedecodesWhenItChanges(t *testing.T) {
+	// GIVEN
+	root := t.TempDir()
+	file := writeIndexFixture(t, root, "2026", "123.fit", "ride")
+	if err := WriteMetadata(file, Metadata{ActivityID: 123, Name: "Col du Galibier", SportType: "GravelRide", Gear: "Canyon Grail", Commute: true}); err != nil {
+		t.Fatalf("failed to write metadata: %v", err)
+	}
+	decoder := &countingDecoder{}
+	index := Open(root, "athlete", "fit", "test-v1")
+	activities, _ := index.Load([]File{{Path: file}}, decoder.decode)
+	if len(activities) != 1 {
+		t.Fatalf("expected one activity, got %d", len(activities))
+	}
+	activity := activities[0]
+	if activity.Id != 123 || activity.Name != "Col du Galibier" || activity.SportType != "GravelRide" || !activity.Commute || activity.GearId == nil || *activity.GearId != "Canyon Grail" {
+		t.Fatalf("expected sidecar metadata on the activity, got %#v", activity)
+	}
+	if path, ok := index.SourceFile(123); !ok || path != file {
+		t.Fatalf("expected source file to be found by the sidecar activity id, got %q (%t)", path, ok)
+	}
+
+	// WHEN
+	if err := WriteMetadata(file, Metadata{ActivityID: 123, Name: "Galibier"}); err != nil {
+		t.Fatalf("failed to rewrite metadata: %v", err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(MetadataPath(file), later, later); err != nil {
+		t.Fatalf("failed to set metadata time: %v", err)
+	}
+	activities, stats := index.Load([]File{{Path: file}}, decoder.decode)
+
+	// THEN
+	if stats.Decoded != 1 || decoder.calls != 2 {
+		t.Fatalf("expected a metadata change to decode the file again, got %#v (%d calls)", stats, decoder.calls)
+	}
+	if activities[0].Name != "Galibier" || activities[0].Commute {
+		t.Fatalf("expected the new metadata to replace the previous one, got %#v", activities[0])
+	}
+}
+
+type countingDecoder struct {
+	calls   int
+	failing map[string]bool
+}
+
+func (decoder *countingDecoder) decode(file File) (*strava.Activity, error) {
+	decoder.calls++
+	if decoder.failing[file.Path] {
+		return nil, errors.New("corrupted file")
+	}
+	return &strava.Activity{
+		Id:   int64(decoder.calls),
+		Name: file.Path,
+		Stream: &strava.Stream{
+			Distance: strava.DistanceStream{Data: []float64{0, 10}, OriginalSize: 2},
+			Time:     strava.TimeStream{Data: []int{0, 5}, OriginalSize: 2},
+		},
+	}, nil
+}
+
+func writeIndexFixture(t *testing.T, root string, year string, name string, content string) string {
+	t.Helper()
+	yearDirectory := filepath.Join(root, year)
+	if err := os.MkdirAll(yearDirectory, 0o700); err != nil {
+		t.Fatalf("failed to create year directory: %v", err)
+	}
+	filePath := filepath.Join(yearDirectory, name)
+	if err := os.WriteFile(filePath, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+	// Keep modification times distinct from the initial scan on coarse filesystems.
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filePath, past, past); err != nil {
+		t.Fatalf("failed to set fixture time: %v", err)
+	}
+	return filePath
+}
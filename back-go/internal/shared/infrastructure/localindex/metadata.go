@@ -0,0 +1,94 @@
+package localindex
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+
+	"mystravastats/internal/shared/domain/business"
+	"mystravastats/internal/shared/domain/strava"
+)
+
+// MetadataSuffix is appended to an activity file name to get its metadata
+// sidecar, e.g. 2024/123456.fit.meta.
+const MetadataSuffix = ".meta"
+
+// Metadata carries the activity fields a FIT/GPX/TCX file cannot hold, such as
+// the name, gear and commute flag of a Strava archive export. It is applied on
+// top of the decoded activity; empty fields leave the decoded value unchanged.
+type Metadata struct {
+	ActivityID  int64  `json:"activityId,omitempty"`
+	Name        string `json:"name,omitempty"`
+	SportType   string `json:"sportType,omitempty"`
+	Description string `json:"description,omitempty"`
+	Gear        string `json:"gear,omitempty"`
+	Commute     bool   `json:"commute,omitempty"`
+}
+
+// MetadataPath returns the sidecar path of an activity file.
+func MetadataPath(filePath string) string {
+	return filePath + MetadataSuffix
+}
+
+// WriteMetadata stores the sidecar of an activity file.
+func WriteMetadata(filePath string, metadata Metadata) error {
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomically(MetadataPath(filePath), data)
+}
+
+// ReadMetadata loads the sidecar of an activity file, if any.
+func ReadMetadata(filePath string) (Metadata, bool, error) {
+	var metadata Metadata
+	data, err := os.ReadFile(MetadataPath(filePath))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return metadata, false, nil
+		}
+		return metadata, false, err
+	}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return metadata, false, err
+	}
+	return metadata, true, nil
+}
+
+// Apply overrides the decoded activity fields present in metadata.
+func (metadata Metadata) Apply(activity *strava.Activity) {
+	if activity == nil {
+		return
+	}
+	if metadata.ActivityID > 0 {
+		activity.Id = metadata.ActivityID
+		activity.UploadId = metadata.ActivityID
+	}
+	if name := strings.TrimSpace(metadata.Name); name != "" {
+		activity.Name = name
+	}
+	if _, known := business.ActivityTypes[metadata.SportType]; known && metadata.SportType != business.Commute.String() {
+		activity.SportType = metadata.SportType
+		activity.Type = metadata.SportType
+	}
+	if description := strings.TrimSpace(metadata.Description); description != "" {
+		activity.Description = &description
+	}
+	if gear := strings.TrimSpace(metadata.Gear); gear != "" {
+		activity.GearId = &gear
+	}
+	if metadata.Commute {
+		activity.Commute = true
+	}
+}
+
+// metadataModTime identifies the sidecar version used for an index entry; it
+// is 0 when the file has no sidecar.
+func metadataModTime(filePath string) int64 {
+	info, err := os.Stat(MetadataPath(filePath))
+	if err != nil || info.IsDir() {
+		return 0
+	}
+	return info.ModTime().UnixNano()
+}
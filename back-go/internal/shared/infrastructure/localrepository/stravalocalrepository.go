@@ -2,10 +2,13 @@ package localrepository
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"mystravastats/internal/platform/cachewrite"
 	"mystravastats/internal/shared/domain/business"
 	"mystravastats/internal/shared/domain/strava"
+	"mystravastats/internal/shared/infrastructure/secretstore"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -78,7 +81,7 @@ func (repo *StravaRepository) SaveAthleteToCache(clientId string, stravaAthlete
 		return
 	}
 
-	if err := os.WriteFile(athleteJsonFile, data, secureFileMode); err != nil {
+	if err := cachewrite.WriteFile(athleteJsonFile, data, secureFileMode); err != nil {
 		log.Printf("Failed to write athlete file '%s': %v", athleteJsonFile, err)
 		return
 	}
@@ -141,12 +144,123 @@ func (repo *StravaRepository) SaveActivitiesToCache(clientId string, year int, a
 	}
 
 	yearActivitiesJsonFile := filepath.Join(yearActivitiesDirectory, fmt.Sprintf("activities-%s-%d.json", clientId, year))
-	if err := os.WriteFile(yearActivitiesJsonFile, data, secureFileMode); err != nil {
+	if err := cachewrite.WriteFile(yearActivitiesJsonFile, data, secureFileMode); err != nil {
 		log.Printf("Failed to write activities file '%s': %v", yearActivitiesJsonFile, err)
 		return
 	}
 }
 
+// UpsertActivityInCache replaces or appends one activity summary in the year cache file.
+func (repo *StravaRepository) UpsertActivityInCache(clientId string, year int, activity strava.Activity) {
+	activity.Stream = nil
+	activities := repo.LoadActivitiesFromCache(clientId, year)
+	replaced := false
+	for index := range activities {
+		if activities[index].Id == activity.Id {
+			activities[index] = activity
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		activities = append(activities, activity)
+	}
+	repo.SaveActivitiesToCache(clientId, year, activities)
+}
+
+// RemoveActivityFromCache drops one activity summary from the year cache file and deletes
+// its stream and detailed activity files. It returns true when something was removed.
+func (repo *StravaRepository) RemoveActivityFromCache(clientId string, year int, activityId int64) bool {
+	removed := false
+	activities := repo.LoadActivitiesFromCache(clientId, year)
+	kept := make([]strava.Activity, 0, len(activities))
+	for _, activity := range activities {
+		if activity.Id == activityId {
+			removed = true
+			continue
+		}
+		kept = append(kept, activity)
+	}
+	if removed {
+		repo.SaveActivitiesToCache(clientId, year, kept)
+	}
+
+	if repo.RemoveActivityFilesFromCache(clientId, year, activityId, true, true) > 0 {
+		removed = true
+	}
+	return removed
+}
+
+// RemoveActivityFilesFromCache deletes the stream and/or detailed activity files of one
+// activity in the year directory and returns the number of files removed.
+func (repo *StravaRepository) RemoveActivityFilesFromCache(clientId string, year int, activityId int64, stream bool, detailed bool) int {
+	activitiesDirectory := filepath.Join(repo.cacheDirectory, fmt.Sprintf("strava-%s", clientId))
+	yearActivitiesDirectory := filepath.Join(activitiesDirectory, fmt.Sprintf("strava-%s-%d", clientId, year))
+
+	names := make([]string, 0, 2)
+	if stream {
+		names = append(names, fmt.Sprintf("stream-%d", activityId))
+	}
+	if detailed {
+		names = append(names, fmt.Sprintf("stravaActivity-%d", activityId))
+	}
+
+	removed := 0
+	for _, name := range names {
+		file := filepath.Join(yearActivitiesDirectory, name)
+		if err := os.Remove(file); err == nil {
+			removed++
+		} else if !os.IsNotExist(err) {
+			log.Printf("Failed to remove cache file '%s': %v", file, err)
+		}
+	}
+	return removed
+}
+
+// RemoveOrphanActivityFiles deletes stream-<id> and stravaActivity-<id> files of the year
+// directory whose id is not in keep. It returns the ids whose files were removed.
+func (repo *StravaRepository) RemoveOrphanActivityFiles(clientId string, year int, keep map[int64]struct{}) map[int64]int {
+	activitiesDirectory := filepath.Join(repo.cacheDirectory, fmt.Sprintf("strava-%s", clientId))
+	yearActivitiesDirectory := filepath.Join(activitiesDirectory, fmt.Sprintf("strava-%s-%d", clientId, year))
+
+	removed := make(map[int64]int)
+	entries, err := os.ReadDir(yearActivitiesDirectory)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Failed to list cache directory '%s': %v", yearActivitiesDirectory, err)
+		}
+		return removed
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		var id string
+		switch {
+		case strings.HasPrefix(entry.Name(), "stream-"):
+			id = strings.TrimPrefix(entry.Name(), "stream-")
+		case strings.HasPrefix(entry.Name(), "stravaActivity-"):
+			id = strings.TrimPrefix(entry.Name(), "stravaActivity-")
+		default:
+			continue
+		}
+		activityId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			continue
+		}
+		if _, ok := keep[activityId]; ok {
+			continue
+		}
+		file := filepath.Join(yearActivitiesDirectory, entry.Name())
+		if err := os.Remove(file); err != nil {
+			log.Printf("Failed to remove orphan cache file '%s': %v", file, err)
+			continue
+		}
+		removed[activityId]++
+	}
+	return removed
+}
+
 func (repo *StravaRepository) LoadDetailedActivityFromCache(clientId string, year int, activityId int64) *strava.DetailedActivity {
 	activitiesDirectory := filepath.Join(repo.cacheDirectory, fmt.Sprintf("strava-%s", clientId))
 	yearActivitiesDirectory := filepath.Join(activitiesDirectory, fmt.Sprintf("strava-%s-%d", clientId, year))
@@ -182,7 +296,7 @@ func (repo *StravaRepository) SaveDetailedActivityToCache(clientId string, year
 		return
 	}
 
-	if err := os.WriteFile(detailedActivityFile, data, secureFileMode); err != nil {
+	if err := cachewrite.WriteFile(detailedActivityFile, data, secureFileMode); err != nil {
 		log.Printf("Failed to write detailed activity file '%s': %v", detailedActivityFile, err)
 		return
 	}
@@ -223,7 +337,7 @@ func (repo *StravaRepository) SaveActivitiesStreamsToCache(clientId string, year
 		return
 	}
 
-	if err := os.WriteFile(streamFile, data, secureFileMode); err != nil {
+	if err := cachewrite.WriteFile(streamFile, data, secureFileMode); err != nil {
 		log.Printf("Failed to write stream file '%s': %v", streamFile, err)
 		return
 	}
@@ -262,9 +376,13 @@ func (repo *StravaRepository) ReadStravaAuthentication(stravaCache string) (stri
 	file := filepath.Join(cacheDirectory, ".strava")
 	properties := make(map[string]string)
 
-	data, err := os.ReadFile(file)
+	data, err := secretstore.ReadFile(file)
 	if err != nil {
-		log.Printf("File .strava not found at '%s': %v", file, err)
+		if errors.Is(err, os.ErrNotExist) {
+			log.Printf("File .strava not found at '%s': %v", file, err)
+		} else {
+			log.Printf("Unable to read .strava at '%s': %v", file, err)
+		}
 		return "", "", false
 	}
 
@@ -322,7 +440,7 @@ func (repo *StravaRepository) SaveHeartRateZoneSettings(clientId string, setting
 		return
 	}
 
-	if err := os.WriteFile(settingsFile, data, secureFileMode); err != nil {
+	if err := cachewrite.WriteFile(settingsFile, data, secureFileMode); err != nil {
 		log.Printf("Failed to write heart rate zone settings file '%s': %v", settingsFile, err)
 	}
 }
@@ -363,7 +481,7 @@ func (repo *StravaRepository) SavePerformanceSettings(clientId string, settings
 		return
 	}
 
-	if err := os.WriteFile(settingsFile, data, secureFileMode); err != nil {
+	if err := cachewrite.WriteFile(settingsFile, data, secureFileMode); err != nil {
 		log.Printf("Failed to write performance settings file '%s': %v", settingsFile, err)
 	}
 }
@@ -404,7 +522,7 @@ func (repo *StravaRepository) SaveAnnualGoalTargets(clientId string, key string,
 		return
 	}
 
-	if err := os.WriteFile(goalsFile, data, secureFileMode); err != nil {
+	if err := cachewrite.WriteFile(goalsFile, data, secureFileMode); err != nil {
 		log.Printf("Failed to write annual goals file '%s': %v", goalsFile, err)
 	}
 }
@@ -433,6 +551,113 @@ func (repo *StravaRepository) loadAnnualGoalsCacheFile(clientId string) annualGo
 	return payload
 }
 
+type customStatisticsCacheFile struct {
+	Statistics map[string][]business.CustomStatisticDefinition `json:"statistics"`
+}
+
+func (repo *StravaRepository) LoadCustomStatistics(clientId string, key string) []business.CustomStatisticDefinition {
+	payload := repo.loadCustomStatisticsCacheFile(clientId)
+	definitions, ok := payload.Statistics[key]
+	if !ok {
+		return []business.CustomStatisticDefinition{}
+	}
+	return definitions
+}
+
+func (repo *StravaRepository) SaveCustomStatistics(clientId string, key string, definitions []business.CustomStatisticDefinition) {
+	payload := repo.loadCustomStatisticsCacheFile(clientId)
+	if len(definitions) == 0 {
+		delete(payload.Statistics, key)
+	} else {
+		payload.Statistics[key] = definitions
+	}
+
+	activitiesDirectory := filepath.Join(repo.cacheDirectory, fmt.Sprintf("strava-%s", clientId))
+	if err := os.MkdirAll(activitiesDirectory, secureDir); err != nil {
+		log.Printf("Failed to create secure custom statistics directory '%s': %v", activitiesDirectory, err)
+		return
+	}
+
+	statisticsFile := filepath.Join(activitiesDirectory, fmt.Sprintf("custom-statistics-%s.json", clientId))
+	data, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		log.Printf("Failed to marshal custom statistics for clientId=%s: %v", clientId, err)
+		return
+	}
+
+	if err := cachewrite.WriteFile(statisticsFile, data, secureFileMode); err != nil {
+		log.Printf("Failed to write custom statistics file '%s': %v", statisticsFile, err)
+	}
+}
+
+func (repo *StravaRepository) loadCustomStatisticsCacheFile(clientId string) customStatisticsCacheFile {
+	activitiesDirectory := filepath.Join(repo.cacheDirectory, fmt.Sprintf("strava-%s", clientId))
+	statisticsFile := filepath.Join(activitiesDirectory, fmt.Sprintf("custom-statistics-%s.json", clientId))
+	if _, err := os.Stat(statisticsFile); os.IsNotExist(err) {
+		return customStatisticsCacheFile{Statistics: map[string][]business.CustomStatisticDefinition{}}
+	}
+
+	data, err := os.ReadFile(statisticsFile)
+	if err != nil {
+		log.Printf("Failed to read custom statistics file '%s': %v", statisticsFile, err)
+		return customStatisticsCacheFile{Statistics: map[string][]business.CustomStatisticDefinition{}}
+	}
+
+	var payload customStatisticsCacheFile
+	if err := json.Unmarshal(data, &payload); err != nil {
+		log.Printf("Failed to unmarshal custom statistics from '%s': %v", statisticsFile, err)
+		return customStatisticsCacheFile{Statistics: map[string][]business.CustomStatisticDefinition{}}
+	}
+	if payload.Statistics == nil {
+		payload.Statistics = map[string][]business.CustomStatisticDefinition{}
+	}
+	return payload
+}
+
+type stravaUploadsCacheFile struct {
+	Uploads []business.StravaUploadRecord `json:"uploads"`
+}
+
+func (repo *StravaRepository) LoadStravaUploads(clientId string) []business.StravaUploadRecord {
+	activitiesDirectory := filepath.Join(repo.cacheDirectory, fmt.Sprintf("strava-%s", clientId))
+	uploadsFile := filepath.Join(activitiesDirectory, fmt.Sprintf("strava-uploads-%s.json", clientId))
+	if _, err := os.Stat(uploadsFile); os.IsNotExist(err) {
+		return []business.StravaUploadRecord{}
+	}
+
+	data, err := os.ReadFile(uploadsFile)
+	if err != nil {
+		log.Printf("Failed to read Strava uploads file '%s': %v", uploadsFile, err)
+		return []business.StravaUploadRecord{}
+	}
+
+	var payload stravaUploadsCacheFile
+	if err := json.Unmarshal(data, &payload); err != nil {
+		log.Printf("Failed to unmarshal Strava uploads from '%s': %v", uploadsFile, err)
+		return []business.StravaUploadRecord{}
+	}
+	return payload.Uploads
+}
+
+func (repo *StravaRepository) SaveStravaUploads(clientId string, uploads []business.StravaUploadRecord) {
+	activitiesDirectory := filepath.Join(repo.cacheDirectory, fmt.Sprintf("strava-%s", clientId))
+	if err := os.MkdirAll(activitiesDirectory, secureDir); err != nil {
+		log.Printf("Failed to create secure Strava uploads directory '%s': %v", activitiesDirectory, err)
+		return
+	}
+
+	uploadsFile := filepath.Join(activitiesDirectory, fmt.Sprintf("strava-uploads-%s.json", clientId))
+	data, err := json.MarshalIndent(stravaUploadsCacheFile{Uploads: uploads}, "", "  ")
+	if err != nil {
+		log.Printf("Failed to marshal Strava uploads for clientId=%s: %v", clientId, err)
+		return
+	}
+
+	if err := cachewrite.WriteFile(uploadsFile, data, secureFileMode); err != nil {
+		log.Printf("Failed to write Strava uploads file '%s': %v", uploadsFile, err)
+	}
+}
+
 func fileExists(filename string) bool {
 	info, err := os.Stat(filename)
 	if os.IsNotExist(err) {
@@ -0,0 +1,131 @@
+package localtrack
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+
+	"mystravastats/internal/shared/domain/business"
+	"mystravastats/internal/shared/domain/strava"
+)
+
+// FilterByType keeps the activities of the given types. Commute only matches
+// commuting rides, and the other types exclude commutes.
+func FilterByType(activities []*strava.Activity, activityTypes ...business.ActivityType) []*strava.Activity {
+	if len(activityTypes) == 0 {
+		return []*strava.Activity{}
+	}
+
+	filtered := make([]*strava.Activity, 0, len(activities))
+	for _, activity := range activities {
+		if activity == nil {
+			continue
+		}
+		sportType := activity.SportType
+		if sportType == "" {
+			sportType = activity.Type
+		}
+		for _, activityType := range activityTypes {
+			if activityType == business.Commute {
+				if sportType == business.Ride.String() && activity.Commute {
+					filtered = append(filtered, activity)
+					break
+				}
+				continue
+			}
+			if sportType == activityType.String() && !activity.Commute {
+				filtered = append(filtered, activity)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// FilterByYear keeps the activities started in year, or all of them when year is nil.
+func FilterByYear(activities []*strava.Activity, year *int) []*strava.Activity {
+	if year == nil {
+		return activities
+	}
+
+	filtered := make([]*strava.Activity, 0, len(activities))
+	for _, activity := range activities {
+		if activity == nil {
+			continue
+		}
+		activityYear, err := strconv.Atoi(ExtractYear(activity.StartDateLocal))
+		if err != nil {
+			continue
+		}
+		if activityYear == *year {
+			filtered = append(filtered, activity)
+		}
+	}
+	return filtered
+}
+
+// GroupByYear groups activities by the year of their local start date.
+func GroupByYear(activities []*strava.Activity) map[string][]*strava.Activity {
+	activitiesByYear := make(map[string][]*strava.Activity)
+	for _, activity := range activities {
+		if activity == nil {
+			continue
+		}
+		year := ExtractYear(activity.StartDateLocal)
+		if year == "" {
+			year = ExtractYear(activity.StartDate)
+		}
+		if year == "" {
+			continue
+		}
+		activitiesByYear[year] = append(activitiesByYear[year], activity)
+	}
+	return activitiesByYear
+}
+
+// FilterCacheKey keys the filtered activity lists cached by the providers.
+func FilterCacheKey(year *int, activityTypes ...business.ActivityType) string {
+	yearKey := "all"
+	if year != nil {
+		yearKey = strconv.Itoa(*year)
+	}
+	return fmt.Sprintf("%s:%v", yearKey, activityTypes)
+}
+
+func ClonePointers(activities []*strava.Activity) []*strava.Activity {
+	if len(activities) == 0 {
+		return []*strava.Activity{}
+	}
+	cloned := make([]*strava.Activity, len(activities))
+	copy(cloned, activities)
+	return cloned
+}
+
+func ExtractYear(value string) string {
+	if len(value) >= 4 {
+		return value[:4]
+	}
+	return ""
+}
+
+// ExtractSortableDay returns the YYYY-MM-DD prefix of a date, or an empty string.
+func ExtractSortableDay(value string) string {
+	trimmed := strings.TrimSpace(value)
+	if len(trimmed) < 10 {
+		return ""
+	}
+	day := trimmed[:10]
+	if _, err := time.Parse("2006-01-02", day); err != nil {
+		return ""
+	}
+	return day
+}
+
+// HashStringToInt derives the stable numeric ids of local activities.
+func HashStringToInt(value string) int {
+	hasher := fnv.New32a()
+	_, _ = hasher.Write([]byte(value))
+	return int(hasher.Sum32())
+}
@@ -0,0 +1,405 @@
+// Package localtrack holds what the providers reading track points from local
+// files share: building the Strava stream from the points and filtering the
+// loaded activities by year and type.
+package localtrack
+
+import (
+	"math"
+	"time"
+
+	"mystravastats/internal/shared/domain/strava"
+)
+
+// Point is one track sample converted to metric units and degrees. The has
+// flags tell a missing value from a zero one.
+type Point struct {
+	Latitude     float64
+	Longitude    float64
+	HasPosition  bool
+	Elevation    float64
+	HasElevation bool
+	Distance     float64
+	HasDistance  bool
+	Speed        float64
+	HasSpeed     bool
+	Timestamp    time.Time
+	HeartRate    int
+	Cadence      int
+	Watts        float64
+}
+
+// StreamStats holds the totals and the per-point series computed while
+// building a stream.
+type StreamStats struct {
+	DistanceMeters      float64
+	ElevationGainMeters float64
+	ElapsedTime         int
+	MovingTime          int
+	AltitudeData        []float64
+	VelocityData        []float64
+	HeartRateData       []int
+	CadenceData         []int
+	PowerData           []float64
+}
+
+// BuildStream turns track points into a Strava stream. Distances come from the
+// recorded distance when the points carry one, otherwise from the positions;
+// points without a timestamp are spaced one second apart from startTime.
+func BuildStream(points []Point, startTime time.Time) (*strava.Stream, StreamStats) {
+	if len(points) == 0 {
+		return nil, StreamStats{}
+	}
+
+	distanceData := make([]float64, 0, len(points))
+	timeData := make([]int, 0, len(points))
+	coordinates := make([][]float64, 0, len(points))
+	altitudeData := make([]float64, 0, len(points))
+	velocityData := make([]float64, 0, len(points))
+	gradeData := make([]float64, 0, len(points))
+	movingData := make([]bool, 0, len(points))
+	cadenceData := make([]int, 0, len(points))
+	heartRateData := make([]int, 0, len(points))
+	powerData := make([]float64, 0, len(points))
+
+	stats := StreamStats{}
+	useRecordedDistance := hasRecordedDistance(points)
+	previous := points[0]
+	previousTime := resolvePointTime(previous, startTime, 0)
+	lastElapsedSeconds := 0
+
+	for index, point := range points {
+		pointTime := resolvePointTime(point, startTime, index)
+		if pointTime.Before(previousTime) {
+			pointTime = previousTime
+		}
+
+		deltaDistance := 0.0
+		deltaSeconds := 0
+		elevationDelta := 0.0
+		if index > 0 {
+			switch {
+			case useRecordedDistance && point.HasDistance:
+				deltaDistance = math.Max(0, point.Distance-stats.DistanceMeters)
+			case !useRecordedDistance && point.HasPosition && previous.HasPosition:
+				deltaDistance = haversineMeters(previous.Latitude, previous.Longitude, point.Latitude, point.Longitude)
+			}
+			deltaSeconds = int(math.Round(pointTime.Sub(previousTime).Seconds()))
+			if deltaSeconds < 0 {
+				deltaSeconds = 0
+			}
+			if point.HasElevation && previous.HasElevation {
+				elevationDelta = point.Elevation - previous.Elevation
+				if elevationDelta > 0 {
+					stats.ElevationGainMeters += elevationDelta
+				}
+			}
+		} else if useRecordedDistance && point.HasDistance {
+			stats.DistanceMeters = point.Distance
+		}
+
+		stats.DistanceMeters += deltaDistance
+		distanceData = append(distanceData, stats.DistanceMeters)
+		if point.HasPosition {
+			coordinates = append(coordinates, []float64{point.Latitude, point.Longitude})
+		} else {
+			coordinates = append(coordinates, []float64{0, 0})
+		}
+
+		elapsedSeconds := int(math.Round(pointTime.Sub(startTime).Seconds()))
+		if elapsedSeconds < lastElapsedSeconds {
+			elapsedSeconds = lastElapsedSeconds
+		}
+		lastElapsedSeconds = elapsedSeconds
+		timeData = append(timeData, elapsedSeconds)
+		stats.ElapsedTime = elapsedSeconds
+
+		if point.HasElevation {
+			altitudeData = append(altitudeData, point.Elevation)
+		} else {
+			altitudeData = append(altitudeData, 0)
+		}
+
+		speed := 0.0
+		if point.HasSpeed {
+			speed = point.Speed
+		} else if deltaSeconds > 0 {
+			speed = deltaDistance / float64(deltaSeconds)
+		}
+		velocityData = append(velocityData, speed)
+
+		grade := 0.0
+		if deltaDistance > 0 {
+			grade = elevationDelta / deltaDistance
+		}
+		gradeData = append(gradeData, grade)
+
+		moving := deltaDistance > 0.5 || (point.HasSpeed && point.Speed > 0.1)
+		movingData = append(movingData, moving)
+		if moving {
+			stats.MovingTime += deltaSeconds
+		}
+
+		cadenceData = append(cadenceData, point.Cadence)
+		heartRateData = append(heartRateData, point.HeartRate)
+		powerData = append(powerData, point.Watts)
+
+		previous = point
+		previousTime = pointTime
+	}
+
+	stats.AltitudeData = altitudeData
+	stats.VelocityData = velocityData
+	stats.HeartRateData = heartRateData
+	stats.CadenceData = cadenceData
+	stats.PowerData = powerData
+
+	stream := &strava.Stream{
+		Distance: strava.DistanceStream{
+			Data:         distanceData,
+			OriginalSize: len(distanceData),
+			Resolution:   "high",
+			SeriesType:   "distance",
+		},
+		Time: strava.TimeStream{
+			Data:         timeData,
+			OriginalSize: len(timeData),
+			Resolution:   "high",
+			SeriesType:   "distance",
+		},
+		Moving: &strava.MovingStream{
+			Data:         movingData,
+			OriginalSize: len(movingData),
+			Resolution:   "high",
+			SeriesType:   "distance",
+		},
+	}
+
+	if normalizedCoordinates, ok := normalizeCoordinates(coordinates); ok {
+		stream.LatLng = &strava.LatLngStream{
+			Data:         normalizedCoordinates,
+			OriginalSize: len(normalizedCoordinates),
+			Resolution:   "high",
+			SeriesType:   "distance",
+		}
+	}
+	if HasAnyFloat(altitudeData) {
+		stream.Altitude = &strava.AltitudeStream{
+			Data:         altitudeData,
+			OriginalSize: len(altitudeData),
+			Resolution:   "high",
+			SeriesType:   "distance",
+		}
+	}
+	if HasAnyFloat(velocityData) {
+		stream.VelocitySmooth = &strava.SmoothVelocityStream{
+			Data:         velocityData,
+			OriginalSize: len(velocityData),
+			Resolution:   "high",
+			SeriesType:   "distance",
+		}
+	}
+	if HasAnyFloat(gradeData) {
+		stream.GradeSmooth = &strava.SmoothGradeStream{
+			Data:         gradeData,
+			OriginalSize: len(gradeData),
+			Resolution:   "high",
+			SeriesType:   "distance",
+		}
+	}
+	if hasAnyInt(cadenceData) {
+		stream.Cadence = &strava.CadenceStream{
+			Data:         cadenceData,
+			OriginalSize: len(cadenceData),
+			Resolution:   "high",
+			SeriesType:   "distance",
+		}
+	}
+	if hasAnyInt(heartRateData) {
+		stream.HeartRate = &strava.HeartRateStream{
+			Data:         heartRateData,
+			OriginalSize: len(heartRateData),
+			Resolution:   "high",
+			SeriesType:   "distance",
+		}
+	}
+	if HasAnyFloat(powerData) {
+		stream.Watts = &strava.PowerStream{
+			Data:         powerData,
+			OriginalSize: len(powerData),
+			Resolution:   "high",
+			SeriesType:   "distance",
+		}
+	}
+
+	return stream, stats
+}
+
+func hasRecordedDistance(points []Point) bool {
+	for _, point := range points {
+		if point.HasDistance && point.Distance > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func resolvePointTime(point Point, startTime time.Time, index int) time.Time {
+	if !point.Timestamp.IsZero() {
+		return point.Timestamp
+	}
+	return startTime.Add(time.Duration(index) * time.Second)
+}
+
+// FirstCoordinate returns the first recorded position, or nil.
+func FirstCoordinate(points []Point) []float64 {
+	for _, point := range points {
+		if point.HasPosition {
+			return []float64{point.Latitude, point.Longitude}
+		}
+	}
+	return nil
+}
+
+func normalizeCoordinates(coordinates [][]float64) ([][]float64, bool) {
+	if len(coordinates) == 0 {
+		return nil, false
+	}
+
+	normalized := make([][]float64, len(coordinates))
+	copy(normalized, coordinates)
+
+	firstValidIndex := -1
+	for index, coordinate := range normalized {
+		if IsCoordinateValid(coordinate) {
+			firstValidIndex = index
+			break
+		}
+	}
+	if firstValidIndex < 0 {
+		return nil, false
+	}
+
+	firstValid := normalized[firstValidIndex]
+	for i := 0; i < firstValidIndex; i++ {
+		normalized[i] = []float64{firstValid[0], firstValid[1]}
+	}
+
+	lastValid := firstValid
+	for index := firstValidIndex + 1; index < len(normalized); index++ {
+		if IsCoordinateValid(normalized[index]) {
+			lastValid = normalized[index]
+			continue
+		}
+		normalized[index] = []float64{lastValid[0], lastValid[1]}
+	}
+
+	return normalized, true
+}
+
+// IsCoordinateValid rejects missing, non-finite and 0,0 coordinates.
+func IsCoordinateValid(coordinate []float64) bool {
+	if len(coordinate) < 2 {
+		return false
+	}
+	lat := coordinate[0]
+	lng := coordinate[1]
+	if !IsFinite(lat) || !IsFinite(lng) {
+		return false
+	}
+	return !(lat == 0 && lng == 0)
+}
+
+func HasAnyFloat(values []float64) bool {
+	for _, value := range values {
+		if IsFinite(value) && math.Abs(value) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAnyInt(values []int) bool {
+	for _, value := range values {
+		if value > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// AverageInt averages the positive values, zero meaning no sample.
+func AverageInt(values []int) float64 {
+	sum := 0
+	count := 0
+	for _, value := range values {
+		if value > 0 {
+			sum += value
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return float64(sum) / float64(count)
+}
+
+// AverageFloat averages the positive finite values.
+func AverageFloat(values []float64) float64 {
+	sum := 0.0
+	count := 0
+	for _, value := range values {
+		if value > 0 && IsFinite(value) {
+			sum += value
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return sum / float64(count)
+}
+
+func MaxInt(values []int) int {
+	maximum := 0
+	for _, value := range values {
+		if value > maximum {
+			maximum = value
+		}
+	}
+	return maximum
+}
+
+func MaxFloat(values []float64) float64 {
+	maximum := 0.0
+	for _, value := range values {
+		if value > maximum && IsFinite(value) {
+			maximum = value
+		}
+	}
+	return maximum
+}
+
+func RoundedNonNegative(value float64) int {
+	if !IsFinite(value) || value <= 0 {
+		return 0
+	}
+	return int(math.Round(value))
+}
+
+func haversineMeters(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusMeters = 6371e3
+	lat1Rad := lat1 * math.Pi / 180
+	lat2Rad := lat2 * math.Pi / 180
+	deltaLat := (lat2 - lat1) * math.Pi / 180
+	deltaLon := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(deltaLat/2)*math.Sin(deltaLat/2) +
+		math.Cos(lat1Rad)*math.Cos(lat2Rad)*
+			math.Sin(deltaLon/2)*math.Sin(deltaLon/2)
+	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+	return earthRadiusMeters * c
+}
+
+func IsFinite(value float64) bool {
+	return !math.IsNaN(value) && !math.IsInf(value, 0)
+}
@@ -0,0 +1,69 @@
+package localtrack
+
+import (
+	"testing"
+	"time"
+
+	"mystravastats/internal/shared/domain/business"
+	"mystravastats/internal/shared/domain/strava"
+)
+
+func TestBuildStream_PrefersRecordedDistanceOverPositions(t *testing.T) {
+	// GIVEN
+	start := time.Date(2026, time.May, 3, 8, 0, 0, 0, time.UTC)
+	points := []Point{
+		{Timestamp: start, Latitude: 45.0, Longitude: 5.0, HasPosition: true, Distance: 0, HasDistance: true, Elevation: 200, HasElevation: true},
+		{Timestamp: start.Add(10 * time.Second), Latitude: 45.001, Longitude: 5.0, HasPosition: true, Distance: 50, HasDistance: true, Elevation: 205, HasElevation: true, HeartRate: 140},
+		{Latitude: 45.002, Longitude: 5.0, HasPosition: true, Distance: 100, HasDistance: true, Elevation: 203, HasElevation: true, HeartRate: 150},
+	}
+
+	// WHEN
+	stream, stats := BuildStream(points, start)
+
+	// THEN
+	if stats.DistanceMeters != 100 || stats.ElevationGainMeters != 5 {
+		t.Fatalf("expected the recorded 100 m and 5 m of gain, got %.1f m and %.1f m", stats.DistanceMeters, stats.ElevationGainMeters)
+	}
+	if len(stream.Time.Data) != 3 || stream.Time.Data[1] != 10 {
+		t.Fatalf("expected elapsed seconds from the timestamps, got %v", stream.Time.Data)
+	}
+	if stream.HeartRate == nil || stream.LatLng == nil || stream.Cadence != nil {
+		t.Fatal("expected heart rate and position streams, and no cadence stream")
+	}
+}
+
+func TestBuildStream_MeasuresDistanceFromPositionsWithoutRecordedDistance(t *testing.T) {
+	// GIVEN
+	start := time.Date(2026, time.May, 3, 8, 0, 0, 0, time.UTC)
+	points := []Point{
+		{Timestamp: start, Latitude: 45.0, Longitude: 5.0, HasPosition: true},
+		{Timestamp: start.Add(60 * time.Second), Latitude: 45.01, Longitude: 5.0, HasPosition: true},
+	}
+
+	// WHEN
+	_, stats := BuildStream(points, start)
+
+	// THEN
+	if stats.DistanceMeters < 1100 || stats.DistanceMeters > 1115 || stats.MovingTime != 60 {
+		t.Fatalf("expected about 1112 m moved in 60 s, got %.1f m in %d s", stats.DistanceMeters, stats.MovingTime)
+	}
+}
+
+func TestFilterByType_KeepsCommutesApart(t *testing.T) {
+	// GIVEN
+	activities := []*strava.Activity{
+		{Id: 1, SportType: "Ride"},
+		{Id: 2, SportType: "Ride", Commute: true},
+		{Id: 3, Type: "Run"},
+	}
+
+	// WHEN
+	rides := FilterByType(activities, business.Ride)
+	commutes := FilterByType(activities, business.Commute)
+	runs := FilterByType(activities, business.Run)
+
+	// THEN
+	if len(rides) != 1 || rides[0].Id != 1 || len(commutes) != 1 || commutes[0].Id != 2 || len(runs) != 1 {
+		t.Fatalf("expected one ride, one commute and one run, got %d, %d and %d", len(rides), len(commutes), len(runs))
+	}
+}
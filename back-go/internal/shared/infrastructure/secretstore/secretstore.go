@@ -0,0 +1,296 @@
+// Package secretstore reads and writes the files holding Strava secrets, the
+// .strava credentials and the .strava-token.json OAuth token.
+//
+// When a key is configured with STRAVA_SECRET_KEY or STRAVA_SECRET_KEY_FILE,
+// files are written as an AES-256-GCM envelope and EncryptInPlace migrates
+// existing plain files. Reads never rewrite a file. Without a key, files are
+// read and written in plain text as before, and encrypted files cannot be
+// read.
+package secretstore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"mystravastats/internal/platform/cachewrite"
+	"mystravastats/internal/platform/runtimeconfig"
+)
+
+const (
+	keyEnv     = "STRAVA_SECRET_KEY"
+	keyFileEnv = "STRAVA_SECRET_KEY_FILE"
+
+	envelopeFormat  = "mystravastats-secret"
+	envelopeVersion = 1
+	kdfName         = "pbkdf2-sha256"
+	kdfIterations   = 600_000
+	saltSize        = 16
+	generatedKeyLen = 32
+)
+
+var (
+	// ErrKeyRequired is returned when an encrypted file is read without a
+	// configured key.
+	ErrKeyRequired = errors.New("file is encrypted; set " + keyEnv + " or " + keyFileEnv)
+	// ErrDecrypt is returned when the configured key does not open a file.
+	ErrDecrypt = errors.New("unable to decrypt file with the configured key")
+)
+
+// envelope is the on-disk format of an encrypted file. Every write uses a new
+// salt and nonce.
+type envelope struct {
+	Format     string `json:"format"`
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       string `json:"salt"`
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// derivedKeys avoids running PBKDF2 again for a file read or written before.
+var derivedKeys = struct {
+	sync.Mutex
+	byInput map[string][]byte
+}{byInput: make(map[string][]byte)}
+
+// KeySource tells where the encryption key comes from: "env", "file" or
+// "none".
+func KeySource() string {
+	if value, ok := runtimeconfig.OptionalValue(keyEnv); ok && strings.TrimSpace(value) != "" {
+		return "env"
+	}
+	if value, ok := runtimeconfig.OptionalValue(keyFileEnv); ok && strings.TrimSpace(value) != "" {
+		return "file"
+	}
+	return "none"
+}
+
+// Enabled reports whether files are encrypted on write.
+func Enabled() bool {
+	return KeySource() != "none"
+}
+
+// ReadFile returns the plain content of path, decrypting it when it is an
+// encrypted envelope. The file itself is left as it is.
+func ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	return decrypt(data)
+}
+
+// EncryptInPlace rewrites the existing plain files among paths encrypted, when
+// a key is configured. Missing and already encrypted files are skipped; a
+// failed migration is logged and leaves the file plain.
+func EncryptInPlace(paths ...string) {
+	if !Enabled() {
+		return
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil || IsEncrypted(data) {
+			continue
+		}
+		if err := WriteFile(path, data); err != nil {
+			log.Printf("Unable to encrypt %s: %v", path, err)
+			continue
+		}
+		log.Printf("Encrypted %s with the configured secret key", path)
+	}
+}
+
+// WriteFile writes data to path with 0600 permissions, encrypted when a key
+// is configured. The file is replaced atomically.
+func WriteFile(path string, data []byte) error {
+	content := data
+	if Enabled() {
+		encrypted, err := encrypt(data)
+		if err != nil {
+			return err
+		}
+		content = encrypted
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	if err := cachewrite.WriteFile(path, content, 0o600); err != nil {
+		return err
+	}
+	// A leftover temporary file keeps its permissions when rewritten.
+	return os.Chmod(path, 0o600)
+}
+
+// IsEncrypted reports whether data is an encrypted envelope.
+func IsEncrypted(data []byte) bool {
+	var header struct {
+		Format string `json:"format"`
+	}
+	return json.Unmarshal(data, &header) == nil && header.Format == envelopeFormat
+}
+
+// FileEncrypted reports whether the file at path exists and is encrypted.
+func FileEncrypted(path string) bool {
+	data, err := os.ReadFile(path)
+	return err == nil && IsEncrypted(data)
+}
+
+func encrypt(plain []byte) ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(salt, kdfIterations)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(envelope{
+		Format:     envelopeFormat,
+		Version:    envelopeVersion,
+		KDF:        kdfName,
+		Iterations: kdfIterations,
+		Salt:       hex.EncodeToString(salt),
+		Nonce:      hex.EncodeToString(nonce),
+		Ciphertext: hex.EncodeToString(aead.Seal(nil, nonce, plain, []byte(envelopeFormat))),
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func decrypt(data []byte) ([]byte, error) {
+	var sealed envelope
+	if err := json.Unmarshal(data, &sealed); err != nil {
+		return nil, err
+	}
+	if sealed.Version != envelopeVersion || sealed.KDF != kdfName || sealed.Iterations <= 0 {
+		return nil, fmt.Errorf("unsupported encrypted file version %d (%s)", sealed.Version, sealed.KDF)
+	}
+	if !Enabled() {
+		return nil, ErrKeyRequired
+	}
+	salt, saltErr := hex.DecodeString(sealed.Salt)
+	nonce, nonceErr := hex.DecodeString(sealed.Nonce)
+	ciphertext, ciphertextErr := hex.DecodeString(sealed.Ciphertext)
+	if err := errors.Join(saltErr, nonceErr, ciphertextErr); err != nil {
+		return nil, fmt.Errorf("malformed encrypted file: %w", err)
+	}
+	aead, err := newAEAD(salt, sealed.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("malformed encrypted file: invalid nonce")
+	}
+	plain, err := aead.Open(nil, nonce, ciphertext, []byte(envelopeFormat))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plain, nil
+}
+
+func newAEAD(salt []byte, iterations int) (cipher.AEAD, error) {
+	key, err := deriveKey(salt, iterations)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func deriveKey(salt []byte, iterations int) ([]byte, error) {
+	material, err := keyMaterial()
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(material)
+	cacheKey := fmt.Sprintf("%x|%x|%d", digest, salt, iterations)
+
+	derivedKeys.Lock()
+	defer derivedKeys.Unlock()
+	if key, ok := derivedKeys.byInput[cacheKey]; ok {
+		return key, nil
+	}
+	key, err := pbkdf2.Key(sha256.New, string(material), salt, iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	derivedKeys.byInput[cacheKey] = key
+	return key, nil
+}
+
+// keyMaterial returns STRAVA_SECRET_KEY, or the content of
+// STRAVA_SECRET_KEY_FILE. A missing key file is created with a random key so
+// that pointing the variable at a keyring location is enough to opt in.
+func keyMaterial() ([]byte, error) {
+	if value, ok := runtimeconfig.OptionalValue(keyEnv); ok && strings.TrimSpace(value) != "" {
+		return []byte(strings.TrimSpace(value)), nil
+	}
+	path, ok := runtimeconfig.OptionalValue(keyFileEnv)
+	path = strings.TrimSpace(path)
+	if !ok || path == "" {
+		return nil, ErrKeyRequired
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return createKeyFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", keyFileEnv, err)
+	}
+	material := []byte(strings.TrimSpace(string(data)))
+	if len(material) == 0 {
+		return nil, fmt.Errorf("%s %s is empty", keyFileEnv, path)
+	}
+	return material, nil
+}
+
+func createKeyFile(path string) ([]byte, error) {
+	random := make([]byte, generatedKeyLen)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	material := []byte(hex.EncodeToString(random))
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		// Another caller created it first.
+		return keyMaterial()
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, writeErr := file.Write(append(material, '\n'))
+	closeErr := file.Close()
+	if err := errors.Join(writeErr, closeErr); err != nil {
+		return nil, err
+	}
+	log.Printf("Created secret key file %s", path)
+	return material, nil
+}
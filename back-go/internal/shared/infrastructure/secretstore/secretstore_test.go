@@ -0,0 +1,169 @@
+package secretstore
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testCredentials = "clientId=12345\nclientSecret=very-secret-value\nuseCache=false\n"
+
+func TestWriteFile_EncryptsWithConfiguredKey(t *testing.T) {
+	// GIVEN
+	t.Setenv(keyEnv, "correct horse battery staple")
+	path := filepath.Join(t.TempDir(), ".strava")
+
+	// WHEN
+	err := WriteFile(path, []byte(testCredentials))
+
+	// THEN
+	if err != nil {
+		t.Fatalf("expected encrypted write to succeed, got %v", err)
+	}
+	raw, _ := os.ReadFile(path)
+	if !IsEncrypted(raw) || bytes.Contains(raw, []byte("very-secret-value")) {
+		t.Fatalf("expected an encrypted envelope without the secret, got %s", raw)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected 0600 permissions, got %o", info.Mode().Perm())
+	}
+	plain, err := ReadFile(path)
+	if err != nil || string(plain) != testCredentials {
+		t.Fatalf("expected decrypted credentials, got %q (%v)", plain, err)
+	}
+}
+
+func TestReadFile_LeavesPlainFileUntouched(t *testing.T) {
+	// GIVEN
+	path := filepath.Join(t.TempDir(), ".strava-token.json")
+	if err := os.WriteFile(path, []byte(`{"refresh_token":"refresh-value"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(keyEnv, "correct horse battery staple")
+
+	// WHEN
+	plain, err := ReadFile(path)
+
+	// THEN
+	if err != nil || string(plain) != `{"refresh_token":"refresh-value"}` {
+		t.Fatalf("expected plain token content, got %q (%v)", plain, err)
+	}
+	if FileEncrypted(path) {
+		t.Fatal("expected a read to leave the plain token file as it is")
+	}
+}
+
+func TestEncryptInPlace_MigratesPlainFiles(t *testing.T) {
+	// GIVEN
+	directory := t.TempDir()
+	plainPath := filepath.Join(directory, ".strava-token.json")
+	if err := os.WriteFile(plainPath, []byte(`{"refresh_token":"refresh-value"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	missingPath := filepath.Join(directory, ".strava")
+
+	// WHEN
+	EncryptInPlace(plainPath, missingPath)
+	plainWithoutKey := !FileEncrypted(plainPath)
+	t.Setenv(keyEnv, "correct horse battery staple")
+	EncryptInPlace(plainPath, missingPath)
+
+	// THEN
+	if !plainWithoutKey {
+		t.Fatal("expected files to stay plain without a key")
+	}
+	if !FileEncrypted(plainPath) {
+		t.Fatal("expected the plain token file to be migrated to the encrypted format")
+	}
+	if info, _ := os.Stat(plainPath); info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected 0600 permissions, got %o", info.Mode().Perm())
+	}
+	if _, err := os.Stat(missingPath); !os.IsNotExist(err) {
+		t.Fatalf("expected no file to be created, got %v", err)
+	}
+	if plain, err := ReadFile(plainPath); err != nil || string(plain) != `{"refresh_token":"refresh-value"}` {
+		t.Fatalf("expected decrypted token content, got %q (%v)", plain, err)
+	}
+}
+
+func TestReadFile_ReportsMissingOrWrongKey(t *testing.T) {
+	// GIVEN
+	t.Setenv(keyEnv, "first key")
+	path := filepath.Join(t.TempDir(), ".strava")
+	if err := WriteFile(path, []byte(testCredentials)); err != nil {
+		t.Fatal(err)
+	}
+
+	// WHEN
+	t.Setenv(keyEnv, "second key")
+	_, wrongKeyErr := ReadFile(path)
+	t.Setenv(keyEnv, "")
+	_, missingKeyErr := ReadFile(path)
+
+	// THEN
+	if !errors.Is(wrongKeyErr, ErrDecrypt) {
+		t.Fatalf("expected ErrDecrypt with another key, got %v", wrongKeyErr)
+	}
+	if !errors.Is(missingKeyErr, ErrKeyRequired) {
+		t.Fatalf("expected ErrKeyRequired without key, got %v", missingKeyErr)
+	}
+}
+
+func TestWriteFile_CreatesMissingKeyFile(t *testing.T) {
+	// GIVEN
+	directory := t.TempDir()
+	keyFile := filepath.Join(directory, "keyring", "mystravastats.key")
+	t.Setenv(keyFileEnv, keyFile)
+	path := filepath.Join(directory, ".strava")
+
+	// WHEN
+	err := WriteFile(path, []byte(testCredentials))
+
+	// THEN
+	if err != nil {
+		t.Fatalf("expected write with a generated key to succeed, got %v", err)
+	}
+	info, statErr := os.Stat(keyFile)
+	if statErr != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected generated key file with 0600 permissions, got %v", statErr)
+	}
+	if KeySource() != "file" || !FileEncrypted(path) {
+		t.Fatalf("expected file encrypted with the key file, source=%s", KeySource())
+	}
+	if plain, err := ReadFile(path); err != nil || string(plain) != testCredentials {
+		t.Fatalf("expected decrypted credentials, got %q (%v)", plain, err)
+	}
+}
+
+func TestSecretStore_NeverLogsSecrets(t *testing.T) {
+	// GIVEN
+	var output bytes.Buffer
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+	key := "key-that-must-stay-private"
+	t.Setenv(keyEnv, key)
+	plainPath := filepath.Join(t.TempDir(), ".strava")
+	if err := os.WriteFile(plainPath, []byte(testCredentials), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// WHEN
+	EncryptInPlace(plainPath)
+	t.Setenv(keyEnv, "another-key")
+	_, err := ReadFile(plainPath)
+
+	// THEN
+	logged := output.String() + err.Error()
+	for _, secret := range []string{"very-secret-value", key, "another-key"} {
+		if strings.Contains(logged, secret) {
+			t.Fatalf("expected %q to never be logged, got %s", secret, logged)
+		}
+	}
+	if !strings.Contains(output.String(), "Encrypted "+plainPath) {
+		t.Fatalf("expected the migration to be logged by path, got %s", output.String())
+	}
+}
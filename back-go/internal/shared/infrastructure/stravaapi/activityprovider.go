@@ -1,13 +1,16 @@
 package stravaapi
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"mystravastats/domain/statistics"
 	"mystravastats/internal/helpers"
+	"mystravastats/internal/platform/events"
 	"mystravastats/internal/shared/domain/business"
 	"mystravastats/internal/shared/domain/strava"
 	"mystravastats/internal/shared/infrastructure/localrepository"
+	"mystravastats/internal/shared/infrastructure/secretstore"
 	"path/filepath"
 	"runtime"
 	"sort"
@@ -40,6 +43,11 @@ type StravaActivityProvider struct {
 	manifestMutex         sync.Mutex
 	cacheManifest         cacheManifest
 	rateLimitUntilUnix    atomic.Int64
+	dataRevision          atomic.Int64
+	webhook               webhookQueue
+	reconciliation        reconciliationState
+	uploadMutex           sync.Mutex
+	uploadPoll            time.Duration
 }
 
 const detailedBackfillRequestDelay = 1500 * time.Millisecond
@@ -54,6 +62,7 @@ func NewStravaActivityProvider(stravaCache string, serverPort string) *StravaAct
 		cacheRoot:            stravaCache,
 	}
 
+	secretstore.EncryptInPlace(filepath.Join(stravaCache, ".strava"), filepath.Join(stravaCache, ".strava-token.json"))
 	id, secret, useCache := provider.localStorageProvider.ReadStravaAuthentication(stravaCache)
 	if id == "" {
 		log.Fatal(`
@@ -147,7 +156,7 @@ func (provider *StravaActivityProvider) GetDetailedActivity(activityId int64) *s
 			api = provider.ensureStravaAPI()
 		}
 		if api != nil {
-			detailedActivity, err := api.GetDetailedActivity(activityId)
+			detailedActivity, err := api.Interactive().GetDetailedActivity(activityId)
 			if err == nil && detailedActivity != nil {
 				year := resolveDetailedActivityYear(detailedActivity)
 				provider.localStorageProvider.SaveDetailedActivityToCache(provider.clientId, year, *detailedActivity)
@@ -175,6 +184,9 @@ func (provider *StravaActivityProvider) GetDetailedActivity(activityId int64) *s
 		api = provider.ensureStravaAPI()
 	}
 
+	if api != nil {
+		api = api.Interactive()
+	}
 	if api != nil && stravaDetailedActivity == nil {
 		detailedActivity, err := api.GetDetailedActivity(activityId)
 		if err == nil && detailedActivity != nil {
@@ -428,6 +440,18 @@ func (provider *StravaActivityProvider) retrieveLoggedInAthlete(clientId string)
 }
 
 func (provider *StravaActivityProvider) retrieveActivities(clientId string, year int, failFastOnRateLimit bool) ([]strava.Activity, error) {
+	activities, err := provider.listYearActivities(year, failFastOnRateLimit)
+	if err != nil {
+		return nil, err
+	}
+	provider.reconcileYearCache(clientId, year, activities)
+	provider.localStorageProvider.SaveActivitiesToCache(clientId, year, activities)
+	return activities, nil
+}
+
+// listYearActivities fetches the activity summaries of year from Strava,
+// without touching the cache.
+func (provider *StravaActivityProvider) listYearActivities(year int, failFastOnRateLimit bool) ([]strava.Activity, error) {
 	log.Printf("⌛ Load activities from Strava for year %d", year)
 	if provider.isStravaRateLimitedNow() {
 		return nil, ErrStravaRateLimitReached
@@ -450,10 +474,7 @@ func (provider *StravaActivityProvider) retrieveActivities(clientId string, year
 		}
 
 		if err == nil {
-			filteredActivities := filterByActivityTypes(retrievedActivities)
-			provider.localStorageProvider.SaveActivitiesToCache(clientId, year, filteredActivities)
-
-			return filteredActivities, nil
+			return filterByActivityTypes(retrievedActivities), nil
 		}
 		provider.markStravaRateLimited(err, fmt.Sprintf("activities year %d", year))
 
@@ -754,6 +775,12 @@ func (provider *StravaActivityProvider) markStravaRateLimited(err error, source
 	if !IsRateLimitError(err) {
 		return
 	}
+	var budgetErr *BudgetExhaustedError
+	if errors.As(err, &budgetErr) {
+		// The request was never sent: the scheduler already holds it back.
+		log.Printf("Strava request skipped (%s): %v", source, err)
+		return
+	}
 
 	previousUntilUnix := provider.rateLimitUntilUnix.Load()
 	until := time.Now().UTC().Add(stravaRateLimitCooldown)
@@ -858,66 +885,82 @@ func (provider *StravaActivityProvider) loadDetailedActivityFromCacheAnyYear(act
 }
 
 func (provider *StravaActivityProvider) refreshAllYearsActivitiesFromCurrentYear(startYear int) bool {
+	yearCount := startYear - 2010 + 1
+	progress := events.Start(events.OperationStravaRefresh, provider.clientId, yearCount, "Refreshing Strava activities year by year.")
 	for year := startYear; year >= 2010; year-- {
+		progress.Update("activities", year, startYear-year, 0)
 		refreshed, err := provider.retrieveActivities(provider.clientId, year, true)
 		if err != nil {
 			if IsRateLimitError(err) {
 				log.Printf("Background refresh stopped at year %d due to Strava rate limit", year)
+				progress.Fail(fmt.Sprintf("Stopped at year %d by the Strava rate limit.", year))
 				return true
 			}
 			log.Printf("Background refresh: failed for year %d: %v", year, err)
+			progress.Error(fmt.Sprintf("year %d: %v", year, err))
 			continue
 		}
 
 		if len(refreshed) > 0 {
+			progress.Update("streams", year, startYear-year, 0)
 			refreshed = provider.loadActivitiesStreams(provider.clientId, year, refreshed)
 			if provider.isStravaRateLimitedNow() {
 				log.Printf("Background refresh stream load stopped at year %d due to Strava rate limit", year)
+				progress.Fail(fmt.Sprintf("Stopped at year %d by the Strava rate limit.", year))
 				return true
 			}
 		}
 
-		refreshedPointers := appendActivityPointers(make([]*strava.Activity, 0, len(refreshed)), refreshed)
-		existing := provider.getActivitiesSnapshot()
-		merged := make([]*strava.Activity, 0, len(existing)+len(refreshedPointers))
-		for _, activity := range existing {
-			if activity == nil {
-				continue
-			}
-			if len(activity.StartDateLocal) >= 4 {
-				if y, parseErr := strconv.Atoi(activity.StartDateLocal[:4]); parseErr == nil && y == year {
-					continue
-				}
-			}
-			merged = append(merged, activity)
+		total := provider.mergeRefreshedYear(year, refreshed)
+		log.Printf("Background refresh merged year %d activities (%d total)", year, total)
+	}
+
+	progress.Update("activities", 2010, yearCount, 0)
+	progress.Complete(fmt.Sprintf("Refreshed %d year(s) of Strava activities.", yearCount))
+	return false
+}
+
+// mergeRefreshedYear replaces the activities of year with the list just
+// fetched from Strava and invalidates the best efforts of every activity
+// involved. It returns the number of activities after the merge.
+func (provider *StravaActivityProvider) mergeRefreshedYear(year int, refreshed []strava.Activity) int {
+	refreshedPointers := appendActivityPointers(make([]*strava.Activity, 0, len(refreshed)), refreshed)
+	existing := provider.getActivitiesSnapshot()
+	merged := make([]*strava.Activity, 0, len(existing)+len(refreshedPointers))
+	for _, activity := range existing {
+		if activity == nil {
+			continue
 		}
-		merged = append(merged, refreshedPointers...)
-		provider.replaceActivities(merged)
-		invalidatedActivityIDs := map[int64]struct{}{}
-		for _, activity := range existing {
-			if activity == nil {
+		if len(activity.StartDateLocal) >= 4 {
+			if y, parseErr := strconv.Atoi(activity.StartDateLocal[:4]); parseErr == nil && y == year {
 				continue
 			}
-			activityYear := resolveActivityYear(activity)
-			if activityYear == year {
-				invalidatedActivityIDs[activity.Id] = struct{}{}
-			}
 		}
-		for _, activity := range refreshedPointers {
-			if activity == nil {
-				continue
-			}
+		merged = append(merged, activity)
+	}
+	merged = append(merged, refreshedPointers...)
+	provider.replaceActivities(merged)
+	invalidatedActivityIDs := map[int64]struct{}{}
+	for _, activity := range existing {
+		if activity == nil {
+			continue
+		}
+		activityYear := resolveActivityYear(activity)
+		if activityYear == year {
 			invalidatedActivityIDs[activity.Id] = struct{}{}
 		}
-		removedEntries := statistics.InvalidateBestEffortCacheByActivityIDs(invalidatedActivityIDs)
-		if removedEntries > 0 {
-			log.Printf("Invalidated %d best-effort cache entries after refreshing year %d", removedEntries, year)
+	}
+	for _, activity := range refreshedPointers {
+		if activity == nil {
+			continue
 		}
-
-		log.Printf("Background refresh merged year %d activities (%d total)", year, len(merged))
+		invalidatedActivityIDs[activity.Id] = struct{}{}
 	}
-
-	return false
+	removedEntries := statistics.InvalidateBestEffortCacheByActivityIDs(invalidatedActivityIDs)
+	if removedEntries > 0 {
+		log.Printf("Invalidated %d best-effort cache entries after refreshing year %d", removedEntries, year)
+	}
+	return len(merged)
 }
 
 func (provider *StravaActivityProvider) backfillMissingStreams() {
@@ -941,23 +984,36 @@ func (provider *StravaActivityProvider) backfillMissingStreams() {
 	}
 
 	years := make([]int, 0, len(activitiesByYear))
-	for year := range activitiesByYear {
+	missingStreams := 0
+	for year, yearActivities := range activitiesByYear {
 		years = append(years, year)
+		missingStreams += len(yearActivities)
 	}
 	sort.Sort(sort.Reverse(sort.IntSlice(years)))
+	if missingStreams == 0 {
+		return
+	}
 
+	progress := events.Start(events.OperationStreamBackfill, provider.clientId, missingStreams, "Loading missing activity streams.")
+	processed := 0
 	for _, year := range years {
 		if provider.isStravaRateLimitedNow() {
 			log.Printf("Stream backfill stopped early due to Strava rate limit")
+			progress.Fail("Stopped by the Strava rate limit.")
 			return
 		}
 		yearActivities := activitiesByYear[year]
+		progress.Update("streams", year, processed, 0)
 		provider.loadMissingStreamsForPointers(year, yearActivities)
+		processed += len(yearActivities)
 		if provider.isStravaRateLimitedNow() {
 			log.Printf("Stream backfill stopped at year %d due to Strava rate limit", year)
+			progress.Fail(fmt.Sprintf("Stopped at year %d by the Strava rate limit.", year))
 			return
 		}
 	}
+	progress.Update("streams", years[len(years)-1], processed, 0)
+	progress.Complete(fmt.Sprintf("Checked streams of %d activities.", processed))
 }
 
 func (provider *StravaActivityProvider) backfillMissingDetailedActivities(startYear int) bool {
@@ -1000,8 +1056,10 @@ func (provider *StravaActivityProvider) backfillMissingDetailedActivities(startY
 	}
 
 	log.Printf("Detailed backfill started for %d missing activities", missingDetails)
+	progress := events.Start(events.OperationDetailedBackfill, provider.clientId, missingDetails, "Loading missing detailed activities.")
 	lastRequestAt := time.Time{}
 	totalLoaded := 0
+	processed := 0
 
 	for year := startYear; year >= 2010; year-- {
 		yearActivities := activitiesByYear[year]
@@ -1015,8 +1073,11 @@ func (provider *StravaActivityProvider) backfillMissingDetailedActivities(startY
 
 		loadedForYear := 0
 		for _, activity := range yearActivities {
+			progress.Update("details", year, processed, 0)
+			processed++
 			if provider.isStravaRateLimitedNow() {
 				log.Printf("Detailed backfill stopped before activity %d because Strava rate limit is active", activity.Id)
+				progress.Fail(fmt.Sprintf("Stopped at year %d by the Strava rate limit.", year))
 				return true
 			}
 
@@ -1036,9 +1097,11 @@ func (provider *StravaActivityProvider) backfillMissingDetailedActivities(startY
 						year,
 						activity.Id,
 					)
+					progress.Fail(fmt.Sprintf("Stopped at year %d by the Strava rate limit.", year))
 					return true
 				}
 				log.Printf("Unable to backfill detailed activity %d: %v", activity.Id, err)
+				progress.Error(fmt.Sprintf("activity %d: %v", activity.Id, err))
 				continue
 			}
 			if detailedActivity == nil {
@@ -1056,6 +1119,8 @@ func (provider *StravaActivityProvider) backfillMissingDetailedActivities(startY
 	}
 
 	log.Printf("Detailed backfill completed (%d activities cached)", totalLoaded)
+	progress.Update("details", 0, processed, 0)
+	progress.Complete(fmt.Sprintf("Cached %d detailed activities.", totalLoaded))
 	return false
 }
 
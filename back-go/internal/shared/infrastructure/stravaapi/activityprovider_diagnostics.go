@@ -2,11 +2,13 @@ package stravaapi
 
 import (
 	"os"
+	"path/filepath"
 	"sort"
 	"time"
 
 	"mystravastats/domain/statistics"
 	"mystravastats/internal/shared/domain/strava"
+	"mystravastats/internal/shared/infrastructure/secretstore"
 )
 
 func (provider *StravaActivityProvider) CacheDiagnostics() map[string]any {
@@ -27,6 +29,7 @@ func (provider *StravaActivityProvider) CacheDiagnostics() map[string]any {
 		"cacheRoot":         provider.cacheRoot,
 		"activities":        len(activities),
 		"availableYearBins": availableYearBins(activities),
+		"dataRevision":      provider.dataRevision.Load(),
 		"refresh": map[string]any{
 			"backgroundInProgress": provider.backgroundRefresh.Load(),
 			"warmupInProgress":     provider.warmupInProgress.Load(),
@@ -34,7 +37,11 @@ func (provider *StravaActivityProvider) CacheDiagnostics() map[string]any {
 		"rateLimit": map[string]any{
 			"active":       provider.isStravaRateLimitedNow(),
 			"untilEpochMs": rateLimitUntilUnix * 1000,
+			"budget":       RateBudgetDiagnostics(provider.clientId),
 		},
+		"webhook":        provider.webhookDiagnostics(),
+		"secrets":        provider.secretsDiagnostics(),
+		"reconciliation": provider.ReconciliationReport(),
 		"manifest": map[string]any{
 			"schemaVersion": manifest.SchemaVersion,
 			"updatedAt":     manifest.UpdatedAt,
@@ -63,6 +70,16 @@ func (provider *StravaActivityProvider) CacheDiagnostics() map[string]any {
 	}
 }
 
+// secretsDiagnostics tells how the credentials and token files are stored,
+// never what they contain.
+func (provider *StravaActivityProvider) secretsDiagnostics() map[string]any {
+	return map[string]any{
+		"keySource":            secretstore.KeySource(),
+		"credentialsEncrypted": secretstore.FileEncrypted(filepath.Join(provider.cacheRoot, ".strava")),
+		"tokenEncrypted":       secretstore.FileEncrypted(filepath.Join(provider.cacheRoot, ".strava-token.json")),
+	}
+}
+
 func availableYearBins(activities []*strava.Activity) []string {
 	yearsSet := make(map[string]struct{})
 	for _, activity := range activities {
@@ -0,0 +1,44 @@
+package stravaapi
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mystravastats/internal/shared/infrastructure/secretstore"
+)
+
+func TestCacheDiagnostics_ReportsSecretStorageWithoutSecrets(t *testing.T) {
+	// GIVEN
+	t.Setenv("STRAVA_SECRET_KEY", "diagnostics-key")
+	root := t.TempDir()
+	if err := secretstore.WriteFile(filepath.Join(root, ".strava"), []byte("clientId=123\nclientSecret=client-secret-value\nuseCache=false\n")); err != nil {
+		t.Fatal(err)
+	}
+	provider := &StravaActivityProvider{
+		clientId:     "123",
+		clientSecret: "client-secret-value",
+		cacheRoot:    root,
+		StravaApi:    &StravaApi{accessToken: "access-value", tokenStore: filepath.Join(root, ".strava-token.json")},
+	}
+	if err := provider.StravaApi.saveToken(Token{AccessToken: "access-value", RefreshToken: "refresh-value"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// WHEN
+	data, err := json.Marshal(provider.CacheDiagnostics())
+
+	// THEN
+	if err != nil {
+		t.Fatalf("expected diagnostics to marshal, got %v", err)
+	}
+	for _, secret := range []string{"client-secret-value", "access-value", "refresh-value", "diagnostics-key"} {
+		if strings.Contains(string(data), secret) {
+			t.Fatalf("expected diagnostics to never contain %q, got %s", secret, data)
+		}
+	}
+	if !strings.Contains(string(data), `"secrets":{"credentialsEncrypted":true,"keySource":"env","tokenEncrypted":true}`) {
+		t.Fatalf("expected encrypted secret storage to be reported, got %s", data)
+	}
+}
@@ -0,0 +1,247 @@
+package stravaapi
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"mystravastats/domain/statistics"
+	"mystravastats/internal/shared/domain/strava"
+)
+
+const maxReconciliationChanges = 20
+
+const (
+	reconciliationAdded   = "added"
+	reconciliationUpdated = "updated"
+	reconciliationRemoved = "removed"
+)
+
+// ReconciliationChange describes one activity whose cached copy differed from Strava.
+type ReconciliationChange struct {
+	ActivityID int64    `json:"activityId"`
+	Year       int      `json:"year"`
+	Change     string   `json:"change"`
+	Name       string   `json:"name,omitempty"`
+	Fields     []string `json:"fields,omitempty"`
+}
+
+// ReconciliationReport summarizes the differences found between the yearly
+// cache files and the activity lists returned by Strava.
+type ReconciliationReport struct {
+	LastRunAt           string                 `json:"lastRunAt"`
+	YearsChecked        int                    `json:"yearsChecked"`
+	Added               int                    `json:"added"`
+	Updated             int                    `json:"updated"`
+	Removed             int                    `json:"removed"`
+	OrphanFilesRemoved  int                    `json:"orphanFilesRemoved"`
+	StaleStreamsDropped int                    `json:"staleStreamsDropped"`
+	RecentChanges       []ReconciliationChange `json:"recentChanges"`
+}
+
+type reconciliationState struct {
+	mutex  sync.Mutex
+	report ReconciliationReport
+}
+
+// reconcileYearCache compares the cached summaries of a year with the list just
+// returned by Strava, before the year file is overwritten.
+//
+// Strava summaries carry no modification timestamp, so edits are detected by
+// comparing the fields users can change. Renames and sport changes patch the
+// cached detailed activity; edits that change the recorded data (crop, start
+// time) drop the stream and detailed files so the backfill downloads them again.
+// It returns the number of activities added, updated or removed.
+func (provider *StravaActivityProvider) reconcileYearCache(clientId string, year int, remote []strava.Activity) int {
+	cached := provider.localStorageProvider.LoadActivitiesFromCache(clientId, year)
+	if cached == nil {
+		return 0
+	}
+
+	remoteByID := make(map[int64]strava.Activity, len(remote))
+	keep := make(map[int64]struct{}, len(remote))
+	for _, activity := range remote {
+		remoteByID[activity.Id] = activity
+		keep[activity.Id] = struct{}{}
+	}
+	cachedByID := make(map[int64]strava.Activity, len(cached))
+	for _, activity := range cached {
+		cachedByID[activity.Id] = activity
+	}
+
+	changes := make([]ReconciliationChange, 0)
+	staleStreams := 0
+	for _, activity := range remote {
+		previous, existed := cachedByID[activity.Id]
+		if !existed {
+			changes = append(changes, ReconciliationChange{ActivityID: activity.Id, Year: year, Change: reconciliationAdded, Name: activity.Name})
+			continue
+		}
+		fields, dataChanged := changedSummaryFields(previous, activity)
+		if len(fields) == 0 {
+			continue
+		}
+		if dataChanged {
+			staleStreams += provider.localStorageProvider.RemoveActivityFilesFromCache(clientId, year, activity.Id, true, true)
+		} else if detailed := provider.localStorageProvider.LoadDetailedActivityFromCache(clientId, year, activity.Id); detailed != nil {
+			provider.localStorageProvider.SaveDetailedActivityToCache(clientId, year, *patchDetailedActivitySummary(detailed, activity))
+		}
+		changes = append(changes, ReconciliationChange{ActivityID: activity.Id, Year: year, Change: reconciliationUpdated, Name: activity.Name, Fields: fields})
+	}
+	for _, activity := range cached {
+		if _, stillRemote := remoteByID[activity.Id]; !stillRemote {
+			changes = append(changes, ReconciliationChange{ActivityID: activity.Id, Year: year, Change: reconciliationRemoved, Name: activity.Name})
+		}
+	}
+
+	orphanFiles := 0
+	for _, count := range provider.localStorageProvider.RemoveOrphanActivityFiles(clientId, year, keep) {
+		orphanFiles += count
+	}
+
+	invalidated := make(map[int64]struct{})
+	for _, change := range changes {
+		if change.Change != reconciliationAdded {
+			invalidated[change.ActivityID] = struct{}{}
+		}
+	}
+	if len(invalidated) > 0 {
+		statistics.InvalidateBestEffortCacheByActivityIDs(invalidated)
+		provider.dataRevision.Add(1)
+	}
+
+	provider.recordReconciliation(changes, orphanFiles, staleStreams)
+	if len(changes) > 0 || orphanFiles > 0 {
+		log.Printf("Reconciled year %d cache: %d change(s), %d orphan file(s) removed, %d stale file(s) dropped",
+			year, len(changes), orphanFiles, staleStreams)
+	}
+	return len(changes)
+}
+
+// ReconcileCachedYears reconciles every cached past year with Strava. The
+// retrieval of a year only runs for the current year and on the startup
+// refresh, so a long-running server would otherwise keep deleted or edited
+// activities of past years. Only the activity lists are fetched: the files
+// dropped by an edit are downloaded again by the next backfill. The pass stops
+// at the first error, such as the Strava rate limit.
+func (provider *StravaActivityProvider) ReconcileCachedYears() (ReconciliationReport, error) {
+	if provider.useCacheAuth || provider.clientSecret == "" {
+		return provider.ReconciliationReport(), ErrStravaCacheOnly
+	}
+	if !provider.backgroundRefresh.CompareAndSwap(false, true) {
+		return provider.ReconciliationReport(), ErrStravaRefreshInProgress
+	}
+	defer provider.backgroundRefresh.Store(false)
+
+	for year := time.Now().Year() - 1; year >= 2010; year-- {
+		if !provider.localStorageProvider.IsLocalCacheExistForYear(provider.clientId, year) {
+			continue
+		}
+		remote, err := provider.listYearActivities(year, true)
+		if err != nil {
+			return provider.ReconciliationReport(), fmt.Errorf("reconcile year %d: %w", year, err)
+		}
+		changed := provider.reconcileYearCache(provider.clientId, year, remote)
+		provider.localStorageProvider.SaveActivitiesToCache(provider.clientId, year, remote)
+		if changed > 0 {
+			provider.mergeRefreshedYear(year, provider.loadCachedStreams(year, remote))
+		}
+	}
+	return provider.ReconciliationReport(), nil
+}
+
+// loadCachedStreams attaches the cached streams to activities without asking
+// Strava for the missing ones.
+func (provider *StravaActivityProvider) loadCachedStreams(year int, activities []strava.Activity) []strava.Activity {
+	streamIDs := provider.localStorageProvider.BuildStreamIdsSet(provider.clientId, year)
+	for index := range activities {
+		if streamIDs[activities[index].Id] {
+			activities[index].Stream = provider.localStorageProvider.LoadActivitiesStreamsFromCache(provider.clientId, year, activities[index])
+		}
+	}
+	return activities
+}
+
+func (provider *StravaActivityProvider) recordReconciliation(changes []ReconciliationChange, orphanFiles int, staleStreams int) {
+	state := &provider.reconciliation
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+
+	report := &state.report
+	report.LastRunAt = time.Now().UTC().Format(time.RFC3339)
+	report.YearsChecked++
+	report.OrphanFilesRemoved += orphanFiles
+	report.StaleStreamsDropped += staleStreams
+	for _, change := range changes {
+		switch change.Change {
+		case reconciliationAdded:
+			report.Added++
+		case reconciliationUpdated:
+			report.Updated++
+		case reconciliationRemoved:
+			report.Removed++
+		}
+	}
+	report.RecentChanges = append(changes, report.RecentChanges...)
+	if len(report.RecentChanges) > maxReconciliationChanges {
+		report.RecentChanges = report.RecentChanges[:maxReconciliationChanges]
+	}
+}
+
+// ReconciliationReport returns the cumulative reconciliation counters since startup.
+func (provider *StravaActivityProvider) ReconciliationReport() ReconciliationReport {
+	state := &provider.reconciliation
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+
+	report := state.report
+	report.RecentChanges = append([]ReconciliationChange{}, state.report.RecentChanges...)
+	return report
+}
+
+// changedSummaryFields lists the edited fields and reports whether any of them
+// means the recorded data itself changed.
+func changedSummaryFields(cached strava.Activity, remote strava.Activity) ([]string, bool) {
+	fields := make([]string, 0)
+	dataChanged := false
+	compare := func(name string, changed bool, affectsData bool) {
+		if !changed {
+			return
+		}
+		fields = append(fields, name)
+		dataChanged = dataChanged || affectsData
+	}
+
+	compare("name", cached.Name != remote.Name, false)
+	compare("type", cached.Type != remote.Type, false)
+	compare("sport_type", cached.SportType != remote.SportType, false)
+	compare("commute", cached.Commute != remote.Commute, false)
+	compare("gear_id", stringValue(cached.GearId) != stringValue(remote.GearId), false)
+	compare("start_date", cached.StartDate != remote.StartDate, true)
+	compare("distance", fmt.Sprintf("%.1f", cached.Distance) != fmt.Sprintf("%.1f", remote.Distance), true)
+	compare("elapsed_time", cached.ElapsedTime != remote.ElapsedTime, true)
+	compare("moving_time", cached.MovingTime != remote.MovingTime, true)
+	compare("total_elevation_gain", fmt.Sprintf("%.1f", cached.TotalElevationGain) != fmt.Sprintf("%.1f", remote.TotalElevationGain), true)
+
+	sort.Strings(fields)
+	return fields, dataChanged
+}
+
+func patchDetailedActivitySummary(detailed *strava.DetailedActivity, activity strava.Activity) *strava.DetailedActivity {
+	patched := *detailed
+	patched.Name = activity.Name
+	patched.Type = activity.Type
+	patched.SportType = activity.SportType
+	patched.Commute = activity.Commute
+	patched.GearId = activity.GearId
+	return &patched
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
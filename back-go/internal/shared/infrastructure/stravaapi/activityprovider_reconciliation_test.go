@@ -0,0 +1,188 @@
+package stravaapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mystravastats/internal/shared/domain/strava"
+	"mystravastats/internal/shared/infrastructure/localrepository"
+)
+
+func TestRetrieveActivities_ReconcilesDeletedAndEditedActivities(t *testing.T) {
+	// GIVEN
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/athlete/activities" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = fmt.Fprint(w, `[]`)
+			return
+		}
+		_, _ = fmt.Fprint(w, `[
+			{"id":1,"name":"Renamed ride","type":"Ride","sport_type":"GravelRide","start_date":"2025-03-01T08:00:00Z","start_date_local":"2025-03-01T09:00:00","distance":20000,"moving_time":3600,"elapsed_time":3700},
+			{"id":3,"name":"Cropped run","type":"Run","sport_type":"Run","start_date":"2025-04-01T08:00:00Z","start_date_local":"2025-04-01T09:00:00","distance":8000,"moving_time":2400,"elapsed_time":2500}
+		]`)
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	repo := localrepository.NewStravaRepository(cacheDir)
+	clientID := "123"
+	repo.InitLocalStorageForClientId(clientID)
+	repo.SaveActivitiesToCache(clientID, 2025, []strava.Activity{
+		{Id: 1, Name: "Morning ride", Type: "Ride", SportType: "Ride", StartDate: "2025-03-01T08:00:00Z", StartDateLocal: "2025-03-01T09:00:00", Distance: 20000, MovingTime: 3600, ElapsedTime: 3700},
+		{Id: 2, Name: "Deleted ride", Type: "Ride", SportType: "Ride", StartDate: "2025-03-02T08:00:00Z", StartDateLocal: "2025-03-02T09:00:00", Distance: 10000},
+		{Id: 3, Name: "Cropped run", Type: "Run", SportType: "Run", StartDate: "2025-04-01T08:00:00Z", StartDateLocal: "2025-04-01T09:00:00", Distance: 10000, MovingTime: 3000, ElapsedTime: 3100},
+	})
+	for _, id := range []int64{1, 2, 3} {
+		repo.SaveActivitiesStreamsToCache(clientID, 2025, strava.Activity{Id: id}, strava.Stream{})
+		repo.SaveDetailedActivityToCache(clientID, 2025, strava.DetailedActivity{Id: id, Name: "old", Type: "Ride", SportType: "Ride"})
+	}
+	provider := &StravaActivityProvider{
+		clientId:             clientID,
+		localStorageProvider: repo,
+		StravaApi: &StravaApi{
+			accessToken: "test-token",
+			properties:  StravaProperties{APIBaseURL: server.URL},
+			httpClient:  server.Client(),
+		},
+	}
+
+	// WHEN
+	activities, err := provider.retrieveActivities(clientID, 2025, true)
+
+	// THEN
+	if err != nil || len(activities) != 2 {
+		t.Fatalf("expected 2 remote activities, got %d (err=%v)", len(activities), err)
+	}
+	yearDirectory := filepath.Join(cacheDir, "strava-123", "strava-123-2025")
+	for _, name := range []string{"stream-2", "stravaActivity-2", "stream-3", "stravaActivity-3"} {
+		if _, err := os.Stat(filepath.Join(yearDirectory, name)); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be removed, got %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(yearDirectory, "stream-1")); err != nil {
+		t.Fatalf("expected renamed activity to keep its stream: %v", err)
+	}
+	detailed := repo.LoadDetailedActivityFromCache(clientID, 2025, 1)
+	if detailed == nil || detailed.Name != "Renamed ride" || detailed.SportType != "GravelRide" {
+		t.Fatalf("expected renamed detailed activity to be patched, got %#v", detailed)
+	}
+	cached := repo.LoadActivitiesFromCache(clientID, 2025)
+	if len(cached) != 2 || cached[0].Name != "Renamed ride" {
+		t.Fatalf("expected rewritten year summaries, got %#v", cached)
+	}
+	report := provider.ReconciliationReport()
+	if report.Updated != 2 || report.Removed != 1 || report.Added != 0 {
+		t.Fatalf("expected 2 updated and 1 removed activity, got %#v", report)
+	}
+	if report.OrphanFilesRemoved != 2 || report.StaleStreamsDropped != 2 {
+		t.Fatalf("expected 2 orphan and 2 stale files removed, got %#v", report)
+	}
+}
+
+func TestChangedSummaryFields_SeparatesMetadataFromRecordedDataEdits(t *testing.T) {
+	// GIVEN
+	cached := strava.Activity{Name: "Ride", Type: "Ride", SportType: "Ride", Distance: 1000}
+	renamed := cached
+	renamed.Name = "Lunch ride"
+	cropped := cached
+	cropped.Distance = 900
+
+	// WHEN
+	renamedFields, renamedData := changedSummaryFields(cached, renamed)
+	croppedFields, croppedData := changedSummaryFields(cached, cropped)
+
+	// THEN
+	if len(renamedFields) != 1 || renamedFields[0] != "name" || renamedData {
+		t.Fatalf("expected metadata-only name change, got %v data=%t", renamedFields, renamedData)
+	}
+	if len(croppedFields) != 1 || croppedFields[0] != "distance" || !croppedData {
+		t.Fatalf("expected recorded-data distance change, got %v data=%t", croppedFields, croppedData)
+	}
+}
+
+func TestReconcileCachedYears_RemovesActivitiesDeletedFromPastYears(t *testing.T) {
+	// GIVEN
+	pastYear := time.Now().Year() - 1
+	listRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/athlete/activities" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = fmt.Fprint(w, `[]`)
+			return
+		}
+		listRequests++
+		_, _ = fmt.Fprintf(w, `[
+			{"id":1,"name":"Kept ride","type":"Ride","sport_type":"Ride","start_date":"%[1]d-03-01T08:00:00Z","start_date_local":"%[1]d-03-01T09:00:00","distance":20000,"moving_time":3600,"elapsed_time":3700}
+		]`, pastYear)
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	repo := localrepository.NewStravaRepository(cacheDir)
+	clientID := "123"
+	repo.InitLocalStorageForClientId(clientID)
+	cached := []strava.Activity{
+		{Id: 1, Name: "Kept ride", Type: "Ride", SportType: "Ride", StartDate: fmt.Sprintf("%d-03-01T08:00:00Z", pastYear), StartDateLocal: fmt.Sprintf("%d-03-01T09:00:00", pastYear), Distance: 20000, MovingTime: 3600, ElapsedTime: 3700},
+		{Id: 2, Name: "Deleted ride", Type: "Ride", SportType: "Ride", StartDate: fmt.Sprintf("%d-03-02T08:00:00Z", pastYear), StartDateLocal: fmt.Sprintf("%d-03-02T09:00:00", pastYear), Distance: 10000},
+	}
+	repo.SaveActivitiesToCache(clientID, pastYear, cached)
+	repo.SaveActivitiesStreamsToCache(clientID, pastYear, cached[0], strava.Stream{})
+	provider := &StravaActivityProvider{
+		clientId:             clientID,
+		clientSecret:         "secret",
+		localStorageProvider: repo,
+		StravaApi: &StravaApi{
+			accessToken: "test-token",
+			properties:  StravaProperties{APIBaseURL: server.URL},
+			httpClient:  server.Client(),
+		},
+	}
+	provider.replaceActivities([]*strava.Activity{&cached[0], &cached[1]})
+
+	// WHEN
+	report, err := provider.ReconcileCachedYears()
+
+	// THEN
+	if err != nil {
+		t.Fatalf("expected the reconciliation to succeed, got %v", err)
+	}
+	if listRequests != 1 {
+		t.Fatalf("expected only the cached year to be listed, got %d list requests", listRequests)
+	}
+	if report.YearsChecked != 1 || report.Removed != 1 {
+		t.Fatalf("expected one checked year and one removed activity, got %#v", report)
+	}
+	if provider.GetActivity(2) != nil || provider.GetActivity(1) == nil || provider.GetActivity(1).Stream == nil {
+		t.Fatal("expected the deleted activity to leave memory and the kept one to keep its stream")
+	}
+	if remaining := repo.LoadActivitiesFromCache(clientID, pastYear); len(remaining) != 1 {
+		t.Fatalf("expected the year file to be rewritten, got %d activities", len(remaining))
+	}
+}
+
+func TestReconcileCachedYears_SkipsCacheOnlyMode(t *testing.T) {
+	// GIVEN
+	provider := &StravaActivityProvider{clientId: "123", useCacheAuth: true}
+
+	// WHEN
+	_, err := provider.ReconcileCachedYears()
+
+	// THEN
+	if !errors.Is(err, ErrStravaCacheOnly) {
+		t.Fatalf("expected cache-only error, got %v", err)
+	}
+}
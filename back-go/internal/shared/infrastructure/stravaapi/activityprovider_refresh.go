@@ -0,0 +1,100 @@
+package stravaapi
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+// RefreshResult reports a synchronous refresh of the current year from Strava.
+type RefreshResult struct {
+	Status            string   `json:"status"`
+	Message           string   `json:"message"`
+	ClientID          string   `json:"clientId"`
+	Year              int      `json:"year"`
+	FetchedActivities int      `json:"fetchedActivities"`
+	NewActivityIDs    []int64  `json:"newActivityIds"`
+	Errors            []string `json:"errors"`
+}
+
+// RefreshCurrentYear fetches the current year activity list and the missing
+// streams, then merges them like the startup background refresh does. Older
+// years are left to the background refresh and the webhook.
+func (provider *StravaActivityProvider) RefreshCurrentYear() RefreshResult {
+	year := time.Now().Year()
+	result := RefreshResult{
+		Status:         "skipped",
+		ClientID:       provider.clientId,
+		Year:           year,
+		NewActivityIDs: []int64{},
+		Errors:         []string{},
+	}
+	if provider.useCacheAuth || provider.clientSecret == "" {
+		result.Message = "Strava refresh skipped: cache-only mode."
+		return result
+	}
+	if !provider.backgroundRefresh.CompareAndSwap(false, true) {
+		result.Message = "Strava refresh skipped: a background refresh is already running."
+		return result
+	}
+	defer provider.backgroundRefresh.Store(false)
+
+	before := make(map[int64]struct{})
+	for _, activity := range provider.getActivitiesSnapshot() {
+		if activity != nil {
+			before[activity.Id] = struct{}{}
+		}
+	}
+
+	refreshed, err := provider.retrieveActivities(provider.clientId, year, true)
+	if err != nil {
+		result.Status = "failed"
+		if IsRateLimitError(err) {
+			result.Status = "rate_limited"
+		}
+		result.Message = fmt.Sprintf("Strava refresh of %d failed.", year)
+		result.Errors = append(result.Errors, err.Error())
+		return result
+	}
+	if len(refreshed) > 0 {
+		refreshed = provider.loadActivitiesStreams(provider.clientId, year, refreshed)
+	}
+	provider.mergeRefreshedYear(year, refreshed)
+
+	result.FetchedActivities = len(refreshed)
+	for _, activity := range refreshed {
+		if _, known := before[activity.Id]; !known {
+			result.NewActivityIDs = append(result.NewActivityIDs, activity.Id)
+		}
+	}
+	sort.Slice(result.NewActivityIDs, func(i, j int) bool { return result.NewActivityIDs[i] < result.NewActivityIDs[j] })
+
+	if len(result.NewActivityIDs) > 0 {
+		provider.dataRevision.Add(1)
+		result.Status = "refreshed"
+		result.Message = fmt.Sprintf("%d new Strava activity(ies) in %d.", len(result.NewActivityIDs), year)
+	} else {
+		result.Status = "up_to_date"
+		result.Message = fmt.Sprintf("Strava activities of %d are up to date.", year)
+	}
+	if provider.isStravaRateLimitedNow() {
+		result.Errors = append(result.Errors, "stream download stopped by the Strava rate limit")
+	}
+	log.Printf("%s", result.Message)
+	return result
+}
+
+// ReloadFromCache rereads the athlete, the settings and the activities from the
+// local cache, for instance after the cache was restored from a backup. Strava
+// is not called.
+func (provider *StravaActivityProvider) ReloadFromCache() {
+	provider.heartRateZoneSettings = provider.localStorageProvider.LoadHeartRateZoneSettings(provider.clientId)
+	provider.performanceSettings = provider.localStorageProvider.LoadPerformanceSettings(provider.clientId)
+	provider.loadPersistentCacheArtifacts()
+	provider.stravaAthlete = provider.localStorageProvider.LoadAthleteFromCache(provider.clientId)
+	provider.replaceActivities(provider.loadFromLocalCache(provider.clientId))
+	provider.dataRevision.Add(1)
+	log.Printf("Reloaded %d activities from the local cache", len(provider.getActivitiesSnapshot()))
+	provider.launchBackgroundWarmup("cache reload")
+}
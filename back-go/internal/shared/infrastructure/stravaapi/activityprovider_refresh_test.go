@@ -0,0 +1,76 @@
+package stravaapi
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"mystravastats/internal/shared/domain/strava"
+	"mystravastats/internal/shared/infrastructure/localrepository"
+)
+
+func TestRefreshCurrentYear_ReportsNewActivities(t *testing.T) {
+	// GIVEN
+	year := time.Now().Year()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/athlete/activities" && r.URL.Query().Get("page") == "1":
+			_, _ = fmt.Fprintf(w, `[
+				{"id":1,"name":"Known ride","type":"Ride","sport_type":"Ride","start_date":"%[1]d-01-02T08:00:00Z","start_date_local":"%[1]d-01-02T09:00:00","distance":20000,"moving_time":3600,"elapsed_time":3700},
+				{"id":2,"name":"New run","type":"Run","sport_type":"Run","start_date":"%[1]d-01-03T08:00:00Z","start_date_local":"%[1]d-01-03T09:00:00","distance":8000,"moving_time":2400,"elapsed_time":2500}
+			]`, year)
+		case r.URL.Path == "/athlete/activities":
+			_, _ = fmt.Fprint(w, `[]`)
+		case strings.HasSuffix(r.URL.Path, "/streams"):
+			_, _ = fmt.Fprint(w, `{"time":{"data":[0,60],"original_size":2},"distance":{"data":[0,300],"original_size":2}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	repo := localrepository.NewStravaRepository(t.TempDir())
+	repo.InitLocalStorageForClientId("123")
+	provider := &StravaActivityProvider{
+		clientId:             "123",
+		clientSecret:         "secret",
+		localStorageProvider: repo,
+		StravaApi: &StravaApi{
+			accessToken: "test-token",
+			properties:  StravaProperties{APIBaseURL: server.URL},
+			httpClient:  server.Client(),
+		},
+	}
+	provider.replaceActivities([]*strava.Activity{{Id: 1, StartDateLocal: fmt.Sprintf("%d-01-02T09:00:00", year)}})
+
+	// WHEN
+	result := provider.RefreshCurrentYear()
+
+	// THEN
+	if result.Status != "refreshed" || result.FetchedActivities != 2 {
+		t.Fatalf("expected refreshed status with 2 fetched activities, got %#v", result)
+	}
+	if len(result.NewActivityIDs) != 1 || result.NewActivityIDs[0] != 2 {
+		t.Fatalf("expected activity 2 to be reported as new, got %v", result.NewActivityIDs)
+	}
+	if provider.GetActivity(2) == nil || len(provider.getActivitiesSnapshot()) != 2 {
+		t.Fatalf("expected refreshed activities in memory, got %d", len(provider.getActivitiesSnapshot()))
+	}
+}
+
+func TestRefreshCurrentYear_SkipsCacheOnlyMode(t *testing.T) {
+	// GIVEN
+	provider := &StravaActivityProvider{clientId: "123", useCacheAuth: true}
+
+	// WHEN
+	result := provider.RefreshCurrentYear()
+
+	// THEN
+	if result.Status != "skipped" {
+		t.Fatalf("expected skipped refresh in cache-only mode, got %#v", result)
+	}
+}
@@ -0,0 +1,124 @@
+package stravaapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"mystravastats/internal/shared/domain/business"
+)
+
+const (
+	uploadPollInterval    = 2 * time.Second
+	uploadPollMaxAttempts = 30
+)
+
+// UploadResult describes the Strava activity a local file was uploaded as.
+// Duplicate is true when Strava (or a previous upload) already holds the file.
+type UploadResult struct {
+	UploadID         int64
+	StravaActivityID int64
+	Duplicate        bool
+}
+
+// UploadLocalFile pushes a local FIT/GPX/TCX file to Strava, waits for Strava
+// to process it and pulls the resulting activity into the year cache so the
+// composite provider can merge it with the local copy straight away.
+//
+// Files already uploaded from this cache are not sent again: the Strava id
+// recorded for their content hash is returned as a duplicate.
+func (provider *StravaActivityProvider) UploadLocalFile(filePath string) (UploadResult, error) {
+	contentHash, err := hashUploadFile(filePath)
+	if err != nil {
+		return UploadResult{}, err
+	}
+
+	provider.uploadMutex.Lock()
+	defer provider.uploadMutex.Unlock()
+
+	records := provider.localStorageProvider.LoadStravaUploads(provider.clientId)
+	for _, record := range records {
+		if record.ContentHash == contentHash && record.StravaActivityID != 0 {
+			return UploadResult{UploadID: record.UploadID, StravaActivityID: record.StravaActivityID, Duplicate: true}, nil
+		}
+	}
+
+	if provider.isStravaRateLimitedNow() {
+		return UploadResult{}, ErrStravaRateLimitReached
+	}
+	api := provider.StravaApi
+	if api == nil && !provider.useCacheAuth {
+		api = provider.ensureStravaAPI()
+	}
+	if api == nil {
+		return UploadResult{}, errors.New("strava api unavailable")
+	}
+	api = api.Interactive()
+
+	upload, err := api.UploadActivity(filePath, "mystravastats-"+contentHash[:16]+strings.ToLower(filepath.Ext(filePath)))
+	if err == nil {
+		upload, err = api.WaitForUpload(upload, provider.uploadPollInterval(), uploadPollMaxAttempts)
+	}
+	if err != nil {
+		provider.markStravaRateLimited(err, fmt.Sprintf("upload %s", filepath.Base(filePath)))
+		return UploadResult{}, err
+	}
+
+	result := UploadResult{UploadID: upload.ID, StravaActivityID: upload.ActivityID}
+	if upload.ActivityID == 0 {
+		duplicateID, ok := upload.DuplicateActivityID()
+		if !ok {
+			return result, fmt.Errorf("strava rejected %s: %s", filepath.Base(filePath), upload.Error)
+		}
+		result.StravaActivityID = duplicateID
+		result.Duplicate = true
+	}
+
+	records = append(records, business.StravaUploadRecord{
+		ContentHash:      contentHash,
+		FilePath:         filePath,
+		UploadID:         result.UploadID,
+		StravaActivityID: result.StravaActivityID,
+		UploadedAt:       time.Now().UTC().Format(time.RFC3339),
+	})
+	provider.localStorageProvider.SaveStravaUploads(provider.clientId, records)
+
+	if provider.findActivityById(result.StravaActivityID) == nil {
+		if err := provider.refreshWebhookActivity(result.StravaActivityID); err != nil {
+			// The upload itself succeeded; the next sync picks the activity up.
+			log.Printf("Uploaded %s as Strava activity %d but could not cache it yet: %v", filepath.Base(filePath), result.StravaActivityID, err)
+		}
+	}
+	log.Printf("Uploaded %s to Strava as activity %d (duplicate=%t)", filepath.Base(filePath), result.StravaActivityID, result.Duplicate)
+	return result, nil
+}
+
+func (provider *StravaActivityProvider) uploadPollInterval() time.Duration {
+	if provider.uploadPoll > 0 {
+		return provider.uploadPoll
+	}
+	return uploadPollInterval
+}
+
+func hashUploadFile(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("unable to read %s: %w", filePath, err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("unable to read %s: %w", filePath, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,152 @@
+package stravaapi
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUploadLocalFile_PollsUploadAndCachesResultingActivity(t *testing.T) {
+	// GIVEN
+	server := newUploadStandInServer(2, "")
+	defer server.Close()
+	provider, repo, _ := newWebhookTestProvider(t, server.URL)
+	provider.uploadPoll = time.Millisecond
+	filePath := writeUploadFixture(t, "ride.fit", "fit-content")
+
+	// WHEN
+	result, err := provider.UploadLocalFile(filePath)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("expected upload to succeed, got %v", err)
+	}
+	if result.UploadID != 9 || result.StravaActivityID != 42 || result.Duplicate {
+		t.Fatalf("expected upload 9 to become activity 42, got %#v", result)
+	}
+	if server.dataType != "fit" || server.fileName != "ride.fit" {
+		t.Fatalf("expected FIT multipart upload, got data_type=%q file=%q", server.dataType, server.fileName)
+	}
+	if polls := server.callCount("/uploads/9"); polls != 2 {
+		t.Fatalf("expected 2 status polls, got %d", polls)
+	}
+	if cached := repo.LoadActivitiesFromCache("123", 2026); len(cached) != 1 || cached[0].Id != 42 {
+		t.Fatalf("expected uploaded activity in 2026 cache, got %#v", cached)
+	}
+	if provider.GetActivity(42) == nil {
+		t.Fatal("expected uploaded activity in memory")
+	}
+	records := repo.LoadStravaUploads("123")
+	if len(records) != 1 || records[0].StravaActivityID != 42 || records[0].FilePath != filePath {
+		t.Fatalf("expected upload to be recorded, got %#v", records)
+	}
+
+	// WHEN
+	again, err := provider.UploadLocalFile(filePath)
+
+	// THEN
+	if err != nil || !again.Duplicate || again.StravaActivityID != 42 {
+		t.Fatalf("expected recorded upload to be reported as duplicate, got %#v, %v", again, err)
+	}
+	if posts := server.callCount("/uploads"); posts != 1 {
+		t.Fatalf("expected the file to be sent once, got %d uploads", posts)
+	}
+}
+
+func TestUploadLocalFile_ReportsStravaDuplicateError(t *testing.T) {
+	// GIVEN
+	server := newUploadStandInServer(0, "ride.gpx duplicate of activity 42")
+	defer server.Close()
+	provider, repo, _ := newWebhookTestProvider(t, server.URL)
+	provider.uploadPoll = time.Millisecond
+	filePath := writeUploadFixture(t, "ride.gpx", "<gpx/>")
+
+	// WHEN
+	result, err := provider.UploadLocalFile(filePath)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("expected duplicate to be handled, got %v", err)
+	}
+	if !result.Duplicate || result.StravaActivityID != 42 {
+		t.Fatalf("expected duplicate of activity 42, got %#v", result)
+	}
+	if server.dataType != "gpx" {
+		t.Fatalf("expected GPX data type, got %q", server.dataType)
+	}
+	if records := repo.LoadStravaUploads("123"); len(records) != 1 || records[0].StravaActivityID != 42 {
+		t.Fatalf("expected duplicate to be recorded, got %#v", records)
+	}
+}
+
+type uploadStandInServer struct {
+	*httptest.Server
+	mutex           sync.Mutex
+	calls           map[string]int
+	pollsBeforeDone int
+	uploadError     string
+	dataType        string
+	fileName        string
+}
+
+// newUploadStandInServer accepts one upload, reports it as processing for
+// pollsBeforeDone status calls, then resolves it to activity 42 (or to
+// uploadError when set).
+func newUploadStandInServer(pollsBeforeDone int, uploadError string) *uploadStandInServer {
+	server := &uploadStandInServer{calls: make(map[string]int), pollsBeforeDone: pollsBeforeDone, uploadError: uploadError}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.mutex.Lock()
+		defer server.mutex.Unlock()
+		server.calls[r.URL.Path]++
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/uploads":
+			if err := r.ParseMultipartForm(1 << 20); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			server.dataType = r.FormValue("data_type")
+			if _, header, err := r.FormFile("file"); err == nil {
+				server.fileName = header.Filename
+			}
+			w.WriteHeader(http.StatusCreated)
+			_, _ = fmt.Fprint(w, `{"id":9,"id_str":"9","status":"Your activity is still being processed."}`)
+		case r.URL.Path == "/uploads/9":
+			switch {
+			case server.calls[r.URL.Path] < server.pollsBeforeDone:
+				_, _ = fmt.Fprint(w, `{"id":9,"status":"Your activity is still being processed."}`)
+			case server.uploadError != "":
+				_, _ = fmt.Fprintf(w, `{"id":9,"status":"There was an error processing your activity.","error":%q}`, server.uploadError)
+			default:
+				_, _ = fmt.Fprint(w, `{"id":9,"status":"Your activity is ready.","activity_id":42}`)
+			}
+		case r.URL.Path == "/activities/42":
+			_, _ = fmt.Fprint(w, `{"id":42,"name":"Uploaded Ride","type":"Ride","sport_type":"Ride","start_date":"2026-05-02T06:00:00Z","start_date_local":"2026-05-02T08:00:00","distance":30000,"moving_time":3600,"upload_id":9}`)
+		case r.URL.Path == "/activities/42/streams":
+			_, _ = fmt.Fprint(w, `{"time":{"data":[0,60],"original_size":2},"distance":{"data":[0,300],"original_size":2}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	return server
+}
+
+func (server *uploadStandInServer) callCount(path string) int {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return server.calls[path]
+}
+
+func writeUploadFixture(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("unable to write upload fixture: %v", err)
+	}
+	return path
+}
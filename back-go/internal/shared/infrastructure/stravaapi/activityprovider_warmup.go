@@ -5,6 +5,7 @@ import (
 	"log"
 	"mystravastats/domain/statistics"
 	"mystravastats/internal/helpers"
+	"mystravastats/internal/platform/events"
 	"mystravastats/internal/shared/domain/business"
 	"mystravastats/internal/shared/domain/strava"
 	"sort"
@@ -57,7 +58,9 @@ func (provider *StravaActivityProvider) runWarmupPipeline(reason string) {
 	}
 
 	log.Printf("Warmup started (%s)", reason)
+	progress := events.Start(events.OperationWarmup, provider.clientId, 3, reason)
 
+	progress.Update("year_summaries", 0, 0, 0)
 	warmupPayload := warmupSummariesFile{
 		YearSummaries: provider.computeWarmupYearSummaries(activities),
 	}
@@ -65,23 +68,33 @@ func (provider *StravaActivityProvider) runWarmupPipeline(reason string) {
 	if err := provider.persistWarmupArtifacts(warmupPayload, "ready", "pending", "pending", preparedYears); err != nil {
 		log.Printf("Warmup priority 1 failed: %v", err)
 		_ = provider.persistWarmupArtifacts(warmupPayload, "failed", "pending", "pending", preparedYears)
+		progress.Error(err.Error())
+		progress.Fail("Warmup priority 1 failed.")
 		return
 	}
 
+	progress.Update("major_best_efforts", 0, 1, 0)
 	warmupPayload.MajorBestEfforts = provider.precomputeMajorBestEfforts(activities)
 	if err := provider.persistWarmupArtifacts(warmupPayload, "ready", "ready", "pending", preparedYears); err != nil {
 		log.Printf("Warmup priority 2 failed: %v", err)
 		_ = provider.persistWarmupArtifacts(warmupPayload, "ready", "failed", "pending", preparedYears)
+		progress.Error(err.Error())
+		progress.Fail("Warmup priority 2 failed.")
 		return
 	}
 
+	progress.Update("advanced_metrics", 0, 2, 0)
 	warmupPayload.AdvancedMetrics = provider.precomputeAdvancedMetrics(activities)
 	if err := provider.persistWarmupArtifacts(warmupPayload, "ready", "ready", "ready", preparedYears); err != nil {
 		log.Printf("Warmup priority 3 failed: %v", err)
 		_ = provider.persistWarmupArtifacts(warmupPayload, "ready", "ready", "failed", preparedYears)
+		progress.Error(err.Error())
+		progress.Fail("Warmup priority 3 failed.")
 		return
 	}
 
+	progress.Update("advanced_metrics", 0, 3, 0)
+	progress.Complete(fmt.Sprintf("Warmup prepared %d year(s).", len(preparedYears)))
 	log.Printf("Warmup completed (%s)", reason)
 }
 
@@ -0,0 +1,360 @@
+package stravaapi
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"mystravastats/domain/statistics"
+	"mystravastats/internal/shared/domain/strava"
+)
+
+const (
+	WebhookAspectCreate = "create"
+	WebhookAspectUpdate = "update"
+	WebhookAspectDelete = "delete"
+
+	webhookObjectActivity = "activity"
+)
+
+// WebhookEvent is the payload Strava posts to a push subscription callback.
+type WebhookEvent struct {
+	ObjectType     string         `json:"object_type"`
+	ObjectID       int64          `json:"object_id"`
+	AspectType     string         `json:"aspect_type"`
+	OwnerID        int64          `json:"owner_id"`
+	SubscriptionID int64          `json:"subscription_id"`
+	EventTime      int64          `json:"event_time"`
+	Updates        map[string]any `json:"updates,omitempty"`
+}
+
+type webhookQueue struct {
+	mutex           sync.Mutex
+	pending         []WebhookEvent
+	workerActive    bool
+	workers         sync.WaitGroup
+	received        int
+	processed       int
+	failed          int
+	ignored         int
+	lastEventAt     string
+	lastProcessedAt string
+	lastError       string
+}
+
+// EnqueueWebhookEvent queues an activity event for background processing and
+// returns false when the event is ignored (other object types, other athletes
+// or unknown aspects). Events for an activity already waiting in the queue
+// replace the pending one.
+func (provider *StravaActivityProvider) EnqueueWebhookEvent(event WebhookEvent) bool {
+	queue := &provider.webhook
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	queue.received++
+	queue.lastEventAt = time.Now().UTC().Format(time.RFC3339)
+	if reason := provider.webhookIgnoreReason(event); reason != "" {
+		queue.ignored++
+		log.Printf("Strava webhook event ignored (%s): %s %s %d", reason, event.ObjectType, event.AspectType, event.ObjectID)
+		return false
+	}
+
+	replaced := false
+	for index := range queue.pending {
+		if queue.pending[index].ObjectID == event.ObjectID {
+			queue.pending[index] = event
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		queue.pending = append(queue.pending, event)
+	}
+	provider.startWebhookWorkerLocked()
+	return true
+}
+
+func (provider *StravaActivityProvider) webhookIgnoreReason(event WebhookEvent) string {
+	if event.ObjectType != webhookObjectActivity {
+		return "not an activity"
+	}
+	if event.ObjectID <= 0 {
+		return "missing activity id"
+	}
+	switch event.AspectType {
+	case WebhookAspectCreate, WebhookAspectUpdate, WebhookAspectDelete:
+	default:
+		return "unknown aspect type"
+	}
+	if athleteID := provider.stravaAthlete.Id; athleteID == 0 || event.OwnerID != athleteID {
+		return fmt.Sprintf("owner %d is not athlete %d", event.OwnerID, athleteID)
+	}
+	return ""
+}
+
+// startWebhookWorkerLocked starts the queue worker; the queue mutex must be held.
+func (provider *StravaActivityProvider) startWebhookWorkerLocked() {
+	queue := &provider.webhook
+	if queue.workerActive || len(queue.pending) == 0 {
+		return
+	}
+	queue.workerActive = true
+	queue.workers.Add(1)
+	go provider.runWebhookWorker()
+}
+
+func (provider *StravaActivityProvider) runWebhookWorker() {
+	queue := &provider.webhook
+	defer queue.workers.Done()
+
+	for {
+		queue.mutex.Lock()
+		if len(queue.pending) == 0 {
+			queue.workerActive = false
+			queue.mutex.Unlock()
+			return
+		}
+		if provider.isStravaRateLimitedNow() {
+			provider.pauseWebhookWorkerLocked(time.Unix(provider.rateLimitUntilUnix.Load(), 0))
+			queue.mutex.Unlock()
+			return
+		}
+		event := queue.pending[0]
+		queue.pending = queue.pending[1:]
+		queue.mutex.Unlock()
+
+		err := provider.processWebhookEvent(event)
+
+		queue.mutex.Lock()
+		queue.lastProcessedAt = time.Now().UTC().Format(time.RFC3339)
+		if err != nil {
+			queue.lastError = err.Error()
+			log.Printf("Strava webhook %s for activity %d failed: %v", event.AspectType, event.ObjectID, err)
+		}
+		if err != nil && IsRateLimitError(err) {
+			// Keep the event so it is replayed once the rate limit window is over.
+			queue.pending = append([]WebhookEvent{event}, queue.pending...)
+			retryAt := time.Unix(provider.rateLimitUntilUnix.Load(), 0)
+			var budgetErr *BudgetExhaustedError
+			if errors.As(err, &budgetErr) {
+				retryAt = budgetErr.RetryAt
+			}
+			provider.pauseWebhookWorkerLocked(retryAt)
+			queue.mutex.Unlock()
+			return
+		}
+		if err != nil {
+			queue.failed++
+		} else {
+			queue.processed++
+		}
+		queue.mutex.Unlock()
+	}
+}
+
+// pauseWebhookWorkerLocked stops the worker and restarts it at retryAt; the
+// queue mutex must be held.
+func (provider *StravaActivityProvider) pauseWebhookWorkerLocked(retryAt time.Time) {
+	provider.webhook.workerActive = false
+	retryIn := time.Until(retryAt)
+	if retryIn < time.Second {
+		retryIn = time.Second
+	}
+	log.Printf("Strava webhook queue paused by rate limit; retrying in %s", retryIn.Round(time.Second))
+	time.AfterFunc(retryIn, provider.resumeWebhookWorker)
+}
+
+func (provider *StravaActivityProvider) resumeWebhookWorker() {
+	provider.webhook.mutex.Lock()
+	defer provider.webhook.mutex.Unlock()
+	provider.startWebhookWorkerLocked()
+}
+
+func (provider *StravaActivityProvider) processWebhookEvent(event WebhookEvent) error {
+	if event.AspectType == WebhookAspectDelete {
+		return provider.deleteWebhookActivity(event.ObjectID)
+	}
+	return provider.refreshWebhookActivity(event.ObjectID)
+}
+
+func (provider *StravaActivityProvider) webhookAPI() (*StravaApi, error) {
+	if provider.isStravaRateLimitedNow() {
+		return nil, ErrStravaRateLimitReached
+	}
+	api := provider.StravaApi
+	if api == nil && !provider.useCacheAuth {
+		api = provider.ensureStravaAPI()
+	}
+	if api == nil {
+		return nil, errors.New("strava api unavailable")
+	}
+	return api, nil
+}
+
+// deleteWebhookActivity removes an activity from the cache only once Strava
+// answers 404 for it: push events are not signed, so a delete event alone
+// is not trusted. An activity that still exists is refreshed instead.
+func (provider *StravaActivityProvider) deleteWebhookActivity(activityID int64) error {
+	api, err := provider.webhookAPI()
+	if err != nil {
+		return err
+	}
+	detailedActivity, err := api.GetDetailedActivity(activityID)
+	if errors.Is(err, ErrStravaActivityNotFound) {
+		provider.removeWebhookActivity(activityID)
+		return nil
+	}
+	if err != nil {
+		provider.markStravaRateLimited(err, fmt.Sprintf("webhook activity %d", activityID))
+		return err
+	}
+	log.Printf("Strava webhook delete of activity %d not confirmed: the activity still exists, refreshing it", activityID)
+	return provider.storeWebhookActivity(api, detailedActivity)
+}
+
+// refreshWebhookActivity fetches one activity with its detailed payload and
+// stream, then writes it into the year cache and the in-memory activity list.
+func (provider *StravaActivityProvider) refreshWebhookActivity(activityID int64) error {
+	api, err := provider.webhookAPI()
+	if err != nil {
+		return err
+	}
+	detailedActivity, err := api.GetDetailedActivity(activityID)
+	if err != nil {
+		provider.markStravaRateLimited(err, fmt.Sprintf("webhook activity %d", activityID))
+		return err
+	}
+	return provider.storeWebhookActivity(api, detailedActivity)
+}
+
+func (provider *StravaActivityProvider) storeWebhookActivity(api *StravaApi, detailedActivity *strava.DetailedActivity) error {
+	activityID := detailedActivity.Id
+	activity := detailedActivity.ToStravaActivity()
+	activity.Stream = nil
+	if len(filterByActivityTypes([]strava.Activity{*activity})) == 0 {
+		log.Printf("Strava webhook activity %d has unsupported type %q; removing it from cache", activityID, activity.Type)
+		provider.removeWebhookActivity(activityID)
+		return nil
+	}
+
+	year := resolveActivityYear(activity)
+	previous := provider.findActivityById(activityID)
+	var stream *strava.Stream
+	if previous != nil && resolveActivityYear(previous) == year {
+		stream = previous.Stream
+	}
+	if stream == nil {
+		stream = provider.localStorageProvider.LoadActivitiesStreamsFromCache(provider.clientId, year, *activity)
+	}
+	if stream == nil {
+		var err error
+		stream, err = api.GetActivityStream(*activity)
+		if err != nil {
+			provider.markStravaRateLimited(err, fmt.Sprintf("webhook stream %d", activityID))
+			return err
+		}
+	}
+
+	if previous != nil {
+		if previousYear := resolveActivityYear(previous); previousYear != year {
+			provider.localStorageProvider.RemoveActivityFromCache(provider.clientId, previousYear, activityID)
+		}
+	}
+	// The summary goes first: it creates the year directory for a new year.
+	provider.localStorageProvider.UpsertActivityInCache(provider.clientId, year, *activity)
+	detailedActivity.Stream = stream
+	provider.localStorageProvider.SaveDetailedActivityToCache(provider.clientId, year, *detailedActivity)
+	if stream != nil {
+		provider.localStorageProvider.SaveActivitiesStreamsToCache(provider.clientId, year, *activity, *stream)
+	}
+
+	activity.Stream = stream
+	provider.replaceActivity(activityID, activity)
+	log.Printf("Strava webhook refreshed activity %d (%s) in year %d cache", activityID, activity.Name, year)
+	return nil
+}
+
+// removeWebhookActivity drops a deleted activity from its year cache and from
+// memory. The year is taken from the loaded activity; the other cached years
+// are only scanned when the activity is not loaded or is cached under another
+// year, as happens around New Year when the local and UTC years differ.
+func (provider *StravaActivityProvider) removeWebhookActivity(activityID int64) {
+	removed := false
+	knownYear := 0
+	if previous := provider.findActivityById(activityID); previous != nil {
+		knownYear = resolveActivityYear(previous)
+		removed = provider.localStorageProvider.RemoveActivityFromCache(provider.clientId, knownYear, activityID)
+	}
+	if !removed {
+		removed = provider.removeActivityFromCachedYears(activityID, knownYear)
+	}
+	if provider.replaceActivity(activityID, nil) {
+		removed = true
+	}
+	if removed {
+		log.Printf("Strava webhook removed activity %d from cache", activityID)
+	}
+}
+
+func (provider *StravaActivityProvider) removeActivityFromCachedYears(activityID int64, skippedYear int) bool {
+	for year := time.Now().Year(); year >= 2010; year-- {
+		if year == skippedYear || !provider.localStorageProvider.IsLocalCacheExistForYear(provider.clientId, year) {
+			continue
+		}
+		if provider.localStorageProvider.RemoveActivityFromCache(provider.clientId, year, activityID) {
+			return true
+		}
+	}
+	return false
+}
+
+// replaceActivity swaps (or removes when activity is nil) one activity in memory
+// and drops its best-effort cache entries. It returns true when the in-memory
+// list changed.
+func (provider *StravaActivityProvider) replaceActivity(activityID int64, activity *strava.Activity) bool {
+	existing := provider.getActivitiesSnapshot()
+	updated := make([]*strava.Activity, 0, len(existing)+1)
+	changed := false
+	for _, current := range existing {
+		if current != nil && current.Id == activityID {
+			changed = true
+			continue
+		}
+		updated = append(updated, current)
+	}
+	if activity != nil {
+		updated = append(updated, activity)
+		changed = true
+	}
+	if !changed {
+		return false
+	}
+
+	provider.replaceActivities(updated)
+	provider.dataRevision.Add(1)
+	removedEntries := statistics.InvalidateBestEffortCacheByActivityIDs(map[int64]struct{}{activityID: {}})
+	if removedEntries > 0 {
+		log.Printf("Invalidated %d best-effort cache entries after webhook update of activity %d", removedEntries, activityID)
+	}
+	return true
+}
+
+func (provider *StravaActivityProvider) webhookDiagnostics() map[string]any {
+	queue := &provider.webhook
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	return map[string]any{
+		"queued":          len(queue.pending),
+		"workerActive":    queue.workerActive,
+		"received":        queue.received,
+		"processed":       queue.processed,
+		"failed":          queue.failed,
+		"ignored":         queue.ignored,
+		"lastEventAt":     queue.lastEventAt,
+		"lastProcessedAt": queue.lastProcessedAt,
+		"lastError":       queue.lastError,
+	}
+}
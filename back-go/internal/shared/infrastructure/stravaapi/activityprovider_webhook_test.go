@@ -0,0 +1,232 @@
+package stravaapi
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"mystravastats/internal/shared/domain/strava"
+	"mystravastats/internal/shared/infrastructure/localrepository"
+)
+
+func TestWebhookEvents_ReplayCreateUpdateDeleteAgainstYearCache(t *testing.T) {
+	// GIVEN
+	server := newWebhookStandInServer()
+	defer server.Close()
+	server.setActivity(`{"id":42,"name":"Morning Ride","type":"Ride","sport_type":"Ride","start_date":"2026-05-02T06:00:00Z","start_date_local":"2026-05-02T08:00:00","distance":30000,"moving_time":3600,"upload_id":7}`)
+	provider, repo, cacheDir := newWebhookTestProvider(t, server.URL)
+	events := []WebhookEvent{
+		{ObjectType: "activity", AspectType: WebhookAspectCreate, ObjectID: 42, OwnerID: 123},
+		{ObjectType: "activity", AspectType: WebhookAspectUpdate, ObjectID: 42, OwnerID: 123, Updates: map[string]any{"title": "Evening Ride"}},
+		{ObjectType: "activity", AspectType: WebhookAspectDelete, ObjectID: 42, OwnerID: 123},
+	}
+
+	// WHEN / THEN
+	if !provider.EnqueueWebhookEvent(events[0]) {
+		t.Fatal("expected create event to be queued")
+	}
+	provider.webhook.workers.Wait()
+	cached := repo.LoadActivitiesFromCache("123", 2026)
+	if len(cached) != 1 || cached[0].Name != "Morning Ride" {
+		t.Fatalf("expected created activity in 2026 cache, got %#v", cached)
+	}
+	if provider.GetActivity(42) == nil || provider.GetActivity(42).Stream == nil {
+		t.Fatal("expected created activity with stream in memory")
+	}
+	yearDirectory := filepath.Join(cacheDir, "strava-123", "strava-123-2026")
+	for _, name := range []string{"stream-42", "stravaActivity-42"} {
+		if _, err := os.Stat(filepath.Join(yearDirectory, name)); err != nil {
+			t.Fatalf("expected %s in year cache: %v", name, err)
+		}
+	}
+
+	server.setActivity(`{"id":42,"name":"Evening Ride","type":"Ride","sport_type":"Ride","start_date":"2026-05-02T06:00:00Z","start_date_local":"2026-05-02T08:00:00","distance":30000,"moving_time":3600,"upload_id":7}`)
+	provider.EnqueueWebhookEvent(events[1])
+	provider.webhook.workers.Wait()
+	cached = repo.LoadActivitiesFromCache("123", 2026)
+	if len(cached) != 1 || cached[0].Name != "Evening Ride" {
+		t.Fatalf("expected updated activity summary, got %#v", cached)
+	}
+	if calls := server.callCount("/activities/42/streams"); calls != 1 {
+		t.Fatalf("expected update to reuse the cached stream, got %d stream calls", calls)
+	}
+
+	server.setActivity("")
+	provider.EnqueueWebhookEvent(events[2])
+	provider.webhook.workers.Wait()
+	if cached := repo.LoadActivitiesFromCache("123", 2026); len(cached) != 0 {
+		t.Fatalf("expected deleted activity to leave the year cache, got %#v", cached)
+	}
+	if provider.GetActivity(42) != nil {
+		t.Fatal("expected deleted activity to leave memory")
+	}
+	for _, name := range []string{"stream-42", "stravaActivity-42"} {
+		if _, err := os.Stat(filepath.Join(yearDirectory, name)); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be removed, got %v", name, err)
+		}
+	}
+	diagnostics := provider.webhookDiagnostics()
+	if diagnostics["processed"] != 3 || diagnostics["failed"] != 0 {
+		t.Fatalf("expected 3 processed events, got %#v", diagnostics)
+	}
+	if calls := server.callCount("/athlete/activities"); calls != 0 {
+		t.Fatalf("expected no activity list polling, got %d calls", calls)
+	}
+}
+
+func TestEnqueueWebhookEvent_IgnoresOtherObjectsAndAthletes(t *testing.T) {
+	// GIVEN
+	provider := &StravaActivityProvider{stravaAthlete: strava.Athlete{Id: 123}}
+
+	// WHEN
+	athleteEvent := provider.EnqueueWebhookEvent(WebhookEvent{ObjectType: "athlete", AspectType: WebhookAspectUpdate, ObjectID: 123, OwnerID: 123})
+	otherOwner := provider.EnqueueWebhookEvent(WebhookEvent{ObjectType: "activity", AspectType: WebhookAspectCreate, ObjectID: 42, OwnerID: 999})
+	missingOwner := provider.EnqueueWebhookEvent(WebhookEvent{ObjectType: "activity", AspectType: WebhookAspectDelete, ObjectID: 42})
+	unknownAthlete := (&StravaActivityProvider{}).EnqueueWebhookEvent(WebhookEvent{ObjectType: "activity", AspectType: WebhookAspectDelete, ObjectID: 42})
+
+	// THEN
+	if athleteEvent || otherOwner || missingOwner || unknownAthlete {
+		t.Fatalf("expected events to be ignored, got athlete=%t otherOwner=%t missingOwner=%t unknownAthlete=%t", athleteEvent, otherOwner, missingOwner, unknownAthlete)
+	}
+	if diagnostics := provider.webhookDiagnostics(); diagnostics["ignored"] != 3 || diagnostics["queued"] != 0 {
+		t.Fatalf("expected 3 ignored events, got %#v", diagnostics)
+	}
+}
+
+func TestWebhookEvents_KeepsEventQueuedWhenRateLimited(t *testing.T) {
+	// GIVEN
+	server := newWebhookStandInServer()
+	defer server.Close()
+	server.rateLimited = true
+	provider, _, _ := newWebhookTestProvider(t, server.URL)
+
+	// WHEN
+	provider.EnqueueWebhookEvent(WebhookEvent{ObjectType: "activity", AspectType: WebhookAspectCreate, ObjectID: 42, OwnerID: 123})
+	provider.webhook.workers.Wait()
+
+	// THEN
+	if !provider.isStravaRateLimitedNow() {
+		t.Fatal("expected provider to enter rate-limit cooldown")
+	}
+	if diagnostics := provider.webhookDiagnostics(); diagnostics["queued"] != 1 || diagnostics["failed"] != 0 {
+		t.Fatalf("expected event to stay queued for replay, got %#v", diagnostics)
+	}
+}
+
+func TestWebhookEvents_KeepsActivityWhenStravaDoesNotConfirmDelete(t *testing.T) {
+	// GIVEN
+	server := newWebhookStandInServer()
+	defer server.Close()
+	server.setActivity(`{"id":42,"name":"Morning Ride","type":"Ride","sport_type":"Ride","start_date":"2026-05-02T06:00:00Z","start_date_local":"2026-05-02T08:00:00","distance":30000,"moving_time":3600,"upload_id":7}`)
+	provider, repo, _ := newWebhookTestProvider(t, server.URL)
+	provider.EnqueueWebhookEvent(WebhookEvent{ObjectType: "activity", AspectType: WebhookAspectCreate, ObjectID: 42, OwnerID: 123})
+	provider.webhook.workers.Wait()
+
+	// WHEN
+	provider.EnqueueWebhookEvent(WebhookEvent{ObjectType: "activity", AspectType: WebhookAspectDelete, ObjectID: 42, OwnerID: 123})
+	provider.webhook.workers.Wait()
+
+	// THEN
+	if cached := repo.LoadActivitiesFromCache("123", 2026); len(cached) != 1 {
+		t.Fatalf("expected the activity to stay in the year cache, got %#v", cached)
+	}
+	if provider.GetActivity(42) == nil {
+		t.Fatal("expected the activity to stay in memory")
+	}
+}
+
+type webhookStandInServer struct {
+	*httptest.Server
+	mutex       sync.Mutex
+	activity    string
+	calls       map[string]int
+	rateLimited bool
+}
+
+// newWebhookStandInServer serves the Strava endpoints a webhook event needs:
+// the detailed activity and its streams.
+func newWebhookStandInServer() *webhookStandInServer {
+	server := &webhookStandInServer{calls: make(map[string]int)}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.mutex.Lock()
+		defer server.mutex.Unlock()
+		server.calls[r.URL.Path]++
+		if server.rateLimited {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/activities/42":
+			if server.activity == "" {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = fmt.Fprint(w, server.activity)
+		case "/activities/42/streams":
+			_, _ = fmt.Fprint(w, `{"time":{"data":[0,60],"original_size":2},"distance":{"data":[0,300],"original_size":2}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	return server
+}
+
+func (server *webhookStandInServer) setActivity(payload string) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.activity = payload
+}
+
+func TestRemoveWebhookActivity_FallsBackToTheCachedYearsWhenTheLoadedYearMisses(t *testing.T) {
+	// GIVEN
+	provider, repo, _ := newWebhookTestProvider(t, "http://127.0.0.1:0")
+	newYearsEve := strava.Activity{Id: 99, Name: "New Year's Eve Run", StartDate: "2015-12-31T23:30:00Z", StartDateLocal: "2016-01-01T00:30:00"}
+	other := strava.Activity{Id: 7, Name: "Spring Ride", StartDate: "2016-04-02T08:00:00Z", StartDateLocal: "2016-04-02T10:00:00"}
+	repo.SaveActivitiesToCache("123", 2015, []strava.Activity{newYearsEve})
+	repo.SaveActivitiesToCache("123", 2016, []strava.Activity{other})
+	provider.replaceActivities([]*strava.Activity{&newYearsEve, &other})
+
+	// WHEN
+	provider.removeWebhookActivity(99)
+
+	// THEN
+	if cached := repo.LoadActivitiesFromCache("123", 2015); len(cached) != 0 {
+		t.Fatalf("expected the activity to leave the 2015 cache, got %#v", cached)
+	}
+	if cached := repo.LoadActivitiesFromCache("123", 2016); len(cached) != 1 || cached[0].Id != 7 {
+		t.Fatalf("expected the 2016 cache to keep its activity, got %#v", cached)
+	}
+	if provider.GetActivity(99) != nil || provider.GetActivity(7) == nil {
+		t.Fatal("expected only the deleted activity to leave memory")
+	}
+}
+
+func (server *webhookStandInServer) callCount(path string) int {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return server.calls[path]
+}
+
+func newWebhookTestProvider(t *testing.T, serverURL string) (*StravaActivityProvider, *localrepository.StravaRepository, string) {
+	t.Helper()
+	cacheDir := t.TempDir()
+	repo := localrepository.NewStravaRepository(cacheDir)
+	repo.InitLocalStorageForClientId("123")
+	provider := &StravaActivityProvider{
+		clientId:             "123",
+		localStorageProvider: repo,
+		stravaAthlete:        strava.Athlete{Id: 123},
+		StravaApi: &StravaApi{
+			accessToken: "test-token",
+			properties:  StravaProperties{APIBaseURL: serverURL},
+			httpClient:  http.DefaultClient,
+		},
+	}
+	provider.replaceActivities(nil)
+	return provider, repo, cacheDir
+}
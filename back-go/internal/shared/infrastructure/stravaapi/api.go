@@ -12,10 +12,10 @@ import (
 	"mystravastats/internal/helpers"
 	"mystravastats/internal/platform/runtimeconfig"
 	"mystravastats/internal/shared/domain/strava"
+	"mystravastats/internal/shared/infrastructure/secretstore"
 	"net/http"
 	"net/url"
 	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
@@ -35,6 +35,8 @@ type StravaApi struct {
 	tokenStore   string
 	properties   StravaProperties
 	httpClient   *http.Client
+	scheduler    *rateBudgetScheduler
+	priority     requestPriority
 }
 
 type Token struct {
@@ -50,6 +52,17 @@ type Token struct {
 
 var ErrStravaRateLimitReached = errors.New("strava rate limit reached (429)")
 
+// ErrStravaActivityNotFound is returned when Strava answers 404 for an activity.
+var ErrStravaActivityNotFound = errors.New("not found (404)")
+
+// ErrStravaCacheOnly is returned by operations that need Strava when the
+// provider runs from the local cache only.
+var ErrStravaCacheOnly = errors.New("strava api disabled: cache-only mode")
+
+// ErrStravaRefreshInProgress is returned when another refresh of the provider
+// is already running.
+var ErrStravaRefreshInProgress = errors.New("a strava refresh is already running")
+
 const tokenRefreshBuffer = time.Hour
 
 func IsRateLimitError(err error) bool {
@@ -83,6 +96,7 @@ func newStravaApi(clientId, clientSecret, tokenStore string) *StravaApi {
 		tokenStore:   strings.TrimSpace(tokenStore),
 		properties:   properties,
 		httpClient:   &http.Client{},
+		scheduler:    rateBudgetFor(clientId),
 	}
 
 	err := api.setAccessToken(clientId, clientSecret)
@@ -201,7 +215,7 @@ func (api *StravaApi) authorizationURL(clientId, redirectURI, state string) stri
 	values.Set("response_type", "code")
 	values.Set("redirect_uri", redirectURI)
 	values.Set("approval_prompt", "auto")
-	values.Set("scope", "read_all,activity:read_all,profile:read_all")
+	values.Set("scope", OAuthScope())
 	values.Set("state", state)
 	return fmt.Sprintf("%s/oauth/authorize?%s", api.properties.URL, values.Encode())
 }
@@ -314,7 +328,7 @@ func (api *StravaApi) usePersistedTokenIfAvailable(clientId, clientSecret string
 }
 
 func (api *StravaApi) loadPersistedToken() (Token, error) {
-	data, err := os.ReadFile(api.tokenStore)
+	data, err := secretstore.ReadFile(api.tokenStore)
 	if err != nil {
 		return Token{}, err
 	}
@@ -345,18 +359,12 @@ func (api *StravaApi) saveToken(token Token) error {
 	if api.tokenStore == "" {
 		return nil
 	}
-	if err := os.MkdirAll(filepath.Dir(api.tokenStore), 0o700); err != nil {
-		return err
-	}
 	data, err := json.MarshalIndent(token, "", "  ")
 	if err != nil {
 		return err
 	}
 	data = append(data, '\n')
-	if err := os.WriteFile(api.tokenStore, data, 0o600); err != nil {
-		return err
-	}
-	return os.Chmod(api.tokenStore, 0o600)
+	return secretstore.WriteFile(api.tokenStore, data)
 }
 
 func newOAuthState() (string, error) {
@@ -375,10 +383,14 @@ func (api *StravaApi) RetrieveLoggedInAthlete() (*strava.Athlete, error) {
 func (api *StravaApi) retrieveAthlete(url string) (*strava.Athlete, error) {
 	req, _ := http.NewRequest("GET", url, nil)
 	req.Header.Set("Authorization", "Bearer "+api.accessToken)
+	if err := api.acquireBudget(); err != nil {
+		return nil, err
+	}
 	resp, err := api.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("unable to connect to Strava API: %v", err)
 	}
+	api.observeBudget(resp)
 	defer func(Body io.ReadCloser) {
 		err := Body.Close()
 		if err != nil {
@@ -450,10 +462,14 @@ func (api *StravaApi) getActivities(year int, failFastOnRateLimit bool) ([]strav
 		client := *api.httpClient
 		client.Timeout = 15 * time.Second
 
+		if err := api.acquireBudget(); err != nil {
+			return nil, err
+		}
 		resp, err := client.Do(req)
 		if err != nil {
 			return nil, fmt.Errorf("http call error: %w", err)
 		}
+		api.observeBudget(resp)
 
 		// Handle HTTP status codes first
 		if resp.StatusCode == http.StatusUnauthorized {
@@ -543,7 +559,7 @@ func (api *StravaApi) GetDetailedActivity(activityId int64) (*strava.DetailedAct
 		return nil, fmt.Errorf("invalid token (401 Unauthorized)")
 	}
 	if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("activity %d not found (404)", activityId)
+		return nil, fmt.Errorf("activity %d: %w", activityId, ErrStravaActivityNotFound)
 	}
 	if resp.StatusCode == http.StatusTooManyRequests {
 		return nil, fmt.Errorf("%w while loading detailed activity %d", ErrStravaRateLimitReached, activityId)
@@ -620,10 +636,14 @@ func (api *StravaApi) doGetWithRateLimitRetry(url string, timeout time.Duration,
 		client := *api.httpClient
 		client.Timeout = timeout
 
+		if err := api.acquireBudget(); err != nil {
+			return nil, err
+		}
 		resp, err := client.Do(req)
 		if err != nil {
 			return nil, fmt.Errorf("unable to connect to Strava API: %w", err)
 		}
+		api.observeBudget(resp)
 
 		if resp.StatusCode != http.StatusTooManyRequests {
 			return resp, nil
@@ -653,6 +673,28 @@ func (api *StravaApi) doGetWithRateLimitRetry(url string, timeout time.Duration,
 	return nil, fmt.Errorf("strava request failed after %d attempts", maxAttempts)
 }
 
+// Interactive returns a view of the API whose requests may use the budget kept
+// in reserve for user-facing calls and are refused rather than delayed.
+func (api *StravaApi) Interactive() *StravaApi {
+	interactive := *api
+	interactive.priority = priorityInteractive
+	return &interactive
+}
+
+func (api *StravaApi) acquireBudget() error {
+	if api.scheduler == nil {
+		return nil
+	}
+	return api.scheduler.acquire(api.priority)
+}
+
+func (api *StravaApi) observeBudget(resp *http.Response) {
+	if api.scheduler == nil || resp == nil {
+		return
+	}
+	api.scheduler.observe(resp.Header, resp.StatusCode)
+}
+
 func (api *StravaApi) apiURL(path string) string {
 	return api.apiBaseURL() + "/" + strings.TrimLeft(path, "/")
 }
@@ -271,6 +271,22 @@ func TestAuthorizationURLIncludesStateAndScopes(t *testing.T) {
 	}
 }
 
+func TestAuthorizationURLRequestsWriteScopeWhenUploadsAreEnabled(t *testing.T) {
+	t.Setenv(UploadEnabledEnv, "true")
+	api := &StravaApi{
+		properties: StravaProperties{
+			URL: "https://www.strava.com",
+		},
+	}
+
+	authURL := api.authorizationURL("12345", "http://localhost:8090/exchange_token", "state-token")
+
+	expected := "scope=read_all%2Cactivity%3Aread_all%2Cprofile%3Aread_all%2Cactivity%3Awrite"
+	if !strings.Contains(authURL, expected) {
+		t.Fatalf("expected authorize URL to contain %q, got %s", expected, authURL)
+	}
+}
+
 func TestUsePersistedTokenWhenStillValid(t *testing.T) {
 	tokenPath := filepath.Join(t.TempDir(), ".strava-token.json")
 	writeTokenFixture(t, tokenPath, fmt.Sprintf(`{
@@ -368,3 +384,29 @@ func writeTokenFixture(t *testing.T, path string, payload string) {
 		t.Fatalf("unable to write token fixture: %v", err)
 	}
 }
+
+func TestSaveToken_EncryptsTokenStoreWithSecretKey(t *testing.T) {
+	// GIVEN
+	t.Setenv("STRAVA_SECRET_KEY", "token-store-key")
+	tokenPath := filepath.Join(t.TempDir(), ".strava-token.json")
+	api := &StravaApi{tokenStore: tokenPath}
+
+	// WHEN
+	err := api.applyToken(Token{AccessToken: "access-value", RefreshToken: "refresh-value", ExpiresAt: time.Now().Add(time.Hour).Unix()})
+
+	// THEN
+	if err != nil {
+		t.Fatalf("expected token to be stored, got %v", err)
+	}
+	raw, err := os.ReadFile(tokenPath)
+	if err != nil {
+		t.Fatalf("expected token file: %v", err)
+	}
+	if strings.Contains(string(raw), "refresh-value") || strings.Contains(string(raw), "access-value") {
+		t.Fatalf("expected encrypted token file, got %s", raw)
+	}
+	token, err := api.loadPersistedToken()
+	if err != nil || token.RefreshToken != "refresh-value" {
+		t.Fatalf("expected persisted refresh token to be readable, got %#v (%v)", token, err)
+	}
+}
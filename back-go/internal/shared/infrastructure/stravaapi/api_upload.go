@@ -0,0 +1,190 @@
+package stravaapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Upload mirrors the Strava upload resource returned by POST /uploads and
+// GET /uploads/{id}.
+type Upload struct {
+	ID         int64  `json:"id"`
+	IDStr      string `json:"id_str,omitempty"`
+	ExternalID string `json:"external_id,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Status     string `json:"status,omitempty"`
+	ActivityID int64  `json:"activity_id,omitempty"`
+}
+
+// Done reports whether Strava finished processing the upload, either by
+// creating an activity or by rejecting the file.
+func (upload *Upload) Done() bool {
+	return upload.ActivityID != 0 || strings.TrimSpace(upload.Error) != ""
+}
+
+var uploadDuplicatePattern = regexp.MustCompile(`(?i)duplicate of .*?(\d+)`)
+
+// DuplicateActivityID extracts the existing activity id from a Strava
+// "duplicate of activity N" upload error.
+func (upload *Upload) DuplicateActivityID() (int64, bool) {
+	match := uploadDuplicatePattern.FindStringSubmatch(upload.Error)
+	if len(match) != 2 {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
+
+// UploadDataType returns the Strava data_type for a local activity file.
+func UploadDataType(filePath string) (string, bool) {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".fit":
+		return "fit", true
+	case ".gpx":
+		return "gpx", true
+	case ".tcx":
+		return "tcx", true
+	default:
+		return "", false
+	}
+}
+
+// UploadActivity sends a local activity file to Strava. The returned upload is
+// usually still being processed; use WaitForUpload to obtain the activity id.
+func (api *StravaApi) UploadActivity(filePath string, externalID string) (*Upload, error) {
+	dataType, ok := UploadDataType(filePath)
+	if !ok {
+		return nil, fmt.Errorf("unsupported upload file type: %s", filepath.Base(filePath))
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", filePath, err)
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if err := writer.WriteField("data_type", dataType); err != nil {
+		return nil, fmt.Errorf("unable to build upload request: %w", err)
+	}
+	if externalID != "" {
+		if err := writer.WriteField("external_id", externalID); err != nil {
+			return nil, fmt.Errorf("unable to build upload request: %w", err)
+		}
+	}
+	part, err := writer.CreateFormFile("file", filepath.Base(filePath))
+	if err != nil {
+		return nil, fmt.Errorf("unable to build upload request: %w", err)
+	}
+	if _, err := part.Write(content); err != nil {
+		return nil, fmt.Errorf("unable to build upload request: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("unable to build upload request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, api.apiURL("uploads"), &body)
+	if err != nil {
+		return nil, fmt.Errorf("request build error: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+api.accessToken)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	client := *api.httpClient
+	client.Timeout = 60 * time.Second
+
+	if err := api.acquireBudget(); err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to Strava API: %w", err)
+	}
+	api.observeBudget(resp)
+	defer func(Body io.ReadCloser) {
+		if closeErr := Body.Close(); closeErr != nil {
+			log.Printf("warning: failed to close response body: %v", closeErr)
+		}
+	}(resp.Body)
+
+	return decodeUploadResponse(resp, filepath.Base(filePath))
+}
+
+// GetUpload returns the processing status of a previous upload.
+func (api *StravaApi) GetUpload(uploadID int64) (*Upload, error) {
+	resp, err := api.doGetWithRateLimitRetry(api.apiURL(fmt.Sprintf("uploads/%d", uploadID)), 10*time.Second, 1, true)
+	if err != nil {
+		return nil, err
+	}
+	defer func(Body io.ReadCloser) {
+		if closeErr := Body.Close(); closeErr != nil {
+			log.Printf("warning: failed to close response body: %v", closeErr)
+		}
+	}(resp.Body)
+
+	return decodeUploadResponse(resp, fmt.Sprintf("upload %d", uploadID))
+}
+
+// WaitForUpload polls an upload until Strava reports an activity id or an
+// error, giving up after maxAttempts polls.
+func (api *StravaApi) WaitForUpload(upload *Upload, interval time.Duration, maxAttempts int) (*Upload, error) {
+	if upload == nil {
+		return nil, fmt.Errorf("missing upload")
+	}
+	current := upload
+	for attempt := 0; attempt < maxAttempts && !current.Done(); attempt++ {
+		time.Sleep(interval)
+		next, err := api.GetUpload(upload.ID)
+		if err != nil {
+			return current, err
+		}
+		current = next
+	}
+	if !current.Done() {
+		return current, fmt.Errorf("upload %d is still processing: %s", current.ID, current.Status)
+	}
+	return current, nil
+}
+
+func decodeUploadResponse(resp *http.Response, label string) (*Upload, error) {
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("invalid token (401 Unauthorized)")
+	}
+	if resp.StatusCode == http.StatusForbidden {
+		return nil, fmt.Errorf("missing activity:write scope (403 Forbidden)")
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, fmt.Errorf("%w while uploading %s", ErrStravaRateLimitReached, label)
+	}
+
+	limited := io.LimitReader(resp.Body, 64*1024)
+	body, _ := io.ReadAll(limited)
+	var upload Upload
+	decodeErr := json.Unmarshal(body, &upload)
+	// Strava answers rejected files (including duplicates) with a 4xx carrying
+	// a regular upload payload, so keep the payload whenever it decodes.
+	if decodeErr == nil && (upload.ID != 0 || upload.Error != "") {
+		return &upload, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("strava upload call failed for %s: %d - %s", label, resp.StatusCode, string(body))
+	}
+	if decodeErr != nil {
+		return nil, fmt.Errorf("failed to decode upload response: %v", decodeErr)
+	}
+	return &upload, nil
+}
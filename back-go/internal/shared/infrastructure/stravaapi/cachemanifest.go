@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"sort"
 	"time"
+
+	"mystravastats/internal/platform/cachewrite"
 )
 
 const (
@@ -201,12 +203,8 @@ func writeJSONAtomically(path string, payload []byte) error {
 		return fmt.Errorf("create directory for %s: %w", path, err)
 	}
 
-	tmp := path + ".tmp"
-	if err := os.WriteFile(tmp, payload, 0o644); err != nil {
-		return fmt.Errorf("write temp file %s: %w", tmp, err)
-	}
-	if err := os.Rename(tmp, path); err != nil {
-		return fmt.Errorf("rename temp file %s to %s: %w", tmp, path, err)
+	if err := cachewrite.WriteFile(path, payload, 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
 	}
 
 	return nil
@@ -0,0 +1,306 @@
+package stravaapi
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mystravastats/internal/platform/runtimeconfig"
+)
+
+const (
+	rateLimitShortBudgetEnv        = "STRAVA_RATE_LIMIT_SHORT_BUDGET"
+	rateLimitDailyBudgetEnv        = "STRAVA_RATE_LIMIT_DAILY_BUDGET"
+	rateLimitInteractiveReserveEnv = "STRAVA_RATE_LIMIT_INTERACTIVE_RESERVE"
+
+	// Strava's default read limits: 100 requests per 15 minutes, 1000 per day.
+	defaultShortBudget        = 100
+	defaultDailyBudget        = 1000
+	defaultInteractiveReserve = 10
+
+	shortRateWindow = 15 * time.Minute
+)
+
+type requestPriority int
+
+const (
+	priorityBackground requestPriority = iota
+	priorityInteractive
+)
+
+func (priority requestPriority) String() string {
+	if priority == priorityInteractive {
+		return "interactive"
+	}
+	return "background"
+}
+
+// BudgetExhaustedError is returned before a request is sent when the local
+// budget has no room left for it. It wraps ErrStravaRateLimitReached so callers
+// stop their loops, without tripping the 429 cooldown.
+type BudgetExhaustedError struct {
+	Window   string
+	Priority string
+	RetryAt  time.Time
+}
+
+func (err *BudgetExhaustedError) Error() string {
+	return fmt.Sprintf("strava %s budget exhausted for %s requests until %s", err.Window, err.Priority, err.RetryAt.UTC().Format(time.RFC3339))
+}
+
+func (err *BudgetExhaustedError) Unwrap() error {
+	return ErrStravaRateLimitReached
+}
+
+// RateBudgetWindow describes one Strava rate-limit window.
+type RateBudgetWindow struct {
+	Limit     int    `json:"limit"`
+	Budget    int    `json:"budget"`
+	Used      int    `json:"used"`
+	Remaining int    `json:"remaining"`
+	ResetsAt  string `json:"resetsAt"`
+}
+
+// RateBudgetSnapshot is the scheduler state reported in health details.
+type RateBudgetSnapshot struct {
+	Short              RateBudgetWindow `json:"short"`
+	Daily              RateBudgetWindow `json:"daily"`
+	InteractiveReserve int              `json:"interactiveReserve"`
+	QueuedBackground   int              `json:"queuedBackground"`
+	Sent               map[string]int   `json:"sent"`
+	Refused            map[string]int   `json:"refused"`
+	HeadersObservedAt  string           `json:"headersObservedAt,omitempty"`
+}
+
+// rateBudgetScheduler paces Strava calls inside the 15-minute and daily windows.
+//
+// Each window is a bucket refilled when Strava resets it (quarter hours and
+// midnight UTC). Usage is counted locally and replaced by X-RateLimit-Usage
+// whenever Strava reports it. Background requests stop short of the budget by
+// the interactive reserve and wait for the next quarter hour; interactive
+// requests may use the reserve but never wait.
+type rateBudgetScheduler struct {
+	mutex              sync.Mutex
+	shortBudget        int
+	dailyBudget        int
+	interactiveReserve int
+	shortLimit         int
+	dailyLimit         int
+	shortUsed          int
+	dailyUsed          int
+	shortWindowStart   time.Time
+	dayStart           time.Time
+	waiting            int
+	sent               map[requestPriority]int
+	refused            map[requestPriority]int
+	headersObservedAt  time.Time
+	now                func() time.Time
+	sleep              func(time.Duration)
+}
+
+// Strava counts requests per application, so every API client of one clientId
+// shares a scheduler while athletes with their own application get their own.
+var (
+	rateBudgetsMutex sync.Mutex
+	rateBudgets      = map[string]*rateBudgetScheduler{}
+)
+
+func rateBudgetFor(clientId string) *rateBudgetScheduler {
+	rateBudgetsMutex.Lock()
+	defer rateBudgetsMutex.Unlock()
+	scheduler, ok := rateBudgets[clientId]
+	if !ok {
+		scheduler = newRateBudgetScheduler(
+			runtimeconfig.IntValue(rateLimitShortBudgetEnv, defaultShortBudget),
+			runtimeconfig.IntValue(rateLimitDailyBudgetEnv, defaultDailyBudget),
+			runtimeconfig.IntValue(rateLimitInteractiveReserveEnv, defaultInteractiveReserve),
+		)
+		rateBudgets[clientId] = scheduler
+	}
+	return scheduler
+}
+
+// RateBudgetDiagnostics reports the Strava request budget of clientId.
+func RateBudgetDiagnostics(clientId string) RateBudgetSnapshot {
+	return rateBudgetFor(clientId).snapshot()
+}
+
+func newRateBudgetScheduler(shortBudget int, dailyBudget int, interactiveReserve int) *rateBudgetScheduler {
+	if shortBudget <= 0 {
+		shortBudget = defaultShortBudget
+	}
+	if dailyBudget <= 0 {
+		dailyBudget = defaultDailyBudget
+	}
+	if interactiveReserve < 0 {
+		interactiveReserve = 0
+	}
+	return &rateBudgetScheduler{
+		shortBudget:        shortBudget,
+		dailyBudget:        dailyBudget,
+		interactiveReserve: interactiveReserve,
+		sent:               make(map[requestPriority]int),
+		refused:            make(map[requestPriority]int),
+		now:                time.Now,
+		sleep:              time.Sleep,
+	}
+}
+
+// acquire reserves one request. Background requests block until the next
+// short window when it is full; a full daily window is refused for everyone.
+func (scheduler *rateBudgetScheduler) acquire(priority requestPriority) error {
+	scheduler.mutex.Lock()
+	defer scheduler.mutex.Unlock()
+
+	for {
+		now := scheduler.now().UTC()
+		scheduler.rollWindows(now)
+
+		reserve := 0
+		if priority == priorityBackground {
+			reserve = scheduler.interactiveReserve
+		}
+		shortCapacity := effectiveLimit(scheduler.shortBudget, scheduler.shortLimit) - reserve
+		dailyCapacity := effectiveLimit(scheduler.dailyBudget, scheduler.dailyLimit) - reserve
+
+		if scheduler.dailyUsed >= dailyCapacity {
+			scheduler.refused[priority]++
+			return &BudgetExhaustedError{Window: "daily", Priority: priority.String(), RetryAt: scheduler.dayStart.Add(24 * time.Hour)}
+		}
+		if scheduler.shortUsed < shortCapacity {
+			scheduler.shortUsed++
+			scheduler.dailyUsed++
+			scheduler.sent[priority]++
+			return nil
+		}
+
+		resetAt := scheduler.shortWindowStart.Add(shortRateWindow)
+		if priority == priorityInteractive {
+			scheduler.refused[priority]++
+			return &BudgetExhaustedError{Window: "15-minute", Priority: priority.String(), RetryAt: resetAt}
+		}
+
+		wait := resetAt.Sub(now)
+		log.Printf("Strava 15-minute budget reached (%d/%d); background request waits %s", scheduler.shortUsed, shortCapacity, wait.Round(time.Second))
+		scheduler.waiting++
+		scheduler.mutex.Unlock()
+		scheduler.sleep(wait)
+		scheduler.mutex.Lock()
+		scheduler.waiting--
+	}
+}
+
+// observe aligns local usage with the limits and usage Strava reports.
+// Read limits are preferred because the application only sends GET requests.
+func (scheduler *rateBudgetScheduler) observe(header http.Header, statusCode int) {
+	limitShort, limitDaily, hasLimit := parseRateLimitPair(firstHeader(header, "X-ReadRateLimit-Limit", "X-RateLimit-Limit"))
+	usageShort, usageDaily, hasUsage := parseRateLimitPair(firstHeader(header, "X-ReadRateLimit-Usage", "X-RateLimit-Usage"))
+
+	scheduler.mutex.Lock()
+	defer scheduler.mutex.Unlock()
+
+	scheduler.rollWindows(scheduler.now().UTC())
+	if hasLimit {
+		scheduler.shortLimit = limitShort
+		scheduler.dailyLimit = limitDaily
+	}
+	if hasUsage {
+		scheduler.shortUsed = usageShort
+		scheduler.dailyUsed = usageDaily
+	}
+	if hasLimit || hasUsage {
+		scheduler.headersObservedAt = scheduler.now().UTC()
+	}
+	if statusCode == http.StatusTooManyRequests && !hasUsage {
+		scheduler.shortUsed = effectiveLimit(scheduler.shortBudget, scheduler.shortLimit)
+	}
+}
+
+func (scheduler *rateBudgetScheduler) rollWindows(now time.Time) {
+	shortWindowStart := now.Truncate(shortRateWindow)
+	if !scheduler.shortWindowStart.Equal(shortWindowStart) {
+		scheduler.shortWindowStart = shortWindowStart
+		scheduler.shortUsed = 0
+	}
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if !scheduler.dayStart.Equal(dayStart) {
+		scheduler.dayStart = dayStart
+		scheduler.dailyUsed = 0
+	}
+}
+
+func (scheduler *rateBudgetScheduler) snapshot() RateBudgetSnapshot {
+	scheduler.mutex.Lock()
+	defer scheduler.mutex.Unlock()
+
+	scheduler.rollWindows(scheduler.now().UTC())
+	snapshot := RateBudgetSnapshot{
+		Short:              budgetWindow(scheduler.shortBudget, scheduler.shortLimit, scheduler.shortUsed, scheduler.shortWindowStart.Add(shortRateWindow)),
+		Daily:              budgetWindow(scheduler.dailyBudget, scheduler.dailyLimit, scheduler.dailyUsed, scheduler.dayStart.Add(24*time.Hour)),
+		InteractiveReserve: scheduler.interactiveReserve,
+		QueuedBackground:   scheduler.waiting,
+		Sent:               priorityCounts(scheduler.sent),
+		Refused:            priorityCounts(scheduler.refused),
+	}
+	if !scheduler.headersObservedAt.IsZero() {
+		snapshot.HeadersObservedAt = scheduler.headersObservedAt.Format(time.RFC3339)
+	}
+	return snapshot
+}
+
+func budgetWindow(budget int, limit int, used int, resetsAt time.Time) RateBudgetWindow {
+	capacity := effectiveLimit(budget, limit)
+	remaining := capacity - used
+	if remaining < 0 {
+		remaining = 0
+	}
+	return RateBudgetWindow{
+		Limit:     limit,
+		Budget:    budget,
+		Used:      used,
+		Remaining: remaining,
+		ResetsAt:  resetsAt.Format(time.RFC3339),
+	}
+}
+
+func priorityCounts(counts map[requestPriority]int) map[string]int {
+	return map[string]int{
+		priorityBackground.String():  counts[priorityBackground],
+		priorityInteractive.String(): counts[priorityInteractive],
+	}
+}
+
+// effectiveLimit keeps the configured budget unless Strava reported a lower limit.
+func effectiveLimit(budget int, limit int) int {
+	if limit > 0 && limit < budget {
+		return limit
+	}
+	return budget
+}
+
+func firstHeader(header http.Header, keys ...string) string {
+	for _, key := range keys {
+		if value := strings.TrimSpace(header.Get(key)); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// parseRateLimitPair parses Strava's "<15-minute>,<daily>" header values.
+func parseRateLimitPair(value string) (int, int, bool) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	short, shortErr := strconv.Atoi(strings.TrimSpace(parts[0]))
+	daily, dailyErr := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if shortErr != nil || dailyErr != nil {
+		return 0, 0, false
+	}
+	return short, daily, true
+}
@@ -0,0 +1,147 @@
+package stravaapi
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateBudgetScheduler_KeepsReserveForInteractiveRequests(t *testing.T) {
+	// GIVEN
+	scheduler, clock := testRateBudgetScheduler(5, 100, 2)
+	var waited time.Duration
+	scheduler.sleep = func(duration time.Duration) {
+		waited = duration
+		*clock = clock.Add(duration)
+	}
+	for i := 0; i < 3; i++ {
+		if err := scheduler.acquire(priorityBackground); err != nil {
+			t.Fatalf("expected background request %d to pass, got %v", i+1, err)
+		}
+	}
+
+	// WHEN
+	interactiveErr := scheduler.acquire(priorityInteractive)
+	backgroundErr := scheduler.acquire(priorityBackground)
+
+	// THEN
+	if interactiveErr != nil {
+		t.Fatalf("expected interactive request to use the reserve, got %v", interactiveErr)
+	}
+	if backgroundErr != nil {
+		t.Fatalf("expected background request to wait for the next window, got %v", backgroundErr)
+	}
+	if waited != 10*time.Minute {
+		t.Fatalf("expected background wait until the quarter-hour reset, got %s", waited)
+	}
+	snapshot := scheduler.snapshot()
+	if snapshot.Short.Used != 1 || snapshot.Sent["background"] != 4 || snapshot.Sent["interactive"] != 1 {
+		t.Fatalf("unexpected budget snapshot %#v", snapshot)
+	}
+}
+
+func TestRateBudgetScheduler_RefusesWhenDailyBudgetIsSpent(t *testing.T) {
+	// GIVEN
+	scheduler, _ := testRateBudgetScheduler(100, 3, 1)
+	header := http.Header{}
+	header.Set("X-RateLimit-Limit", "200,2000")
+	header.Set("X-RateLimit-Usage", "10,2")
+	scheduler.observe(header, http.StatusOK)
+
+	// WHEN
+	backgroundErr := scheduler.acquire(priorityBackground)
+	interactiveErr := scheduler.acquire(priorityInteractive)
+	exhaustedErr := scheduler.acquire(priorityInteractive)
+
+	// THEN
+	var budgetErr *BudgetExhaustedError
+	if !errors.As(backgroundErr, &budgetErr) || budgetErr.Window != "daily" || !IsRateLimitError(backgroundErr) {
+		t.Fatalf("expected daily budget refusal for background request, got %v", backgroundErr)
+	}
+	if interactiveErr != nil {
+		t.Fatalf("expected interactive request to use the daily reserve, got %v", interactiveErr)
+	}
+	if !errors.As(exhaustedErr, &budgetErr) {
+		t.Fatalf("expected interactive refusal once the daily budget is spent, got %v", exhaustedErr)
+	}
+	if snapshot := scheduler.snapshot(); snapshot.Daily.Remaining != 0 || snapshot.Refused["background"] != 1 {
+		t.Fatalf("unexpected budget snapshot %#v", snapshot)
+	}
+}
+
+func TestRateBudgetFor_SharesOneSchedulerPerClientID(t *testing.T) {
+	// GIVEN
+	first := rateBudgetFor("ratebudget-client-a")
+
+	// WHEN
+	again := rateBudgetFor("ratebudget-client-a")
+	other := rateBudgetFor("ratebudget-client-b")
+
+	// THEN
+	if first != again {
+		t.Fatal("expected API clients of one clientId to share their budget")
+	}
+	if first == other {
+		t.Fatal("expected another clientId to get its own budget")
+	}
+	if err := first.acquire(priorityInteractive); err != nil {
+		t.Fatal(err)
+	}
+	if used := RateBudgetDiagnostics("ratebudget-client-b").Short.Used; used != 0 {
+		t.Fatalf("expected requests of one client not to use the budget of another, got %d", used)
+	}
+}
+
+func TestStravaApi_AlignsBudgetWithRateLimitHeaders(t *testing.T) {
+	// GIVEN
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "200,2000")
+		w.Header().Set("X-RateLimit-Usage", "150,900")
+		w.Header().Set("X-ReadRateLimit-Limit", "80,1000")
+		w.Header().Set("X-ReadRateLimit-Usage", "75,850")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":42}`))
+	}))
+	defer server.Close()
+	scheduler, clock := testRateBudgetScheduler(100, 1000, 10)
+	api := &StravaApi{
+		accessToken: "test-token",
+		properties:  StravaProperties{APIBaseURL: server.URL},
+		httpClient:  server.Client(),
+		scheduler:   scheduler,
+	}
+
+	// WHEN
+	_, err := api.GetDetailedActivity(42)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("expected detailed activity call to succeed, got %v", err)
+	}
+	snapshot := scheduler.snapshot()
+	if snapshot.Short.Limit != 80 || snapshot.Short.Used != 75 || snapshot.Short.Remaining != 5 {
+		t.Fatalf("expected read limits from headers, got %#v", snapshot.Short)
+	}
+	if snapshot.Daily.Remaining != 150 || snapshot.HeadersObservedAt == "" {
+		t.Fatalf("expected daily usage from headers, got %#v", snapshot)
+	}
+	// 75 used out of 80 minus a reserve of 10: background requests must wait.
+	waited := false
+	scheduler.sleep = func(duration time.Duration) {
+		waited = true
+		*clock = clock.Add(duration)
+	}
+	if err := scheduler.acquire(priorityBackground); err != nil || !waited {
+		t.Fatalf("expected background request to wait for the reserve, waited=%t err=%v", waited, err)
+	}
+}
+
+func testRateBudgetScheduler(shortBudget int, dailyBudget int, reserve int) (*rateBudgetScheduler, *time.Time) {
+	clock := time.Date(2026, 5, 2, 10, 5, 0, 0, time.UTC)
+	scheduler := newRateBudgetScheduler(shortBudget, dailyBudget, reserve)
+	scheduler.now = func() time.Time { return clock }
+	scheduler.sleep = func(time.Duration) {}
+	return scheduler, &clock
+}
@@ -0,0 +1,41 @@
+package stravaapi
+
+import (
+	"strings"
+
+	"mystravastats/internal/platform/runtimeconfig"
+)
+
+// UploadEnabledEnv turns on uploads of local activities to Strava. OAuth
+// enrollments only ask for activity:write when it is set.
+const UploadEnabledEnv = "STRAVA_UPLOAD_ENABLED"
+
+const uploadScope = "activity:write"
+
+var readScopes = []string{"read_all", "activity:read_all", "profile:read_all"}
+
+// UploadEnabled reports whether uploads to Strava are turned on.
+func UploadEnabled() bool {
+	return runtimeconfig.BoolValue(UploadEnabledEnv, false)
+}
+
+// ReadScopes lists the scopes every token needs to read the athlete's
+// activities.
+func ReadScopes() []string {
+	return append([]string(nil), readScopes...)
+}
+
+// RequiredScopes lists the scopes requested by the OAuth enrollments: the read
+// scopes, plus activity:write when uploads are enabled.
+func RequiredScopes() []string {
+	scopes := ReadScopes()
+	if UploadEnabled() {
+		scopes = append(scopes, uploadScope)
+	}
+	return scopes
+}
+
+// OAuthScope joins RequiredScopes as expected by the Strava authorize URL.
+func OAuthScope() string {
+	return strings.Join(RequiredScopes(), ",")
+}
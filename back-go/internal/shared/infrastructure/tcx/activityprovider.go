@@ -0,0 +1,664 @@
+package tcx
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mystravastats/internal/helpers"
+	"mystravastats/internal/shared/domain/business"
+	"mystravastats/internal/shared/domain/strava"
+	"mystravastats/internal/shared/infrastructure/localindex"
+	"mystravastats/internal/shared/infrastructure/localrepository"
+	"mystravastats/internal/shared/infrastructure/localtrack"
+)
+
+const (
+	firstSupportedYear = 2010
+	// tcxDecoderVersion must be bumped when DecodeTCXActivity output changes so
+	// the persistent index is rebuilt instead of serving stale summaries.
+	tcxDecoderVersion = "tcx-decoder-v1"
+)
+
+type TCXActivityProvider struct {
+	tcxDirectory          string
+	clientID              string
+	stravaAthlete         strava.Athlete
+	activities            []*strava.Activity
+	activityByID          map[int64]*strava.Activity
+	filteredActivities    map[string][]*strava.Activity
+	heartRateZoneSettings business.HeartRateZoneSettings
+	performanceSettings   business.AthletePerformanceSettings
+	localStorageProvider  *localrepository.StravaRepository
+	index                 *localindex.Index
+	dataMutex             sync.RWMutex
+	cacheMutex            sync.RWMutex
+}
+
+func NewTCXActivityProvider(tcxDirectory string) *TCXActivityProvider {
+	resolvedDirectory := strings.TrimSpace(tcxDirectory)
+	if resolvedDirectory == "" {
+		resolvedDirectory = "."
+	}
+	resolvedDirectory = filepath.Clean(resolvedDirectory)
+
+	clientID := deriveTCXClientID(resolvedDirectory)
+	firstName := deriveFirstNameFromTCXDirectory(resolvedDirectory)
+	athleteID := int64(localtrack.HashStringToInt("athlete:" + clientID))
+
+	localStorageProvider := localrepository.NewStravaRepository(resolvedDirectory)
+	localStorageProvider.InitLocalStorageForClientId(clientID)
+
+	provider := &TCXActivityProvider{
+		tcxDirectory:         resolvedDirectory,
+		clientID:             clientID,
+		localStorageProvider: localStorageProvider,
+		index:                localindex.Open(resolvedDirectory, clientID, "tcx", tcxDecoderVersion),
+		stravaAthlete: strava.Athlete{
+			Id:        athleteID,
+			Firstname: &firstName,
+		},
+		heartRateZoneSettings: localStorageProvider.LoadHeartRateZoneSettings(clientID),
+		performanceSettings:   localStorageProvider.LoadPerformanceSettings(clientID),
+	}
+
+	loadedActivities := provider.loadActivitiesFromTCXDirectory()
+	provider.replaceActivities(loadedActivities)
+
+	log.Printf("Initialize TCXActivityProvider using %s ...", provider.tcxDirectory)
+	log.Printf("✅ TCX mode ready with profile=%s and %d activities", provider.clientID, len(loadedActivities))
+
+	return provider
+}
+
+func (provider *TCXActivityProvider) GetDetailedActivity(activityID int64) *strava.DetailedActivity {
+	activity := provider.findActivityByID(activityID)
+	if activity == nil {
+		return nil
+	}
+	return activity.ToStravaDetailedActivity()
+}
+
+func (provider *TCXActivityProvider) GetCachedDetailedActivity(activityID int64) *strava.DetailedActivity {
+	return provider.GetDetailedActivity(activityID)
+}
+
+func (provider *TCXActivityProvider) GetActivitiesByYearAndActivityTypes(year *int, activityTypes ...business.ActivityType) []*strava.Activity {
+	cacheKey := localtrack.FilterCacheKey(year, activityTypes...)
+	provider.cacheMutex.RLock()
+	if cachedActivities, ok := provider.filteredActivities[cacheKey]; ok {
+		provider.cacheMutex.RUnlock()
+		return localtrack.ClonePointers(cachedActivities)
+	}
+	provider.cacheMutex.RUnlock()
+
+	filteredActivities := localtrack.FilterByYear(provider.getActivitiesSnapshot(), year)
+	filteredActivities = localtrack.FilterByType(filteredActivities, activityTypes...)
+
+	provider.cacheMutex.Lock()
+	provider.filteredActivities[cacheKey] = filteredActivities
+	provider.cacheMutex.Unlock()
+
+	return localtrack.ClonePointers(filteredActivities)
+}
+
+func (provider *TCXActivityProvider) GetActivitiesByActivityTypeGroupByYear(activityTypes ...business.ActivityType) map[string][]*strava.Activity {
+	filteredActivities := localtrack.FilterByType(provider.getActivitiesSnapshot(), activityTypes...)
+	return localtrack.GroupByYear(filteredActivities)
+}
+
+func (provider *TCXActivityProvider) GetActivitiesByActivityTypeGroupByActiveDays(activityTypes ...business.ActivityType) map[string]int {
+	filteredActivities := localtrack.FilterByType(provider.getActivitiesSnapshot(), activityTypes...)
+	result := make(map[string]int)
+	for _, activity := range filteredActivities {
+		date := localtrack.ExtractSortableDay(activity.StartDateLocal)
+		if date == "" {
+			continue
+		}
+		result[date] += int(activity.Distance / 1000)
+	}
+	return result
+}
+
+func (provider *TCXActivityProvider) GetAthlete() strava.Athlete {
+	return provider.stravaAthlete
+}
+
+func (provider *TCXActivityProvider) GetHeartRateZoneSettings() business.HeartRateZoneSettings {
+	provider.dataMutex.RLock()
+	defer provider.dataMutex.RUnlock()
+
+	return provider.heartRateZoneSettings
+}
+
+func (provider *TCXActivityProvider) SaveHeartRateZoneSettings(settings business.HeartRateZoneSettings) business.HeartRateZoneSettings {
+	provider.dataMutex.Lock()
+	provider.heartRateZoneSettings = settings
+	provider.dataMutex.Unlock()
+
+	provider.localStorageProvider.SaveHeartRateZoneSettings(provider.clientID, settings)
+	return settings
+}
+
+func (provider *TCXActivityProvider) GetPerformanceSettings() business.AthletePerformanceSettings {
+	provider.dataMutex.RLock()
+	defer provider.dataMutex.RUnlock()
+
+	return provider.performanceSettings
+}
+
+func (provider *TCXActivityProvider) SavePerformanceSettings(settings business.AthletePerformanceSettings) business.AthletePerformanceSettings {
+	provider.dataMutex.Lock()
+	provider.performanceSettings = settings
+	provider.dataMutex.Unlock()
+
+	provider.localStorageProvider.SavePerformanceSettings(provider.clientID, settings)
+	return settings
+}
+
+func (provider *TCXActivityProvider) CacheDiagnostics() map[string]any {
+	provider.dataMutex.RLock()
+	activitiesCount := len(provider.activities)
+	provider.dataMutex.RUnlock()
+
+	yearsSet := make(map[string]struct{})
+	for _, activity := range provider.getActivitiesSnapshot() {
+		if activity == nil {
+			continue
+		}
+		year := localtrack.ExtractYear(activity.StartDateLocal)
+		if year == "" {
+			year = localtrack.ExtractYear(activity.StartDate)
+		}
+		if year != "" {
+			yearsSet[year] = struct{}{}
+		}
+	}
+
+	years := make([]string, 0, len(yearsSet))
+	for year := range yearsSet {
+		years = append(years, year)
+	}
+	sort.Strings(years)
+
+	return map[string]any{
+		"timestamp":         time.Now().UTC().Format(time.RFC3339),
+		"provider":          "tcx",
+		"tcxDirectory":      provider.tcxDirectory,
+		"athleteId":         provider.clientID,
+		"activities":        activitiesCount,
+		"availableYearBins": years,
+		"index":             provider.index.Diagnostics(),
+	}
+}
+
+func (provider *TCXActivityProvider) ClientID() string {
+	return provider.clientID
+}
+
+func (provider *TCXActivityProvider) CacheRootPath() string {
+	return provider.tcxDirectory
+}
+
+// SourceFile returns the TCX file an activity was decoded from.
+func (provider *TCXActivityProvider) SourceFile(activityID int64) (string, bool) {
+	return provider.index.SourceFile(activityID)
+}
+
+func (provider *TCXActivityProvider) Reload() {
+	provider.replaceActivities(provider.loadActivitiesFromTCXDirectory())
+}
+
+func (provider *TCXActivityProvider) loadActivitiesFromTCXDirectory() []*strava.Activity {
+	start := time.Now()
+	files := make([]localindex.File, 0)
+
+	for year := time.Now().Year(); year >= firstSupportedYear; year-- {
+		yearDirectory := filepath.Join(provider.tcxDirectory, strconv.Itoa(year))
+		yearEntries, err := os.ReadDir(yearDirectory)
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				log.Printf("Unable to list TCX directory %s: %v", yearDirectory, err)
+			}
+			continue
+		}
+
+		for _, entry := range yearEntries {
+			if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".tcx") {
+				continue
+			}
+			files = append(files, localindex.File{Path: filepath.Join(yearDirectory, entry.Name()), Year: year})
+		}
+	}
+
+	loadedActivities, stats := provider.index.Load(files, func(file localindex.File) (*strava.Activity, error) {
+		return DecodeTCXActivity(file.Path, provider.stravaAthlete.Id)
+	})
+
+	sort.SliceStable(loadedActivities, func(i, j int) bool {
+		left, leftOK := helpers.ParseActivityDate(loadedActivities[i].StartDateLocal)
+		right, rightOK := helpers.ParseActivityDate(loadedActivities[j].StartDateLocal)
+		switch {
+		case leftOK && rightOK:
+			return left.After(right)
+		case leftOK && !rightOK:
+			return true
+		case !leftOK && rightOK:
+			return false
+		default:
+			return loadedActivities[i].StartDateLocal > loadedActivities[j].StartDateLocal
+		}
+	})
+
+	log.Printf("Loaded %d TCX activities in %s (index hits=%d decoded=%d removed=%d)", len(loadedActivities), time.Since(start), stats.Hits, stats.Decoded, stats.Removed)
+	return loadedActivities
+}
+
+type tcxDocument struct {
+	Activities []tcxActivity `xml:"Activities>Activity"`
+}
+
+type tcxActivity struct {
+	Sport string   `xml:"Sport,attr"`
+	ID    string   `xml:"Id"`
+	Notes string   `xml:"Notes"`
+	Laps  []tcxLap `xml:"Lap"`
+}
+
+type tcxLap struct {
+	StartTime        string           `xml:"StartTime,attr"`
+	TotalTimeSeconds string           `xml:"TotalTimeSeconds"`
+	DistanceMeters   string           `xml:"DistanceMeters"`
+	MaximumSpeed     string           `xml:"MaximumSpeed"`
+	AverageHeartRate tcxValue         `xml:"AverageHeartRateBpm"`
+	MaximumHeartRate tcxValue         `xml:"MaximumHeartRateBpm"`
+	Cadence          string           `xml:"Cadence"`
+	TriggerMethod    string           `xml:"TriggerMethod"`
+	Tracks           []tcxTrack       `xml:"Track"`
+	Extensions       tcxLapExtensions `xml:"Extensions"`
+}
+
+type tcxValue struct {
+	Value string `xml:"Value"`
+}
+
+type tcxTrack struct {
+	Points []tcxTrackPoint `xml:"Trackpoint"`
+}
+
+type tcxTrackPoint struct {
+	Time           string                  `xml:"Time"`
+	Position       *tcxPosition            `xml:"Position"`
+	AltitudeMeters string                  `xml:"AltitudeMeters"`
+	DistanceMeters string                  `xml:"DistanceMeters"`
+	HeartRate      tcxValue                `xml:"HeartRateBpm"`
+	Cadence        string                  `xml:"Cadence"`
+	Extensions     tcxTrackPointExtensions `xml:"Extensions"`
+}
+
+type tcxPosition struct {
+	LatitudeDegrees  string `xml:"LatitudeDegrees"`
+	LongitudeDegrees string `xml:"LongitudeDegrees"`
+}
+
+// tcxTrackPointExtensions maps the Garmin ActivityExtension v2 "TPX" block,
+// usually written with the ns3 prefix. Field tags omit the namespace so any
+// prefix used by the exporting device is accepted.
+type tcxTrackPointExtensions struct {
+	TPX struct {
+		Speed      string `xml:"Speed"`
+		RunCadence string `xml:"RunCadence"`
+		Watts      string `xml:"Watts"`
+	} `xml:"TPX"`
+}
+
+type tcxLapExtensions struct {
+	LX struct {
+		AvgSpeed string `xml:"AvgSpeed"`
+		AvgWatts string `xml:"AvgWatts"`
+		MaxWatts string `xml:"MaxWatts"`
+	} `xml:"LX"`
+}
+
+type tcxLapTotals struct {
+	timerSeconds float64
+	distance     float64
+	maxSpeed     float64
+	maxHeartRate int
+	maxWatts     float64
+	averageWatts float64
+}
+
+func DecodeTCXActivity(filePath string, athleteID int64) (*strava.Activity, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var document tcxDocument
+	if err := xml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	if len(document.Activities) == 0 {
+		return nil, errors.New("TCX file has no activity")
+	}
+
+	tcxActivity := document.Activities[0]
+	if len(tcxActivity.Laps) == 0 {
+		return nil, errors.New("TCX activity has no lap")
+	}
+
+	points := flattenTCXTrackPoints(tcxActivity.Laps)
+	if len(points) < 2 {
+		return nil, errors.New("TCX file must contain at least 2 track points")
+	}
+
+	startTime := resolveTCXStartTime(tcxActivity, points)
+	if startTime.IsZero() {
+		return nil, errors.New("TCX activity has no start time")
+	}
+
+	stream, stats := localtrack.BuildStream(points, startTime)
+	if stream == nil || len(stream.Distance.Data) == 0 || len(stream.Time.Data) == 0 {
+		return nil, errors.New("TCX file has no usable stream")
+	}
+
+	laps := summarizeTCXLaps(tcxActivity.Laps)
+	sportType := mapTCXSportToActivityType(tcxActivity.Sport)
+
+	distance := laps.distance
+	if distance <= 0 {
+		distance = stats.DistanceMeters
+	}
+
+	elapsedTime := stats.ElapsedTime
+	if elapsedTime <= 0 {
+		elapsedTime = localtrack.RoundedNonNegative(laps.timerSeconds)
+	}
+
+	movingTime := localtrack.RoundedNonNegative(laps.timerSeconds)
+	if movingTime <= 0 || (stats.MovingTime > 0 && stats.MovingTime < movingTime) {
+		movingTime = stats.MovingTime
+	}
+	if movingTime <= 0 {
+		movingTime = elapsedTime
+	}
+
+	averageSpeed := 0.0
+	if movingTime > 0 {
+		averageSpeed = distance / float64(movingTime)
+	}
+
+	maxSpeed := laps.maxSpeed
+	if maxSpeed <= 0 {
+		maxSpeed = localtrack.MaxFloat(stats.VelocityData)
+	}
+
+	maxHeartRate := localtrack.MaxInt(stats.HeartRateData)
+	if laps.maxHeartRate > maxHeartRate {
+		maxHeartRate = laps.maxHeartRate
+	}
+
+	averageWatts := localtrack.AverageFloat(stats.PowerData)
+	if averageWatts <= 0 {
+		averageWatts = laps.averageWatts
+	}
+
+	startDateUTC := startTime.UTC()
+	startDateLocal := helpers.ActivityLocalTime(startDateUTC)
+	activityID := tcxActivityID(filePath, startDateUTC, sportType, distance)
+
+	name := strings.TrimSpace(tcxActivity.Notes)
+	if name == "" {
+		name = fmt.Sprintf("%s - %s", sportType, startDateLocal.Format("2006-01-02 15:04:05"))
+	}
+
+	return &strava.Activity{
+		Athlete:              strava.AthleteRef{ID: int(athleteID)},
+		AverageSpeed:         averageSpeed,
+		AverageCadence:       localtrack.AverageInt(stats.CadenceData),
+		AverageHeartrate:     localtrack.AverageInt(stats.HeartRateData),
+		MaxHeartrate:         float64(maxHeartRate),
+		AverageWatts:         averageWatts,
+		Commute:              false,
+		Distance:             distance,
+		DeviceWatts:          localtrack.HasAnyFloat(stats.PowerData) || laps.averageWatts > 0,
+		ElapsedTime:          elapsedTime,
+		ElevHigh:             localtrack.MaxFloat(stats.AltitudeData),
+		Id:                   activityID,
+		Kilojoules:           0.8604 * averageWatts * float64(elapsedTime) / 1000,
+		MaxSpeed:             maxSpeed,
+		MovingTime:           movingTime,
+		Name:                 name,
+		SportType:            sportType,
+		StartDate:            startDateUTC.Format(time.RFC3339),
+		StartDateLocal:       startDateLocal.Format(time.RFC3339),
+		StartLatlng:          localtrack.FirstCoordinate(points),
+		TotalElevationGain:   stats.ElevationGainMeters,
+		Type:                 sportType,
+		UploadId:             activityID,
+		WeightedAverageWatts: int(math.Round(averageWatts)),
+		Stream:               stream,
+	}, nil
+}
+
+func flattenTCXTrackPoints(laps []tcxLap) []localtrack.Point {
+	points := make([]localtrack.Point, 0)
+	for _, lap := range laps {
+		for _, track := range lap.Tracks {
+			for _, point := range track.Points {
+				parsedPoint := localtrack.Point{
+					Timestamp: parseTCXTime(point.Time),
+					HeartRate: parseOptionalInt(point.HeartRate.Value),
+					Cadence:   parseOptionalInt(point.Cadence),
+				}
+				if runCadence := parseOptionalInt(point.Extensions.TPX.RunCadence); runCadence > parsedPoint.Cadence {
+					parsedPoint.Cadence = runCadence
+				}
+				if watts, ok := parseOptionalFloat(point.Extensions.TPX.Watts); ok && watts >= 0 {
+					parsedPoint.Watts = watts
+				}
+				if speed, ok := parseOptionalFloat(point.Extensions.TPX.Speed); ok && speed >= 0 {
+					parsedPoint.Speed = speed
+					parsedPoint.HasSpeed = true
+				}
+				if point.Position != nil {
+					latitude, latitudeOK := parseOptionalFloat(point.Position.LatitudeDegrees)
+					longitude, longitudeOK := parseOptionalFloat(point.Position.LongitudeDegrees)
+					if latitudeOK && longitudeOK && localtrack.IsCoordinateValid([]float64{latitude, longitude}) {
+						parsedPoint.Latitude = latitude
+						parsedPoint.Longitude = longitude
+						parsedPoint.HasPosition = true
+					}
+				}
+				if elevation, ok := parseOptionalFloat(point.AltitudeMeters); ok {
+					parsedPoint.Elevation = elevation
+					parsedPoint.HasElevation = true
+				}
+				if distance, ok := parseOptionalFloat(point.DistanceMeters); ok && distance >= 0 {
+					parsedPoint.Distance = distance
+					parsedPoint.HasDistance = true
+				}
+				if parsedPoint.Timestamp.IsZero() && !parsedPoint.HasPosition && !parsedPoint.HasDistance {
+					continue
+				}
+				points = append(points, parsedPoint)
+			}
+		}
+	}
+	return points
+}
+
+func summarizeTCXLaps(laps []tcxLap) tcxLapTotals {
+	totals := tcxLapTotals{}
+	weightedWatts := 0.0
+	weightedSeconds := 0.0
+	for _, lap := range laps {
+		timerSeconds, _ := parseOptionalFloat(lap.TotalTimeSeconds)
+		if timerSeconds > 0 {
+			totals.timerSeconds += timerSeconds
+		}
+		if distance, ok := parseOptionalFloat(lap.DistanceMeters); ok && distance > 0 {
+			totals.distance += distance
+		}
+		if maxSpeed, ok := parseOptionalFloat(lap.MaximumSpeed); ok && maxSpeed > totals.maxSpeed {
+			totals.maxSpeed = maxSpeed
+		}
+		if maxHeartRate := parseOptionalInt(lap.MaximumHeartRate.Value); maxHeartRate > totals.maxHeartRate {
+			totals.maxHeartRate = maxHeartRate
+		}
+		if maxWatts, ok := parseOptionalFloat(lap.Extensions.LX.MaxWatts); ok && maxWatts > totals.maxWatts {
+			totals.maxWatts = maxWatts
+		}
+		if averageWatts, ok := parseOptionalFloat(lap.Extensions.LX.AvgWatts); ok && averageWatts > 0 && timerSeconds > 0 {
+			weightedWatts += averageWatts * timerSeconds
+			weightedSeconds += timerSeconds
+		}
+	}
+	if weightedSeconds > 0 {
+		totals.averageWatts = weightedWatts / weightedSeconds
+	}
+	return totals
+}
+
+func resolveTCXStartTime(activity tcxActivity, points []localtrack.Point) time.Time {
+	if startTime := parseTCXTime(activity.ID); !startTime.IsZero() {
+		return startTime
+	}
+	for _, lap := range activity.Laps {
+		if startTime := parseTCXTime(lap.StartTime); !startTime.IsZero() {
+			return startTime
+		}
+	}
+	for _, point := range points {
+		if !point.Timestamp.IsZero() {
+			return point.Timestamp
+		}
+	}
+	return time.Time{}
+}
+
+func parseTCXTime(value string) time.Time {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return time.Time{}
+	}
+	layouts := []string{
+		time.RFC3339Nano,
+		time.RFC3339,
+		"2006-01-02T15:04:05Z0700",
+		"2006-01-02T15:04:05",
+	}
+	for _, layout := range layouts {
+		parsed, err := time.Parse(layout, trimmed)
+		if err == nil {
+			return parsed
+		}
+	}
+	return time.Time{}
+}
+
+func parseOptionalFloat(value string) (float64, bool) {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return 0, false
+	}
+	parsed, err := strconv.ParseFloat(trimmed, 64)
+	if err != nil || !localtrack.IsFinite(parsed) {
+		return 0, false
+	}
+	return parsed, true
+}
+
+func parseOptionalInt(value string) int {
+	parsed, ok := parseOptionalFloat(value)
+	if !ok || parsed < 0 {
+		return 0
+	}
+	return int(math.Round(parsed))
+}
+
+func mapTCXSportToActivityType(sport string) string {
+	switch strings.ToLower(strings.TrimSpace(sport)) {
+	case "running", "run":
+		return business.Run.String()
+	case "biking", "cycling", "ride":
+		return business.Ride.String()
+	case "walking", "walk":
+		return business.Walk.String()
+	case "hiking", "hike":
+		return business.Hike.String()
+	default:
+		return business.Ride.String()
+	}
+}
+
+func deriveTCXClientID(tcxDirectory string) string {
+	base := strings.TrimSpace(filepath.Base(tcxDirectory))
+	base = strings.ToLower(base)
+	if base == "" || base == "." || base == string(filepath.Separator) {
+		return "tcx-local"
+	}
+	base = strings.ReplaceAll(base, " ", "-")
+	return base
+}
+
+func deriveFirstNameFromTCXDirectory(tcxDirectory string) string {
+	base := strings.TrimSpace(filepath.Base(tcxDirectory))
+	if strings.HasPrefix(strings.ToLower(base), "tcx-") && len(base) > 4 {
+		return base[4:]
+	}
+	if base != "" && base != "." {
+		return base
+	}
+	return "TCX User"
+}
+
+func tcxActivityID(filePath string, startDate time.Time, sportType string, distanceMeters float64) int64 {
+	identity := fmt.Sprintf("%s|%s|%s|%.3f", filePath, startDate.UTC().Format(time.RFC3339), sportType, distanceMeters)
+	return int64(localtrack.HashStringToInt(identity))
+}
+
+func (provider *TCXActivityProvider) findActivityByID(activityID int64) *strava.Activity {
+	provider.dataMutex.RLock()
+	defer provider.dataMutex.RUnlock()
+	if provider.activityByID == nil {
+		return nil
+	}
+	return provider.activityByID[activityID]
+}
+
+func (provider *TCXActivityProvider) replaceActivities(activities []*strava.Activity) {
+	provider.dataMutex.Lock()
+	provider.activities = activities
+	provider.activityByID = make(map[int64]*strava.Activity, len(activities))
+	for _, activity := range activities {
+		if activity == nil {
+			continue
+		}
+		provider.activityByID[activity.Id] = activity
+	}
+	provider.dataMutex.Unlock()
+
+	provider.cacheMutex.Lock()
+	provider.filteredActivities = make(map[string][]*strava.Activity)
+	provider.cacheMutex.Unlock()
+}
+
+func (provider *TCXActivityProvider) getActivitiesSnapshot() []*strava.Activity {
+	provider.dataMutex.RLock()
+	defer provider.dataMutex.RUnlock()
+
+	snapshot := make([]*strava.Activity, len(provider.activities))
+	copy(snapshot, provider.activities)
+	return snapshot
+}
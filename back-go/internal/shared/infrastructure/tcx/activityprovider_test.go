@@ -0,0 +1,164 @@
+package tcx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"mystravastats/internal/shared/domain/business"
+)
+
+func TestDecodeTCXActivity_MapsLapsAndTrackpointsToActivityWithStreams(t *testing.T) {
+	// GIVEN
+	tcxFile := writeTestTCX(t, t.TempDir(), "2026", "ride.tcx", `<?xml version="1.0" encoding="UTF-8"?>
+<TrainingCenterDatabase xmlns="http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2" xmlns:ns3="http://www.garmin.com/xmlschemas/ActivityExtension/v2">
+  <Activities>
+    <Activity Sport="Biking">
+      <Id>2026-04-01T08:00:00Z</Id>
+      <Lap StartTime="2026-04-01T08:00:00Z">
+        <TotalTimeSeconds>60</TotalTimeSeconds>
+        <DistanceMeters>111.2</DistanceMeters>
+        <MaximumSpeed>2.5</MaximumSpeed>
+        <AverageHeartRateBpm><Value>125</Value></AverageHeartRateBpm>
+        <MaximumHeartRateBpm><Value>135</Value></MaximumHeartRateBpm>
+        <TriggerMethod>Manual</TriggerMethod>
+        <Track>
+          <Trackpoint>
+            <Time>2026-04-01T08:00:00Z</Time>
+            <Position><LatitudeDegrees>48.1000</LatitudeDegrees><LongitudeDegrees>-1.6000</LongitudeDegrees></Position>
+            <AltitudeMeters>10</AltitudeMeters>
+            <DistanceMeters>0</DistanceMeters>
+            <HeartRateBpm><Value>120</Value></HeartRateBpm>
+            <Cadence>80</Cadence>
+            <Extensions><ns3:TPX><ns3:Speed>1.8</ns3:Speed><ns3:Watts>180</ns3:Watts></ns3:TPX></Extensions>
+          </Trackpoint>
+          <Trackpoint>
+            <Time>2026-04-01T08:01:00Z</Time>
+            <Position><LatitudeDegrees>48.1010</LatitudeDegrees><LongitudeDegrees>-1.6000</LongitudeDegrees></Position>
+            <AltitudeMeters>20</AltitudeMeters>
+            <DistanceMeters>111.2</DistanceMeters>
+            <HeartRateBpm><Value>130</Value></HeartRateBpm>
+            <Cadence>82</Cadence>
+            <Extensions><ns3:TPX><ns3:Speed>1.9</ns3:Speed><ns3:Watts>190</ns3:Watts></ns3:TPX></Extensions>
+          </Trackpoint>
+        </Track>
+      </Lap>
+    </Activity>
+  </Activities>
+</TrainingCenterDatabase>`)
+
+	// WHEN
+	activity, err := DecodeTCXActivity(tcxFile, 42)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("expected TCX activity to decode, got error: %v", err)
+	}
+	if activity.SportType != business.Ride.String() {
+		t.Fatalf("expected Ride sport type, got %q", activity.SportType)
+	}
+	if activity.Distance != 111.2 {
+		t.Fatalf("expected lap distance 111.2m, got %f", activity.Distance)
+	}
+	if activity.MovingTime != 60 || activity.ElapsedTime != 60 {
+		t.Fatalf("expected 60s moving and elapsed time, got %d/%d", activity.MovingTime, activity.ElapsedTime)
+	}
+	if activity.MaxHeartrate != 135 {
+		t.Fatalf("expected lap max heart rate 135, got %f", activity.MaxHeartrate)
+	}
+	if activity.AverageWatts != 185 || !activity.DeviceWatts {
+		t.Fatalf("expected 185W device power, got %f (device=%t)", activity.AverageWatts, activity.DeviceWatts)
+	}
+	if activity.TotalElevationGain != 10 {
+		t.Fatalf("expected 10m elevation gain, got %f", activity.TotalElevationGain)
+	}
+	if activity.Stream == nil || activity.Stream.HeartRate == nil || activity.Stream.Cadence == nil || activity.Stream.Watts == nil || activity.Stream.LatLng == nil {
+		t.Fatalf("expected heart-rate, cadence, power and GPS streams, got %#v", activity.Stream)
+	}
+}
+
+func TestDecodeTCXActivity_AcceptsIndoorFileWithoutPositions(t *testing.T) {
+	// GIVEN
+	tcxFile := writeTestTCX(t, t.TempDir(), "2026", "indoor.tcx", `<?xml version="1.0" encoding="UTF-8"?>
+<TrainingCenterDatabase xmlns="http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2" xmlns:ns3="http://www.garmin.com/xmlschemas/ActivityExtension/v2">
+  <Activities>
+    <Activity Sport="Running">
+      <Id>2026-02-10T18:00:00Z</Id>
+      <Lap StartTime="2026-02-10T18:00:00Z">
+        <TotalTimeSeconds>120</TotalTimeSeconds>
+        <DistanceMeters>400</DistanceMeters>
+        <Track>
+          <Trackpoint><Time>2026-02-10T18:00:00Z</Time><DistanceMeters>0</DistanceMeters><Extensions><ns3:TPX><ns3:RunCadence>84</ns3:RunCadence></ns3:TPX></Extensions></Trackpoint>
+          <Trackpoint><Time>2026-02-10T18:02:00Z</Time><DistanceMeters>400</DistanceMeters><Extensions><ns3:TPX><ns3:RunCadence>86</ns3:RunCadence></ns3:TPX></Extensions></Trackpoint>
+        </Track>
+      </Lap>
+    </Activity>
+  </Activities>
+</TrainingCenterDatabase>`)
+
+	// WHEN
+	activity, err := DecodeTCXActivity(tcxFile, 42)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("expected indoor TCX activity to decode, got error: %v", err)
+	}
+	if activity.SportType != business.Run.String() {
+		t.Fatalf("expected Run sport type, got %q", activity.SportType)
+	}
+	if activity.Stream == nil || activity.Stream.LatLng != nil {
+		t.Fatalf("expected stream without GPS trace, got %#v", activity.Stream)
+	}
+	if activity.Stream.Cadence == nil || activity.AverageCadence != 85 {
+		t.Fatalf("expected run cadence from TPX extension, got %f", activity.AverageCadence)
+	}
+}
+
+func TestTCXActivityProvider_FiltersActivitiesByYearAndType(t *testing.T) {
+	// GIVEN
+	root := t.TempDir()
+	writeTestTCX(t, root, "2026", "run.tcx", `<?xml version="1.0" encoding="UTF-8"?>
+<TrainingCenterDatabase xmlns="http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2">
+  <Activities><Activity Sport="Running"><Id>2026-01-01T08:00:00Z</Id>
+    <Lap StartTime="2026-01-01T08:00:00Z"><TotalTimeSeconds>300</TotalTimeSeconds><Track>
+      <Trackpoint><Time>2026-01-01T08:00:00Z</Time><Position><LatitudeDegrees>48.1000</LatitudeDegrees><LongitudeDegrees>-1.6000</LongitudeDegrees></Position></Trackpoint>
+      <Trackpoint><Time>2026-01-01T08:05:00Z</Time><Position><LatitudeDegrees>48.1010</LatitudeDegrees><LongitudeDegrees>-1.6000</LongitudeDegrees></Position></Trackpoint>
+    </Track></Lap>
+  </Activity></Activities>
+</TrainingCenterDatabase>`)
+	writeTestTCX(t, root, "2025", "ride.tcx", `<?xml version="1.0" encoding="UTF-8"?>
+<TrainingCenterDatabase xmlns="http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2">
+  <Activities><Activity Sport="Biking"><Id>2025-01-01T08:00:00Z</Id>
+    <Lap StartTime="2025-01-01T08:00:00Z"><TotalTimeSeconds>300</TotalTimeSeconds><Track>
+      <Trackpoint><Time>2025-01-01T08:00:00Z</Time><Position><LatitudeDegrees>48.1000</LatitudeDegrees><LongitudeDegrees>-1.6000</LongitudeDegrees></Position></Trackpoint>
+      <Trackpoint><Time>2025-01-01T08:05:00Z</Time><Position><LatitudeDegrees>48.1010</LatitudeDegrees><LongitudeDegrees>-1.6000</LongitudeDegrees></Position></Trackpoint>
+    </Track></Lap>
+  </Activity></Activities>
+</TrainingCenterDatabase>`)
+	provider := NewTCXActivityProvider(root)
+	year := 2026
+
+	// WHEN
+	activities := provider.GetActivitiesByYearAndActivityTypes(&year, business.Run)
+
+	// THEN
+	if len(activities) != 1 {
+		t.Fatalf("expected one 2026 run activity, got %d", len(activities))
+	}
+	if activities[0].SportType != business.Run.String() {
+		t.Fatalf("expected Run, got %s", activities[0].SportType)
+	}
+}
+
+func writeTestTCX(t *testing.T, root string, year string, name string, content string) string {
+	t.Helper()
+	yearDirectory := filepath.Join(root, year)
+	if err := os.MkdirAll(yearDirectory, 0o700); err != nil {
+		t.Fatalf("failed to create year directory: %v", err)
+	}
+	filePath := filepath.Join(yearDirectory, name)
+	if err := os.WriteFile(filePath, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write TCX fixture: %v", err)
+	}
+	return filePath
+}
@@ -0,0 +1,19 @@
+package application
+
+import "mystravastats/internal/shared/domain/business"
+
+type SourceMergeOverridesReader interface {
+	FindSourceMergeOverrides() (business.SourceMergeOverrides, error)
+}
+
+type SourceMergeOverridesWriter interface {
+	LinkSourceActivities(sources []business.SourceActivityKey) (business.SourceMergeOverrides, error)
+	SplitSourceActivity(source business.SourceActivityKey) (business.SourceMergeOverrides, error)
+	UpdateDefaultFieldSources(fields map[string]string) (business.SourceMergeOverrides, error)
+	UpdateActivityFieldSources(activityID int64, fields map[string]string) (business.SourceMergeOverrides, error)
+	ResetActivitySourceMerge(activityID int64) (business.SourceMergeOverrides, error)
+}
+
+type SourceCoverageReader interface {
+	FindSourceCoverage(year *int) (business.SourceCoverageReport, error)
+}
@@ -0,0 +1,15 @@
+package application
+
+import "mystravastats/internal/shared/domain/business"
+
+type GetSourceCoverageUseCase struct {
+	reader SourceCoverageReader
+}
+
+func NewGetSourceCoverageUseCase(reader SourceCoverageReader) *GetSourceCoverageUseCase {
+	return &GetSourceCoverageUseCase{reader: reader}
+}
+
+func (uc *GetSourceCoverageUseCase) Execute(year *int) (business.SourceCoverageReport, error) {
+	return uc.reader.FindSourceCoverage(year)
+}
@@ -0,0 +1,15 @@
+package application
+
+import "mystravastats/internal/shared/domain/business"
+
+type GetSourceMergeOverridesUseCase struct {
+	reader SourceMergeOverridesReader
+}
+
+func NewGetSourceMergeOverridesUseCase(reader SourceMergeOverridesReader) *GetSourceMergeOverridesUseCase {
+	return &GetSourceMergeOverridesUseCase{reader: reader}
+}
+
+func (uc *GetSourceMergeOverridesUseCase) Execute() (business.SourceMergeOverrides, error) {
+	return uc.reader.FindSourceMergeOverrides()
+}
@@ -0,0 +1,63 @@
+package application
+
+import "mystravastats/internal/shared/domain/business"
+
+type LinkSourceActivitiesUseCase struct {
+	writer SourceMergeOverridesWriter
+}
+
+func NewLinkSourceActivitiesUseCase(writer SourceMergeOverridesWriter) *LinkSourceActivitiesUseCase {
+	return &LinkSourceActivitiesUseCase{writer: writer}
+}
+
+func (uc *LinkSourceActivitiesUseCase) Execute(request business.SourceMergeLinkRequest) (business.SourceMergeOverrides, error) {
+	return uc.writer.LinkSourceActivities(request.Sources)
+}
+
+type SplitSourceActivityUseCase struct {
+	writer SourceMergeOverridesWriter
+}
+
+func NewSplitSourceActivityUseCase(writer SourceMergeOverridesWriter) *SplitSourceActivityUseCase {
+	return &SplitSourceActivityUseCase{writer: writer}
+}
+
+func (uc *SplitSourceActivityUseCase) Execute(source business.SourceActivityKey) (business.SourceMergeOverrides, error) {
+	return uc.writer.SplitSourceActivity(source)
+}
+
+type UpdateDefaultFieldSourcesUseCase struct {
+	writer SourceMergeOverridesWriter
+}
+
+func NewUpdateDefaultFieldSourcesUseCase(writer SourceMergeOverridesWriter) *UpdateDefaultFieldSourcesUseCase {
+	return &UpdateDefaultFieldSourcesUseCase{writer: writer}
+}
+
+func (uc *UpdateDefaultFieldSourcesUseCase) Execute(request business.SourceMergeFieldSourcesRequest) (business.SourceMergeOverrides, error) {
+	return uc.writer.UpdateDefaultFieldSources(request.Fields)
+}
+
+type UpdateActivityFieldSourcesUseCase struct {
+	writer SourceMergeOverridesWriter
+}
+
+func NewUpdateActivityFieldSourcesUseCase(writer SourceMergeOverridesWriter) *UpdateActivityFieldSourcesUseCase {
+	return &UpdateActivityFieldSourcesUseCase{writer: writer}
+}
+
+func (uc *UpdateActivityFieldSourcesUseCase) Execute(activityID int64, request business.SourceMergeFieldSourcesRequest) (business.SourceMergeOverrides, error) {
+	return uc.writer.UpdateActivityFieldSources(activityID, request.Fields)
+}
+
+type ResetActivitySourceMergeUseCase struct {
+	writer SourceMergeOverridesWriter
+}
+
+func NewResetActivitySourceMergeUseCase(writer SourceMergeOverridesWriter) *ResetActivitySourceMergeUseCase {
+	return &ResetActivitySourceMergeUseCase{writer: writer}
+}
+
+func (uc *ResetActivitySourceMergeUseCase) Execute(activityID int64) (business.SourceMergeOverrides, error) {
+	return uc.writer.ResetActivitySourceMerge(activityID)
+}
@@ -0,0 +1,95 @@
+package infrastructure
+
+import (
+	"fmt"
+
+	"mystravastats/internal/platform/activityprovider"
+	"mystravastats/internal/shared/domain/business"
+)
+
+// sourceMergeEditor is implemented by the composite provider.
+type sourceMergeEditor interface {
+	SourceMergeOverrides() business.SourceMergeOverrides
+	LinkSourceActivities(sources []business.SourceActivityKey) (business.SourceMergeOverrides, error)
+	SplitSourceActivity(source business.SourceActivityKey) (business.SourceMergeOverrides, error)
+	SetDefaultFieldSources(fields map[string]string) (business.SourceMergeOverrides, error)
+	SetActivityFieldSources(activityID int64, fields map[string]string) (business.SourceMergeOverrides, error)
+	ResetActivityMerge(activityID int64) (business.SourceMergeOverrides, error)
+}
+
+// sourceCoverageReporter is implemented by the composite provider.
+type sourceCoverageReporter interface {
+	SourceCoverage(year *int) business.SourceCoverageReport
+}
+
+type SourceMergeServiceAdapter struct {
+	providers activityprovider.Lookup
+}
+
+func NewSourceMergeServiceAdapter(providers activityprovider.Lookup) *SourceMergeServiceAdapter {
+	return &SourceMergeServiceAdapter{providers: providers}
+}
+
+func (adapter *SourceMergeServiceAdapter) FindSourceMergeOverrides() (business.SourceMergeOverrides, error) {
+	editor, err := adapter.editor()
+	if err != nil {
+		return business.SourceMergeOverrides{}, err
+	}
+	return editor.SourceMergeOverrides(), nil
+}
+
+func (adapter *SourceMergeServiceAdapter) LinkSourceActivities(sources []business.SourceActivityKey) (business.SourceMergeOverrides, error) {
+	editor, err := adapter.editor()
+	if err != nil {
+		return business.SourceMergeOverrides{}, err
+	}
+	return editor.LinkSourceActivities(sources)
+}
+
+func (adapter *SourceMergeServiceAdapter) SplitSourceActivity(source business.SourceActivityKey) (business.SourceMergeOverrides, error) {
+	editor, err := adapter.editor()
+	if err != nil {
+		return business.SourceMergeOverrides{}, err
+	}
+	return editor.SplitSourceActivity(source)
+}
+
+func (adapter *SourceMergeServiceAdapter) UpdateDefaultFieldSources(fields map[string]string) (business.SourceMergeOverrides, error) {
+	editor, err := adapter.editor()
+	if err != nil {
+		return business.SourceMergeOverrides{}, err
+	}
+	return editor.SetDefaultFieldSources(fields)
+}
+
+func (adapter *SourceMergeServiceAdapter) UpdateActivityFieldSources(activityID int64, fields map[string]string) (business.SourceMergeOverrides, error) {
+	editor, err := adapter.editor()
+	if err != nil {
+		return business.SourceMergeOverrides{}, err
+	}
+	return editor.SetActivityFieldSources(activityID, fields)
+}
+
+func (adapter *SourceMergeServiceAdapter) ResetActivitySourceMerge(activityID int64) (business.SourceMergeOverrides, error) {
+	editor, err := adapter.editor()
+	if err != nil {
+		return business.SourceMergeOverrides{}, err
+	}
+	return editor.ResetActivityMerge(activityID)
+}
+
+func (adapter *SourceMergeServiceAdapter) FindSourceCoverage(year *int) (business.SourceCoverageReport, error) {
+	reporter, ok := activityprovider.Unfiltered(adapter.providers()).(sourceCoverageReporter)
+	if !ok {
+		return business.SourceCoverageReport{}, fmt.Errorf("source coverage needs at least two sources combined in composite mode")
+	}
+	return reporter.SourceCoverage(year), nil
+}
+
+func (adapter *SourceMergeServiceAdapter) editor() (sourceMergeEditor, error) {
+	editor, ok := activityprovider.Unfiltered(adapter.providers()).(sourceMergeEditor)
+	if !ok {
+		return nil, fmt.Errorf("merge overrides need at least two sources combined in composite mode")
+	}
+	return editor, nil
+}
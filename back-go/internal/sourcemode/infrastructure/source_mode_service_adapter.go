@@ -17,14 +17,16 @@ import (
 	"mystravastats/internal/shared/domain/strava"
 	fitprovider "mystravastats/internal/shared/infrastructure/fit"
 	gpxprovider "mystravastats/internal/shared/infrastructure/gpx"
+	jsonexportprovider "mystravastats/internal/shared/infrastructure/jsonexport"
 	"mystravastats/internal/shared/infrastructure/localrepository"
+	"mystravastats/internal/shared/infrastructure/secretstore"
+	"mystravastats/internal/shared/infrastructure/stravaapi"
+	tcxprovider "mystravastats/internal/shared/infrastructure/tcx"
 )
 
 const maxPreviewErrors = 8
 const stravaSettingsURL = "https://www.strava.com/settings/api"
 
-var requiredStravaScopes = []string{"read_all", "activity:read_all", "profile:read_all"}
-
 type SourceModeServiceAdapter struct{}
 
 func NewSourceModeServiceAdapter() *SourceModeServiceAdapter {
@@ -50,6 +52,16 @@ func (adapter *SourceModeServiceAdapter) PreviewSourceMode(request business.Sour
 			path, _ = runtimeconfig.OptionalValue("GPX_FILES_PATH")
 		}
 		return previewLocalSourceMode(mode, "GPX_FILES_PATH", ".gpx", path, runtimeconfig.OptionalValue, decodeGPXPreviewActivity)
+	case business.SourceModeTCX:
+		if path == "" {
+			path, _ = runtimeconfig.OptionalValue("TCX_FILES_PATH")
+		}
+		return previewLocalSourceMode(mode, "TCX_FILES_PATH", ".tcx", path, runtimeconfig.OptionalValue, decodeTCXPreviewActivity)
+	case business.SourceModeJSON:
+		if path == "" {
+			path, _ = runtimeconfig.OptionalValue("JSON_FILES_PATH")
+		}
+		return previewLocalSourceMode(mode, "JSON_FILES_PATH", ".json", path, runtimeconfig.OptionalValue, decodeJSONPreviewActivity)
 	case business.SourceModeStrava:
 		if path == "" {
 			path = helpers.StravaCachePath
@@ -63,7 +75,7 @@ func (adapter *SourceModeServiceAdapter) PreviewSourceMode(request business.Sour
 			Errors: []business.SourceModePreviewError{{
 				Message: fmt.Sprintf("unsupported source mode %q", request.Mode),
 			}},
-			Recommendations: []string{"Choose STRAVA, FIT or GPX."},
+			Recommendations: []string{"Choose STRAVA, FIT, GPX, TCX or JSON."},
 		}
 	}
 }
@@ -132,6 +144,10 @@ func normalizeSourceMode(raw string) business.SourceMode {
 		return business.SourceModeFIT
 	case "GPX":
 		return business.SourceModeGPX
+	case "TCX":
+		return business.SourceModeTCX
+	case "JSON":
+		return business.SourceModeJSON
 	case "STRAVA", "":
 		return business.SourceModeStrava
 	default:
@@ -270,7 +286,7 @@ func previewStravaSourceMode(path string) business.SourceModePreview {
 			Path:    filepath.Join(path, ".strava"),
 			Message: ".strava file is missing or does not contain clientId",
 		})
-		preview.Recommendations = []string{"Configure Strava credentials or switch to FIT/GPX local mode."}
+		preview.Recommendations = []string{"Configure Strava credentials or switch to FIT/GPX/TCX/JSON local mode."}
 		return preview
 	}
 
@@ -319,7 +335,7 @@ func inspectStravaOAuth(path string, clientID string, clientSecret string, useCa
 		ClientIDPresent:     strings.TrimSpace(clientID) != "",
 		ClientSecretPresent: strings.TrimSpace(clientSecret) != "",
 		CacheOnly:           useCache,
-		RequiredScopes:      append([]string(nil), requiredStravaScopes...),
+		RequiredScopes:      stravaapi.RequiredScopes(),
 		GrantedScopes:       []string{},
 		MissingScopes:       []string{},
 	}
@@ -327,7 +343,14 @@ func inspectStravaOAuth(path string, clientID string, clientSecret string, useCa
 	if _, err := os.Stat(credentialsFile); err == nil {
 		status.CredentialsFilePresent = true
 	}
+	status.CredentialsEncrypted = secretstore.FileEncrypted(credentialsFile)
+	status.TokenEncrypted = secretstore.FileEncrypted(tokenFile)
 
+	if status.CredentialsEncrypted && !status.ClientIDPresent {
+		status.Status = "needs_secret_key"
+		status.Message = "The .strava file is encrypted; set STRAVA_SECRET_KEY or STRAVA_SECRET_KEY_FILE to the key used to write it."
+		return status
+	}
 	if !status.CredentialsPresent || !status.ClientIDPresent || !status.ClientSecretPresent {
 		status.Message = "Create a Strava app, then run the local setup assistant with Client ID and Client Secret."
 		return status
@@ -350,6 +373,9 @@ func inspectStravaOAuth(path string, clientID string, clientSecret string, useCa
 		status.Status = "token_unreadable"
 		status.TokenError = err.Error()
 		status.Message = "The OAuth token file exists but cannot be read."
+		if errors.Is(err, secretstore.ErrKeyRequired) || errors.Is(err, secretstore.ErrDecrypt) {
+			status.Message = "The OAuth token file is encrypted with another key or no key is configured."
+		}
 		return status
 	}
 
@@ -367,7 +393,7 @@ func inspectStravaOAuth(path string, clientID string, clientSecret string, useCa
 	status.GrantedScopes = splitStravaScopes(tokenPayload.Scope)
 	status.ScopesVerified = len(status.GrantedScopes) > 0
 	if status.ScopesVerified {
-		status.MissingScopes = missingStravaScopes(status.GrantedScopes, requiredStravaScopes)
+		status.MissingScopes = missingStravaScopes(status.GrantedScopes, status.RequiredScopes)
 	}
 
 	status.Status, status.Message = stravaOAuthStatusMessage(status)
@@ -376,7 +402,7 @@ func inspectStravaOAuth(path string, clientID string, clientSecret string, useCa
 
 func readStravaOAuthToken(path string) (stravaOAuthTokenFile, error) {
 	var token stravaOAuthTokenFile
-	data, err := os.ReadFile(path)
+	data, err := secretstore.ReadFile(path)
 	if err != nil {
 		return token, err
 	}
@@ -459,6 +485,14 @@ func decodeGPXPreviewActivity(filePath string, athleteID int64, year int) (*stra
 	return gpxprovider.DecodeGPXActivity(filePath, athleteID, year)
 }
 
+func decodeTCXPreviewActivity(filePath string, athleteID int64, _ int) (*strava.Activity, error) {
+	return tcxprovider.DecodeTCXActivity(filePath, athleteID)
+}
+
+func decodeJSONPreviewActivity(filePath string, athleteID int64, _ int) (*strava.Activity, error) {
+	return jsonexportprovider.DecodeJSONActivity(filePath, athleteID)
+}
+
 func activeSourceMode() business.SourceMode {
 	if _, configured := runtimeconfig.OptionalValue("FIT_FILES_PATH"); configured {
 		return business.SourceModeFIT
@@ -466,6 +500,12 @@ func activeSourceMode() business.SourceMode {
 	if _, configured := runtimeconfig.OptionalValue("GPX_FILES_PATH"); configured {
 		return business.SourceModeGPX
 	}
+	if _, configured := runtimeconfig.OptionalValue("TCX_FILES_PATH"); configured {
+		return business.SourceModeTCX
+	}
+	if _, configured := runtimeconfig.OptionalValue("JSON_FILES_PATH"); configured {
+		return business.SourceModeJSON
+	}
 	return business.SourceModeStrava
 }
 
@@ -503,11 +543,15 @@ func sourceModeEnvironment(mode business.SourceMode, configKey string, path stri
 func sourceModeUnsetKeys(mode business.SourceMode) []string {
 	switch mode {
 	case business.SourceModeStrava:
-		return []string{"FIT_FILES_PATH", "GPX_FILES_PATH"}
+		return []string{"FIT_FILES_PATH", "GPX_FILES_PATH", "TCX_FILES_PATH", "JSON_FILES_PATH"}
 	case business.SourceModeGPX:
-		return []string{"FIT_FILES_PATH"}
+		return []string{"FIT_FILES_PATH", "TCX_FILES_PATH", "JSON_FILES_PATH"}
 	case business.SourceModeFIT:
-		return []string{"GPX_FILES_PATH"}
+		return []string{"GPX_FILES_PATH", "TCX_FILES_PATH", "JSON_FILES_PATH"}
+	case business.SourceModeTCX:
+		return []string{"FIT_FILES_PATH", "GPX_FILES_PATH", "JSON_FILES_PATH"}
+	case business.SourceModeJSON:
+		return []string{"FIT_FILES_PATH", "GPX_FILES_PATH", "TCX_FILES_PATH"}
 	default:
 		return []string{}
 	}
@@ -1,20 +1,23 @@
 package infrastructure
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"mystravastats/internal/shared/domain/business"
+	"mystravastats/internal/shared/infrastructure/secretstore"
 )
 
 func TestPreviewSourceMode_GPXValidatesYearFoldersAndFields(t *testing.T) {
 	// GIVEN
 	root := t.TempDir()
-	writeSourceModeGPX(t, root, "2026", "ride.gpx", `<?xml version="1.0" encoding="UTF-8"?>
+	writeSourceModeFile(t, root, "2026", "ride.gpx", `<?xml version="1.0" encoding="UTF-8"?>
 <gpx version="1.1" creator="test">
   <trk><name>Ride</name><type>cycling</type><trkseg>
     <trkpt lat="48.1000" lon="-1.6000"><ele>10</ele><time>2026-01-01T08:00:00Z</time></trkpt>
@@ -53,6 +56,73 @@ func TestPreviewSourceMode_GPXValidatesYearFoldersAndFields(t *testing.T) {
 	}
 }
 
+func TestPreviewSourceMode_TCXValidatesYearFoldersAndFields(t *testing.T) {
+	// GIVEN
+	root := t.TempDir()
+	writeSourceModeFile(t, root, "2026", "run.tcx", `<?xml version="1.0" encoding="UTF-8"?>
+<TrainingCenterDatabase xmlns="http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2">
+  <Activities><Activity Sport="Running"><Id>2026-01-01T08:00:00Z</Id>
+    <Lap StartTime="2026-01-01T08:00:00Z"><TotalTimeSeconds>300</TotalTimeSeconds><Track>
+      <Trackpoint><Time>2026-01-01T08:00:00Z</Time><Position><LatitudeDegrees>48.1000</LatitudeDegrees><LongitudeDegrees>-1.6000</LongitudeDegrees></Position><AltitudeMeters>10</AltitudeMeters><HeartRateBpm><Value>140</Value></HeartRateBpm></Trackpoint>
+      <Trackpoint><Time>2026-01-01T08:05:00Z</Time><Position><LatitudeDegrees>48.1010</LatitudeDegrees><LongitudeDegrees>-1.6000</LongitudeDegrees></Position><AltitudeMeters>15</AltitudeMeters><HeartRateBpm><Value>150</Value></HeartRateBpm></Trackpoint>
+    </Track></Lap>
+  </Activity></Activities>
+</TrainingCenterDatabase>`)
+	adapter := NewSourceModeServiceAdapter()
+
+	// WHEN
+	preview := adapter.PreviewSourceMode(business.SourceModePreviewRequest{
+		Mode: "tcx",
+		Path: root,
+	})
+
+	// THEN
+	if preview.Mode != business.SourceModeTCX || !preview.Supported || !preview.Readable || !preview.ValidStructure {
+		t.Fatalf("expected supported readable valid TCX preview, got %#v", preview)
+	}
+	if preview.FileCount != 1 || preview.ValidFileCount != 1 || preview.ActivityCount != 1 {
+		t.Fatalf("expected one valid activity, got %#v", preview)
+	}
+	if len(preview.Environment) != 4 || preview.Environment[0].Key != "TCX_FILES_PATH" || preview.Environment[0].Value != root {
+		t.Fatalf("expected TCX environment activation unsetting FIT, GPX and JSON, got %#v", preview.Environment)
+	}
+}
+
+func TestPreviewSourceMode_JSONReportsFieldCoverageOfSuuntoAndCorosExports(t *testing.T) {
+	// GIVEN
+	root := t.TempDir()
+	writeSourceModeFile(t, root, "2026", "suunto.json", `{"DeviceLog": {"Header": {"Activity": "Running"}, "Samples": [
+  {"TimeISO8601": "2026-01-01T08:00:00Z", "HR": 2.3, "Distance": 0, "Altitude": 10, "Latitude": 0.83950337, "Longitude": -0.027925268},
+  {"TimeISO8601": "2026-01-01T08:05:00Z", "HR": 2.5, "Distance": 1000, "Altitude": 15, "Latitude": 0.839520824, "Longitude": -0.027925268}
+]}}`)
+	writeSourceModeFile(t, root, "2025", "coros.json", `{"data": {"summary": {"sportType": 200}, "frequencyList": [
+  {"timestamp": 173571840000, "distance": 0, "heart": 130},
+  {"timestamp": 173571870000, "distance": 300000, "heart": 140}
+]}}`)
+	writeSourceModeFile(t, root, "2025", "broken.json", `{"activities": []}`)
+	adapter := NewSourceModeServiceAdapter()
+
+	// WHEN
+	preview := adapter.PreviewSourceMode(business.SourceModePreviewRequest{
+		Mode: "json",
+		Path: root,
+	})
+
+	// THEN
+	if preview.Mode != business.SourceModeJSON || !preview.Supported || !preview.ValidStructure {
+		t.Fatalf("expected supported valid JSON preview, got %#v", preview)
+	}
+	if preview.FileCount != 3 || preview.ValidFileCount != 2 || preview.InvalidFileCount != 1 || len(preview.Years) != 2 {
+		t.Fatalf("expected two valid exports and one invalid file over two years, got %#v", preview)
+	}
+	if strings.Join(preview.MissingFields, ",") != "power,cadence" {
+		t.Fatalf("expected only power and cadence to be missing, got %v", preview.MissingFields)
+	}
+	if len(preview.Environment) != 4 || preview.Environment[0].Key != "JSON_FILES_PATH" {
+		t.Fatalf("expected JSON environment activation unsetting FIT, GPX and TCX, got %#v", preview.Environment)
+	}
+}
+
 func TestPreviewSourceMode_StravaReportsOAuthEnrollmentStatus(t *testing.T) {
 	// GIVEN
 	root := t.TempDir()
@@ -95,6 +165,69 @@ func TestPreviewSourceMode_StravaReportsOAuthEnrollmentStatus(t *testing.T) {
 	}
 }
 
+func TestPreviewSourceMode_StravaReportsMissingWriteScopeWhenUploadsAreEnabled(t *testing.T) {
+	// GIVEN
+	t.Setenv("STRAVA_UPLOAD_ENABLED", "true")
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, ".strava"), []byte("clientId=12345\nclientSecret=secret\nuseCache=false\n"), 0o600); err != nil {
+		t.Fatalf("failed to write .strava: %v", err)
+	}
+	token := `{"access_token":"access","refresh_token":"refresh","expires_at":` + strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10) + `,"scope":"read_all,activity:read_all,profile:read_all","athlete":{"id":42}}`
+	if err := os.WriteFile(filepath.Join(root, ".strava-token.json"), []byte(token), 0o600); err != nil {
+		t.Fatalf("failed to write token: %v", err)
+	}
+	adapter := NewSourceModeServiceAdapter()
+
+	// WHEN
+	preview := adapter.PreviewSourceMode(business.SourceModePreviewRequest{Mode: "STRAVA", Path: root})
+
+	// THEN
+	oauth := preview.StravaOAuth
+	if oauth == nil || oauth.Status != "scope_incomplete" {
+		t.Fatalf("expected read-only token to need the upload scope, got %#v", oauth)
+	}
+	if strings.Join(oauth.MissingScopes, ",") != "activity:write" || !slices.Contains(oauth.RequiredScopes, "activity:write") {
+		t.Fatalf("expected activity:write to be required and missing, got %#v", oauth)
+	}
+}
+
+func TestPreviewSourceMode_StravaReadsEncryptedCredentialsWithoutExposingThem(t *testing.T) {
+	// GIVEN
+	t.Setenv("STRAVA_SECRET_KEY", "source-mode-key")
+	root := t.TempDir()
+	if err := secretstore.WriteFile(filepath.Join(root, ".strava"), []byte("clientId=12345\nclientSecret=client-secret-value\nuseCache=false\n")); err != nil {
+		t.Fatalf("failed to write .strava: %v", err)
+	}
+	token := `{"access_token":"access-value","refresh_token":"refresh-value","expires_at":` + strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10) + `,"scope":"read_all,activity:read_all,profile:read_all","athlete":{"id":42}}`
+	if err := secretstore.WriteFile(filepath.Join(root, ".strava-token.json"), []byte(token)); err != nil {
+		t.Fatalf("failed to write token: %v", err)
+	}
+	adapter := NewSourceModeServiceAdapter()
+
+	// WHEN
+	preview := adapter.PreviewSourceMode(business.SourceModePreviewRequest{Mode: "STRAVA", Path: root})
+	t.Setenv("STRAVA_SECRET_KEY", "")
+	withoutKey := adapter.PreviewSourceMode(business.SourceModePreviewRequest{Mode: "STRAVA", Path: root})
+
+	// THEN
+	oauth := preview.StravaOAuth
+	if oauth == nil || oauth.Status != "ready" || !oauth.CredentialsEncrypted || !oauth.TokenEncrypted || !oauth.RefreshTokenPresent {
+		t.Fatalf("expected ready OAuth status read from encrypted files, got %#v", oauth)
+	}
+	if withoutKey.StravaOAuth == nil || withoutKey.StravaOAuth.Status != "needs_secret_key" {
+		t.Fatalf("expected encrypted credentials to require the secret key, got %#v", withoutKey.StravaOAuth)
+	}
+	data, err := json.Marshal([]business.SourceModePreview{preview, withoutKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"client-secret-value", "access-value", "refresh-value", "source-mode-key"} {
+		if strings.Contains(string(data), secret) {
+			t.Fatalf("expected preview to never contain %q, got %s", secret, data)
+		}
+	}
+}
+
 func TestWriteSourceModeEnv_SavesFITAndUnsetsGPX(t *testing.T) {
 	root := t.TempDir()
 	previousCwd, err := os.Getwd()
@@ -135,7 +268,7 @@ func TestWriteSourceModeEnv_SavesFITAndUnsetsGPX(t *testing.T) {
 	}
 }
 
-func writeSourceModeGPX(t *testing.T, root string, year string, name string, content string) string {
+func writeSourceModeFile(t *testing.T, root string, year string, name string, content string) string {
 	t.Helper()
 	yearDirectory := filepath.Join(root, year)
 	if err := os.MkdirAll(yearDirectory, 0o700); err != nil {
@@ -143,7 +276,7 @@ func writeSourceModeGPX(t *testing.T, root string, year string, name string, con
 	}
 	filePath := filepath.Join(yearDirectory, name)
 	if err := os.WriteFile(filePath, []byte(content), 0o600); err != nil {
-		t.Fatalf("failed to write GPX fixture: %v", err)
+		t.Fatalf("failed to write source fixture: %v", err)
 	}
 	return filePath
 }
@@ -0,0 +1,537 @@
+package sourcesync
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"mystravastats/internal/helpers"
+	"mystravastats/internal/platform/activityprovider"
+	"mystravastats/internal/shared/domain/strava"
+	"mystravastats/internal/shared/infrastructure/localindex"
+)
+
+const (
+	gpxDestinationEnv = "GPX_FILES_PATH"
+	tcxDestinationEnv = "TCX_FILES_PATH"
+
+	stravaArchiveMetadataFile = "activities.csv"
+	// maxArchiveEntryBytes bounds the uncompressed size of one activity file
+	// so a malformed archive cannot exhaust memory.
+	maxArchiveEntryBytes = 256 << 20
+	// MaxArchiveUploadBytes bounds an archive sent in a request body. Larger
+	// exports are imported by path from a source folder.
+	MaxArchiveUploadBytes = maxArchiveEntryBytes
+)
+
+// ErrArchivePathOutsideSources is returned for an archive path that is not
+// inside one of the source folders of the athlete.
+var ErrArchivePathOutsideSources = errors.New("archive path must be inside one of the athlete's FIT, GPX, TCX or JSON folders")
+
+// StravaArchiveImportResult reports the import of a Strava "download your
+// account" archive into the local FIT/GPX/TCX year folders.
+type StravaArchiveImportResult struct {
+	Status               string            `json:"status"`
+	Message              string            `json:"message"`
+	ArchivePath          string            `json:"archivePath"`
+	DestinationPaths     map[string]string `json:"destinationPaths"`
+	MetadataRows         int               `json:"metadataRows"`
+	MetadataMatchedFiles int               `json:"metadataMatchedFiles"`
+	ScannedFiles         int               `json:"scannedFiles"`
+	ImportedFiles        int               `json:"importedFiles"`
+	AlreadyPresentFiles  int               `json:"alreadyPresentFiles"`
+	SkippedFiles         int               `json:"skippedFiles"`
+	InvalidFiles         int               `json:"invalidFiles"`
+	// CreatedYearDirectories lists full paths, since one archive can feed
+	// several source folders.
+	CreatedYearDirectories []string              `json:"createdYearDirectories"`
+	Imported               []ImportedArchiveFile `json:"imported"`
+	Errors                 []string              `json:"errors"`
+
+	importedActivityIDs []int64
+}
+
+type ImportedArchiveFile struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Format      string `json:"format"`
+	Year        string `json:"year"`
+	ActivityID  int64  `json:"activityId"`
+	Name        string `json:"name"`
+	StartDate   string `json:"startDate"`
+}
+
+// archiveTarget is the source folder receiving one file format.
+type archiveTarget struct {
+	format     string
+	configKey  string
+	extension  string
+	path       string
+	configured bool
+	decode     func(filePath string) (*strava.Activity, error)
+}
+
+func ImportStravaArchive(athleteID string, archivePath string) SyncResult {
+	return defaultService.ImportStravaArchive(athleteID, archivePath)
+}
+
+// ResolveArchivePath returns the real path of an archive referenced by a
+// request, which must be inside one of the source folders of the athlete so
+// that the API cannot be used to open any file of the host. Symbolic links are
+// resolved before the check. An empty athleteID selects the default athlete.
+func ResolveArchivePath(athleteID string, archivePath string) (string, error) {
+	roots := athleteWatchRoots(archiveAthlete(athleteID))
+	absolute, err := filepath.Abs(strings.TrimSpace(archivePath))
+	if err != nil || !insideSourceRoots(roots, absolute, false) {
+		return "", ErrArchivePathOutsideSources
+	}
+	resolved, err := filepath.EvalSymlinks(absolute)
+	if err != nil {
+		return "", err
+	}
+	if !insideSourceRoots(roots, resolved, true) {
+		return "", ErrArchivePathOutsideSources
+	}
+	return resolved, nil
+}
+
+func archiveAthlete(athleteID string) string {
+	if athleteID == "" {
+		return activityprovider.DefaultAthlete()
+	}
+	return athleteID
+}
+
+func insideSourceRoots(roots []watchRoot, path string, resolveRoots bool) bool {
+	for _, root := range roots {
+		rootPath, err := filepath.Abs(root.path)
+		if err == nil && resolveRoots {
+			rootPath, err = filepath.EvalSymlinks(rootPath)
+		}
+		if err != nil {
+			continue
+		}
+		relative, err := filepath.Rel(rootPath, path)
+		if err == nil && relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ImportStravaArchive unpacks the activity files of a Strava bulk export into
+// the FIT, GPX or TCX folder of the athlete and writes the metadata of
+// activities.csv next to them as localindex sidecars. Only the provider of
+// that athlete is reloaded.
+func (service *Service) ImportStravaArchive(athleteID string, archivePath string) SyncResult {
+	if !service.beginRun("strava_archive") {
+		result := service.LastResult()
+		result.Status = "running"
+		result.Reason = "strava_archive"
+		result.Message = "Synchronization is already running."
+		return result
+	}
+	defer service.endRun()
+
+	startedAt := service.now()
+	athleteID = archiveAthlete(athleteID)
+	archive := service.importStravaArchive(athleteID, strings.TrimSpace(archivePath))
+	if archive.ImportedFiles > 0 && service.reloadAthlete != nil {
+		service.reloadAthlete(athleteID)
+	}
+	completedAt := service.now()
+	result := SyncResult{
+		Status:      syncStatusFromArchive(archive),
+		Reason:      "strava_archive",
+		Message:     archive.Message,
+		StartedAt:   startedAt.UTC().Format(time.RFC3339),
+		CompletedAt: completedAt.UTC().Format(time.RFC3339),
+		DurationMs:  completedAt.Sub(startedAt).Milliseconds(),
+		Reloaded:    archive.ImportedFiles > 0 && service.reloadAthlete != nil,
+		FIT:         service.LastResult().FIT,
+		Archive:     &archive,
+
+		ImportedActivityIDs: archive.importedActivityIDs,
+	}
+	service.storeLastResult(result)
+	log.Printf("Strava archive import: %s", archive.Message)
+	return result
+}
+
+func (service *Service) importStravaArchive(athleteID string, archivePath string) StravaArchiveImportResult {
+	result := StravaArchiveImportResult{
+		Status:           "failed",
+		ArchivePath:      archivePath,
+		DestinationPaths: map[string]string{},
+	}
+	targets := service.archiveTargets(athleteID)
+	for _, target := range targets {
+		if target.configured {
+			result.DestinationPaths[target.format] = target.path
+		}
+	}
+	if len(result.DestinationPaths) == 0 {
+		result.Status = "not_configured"
+		result.Message = "Strava archive import needs FIT_FILES_PATH, GPX_FILES_PATH or TCX_FILES_PATH."
+		return result
+	}
+	if archivePath == "" {
+		result.Message = "Strava archive path is required."
+		return result
+	}
+
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		result.Message = "Unable to open the Strava archive."
+		result.Errors = append(result.Errors, err.Error())
+		return result
+	}
+	defer reader.Close()
+
+	metadataByFile, rows, err := readStravaArchiveMetadata(&reader.Reader)
+	result.MetadataRows = rows
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+	}
+
+	fingerprints := map[string]map[string]bool{}
+	createdYears := map[string]struct{}{}
+	skippedFormats := map[string]int{}
+	for _, file := range reader.File {
+		format, ok := archiveActivityFormat(file.Name)
+		if !ok || file.FileInfo().IsDir() {
+			continue
+		}
+		result.ScannedFiles++
+		target := targets[format]
+		if !target.configured {
+			result.SkippedFiles++
+			skippedFormats[format]++
+			continue
+		}
+		if _, ok := fingerprints[format]; !ok {
+			fingerprints[format] = service.existingActivityFingerprints(target.path, target.extension, "", target.decode)
+		}
+		metadata, hasMetadata := metadataByFile[path.Clean(file.Name)]
+		if hasMetadata {
+			result.MetadataMatchedFiles++
+		}
+		service.importArchiveFile(file, target, metadata, fingerprints[format], createdYears, &result)
+	}
+
+	for _, format := range []string{"fit", "gpx", "tcx"} {
+		if count := skippedFormats[format]; count > 0 {
+			result.Errors = append(result.Errors, fmt.Sprintf("%d %s file(s) skipped because %s is not configured", count, strings.ToUpper(format), targets[format].configKey))
+		}
+	}
+	result.CreatedYearDirectories = sortedMapKeys(createdYears)
+	result.Status = archiveImportStatus(result)
+	result.Message = archiveImportMessage(result)
+	if len(result.Errors) > 0 {
+		log.Printf("Strava archive import completed with %d error(s)", len(result.Errors))
+	}
+	return result
+}
+
+func (service *Service) importArchiveFile(
+	file *zip.File,
+	target archiveTarget,
+	metadata localindex.Metadata,
+	fingerprints map[string]bool,
+	createdYears map[string]struct{},
+	result *StravaArchiveImportResult,
+) {
+	temporaryPath, err := extractArchiveFile(file, target.format)
+	if err != nil {
+		result.InvalidFiles++
+		result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", file.Name, err))
+		return
+	}
+	defer os.Remove(temporaryPath)
+
+	activity, err := target.decode(temporaryPath)
+	if err != nil {
+		result.InvalidFiles++
+		result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", file.Name, err))
+		return
+	}
+	fingerprint := activityFingerprint(activity)
+	if fingerprint != "" && fingerprints[fingerprint] {
+		result.AlreadyPresentFiles++
+		result.SkippedFiles++
+		return
+	}
+
+	year := activityYear(activity)
+	yearDirectory := filepath.Join(target.path, year)
+	if _, statErr := os.Stat(yearDirectory); errors.Is(statErr, os.ErrNotExist) {
+		createdYears[yearDirectory] = struct{}{}
+	}
+	if err := os.MkdirAll(yearDirectory, 0o755); err != nil {
+		result.InvalidFiles++
+		result.Errors = append(result.Errors, err.Error())
+		return
+	}
+
+	destinationFile, err := service.destinationFilePath(archiveDestinationName(file.Name, metadata, temporaryPath), yearDirectory)
+	if err != nil {
+		result.InvalidFiles++
+		result.Errors = append(result.Errors, err.Error())
+		return
+	}
+	// The sidecar is written first so the first index scan of the new file
+	// already sees the Strava metadata.
+	if metadata != (localindex.Metadata{}) {
+		if err := localindex.WriteMetadata(destinationFile, metadata); err != nil {
+			result.InvalidFiles++
+			result.Errors = append(result.Errors, err.Error())
+			return
+		}
+	}
+	if err := copyFileAtomic(temporaryPath, destinationFile); err != nil {
+		_ = os.Remove(localindex.MetadataPath(destinationFile))
+		result.InvalidFiles++
+		result.Errors = append(result.Errors, err.Error())
+		return
+	}
+
+	result.ImportedFiles++
+	if fingerprint != "" {
+		fingerprints[fingerprint] = true
+	}
+	metadata.Apply(activity)
+	result.importedActivityIDs = append(result.importedActivityIDs, activity.Id)
+	if len(result.Imported) < 25 {
+		result.Imported = append(result.Imported, ImportedArchiveFile{
+			Source:      file.Name,
+			Destination: destinationFile,
+			Format:      target.format,
+			Year:        year,
+			ActivityID:  activity.Id,
+			Name:        activity.Name,
+			StartDate:   helpers.FirstNonEmpty(activity.StartDateLocal, activity.StartDate),
+		})
+	}
+}
+
+func (service *Service) archiveTargets(athleteID string) map[string]archiveTarget {
+	targets := map[string]archiveTarget{
+		"fit": {format: "fit", configKey: fitDestinationEnv, extension: ".fit", decode: func(filePath string) (*strava.Activity, error) {
+			return service.decodeFIT(filePath, 0)
+		}},
+		"gpx": {format: "gpx", configKey: gpxDestinationEnv, extension: ".gpx", decode: func(filePath string) (*strava.Activity, error) {
+			return service.decodeGPX(filePath, 0, 0)
+		}},
+		"tcx": {format: "tcx", configKey: tcxDestinationEnv, extension: ".tcx", decode: func(filePath string) (*strava.Activity, error) {
+			return service.decodeTCX(filePath, 0)
+		}},
+	}
+	var roots map[string]string
+	if service.sourceRoots != nil {
+		roots, _ = service.sourceRoots(athleteID)
+	}
+	for format, target := range targets {
+		target.path = strings.TrimSpace(roots[format])
+		target.configured = target.path != ""
+		targets[format] = target
+	}
+	return targets
+}
+
+// readStravaArchiveMetadata maps the archive file names listed in
+// activities.csv to their Strava metadata. Columns are looked up by their
+// English header, the first one winning when Strava repeats a header.
+func readStravaArchiveMetadata(reader *zip.Reader) (map[string]localindex.Metadata, int, error) {
+	metadataByFile := map[string]localindex.Metadata{}
+	var csvFile *zip.File
+	for _, file := range reader.File {
+		if path.Base(file.Name) == stravaArchiveMetadataFile && (csvFile == nil || len(file.Name) < len(csvFile.Name)) {
+			csvFile = file
+		}
+	}
+	if csvFile == nil {
+		return metadataByFile, 0, errors.New("activities.csv not found; files are imported without Strava metadata")
+	}
+
+	content, err := csvFile.Open()
+	if err != nil {
+		return metadataByFile, 0, err
+	}
+	defer content.Close()
+
+	csvReader := csv.NewReader(content)
+	csvReader.FieldsPerRecord = -1
+	csvReader.LazyQuotes = true
+	header, err := csvReader.Read()
+	if err != nil {
+		return metadataByFile, 0, fmt.Errorf("activities.csv: %w", err)
+	}
+	columns := map[string]int{}
+	for index, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		if _, exists := columns[name]; !exists {
+			columns[name] = index
+		}
+	}
+	if _, ok := columns["Filename"]; !ok {
+		return metadataByFile, 0, errors.New("activities.csv has no Filename column; export the archive with English as account language to import Strava metadata")
+	}
+
+	rows := 0
+	for {
+		record, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return metadataByFile, rows, fmt.Errorf("activities.csv: %w", err)
+		}
+		rows++
+		column := func(name string) string {
+			index, ok := columns[name]
+			if !ok || index >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[index])
+		}
+		fileName := column("Filename")
+		if fileName == "" {
+			continue
+		}
+		activityID, _ := strconv.ParseInt(column("Activity ID"), 10, 64)
+		metadataByFile[path.Clean(fileName)] = localindex.Metadata{
+			ActivityID:  activityID,
+			Name:        column("Activity Name"),
+			SportType:   strings.NewReplacer(" ", "", "-", "").Replace(column("Activity Type")),
+			Description: column("Activity Description"),
+			Gear:        column("Activity Gear"),
+			Commute:     parseArchiveBool(column("Commute")),
+		}
+	}
+	return metadataByFile, rows, nil
+}
+
+// archiveActivityFormat recognizes activity files, gzipped or not.
+func archiveActivityFormat(name string) (string, bool) {
+	lower := strings.ToLower(strings.TrimSuffix(strings.ToLower(name), ".gz"))
+	for _, format := range []string{"fit", "gpx", "tcx"} {
+		if strings.HasSuffix(lower, "."+format) {
+			return format, true
+		}
+	}
+	return "", false
+}
+
+// extractArchiveFile writes an archive entry to a temporary file, gunzipping
+// it when needed. Strava pads some GPX/TCX exports with leading whitespace,
+// which XML decoders reject, so it is trimmed.
+func extractArchiveFile(file *zip.File, format string) (string, error) {
+	content, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer content.Close()
+
+	var reader io.Reader = content
+	if strings.EqualFold(path.Ext(file.Name), ".gz") {
+		gzipReader, err := gzip.NewReader(content)
+		if err != nil {
+			return "", err
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+	data, err := io.ReadAll(io.LimitReader(reader, maxArchiveEntryBytes+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxArchiveEntryBytes {
+		return "", fmt.Errorf("file is larger than %d MiB once uncompressed", maxArchiveEntryBytes>>20)
+	}
+	if format != "fit" {
+		data = bytes.TrimLeft(data, " \t\r\n")
+	}
+
+	temporary, err := os.CreateTemp("", "strava-archive-*."+format)
+	if err != nil {
+		return "", err
+	}
+	_, writeErr := temporary.Write(data)
+	closeErr := temporary.Close()
+	if writeErr != nil || closeErr != nil {
+		_ = os.Remove(temporary.Name())
+		return "", errors.Join(writeErr, closeErr)
+	}
+	return temporary.Name(), nil
+}
+
+// archiveDestinationName names imported files after the Strava activity id
+// when activities.csv provides it. destinationFilePath only uses the base
+// name of the returned path and hashes temporaryPath on collisions, so the
+// name is joined to the temporary directory.
+func archiveDestinationName(entryName string, metadata localindex.Metadata, temporaryPath string) string {
+	base := strings.TrimSuffix(path.Base(entryName), path.Ext(entryName))
+	if !strings.EqualFold(path.Ext(entryName), ".gz") {
+		base = path.Base(entryName)
+	}
+	if metadata.ActivityID > 0 {
+		base = strconv.FormatInt(metadata.ActivityID, 10) + path.Ext(base)
+	}
+	return filepath.Join(filepath.Dir(temporaryPath), base)
+}
+
+func parseArchiveBool(value string) bool {
+	if parsed, err := strconv.ParseBool(value); err == nil {
+		return parsed
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	return err == nil && number > 0
+}
+
+func syncStatusFromArchive(result StravaArchiveImportResult) string {
+	switch result.Status {
+	case "failed":
+		return "failed"
+	case "not_configured":
+		return "skipped"
+	default:
+		return "completed"
+	}
+}
+
+func archiveImportStatus(result StravaArchiveImportResult) string {
+	if result.ScannedFiles == 0 {
+		return "no_files"
+	}
+	if result.ImportedFiles > 0 {
+		return "imported"
+	}
+	if result.InvalidFiles > 0 && result.InvalidFiles == result.ScannedFiles {
+		return "failed"
+	}
+	return "up_to_date"
+}
+
+func archiveImportMessage(result StravaArchiveImportResult) string {
+	switch result.Status {
+	case "imported":
+		return fmt.Sprintf("%d activity file(s) imported from the Strava archive, %d with Strava metadata.", result.ImportedFiles, result.MetadataMatchedFiles)
+	case "up_to_date":
+		return fmt.Sprintf("%d activity file(s) from the Strava archive already present.", result.AlreadyPresentFiles)
+	case "no_files":
+		return "The Strava archive contains no FIT, GPX or TCX activity file."
+	default:
+		return "No activity file of the Strava archive could be imported."
+	}
+}
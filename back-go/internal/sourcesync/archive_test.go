@@ -0,0 +1,230 @@
+package sourcesync
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mystravastats/internal/shared/domain/strava"
+	"mystravastats/internal/shared/infrastructure/localindex"
+)
+
+func TestImportStravaArchive_PlacesFilesInYearDirectoriesWithMetadata(t *testing.T) {
+	// GIVEN
+	archivePath := writeStravaArchive(t, map[string][]byte{
+		"activities.csv": []byte("Activity ID,Activity Date,Activity Name,Activity Type,Activity Description,Distance,Commute,Activity Gear,Filename,Distance\n" +
+			"111,\"May 1, 2025, 8:00:00 AM\",Col de la Croix de Fer,Gravel Ride,\"Long day, \"\"epic\"\"\",82.5,false,Canyon Grail,activities/111.fit.gz,82500\n" +
+			"222,\"Mar 2, 2024, 7:00:00 AM\",Morning Run,Run,,10.1,1,Pegasus 40,activities/222.gpx,10100\n" +
+			"333,\"Jan 3, 2024, 7:00:00 AM\",Old ride,Ride,,20,false,,activities/333.tcx.gz,20000\n"),
+		"activities/111.fit.gz": gzipBytes(t, []byte("2025-05-01T08:00:00Z")),
+		"activities/222.gpx":    []byte("\n  2024-03-02T07:00:00Z"),
+		"activities/333.tcx.gz": gzipBytes(t, []byte("2024-01-03T07:00:00Z")),
+		"media/photo.jpg":       []byte("jpg"),
+	})
+	fitDirectory := t.TempDir()
+	gpxDirectory := t.TempDir()
+	reloadCount := 0
+	service := testArchiveService(fitDirectory, gpxDirectory, func() { reloadCount++ })
+
+	// WHEN
+	result := service.ImportStravaArchive("alice", archivePath)
+
+	// THEN
+	if result.Status != "completed" || result.Reason != "strava_archive" || result.Archive == nil {
+		t.Fatalf("expected completed archive import, got %#v", result)
+	}
+	archive := result.Archive
+	if archive.Status != "imported" || archive.ImportedFiles != 2 || archive.ScannedFiles != 3 || archive.SkippedFiles != 1 {
+		t.Fatalf("expected 2 imported files and the TCX skipped, got %#v", archive)
+	}
+	if archive.MetadataRows != 3 || archive.MetadataMatchedFiles != 2 {
+		t.Fatalf("expected CSV metadata to match both imported files, got rows=%d matched=%d", archive.MetadataRows, archive.MetadataMatchedFiles)
+	}
+	if len(archive.Errors) != 1 || !strings.Contains(archive.Errors[0], "TCX_FILES_PATH") {
+		t.Fatalf("expected one error about the unconfigured TCX folder, got %#v", archive.Errors)
+	}
+	if reloadCount != 1 || !result.Reloaded {
+		t.Fatalf("expected one provider reload, got reloadCount=%d reloaded=%t", reloadCount, result.Reloaded)
+	}
+
+	fitFile := filepath.Join(fitDirectory, "2025", "111.fit")
+	if content, err := os.ReadFile(fitFile); err != nil || string(content) != "2025-05-01T08:00:00Z" {
+		t.Fatalf("expected gunzipped FIT in its year directory, got %q (%v)", content, err)
+	}
+	metadata, found, err := localindex.ReadMetadata(fitFile)
+	if err != nil || !found {
+		t.Fatalf("expected FIT metadata sidecar, found=%t err=%v", found, err)
+	}
+	expected := localindex.Metadata{ActivityID: 111, Name: "Col de la Croix de Fer", SportType: "GravelRide", Description: "Long day, \"epic\"", Gear: "Canyon Grail"}
+	if metadata != expected {
+		t.Fatalf("expected %#v, got %#v", expected, metadata)
+	}
+
+	gpxFile := filepath.Join(gpxDirectory, "2024", "222.gpx")
+	if content, err := os.ReadFile(gpxFile); err != nil || string(content) != "2024-03-02T07:00:00Z" {
+		t.Fatalf("expected trimmed GPX in its year directory, got %q (%v)", content, err)
+	}
+	if metadata, _, _ := localindex.ReadMetadata(gpxFile); !metadata.Commute || metadata.Gear != "Pegasus 40" {
+		t.Fatalf("expected commute and gear on the GPX sidecar, got %#v", metadata)
+	}
+}
+
+func TestImportStravaArchive_SkipsActivitiesAlreadyPresent(t *testing.T) {
+	// GIVEN
+	archivePath := writeStravaArchive(t, map[string][]byte{
+		"activities/111.fit.gz": gzipBytes(t, []byte("2025-05-01T08:00:00Z")),
+	})
+	fitDirectory := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(fitDirectory, "2025"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(fitDirectory, "2025", "garmin.fit"), []byte("2025-05-01T08:00:00Z"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	reloadCount := 0
+	service := testArchiveService(fitDirectory, "", func() { reloadCount++ })
+
+	// WHEN
+	result := service.ImportStravaArchive("alice", archivePath)
+
+	// THEN
+	if result.Archive.Status != "up_to_date" || result.Archive.AlreadyPresentFiles != 1 || result.Archive.ImportedFiles != 0 {
+		t.Fatalf("expected archive activity to be recognized as present, got %#v", result.Archive)
+	}
+	if reloadCount != 0 || result.Reloaded {
+		t.Fatalf("expected no reload without imported files, got reloadCount=%d", reloadCount)
+	}
+	if len(result.Archive.Errors) != 1 || !strings.Contains(result.Archive.Errors[0], "activities.csv not found") {
+		t.Fatalf("expected a note about the missing activities.csv, got %#v", result.Archive.Errors)
+	}
+}
+
+func TestImportStravaArchive_RequiresAConfiguredDestinationForTheAthlete(t *testing.T) {
+	// GIVEN
+	service := testArchiveService(t.TempDir(), "", func() {})
+
+	// WHEN
+	result := service.ImportStravaArchive("bob", "/tmp/export.zip")
+
+	// THEN
+	if result.Status != "skipped" || result.Archive.Status != "not_configured" {
+		t.Fatalf("expected not configured archive import, got %s/%s", result.Status, result.Archive.Status)
+	}
+}
+
+func TestResolveArchivePath_OnlyAcceptsArchivesInsideSourceFolders(t *testing.T) {
+	// GIVEN
+	fitDirectory := t.TempDir()
+	outsideDirectory := t.TempDir()
+	t.Setenv("FIT_FILES_PATH", fitDirectory)
+	inside := filepath.Join(fitDirectory, "export.zip")
+	outside := filepath.Join(outsideDirectory, "export.zip")
+	link := filepath.Join(fitDirectory, "link.zip")
+	for _, path := range []string{inside, outside} {
+		if err := os.WriteFile(path, []byte("zip"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(outside, link); err != nil {
+		t.Fatal(err)
+	}
+
+	// WHEN
+	resolved, insideErr := ResolveArchivePath("", inside)
+	_, outsideErr := ResolveArchivePath("", outside)
+	_, traversalErr := ResolveArchivePath("", filepath.Join(fitDirectory, "..", filepath.Base(outsideDirectory), "export.zip"))
+	_, linkErr := ResolveArchivePath("", link)
+	_, unknownAthleteErr := ResolveArchivePath("unknown", inside)
+
+	// THEN
+	if insideErr != nil || filepath.Base(resolved) != "export.zip" {
+		t.Fatalf("expected the archive of the FIT folder to be accepted, got %q (%v)", resolved, insideErr)
+	}
+	for name, err := range map[string]error{"outside": outsideErr, "traversal": traversalErr, "symlink": linkErr, "unknown athlete": unknownAthleteErr} {
+		if err != ErrArchivePathOutsideSources {
+			t.Fatalf("expected the %s path to be rejected, got %v", name, err)
+		}
+	}
+}
+
+// testArchiveService decodes fixtures whose content is the activity start date.
+// Leading whitespace is rejected like XML decoders do. Only the athlete "alice"
+// has local folders.
+func testArchiveService(fitDirectory string, gpxDirectory string, reload func()) *Service {
+	decode := func(filePath string) (*strava.Activity, error) {
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		if len(content) == 0 || strings.TrimSpace(string(content)) != string(content) {
+			return nil, fmt.Errorf("invalid fixture %q", content)
+		}
+		return &strava.Activity{Type: "Ride", StartDate: string(content), Distance: 1_000, ElapsedTime: 600}, nil
+	}
+	service := testService(fitDirectory, "", func(filePath string, _ int64) (*strava.Activity, error) {
+		return decode(filePath)
+	}, reload)
+	service.sourceRoots = func(athleteID string) (map[string]string, bool) {
+		if athleteID != "alice" {
+			return map[string]string{"strava": "strava-cache"}, true
+		}
+		roots := map[string]string{}
+		if fitDirectory != "" {
+			roots["fit"] = fitDirectory
+		}
+		if gpxDirectory != "" {
+			roots["gpx"] = gpxDirectory
+		}
+		return roots, true
+	}
+	service.reloadAthlete = func(athleteID string) {
+		if athleteID == "alice" {
+			reload()
+		}
+	}
+	service.decodeGPX = func(filePath string, _ int64, _ int) (*strava.Activity, error) {
+		return decode(filePath)
+	}
+	return service
+}
+
+func writeStravaArchive(t *testing.T, files map[string][]byte) string {
+	t.Helper()
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	for name, content := range files {
+		entry, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := entry.Write(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	archivePath := filepath.Join(t.TempDir(), "export_12345.zip")
+	if err := os.WriteFile(archivePath, buffer.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return archivePath
+}
+
+func gzipBytes(t *testing.T, content []byte) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err := writer.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
@@ -0,0 +1,152 @@
+package sourcesync
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"mystravastats/internal/platform/cachewrite"
+)
+
+const (
+	syncHistoryFileName     = "source-sync-history.json"
+	syncHistoryLimitEnv     = "SOURCE_SYNC_HISTORY_LIMIT"
+	defaultSyncHistoryLimit = 100
+	maxHistoryErrors        = 50
+)
+
+// SyncHistoryEntry is one finished synchronization. Errors gathers the errors
+// reported by every step of the run.
+type SyncHistoryEntry struct {
+	SyncResult
+	Errors []string `json:"errors"`
+}
+
+// syncHistory keeps the latest synchronization results in a JSON file of the
+// cache root, newest last.
+type syncHistory struct {
+	mutex   sync.Mutex
+	path    string
+	limit   int
+	entries []SyncHistoryEntry
+	loaded  bool
+}
+
+func newSyncHistory(path string, limit int) *syncHistory {
+	if limit <= 0 {
+		limit = defaultSyncHistoryLimit
+	}
+	return &syncHistory{path: path, limit: limit}
+}
+
+func History(limit int) []SyncHistoryEntry {
+	return defaultService.History(limit)
+}
+
+// History returns up to limit entries, newest first. A limit of zero or less
+// returns the whole history.
+func (service *Service) History(limit int) []SyncHistoryEntry {
+	if service.history == nil {
+		return []SyncHistoryEntry{}
+	}
+	return service.history.list(limit)
+}
+
+func (history *syncHistory) append(result SyncResult) {
+	history.mutex.Lock()
+	defer history.mutex.Unlock()
+	history.load()
+
+	history.entries = append(history.entries, SyncHistoryEntry{SyncResult: result, Errors: syncResultErrors(result)})
+	if overflow := len(history.entries) - history.limit; overflow > 0 {
+		history.entries = append([]SyncHistoryEntry(nil), history.entries[overflow:]...)
+	}
+	if err := history.save(); err != nil {
+		log.Printf("Unable to save source synchronization history to %s: %v", history.path, err)
+	}
+}
+
+func (history *syncHistory) list(limit int) []SyncHistoryEntry {
+	history.mutex.Lock()
+	defer history.mutex.Unlock()
+	history.load()
+
+	count := len(history.entries)
+	if limit > 0 && limit < count {
+		count = limit
+	}
+	entries := make([]SyncHistoryEntry, 0, count)
+	for index := len(history.entries) - 1; index >= 0 && len(entries) < count; index-- {
+		entries = append(entries, history.entries[index])
+	}
+	return entries
+}
+
+func (history *syncHistory) load() {
+	if history.loaded {
+		return
+	}
+	history.loaded = true
+	if history.path == "" {
+		return
+	}
+	data, err := os.ReadFile(history.path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("Unable to read source synchronization history from %s: %v", history.path, err)
+		}
+		return
+	}
+	if err := json.Unmarshal(data, &history.entries); err != nil {
+		log.Printf("Unable to parse source synchronization history from %s: %v", history.path, err)
+		history.entries = nil
+	}
+	if overflow := len(history.entries) - history.limit; overflow > 0 {
+		history.entries = history.entries[overflow:]
+	}
+}
+
+func (history *syncHistory) save() error {
+	if history.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(history.path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(history.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return cachewrite.WriteFile(history.path, data, 0o644)
+}
+
+func syncResultErrors(result SyncResult) []string {
+	errs := make([]string, 0)
+	seen := map[string]struct{}{}
+	add := func(values []string) {
+		for _, value := range values {
+			if _, duplicate := seen[value]; duplicate || len(errs) >= maxHistoryErrors {
+				continue
+			}
+			seen[value] = struct{}{}
+			errs = append(errs, value)
+		}
+	}
+	// Watcher and archive runs carry the FIT block of the previous run.
+	if result.Watch == nil && result.Archive == nil {
+		add(result.FIT.Errors)
+		if result.FIT.DeviceSync != nil {
+			add(result.FIT.DeviceSync.Errors)
+		}
+	}
+	if result.Archive != nil {
+		add(result.Archive.Errors)
+	}
+	for _, refresh := range result.Strava {
+		add(refresh.Errors)
+	}
+	return errs
+}
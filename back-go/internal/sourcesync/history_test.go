@@ -0,0 +1,54 @@
+package sourcesync
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"mystravastats/internal/shared/infrastructure/stravaapi"
+)
+
+func TestSyncHistory_KeepsRollingLimitAcrossReloads(t *testing.T) {
+	// GIVEN
+	path := filepath.Join(t.TempDir(), syncHistoryFileName)
+	history := newSyncHistory(path, 2)
+
+	// WHEN
+	history.append(SyncResult{Reason: "first", Status: "completed"})
+	history.append(SyncResult{Reason: "second", Status: "completed"})
+	history.append(SyncResult{Reason: "third", Status: "failed"})
+	entries := newSyncHistory(path, 2).list(0)
+
+	// THEN
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 persisted entries, got %d", len(entries))
+	}
+	if entries[0].Reason != "third" || entries[1].Reason != "second" {
+		t.Fatalf("expected newest entries first, got %s then %s", entries[0].Reason, entries[1].Reason)
+	}
+	if limited := history.list(1); len(limited) != 1 || limited[0].Reason != "third" {
+		t.Fatalf("expected limit to keep the newest entry, got %#v", limited)
+	}
+}
+
+func TestSyncHistory_GathersRunErrors(t *testing.T) {
+	// GIVEN
+	history := newSyncHistory("", 10)
+	result := SyncResult{
+		Reason: "scheduled",
+		FIT: FITImportResult{
+			Errors:     []string{"broken.fit: invalid header"},
+			DeviceSync: &FITDeviceSyncResult{Errors: []string{"device busy", "broken.fit: invalid header"}},
+		},
+		Strava: []stravaapi.RefreshResult{{Status: "rate_limited", Errors: []string{"rate limit reached"}}},
+	}
+
+	// WHEN
+	history.append(result)
+
+	// THEN
+	expected := []string{"broken.fit: invalid header", "device busy", "rate limit reached"}
+	if errors := history.list(0)[0].Errors; !reflect.DeepEqual(errors, expected) {
+		t.Fatalf("expected errors %v, got %v", expected, errors)
+	}
+}
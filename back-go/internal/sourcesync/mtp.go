@@ -0,0 +1,180 @@
+package sourcesync
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"mystravastats/internal/platform/runtimeconfig"
+)
+
+const garminMTPEnv = "GARMIN_MTP_ENABLED"
+
+// mtpDevice is a Garmin watch reached over MTP instead of a mounted
+// filesystem.
+type mtpDevice interface {
+	Name() string
+	ListActivityFiles() ([]mtpFile, error)
+	ReadFile(file mtpFile, destination io.Writer) error
+	Close() error
+}
+
+type mtpFile struct {
+	handle uint32
+	Name   string
+	Size   int64
+}
+
+// openConfiguredMTPDevices opens the Garmin watches reachable over MTP. The
+// backend is opt-in: it claims the USB interface, which other MTP clients such
+// as gvfs may be holding.
+func openConfiguredMTPDevices() ([]mtpDevice, error) {
+	if !runtimeconfig.BoolValue(garminMTPEnv, false) {
+		return nil, nil
+	}
+	return openUSBMTPDevices()
+}
+
+// syncMTPToInbox copies new activity files from the first Garmin watch that
+// answers over MTP. It returns false when no MTP device was found.
+func (service *Service) syncMTPToInbox(result *FITDeviceSyncResult, destinationPath string) bool {
+	devices, err := service.openMTPDevices()
+	defer func() {
+		for _, device := range devices {
+			_ = device.Close()
+		}
+	}()
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+	}
+	if len(devices) == 0 {
+		return false
+	}
+
+	device := devices[0]
+	result.Backend = "mtp"
+	result.Device = device.Name()
+	result.SourcePath = mtpActivityPath(device.Name())
+	if err := os.MkdirAll(result.InboxPath, 0o755); err != nil {
+		result.Status = "failed"
+		result.Message = "Unable to create FIT inbox directory."
+		result.Errors = append(result.Errors, err.Error())
+		return true
+	}
+	files, err := device.ListActivityFiles()
+	if err != nil {
+		result.Status = "failed"
+		result.Message = "Unable to list Garmin activity files over MTP."
+		result.Errors = append(result.Errors, err.Error())
+		return true
+	}
+
+	result.Status = "ok"
+	result.ScannedFiles = len(files)
+	var fingerprints map[string]bool
+	for _, file := range files {
+		source := result.SourcePath + "/" + file.Name
+		preferred := filepath.Join(result.InboxPath, inboxFileName(file.Name))
+		if info, statErr := os.Stat(preferred); statErr == nil && !info.IsDir() && info.Size() == file.Size {
+			result.AlreadyPresentFiles++
+			result.SkippedFiles++
+			continue
+		}
+		if fingerprints == nil {
+			fingerprints = service.mtpKnownFingerprints(destinationPath, result.InboxPath)
+		}
+
+		destination, copied, copyErr := service.copyMTPFileToInbox(device, file, preferred, fingerprints)
+		if copyErr != nil {
+			result.InvalidFiles++
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", source, copyErr))
+			continue
+		}
+		if !copied {
+			result.AlreadyPresentFiles++
+			result.SkippedFiles++
+			continue
+		}
+		result.CopiedFiles++
+		if len(result.Copied) < 25 {
+			result.Copied = append(result.Copied, FITDeviceSyncFile{
+				Source:      source,
+				Destination: destination,
+			})
+		}
+	}
+	if result.InvalidFiles > 0 && result.InvalidFiles == result.ScannedFiles {
+		result.Status = "failed"
+	}
+	result.Message = garminDeviceSyncMessage(*result)
+	return true
+}
+
+// copyMTPFileToInbox downloads a watch file next to its inbox destination and
+// keeps it only when its activity is not already in the library or the inbox.
+func (service *Service) copyMTPFileToInbox(device mtpDevice, file mtpFile, preferred string, fingerprints map[string]bool) (string, bool, error) {
+	tempPath := preferred + ".part"
+	out, err := os.OpenFile(tempPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return "", false, err
+	}
+	readErr := device.ReadFile(file, out)
+	closeErr := out.Close()
+	if err := errors.Join(readErr, closeErr); err != nil {
+		_ = os.Remove(tempPath)
+		return "", false, err
+	}
+
+	activity, err := service.decodeFIT(tempPath, 0)
+	if err != nil {
+		_ = os.Remove(tempPath)
+		return "", false, err
+	}
+	fingerprint := activityFingerprint(activity)
+	if fingerprint != "" && fingerprints[fingerprint] {
+		_ = os.Remove(tempPath)
+		return "", false, nil
+	}
+
+	destination, err := availableInboxDestination(tempPath, preferred)
+	if err != nil {
+		_ = os.Remove(tempPath)
+		return "", false, err
+	}
+	if err := os.Rename(tempPath, destination); err != nil {
+		_ = os.Remove(tempPath)
+		return "", false, err
+	}
+	if fingerprint != "" {
+		fingerprints[fingerprint] = true
+	}
+	return destination, true, nil
+}
+
+func (service *Service) mtpKnownFingerprints(destinationPath string, inboxPath string) map[string]bool {
+	fingerprints := map[string]bool{}
+	if strings.TrimSpace(destinationPath) != "" {
+		fingerprints = service.existingFITFingerprints(destinationPath, "", nil)
+	}
+	if strings.TrimSpace(destinationPath) == "" || !sameOrInside(inboxPath, destinationPath) {
+		for fingerprint := range service.existingFITFingerprints(inboxPath, "", nil) {
+			fingerprints[fingerprint] = true
+		}
+	}
+	return fingerprints
+}
+
+func inboxFileName(name string) string {
+	base := filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	if strings.TrimSpace(base) == "" || base == "." || base == ".." || base == string(filepath.Separator) {
+		return "activity.fit"
+	}
+	return base
+}
+
+func mtpActivityPath(deviceName string) string {
+	return "mtp://" + deviceName + "/GARMIN/Activity"
+}
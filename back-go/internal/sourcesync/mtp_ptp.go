@@ -0,0 +1,318 @@
+package sourcesync
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+// MTP extends PTP (ISO 15740). Only the read-only operations needed to copy
+// activity files are implemented.
+
+const (
+	ptpContainerCommand  = 1
+	ptpContainerData     = 2
+	ptpContainerResponse = 3
+	ptpHeaderSize        = 12
+
+	ptpOperationOpenSession      = 0x1002
+	ptpOperationCloseSession     = 0x1003
+	ptpOperationGetStorageIDs    = 0x1004
+	ptpOperationGetObjectHandles = 0x1007
+	ptpOperationGetObjectInfo    = 0x1008
+	ptpOperationGetObject        = 0x1009
+
+	ptpResponseOK                 = 0x2001
+	ptpResponseSessionAlreadyOpen = 0x201E
+
+	ptpFormatAssociation = 0x3001
+	ptpRootFolder        = 0xFFFFFFFF
+
+	// ptpObjectInfoFilenameOffset skips the fixed-size fields of an
+	// ObjectInfo dataset.
+	ptpObjectInfoFilenameOffset = 52
+)
+
+// mtpTransport moves PTP containers over a pair of bulk endpoints. Read
+// returns at most one container, possibly split across several calls.
+type mtpTransport interface {
+	Write(data []byte) error
+	Read(buffer []byte) (int, error)
+	Close() error
+}
+
+type ptpSession struct {
+	name          string
+	transport     mtpTransport
+	transactionID uint32
+	buffer        []byte
+}
+
+type ptpObjectInfo struct {
+	format uint16
+	size   uint32
+	name   string
+}
+
+func newPTPSession(name string, transport mtpTransport) (*ptpSession, error) {
+	session := &ptpSession{
+		name:      name,
+		transport: transport,
+		buffer:    make([]byte, 64*1024),
+	}
+	code, err := session.transaction(ptpOperationOpenSession, nil, 1)
+	if err != nil && code != ptpResponseSessionAlreadyOpen {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (session *ptpSession) Name() string {
+	return session.name
+}
+
+func (session *ptpSession) Close() error {
+	_, closeSessionErr := session.transaction(ptpOperationCloseSession, nil)
+	return errors.Join(closeSessionErr, session.transport.Close())
+}
+
+// ListActivityFiles returns the FIT files of GARMIN/Activity on the first
+// storage holding that folder.
+func (session *ptpSession) ListActivityFiles() ([]mtpFile, error) {
+	storages, err := session.storageIDs()
+	if err != nil {
+		return nil, err
+	}
+	for _, storage := range storages {
+		garmin, found, err := session.findFolder(storage, ptpRootFolder, "GARMIN")
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			continue
+		}
+		activity, found, err := session.findFolder(storage, garmin, "Activity")
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			continue
+		}
+		return session.fitFiles(storage, activity)
+	}
+	return nil, errors.New("GARMIN/Activity folder not found on MTP device")
+}
+
+func (session *ptpSession) ReadFile(file mtpFile, destination io.Writer) error {
+	_, err := session.transaction(ptpOperationGetObject, destination, file.handle)
+	return err
+}
+
+func (session *ptpSession) storageIDs() ([]uint32, error) {
+	var data dataBuffer
+	if _, err := session.transaction(ptpOperationGetStorageIDs, &data); err != nil {
+		return nil, err
+	}
+	return ptpUint32Array(data)
+}
+
+func (session *ptpSession) objectHandles(storage uint32, parent uint32) ([]uint32, error) {
+	var data dataBuffer
+	if _, err := session.transaction(ptpOperationGetObjectHandles, &data, storage, 0, parent); err != nil {
+		return nil, err
+	}
+	return ptpUint32Array(data)
+}
+
+func (session *ptpSession) objectInfo(handle uint32) (ptpObjectInfo, error) {
+	var data dataBuffer
+	if _, err := session.transaction(ptpOperationGetObjectInfo, &data, handle); err != nil {
+		return ptpObjectInfo{}, err
+	}
+	if len(data) < ptpObjectInfoFilenameOffset+1 {
+		return ptpObjectInfo{}, errors.New("truncated MTP object info")
+	}
+	name, err := ptpString(data[ptpObjectInfoFilenameOffset:])
+	if err != nil {
+		return ptpObjectInfo{}, err
+	}
+	return ptpObjectInfo{
+		format: binary.LittleEndian.Uint16(data[4:6]),
+		size:   binary.LittleEndian.Uint32(data[8:12]),
+		name:   name,
+	}, nil
+}
+
+func (session *ptpSession) findFolder(storage uint32, parent uint32, name string) (uint32, bool, error) {
+	handles, err := session.objectHandles(storage, parent)
+	if err != nil {
+		return 0, false, err
+	}
+	for _, handle := range handles {
+		info, err := session.objectInfo(handle)
+		if err != nil {
+			return 0, false, err
+		}
+		if info.format == ptpFormatAssociation && strings.EqualFold(info.name, name) {
+			return handle, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+func (session *ptpSession) fitFiles(storage uint32, folder uint32) ([]mtpFile, error) {
+	handles, err := session.objectHandles(storage, folder)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]mtpFile, 0, len(handles))
+	for _, handle := range handles {
+		info, err := session.objectInfo(handle)
+		if err != nil {
+			return nil, err
+		}
+		if info.format == ptpFormatAssociation || !strings.HasSuffix(strings.ToLower(info.name), ".fit") {
+			continue
+		}
+		files = append(files, mtpFile{handle: handle, Name: info.name, Size: int64(info.size)})
+	}
+	return files, nil
+}
+
+// transaction runs one operation. The data phase, when the device sends one,
+// is copied to data. The response code is returned even on failure.
+func (session *ptpSession) transaction(operation uint16, data io.Writer, params ...uint32) (uint16, error) {
+	transactionID := session.transactionID
+	session.transactionID++
+
+	command := make([]byte, ptpHeaderSize+4*len(params))
+	putPTPHeader(command, ptpContainerCommand, operation, transactionID)
+	for index, param := range params {
+		binary.LittleEndian.PutUint32(command[ptpHeaderSize+4*index:], param)
+	}
+	if err := session.transport.Write(command); err != nil {
+		return 0, err
+	}
+
+	containerType, code, payload, remaining, err := session.readContainerStart()
+	if err != nil {
+		return 0, err
+	}
+	if containerType == ptpContainerData {
+		if data == nil {
+			data = io.Discard
+		}
+		if err := session.copyContainerPayload(data, payload, remaining); err != nil {
+			return 0, err
+		}
+		containerType, code, payload, remaining, err = session.readContainerStart()
+		if err != nil {
+			return 0, err
+		}
+	}
+	if containerType != ptpContainerResponse {
+		return 0, fmt.Errorf("unexpected MTP container type %d", containerType)
+	}
+	if err := session.copyContainerPayload(io.Discard, payload, remaining); err != nil {
+		return code, err
+	}
+	if code != ptpResponseOK {
+		return code, fmt.Errorf("MTP operation 0x%04X failed with response 0x%04X", operation, code)
+	}
+	return code, nil
+}
+
+// readContainerStart reads the first chunk of a container. Zero-length
+// packets ending the previous transfer are skipped.
+func (session *ptpSession) readContainerStart() (uint16, uint16, []byte, int, error) {
+	for attempt := 0; attempt < 3; attempt++ {
+		n, err := session.transport.Read(session.buffer)
+		if err != nil {
+			return 0, 0, nil, 0, err
+		}
+		if n == 0 {
+			continue
+		}
+		if n < ptpHeaderSize {
+			return 0, 0, nil, 0, errors.New("truncated MTP container")
+		}
+		length := int(binary.LittleEndian.Uint32(session.buffer[0:4]))
+		if length < ptpHeaderSize || n > length {
+			return 0, 0, nil, 0, fmt.Errorf("invalid MTP container length %d", length)
+		}
+		containerType := binary.LittleEndian.Uint16(session.buffer[4:6])
+		code := binary.LittleEndian.Uint16(session.buffer[6:8])
+		return containerType, code, session.buffer[ptpHeaderSize:n], length - n, nil
+	}
+	return 0, 0, nil, 0, errors.New("MTP device sent no container")
+}
+
+func (session *ptpSession) copyContainerPayload(destination io.Writer, first []byte, remaining int) error {
+	if _, err := destination.Write(first); err != nil {
+		return err
+	}
+	for remaining > 0 {
+		n, err := session.transport.Read(session.buffer[:min(remaining, len(session.buffer))])
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return errors.New("MTP transfer ended before the container")
+		}
+		if _, err := destination.Write(session.buffer[:n]); err != nil {
+			return err
+		}
+		remaining -= n
+	}
+	return nil
+}
+
+func putPTPHeader(container []byte, containerType uint16, code uint16, transactionID uint32) {
+	binary.LittleEndian.PutUint32(container[0:4], uint32(len(container)))
+	binary.LittleEndian.PutUint16(container[4:6], containerType)
+	binary.LittleEndian.PutUint16(container[6:8], code)
+	binary.LittleEndian.PutUint32(container[8:12], transactionID)
+}
+
+type dataBuffer []byte
+
+func (buffer *dataBuffer) Write(data []byte) (int, error) {
+	*buffer = append(*buffer, data...)
+	return len(data), nil
+}
+
+func ptpUint32Array(data []byte) ([]uint32, error) {
+	if len(data) < 4 {
+		return nil, errors.New("truncated MTP array")
+	}
+	count := int(binary.LittleEndian.Uint32(data[0:4]))
+	if count > (len(data)-4)/4 {
+		return nil, errors.New("truncated MTP array")
+	}
+	values := make([]uint32, count)
+	for index := range values {
+		values[index] = binary.LittleEndian.Uint32(data[4+4*index:])
+	}
+	return values, nil
+}
+
+// ptpString reads a PTP string: a character count, terminating NUL
+// included, followed by UTF-16LE code units.
+func ptpString(data []byte) (string, error) {
+	count := int(data[0])
+	if len(data) < 1+2*count {
+		return "", errors.New("truncated MTP string")
+	}
+	units := make([]uint16, 0, count)
+	for index := 0; index < count; index++ {
+		unit := binary.LittleEndian.Uint16(data[1+2*index:])
+		if unit == 0 {
+			break
+		}
+		units = append(units, unit)
+	}
+	return string(utf16.Decode(units)), nil
+}
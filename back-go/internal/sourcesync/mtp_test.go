@@ -0,0 +1,297 @@
+package sourcesync
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"mystravastats/internal/shared/domain/strava"
+)
+
+func TestSynchronize_SyncsGarminMTPDeviceToInbox(t *testing.T) {
+	// GIVEN
+	destinationDirectory := t.TempDir()
+	inboxDirectory := filepath.Join(destinationDirectory, "_inbox")
+	writeTestFile(t, filepath.Join(destinationDirectory, "2026", "morning.fit"), "2026-06-01T07:00:00Z")
+	writeTestFile(t, filepath.Join(inboxDirectory, "already.fit"), "2026-06-01T07:00:00Z")
+	watch := newFakeGarminWatch(map[string]string{
+		"new-run.fit":     "2026-06-05T08:00:00Z",
+		"renamed-run.fit": "2026-06-01T07:00:00Z",
+		"already.fit":     "2026-06-01T07:00:00Z",
+		"notes.txt":       "not an activity",
+	})
+	service := testService(destinationDirectory, t.TempDir(), decodeTestActivityFile, func() {})
+	service.fitInboxPath = func() (string, bool) { return inboxDirectory, true }
+	service.openMTPDevices = func() ([]mtpDevice, error) {
+		device, err := newPTPSession("fenix 7", watch)
+		if err != nil {
+			return nil, err
+		}
+		return []mtpDevice{device}, nil
+	}
+
+	// WHEN
+	result := service.Synchronize("test")
+
+	// THEN
+	deviceSync := result.FIT.DeviceSync
+	if deviceSync == nil || deviceSync.Backend != "mtp" || deviceSync.Device != "fenix 7" || deviceSync.SourcePath != "mtp://fenix 7/GARMIN/Activity" {
+		t.Fatalf("expected MTP device sync diagnostics, got %#v", deviceSync)
+	}
+	if deviceSync.ScannedFiles != 3 || deviceSync.CopiedFiles != 1 || deviceSync.AlreadyPresentFiles != 2 || len(deviceSync.Errors) != 0 {
+		t.Fatalf("expected 1 copied and 2 already present files, got %#v", deviceSync)
+	}
+	if _, err := os.Stat(filepath.Join(inboxDirectory, "new-run.fit")); err != nil {
+		t.Fatalf("expected new FIT downloaded to inbox: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(inboxDirectory, "renamed-run.fit")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected FIT of a known activity to be dropped, got %v", err)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(inboxDirectory, "*.part")); len(matches) != 0 {
+		t.Fatalf("expected no partial download left, got %v", matches)
+	}
+	if result.FIT.ImportedFiles != 1 {
+		t.Fatalf("expected 1 imported file, got %d", result.FIT.ImportedFiles)
+	}
+	if !watch.closed {
+		t.Fatal("expected MTP device to be closed")
+	}
+}
+
+func TestSyncGarminToInbox_ReportsMTPErrorsWithoutDevice(t *testing.T) {
+	// GIVEN
+	destinationDirectory := t.TempDir()
+	service := testService(destinationDirectory, t.TempDir(), decodeTestActivityFile, func() {})
+	service.openMTPDevices = func() ([]mtpDevice, error) {
+		return nil, errors.New("fenix 7: permission denied")
+	}
+
+	// WHEN
+	result := service.syncGarminToInbox(filepath.Join(destinationDirectory, "_inbox"), destinationDirectory)
+
+	// THEN
+	if result.Status != "no_device" || result.Backend != "filesystem" {
+		t.Fatalf("expected no device on the filesystem backend, got %#v", result)
+	}
+	if len(result.Errors) != 1 || !strings.Contains(result.Message, "MTP") {
+		t.Fatalf("expected MTP error to be reported, got %#v", result)
+	}
+}
+
+func TestPTPSession_FailsWithoutGarminActivityFolder(t *testing.T) {
+	// GIVEN
+	watch := newFakeGarminWatch(nil)
+	delete(watch.objects, fakeActivityFolder)
+	device, err := newPTPSession("fenix 7", watch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// WHEN
+	_, err = device.ListActivityFiles()
+
+	// THEN
+	if err == nil {
+		t.Fatal("expected missing GARMIN/Activity folder to fail")
+	}
+}
+
+func decodeTestActivityFile(filePath string, _ int64) (*strava.Activity, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := time.Parse(time.RFC3339, string(content)); err != nil {
+		return nil, err
+	}
+	return &strava.Activity{
+		Type:        "Run",
+		StartDate:   string(content),
+		Distance:    10_000,
+		ElapsedTime: 3000,
+	}, nil
+}
+
+func writeTestFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+const (
+	fakeStorageID      = 0x00010001
+	fakeGarminFolder   = 1
+	fakeActivityFolder = 2
+	fakeMusicFolder    = 3
+	fakePacketSize     = 64
+)
+
+type fakeMTPObject struct {
+	parent  uint32
+	name    string
+	folder  bool
+	content []byte
+}
+
+// fakeMTPTransport answers PTP commands like a Garmin watch, splitting data
+// containers into USB packets.
+type fakeMTPTransport struct {
+	objects     map[uint32]fakeMTPObject
+	sessionOpen bool
+	closed      bool
+	pending     [][]byte
+}
+
+func newFakeGarminWatch(files map[string]string) *fakeMTPTransport {
+	transport := &fakeMTPTransport{objects: map[uint32]fakeMTPObject{
+		fakeGarminFolder:   {parent: ptpRootFolder, name: "GARMIN", folder: true},
+		fakeActivityFolder: {parent: fakeGarminFolder, name: "Activity", folder: true},
+		fakeMusicFolder:    {parent: ptpRootFolder, name: "Music", folder: true},
+	}}
+	handle := uint32(10)
+	for name, content := range files {
+		transport.objects[handle] = fakeMTPObject{parent: fakeActivityFolder, name: name, content: []byte(content)}
+		handle++
+	}
+	return transport
+}
+
+func (transport *fakeMTPTransport) Write(command []byte) error {
+	if transport.closed {
+		return errors.New("device closed")
+	}
+	operation := binary.LittleEndian.Uint16(command[6:8])
+	transactionID := binary.LittleEndian.Uint32(command[8:12])
+	params := make([]uint32, 0, 3)
+	for offset := ptpHeaderSize; offset+4 <= len(command); offset += 4 {
+		params = append(params, binary.LittleEndian.Uint32(command[offset:]))
+	}
+
+	response := uint16(ptpResponseOK)
+	var data []byte
+	switch operation {
+	case ptpOperationOpenSession:
+		if transport.sessionOpen {
+			response = ptpResponseSessionAlreadyOpen
+		}
+		transport.sessionOpen = true
+	case ptpOperationCloseSession:
+		transport.sessionOpen = false
+	case ptpOperationGetStorageIDs:
+		data = fakePTPArray(fakeStorageID)
+	case ptpOperationGetObjectHandles:
+		handles := make([]uint32, 0)
+		for handle := uint32(0); handle < 100; handle++ {
+			if object, ok := transport.objects[handle]; ok && object.parent == params[2] {
+				handles = append(handles, handle)
+			}
+		}
+		data = fakePTPArray(handles...)
+	case ptpOperationGetObjectInfo:
+		object, ok := transport.objects[params[0]]
+		if !ok {
+			response = 0x2009 // Invalid_ObjectHandle
+			break
+		}
+		data = fakePTPObjectInfo(object)
+	case ptpOperationGetObject:
+		object, ok := transport.objects[params[0]]
+		if !ok {
+			response = 0x2009
+			break
+		}
+		data = object.content
+	default:
+		response = 0x2005 // Operation_Not_Supported
+	}
+	if !transport.sessionOpen && operation != ptpOperationCloseSession {
+		response = 0x2003 // Session_Not_Open
+		data = nil
+	}
+
+	if data != nil {
+		container := make([]byte, ptpHeaderSize+len(data))
+		putPTPHeader(container, ptpContainerData, operation, transactionID)
+		copy(container[ptpHeaderSize:], data)
+		for offset := 0; offset < len(container); offset += fakePacketSize {
+			transport.pending = append(transport.pending, container[offset:min(offset+fakePacketSize, len(container))])
+		}
+		if len(container)%fakePacketSize == 0 {
+			transport.pending = append(transport.pending, []byte{})
+		}
+	}
+	container := make([]byte, ptpHeaderSize)
+	putPTPHeader(container, ptpContainerResponse, response, transactionID)
+	transport.pending = append(transport.pending, container)
+	return nil
+}
+
+func (transport *fakeMTPTransport) Read(buffer []byte) (int, error) {
+	if len(transport.pending) == 0 {
+		return 0, errors.New("timeout")
+	}
+	packet := transport.pending[0]
+	n := copy(buffer, packet)
+	if n < len(packet) {
+		transport.pending[0] = packet[n:]
+	} else {
+		transport.pending = transport.pending[1:]
+	}
+	return n, nil
+}
+
+func (transport *fakeMTPTransport) Close() error {
+	transport.closed = true
+	return nil
+}
+
+func fakePTPArray(values ...uint32) []byte {
+	data := make([]byte, 4+4*len(values))
+	binary.LittleEndian.PutUint32(data, uint32(len(values)))
+	for index, value := range values {
+		binary.LittleEndian.PutUint32(data[4+4*index:], value)
+	}
+	return data
+}
+
+func fakePTPObjectInfo(object fakeMTPObject) []byte {
+	data := make([]byte, ptpObjectInfoFilenameOffset)
+	binary.LittleEndian.PutUint32(data[0:4], fakeStorageID)
+	format := uint16(0x3000) // Undefined
+	if object.folder {
+		format = ptpFormatAssociation
+	}
+	binary.LittleEndian.PutUint16(data[4:6], format)
+	binary.LittleEndian.PutUint32(data[8:12], uint32(len(object.content)))
+	binary.LittleEndian.PutUint32(data[38:42], object.parent)
+
+	units := append(utf16.Encode([]rune(object.name)), 0)
+	data = append(data, byte(len(units)))
+	for _, unit := range units {
+		data = binary.LittleEndian.AppendUint16(data, unit)
+	}
+	// Empty capture and modification dates, no keywords.
+	return append(data, 0, 0, 0)
+}
+
+func TestOpenConfiguredMTPDevices_IsDisabledByDefault(t *testing.T) {
+	// GIVEN
+	t.Setenv(garminMTPEnv, "")
+
+	// WHEN
+	devices, err := openConfiguredMTPDevices()
+
+	// THEN
+	if devices != nil || err != nil {
+		t.Fatalf("expected MTP to stay off unless enabled, got %d device(s) and %v", len(devices), err)
+	}
+}
@@ -0,0 +1,220 @@
+//go:build linux
+
+package sourcesync
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// Garmin watches are driven through the kernel usbfs interface, which needs
+// no libusb or cgo. The process needs read/write access to /dev/bus/usb, which
+// udev usually grants to the logged-in user.
+
+const (
+	garminUSBVendorID = "091e"
+	usbSysfsDevices   = "/sys/bus/usb/devices"
+	usbTimeoutMs      = 5000
+
+	usbdevfsClaimInterface   = 0x8004550f
+	usbdevfsReleaseInterface = 0x80045510
+)
+
+type usbdevfsBulkTransfer struct {
+	endpoint uint32
+	length   uint32
+	timeout  uint32
+	data     uintptr
+}
+
+// usbdevfsBulk is _IOWR('U', 2, struct usbdevfs_bulktransfer).
+var usbdevfsBulk = uintptr(3<<30 | unsafe.Sizeof(usbdevfsBulkTransfer{})<<16 | 'U'<<8 | 2)
+
+type usbMTPInterface struct {
+	deviceName  string
+	devicePath  string
+	number      uint32
+	inEndpoint  uint32
+	outEndpoint uint32
+}
+
+type usbfsTransport struct {
+	file        *os.File
+	iface       usbMTPInterface
+	interfaceID uint32
+}
+
+func openUSBMTPDevices() ([]mtpDevice, error) {
+	interfaces, err := garminUSBMTPInterfaces(usbSysfsDevices)
+	if err != nil {
+		return nil, err
+	}
+	devices := make([]mtpDevice, 0, len(interfaces))
+	var errs []error
+	for _, iface := range interfaces {
+		transport, err := openUSBFSTransport(iface)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", iface.deviceName, err))
+			continue
+		}
+		session, err := newPTPSession(iface.deviceName, transport)
+		if err != nil {
+			_ = transport.Close()
+			errs = append(errs, fmt.Errorf("%s: %w", iface.deviceName, err))
+			continue
+		}
+		devices = append(devices, session)
+	}
+	return devices, errors.Join(errs...)
+}
+
+// garminUSBMTPInterfaces lists the MTP interfaces of connected Garmin devices
+// from sysfs.
+func garminUSBMTPInterfaces(sysfsRoot string) ([]usbMTPInterface, error) {
+	entries, err := os.ReadDir(sysfsRoot)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	interfaces := make([]usbMTPInterface, 0)
+	for _, entry := range entries {
+		devicePath := filepath.Join(sysfsRoot, entry.Name())
+		if strings.Contains(entry.Name(), ":") || sysfsValue(devicePath, "idVendor") != garminUSBVendorID {
+			continue
+		}
+		busNumber, busErr := strconv.Atoi(sysfsValue(devicePath, "busnum"))
+		deviceNumber, deviceErr := strconv.Atoi(sysfsValue(devicePath, "devnum"))
+		if busErr != nil || deviceErr != nil {
+			continue
+		}
+		name := sysfsValue(devicePath, "product")
+		if name == "" {
+			name = "Garmin " + sysfsValue(devicePath, "idProduct")
+		}
+		iface, found := sysfsMTPInterface(devicePath, entry.Name())
+		if !found {
+			continue
+		}
+		iface.deviceName = name
+		iface.devicePath = fmt.Sprintf("/dev/bus/usb/%03d/%03d", busNumber, deviceNumber)
+		interfaces = append(interfaces, iface)
+	}
+	sort.Slice(interfaces, func(i, j int) bool { return interfaces[i].devicePath < interfaces[j].devicePath })
+	return interfaces, nil
+}
+
+// sysfsMTPInterface finds the interface announcing MTP, either as a still
+// image class interface or through its "MTP" interface string, and its bulk
+// endpoints.
+func sysfsMTPInterface(devicePath string, deviceID string) (usbMTPInterface, bool) {
+	interfacePaths, _ := filepath.Glob(filepath.Join(devicePath, deviceID+":*"))
+	sort.Strings(interfacePaths)
+	for _, interfacePath := range interfacePaths {
+		stillImage := sysfsValue(interfacePath, "bInterfaceClass") == "06" && sysfsValue(interfacePath, "bInterfaceSubClass") == "01"
+		if !stillImage && !strings.Contains(strings.ToUpper(sysfsValue(interfacePath, "interface")), "MTP") {
+			continue
+		}
+		number, err := strconv.ParseUint(sysfsValue(interfacePath, "bInterfaceNumber"), 16, 8)
+		if err != nil {
+			continue
+		}
+		iface := usbMTPInterface{number: uint32(number)}
+		endpointPaths, _ := filepath.Glob(filepath.Join(interfacePath, "ep_*"))
+		for _, endpointPath := range endpointPaths {
+			if sysfsValue(endpointPath, "type") != "Bulk" {
+				continue
+			}
+			address, err := strconv.ParseUint(sysfsValue(endpointPath, "bEndpointAddress"), 16, 8)
+			if err != nil {
+				continue
+			}
+			if sysfsValue(endpointPath, "direction") == "in" {
+				iface.inEndpoint = uint32(address)
+			} else {
+				iface.outEndpoint = uint32(address)
+			}
+		}
+		if iface.inEndpoint != 0 && iface.outEndpoint != 0 {
+			return iface, true
+		}
+	}
+	return usbMTPInterface{}, false
+}
+
+func sysfsValue(path string, name string) string {
+	content, err := os.ReadFile(filepath.Join(path, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
+
+func openUSBFSTransport(iface usbMTPInterface) (*usbfsTransport, error) {
+	file, err := os.OpenFile(iface.devicePath, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	transport := &usbfsTransport{file: file, iface: iface, interfaceID: iface.number}
+	if err := transport.ioctl(usbdevfsClaimInterface, unsafe.Pointer(&transport.interfaceID)); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("claim MTP interface: %w", err)
+	}
+	return transport, nil
+}
+
+func (transport *usbfsTransport) Write(data []byte) error {
+	n, err := transport.bulk(transport.iface.outEndpoint, data)
+	if err != nil {
+		return err
+	}
+	if n != len(data) {
+		return fmt.Errorf("short MTP write: %d of %d bytes", n, len(data))
+	}
+	return nil
+}
+
+func (transport *usbfsTransport) Read(buffer []byte) (int, error) {
+	return transport.bulk(transport.iface.inEndpoint, buffer)
+}
+
+func (transport *usbfsTransport) Close() error {
+	releaseErr := transport.ioctl(usbdevfsReleaseInterface, unsafe.Pointer(&transport.interfaceID))
+	return errors.Join(releaseErr, transport.file.Close())
+}
+
+func (transport *usbfsTransport) bulk(endpoint uint32, data []byte) (int, error) {
+	request := usbdevfsBulkTransfer{
+		endpoint: endpoint,
+		length:   uint32(len(data)),
+		timeout:  usbTimeoutMs,
+	}
+	var pinner runtime.Pinner
+	defer pinner.Unpin()
+	if len(data) > 0 {
+		pinner.Pin(&data[0])
+		request.data = uintptr(unsafe.Pointer(&data[0]))
+	}
+	n, _, errno := syscall.Syscall(syscall.SYS_IOCTL, transport.file.Fd(), usbdevfsBulk, uintptr(unsafe.Pointer(&request)))
+	if errno != 0 {
+		return 0, fmt.Errorf("USB bulk transfer on endpoint 0x%02x: %w", endpoint, errno)
+	}
+	return int(n), nil
+}
+
+func (transport *usbfsTransport) ioctl(request uintptr, argument unsafe.Pointer) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, transport.file.Fd(), request, uintptr(argument))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
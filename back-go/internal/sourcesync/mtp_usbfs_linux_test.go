@@ -0,0 +1,53 @@
+//go:build linux
+
+package sourcesync
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestGarminUSBMTPInterfaces_FindsVendorInterfaceNamedMTP(t *testing.T) {
+	// GIVEN
+	sysfsRoot := t.TempDir()
+	writeSysfsValues(t, filepath.Join(sysfsRoot, "1-2"), map[string]string{
+		"idVendor": "091e", "idProduct": "4cda", "product": "fenix 7", "busnum": "1", "devnum": "7",
+	})
+	writeSysfsValues(t, filepath.Join(sysfsRoot, "1-2", "1-2:1.0"), map[string]string{
+		"bInterfaceClass": "ff", "bInterfaceSubClass": "ff", "bInterfaceNumber": "00", "interface": "MTP",
+	})
+	writeSysfsValues(t, filepath.Join(sysfsRoot, "1-2", "1-2:1.0", "ep_81"), map[string]string{
+		"type": "Bulk", "direction": "in", "bEndpointAddress": "81",
+	})
+	writeSysfsValues(t, filepath.Join(sysfsRoot, "1-2", "1-2:1.0", "ep_01"), map[string]string{
+		"type": "Bulk", "direction": "out", "bEndpointAddress": "01",
+	})
+	writeSysfsValues(t, filepath.Join(sysfsRoot, "1-2", "1-2:1.0", "ep_82"), map[string]string{
+		"type": "Interrupt", "direction": "in", "bEndpointAddress": "82",
+	})
+	writeSysfsValues(t, filepath.Join(sysfsRoot, "1-3"), map[string]string{
+		"idVendor": "046d", "product": "Keyboard", "busnum": "1", "devnum": "8",
+	})
+
+	// WHEN
+	interfaces, err := garminUSBMTPInterfaces(sysfsRoot)
+
+	// THEN
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(interfaces) != 1 {
+		t.Fatalf("expected one Garmin MTP interface, got %#v", interfaces)
+	}
+	iface := interfaces[0]
+	if iface.deviceName != "fenix 7" || iface.devicePath != "/dev/bus/usb/001/007" || iface.inEndpoint != 0x81 || iface.outEndpoint != 0x01 {
+		t.Fatalf("unexpected MTP interface: %#v", iface)
+	}
+}
+
+func writeSysfsValues(t *testing.T, directory string, values map[string]string) {
+	t.Helper()
+	for name, value := range values {
+		writeTestFile(t, filepath.Join(directory, name), value+"\n")
+	}
+}
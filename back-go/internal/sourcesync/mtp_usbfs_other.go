@@ -0,0 +1,16 @@
+//go:build !linux
+
+package sourcesync
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Native MTP access is only implemented through Linux usbfs. Other systems
+// keep using a mounted or OpenMTP-exported activity directory; enabling
+// GARMIN_MTP_ENABLED there reports this error in the synchronization status
+// instead of silently finding no device.
+func openUSBMTPDevices() ([]mtpDevice, error) {
+	return nil, fmt.Errorf("native Garmin MTP access is not supported on %s: mount the watch or export its GARMIN/Activity folder, and set GARMIN_FIT_SOURCE_PATH", runtime.GOOS)
+}
@@ -0,0 +1,82 @@
+package sourcesync
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"mystravastats/internal/platform/activityprovider"
+	"mystravastats/internal/platform/runtimeconfig"
+	"mystravastats/internal/shared/infrastructure/stravaapi"
+)
+
+const stravaReconcileScheduleEnv = "STRAVA_RECONCILE_SCHEDULE"
+
+// StravaReconciliation reports the reconciliation of the cached past years of
+// one Strava provider.
+type StravaReconciliation struct {
+	ClientID string                         `json:"clientId"`
+	Report   stravaapi.ReconciliationReport `json:"report"`
+	Error    string                         `json:"error,omitempty"`
+}
+
+// ReconcileStrava reconciles the cached past years of every Strava provider
+// built so far with the activity lists returned by Strava.
+func ReconcileStrava() []StravaReconciliation {
+	results := make([]StravaReconciliation, 0)
+	seen := map[*stravaapi.StravaActivityProvider]struct{}{}
+	for _, provider := range activityprovider.Loaded() {
+		stravaProvider, ok := activityprovider.StravaOf(provider)
+		if !ok {
+			continue
+		}
+		if _, duplicate := seen[stravaProvider]; duplicate {
+			continue
+		}
+		seen[stravaProvider] = struct{}{}
+
+		report, err := stravaProvider.ReconcileCachedYears()
+		result := StravaReconciliation{ClientID: stravaProvider.ClientID(), Report: report}
+		if err != nil {
+			log.Printf("Strava reconciliation of client %s stopped: %v", result.ClientID, err)
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// StartReconcileScheduler runs ReconcileStrava on STRAVA_RECONCILE_SCHEDULE,
+// an interval or a cron expression like SOURCE_SYNC_SCHEDULE. Each run lists
+// every cached year, so the schedule is meant to be daily or weekly. It stops
+// when ctx is cancelled.
+func StartReconcileScheduler(ctx context.Context) {
+	expression, configured := runtimeconfig.OptionalValue(stravaReconcileScheduleEnv)
+	if !configured || strings.TrimSpace(expression) == "" {
+		return
+	}
+	schedule, err := parseSyncSchedule(expression)
+	if err != nil {
+		log.Printf("Strava reconciliation schedule %q ignored: %v", expression, err)
+		return
+	}
+	go runReconcileSchedule(ctx, schedule, time.Now)
+}
+
+func runReconcileSchedule(ctx context.Context, schedule syncSchedule, now func() time.Time) {
+	for {
+		next := schedule.Next(now())
+		if next.IsZero() {
+			return
+		}
+		timer := time.NewTimer(next.Sub(now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			ReconcileStrava()
+		}
+	}
+}
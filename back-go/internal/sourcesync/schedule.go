@@ -0,0 +1,192 @@
+package sourcesync
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const minimumSyncInterval = time.Minute
+
+// syncSchedule returns the next run time strictly after a given time.
+type syncSchedule interface {
+	Next(after time.Time) time.Time
+}
+
+type intervalSchedule struct {
+	interval time.Duration
+}
+
+func (schedule intervalSchedule) Next(after time.Time) time.Time {
+	return after.Add(schedule.interval)
+}
+
+// cronSchedule is a standard five-field cron expression evaluated in local
+// time: minute, hour, day of month, month, day of week.
+type cronSchedule struct {
+	minutes  uint64
+	hours    uint64
+	days     uint64
+	months   uint64
+	weekdays uint64
+	// Like cron, when both day fields are restricted a day matching either
+	// of them is due.
+	daysRestricted     bool
+	weekdaysRestricted bool
+}
+
+var cronShortcuts = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+}
+
+// parseSyncSchedule accepts a Go duration such as "30m" or "6h", or a cron
+// expression such as "0 */6 * * *" or "@daily".
+func parseSyncSchedule(expression string) (syncSchedule, error) {
+	expression = strings.TrimSpace(expression)
+	if expression == "" {
+		return nil, fmt.Errorf("empty schedule")
+	}
+	if !strings.ContainsAny(expression, " \t*@") {
+		interval, err := time.ParseDuration(expression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval %q: %w", expression, err)
+		}
+		if interval < minimumSyncInterval {
+			return nil, fmt.Errorf("interval %s is shorter than %s", interval, minimumSyncInterval)
+		}
+		return intervalSchedule{interval: interval}, nil
+	}
+	return parseCronSchedule(expression)
+}
+
+func parseCronSchedule(expression string) (cronSchedule, error) {
+	if shortcut, ok := cronShortcuts[strings.ToLower(expression)]; ok {
+		expression = shortcut
+	}
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return cronSchedule{}, fmt.Errorf("cron expression %q must have 5 fields", expression)
+	}
+
+	var schedule cronSchedule
+	var err error
+	if schedule.minutes, err = parseCronField(fields[0], 0, 59); err != nil {
+		return cronSchedule{}, fmt.Errorf("minute: %w", err)
+	}
+	if schedule.hours, err = parseCronField(fields[1], 0, 23); err != nil {
+		return cronSchedule{}, fmt.Errorf("hour: %w", err)
+	}
+	if schedule.days, err = parseCronField(fields[2], 1, 31); err != nil {
+		return cronSchedule{}, fmt.Errorf("day of month: %w", err)
+	}
+	if schedule.months, err = parseCronField(fields[3], 1, 12); err != nil {
+		return cronSchedule{}, fmt.Errorf("month: %w", err)
+	}
+	if schedule.weekdays, err = parseCronField(fields[4], 0, 7); err != nil {
+		return cronSchedule{}, fmt.Errorf("day of week: %w", err)
+	}
+	// 7 is another name for Sunday.
+	if schedule.weekdays&(1<<7) != 0 {
+		schedule.weekdays |= 1
+	}
+	schedule.daysRestricted = !strings.HasPrefix(fields[2], "*")
+	schedule.weekdaysRestricted = !strings.HasPrefix(fields[4], "*")
+	return schedule, nil
+}
+
+// parseCronField reads a comma-separated list of "*", "n" or "a-b" items, each
+// with an optional "/step", into a bit set.
+func parseCronField(field string, minimum int, maximum int) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			value, err := strconv.Atoi(stepPart)
+			if err != nil || value <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+			step = value
+		}
+
+		start, end := minimum, maximum
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			low, high, _ := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = cronValue(low, minimum, maximum); err != nil {
+				return 0, err
+			}
+			if end, err = cronValue(high, minimum, maximum); err != nil {
+				return 0, err
+			}
+			if end < start {
+				return 0, fmt.Errorf("invalid range %q", rangePart)
+			}
+		default:
+			value, err := cronValue(rangePart, minimum, maximum)
+			if err != nil {
+				return 0, err
+			}
+			start = value
+			if !hasStep {
+				end = value
+			}
+		}
+		for value := start; value <= end; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+	return bits, nil
+}
+
+func cronValue(text string, minimum int, maximum int) (int, error) {
+	value, err := strconv.Atoi(text)
+	if err != nil || value < minimum || value > maximum {
+		return 0, fmt.Errorf("value %q must be between %d and %d", text, minimum, maximum)
+	}
+	return value, nil
+}
+
+// Next walks forward field by field, skipping a whole month, day or hour when
+// it cannot match. It gives up after five years, which only happens for dates
+// that never exist such as "0 0 30 2 *".
+func (schedule cronSchedule) Next(after time.Time) time.Time {
+	next := after.Truncate(time.Minute).Add(time.Minute)
+	limit := next.AddDate(5, 0, 0)
+	for next.Before(limit) {
+		if schedule.months&(1<<uint(next.Month())) == 0 {
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, next.Location())
+			continue
+		}
+		if !schedule.dayMatches(next) {
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, next.Location())
+			continue
+		}
+		if schedule.hours&(1<<uint(next.Hour())) == 0 {
+			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour()+1, 0, 0, 0, next.Location())
+			continue
+		}
+		if schedule.minutes&(1<<uint(next.Minute())) == 0 {
+			next = next.Add(time.Minute)
+			continue
+		}
+		return next
+	}
+	return time.Time{}
+}
+
+func (schedule cronSchedule) dayMatches(value time.Time) bool {
+	dayMatches := schedule.days&(1<<uint(value.Day())) != 0
+	weekdayMatches := schedule.weekdays&(1<<uint(value.Weekday())) != 0
+	if schedule.daysRestricted && schedule.weekdaysRestricted {
+		return dayMatches || weekdayMatches
+	}
+	return dayMatches && weekdayMatches
+}
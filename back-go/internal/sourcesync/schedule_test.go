@@ -0,0 +1,68 @@
+package sourcesync
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSyncSchedule_AcceptsInterval(t *testing.T) {
+	// GIVEN
+	after := time.Date(2026, 3, 10, 8, 7, 30, 0, time.UTC)
+
+	// WHEN
+	schedule, err := parseSyncSchedule("30m")
+
+	// THEN
+	if err != nil {
+		t.Fatalf("expected valid interval, got %v", err)
+	}
+	if next := schedule.Next(after); !next.Equal(after.Add(30 * time.Minute)) {
+		t.Fatalf("expected next run 30 minutes later, got %s", next)
+	}
+}
+
+func TestParseSyncSchedule_CronNextRun(t *testing.T) {
+	after := time.Date(2026, 3, 10, 8, 7, 30, 0, time.UTC) // Tuesday
+	testCases := []struct {
+		expression string
+		expected   time.Time
+	}{
+		{"*/15 * * * *", time.Date(2026, 3, 10, 8, 15, 0, 0, time.UTC)},
+		{"0 */6 * * *", time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)},
+		{"30 7 * * 1-5", time.Date(2026, 3, 11, 7, 30, 0, 0, time.UTC)},
+		{"0 9 * * 0", time.Date(2026, 3, 15, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 7", time.Date(2026, 3, 15, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 * 5", time.Date(2026, 3, 13, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 1 *", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, testCase := range testCases {
+		schedule, err := parseSyncSchedule(testCase.expression)
+		if err != nil {
+			t.Fatalf("expected %q to parse, got %v", testCase.expression, err)
+		}
+		if next := schedule.Next(after); !next.Equal(testCase.expected) {
+			t.Fatalf("expected %q to run at %s, got %s", testCase.expression, testCase.expected, next)
+		}
+	}
+}
+
+func TestParseSyncSchedule_NeverExistingDateHasNoNextRun(t *testing.T) {
+	schedule, err := parseSyncSchedule("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("expected expression to parse, got %v", err)
+	}
+
+	if next := schedule.Next(time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)); !next.IsZero() {
+		t.Fatalf("expected no next run, got %s", next)
+	}
+}
+
+func TestParseSyncSchedule_RejectsInvalidExpressions(t *testing.T) {
+	for _, expression := range []string{"", "10s", "soon", "* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "@yearly"} {
+		if _, err := parseSyncSchedule(expression); err == nil {
+			t.Fatalf("expected %q to be rejected", expression)
+		}
+	}
+}
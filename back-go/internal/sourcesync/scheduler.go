@@ -0,0 +1,147 @@
+package sourcesync
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"mystravastats/internal/platform/activityprovider"
+	"mystravastats/internal/platform/runtimeconfig"
+	"mystravastats/internal/shared/infrastructure/stravaapi"
+)
+
+const (
+	syncScheduleEnv      = "SOURCE_SYNC_SCHEDULE"
+	syncStravaRefreshEnv = "SOURCE_SYNC_STRAVA_REFRESH"
+)
+
+// Scheduler runs SynchronizeWithStrava, or Synchronize when the Strava
+// refresh is disabled, on an interval or a cron schedule.
+type Scheduler struct {
+	service       *Service
+	expression    string
+	schedule      syncSchedule
+	stravaRefresh bool
+	now           func() time.Time
+}
+
+func NewScheduler(service *Service, expression string, stravaRefresh bool) (*Scheduler, error) {
+	schedule, err := parseSyncSchedule(expression)
+	if err != nil {
+		return nil, err
+	}
+	return &Scheduler{
+		service:       service,
+		expression:    strings.TrimSpace(expression),
+		schedule:      schedule,
+		stravaRefresh: stravaRefresh,
+		now:           time.Now,
+	}, nil
+}
+
+// StartScheduler starts the synchronization scheduler when
+// SOURCE_SYNC_SCHEDULE is set. It stops when ctx is cancelled.
+func StartScheduler(ctx context.Context) {
+	expression, configured := runtimeconfig.OptionalValue(syncScheduleEnv)
+	if !configured || strings.TrimSpace(expression) == "" {
+		return
+	}
+	stravaRefresh := runtimeconfig.BoolValue(syncStravaRefreshEnv, true)
+	scheduler, err := NewScheduler(defaultService, expression, stravaRefresh)
+	if err != nil {
+		log.Printf("Source synchronization schedule %q ignored: %v", expression, err)
+		defaultService.setSchedule(ScheduleStatus{Expression: expression, StravaRefresh: stravaRefresh, Error: err.Error()})
+		return
+	}
+	go scheduler.Run(ctx)
+}
+
+func (scheduler *Scheduler) Run(ctx context.Context) {
+	log.Printf("Source synchronization scheduled with %q (Strava refresh: %t)", scheduler.expression, scheduler.stravaRefresh)
+	defer scheduler.service.setSchedule(ScheduleStatus{Expression: scheduler.expression, StravaRefresh: scheduler.stravaRefresh})
+	for {
+		next := scheduler.schedule.Next(scheduler.now())
+		if next.IsZero() {
+			log.Printf("Source synchronization schedule %q has no next run", scheduler.expression)
+			return
+		}
+		scheduler.service.setSchedule(ScheduleStatus{
+			Enabled:       true,
+			Expression:    scheduler.expression,
+			StravaRefresh: scheduler.stravaRefresh,
+			NextRunAt:     next.UTC().Format(time.RFC3339),
+		})
+
+		timer := time.NewTimer(next.Sub(scheduler.now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			scheduler.RunOnce()
+		}
+	}
+}
+
+// RunOnce runs one scheduled synchronization. A run already in progress makes
+// it a no-op.
+func (scheduler *Scheduler) RunOnce() SyncResult {
+	if scheduler.stravaRefresh {
+		return scheduler.service.SynchronizeWithStrava("scheduled")
+	}
+	return scheduler.service.Synchronize("scheduled")
+}
+
+// refreshLoadedStravaProviders refreshes every Strava provider built so far,
+// including the ones behind a composite provider.
+func refreshLoadedStravaProviders() []stravaapi.RefreshResult {
+	results := make([]stravaapi.RefreshResult, 0)
+	seen := map[*stravaapi.StravaActivityProvider]struct{}{}
+	for _, provider := range activityprovider.Loaded() {
+		stravaProvider, ok := activityprovider.StravaOf(provider)
+		if !ok {
+			continue
+		}
+		if _, duplicate := seen[stravaProvider]; duplicate {
+			continue
+		}
+		seen[stravaProvider] = struct{}{}
+		results = append(results, stravaProvider.RefreshCurrentYear())
+	}
+	return results
+}
+
+// syncStatusWithStrava folds the Strava refreshes into the FIT status. A run
+// with nothing to import from FIT still completes when Strava was refreshed.
+func syncStatusWithStrava(status string, refreshes []stravaapi.RefreshResult) string {
+	refreshed, failed := 0, 0
+	for _, refresh := range refreshes {
+		switch refresh.Status {
+		case "refreshed", "up_to_date":
+			refreshed++
+		case "failed", "rate_limited":
+			failed++
+		}
+	}
+	switch {
+	case status == "failed":
+		return status
+	case failed > 0 && refreshed == 0 && status == "skipped":
+		return "failed"
+	case refreshed > 0 && status == "skipped":
+		return "completed"
+	default:
+		return status
+	}
+}
+
+func syncMessageWithStrava(message string, refreshes []stravaapi.RefreshResult) string {
+	parts := []string{message}
+	for _, refresh := range refreshes {
+		if refresh.Message != "" {
+			parts = append(parts, refresh.Message)
+		}
+	}
+	return strings.Join(parts, " ")
+}
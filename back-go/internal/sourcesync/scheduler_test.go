@@ -0,0 +1,95 @@
+package sourcesync
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"mystravastats/internal/shared/domain/strava"
+	"mystravastats/internal/shared/infrastructure/stravaapi"
+)
+
+func TestSchedulerRunOnce_RefreshesStravaAndRecordsHistory(t *testing.T) {
+	// GIVEN
+	service := testService(t.TempDir(), t.TempDir(), func(string, int64) (*strava.Activity, error) {
+		t.Fatal("expected no FIT file to decode")
+		return nil, nil
+	}, func() {})
+	service.refreshStrava = func() []stravaapi.RefreshResult {
+		return []stravaapi.RefreshResult{{
+			Status:         "refreshed",
+			Message:        "2 new Strava activities.",
+			NewActivityIDs: []int64{7, 8},
+		}}
+	}
+	service.history = newSyncHistory(filepath.Join(t.TempDir(), syncHistoryFileName), 10)
+	scheduler, err := NewScheduler(service, "1h", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// WHEN
+	result := scheduler.RunOnce()
+
+	// THEN
+	if result.Reason != "scheduled" || result.Status != "completed" {
+		t.Fatalf("expected completed scheduled run, got reason=%s status=%s", result.Reason, result.Status)
+	}
+	if !reflect.DeepEqual(result.ImportedActivityIDs, []int64{7, 8}) {
+		t.Fatalf("expected Strava activities to be reported as imported, got %v", result.ImportedActivityIDs)
+	}
+	entries := service.History(0)
+	if len(entries) != 1 || entries[0].Reason != "scheduled" || len(entries[0].Strava) != 1 {
+		t.Fatalf("expected scheduled run in history, got %#v", entries)
+	}
+}
+
+func TestSchedulerRunOnce_SkipsStravaWhenDisabled(t *testing.T) {
+	// GIVEN
+	service := testService(t.TempDir(), t.TempDir(), nil, func() {})
+	service.refreshStrava = func() []stravaapi.RefreshResult {
+		t.Fatal("expected no Strava refresh")
+		return nil
+	}
+	scheduler, err := NewScheduler(service, "0 */6 * * *", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// WHEN
+	result := scheduler.RunOnce()
+
+	// THEN
+	if len(result.Strava) != 0 {
+		t.Fatalf("expected no Strava refresh result, got %#v", result.Strava)
+	}
+}
+
+func TestStatus_ReportsRunInFlight(t *testing.T) {
+	// GIVEN
+	service := testService(t.TempDir(), t.TempDir(), nil, func() {})
+	startedAt := time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return startedAt }
+	if !service.beginRun("scheduled") {
+		t.Fatal("expected run to start")
+	}
+	service.setRunStage("strava_refresh")
+	service.now = func() time.Time { return startedAt.Add(1500 * time.Millisecond) }
+
+	// WHEN
+	status := service.Status()
+	concurrent := service.Synchronize("manual")
+
+	// THEN
+	if !status.Running || status.Reason != "scheduled" || status.Stage != "strava_refresh" || status.ElapsedMs != 1500 {
+		t.Fatalf("expected scheduled run in strava_refresh stage, got %#v", status)
+	}
+	if concurrent.Status != "running" {
+		t.Fatalf("expected concurrent synchronization to report the run in flight, got %s", concurrent.Status)
+	}
+	service.endRun()
+	if service.Status().Running {
+		t.Fatal("expected no run in flight after endRun")
+	}
+}
@@ -20,9 +20,13 @@ import (
 
 	"mystravastats/internal/helpers"
 	"mystravastats/internal/platform/activityprovider"
+	"mystravastats/internal/platform/events"
 	"mystravastats/internal/platform/runtimeconfig"
 	"mystravastats/internal/shared/domain/strava"
 	fitprovider "mystravastats/internal/shared/infrastructure/fit"
+	gpxprovider "mystravastats/internal/shared/infrastructure/gpx"
+	"mystravastats/internal/shared/infrastructure/stravaapi"
+	tcxprovider "mystravastats/internal/shared/infrastructure/tcx"
 )
 
 const (
@@ -33,14 +37,21 @@ const (
 )
 
 type SyncResult struct {
-	Status      string          `json:"status"`
-	Reason      string          `json:"reason"`
-	Message     string          `json:"message"`
-	StartedAt   string          `json:"startedAt"`
-	CompletedAt string          `json:"completedAt"`
-	DurationMs  int64           `json:"durationMs"`
-	Reloaded    bool            `json:"reloaded"`
-	FIT         FITImportResult `json:"fit"`
+	Status      string                     `json:"status"`
+	Reason      string                     `json:"reason"`
+	Message     string                     `json:"message"`
+	StartedAt   string                     `json:"startedAt"`
+	CompletedAt string                     `json:"completedAt"`
+	DurationMs  int64                      `json:"durationMs"`
+	Reloaded    bool                       `json:"reloaded"`
+	FIT         FITImportResult            `json:"fit"`
+	Watch       *WatchResult               `json:"watch,omitempty"`
+	Archive     *StravaArchiveImportResult `json:"archive,omitempty"`
+
+	// ImportedActivityIDs lists every activity the run added, while FIT and
+	// Archive only keep the first imported files.
+	ImportedActivityIDs []int64                   `json:"importedActivityIds,omitempty"`
+	Strava              []stravaapi.RefreshResult `json:"strava,omitempty"`
 }
 
 type FITImportResult struct {
@@ -61,6 +72,8 @@ type FITImportResult struct {
 	Imported               []ImportedFITFile    `json:"imported"`
 	Errors                 []string             `json:"errors"`
 	DeviceSync             *FITDeviceSyncResult `json:"deviceSync,omitempty"`
+
+	importedActivityIDs []int64
 }
 
 type ImportedFITFile struct {
@@ -95,15 +108,25 @@ type FITDeviceSyncFile struct {
 
 type Service struct {
 	decodeFIT        func(filePath string, athleteID int64) (*strava.Activity, error)
+	decodeGPX        func(filePath string, athleteID int64, fallbackYear int) (*strava.Activity, error)
+	decodeTCX        func(filePath string, athleteID int64) (*strava.Activity, error)
 	fitDestination   func() (string, bool)
+	sourceRoots      func(athleteID string) (map[string]string, bool)
 	fitInboxPath     func() (string, bool)
 	garminSourcePath func() (string, bool)
 	reloadProvider   func()
+	reloadAthlete    func(athleteID string)
 	volumeRoots      func() []string
+	openMTPDevices   func() ([]mtpDevice, error)
+	refreshStrava    func() []stravaapi.RefreshResult
+	history          *syncHistory
 	now              func() time.Time
 	running          atomic.Bool
 	lastResultMutex  sync.RWMutex
 	lastResult       SyncResult
+	runMutex         sync.Mutex
+	currentRun       *runState
+	schedule         ScheduleStatus
 }
 
 type garminDevice struct {
@@ -117,11 +140,18 @@ var defaultService = NewService()
 func NewService() *Service {
 	return &Service{
 		decodeFIT:        fitprovider.DecodeFITActivity,
+		decodeGPX:        gpxprovider.DecodeGPXActivity,
+		decodeTCX:        tcxprovider.DecodeTCXActivity,
 		fitDestination:   func() (string, bool) { return runtimeconfig.OptionalValue(fitDestinationEnv) },
+		sourceRoots:      activityprovider.SourceRoots,
 		fitInboxPath:     configuredFITInboxPath,
 		garminSourcePath: func() (string, bool) { return runtimeconfig.OptionalValue(garminSourceEnv) },
 		reloadProvider:   activityprovider.Reload,
+		reloadAthlete:    activityprovider.ReloadAthlete,
 		volumeRoots:      platformVolumeRoots,
+		openMTPDevices:   openConfiguredMTPDevices,
+		refreshStrava:    refreshLoadedStravaProviders,
+		history:          newSyncHistory(filepath.Join(helpers.StravaCachePath, syncHistoryFileName), runtimeconfig.IntValue(syncHistoryLimitEnv, defaultSyncHistoryLimit)),
 		now:              time.Now,
 		lastResult: SyncResult{
 			Status:  "idle",
@@ -147,19 +177,31 @@ func LastResult() SyncResult {
 	return defaultService.LastResult()
 }
 
+// Synchronize imports new FIT files. Strava is refreshed by scheduled runs
+// only, see SynchronizeWithStrava.
 func (service *Service) Synchronize(reason string) SyncResult {
+	return service.synchronize(reason, false)
+}
+
+// SynchronizeWithStrava imports new FIT files, then refreshes the current
+// year of every loaded Strava provider.
+func (service *Service) SynchronizeWithStrava(reason string) SyncResult {
+	return service.synchronize(reason, true)
+}
+
+func (service *Service) synchronize(reason string, refreshStrava bool) SyncResult {
 	reason = strings.TrimSpace(reason)
 	if reason == "" {
 		reason = "manual"
 	}
-	if !service.running.CompareAndSwap(false, true) {
+	if !service.beginRun(reason) {
 		result := service.LastResult()
 		result.Status = "running"
 		result.Reason = reason
 		result.Message = "Synchronization is already running."
 		return result
 	}
-	defer service.running.Store(false)
+	defer service.endRun()
 
 	startedAt := service.now()
 	result := SyncResult{
@@ -167,15 +209,29 @@ func (service *Service) Synchronize(reason string) SyncResult {
 		Reason:    reason,
 		StartedAt: startedAt.UTC().Format(time.RFC3339),
 	}
+	service.setRunStage("fit_import")
 	result.FIT = service.importFIT()
+	if refreshStrava && service.refreshStrava != nil {
+		service.setRunStage("strava_refresh")
+		result.Strava = service.refreshStrava()
+	}
 	if result.FIT.ImportedFiles > 0 {
 		if service.reloadProvider != nil {
+			service.setRunStage("reload")
 			service.reloadProvider()
 			result.Reloaded = true
 		}
 	}
 	result.Status = syncStatusFromFIT(result.FIT)
 	result.Message = syncMessageFromFIT(result.FIT)
+	if len(result.Strava) > 0 {
+		result.Status = syncStatusWithStrava(result.Status, result.Strava)
+		result.Message = syncMessageWithStrava(result.Message, result.Strava)
+	}
+	result.ImportedActivityIDs = append(result.ImportedActivityIDs, result.FIT.importedActivityIDs...)
+	for _, refresh := range result.Strava {
+		result.ImportedActivityIDs = append(result.ImportedActivityIDs, refresh.NewActivityIDs...)
+	}
 	completedAt := service.now()
 	result.CompletedAt = completedAt.UTC().Format(time.RFC3339)
 	result.DurationMs = completedAt.Sub(startedAt).Milliseconds()
@@ -193,6 +249,10 @@ func (service *Service) storeLastResult(result SyncResult) {
 	service.lastResultMutex.Lock()
 	service.lastResult = result
 	service.lastResultMutex.Unlock()
+	if service.history != nil {
+		service.history.append(result)
+	}
+	service.finishRun(result)
 }
 
 func (service *Service) importFIT() FITImportResult {
@@ -218,7 +278,7 @@ func (service *Service) importFIT() FITImportResult {
 		}
 	}
 
-	if deviceSync := service.syncGarminToInbox(strings.TrimSpace(inboxPath)); deviceSync != nil {
+	if deviceSync := service.syncGarminToInbox(strings.TrimSpace(inboxPath), strings.TrimSpace(destinationPath)); deviceSync != nil {
 		result.DeviceSync = deviceSync
 		result.CandidateSourcePaths = append(result.CandidateSourcePaths, deviceSync.CandidateSourcePaths...)
 		if deviceSync.Status == "failed" {
@@ -245,6 +305,11 @@ func (service *Service) importFIT() FITImportResult {
 
 	existingFingerprints := service.existingFITFingerprints(destinationPath, sourcePath, &result)
 	createdYears := map[string]struct{}{}
+	progress := events.Start(events.OperationFITImport, "", len(fitFiles(sourcePath)), sourcePath)
+	defer func() {
+		progress.Update(sourceKind, 0, result.ScannedFiles, 0)
+		progress.Complete(result.Message, result.Errors...)
+	}()
 
 	err := filepath.WalkDir(sourcePath, func(path string, entry os.DirEntry, walkErr error) error {
 		if walkErr != nil {
@@ -257,6 +322,7 @@ func (service *Service) importFIT() FITImportResult {
 		if !strings.EqualFold(filepath.Ext(entry.Name()), ".fit") {
 			return nil
 		}
+		progress.Update(sourceKind, 0, result.ScannedFiles, 0)
 		result.ScannedFiles++
 		activity, decodeErr := service.decodeFIT(path, 0)
 		if decodeErr != nil {
@@ -295,6 +361,7 @@ func (service *Service) importFIT() FITImportResult {
 		}
 
 		result.ImportedFiles++
+		result.importedActivityIDs = append(result.importedActivityIDs, activity.Id)
 		if fingerprint != "" {
 			existingFingerprints[fingerprint] = true
 		}
@@ -322,7 +389,9 @@ func (service *Service) importFIT() FITImportResult {
 	return result
 }
 
-func (service *Service) syncGarminToInbox(inboxPath string) *FITDeviceSyncResult {
+// syncGarminToInbox copies new activity files from a mounted Garmin directory
+// into the inbox, or from a watch reached over MTP when none is mounted.
+func (service *Service) syncGarminToInbox(inboxPath string, destinationPath string) *FITDeviceSyncResult {
 	if strings.TrimSpace(inboxPath) == "" {
 		return nil
 	}
@@ -335,6 +404,12 @@ func (service *Service) syncGarminToInbox(inboxPath string) *FITDeviceSyncResult
 		CandidateSourcePaths: candidates,
 	}
 	if device == nil {
+		if service.openMTPDevices != nil && service.syncMTPToInbox(&result, destinationPath) {
+			return &result
+		}
+		if len(result.Errors) > 0 {
+			result.Message = "No mounted Garmin activity directory or MTP device was detected."
+		}
 		return &result
 	}
 	if err := os.MkdirAll(inboxPath, 0o755); err != nil {
@@ -426,16 +501,27 @@ func (service *Service) detectGarminDevice() (*garminDevice, []string) {
 }
 
 func (service *Service) existingFITFingerprints(destinationPath string, sourcePath string, result *FITImportResult) map[string]bool {
+	return service.existingActivityFingerprints(destinationPath, ".fit", sourcePath, func(path string) (*strava.Activity, error) {
+		return service.decodeFIT(path, 0)
+	})
+}
+
+func (service *Service) existingActivityFingerprints(
+	destinationPath string,
+	extension string,
+	sourcePath string,
+	decode func(path string) (*strava.Activity, error),
+) map[string]bool {
 	fingerprints := map[string]bool{}
 	cleanSourcePath := filepath.Clean(strings.TrimSpace(sourcePath))
 	_ = filepath.WalkDir(destinationPath, func(path string, entry os.DirEntry, walkErr error) error {
-		if walkErr != nil || entry == nil || entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".fit") {
+		if walkErr != nil || entry == nil || entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), extension) {
 			return nil
 		}
 		if cleanSourcePath != "" && sameOrInside(path, cleanSourcePath) {
 			return nil
 		}
-		activity, err := service.decodeFIT(path, 0)
+		activity, err := decode(path)
 		if err != nil {
 			return nil
 		}
@@ -690,6 +776,9 @@ func garminDeviceSyncMessage(result FITDeviceSyncResult) string {
 		return fmt.Sprintf("Copied %d FIT file(s) from Garmin source to inbox.", result.CopiedFiles)
 	}
 	if result.ScannedFiles == 0 {
+		if result.Backend == "mtp" {
+			return "Garmin MTP device was found, but its activity folder contains no FIT files."
+		}
 		return "Mounted Garmin activity directory was found, but it contains no FIT files."
 	}
 	return fmt.Sprintf("%d FIT file(s) already present in inbox.", result.AlreadyPresentFiles)
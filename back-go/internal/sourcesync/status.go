@@ -0,0 +1,110 @@
+package sourcesync
+
+import (
+	"time"
+
+	"mystravastats/internal/platform/events"
+)
+
+// SyncRunStatus tells whether a synchronization is in flight and, if so, which
+// step it is running.
+type SyncRunStatus struct {
+	Running    bool           `json:"running"`
+	Reason     string         `json:"reason,omitempty"`
+	Stage      string         `json:"stage,omitempty"`
+	StartedAt  string         `json:"startedAt,omitempty"`
+	ElapsedMs  int64          `json:"elapsedMs,omitempty"`
+	Schedule   ScheduleStatus `json:"schedule"`
+	LastResult SyncResult     `json:"lastResult"`
+}
+
+// ScheduleStatus describes the background synchronization schedule.
+type ScheduleStatus struct {
+	Enabled       bool   `json:"enabled"`
+	Expression    string `json:"expression,omitempty"`
+	StravaRefresh bool   `json:"stravaRefresh"`
+	NextRunAt     string `json:"nextRunAt,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+type runState struct {
+	reason    string
+	stage     string
+	startedAt time.Time
+	progress  *events.Progress
+}
+
+func Status() SyncRunStatus {
+	return defaultService.Status()
+}
+
+func (service *Service) Status() SyncRunStatus {
+	status := SyncRunStatus{LastResult: service.LastResult()}
+	service.runMutex.Lock()
+	defer service.runMutex.Unlock()
+	status.Schedule = service.schedule
+	status.Running = service.running.Load()
+	if status.Running && service.currentRun != nil {
+		status.Reason = service.currentRun.reason
+		status.Stage = service.currentRun.stage
+		status.StartedAt = service.currentRun.startedAt.UTC().Format(time.RFC3339)
+		status.ElapsedMs = service.now().Sub(service.currentRun.startedAt).Milliseconds()
+	}
+	return status
+}
+
+// beginRun takes the running flag shared by every kind of synchronization. It
+// returns false when another run holds it.
+func (service *Service) beginRun(reason string) bool {
+	if !service.running.CompareAndSwap(false, true) {
+		return false
+	}
+	progress := events.Start(events.OperationSourceSync, "", 0, reason)
+	service.runMutex.Lock()
+	service.currentRun = &runState{reason: reason, stage: "starting", startedAt: service.now(), progress: progress}
+	service.runMutex.Unlock()
+	return true
+}
+
+func (service *Service) setRunStage(stage string) {
+	service.runMutex.Lock()
+	var progress *events.Progress
+	if service.currentRun != nil {
+		service.currentRun.stage = stage
+		progress = service.currentRun.progress
+	}
+	service.runMutex.Unlock()
+	progress.Update(stage, 0, 0, 0)
+}
+
+// finishRun publishes the outcome of the run in flight on the event bus.
+func (service *Service) finishRun(result SyncResult) {
+	service.runMutex.Lock()
+	var progress *events.Progress
+	if service.currentRun != nil {
+		progress = service.currentRun.progress
+	}
+	service.runMutex.Unlock()
+	if result.Status == "failed" {
+		progress.Fail(result.Message, syncResultErrors(result)...)
+		return
+	}
+	progress.Complete(result.Message, syncResultErrors(result)...)
+}
+
+func (service *Service) endRun() {
+	service.runMutex.Lock()
+	if service.currentRun != nil {
+		// No-op when finishRun already published the outcome.
+		service.currentRun.progress.Complete("")
+	}
+	service.currentRun = nil
+	service.runMutex.Unlock()
+	service.running.Store(false)
+}
+
+func (service *Service) setSchedule(schedule ScheduleStatus) {
+	service.runMutex.Lock()
+	service.schedule = schedule
+	service.runMutex.Unlock()
+}
@@ -0,0 +1,433 @@
+package sourcesync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"mystravastats/domain/statistics"
+	"mystravastats/internal/platform/activityprovider"
+	"mystravastats/internal/platform/runtimeconfig"
+	"mystravastats/internal/shared/domain/business"
+)
+
+const (
+	watchEnabledEnv         = "LOCAL_SOURCE_WATCH_ENABLED"
+	watchIntervalEnv        = "LOCAL_SOURCE_WATCH_INTERVAL_MS"
+	watchDebounceEnv        = "LOCAL_SOURCE_WATCH_DEBOUNCE_MS"
+	defaultWatchIntervalMs  = 5000
+	defaultWatchDebounceMs  = 3000
+	minimumWatchIntervalMs  = 500
+	maxReportedChangedFiles = 50
+	watchBackendPolling     = "polling"
+)
+
+// WatchResult describes the file changes that triggered a watcher reload.
+type WatchResult struct {
+	Backend                string   `json:"backend"`
+	Roots                  []string `json:"roots"`
+	AddedFiles             int      `json:"addedFiles"`
+	ModifiedFiles          int      `json:"modifiedFiles"`
+	RemovedFiles           int      `json:"removedFiles"`
+	ChangedFiles           []string `json:"changedFiles"`
+	AffectedActivityIDs    []int64  `json:"affectedActivityIds"`
+	InvalidatedBestEfforts int      `json:"invalidatedBestEfforts"`
+}
+
+type watchRoot struct {
+	athleteID string
+	kind      string
+	path      string
+	extension string
+}
+
+type watchedFileState struct {
+	size    int64
+	modTime int64
+}
+
+type fileChange string
+
+const (
+	fileAdded    fileChange = "added"
+	fileModified fileChange = "modified"
+	fileRemoved  fileChange = "removed"
+)
+
+// Watcher polls the local FIT/GPX/TCX/JSON year folders of every athlete and
+// reloads the providers owning the changed folders once a burst of changes has
+// settled for the debounce window.
+type Watcher struct {
+	service          *Service
+	roots            func() []watchRoot
+	interval         time.Duration
+	debounce         time.Duration
+	activitySnapshot func(athleteID string) map[int64]string
+	reloadAthlete    func(athleteID string)
+	invalidate       func(activityIDs map[int64]struct{}) int
+	now              func() time.Time
+
+	files        map[string]watchedFileState
+	pending      map[string]fileChange
+	lastChangeAt time.Time
+}
+
+func NewWatcher(service *Service) *Watcher {
+	intervalMs := runtimeconfig.IntValue(watchIntervalEnv, defaultWatchIntervalMs)
+	if intervalMs < minimumWatchIntervalMs {
+		intervalMs = minimumWatchIntervalMs
+	}
+	debounceMs := runtimeconfig.IntValue(watchDebounceEnv, defaultWatchDebounceMs)
+	if debounceMs < 0 {
+		debounceMs = 0
+	}
+
+	return &Watcher{
+		service:          service,
+		roots:            configuredWatchRoots,
+		interval:         time.Duration(intervalMs) * time.Millisecond,
+		debounce:         time.Duration(debounceMs) * time.Millisecond,
+		activitySnapshot: providerActivitySnapshot,
+		reloadAthlete:    activityprovider.ReloadAthlete,
+		invalidate:       statistics.InvalidateBestEffortCacheByActivityIDs,
+		now:              time.Now,
+		pending:          make(map[string]fileChange),
+	}
+}
+
+// StartWatcher starts the local source watcher when LOCAL_SOURCE_WATCH_ENABLED
+// is true. It stops when ctx is cancelled.
+func StartWatcher(ctx context.Context) {
+	if !runtimeconfig.BoolValue(watchEnabledEnv, false) {
+		return
+	}
+	watcher := NewWatcher(defaultService)
+	if len(watcher.roots()) == 0 {
+		log.Printf("Local source watcher enabled but no athlete has a FIT, GPX, TCX or JSON folder configured")
+		return
+	}
+	go watcher.Run(ctx)
+}
+
+func (watcher *Watcher) Run(ctx context.Context) {
+	watcher.files = watcher.scan()
+	log.Printf("Local source watcher started (%s every %s, debounce %s) on %s",
+		watchBackendPolling, watcher.interval, watcher.debounce, strings.Join(watcher.rootPaths(), ", "))
+
+	ticker := time.NewTicker(watcher.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			watcher.Poll()
+		}
+	}
+}
+
+// Poll compares the folders with the previous scan and reloads the provider
+// when pending changes are older than the debounce window. It returns true
+// when a reload happened.
+func (watcher *Watcher) Poll() bool {
+	current := watcher.scan()
+	if watcher.files == nil {
+		watcher.files = current
+		return false
+	}
+
+	if changes := diffWatchedFiles(watcher.files, current); len(changes) > 0 {
+		for path, change := range changes {
+			watcher.pending[path] = mergeFileChange(watcher.pending[path], change)
+		}
+		watcher.lastChangeAt = watcher.now()
+	}
+	watcher.files = current
+
+	if len(watcher.pending) == 0 || watcher.now().Sub(watcher.lastChangeAt) < watcher.debounce {
+		return false
+	}
+	return watcher.flush()
+}
+
+func (watcher *Watcher) flush() bool {
+	service := watcher.service
+	if !service.beginRun("watch") {
+		// A synchronization is in progress; keep the changes for the next poll.
+		return false
+	}
+	defer service.endRun()
+
+	pending := watcher.pending
+	watcher.pending = make(map[string]fileChange)
+
+	startedAt := service.now()
+	affected := make(map[int64]struct{})
+	athleteIDs := watcher.changedAthletes(pending)
+	for _, athleteID := range athleteIDs {
+		before := watcher.activitySnapshot(athleteID)
+		watcher.reloadAthlete(athleteID)
+		for activityID := range diffActivitySnapshots(before, watcher.activitySnapshot(athleteID)) {
+			affected[activityID] = struct{}{}
+		}
+	}
+	invalidated := 0
+	if len(affected) > 0 && watcher.invalidate != nil {
+		invalidated = watcher.invalidate(affected)
+	}
+
+	watch := buildWatchResult(watcher.rootPaths(), pending, affected, invalidated)
+	completedAt := service.now()
+	result := SyncResult{
+		Status:      "completed",
+		Reason:      "watch",
+		Message:     watchMessage(watch),
+		StartedAt:   startedAt.UTC().Format(time.RFC3339),
+		CompletedAt: completedAt.UTC().Format(time.RFC3339),
+		DurationMs:  completedAt.Sub(startedAt).Milliseconds(),
+		Reloaded:    len(athleteIDs) > 0,
+		FIT:         service.LastResult().FIT,
+		Watch:       &watch,
+	}
+	service.storeLastResult(result)
+	log.Printf("%s", result.Message)
+	return true
+}
+
+func (watcher *Watcher) scan() map[string]watchedFileState {
+	files := make(map[string]watchedFileState)
+	for _, root := range watcher.roots() {
+		entries, err := os.ReadDir(root.path)
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				log.Printf("Unable to list %s directory %s: %v", strings.ToUpper(root.kind), root.path, err)
+			}
+			continue
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() || !isYearDirectoryName(entry.Name()) {
+				continue
+			}
+			yearDirectory := filepath.Join(root.path, entry.Name())
+			yearEntries, err := os.ReadDir(yearDirectory)
+			if err != nil {
+				continue
+			}
+			for _, yearEntry := range yearEntries {
+				if yearEntry.IsDir() || !strings.EqualFold(filepath.Ext(yearEntry.Name()), root.extension) {
+					continue
+				}
+				info, err := yearEntry.Info()
+				if err != nil {
+					continue
+				}
+				files[filepath.Join(yearDirectory, yearEntry.Name())] = watchedFileState{
+					size:    info.Size(),
+					modTime: info.ModTime().UnixNano(),
+				}
+			}
+		}
+	}
+	return files
+}
+
+func (watcher *Watcher) rootPaths() []string {
+	roots := watcher.roots()
+	paths := make([]string, 0, len(roots))
+	for _, root := range roots {
+		paths = append(paths, root.path)
+	}
+	return paths
+}
+
+// changedAthletes returns the athletes owning the year folders of the pending
+// files, so that only their providers are reloaded.
+func (watcher *Watcher) changedAthletes(pending map[string]fileChange) []string {
+	owners := make(map[string]string)
+	for _, root := range watcher.roots() {
+		owners[root.path] = root.athleteID
+	}
+	seen := make(map[string]struct{})
+	athleteIDs := make([]string, 0, 1)
+	for path := range pending {
+		athleteID, owned := owners[filepath.Dir(filepath.Dir(path))]
+		if !owned {
+			continue
+		}
+		if _, duplicate := seen[athleteID]; duplicate {
+			continue
+		}
+		seen[athleteID] = struct{}{}
+		athleteIDs = append(athleteIDs, athleteID)
+	}
+	sort.Strings(athleteIDs)
+	return athleteIDs
+}
+
+// configuredWatchRoots lists the local source folders of every athlete served
+// by this instance, from the athletes file or the *_FILES_PATH variables.
+func configuredWatchRoots() []watchRoot {
+	roots := make([]watchRoot, 0, 4)
+	for _, athlete := range activityprovider.Athletes() {
+		roots = append(roots, athleteWatchRoots(athlete.ID)...)
+	}
+	return roots
+}
+
+// athleteWatchRoots lists the FIT, GPX, TCX and JSON folders of one athlete.
+func athleteWatchRoots(athleteID string) []watchRoot {
+	sourceRoots, ok := activityprovider.SourceRoots(athleteID)
+	if !ok {
+		return nil
+	}
+	candidates := []watchRoot{
+		{kind: "fit", extension: ".fit"},
+		{kind: "gpx", extension: ".gpx"},
+		{kind: "tcx", extension: ".tcx"},
+		{kind: "json", extension: ".json"},
+	}
+	roots := make([]watchRoot, 0, len(candidates))
+	for _, candidate := range candidates {
+		path, configured := sourceRoots[candidate.kind]
+		if !configured {
+			continue
+		}
+		candidate.athleteID = athleteID
+		candidate.path = filepath.Clean(path)
+		roots = append(roots, candidate)
+	}
+	return roots
+}
+
+// providerActivitySnapshot fingerprints every activity of the athlete so a
+// reload can be reduced to the IDs whose summary or stream actually changed.
+// Providers that have not been built yet have nothing to fingerprint.
+func providerActivitySnapshot(athleteID string) map[int64]string {
+	snapshot := make(map[int64]string)
+	provider, loaded := activityprovider.LoadedFor(athleteID)
+	if !loaded {
+		return snapshot
+	}
+
+	activityTypes := make([]business.ActivityType, 0, len(business.ActivityTypes))
+	for _, activityType := range business.ActivityTypes {
+		activityTypes = append(activityTypes, activityType)
+	}
+
+	for _, activity := range provider.GetActivitiesByYearAndActivityTypes(nil, activityTypes...) {
+		if activity == nil {
+			continue
+		}
+		streamSize := 0
+		if activity.Stream != nil {
+			streamSize = len(activity.Stream.Time.Data)
+		}
+		snapshot[activity.Id] = fmt.Sprintf("%s|%s|%.3f|%.3f|%d|%d|%d",
+			activity.StartDate, activity.SportType, activity.Distance, activity.TotalElevationGain,
+			activity.ElapsedTime, activity.MovingTime, streamSize)
+	}
+	return snapshot
+}
+
+func diffWatchedFiles(previous map[string]watchedFileState, current map[string]watchedFileState) map[string]fileChange {
+	changes := make(map[string]fileChange)
+	for path, state := range current {
+		previousState, existed := previous[path]
+		switch {
+		case !existed:
+			changes[path] = fileAdded
+		case previousState != state:
+			changes[path] = fileModified
+		}
+	}
+	for path := range previous {
+		if _, exists := current[path]; !exists {
+			changes[path] = fileRemoved
+		}
+	}
+	return changes
+}
+
+// mergeFileChange keeps the net effect of several changes seen for the same
+// file during one debounce window.
+func mergeFileChange(previous fileChange, next fileChange) fileChange {
+	switch {
+	case previous == "":
+		return next
+	case previous == fileAdded && next == fileModified:
+		return fileAdded
+	case previous == fileRemoved && next == fileAdded:
+		return fileModified
+	default:
+		return next
+	}
+}
+
+func diffActivitySnapshots(before map[int64]string, after map[int64]string) map[int64]struct{} {
+	affected := make(map[int64]struct{})
+	for activityID, signature := range after {
+		if previous, existed := before[activityID]; !existed || previous != signature {
+			affected[activityID] = struct{}{}
+		}
+	}
+	for activityID := range before {
+		if _, exists := after[activityID]; !exists {
+			affected[activityID] = struct{}{}
+		}
+	}
+	return affected
+}
+
+func buildWatchResult(roots []string, pending map[string]fileChange, affected map[int64]struct{}, invalidated int) WatchResult {
+	result := WatchResult{
+		Backend:                watchBackendPolling,
+		Roots:                  roots,
+		ChangedFiles:           make([]string, 0, len(pending)),
+		AffectedActivityIDs:    make([]int64, 0, len(affected)),
+		InvalidatedBestEfforts: invalidated,
+	}
+	for path, change := range pending {
+		switch change {
+		case fileAdded:
+			result.AddedFiles++
+		case fileModified:
+			result.ModifiedFiles++
+		case fileRemoved:
+			result.RemovedFiles++
+		}
+		result.ChangedFiles = append(result.ChangedFiles, path)
+	}
+	sort.Strings(result.ChangedFiles)
+	if len(result.ChangedFiles) > maxReportedChangedFiles {
+		result.ChangedFiles = result.ChangedFiles[:maxReportedChangedFiles]
+	}
+	for activityID := range affected {
+		result.AffectedActivityIDs = append(result.AffectedActivityIDs, activityID)
+	}
+	sort.Slice(result.AffectedActivityIDs, func(i, j int) bool {
+		return result.AffectedActivityIDs[i] < result.AffectedActivityIDs[j]
+	})
+	return result
+}
+
+func watchMessage(result WatchResult) string {
+	return fmt.Sprintf(
+		"Local source watcher reloaded after %d added, %d modified and %d removed file(s); %d activity(ies) affected.",
+		result.AddedFiles, result.ModifiedFiles, result.RemovedFiles, len(result.AffectedActivityIDs),
+	)
+}
+
+func isYearDirectoryName(name string) bool {
+	if len(name) != 4 {
+		return false
+	}
+	_, err := strconv.Atoi(name)
+	return err == nil
+}
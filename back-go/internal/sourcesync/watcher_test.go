@@ -0,0 +1,165 @@
+package sourcesync
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatcherPoll_DebouncesBurstThenReloadsAndInvalidatesAffectedActivities(t *testing.T) {
+	fitDirectory := t.TempDir()
+	yearDirectory := filepath.Join(fitDirectory, "2026")
+	if err := os.MkdirAll(yearDirectory, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(yearDirectory, "existing.fit"), []byte("fit"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	reloadCount := 0
+	snapshots := []map[int64]string{
+		{1: "existing", 2: "edited-before"},
+		{1: "existing", 2: "edited-after", 3: "new"},
+	}
+	var invalidated map[int64]struct{}
+	clock := time.Date(2026, 6, 3, 8, 0, 0, 0, time.UTC)
+	service := testService(fitDirectory, t.TempDir(), nil, nil)
+	watcher := testWatcher(service, fitDirectory, func() time.Time { return clock })
+	watcher.reloadAthlete = func(string) { reloadCount++ }
+	watcher.activitySnapshot = func(string) map[int64]string {
+		snapshot := snapshots[0]
+		snapshots = snapshots[1:]
+		return snapshot
+	}
+	watcher.invalidate = func(activityIDs map[int64]struct{}) int {
+		invalidated = activityIDs
+		return 4
+	}
+	watcher.Poll()
+
+	if err := os.WriteFile(filepath.Join(yearDirectory, "first.fit"), []byte("fit"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if watcher.Poll() {
+		t.Fatal("expected reload to wait for the debounce window")
+	}
+	clock = clock.Add(time.Second)
+	if err := os.WriteFile(filepath.Join(yearDirectory, "second.fit"), []byte("fit"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if watcher.Poll() {
+		t.Fatal("expected second change to extend the debounce window")
+	}
+
+	clock = clock.Add(3 * time.Second)
+	reloaded := watcher.Poll()
+
+	if !reloaded || reloadCount != 1 {
+		t.Fatalf("expected one debounced reload, reloaded=%t count=%d", reloaded, reloadCount)
+	}
+	if len(invalidated) != 2 {
+		t.Fatalf("expected edited and new activities to be invalidated, got %#v", invalidated)
+	}
+	if _, ok := invalidated[1]; ok {
+		t.Fatalf("expected unchanged activity to keep its cache entries, got %#v", invalidated)
+	}
+	result := service.LastResult()
+	if result.Reason != "watch" || result.Watch == nil {
+		t.Fatalf("expected watcher result in last sync result, got %#v", result)
+	}
+	if result.Watch.AddedFiles != 2 || result.Watch.InvalidatedBestEfforts != 4 {
+		t.Fatalf("expected 2 added files and 4 invalidated entries, got %#v", result.Watch)
+	}
+}
+
+func TestWatcherPoll_KeepsChangesWhileSynchronizationIsRunning(t *testing.T) {
+	fitDirectory := t.TempDir()
+	yearDirectory := filepath.Join(fitDirectory, "2026")
+	if err := os.MkdirAll(yearDirectory, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	reloadCount := 0
+	service := testService(fitDirectory, t.TempDir(), nil, nil)
+	watcher := testWatcher(service, fitDirectory, time.Now)
+	watcher.reloadAthlete = func(string) { reloadCount++ }
+	watcher.debounce = 0
+	watcher.Poll()
+	if err := os.WriteFile(filepath.Join(yearDirectory, "ride.fit"), []byte("fit"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	service.running.Store(true)
+	if watcher.Poll() {
+		t.Fatal("expected watcher to skip reload while synchronization runs")
+	}
+	service.running.Store(false)
+
+	if !watcher.Poll() || reloadCount != 1 {
+		t.Fatalf("expected pending change to reload on the next poll, count=%d", reloadCount)
+	}
+	if service.LastResult().Watch.AddedFiles != 1 {
+		t.Fatalf("expected pending added file to be reported, got %#v", service.LastResult().Watch)
+	}
+}
+
+func TestWatcherPoll_ReloadsOnlyTheAthleteOwningTheChangedFolder(t *testing.T) {
+	// GIVEN
+	aliceDirectory := t.TempDir()
+	bobDirectory := t.TempDir()
+	for _, directory := range []string{aliceDirectory, bobDirectory} {
+		if err := os.MkdirAll(filepath.Join(directory, "2026"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	service := testService(aliceDirectory, t.TempDir(), nil, nil)
+	watcher := testWatcher(service, aliceDirectory, time.Now)
+	watcher.roots = func() []watchRoot {
+		return []watchRoot{
+			{athleteID: "alice", kind: "fit", path: aliceDirectory, extension: ".fit"},
+			{athleteID: "bob", kind: "gpx", path: bobDirectory, extension: ".gpx"},
+		}
+	}
+	reloaded := make([]string, 0)
+	snapshotted := make(map[string]int)
+	watcher.reloadAthlete = func(athleteID string) { reloaded = append(reloaded, athleteID) }
+	watcher.activitySnapshot = func(athleteID string) map[int64]string {
+		snapshotted[athleteID]++
+		return map[int64]string{}
+	}
+	watcher.debounce = 0
+	watcher.Poll()
+
+	// WHEN
+	if err := os.WriteFile(filepath.Join(bobDirectory, "2026", "walk.gpx"), []byte("gpx"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	watcher.Poll()
+
+	// THEN
+	if len(reloaded) != 1 || reloaded[0] != "bob" {
+		t.Fatalf("expected only bob to be reloaded, got %v", reloaded)
+	}
+	if snapshotted["alice"] != 0 || snapshotted["bob"] != 2 {
+		t.Fatalf("expected only bob to be snapshotted before and after the reload, got %v", snapshotted)
+	}
+	if !service.LastResult().Reloaded {
+		t.Fatalf("expected watcher result to report the reload, got %#v", service.LastResult())
+	}
+}
+
+func testWatcher(service *Service, fitDirectory string, now func() time.Time) *Watcher {
+	return &Watcher{
+		service: service,
+		roots: func() []watchRoot {
+			return []watchRoot{{athleteID: "default", kind: "fit", path: fitDirectory, extension: ".fit"}}
+		},
+		interval:         time.Second,
+		debounce:         3 * time.Second,
+		activitySnapshot: func(string) map[int64]string { return map[int64]string{} },
+		reloadAthlete:    func(string) {},
+		invalidate:       func(map[int64]struct{}) int { return 0 },
+		now:              now,
+		pending:          make(map[string]fileChange),
+	}
+}
@@ -16,3 +16,20 @@ type StatisticsReader interface {
 type PersonalRecordsTimelineReader interface {
 	FindPersonalRecordsTimelineByYearMetricAndTypes(year *int, metric *string, activityTypes ...business.ActivityType) []business.PersonalRecordTimelineEntry
 }
+
+// CustomStatisticsStore is an outbound port persisting the custom statistic
+// definitions of an activity type selection.
+type CustomStatisticsStore interface {
+	FindCustomStatisticDefinitions(activityTypes ...business.ActivityType) []business.CustomStatisticDefinition
+	SaveCustomStatisticDefinitions(definitions []business.CustomStatisticDefinition, activityTypes ...business.ActivityType) []business.CustomStatisticDefinition
+}
+
+// PowerCurveReader is an outbound port used by power curve use cases.
+type PowerCurveReader interface {
+	FindPowerCurve(year *int, windowDays int, activityID *int64, activityTypes ...business.ActivityType) business.PowerCurve
+}
+
+// PaceCurveReader is an outbound port used by pace curve use cases.
+type PaceCurveReader interface {
+	FindPaceCurve(year *int, windowDays int, gradeAdjusted bool, activityTypes ...business.ActivityType) business.PaceCurve
+}
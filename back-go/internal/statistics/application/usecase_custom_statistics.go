@@ -0,0 +1,62 @@
+package application
+
+import (
+	"fmt"
+	domainStatistics "mystravastats/domain/statistics"
+	"mystravastats/internal/shared/domain/business"
+	"strings"
+)
+
+type GetCustomStatisticsUseCase struct {
+	store CustomStatisticsStore
+}
+
+func NewGetCustomStatisticsUseCase(store CustomStatisticsStore) *GetCustomStatisticsUseCase {
+	return &GetCustomStatisticsUseCase{
+		store: store,
+	}
+}
+
+func (uc *GetCustomStatisticsUseCase) Execute(activityTypes []business.ActivityType) []business.CustomStatisticDefinition {
+	definitions := uc.store.FindCustomStatisticDefinitions(activityTypes...)
+	if definitions == nil {
+		return []business.CustomStatisticDefinition{}
+	}
+
+	return definitions
+}
+
+type UpdateCustomStatisticsUseCase struct {
+	store CustomStatisticsStore
+}
+
+func NewUpdateCustomStatisticsUseCase(store CustomStatisticsStore) *UpdateCustomStatisticsUseCase {
+	return &UpdateCustomStatisticsUseCase{
+		store: store,
+	}
+}
+
+// Execute replaces the custom statistics of the activity types. Nothing is
+// saved unless every definition has a unique label and a valid expression.
+func (uc *UpdateCustomStatisticsUseCase) Execute(definitions []business.CustomStatisticDefinition, activityTypes []business.ActivityType) ([]business.CustomStatisticDefinition, error) {
+	normalized := make([]business.CustomStatisticDefinition, 0, len(definitions))
+	labels := make(map[string]struct{}, len(definitions))
+	for index, definition := range definitions {
+		label := strings.TrimSpace(definition.Label)
+		if label == "" {
+			return nil, fmt.Errorf("statistic %d: label is required", index+1)
+		}
+		if _, duplicate := labels[strings.ToLower(label)]; duplicate {
+			return nil, fmt.Errorf("statistic %q is defined twice", label)
+		}
+		labels[strings.ToLower(label)] = struct{}{}
+
+		expression, err := domainStatistics.ParseCustomExpression(definition.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("statistic %q: %w", label, err)
+		}
+		normalized = append(normalized, business.CustomStatisticDefinition{Label: label, Expression: expression.String()})
+	}
+
+	return uc.store.SaveCustomStatisticDefinitions(normalized, activityTypes...), nil
+}
@@ -0,0 +1,88 @@
+package application
+
+import (
+	"mystravastats/internal/shared/domain/business"
+	"strings"
+	"testing"
+)
+
+type customStatisticsStoreStub struct {
+	definitions   []business.CustomStatisticDefinition
+	receivedTypes []business.ActivityType
+	saves         int
+}
+
+func (stub *customStatisticsStoreStub) FindCustomStatisticDefinitions(activityTypes ...business.ActivityType) []business.CustomStatisticDefinition {
+	stub.receivedTypes = append([]business.ActivityType(nil), activityTypes...)
+	return stub.definitions
+}
+
+func (stub *customStatisticsStoreStub) SaveCustomStatisticDefinitions(definitions []business.CustomStatisticDefinition, activityTypes ...business.ActivityType) []business.CustomStatisticDefinition {
+	stub.saves++
+	stub.receivedTypes = append([]business.ActivityType(nil), activityTypes...)
+	stub.definitions = definitions
+	return definitions
+}
+
+func TestGetCustomStatisticsUseCase_Execute_ReturnsEmptySliceWhenNil(t *testing.T) {
+	// GIVEN
+	useCase := NewGetCustomStatisticsUseCase(&customStatisticsStoreStub{})
+
+	// WHEN
+	result := useCase.Execute([]business.ActivityType{business.Run})
+
+	// THEN
+	if result == nil || len(result) != 0 {
+		t.Fatalf("expected empty non-nil slice, got %#v", result)
+	}
+}
+
+func TestUpdateCustomStatisticsUseCase_Execute_SavesNormalizedDefinitions(t *testing.T) {
+	// GIVEN
+	store := &customStatisticsStoreStub{}
+	useCase := NewUpdateCustomStatisticsUseCase(store)
+
+	// WHEN
+	result, err := useCase.Execute([]business.CustomStatisticDefinition{
+		{Label: "  Best 15 km ", Expression: " best_distance(15 km) "},
+		{Label: "Commutes", Expression: "count() where commute"},
+	}, []business.ActivityType{business.Ride})
+
+	// THEN
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if store.saves != 1 || len(result) != 2 {
+		t.Fatalf("expected 2 definitions saved once, got %d saves and %#v", store.saves, result)
+	}
+	if result[0].Label != "Best 15 km" || result[0].Expression != "best_distance(15 km)" {
+		t.Fatalf("expected trimmed definition, got %#v", result[0])
+	}
+	if len(store.receivedTypes) != 1 || store.receivedTypes[0] != business.Ride {
+		t.Fatalf("expected Ride to be forwarded, got %v", store.receivedTypes)
+	}
+}
+
+func TestUpdateCustomStatisticsUseCase_Execute_RejectsInvalidDefinitions(t *testing.T) {
+	cases := map[string][]business.CustomStatisticDefinition{
+		"label is required": {{Label: " ", Expression: "count()"}},
+		"is defined twice":  {{Label: "Rides", Expression: "count()"}, {Label: "rides", Expression: "count()"}},
+		"unknown function":  {{Label: "Median", Expression: "median(distance)"}},
+	}
+	for expected, definitions := range cases {
+		// GIVEN
+		store := &customStatisticsStoreStub{}
+		useCase := NewUpdateCustomStatisticsUseCase(store)
+
+		// WHEN
+		_, err := useCase.Execute(definitions, []business.ActivityType{business.Ride})
+
+		// THEN
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected error containing %q, got %v", expected, err)
+		}
+		if store.saves != 0 {
+			t.Fatalf("expected nothing to be saved, got %d saves", store.saves)
+		}
+	}
+}
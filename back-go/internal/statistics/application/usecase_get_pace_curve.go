@@ -0,0 +1,37 @@
+package application
+
+import (
+	"mystravastats/internal/shared/domain/business"
+)
+
+type GetPaceCurveUseCase struct {
+	reader PaceCurveReader
+}
+
+func NewGetPaceCurveUseCase(reader PaceCurveReader) *GetPaceCurveUseCase {
+	return &GetPaceCurveUseCase{
+		reader: reader,
+	}
+}
+
+// Execute returns the best pace curve of the activities of the year,
+// restricted to the last windowDays days when windowDays is positive, with
+// the race predictions and the VDOT trend derived from it. With gradeAdjusted
+// the curve is built on the grade-adjusted pace.
+func (uc *GetPaceCurveUseCase) Execute(year *int, windowDays int, gradeAdjusted bool, activityTypes []business.ActivityType) business.PaceCurve {
+	if windowDays < 0 {
+		windowDays = 0
+	}
+
+	curve := uc.reader.FindPaceCurve(year, windowDays, gradeAdjusted, activityTypes...)
+	if curve.Points == nil {
+		curve.Points = []business.PaceCurvePoint{}
+	}
+	if curve.Predictions == nil {
+		curve.Predictions = []business.RacePrediction{}
+	}
+	if curve.VdotTrend == nil {
+		curve.VdotTrend = []business.VdotTrendPoint{}
+	}
+	return curve
+}
@@ -0,0 +1,33 @@
+package application
+
+import (
+	"mystravastats/internal/shared/domain/business"
+	"testing"
+)
+
+type paceCurveReaderStub struct {
+	curve              business.PaceCurve
+	receivedWindowDays int
+}
+
+func (stub *paceCurveReaderStub) FindPaceCurve(_ *int, windowDays int, _ bool, _ ...business.ActivityType) business.PaceCurve {
+	stub.receivedWindowDays = windowDays
+	return stub.curve
+}
+
+func TestGetPaceCurveUseCase_Execute_ReturnsEmptySlicesWhenNil(t *testing.T) {
+	// GIVEN
+	reader := &paceCurveReaderStub{}
+	useCase := NewGetPaceCurveUseCase(reader)
+
+	// WHEN
+	result := useCase.Execute(nil, -5, false, []business.ActivityType{business.Run})
+
+	// THEN
+	if result.Points == nil || result.Predictions == nil || result.VdotTrend == nil {
+		t.Fatalf("expected non-nil slices, got %#v", result)
+	}
+	if reader.receivedWindowDays != 0 {
+		t.Fatalf("expected negative window to be ignored, got %d", reader.receivedWindowDays)
+	}
+}
@@ -0,0 +1,30 @@
+package application
+
+import (
+	"mystravastats/internal/shared/domain/business"
+)
+
+type GetPowerCurveUseCase struct {
+	reader PowerCurveReader
+}
+
+func NewGetPowerCurveUseCase(reader PowerCurveReader) *GetPowerCurveUseCase {
+	return &GetPowerCurveUseCase{
+		reader: reader,
+	}
+}
+
+// Execute returns the mean-maximal power curve of one activity when
+// activityID is set, otherwise of the activities of the year, restricted to
+// the last windowDays days when windowDays is positive.
+func (uc *GetPowerCurveUseCase) Execute(year *int, windowDays int, activityID *int64, activityTypes []business.ActivityType) business.PowerCurve {
+	if windowDays < 0 || activityID != nil {
+		windowDays = 0
+	}
+
+	curve := uc.reader.FindPowerCurve(year, windowDays, activityID, activityTypes...)
+	if curve.Points == nil {
+		curve.Points = []business.PowerCurvePoint{}
+	}
+	return curve
+}
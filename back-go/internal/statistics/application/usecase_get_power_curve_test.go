@@ -0,0 +1,50 @@
+package application
+
+import (
+	"mystravastats/internal/shared/domain/business"
+	"testing"
+)
+
+type powerCurveReaderStub struct {
+	curve              business.PowerCurve
+	receivedWindowDays int
+	receivedActivityID *int64
+}
+
+func (stub *powerCurveReaderStub) FindPowerCurve(_ *int, windowDays int, activityID *int64, _ ...business.ActivityType) business.PowerCurve {
+	stub.receivedWindowDays = windowDays
+	stub.receivedActivityID = activityID
+	return stub.curve
+}
+
+func TestGetPowerCurveUseCase_Execute_ReturnsEmptyPointsWhenNil(t *testing.T) {
+	// GIVEN
+	reader := &powerCurveReaderStub{}
+	useCase := NewGetPowerCurveUseCase(reader)
+
+	// WHEN
+	result := useCase.Execute(nil, 90, nil, []business.ActivityType{business.Ride})
+
+	// THEN
+	if result.Points == nil || len(result.Points) != 0 {
+		t.Fatalf("expected empty non-nil points, got %#v", result.Points)
+	}
+	if reader.receivedWindowDays != 90 {
+		t.Fatalf("expected window of 90 days, got %d", reader.receivedWindowDays)
+	}
+}
+
+func TestGetPowerCurveUseCase_Execute_IgnoresWindowForOneActivity(t *testing.T) {
+	// GIVEN
+	reader := &powerCurveReaderStub{}
+	useCase := NewGetPowerCurveUseCase(reader)
+	activityID := int64(42)
+
+	// WHEN
+	useCase.Execute(nil, 90, &activityID, []business.ActivityType{business.Ride})
+
+	// THEN
+	if reader.receivedWindowDays != 0 || reader.receivedActivityID == nil || *reader.receivedActivityID != 42 {
+		t.Fatalf("expected activity 42 without window, got %d days and %v", reader.receivedWindowDays, reader.receivedActivityID)
+	}
+}
@@ -0,0 +1,50 @@
+package infrastructure
+
+import (
+	"log"
+	domainStatistics "mystravastats/domain/statistics"
+	"mystravastats/internal/platform/activityprovider"
+	"mystravastats/internal/shared/domain/business"
+	"mystravastats/internal/shared/domain/strava"
+	"mystravastats/internal/shared/infrastructure/localrepository"
+	"sort"
+	"strings"
+)
+
+func loadCustomStatisticDefinitions(provider activityprovider.ActivityProvider, activityTypes ...business.ActivityType) []business.CustomStatisticDefinition {
+	repository := localrepository.NewStravaRepository(provider.CacheRootPath())
+	return repository.LoadCustomStatistics(provider.ClientID(), customStatisticsKey(activityTypes...))
+}
+
+func saveCustomStatisticDefinitions(provider activityprovider.ActivityProvider, definitions []business.CustomStatisticDefinition, activityTypes ...business.ActivityType) []business.CustomStatisticDefinition {
+	repository := localrepository.NewStravaRepository(provider.CacheRootPath())
+	repository.SaveCustomStatistics(provider.ClientID(), customStatisticsKey(activityTypes...), definitions)
+	return definitions
+}
+
+// computeCustomStatistics evaluates the custom statistics defined for the
+// activity types. Definitions edited by hand into an invalid expression are
+// logged and skipped.
+func computeCustomStatistics(definitions []business.CustomStatisticDefinition, activities []*strava.Activity) []domainStatistics.Statistic {
+	statistics := make([]domainStatistics.Statistic, 0, len(definitions))
+	for _, definition := range definitions {
+		expression, err := domainStatistics.ParseCustomExpression(definition.Expression)
+		if err != nil {
+			log.Printf("Skip custom statistic %q: %v", definition.Label, err)
+			continue
+		}
+		statistics = append(statistics, domainStatistics.NewCustomStatistic(definition.Label, expression, activities))
+	}
+	return statistics
+}
+
+// customStatisticsKey stores custom statistics per activity type selection,
+// independently of the order of the types in the request.
+func customStatisticsKey(activityTypes ...business.ActivityType) string {
+	names := make([]string, 0, len(activityTypes))
+	for _, activityType := range activityTypes {
+		names = append(names, activityType.String())
+	}
+	sort.Strings(names)
+	return strings.Join(names, "_")
+}
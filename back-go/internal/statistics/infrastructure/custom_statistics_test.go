@@ -0,0 +1,49 @@
+package infrastructure
+
+import (
+	"mystravastats/internal/shared/domain/business"
+	"mystravastats/internal/shared/domain/strava"
+	"mystravastats/internal/shared/infrastructure/localrepository"
+	"testing"
+)
+
+func TestComputeCustomStatistics_SkipsInvalidExpressions(t *testing.T) {
+	// GIVEN
+	definitions := []business.CustomStatisticDefinition{
+		{Label: "Commutes", Expression: "count() where commute"},
+		{Label: "Broken", Expression: "count() where"},
+	}
+	activities := []*strava.Activity{
+		{Id: 1, Type: "Ride", Distance: 10000, Commute: true},
+		{Id: 2, Type: "Ride", Distance: 50000},
+	}
+
+	// WHEN
+	statistics := computeCustomStatistics(definitions, activities)
+
+	// THEN
+	if len(statistics) != 1 {
+		t.Fatalf("expected 1 statistic, got %d", len(statistics))
+	}
+	if statistics[0].Label() != "Commutes" || statistics[0].Value() != "1" {
+		t.Fatalf("expected Commutes = 1, got %s = %s", statistics[0].Label(), statistics[0].Value())
+	}
+}
+
+func TestCustomStatistics_AreStoredPerActivityTypeSelection(t *testing.T) {
+	// GIVEN
+	repository := localrepository.NewStravaRepository(t.TempDir())
+	definitions := []business.CustomStatisticDefinition{{Label: "Best 15 km", Expression: "best_distance(15 km)"}}
+
+	// WHEN
+	repository.SaveCustomStatistics("42", customStatisticsKey(business.Ride, business.GravelRide), definitions)
+
+	// THEN
+	saved := repository.LoadCustomStatistics("42", customStatisticsKey(business.GravelRide, business.Ride))
+	if len(saved) != 1 || saved[0] != definitions[0] {
+		t.Fatalf("expected saved definitions regardless of type order, got %#v", saved)
+	}
+	if other := repository.LoadCustomStatistics("42", customStatisticsKey(business.Run)); len(other) != 0 {
+		t.Fatalf("expected no Run definitions, got %#v", other)
+	}
+}
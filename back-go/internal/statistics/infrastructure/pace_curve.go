@@ -0,0 +1,138 @@
+package infrastructure
+
+import (
+	"log"
+	"math"
+	domainStatistics "mystravastats/domain/statistics"
+	dataqualityInfra "mystravastats/internal/dataquality/infrastructure"
+	"mystravastats/internal/helpers"
+	"mystravastats/internal/platform/activityprovider"
+	"mystravastats/internal/shared/domain/business"
+	"mystravastats/internal/shared/domain/strava"
+	"sort"
+)
+
+func computePaceCurve(provider activityprovider.ActivityProvider, year *int, windowDays int, gradeAdjusted bool, activityTypes ...business.ActivityType) business.PaceCurve {
+	log.Printf("Compute pace curve for %v (year %v, window %d days, grade-adjusted %t)", activityTypes, year, windowDays, gradeAdjusted)
+
+	activities := dataqualityInfra.FilterExcludedFromStats(provider, provider.GetActivitiesByYearAndActivityTypes(year, activityTypes...))
+	if windowDays > 0 {
+		activities = filterRecentActivities(activities, windowDays)
+	}
+	return buildPaceCurve(activities, windowDays, gradeAdjusted, isRunningOnly(activityTypes))
+}
+
+// buildPaceCurve always returns the curve; the race predictions and the VDOT
+// trend only make sense for runs.
+func buildPaceCurve(activities []*strava.Activity, windowDays int, gradeAdjusted bool, predict bool) business.PaceCurve {
+	dates := activityDays(activities)
+	efforts := domainStatistics.BestPaceCurve(activities, gradeAdjusted)
+
+	points := make([]business.PaceCurvePoint, 0, len(efforts))
+	best := make(map[float64]business.PaceCurvePoint, len(efforts))
+	for _, effort := range efforts {
+		if effort == nil {
+			continue
+		}
+		point := toPaceCurvePoint(effort, dates)
+		points = append(points, point)
+		best[effort.Distance] = point
+	}
+
+	curve := business.PaceCurve{
+		Points:        points,
+		Predictions:   []business.RacePrediction{},
+		VdotTrend:     []business.VdotTrendPoint{},
+		ActivityCount: len(activities),
+		WindowDays:    windowDays,
+		GradeAdjusted: gradeAdjusted,
+	}
+	if !predict {
+		return curve
+	}
+
+	reference, vdot := domainStatistics.BestVdotEffort(efforts)
+	if reference != nil {
+		referencePoint := toPaceCurvePoint(reference, dates)
+		curve.Reference = &referencePoint
+		curve.Vdot = &vdot
+		curve.Predictions = predictRaces(reference, vdot, best)
+	}
+	curve.VdotTrend = buildVdotTrend(activities, dates, gradeAdjusted)
+	return curve
+}
+
+func predictRaces(reference *business.ActivityEffort, vdot float64, best map[float64]business.PaceCurvePoint) []business.RacePrediction {
+	seconds := float64(reference.Seconds)
+	predictions := make([]business.RacePrediction, 0, len(domainStatistics.RacePredictionTargets))
+	for _, target := range domainStatistics.RacePredictionTargets {
+		prediction := business.RacePrediction{
+			Label:          target.Label,
+			Distance:       target.Distance,
+			RiegelSeconds:  int(math.Round(domainStatistics.RiegelRaceTime(reference.Distance, seconds, target.Distance))),
+			CameronSeconds: int(math.Round(domainStatistics.CameronRaceTime(reference.Distance, seconds, target.Distance))),
+			VdotSeconds:    int(math.Round(domainStatistics.VdotRaceTime(vdot, target.Distance))),
+		}
+		if point, ok := best[target.Distance]; ok {
+			prediction.Best = &point
+		}
+		predictions = append(predictions, prediction)
+	}
+	return predictions
+}
+
+// buildVdotTrend returns the best VDOT of each month with a reference effort,
+// oldest first.
+func buildVdotTrend(activities []*strava.Activity, dates map[int64]string, gradeAdjusted bool) []business.VdotTrendPoint {
+	byMonth := make(map[string][]*strava.Activity)
+	for _, activity := range activities {
+		if day := dates[activity.Id]; len(day) >= len("2006-01") {
+			byMonth[day[:7]] = append(byMonth[day[:7]], activity)
+		}
+	}
+	months := make([]string, 0, len(byMonth))
+	for month := range byMonth {
+		months = append(months, month)
+	}
+	sort.Strings(months)
+
+	trend := make([]business.VdotTrendPoint, 0, len(months))
+	for _, month := range months {
+		effort, vdot := domainStatistics.BestVdotEffort(domainStatistics.BestPaceCurve(byMonth[month], gradeAdjusted))
+		if effort == nil {
+			continue
+		}
+		trend = append(trend, business.VdotTrendPoint{
+			Period: month,
+			Vdot:   vdot,
+			Effort: toPaceCurvePoint(effort, dates),
+		})
+	}
+	return trend
+}
+
+func toPaceCurvePoint(effort *business.ActivityEffort, dates map[int64]string) business.PaceCurvePoint {
+	return business.PaceCurvePoint{
+		Distance:     effort.Distance,
+		Seconds:      effort.Seconds,
+		ActivityDate: dates[effort.ActivityShort.Id],
+		Activity:     effort.ActivityShort,
+	}
+}
+
+func activityDays(activities []*strava.Activity) map[int64]string {
+	dates := make(map[int64]string, len(activities))
+	for _, activity := range activities {
+		dates[activity.Id] = helpers.ExtractSortableDay(helpers.FirstNonEmpty(activity.StartDateLocal, activity.StartDate))
+	}
+	return dates
+}
+
+func isRunningOnly(activityTypes []business.ActivityType) bool {
+	for _, activityType := range activityTypes {
+		if activityType != business.Run && activityType != business.TrailRun {
+			return false
+		}
+	}
+	return len(activityTypes) > 0
+}
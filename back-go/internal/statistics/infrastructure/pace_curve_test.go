@@ -0,0 +1,76 @@
+package infrastructure
+
+import (
+	domainStatistics "mystravastats/domain/statistics"
+	"mystravastats/internal/shared/domain/business"
+	"mystravastats/internal/shared/domain/strava"
+	"testing"
+)
+
+// steadyRun returns a run at a constant pace, in seconds per kilometer.
+func steadyRun(id int64, date string, meters int, secondsPerKm int) *strava.Activity {
+	duration := meters * secondsPerKm / 1000
+	times := make([]int, 0, duration+1)
+	distances := make([]float64, 0, duration+1)
+	altitudes := make([]float64, 0, duration+1)
+	for second := 0; second <= duration; second++ {
+		times = append(times, second)
+		distances = append(distances, float64(second)*1000/float64(secondsPerKm))
+		altitudes = append(altitudes, 100)
+	}
+	return &strava.Activity{
+		Id:             id,
+		Name:           "Steady run",
+		Type:           "Run",
+		StartDateLocal: date,
+		Stream: &strava.Stream{
+			Distance: strava.DistanceStream{Data: distances},
+			Time:     strava.TimeStream{Data: times},
+			Altitude: &strava.AltitudeStream{Data: altitudes},
+		},
+	}
+}
+
+func TestBuildPaceCurve_PredictsRacesFromBestVdotEffort(t *testing.T) {
+	// GIVEN
+	domainStatistics.ClearBestEffortCache()
+	activities := []*strava.Activity{
+		steadyRun(1, "2025-03-02T08:00:00Z", 6000, 300),
+		steadyRun(2, "2025-04-06T08:00:00Z", 11000, 270),
+	}
+
+	// WHEN
+	curve := buildPaceCurve(activities, 0, false, true)
+
+	// THEN
+	if curve.Reference == nil || curve.Reference.Activity.Id != 2 || curve.Vdot == nil {
+		t.Fatalf("expected the reference effort to come from activity 2, got %+v", curve.Reference)
+	}
+	if len(curve.Predictions) != len(domainStatistics.RacePredictionTargets) {
+		t.Fatalf("expected %d predictions, got %d", len(domainStatistics.RacePredictionTargets), len(curve.Predictions))
+	}
+	tenKilometers := curve.Predictions[1]
+	if tenKilometers.Best == nil || tenKilometers.Best.Seconds != 2700 || tenKilometers.Best.ActivityDate != "2025-04-06" {
+		t.Fatalf("expected the actual best 10 km of 2700 s on 2025-04-06, got %+v", tenKilometers.Best)
+	}
+	if curve.Predictions[3].Best != nil || curve.Predictions[3].VdotSeconds <= tenKilometers.VdotSeconds*4 {
+		t.Fatalf("expected a marathon prediction without actual effort, got %+v", curve.Predictions[3])
+	}
+	if len(curve.VdotTrend) != 2 || curve.VdotTrend[0].Period != "2025-03" || curve.VdotTrend[0].Vdot >= curve.VdotTrend[1].Vdot {
+		t.Fatalf("expected an increasing VDOT over March and April, got %+v", curve.VdotTrend)
+	}
+}
+
+func TestBuildPaceCurve_SkipsPredictionsForRides(t *testing.T) {
+	// GIVEN
+	domainStatistics.ClearBestEffortCache()
+	activities := []*strava.Activity{steadyRun(1, "2025-03-02T08:00:00Z", 6000, 300)}
+
+	// WHEN
+	curve := buildPaceCurve(activities, 0, false, isRunningOnly([]business.ActivityType{business.Ride}))
+
+	// THEN
+	if len(curve.Points) == 0 || curve.Reference != nil || len(curve.Predictions) != 0 || len(curve.VdotTrend) != 0 {
+		t.Fatalf("expected only the curve, got %+v", curve)
+	}
+}
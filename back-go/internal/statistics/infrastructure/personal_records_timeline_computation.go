@@ -24,12 +24,12 @@ type personalRecordMetricDefinition struct {
 	improvementFormatter func(*business.ActivityEffort, *business.ActivityEffort) string
 }
 
-func computePersonalRecordsTimelineByYearMetricAndTypes(year *int, metric *string, activityTypes ...business.ActivityType) []business.PersonalRecordTimelineEntry {
+func computePersonalRecordsTimelineByYearMetricAndTypes(provider activityprovider.ActivityProvider, year *int, metric *string, activityTypes ...business.ActivityType) []business.PersonalRecordTimelineEntry {
 	if len(activityTypes) == 0 {
 		return []business.PersonalRecordTimelineEntry{}
 	}
 
-	filteredActivities := dataqualityInfra.FilterExcludedFromStats(activityprovider.Get().GetActivitiesByYearAndActivityTypes(year, activityTypes...))
+	filteredActivities := dataqualityInfra.FilterExcludedFromStats(provider, provider.GetActivitiesByYearAndActivityTypes(year, activityTypes...))
 	return buildPersonalRecordsTimeline(filteredActivities, metric, activityTypes)
 }
 
@@ -0,0 +1,79 @@
+package infrastructure
+
+import (
+	"log"
+	domainStatistics "mystravastats/domain/statistics"
+	dataqualityInfra "mystravastats/internal/dataquality/infrastructure"
+	"mystravastats/internal/helpers"
+	"mystravastats/internal/platform/activityprovider"
+	"mystravastats/internal/shared/domain/business"
+	"mystravastats/internal/shared/domain/strava"
+	"time"
+)
+
+func computePowerCurve(provider activityprovider.ActivityProvider, year *int, windowDays int, activityID *int64, activityTypes ...business.ActivityType) business.PowerCurve {
+	log.Printf("Compute power curve for %v (year %v, window %d days, activity %v)", activityTypes, year, windowDays, activityID)
+
+	activities := dataqualityInfra.FilterExcludedFromStats(provider, provider.GetActivitiesByYearAndActivityTypes(year, activityTypes...))
+	if activityID != nil {
+		activities = filterPowerCurveActivity(activities, *activityID)
+	} else if windowDays > 0 {
+		activities = filterRecentActivities(activities, windowDays)
+	}
+	return buildPowerCurve(activities, windowDays, activityID)
+}
+
+func buildPowerCurve(activities []*strava.Activity, windowDays int, activityID *int64) business.PowerCurve {
+	dates := activityDays(activities)
+
+	points := make([]business.PowerCurvePoint, 0, len(domainStatistics.PowerCurveDurations))
+	for _, effort := range domainStatistics.BestMeanMaxPowerCurve(activities) {
+		if effort == nil || effort.AveragePower == nil {
+			continue
+		}
+		points = append(points, business.PowerCurvePoint{
+			Seconds:      effort.Seconds,
+			Watts:        *effort.AveragePower,
+			ActivityDate: dates[effort.ActivityShort.Id],
+			Activity:     effort.ActivityShort,
+		})
+	}
+
+	return business.PowerCurve{
+		Points:         points,
+		TwoParameter:   domainStatistics.FitCriticalPower(points),
+		ThreeParameter: domainStatistics.FitMortonCriticalPower(points),
+		ActivityCount:  len(activities),
+		WindowDays:     windowDays,
+		ActivityID:     activityID,
+	}
+}
+
+func filterPowerCurveActivity(activities []*strava.Activity, activityID int64) []*strava.Activity {
+	for _, activity := range activities {
+		if activity.Id == activityID {
+			return []*strava.Activity{activity}
+		}
+	}
+	return []*strava.Activity{}
+}
+
+// filterRecentActivities keeps the activities of the last windowDays days
+// before the most recent one, so that an old cache still gets a curve.
+func filterRecentActivities(activities []*strava.Activity, windowDays int) []*strava.Activity {
+	var latest time.Time
+	for _, activity := range activities {
+		if date, ok := helpers.ParseActivityDate(activity.StartDateLocal); ok && date.After(latest) {
+			latest = date
+		}
+	}
+	cutoff := latest.AddDate(0, 0, -windowDays)
+
+	filtered := make([]*strava.Activity, 0, len(activities))
+	for _, activity := range activities {
+		if date, ok := helpers.ParseActivityDate(activity.StartDateLocal); ok && !date.Before(cutoff) {
+			filtered = append(filtered, activity)
+		}
+	}
+	return filtered
+}
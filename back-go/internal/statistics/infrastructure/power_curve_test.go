@@ -0,0 +1,61 @@
+package infrastructure
+
+import (
+	"mystravastats/internal/shared/domain/strava"
+	"testing"
+)
+
+func TestFilterRecentActivities_KeepsDaysBeforeLatestActivity(t *testing.T) {
+	// GIVEN
+	activities := []*strava.Activity{
+		{Id: 1, StartDateLocal: "2025-01-10T08:00:00Z"},
+		{Id: 2, StartDateLocal: "2025-03-20T08:00:00Z"},
+		{Id: 3, StartDateLocal: "2025-04-10T08:00:00Z"},
+	}
+
+	// WHEN
+	filtered := filterRecentActivities(activities, 30)
+
+	// THEN
+	if len(filtered) != 2 || filtered[0].Id != 2 || filtered[1].Id != 3 {
+		t.Fatalf("expected activities 2 and 3, got %d activities", len(filtered))
+	}
+}
+
+func TestBuildPowerCurve_LinksPointsToActivityAndDate(t *testing.T) {
+	// GIVEN
+	times := make([]int, 0, 1800)
+	watts := make([]float64, 0, 1800)
+	distances := make([]float64, 0, 1800)
+	for second := 0; second < 1800; second++ {
+		times = append(times, second)
+		watts = append(watts, 250+20000/float64(second+60))
+		distances = append(distances, float64(second)*10)
+	}
+	activity := &strava.Activity{
+		Id:             7,
+		Name:           "Threshold test",
+		Type:           "Ride",
+		StartDateLocal: "2025-05-01T08:00:00Z",
+		Stream: &strava.Stream{
+			Distance: strava.DistanceStream{Data: distances},
+			Time:     strava.TimeStream{Data: times},
+			Watts:    &strava.PowerStream{Data: watts},
+		},
+	}
+
+	// WHEN
+	curve := buildPowerCurve([]*strava.Activity{activity}, 0, nil)
+
+	// THEN
+	if len(curve.Points) == 0 || curve.ActivityCount != 1 {
+		t.Fatalf("expected curve points for 1 activity, got %d points for %d", len(curve.Points), curve.ActivityCount)
+	}
+	first := curve.Points[0]
+	if first.Seconds != 1 || first.Activity.Id != 7 || first.ActivityDate != "2025-05-01" {
+		t.Fatalf("expected 1 s point linked to activity 7 on 2025-05-01, got %+v", first)
+	}
+	if curve.TwoParameter == nil || curve.ThreeParameter == nil {
+		t.Fatalf("expected both critical power models, got %+v and %+v", curve.TwoParameter, curve.ThreeParameter)
+	}
+}
@@ -11,7 +11,7 @@ import (
 	"mystravastats/internal/shared/domain/strava"
 )
 
-func computeStatisticsByYearAndTypes(year *int, activityTypes ...business.ActivityType) []domainStatistics.Statistic {
+func computeStatisticsByYearAndTypes(provider activityprovider.ActivityProvider, year *int, activityTypes ...business.ActivityType) []domainStatistics.Statistic {
 	if len(activityTypes) == 0 {
 		log.Printf("No activity types provided")
 		return []domainStatistics.Statistic{}
@@ -23,7 +23,7 @@ func computeStatisticsByYearAndTypes(year *int, activityTypes ...business.Activi
 		log.Printf("Compute statistics for %v for %v", activityTypes, *year)
 	}
 
-	filteredActivities := dataqualityInfra.FilterExcludedFromStats(activityprovider.Get().GetActivitiesByYearAndActivityTypes(year, activityTypes...))
+	filteredActivities := dataqualityInfra.FilterExcludedFromStats(provider, provider.GetActivitiesByYearAndActivityTypes(year, activityTypes...))
 	if len(filteredActivities) == 0 {
 		if year == nil {
 			log.Printf("No activities found for %v in all years", activityTypes)
@@ -33,7 +33,12 @@ func computeStatisticsByYearAndTypes(year *int, activityTypes ...business.Activi
 		return []domainStatistics.Statistic{}
 	}
 
-	activityType := activityTypes[0]
+	definitions := loadCustomStatisticDefinitions(provider, activityTypes...)
+	statistics := computeBuiltInStatistics(filteredActivities, activityTypes[0])
+	return append(statistics, computeCustomStatistics(definitions, filteredActivities)...)
+}
+
+func computeBuiltInStatistics(filteredActivities []*strava.Activity, activityType business.ActivityType) []domainStatistics.Statistic {
 	switch activityType {
 	case business.Ride, business.GravelRide, business.MountainBikeRide:
 		return computeRideStatistics(filteredActivities)
@@ -2,21 +2,40 @@ package infrastructure
 
 import (
 	domainStatistics "mystravastats/domain/statistics"
+	"mystravastats/internal/platform/activityprovider"
 	"mystravastats/internal/shared/domain/business"
 )
 
 // StatisticsServiceAdapter computes statistics directly and still delegates
 // personal-record timeline to legacy services during migration.
-type StatisticsServiceAdapter struct{}
+type StatisticsServiceAdapter struct {
+	providers activityprovider.Lookup
+}
 
-func NewStatisticsServiceAdapter() *StatisticsServiceAdapter {
-	return &StatisticsServiceAdapter{}
+func NewStatisticsServiceAdapter(providers activityprovider.Lookup) *StatisticsServiceAdapter {
+	return &StatisticsServiceAdapter{providers: providers}
 }
 
 func (adapter *StatisticsServiceAdapter) FindStatisticsByYearAndTypes(year *int, activityTypes ...business.ActivityType) []domainStatistics.Statistic {
-	return computeStatisticsByYearAndTypes(year, activityTypes...)
+	return computeStatisticsByYearAndTypes(adapter.providers(), year, activityTypes...)
 }
 
 func (adapter *StatisticsServiceAdapter) FindPersonalRecordsTimelineByYearMetricAndTypes(year *int, metric *string, activityTypes ...business.ActivityType) []business.PersonalRecordTimelineEntry {
-	return computePersonalRecordsTimelineByYearMetricAndTypes(year, metric, activityTypes...)
+	return computePersonalRecordsTimelineByYearMetricAndTypes(adapter.providers(), year, metric, activityTypes...)
+}
+
+func (adapter *StatisticsServiceAdapter) FindCustomStatisticDefinitions(activityTypes ...business.ActivityType) []business.CustomStatisticDefinition {
+	return loadCustomStatisticDefinitions(adapter.providers(), activityTypes...)
+}
+
+func (adapter *StatisticsServiceAdapter) SaveCustomStatisticDefinitions(definitions []business.CustomStatisticDefinition, activityTypes ...business.ActivityType) []business.CustomStatisticDefinition {
+	return saveCustomStatisticDefinitions(adapter.providers(), definitions, activityTypes...)
+}
+
+func (adapter *StatisticsServiceAdapter) FindPowerCurve(year *int, windowDays int, activityID *int64, activityTypes ...business.ActivityType) business.PowerCurve {
+	return computePowerCurve(adapter.providers(), year, windowDays, activityID, activityTypes...)
+}
+
+func (adapter *StatisticsServiceAdapter) FindPaceCurve(year *int, windowDays int, gradeAdjusted bool, activityTypes ...business.ActivityType) business.PaceCurve {
+	return computePaceCurve(adapter.providers(), year, windowDays, gradeAdjusted, activityTypes...)
 }
@@ -0,0 +1,7 @@
+package application
+
+import "mystravastats/internal/shared/domain/business"
+
+type StravaUploadWriter interface {
+	UploadActivities(request business.StravaUploadRequest) business.StravaUploadResult
+}
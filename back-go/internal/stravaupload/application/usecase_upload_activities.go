@@ -0,0 +1,15 @@
+package application
+
+import "mystravastats/internal/shared/domain/business"
+
+type UploadActivitiesUseCase struct {
+	writer StravaUploadWriter
+}
+
+func NewUploadActivitiesUseCase(writer StravaUploadWriter) *UploadActivitiesUseCase {
+	return &UploadActivitiesUseCase{writer: writer}
+}
+
+func (uc *UploadActivitiesUseCase) Execute(request business.StravaUploadRequest) business.StravaUploadResult {
+	return uc.writer.UploadActivities(request)
+}
@@ -0,0 +1,143 @@
+package infrastructure
+
+import (
+	"fmt"
+
+	"mystravastats/internal/platform/activityprovider"
+	"mystravastats/internal/shared/domain/business"
+	compositeprovider "mystravastats/internal/shared/infrastructure/composite"
+	"mystravastats/internal/shared/infrastructure/stravaapi"
+)
+
+// localUploadSources lists the composite sources whose files can be sent to
+// Strava, in the order they are tried when an activity has several copies.
+var localUploadSources = []string{"fit", "gpx", "tcx"}
+
+type activitySourceResolver interface {
+	ActivitySources(activityID int64) ([]compositeprovider.ActivitySourceRef, bool)
+	SourceProvider(name string) compositeprovider.SourceProvider
+}
+
+type localSourceFileProvider interface {
+	SourceFile(activityID int64) (string, bool)
+}
+
+type stravaFileUploader interface {
+	UploadLocalFile(filePath string) (stravaapi.UploadResult, error)
+}
+
+type StravaUploadServiceAdapter struct {
+	resolver func() (activitySourceResolver, bool)
+	uploader func() (stravaFileUploader, bool)
+}
+
+func NewStravaUploadServiceAdapter(providers activityprovider.Lookup) *StravaUploadServiceAdapter {
+	return &StravaUploadServiceAdapter{
+		resolver: func() (activitySourceResolver, bool) {
+			resolver, ok := activityprovider.Unfiltered(providers()).(activitySourceResolver)
+			return resolver, ok
+		},
+		uploader: func() (stravaFileUploader, bool) {
+			stravaProvider, ok := activityprovider.StravaOf(providers())
+			if !ok {
+				return nil, false
+			}
+			return stravaProvider, true
+		},
+	}
+}
+
+// UploadActivities pushes the local file behind each activity to Strava.
+// Activities that already have a Strava copy in the composite view are
+// reported as duplicates without any API call.
+func (adapter *StravaUploadServiceAdapter) UploadActivities(request business.StravaUploadRequest) business.StravaUploadResult {
+	result := business.StravaUploadResult{Items: make([]business.StravaUploadItem, 0, len(request.ActivityIDs))}
+	resolver, resolverOK := adapter.resolver()
+	uploader, uploaderOK := adapter.uploader()
+
+	for _, activityID := range request.ActivityIDs {
+		var item business.StravaUploadItem
+		if resolverOK && uploaderOK {
+			item = uploadActivity(resolver, uploader, activityID)
+		} else {
+			item = business.StravaUploadItem{
+				ActivityID: activityID,
+				Status:     business.StravaUploadStatusFailed,
+				Message:    "uploads need the Strava source combined with a local FIT, GPX or TCX source",
+			}
+		}
+
+		switch item.Status {
+		case business.StravaUploadStatusUploaded:
+			result.Uploaded++
+		case business.StravaUploadStatusDuplicate:
+			result.Duplicates++
+		default:
+			result.Failed++
+		}
+		result.Items = append(result.Items, item)
+	}
+	return result
+}
+
+func uploadActivity(resolver activitySourceResolver, uploader stravaFileUploader, activityID int64) business.StravaUploadItem {
+	item := business.StravaUploadItem{ActivityID: activityID}
+	refs, ok := resolver.ActivitySources(activityID)
+	if !ok {
+		item.Status = business.StravaUploadStatusNotFound
+		item.Message = fmt.Sprintf("activity %d not found", activityID)
+		return item
+	}
+	for _, ref := range refs {
+		if ref.Provider == "strava" {
+			item.Source = ref.Provider
+			item.Status = business.StravaUploadStatusDuplicate
+			item.StravaActivityID = ref.ActivityID
+			item.Message = "activity is already on Strava"
+			return item
+		}
+	}
+
+	source, filePath, ok := localSourceFile(resolver, refs)
+	if !ok {
+		item.Status = business.StravaUploadStatusFailed
+		item.Message = "no local FIT, GPX or TCX file found for this activity"
+		return item
+	}
+	item.Source = source
+	item.FilePath = filePath
+
+	upload, err := uploader.UploadLocalFile(filePath)
+	if err != nil {
+		item.Status = business.StravaUploadStatusFailed
+		item.Message = err.Error()
+		return item
+	}
+	item.UploadID = upload.UploadID
+	item.StravaActivityID = upload.StravaActivityID
+	if upload.Duplicate {
+		item.Status = business.StravaUploadStatusDuplicate
+		item.Message = "Strava already holds this file"
+	} else {
+		item.Status = business.StravaUploadStatusUploaded
+	}
+	return item
+}
+
+func localSourceFile(resolver activitySourceResolver, refs []compositeprovider.ActivitySourceRef) (string, string, bool) {
+	for _, name := range localUploadSources {
+		for _, ref := range refs {
+			if ref.Provider != name {
+				continue
+			}
+			local, ok := resolver.SourceProvider(name).(localSourceFileProvider)
+			if !ok {
+				continue
+			}
+			if filePath, found := local.SourceFile(ref.ActivityID); found {
+				return name, filePath, true
+			}
+		}
+	}
+	return "", "", false
+}
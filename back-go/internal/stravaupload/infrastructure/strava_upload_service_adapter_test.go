@@ -0,0 +1,132 @@
+package infrastructure
+
+import (
+	"testing"
+	"time"
+
+	"mystravastats/internal/platform/activityprovider"
+	"mystravastats/internal/shared/domain/business"
+	compositeprovider "mystravastats/internal/shared/infrastructure/composite"
+	"mystravastats/internal/shared/infrastructure/stravaapi"
+)
+
+func TestUploadActivities_SkipsActivitiesAlreadyMergedWithStrava(t *testing.T) {
+	// GIVEN
+	resolver := &fakeSourceResolver{
+		sources: map[int64][]compositeprovider.ActivitySourceRef{
+			1: {{Provider: "strava", ActivityID: 500}, {Provider: "fit", ActivityID: 1}},
+			2: {{Provider: "fit", ActivityID: 2}},
+		},
+		local: &fakeLocalSource{files: map[int64]string{1: "/fit/2026/merged.fit", 2: "/fit/2026/local.fit"}},
+	}
+	uploader := &fakeUploader{results: map[string]stravaapi.UploadResult{
+		"/fit/2026/local.fit": {UploadID: 9, StravaActivityID: 42},
+	}}
+	adapter := newTestAdapter(resolver, uploader)
+
+	// WHEN
+	result := adapter.UploadActivities(business.StravaUploadRequest{ActivityIDs: []int64{1, 2, 3}})
+
+	// THEN
+	if result.Uploaded != 1 || result.Duplicates != 1 || result.Failed != 1 {
+		t.Fatalf("expected 1 uploaded, 1 duplicate and 1 failed, got %#v", result)
+	}
+	if item := result.Items[0]; item.Status != business.StravaUploadStatusDuplicate || item.StravaActivityID != 500 {
+		t.Fatalf("expected merged activity to be a duplicate of 500, got %#v", item)
+	}
+	if item := result.Items[1]; item.Status != business.StravaUploadStatusUploaded || item.StravaActivityID != 42 || item.Source != "fit" || item.FilePath != "/fit/2026/local.fit" {
+		t.Fatalf("expected local activity to be uploaded as 42, got %#v", item)
+	}
+	if item := result.Items[2]; item.Status != business.StravaUploadStatusNotFound {
+		t.Fatalf("expected unknown activity to be not_found, got %#v", item)
+	}
+	if len(uploader.uploaded) != 1 || uploader.uploaded[0] != "/fit/2026/local.fit" {
+		t.Fatalf("expected only the local-only file to be uploaded, got %#v", uploader.uploaded)
+	}
+}
+
+func TestUploadActivities_FailsWithoutStravaSource(t *testing.T) {
+	// GIVEN
+	adapter := &StravaUploadServiceAdapter{
+		resolver: func() (activitySourceResolver, bool) { return nil, false },
+		uploader: func() (stravaFileUploader, bool) { return nil, false },
+	}
+
+	// WHEN
+	result := adapter.UploadActivities(business.StravaUploadRequest{ActivityIDs: []int64{7}})
+
+	// THEN
+	if result.Failed != 1 || result.Items[0].Status != business.StravaUploadStatusFailed || result.Items[0].Message == "" {
+		t.Fatalf("expected explanatory failure, got %#v", result)
+	}
+}
+
+func TestNewStravaUploadServiceAdapter_ResolvesSourcesBehindRequestFilters(t *testing.T) {
+	// GIVEN
+	provider := &fakeCompositeProvider{fakeSourceResolver: &fakeSourceResolver{
+		sources: map[int64][]compositeprovider.ActivitySourceRef{2: {{Provider: "fit", ActivityID: 2}}},
+	}}
+	filter, _ := activityprovider.ParseSourceFilter("stream")
+	dateRange, _ := activityprovider.ParseDateRange("2026-01-01", "2026-03-31", "", time.Now())
+	lookup := activityprovider.WithinDateRange(activityprovider.Filtered(func() activityprovider.ActivityProvider { return provider }, filter), dateRange)
+
+	// WHEN
+	resolver, ok := NewStravaUploadServiceAdapter(lookup).resolver()
+
+	// THEN
+	if !ok {
+		t.Fatal("expected the composite sources to be resolved through the source filter and the date range")
+	}
+	if refs, found := resolver.ActivitySources(2); !found || len(refs) != 1 || refs[0].Provider != "fit" {
+		t.Fatalf("expected the fit source of activity 2, got %#v", refs)
+	}
+}
+
+func newTestAdapter(resolver *fakeSourceResolver, uploader *fakeUploader) *StravaUploadServiceAdapter {
+	return &StravaUploadServiceAdapter{
+		resolver: func() (activitySourceResolver, bool) { return resolver, true },
+		uploader: func() (stravaFileUploader, bool) { return uploader, true },
+	}
+}
+
+type fakeSourceResolver struct {
+	sources map[int64][]compositeprovider.ActivitySourceRef
+	local   *fakeLocalSource
+}
+
+func (resolver *fakeSourceResolver) ActivitySources(activityID int64) ([]compositeprovider.ActivitySourceRef, bool) {
+	refs, ok := resolver.sources[activityID]
+	return refs, ok
+}
+
+func (resolver *fakeSourceResolver) SourceProvider(name string) compositeprovider.SourceProvider {
+	if name == "fit" {
+		return resolver.local
+	}
+	return nil
+}
+
+type fakeCompositeProvider struct {
+	activityprovider.ActivityProvider
+	*fakeSourceResolver
+}
+
+type fakeLocalSource struct {
+	compositeprovider.SourceProvider
+	files map[int64]string
+}
+
+func (source *fakeLocalSource) SourceFile(activityID int64) (string, bool) {
+	path, ok := source.files[activityID]
+	return path, ok
+}
+
+type fakeUploader struct {
+	results  map[string]stravaapi.UploadResult
+	uploaded []string
+}
+
+func (uploader *fakeUploader) UploadLocalFile(filePath string) (stravaapi.UploadResult, error) {
+	uploader.uploaded = append(uploader.uploaded, filePath)
+	return uploader.results[filePath], nil
+}
@@ -15,12 +15,15 @@ package main
 import (
 	"context"
 	"embed"
+	"encoding/json"
 	"errors"
 	"flag"
 	"io/fs"
 	"log"
 	"mystravastats/api"
+	"mystravastats/internal/cachebackup"
 	"mystravastats/internal/platform/activityprovider"
+	"mystravastats/internal/platform/cachemigration"
 	"mystravastats/internal/platform/runtimeconfig"
 	"mystravastats/internal/sourcesync"
 	"net"
@@ -43,8 +46,21 @@ func main() {
 	debug := flag.Bool("debug", false, "run in debug mode")
 	host := flag.String("host", "localhost", "server host")
 	port := flag.String("port", "8080", "server port")
+	migrationsDryRun := flag.Bool("cache-migrations-dry-run", false, "print the pending cache migrations and exit")
+	backupPath := flag.String("cache-backup", "", "write a tar.gz snapshot of the athlete cache to this file and exit")
+	restorePath := flag.String("cache-restore", "", "restore the athlete cache from this tar.gz snapshot and exit")
+	athleteID := flag.String("athlete", "", "athlete of -cache-backup and -cache-restore (default athlete when empty)")
 	flag.Parse()
 
+	if *migrationsDryRun {
+		printCacheMigrationsDryRun()
+		return
+	}
+	if *backupPath != "" || *restorePath != "" {
+		runCacheBackupCommand(*backupPath, *restorePath, *athleteID)
+		return
+	}
+
 	// Get host and port from environment variables when provided.
 	if envHost := runtimeconfig.FirstStringValue("", "SERVER_HOST", "HOST"); envHost != "" {
 		*host = envHost
@@ -58,6 +74,10 @@ func main() {
 		log.Fatalf("invalid port %q: must be a number between 1 and 65535", *port)
 	}
 
+	// Upgrade persisted cache files before any provider reads them
+	// (CACHE_MIGRATION_MODE).
+	cachemigration.Startup(activityprovider.CacheRoots())
+
 	// Eager initialization keeps cache loading and background refresh
 	// behavior unchanged from a user perspective at startup.
 	activityprovider.Init(*port)
@@ -69,6 +89,15 @@ func main() {
 	defer cancel()
 	api.StartCacheEviction(ctx)
 
+	// Opt-in polling of local FIT/GPX/TCX/JSON folders (LOCAL_SOURCE_WATCH_ENABLED).
+	sourcesync.StartWatcher(ctx)
+
+	// Opt-in scheduled FIT import and Strava refresh (SOURCE_SYNC_SCHEDULE).
+	sourcesync.StartScheduler(ctx)
+
+	// Opt-in reconciliation of the cached past Strava years (STRAVA_RECONCILE_SCHEDULE).
+	sourcesync.StartReconcileScheduler(ctx)
+
 	// Create a new router
 	router := api.NewRouter()
 
@@ -165,3 +194,63 @@ func displayAddress(host, port string) string {
 	}
 	return net.JoinHostPort(displayHost, port)
 }
+
+// printCacheMigrationsDryRun writes the migrations pending in every cache root
+// as JSON, without changing any file.
+func printCacheMigrationsDryRun() {
+	reports := make([]cachemigration.Report, 0)
+	for _, root := range activityprovider.CacheRoots() {
+		reports = append(reports, cachemigration.Run(root, cachemigration.Schemas, true))
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(reports); err != nil {
+		log.Fatalf("unable to write cache migration report: %v", err)
+	}
+}
+
+// runCacheBackupCommand writes or restores a cache snapshot without starting
+// the server. Stop a running server before restoring, since it would keep
+// serving the previous cache.
+func runCacheBackupCommand(backupPath string, restorePath string, athleteID string) {
+	if backupPath != "" && restorePath != "" {
+		log.Fatal("-cache-backup and -cache-restore cannot be combined")
+	}
+	if athleteID == "" {
+		athleteID = activityprovider.DefaultAthlete()
+	}
+	roots, ok := activityprovider.SourceRoots(athleteID)
+	if !ok {
+		log.Fatalf("athlete %s is not configured", athleteID)
+	}
+
+	if backupPath != "" {
+		file, err := os.Create(backupPath)
+		if err != nil {
+			log.Fatalf("unable to create %s: %v", backupPath, err)
+		}
+		manifest, err := cachebackup.Create(file, athleteID, roots)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			_ = os.Remove(backupPath)
+			log.Fatalf("unable to write cache backup: %v", err)
+		}
+		log.Printf("Cache backup of %s written to %s (%d files)", athleteID, backupPath, len(manifest.Files))
+		return
+	}
+
+	file, err := os.Open(restorePath)
+	if err != nil {
+		log.Fatalf("unable to open %s: %v", restorePath, err)
+	}
+	defer file.Close()
+	result, err := cachebackup.Restore(file, roots)
+	if err != nil {
+		log.Fatalf("unable to restore cache backup: %v", err)
+	}
+	for source, previous := range result.Previous {
+		log.Printf("Previous %s cache kept in %s", source, previous)
+	}
+}
@@ -33,6 +33,20 @@ export interface Stream {
     latlng: number[][] | null;
     watts: number[] | null;
     velocitySmooth?: number[] | null;
+    temperature?: number[] | null;
+    coreTemperature?: number[] | null;
+    leftRightBalance?: number[] | null;
+    verticalOscillation?: number[] | null;
+    groundContactTime?: number[] | null;
+    strideLength?: number[] | null;
+    developerFields?: DeveloperFieldStream[] | null;
+}
+
+export interface DeveloperFieldStream {
+    name: string;
+    units?: string;
+    nativeField?: string;
+    data: number[];
 }
 
 export interface DetailedActivity {
@@ -67,6 +81,47 @@ export interface DetailedActivity {
     activityComparison?: ActivityComparison | null;
     calories?: number;
     sufferScore?: number | null;
+    laps?: Lap[];
+    sessions?: ActivitySession[] | null;
+}
+
+export interface Lap {
+    lapIndex: number;
+    name: string;
+    startIndex: number;
+    endIndex: number;
+    startDate?: string;
+    distance: number;
+    elapsedTime: number;
+    movingTime: number;
+    totalElevationGain: number;
+    averageSpeed: number;
+    maxSpeed: number;
+    averageHeartrate: number;
+    maxHeartrate: number;
+    averageWatts: number;
+    averageCadence: number;
+    trigger?: string;
+    intensity?: string;
+}
+
+export interface ActivitySession {
+    sessionIndex: number;
+    sportType: string;
+    startDate?: string;
+    startIndex: number;
+    endIndex: number;
+    distance: number;
+    elapsedTime: number;
+    movingTime: number;
+    totalElevationGain: number;
+    averageSpeed: number;
+    maxSpeed: number;
+    averageHeartrate: number;
+    maxHeartrate: number;
+    averageWatts: number;
+    firstLapIndex?: number;
+    lapCount?: number;
 }
 
 export interface ActivitySource {
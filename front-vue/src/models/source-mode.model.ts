@@ -1,4 +1,4 @@
-export type SourceMode = "STRAVA" | "FIT" | "GPX";
+export type SourceMode = "STRAVA" | "FIT" | "GPX" | "TCX" | "JSON";
 
 export interface SourceModePreviewRequest {
   mode: SourceMode;
@@ -82,6 +82,9 @@ export interface StravaOAuthStatus {
   requiredScopes: string[];
   missingScopes: string[];
   tokenError: string;
+  // Go backend only: files stored with STRAVA_SECRET_KEY encryption.
+  credentialsEncrypted?: boolean;
+  tokenEncrypted?: boolean;
 }
 
 export interface SourceModePreview {
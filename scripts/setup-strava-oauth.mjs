@@ -8,7 +8,8 @@ import { createInterface } from "node:readline/promises";
 import { stdin as input, stdout as output } from "node:process";
 import { randomBytes } from "node:crypto";
 
-const DEFAULT_SCOPE = "read_all,activity:read_all,profile:read_all";
+const READ_SCOPE = "read_all,activity:read_all,profile:read_all";
+const DEFAULT_SCOPE = process.env.STRAVA_UPLOAD_ENABLED === "true" ? `${READ_SCOPE},activity:write` : READ_SCOPE;
 const DEFAULT_STRAVA_API_BASE_URL = "https://www.strava.com/api/v3";
 const STRAVA_API_BASE_URL = normalizeBaseUrl(process.env.STRAVA_API_BASE_URL ?? DEFAULT_STRAVA_API_BASE_URL);
 const SETTINGS_URL = "https://www.strava.com/settings/api";
@@ -185,7 +186,7 @@ function buildAuthorizeUrl({ clientId, redirectUri, state }) {
 
 function missingRequiredScopes(scope) {
   const granted = new Set(String(scope || DEFAULT_SCOPE).split(",").map((value) => value.trim()).filter(Boolean));
-  return DEFAULT_SCOPE.split(",").filter((required) => !granted.has(required));
+  return READ_SCOPE.split(",").filter((required) => !granted.has(required));
 }
 
 function waitForOAuthCode({ port, state }) {